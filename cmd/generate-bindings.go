@@ -17,21 +17,28 @@ var (
 	bindingsContractID string
 	bindingsNetwork    string
 	bindingsPackage    string
+	bindingsLang       string
 )
 
 var generateBindingsCmd = &cobra.Command{
 	Use:   "generate-bindings <wasm-file>",
-	Short: "Generate TypeScript bindings for a Soroban smart contract",
-	Long: `Generate strongly-typed TypeScript client bindings from a Soroban smart contract.
+	Short: "Generate TypeScript or Go bindings for a Soroban smart contract",
+	Long: `Generate strongly-typed TypeScript or Go client bindings from a Soroban smart contract.
 
 This command extracts the contract specification from the WASM file and generates
 a TypeScript client that provides type-safe method calls with erst integration
 for simulation and debugging.
 
+With --lang go it generates a Go package instead: structs, enums and unions for
+the contract types with ScVal conversions, a client that builds InvokeHostFunction
+envelopes and simulates them through the RPC client, and typed decoding of
+contract events and error enums.
+
 Example:
   erst generate-bindings contract.wasm
   erst generate-bindings --output ./src/bindings --package my-contract contract.wasm
-  erst generate-bindings --contract-id CDLZFC... --network testnet contract.wasm`,
+  erst generate-bindings --contract-id CDLZFC... --network testnet contract.wasm
+  erst generate-bindings --lang go --output ./token --package token contract.wasm`,
 	Args: cobra.ExactArgs(1),
 	RunE: runGenerateBindings,
 }
@@ -63,6 +70,7 @@ func runGenerateBindings(cmd *cobra.Command, args []string) error {
 		PackageName: bindingsPackage,
 		ContractID:  bindingsContractID,
 		Network:     bindingsNetwork,
+		Language:    bindingsLang,
 	}
 
 	// Generate bindings
@@ -90,7 +98,11 @@ func runGenerateBindings(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Generated: %s\n", fullPath)
 	}
 
-	fmt.Printf("\n[OK] TypeScript bindings generated successfully\n")
+	language := "TypeScript"
+	if bindingsLang == "go" {
+		language = "Go"
+	}
+	fmt.Printf("\n[OK] %s bindings generated successfully\n", language)
 	fmt.Printf("Package: %s\n", bindingsPackage)
 	fmt.Printf("Output: %s\n", bindingsOutput)
 
@@ -102,6 +114,7 @@ func init() {
 	generateBindingsCmd.Flags().StringVarP(&bindingsPackage, "package", "p", "", "Package name (defaults to WASM filename)")
 	generateBindingsCmd.Flags().StringVar(&bindingsContractID, "contract-id", "", "Contract ID for network calls")
 	generateBindingsCmd.Flags().StringVarP(&bindingsNetwork, "network", "n", "testnet", "Stellar network (testnet, mainnet, futurenet)")
+	generateBindingsCmd.Flags().StringVarP(&bindingsLang, "lang", "l", "typescript", "Target language (typescript or go)")
 
 	rootCmd.AddCommand(generateBindingsCmd)
 }
@@ -18,7 +18,7 @@ func FormatText(spec *ContractSpec) string {
 	if len(spec.Functions) > 0 {
 		fmt.Fprintf(&b, "Functions (%d):\n", len(spec.Functions))
 		for _, fn := range spec.Functions {
-			fmt.Fprintf(&b, "  %s\n", formatFunction(fn))
+			fmt.Fprintf(&b, "  %s\n", FormatFunction(fn))
 		}
 	}
 
@@ -248,7 +248,9 @@ func FormatJSON(spec *ContractSpec) (string, error) {
 	return string(out), nil
 }
 
-func formatFunction(fn xdr.ScSpecFunctionV0) string {
+// FormatFunction returns the signature of a spec function, as in
+// "transfer(from: Address, amount: I128) -> Void".
+func FormatFunction(fn xdr.ScSpecFunctionV0) string {
 	params := make([]string, len(fn.Inputs))
 	for i, inp := range fn.Inputs {
 		params[i] = fmt.Sprintf("%s: %s", inp.Name, FormatTypeDef(inp.Type))
@@ -0,0 +1,626 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package abi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// ValueFormatter renders Soroban values for display. Without type
+// information values are printed generically: integers in decimal,
+// addresses as strkeys and bytes in hex. When the expected type is known
+// from Spec, struct fields are labelled by name and enums, unions and error
+// enums are printed by variant.
+//
+// A nil *ValueFormatter formats values generically.
+type ValueFormatter struct {
+	// Spec types the values of one contract. It may be nil.
+	Spec *ContractSpec
+	// Decimals scales the 128-bit integers bound to an amount-like name
+	// (amount, balance, allowance, supply) by 10^Decimals, as for token
+	// amounts. Zero prints them unscaled.
+	Decimals uint32
+}
+
+// tokenAmountEvents are the SEP-41 events whose data is an amount.
+var tokenAmountEvents = map[string]bool{
+	"transfer": true, "mint": true, "burn": true, "clawback": true,
+}
+
+// Format renders v without a known type.
+func (f *ValueFormatter) Format(v xdr.ScVal) string {
+	return f.format(v, nil, "")
+}
+
+// FormatTyped renders v as a value of type td.
+func (f *ValueFormatter) FormatTyped(v xdr.ScVal, td xdr.ScSpecTypeDef) string {
+	return f.format(v, &td, "")
+}
+
+// FormatNamed renders v bound to the parameter or field name, which scales
+// 128-bit integers when the name is amount-like.
+func (f *ValueFormatter) FormatNamed(v xdr.ScVal, name string) string {
+	return f.format(v, nil, name)
+}
+
+// FormatAs renders v as the user-defined type typeName of the spec.
+func (f *ValueFormatter) FormatAs(v xdr.ScVal, typeName string) (string, error) {
+	if !f.spec().hasUdt(typeName) {
+		return "", fmt.Errorf("type %q is not defined by the contract spec", typeName)
+	}
+	if s, ok := f.formatUdt(v, typeName); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("value of type %s does not match %s", v.Type, typeName)
+}
+
+// FormatArgs renders the arguments of a call to fn as "name: value" pairs,
+// typed by the function's inputs. Arguments of unknown functions, or beyond
+// the declared inputs, are rendered generically.
+func (f *ValueFormatter) FormatArgs(fn string, args []xdr.ScVal) []string {
+	var inputs []xdr.ScSpecFunctionInputV0
+	if spec, ok := f.spec().Function(fn); ok {
+		inputs = spec.Inputs
+	}
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if i < len(inputs) {
+			in := inputs[i]
+			out[i] = in.Name + ": " + f.format(arg, &in.Type, in.Name)
+			continue
+		}
+		out[i] = f.Format(arg)
+	}
+	return out
+}
+
+// FormatReturn renders the value returned by fn, typed by its output.
+func (f *ValueFormatter) FormatReturn(fn string, v xdr.ScVal) string {
+	if spec, ok := f.spec().Function(fn); ok && len(spec.Outputs) > 0 {
+		return f.format(v, &spec.Outputs[0], "")
+	}
+	return f.Format(v)
+}
+
+// FormatEvent renders the topics and data of a contract event. Events
+// declared by the spec have their parameters typed and labelled; the data
+// of the SEP-41 transfer, mint, burn and clawback events is an amount.
+func (f *ValueFormatter) FormatEvent(topics []xdr.ScVal, data xdr.ScVal) ([]string, string) {
+	if ev, ok := f.spec().matchEvent(topics); ok {
+		return f.formatSpecEvent(ev, topics, data)
+	}
+
+	out := make([]string, len(topics))
+	for i, t := range topics {
+		out[i] = f.Format(t)
+	}
+	name := ""
+	if len(topics) > 0 {
+		if sym, ok := topics[0].GetSym(); ok && tokenAmountEvents[string(sym)] {
+			name = "amount"
+		}
+	}
+	return out, f.format(data, nil, name)
+}
+
+func (f *ValueFormatter) spec() *ContractSpec {
+	if f == nil {
+		return nil
+	}
+	return f.Spec
+}
+
+// Function returns the function of the spec named name.
+func (s *ContractSpec) Function(name string) (*xdr.ScSpecFunctionV0, bool) {
+	if s == nil {
+		return nil, false
+	}
+	for i := range s.Functions {
+		if string(s.Functions[i].Name) == name {
+			return &s.Functions[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *ContractSpec) hasUdt(name string) bool {
+	if s == nil {
+		return false
+	}
+	for _, st := range s.Structs {
+		if st.Name == name {
+			return true
+		}
+	}
+	for _, u := range s.Unions {
+		if u.Name == name {
+			return true
+		}
+	}
+	for _, e := range s.Enums {
+		if e.Name == name {
+			return true
+		}
+	}
+	for _, e := range s.ErrorEnums {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEvent returns the event whose prefix topics are the longest match
+// for the leading symbol topics.
+func (s *ContractSpec) matchEvent(topics []xdr.ScVal) (xdr.ScSpecEventV0, bool) {
+	var best xdr.ScSpecEventV0
+	found := false
+	if s == nil {
+		return best, false
+	}
+	for _, ev := range s.Events {
+		if len(ev.PrefixTopics) == 0 || len(ev.PrefixTopics) > len(topics) {
+			continue
+		}
+		if found && len(ev.PrefixTopics) <= len(best.PrefixTopics) {
+			continue
+		}
+		match := true
+		for i, prefix := range ev.PrefixTopics {
+			sym, ok := topics[i].GetSym()
+			if !ok || sym != prefix {
+				match = false
+				break
+			}
+		}
+		if match {
+			best, found = ev, true
+		}
+	}
+	return best, found
+}
+
+func (f *ValueFormatter) formatSpecEvent(ev xdr.ScSpecEventV0, topics []xdr.ScVal, data xdr.ScVal) ([]string, string) {
+	var topicParams, dataParams []xdr.ScSpecEventParamV0
+	for _, p := range ev.Params {
+		if p.Location == xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationTopicList {
+			topicParams = append(topicParams, p)
+		} else {
+			dataParams = append(dataParams, p)
+		}
+	}
+
+	out := make([]string, len(topics))
+	for i, t := range topics {
+		j := i - len(ev.PrefixTopics)
+		if j < 0 || j >= len(topicParams) {
+			out[i] = f.Format(t)
+			continue
+		}
+		p := topicParams[j]
+		out[i] = p.Name + ": " + f.format(t, &p.Type, p.Name)
+	}
+
+	switch ev.DataFormat {
+	case xdr.ScSpecEventDataFormatScSpecEventDataFormatSingleValue:
+		if len(dataParams) == 1 {
+			p := dataParams[0]
+			return out, f.format(data, &p.Type, p.Name)
+		}
+
+	case xdr.ScSpecEventDataFormatScSpecEventDataFormatVec:
+		if elems, ok := scVec(data); ok && len(elems) == len(dataParams) {
+			fields := make([]string, len(elems))
+			for i, e := range elems {
+				p := dataParams[i]
+				fields[i] = p.Name + ": " + f.format(e, &p.Type, p.Name)
+			}
+			return out, "{" + strings.Join(fields, ", ") + "}"
+		}
+
+	case xdr.ScSpecEventDataFormatScSpecEventDataFormatMap:
+		if entries, ok := scMap(data); ok {
+			fields := make([]string, 0, len(entries))
+			for _, e := range entries {
+				key := f.Format(e.Key)
+				var td *xdr.ScSpecTypeDef
+				for i := range dataParams {
+					if dataParams[i].Name == key {
+						td = &dataParams[i].Type
+						break
+					}
+				}
+				fields = append(fields, key+": "+f.format(e.Val, td, key))
+			}
+			return out, "{" + strings.Join(fields, ", ") + "}"
+		}
+	}
+	return out, f.Format(data)
+}
+
+func (f *ValueFormatter) format(v xdr.ScVal, td *xdr.ScSpecTypeDef, name string) string {
+	if td != nil {
+		if s, ok := f.formatTyped(v, *td, name); ok {
+			return s
+		}
+	}
+	return f.formatPlain(v, name)
+}
+
+// formatTyped renders v as a value of type td, reporting false when the
+// type adds nothing to the generic rendering or does not fit v.
+func (f *ValueFormatter) formatTyped(v xdr.ScVal, td xdr.ScSpecTypeDef, name string) (string, bool) {
+	switch td.Type {
+	case xdr.ScSpecTypeScSpecTypeOption:
+		if td.Option == nil {
+			return "", false
+		}
+		if v.Type == xdr.ScValTypeScvVoid {
+			return "None", true
+		}
+		return f.format(v, &td.Option.ValueType, name), true
+
+	case xdr.ScSpecTypeScSpecTypeResult:
+		if td.Result == nil {
+			return "", false
+		}
+		if v.Type == xdr.ScValTypeScvError {
+			return "Err(" + f.format(v, &td.Result.ErrorType, "") + ")", true
+		}
+		return f.format(v, &td.Result.OkType, name), true
+
+	case xdr.ScSpecTypeScSpecTypeVec:
+		elems, ok := scVec(v)
+		if !ok || td.Vec == nil {
+			return "", false
+		}
+		out := make([]string, len(elems))
+		for i, e := range elems {
+			out[i] = f.format(e, &td.Vec.ElementType, name)
+		}
+		return "[" + strings.Join(out, ", ") + "]", true
+
+	case xdr.ScSpecTypeScSpecTypeMap:
+		entries, ok := scMap(v)
+		if !ok || td.Map == nil {
+			return "", false
+		}
+		out := make([]string, len(entries))
+		for i, e := range entries {
+			out[i] = f.format(e.Key, &td.Map.KeyType, "") + ": " + f.format(e.Val, &td.Map.ValueType, name)
+		}
+		return "{" + strings.Join(out, ", ") + "}", true
+
+	case xdr.ScSpecTypeScSpecTypeTuple:
+		elems, ok := scVec(v)
+		if !ok || td.Tuple == nil || len(elems) != len(td.Tuple.ValueTypes) {
+			return "", false
+		}
+		out := make([]string, len(elems))
+		for i, e := range elems {
+			out[i] = f.format(e, &td.Tuple.ValueTypes[i], "")
+		}
+		return "(" + strings.Join(out, ", ") + ")", true
+
+	case xdr.ScSpecTypeScSpecTypeUdt:
+		if td.Udt == nil {
+			return "", false
+		}
+		return f.formatUdt(v, td.Udt.Name)
+	}
+	return "", false
+}
+
+// formatUdt renders v as the user-defined type name of the spec.
+func (f *ValueFormatter) formatUdt(v xdr.ScVal, name string) (string, bool) {
+	spec := f.spec()
+	if spec == nil {
+		return "", false
+	}
+
+	for _, st := range spec.Structs {
+		if st.Name != name {
+			continue
+		}
+		if isTupleStruct(st) {
+			elems, ok := scVec(v)
+			if !ok || len(elems) != len(st.Fields) {
+				return "", false
+			}
+			out := make([]string, len(elems))
+			for i, e := range elems {
+				out[i] = f.format(e, &st.Fields[i].Type, "")
+			}
+			return name + "(" + strings.Join(out, ", ") + ")", true
+		}
+		entries, ok := scMap(v)
+		if !ok {
+			return "", false
+		}
+		// Fields are printed in declaration order; keys the spec does not
+		// declare follow them.
+		used := make([]bool, len(entries))
+		var out []string
+		for _, field := range st.Fields {
+			for i, e := range entries {
+				if sym, ok := e.Key.GetSym(); ok && string(sym) == field.Name && !used[i] {
+					used[i] = true
+					out = append(out, field.Name+": "+f.format(e.Val, &field.Type, field.Name))
+					break
+				}
+			}
+		}
+		for i, e := range entries {
+			if !used[i] {
+				key := f.Format(e.Key)
+				out = append(out, key+": "+f.format(e.Val, nil, key))
+			}
+		}
+		return name + " { " + strings.Join(out, ", ") + " }", true
+	}
+
+	for _, u := range spec.Unions {
+		if u.Name != name {
+			continue
+		}
+		elems, ok := scVec(v)
+		if !ok || len(elems) == 0 {
+			return "", false
+		}
+		tag, ok := elems[0].GetSym()
+		if !ok {
+			return "", false
+		}
+		for _, c := range u.Cases {
+			switch c.Kind {
+			case xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0:
+				if c.VoidCase.Name == string(tag) {
+					return name + "::" + string(tag), true
+				}
+			case xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0:
+				if c.TupleCase.Name != string(tag) {
+					continue
+				}
+				values := elems[1:]
+				out := make([]string, len(values))
+				for i, e := range values {
+					if i < len(c.TupleCase.Type) {
+						out[i] = f.format(e, &c.TupleCase.Type[i], "")
+					} else {
+						out[i] = f.Format(e)
+					}
+				}
+				return name + "::" + string(tag) + "(" + strings.Join(out, ", ") + ")", true
+			}
+		}
+		return "", false
+	}
+
+	for _, e := range spec.Enums {
+		if e.Name != name {
+			continue
+		}
+		value, ok := v.GetU32()
+		if !ok {
+			return "", false
+		}
+		for _, c := range e.Cases {
+			if c.Value == value {
+				return name + "::" + c.Name, true
+			}
+		}
+		return fmt.Sprintf("%s(%d)", name, value), true
+	}
+
+	for _, e := range spec.ErrorEnums {
+		if e.Name != name {
+			continue
+		}
+		var code xdr.Uint32
+		switch {
+		case v.Type == xdr.ScValTypeScvError && v.Error.Type == xdr.ScErrorTypeSceContract && v.Error.ContractCode != nil:
+			code = *v.Error.ContractCode
+		case v.Type == xdr.ScValTypeScvU32:
+			code = *v.U32
+		default:
+			return "", false
+		}
+		for _, c := range e.Cases {
+			if c.Value == code {
+				return name + "::" + c.Name, true
+			}
+		}
+		return "", false
+	}
+
+	return "", false
+}
+
+// isTupleStruct reports whether st is a tuple struct, whose fields are
+// named by position and which is encoded as a vector.
+func isTupleStruct(st xdr.ScSpecUdtStructV0) bool {
+	if len(st.Fields) == 0 {
+		return false
+	}
+	for i, field := range st.Fields {
+		if field.Name != strconv.Itoa(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatPlain renders v generically. name is the parameter or field the
+// value is bound to, if any.
+func (f *ValueFormatter) formatPlain(v xdr.ScVal, name string) string {
+	switch v.Type {
+	case xdr.ScValTypeScvBool:
+		return strconv.FormatBool(*v.B)
+	case xdr.ScValTypeScvVoid:
+		return "()"
+	case xdr.ScValTypeScvError:
+		return FormatScError(*v.Error)
+	case xdr.ScValTypeScvU32:
+		return strconv.FormatUint(uint64(*v.U32), 10)
+	case xdr.ScValTypeScvI32:
+		return strconv.FormatInt(int64(*v.I32), 10)
+	case xdr.ScValTypeScvU64:
+		return strconv.FormatUint(uint64(*v.U64), 10)
+	case xdr.ScValTypeScvI64:
+		return strconv.FormatInt(int64(*v.I64), 10)
+	case xdr.ScValTypeScvTimepoint:
+		return strconv.FormatUint(uint64(*v.Timepoint), 10)
+	case xdr.ScValTypeScvDuration:
+		return strconv.FormatUint(uint64(*v.Duration), 10) + "s"
+	case xdr.ScValTypeScvU128:
+		return f.amount(u128(*v.U128), name)
+	case xdr.ScValTypeScvI128:
+		return f.amount(i128(*v.I128), name)
+	case xdr.ScValTypeScvU256:
+		p := v.U256
+		return joinWords(new(big.Int).SetUint64(uint64(p.HiHi)), uint64(p.HiLo), uint64(p.LoHi), uint64(p.LoLo)).String()
+	case xdr.ScValTypeScvI256:
+		p := v.I256
+		return joinWords(big.NewInt(int64(p.HiHi)), uint64(p.HiLo), uint64(p.LoHi), uint64(p.LoLo)).String()
+	case xdr.ScValTypeScvBytes:
+		return "0x" + hex.EncodeToString(*v.Bytes)
+	case xdr.ScValTypeScvString:
+		return strconv.Quote(string(*v.Str))
+	case xdr.ScValTypeScvSymbol:
+		return string(*v.Sym)
+
+	case xdr.ScValTypeScvVec:
+		elems, _ := scVec(v)
+		out := make([]string, len(elems))
+		for i, e := range elems {
+			out[i] = f.format(e, nil, name)
+		}
+		return "[" + strings.Join(out, ", ") + "]"
+
+	case xdr.ScValTypeScvMap:
+		entries, _ := scMap(v)
+		out := make([]string, len(entries))
+		for i, e := range entries {
+			key := f.Format(e.Key)
+			out[i] = key + ": " + f.format(e.Val, nil, key)
+		}
+		return "{" + strings.Join(out, ", ") + "}"
+
+	case xdr.ScValTypeScvAddress:
+		s, err := v.Address.String()
+		if err != nil {
+			return "Address(?)"
+		}
+		return s
+
+	case xdr.ScValTypeScvContractInstance:
+		exec := v.Instance.Executable
+		if exec.Type == xdr.ContractExecutableTypeContractExecutableWasm && exec.WasmHash != nil {
+			return "ContractInstance(wasm " + hex.EncodeToString(exec.WasmHash[:]) + ")"
+		}
+		return "ContractInstance(StellarAsset)"
+	case xdr.ScValTypeScvLedgerKeyContractInstance:
+		return "LedgerKeyContractInstance"
+	case xdr.ScValTypeScvLedgerKeyNonce:
+		return fmt.Sprintf("Nonce(%d)", v.NonceKey.Nonce)
+	}
+	return v.Type.String()
+}
+
+// FormatScError renders a host error the way the host prints it, such as
+// "Error(Contract, #7)" or "Error(Budget, ExceededLimit)".
+func FormatScError(e xdr.ScError) string {
+	kind := strings.TrimPrefix(e.Type.String(), "ScErrorTypeSce")
+	if e.Type == xdr.ScErrorTypeSceContract {
+		if e.ContractCode == nil {
+			return "Error(Contract, #?)"
+		}
+		return fmt.Sprintf("Error(Contract, #%d)", *e.ContractCode)
+	}
+	if e.Code == nil {
+		return "Error(" + kind + ")"
+	}
+	return "Error(" + kind + ", " + strings.TrimPrefix(e.Code.String(), "ScErrorCodeScec") + ")"
+}
+
+// amount renders n, scaled by the token decimals when name is amount-like.
+func (f *ValueFormatter) amount(n *big.Int, name string) string {
+	if f == nil || f.Decimals == 0 || !isAmountName(name) {
+		return n.String()
+	}
+	return FormatDecimal(n, f.Decimals)
+}
+
+func isAmountName(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range []string{"amount", "balance", "allowance", "supply"} {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatDecimal renders n scaled down by 10^decimals, without trailing
+// zeros in the fraction: 15000000 with 7 decimals is "1.5".
+func FormatDecimal(n *big.Int, decimals uint32) string {
+	neg := n.Sign() < 0
+	abs := new(big.Int).Abs(n)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	intPart, frac := new(big.Int).DivMod(abs, scale, new(big.Int))
+
+	s := intPart.String()
+	if fracStr := strings.TrimRight(fmt.Sprintf("%0*s", int(decimals), frac.String()), "0"); fracStr != "" {
+		s += "." + fracStr
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func u128(p xdr.UInt128Parts) *big.Int {
+	return joinWords(new(big.Int).SetUint64(uint64(p.Hi)), uint64(p.Lo))
+}
+
+func i128(p xdr.Int128Parts) *big.Int {
+	return joinWords(big.NewInt(int64(p.Hi)), uint64(p.Lo))
+}
+
+// joinWords returns hi followed by the 64-bit words in lo, most significant
+// first. hi carries the sign.
+func joinWords(hi *big.Int, lo ...uint64) *big.Int {
+	n := new(big.Int).Set(hi)
+	for _, w := range lo {
+		n.Lsh(n, 64)
+		n.Add(n, new(big.Int).SetUint64(w))
+	}
+	return n
+}
+
+func scVec(v xdr.ScVal) ([]xdr.ScVal, bool) {
+	if v.Type != xdr.ScValTypeScvVec {
+		return nil, false
+	}
+	if v.Vec == nil || *v.Vec == nil {
+		return nil, true
+	}
+	return **v.Vec, true
+}
+
+func scMap(v xdr.ScVal) ([]xdr.ScMapEntry, bool) {
+	if v.Type != xdr.ScValTypeScvMap {
+		return nil, false
+	}
+	if v.Map == nil || *v.Map == nil {
+		return nil, true
+	}
+	return **v.Map, true
+}
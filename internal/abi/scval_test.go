@@ -0,0 +1,204 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func symVal(s string) xdr.ScVal {
+	sym := xdr.ScSymbol(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+}
+
+func u32Val(n uint32) xdr.ScVal {
+	v := xdr.Uint32(n)
+	return xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &v}
+}
+
+func i128Val(n int64) xdr.ScVal {
+	hi := xdr.Int64(0)
+	if n < 0 {
+		hi = -1
+	}
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Hi: hi, Lo: xdr.Uint64(uint64(n))}}
+}
+
+func vecVal(elems ...xdr.ScVal) xdr.ScVal {
+	vec := xdr.ScVec(elems)
+	p := &vec
+	return xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &p}
+}
+
+func mapVal(entries ...xdr.ScMapEntry) xdr.ScVal {
+	m := xdr.ScMap(entries)
+	p := &m
+	return xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &p}
+}
+
+func accountVal(t *testing.T, seed byte) (xdr.ScVal, string) {
+	t.Helper()
+	var key xdr.Uint256
+	key[0] = seed
+	account := xdr.AccountId(xdr.PublicKey{Type: xdr.PublicKeyTypePublicKeyTypeEd25519, Ed25519: &key})
+	id, err := strkey.Encode(strkey.VersionByteAccountID, key[:])
+	require.NoError(t, err)
+	return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{
+		Type:      xdr.ScAddressTypeScAddressTypeAccount,
+		AccountId: &account,
+	}}, id
+}
+
+func udt(name string) xdr.ScSpecTypeDef {
+	return xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeUdt, Udt: &xdr.ScSpecTypeUdt{Name: name}}
+}
+
+func testSpec() *ContractSpec {
+	i128 := xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeI128}
+	address := xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeAddress}
+	return &ContractSpec{
+		Functions: []xdr.ScSpecFunctionV0{{
+			Name: "transfer",
+			Inputs: []xdr.ScSpecFunctionInputV0{
+				{Name: "from", Type: address},
+				{Name: "to", Type: address},
+				{Name: "amount", Type: i128},
+			},
+		}, {
+			Name:    "state",
+			Outputs: []xdr.ScSpecTypeDef{udt("State")},
+		}},
+		Structs: []xdr.ScSpecUdtStructV0{{
+			Name: "Position",
+			Fields: []xdr.ScSpecUdtStructFieldV0{
+				{Name: "owner", Type: address},
+				{Name: "balance", Type: i128},
+				{Name: "status", Type: udt("Status")},
+			},
+		}, {
+			Name:   "Pair",
+			Fields: []xdr.ScSpecUdtStructFieldV0{{Name: "0", Type: i128}, {Name: "1", Type: i128}},
+		}},
+		Unions: []xdr.ScSpecUdtUnionV0{{
+			Name: "State",
+			Cases: []xdr.ScSpecUdtUnionCaseV0{{
+				Kind:     xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0,
+				VoidCase: &xdr.ScSpecUdtUnionCaseVoidV0{Name: "Idle"},
+			}, {
+				Kind:      xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0,
+				TupleCase: &xdr.ScSpecUdtUnionCaseTupleV0{Name: "Open", Type: []xdr.ScSpecTypeDef{udt("Position")}},
+			}},
+		}},
+		Enums: []xdr.ScSpecUdtEnumV0{{
+			Name:  "Status",
+			Cases: []xdr.ScSpecUdtEnumCaseV0{{Name: "Active", Value: 1}, {Name: "Frozen", Value: 2}},
+		}},
+		ErrorEnums: []xdr.ScSpecUdtErrorEnumV0{{
+			Name:  "TokenError",
+			Cases: []xdr.ScSpecUdtErrorEnumCaseV0{{Name: "Paused", Value: 7}},
+		}},
+		Events: []xdr.ScSpecEventV0{{
+			Name:         "approve",
+			PrefixTopics: []xdr.ScSymbol{"approve"},
+			Params: []xdr.ScSpecEventParamV0{
+				{Name: "owner", Type: address, Location: xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationTopicList},
+				{Name: "amount", Type: i128, Location: xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationData},
+				{Name: "expiration", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeU32}, Location: xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationData},
+			},
+			DataFormat: xdr.ScSpecEventDataFormatScSpecEventDataFormatVec,
+		}},
+	}
+}
+
+func TestValueFormatter_Generic(t *testing.T) {
+	var f *ValueFormatter
+	addr, id := accountVal(t, 1)
+	b := xdr.ScBytes{0xde, 0xad}
+	str := xdr.ScString("hi")
+
+	assert.Equal(t, id, f.Format(addr))
+	assert.Equal(t, "-5", f.Format(i128Val(-5)))
+	assert.Equal(t, "0xdead", f.Format(xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &b}))
+	assert.Equal(t, `"hi"`, f.Format(xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str}))
+	assert.Equal(t, "[7, transfer]", f.Format(vecVal(u32Val(7), symVal("transfer"))))
+	assert.Equal(t, "{a: 1}", f.Format(mapVal(xdr.ScMapEntry{Key: symVal("a"), Val: u32Val(1)})))
+
+	code := xdr.Uint32(7)
+	contractErr := xdr.ScVal{Type: xdr.ScValTypeScvError, Error: &xdr.ScError{Type: xdr.ScErrorTypeSceContract, ContractCode: &code}}
+	assert.Equal(t, "Error(Contract, #7)", f.Format(contractErr))
+}
+
+func TestValueFormatter_UserDefinedTypes(t *testing.T) {
+	f := &ValueFormatter{Spec: testSpec(), Decimals: 7}
+	addr, id := accountVal(t, 2)
+
+	position := mapVal(
+		xdr.ScMapEntry{Key: symVal("balance"), Val: i128Val(15_000_000)},
+		xdr.ScMapEntry{Key: symVal("owner"), Val: addr},
+		xdr.ScMapEntry{Key: symVal("status"), Val: u32Val(2)},
+	)
+	s, err := f.FormatAs(position, "Position")
+	require.NoError(t, err)
+	assert.Equal(t, "Position { owner: "+id+", balance: 1.5, status: Status::Frozen }", s)
+
+	assert.Equal(t, "State::Idle", f.FormatReturn("state", vecVal(symVal("Idle"))))
+	assert.Equal(t, "State::Open(Position { owner: "+id+", balance: 1.5, status: Status::Frozen })",
+		f.FormatReturn("state", vecVal(symVal("Open"), position)))
+
+	s, err = f.FormatAs(vecVal(i128Val(1), i128Val(2)), "Pair")
+	require.NoError(t, err)
+	assert.Equal(t, "Pair(1, 2)", s)
+
+	s, err = f.FormatAs(u32Val(7), "TokenError")
+	require.NoError(t, err)
+	assert.Equal(t, "TokenError::Paused", s)
+
+	s, err = f.FormatAs(u32Val(9), "Status")
+	require.NoError(t, err)
+	assert.Equal(t, "Status(9)", s, "unknown enum values keep their number")
+
+	_, err = f.FormatAs(u32Val(1), "Missing")
+	assert.Error(t, err)
+	_, err = f.FormatAs(u32Val(1), "Position")
+	assert.Error(t, err, "a value of the wrong shape does not match the type")
+}
+
+func TestValueFormatter_FormatArgs(t *testing.T) {
+	f := &ValueFormatter{Spec: testSpec(), Decimals: 7}
+	from, fromID := accountVal(t, 3)
+	to, toID := accountVal(t, 4)
+
+	assert.Equal(t,
+		[]string{"from: " + fromID, "to: " + toID, "amount: -0.25", "9"},
+		f.FormatArgs("transfer", []xdr.ScVal{from, to, i128Val(-2_500_000), u32Val(9)}))
+	assert.Equal(t, []string{"100"}, f.FormatArgs("unknown", []xdr.ScVal{i128Val(100)}))
+}
+
+func TestValueFormatter_FormatEvent(t *testing.T) {
+	f := &ValueFormatter{Spec: testSpec(), Decimals: 7}
+	owner, ownerID := accountVal(t, 5)
+
+	topics, data := f.FormatEvent(
+		[]xdr.ScVal{symVal("approve"), owner},
+		vecVal(i128Val(10_000_000), u32Val(1000)))
+	assert.Equal(t, []string{"approve", "owner: " + ownerID}, topics)
+	assert.Equal(t, "{amount: 1, expiration: 1000}", data)
+
+	topics, data = f.FormatEvent([]xdr.ScVal{symVal("transfer"), owner}, i128Val(5_000_000))
+	assert.Equal(t, []string{"transfer", ownerID}, topics)
+	assert.Equal(t, "0.5", data, "SEP-41 transfer data is an amount")
+}
+
+func TestFormatDecimal(t *testing.T) {
+	assert.Equal(t, "1.5", FormatDecimal(big.NewInt(15_000_000), 7))
+	assert.Equal(t, "0.0000001", FormatDecimal(big.NewInt(1), 7))
+	assert.Equal(t, "-12", FormatDecimal(big.NewInt(-120), 1))
+	assert.Equal(t, "0", FormatDecimal(big.NewInt(0), 7))
+}
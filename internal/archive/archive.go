@@ -0,0 +1,158 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package archive reads a Stellar history archive mirrored on disk. It looks
+// up transactions and ledger headers the way rpc.Client does, so that
+// commands can replay transactions without an RPC provider.
+//
+// An archive is laid out by checkpoint, every 64 ledgers:
+//
+//	.well-known/stellar-history.json                  latest HistoryArchiveState
+//	history/ww/xx/yy/history-wwxxyyzz.json            checkpoint HistoryArchiveState
+//	ledger/ww/xx/yy/ledger-wwxxyyzz.xdr.gz            LedgerHeaderHistoryEntry stream
+//	transactions/ww/xx/yy/transactions-wwxxyyzz.xdr.gz TransactionHistoryEntry stream
+//	results/ww/xx/yy/results-wwxxyyzz.xdr.gz          TransactionHistoryResultEntry stream
+//
+// where wwxxyyzz is the hex sequence of the checkpoint's last ledger. History
+// archives do not keep transaction meta, so transactions read from them have
+// no result meta.
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// CheckpointFrequency is the number of ledgers between checkpoints.
+const CheckpointFrequency = 64
+
+// Checkpoint returns the sequence of the last ledger of the checkpoint that
+// holds ledger seq.
+func Checkpoint(seq uint32) uint32 {
+	return seq/CheckpointFrequency*CheckpointFrequency + CheckpointFrequency - 1
+}
+
+// CategoryPath returns the path, relative to the archive root, of a
+// checkpoint's file of the given category ("ledger", "transactions",
+// "results" or "history") and extension.
+func CategoryPath(category string, checkpoint uint32, ext string) string {
+	hex := fmt.Sprintf("%08x", checkpoint)
+	return filepath.Join(category, hex[0:2], hex[2:4], hex[4:6], category+"-"+hex+"."+ext)
+}
+
+// RootHASPath is the path of the latest HistoryArchiveState.
+const RootHASPath = ".well-known/stellar-history.json"
+
+// HistoryArchiveState is the JSON document describing an archive, or one of
+// its checkpoints. Only the fields erst reads are decoded.
+type HistoryArchiveState struct {
+	Version           int    `json:"version"`
+	Server            string `json:"server,omitempty"`
+	CurrentLedger     uint32 `json:"currentLedger"`
+	NetworkPassphrase string `json:"networkPassphrase,omitempty"`
+}
+
+// Archive is a history archive on disk.
+type Archive struct {
+	root       string
+	passphrase string
+	state      HistoryArchiveState
+	index      *txIndex
+}
+
+// Open opens the archive rooted at root. Transactions are hashed with the
+// archive's network passphrase when its state records one, and with
+// passphrase otherwise.
+func Open(root, passphrase string) (*Archive, error) {
+	raw, err := os.ReadFile(filepath.Join(root, RootHASPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history archive state: %w", err)
+	}
+	var state HistoryArchiveState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse history archive state: %w", err)
+	}
+	if state.NetworkPassphrase != "" {
+		passphrase = state.NetworkPassphrase
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("history archive %s does not record its network passphrase", root)
+	}
+	return &Archive{root: root, passphrase: passphrase, state: state, index: newTxIndex()}, nil
+}
+
+// State returns the archive's latest HistoryArchiveState.
+func (a *Archive) State() HistoryArchiveState {
+	return a.state
+}
+
+// LedgerHeaders returns the ledger headers of a checkpoint.
+func (a *Archive) LedgerHeaders(checkpoint uint32) ([]xdr.LedgerHeaderHistoryEntry, error) {
+	var headers []xdr.LedgerHeaderHistoryEntry
+	err := a.readStream("ledger", checkpoint, func(s *xdr.Stream) error {
+		var entry xdr.LedgerHeaderHistoryEntry
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+		headers = append(headers, entry)
+		return nil
+	})
+	return headers, err
+}
+
+// Transactions returns the transaction sets of a checkpoint's ledgers.
+func (a *Archive) Transactions(checkpoint uint32) ([]xdr.TransactionHistoryEntry, error) {
+	var entries []xdr.TransactionHistoryEntry
+	err := a.readStream("transactions", checkpoint, func(s *xdr.Stream) error {
+		var entry xdr.TransactionHistoryEntry
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// Results returns the transaction results of a checkpoint's ledgers.
+func (a *Archive) Results(checkpoint uint32) ([]xdr.TransactionHistoryResultEntry, error) {
+	var entries []xdr.TransactionHistoryResultEntry
+	err := a.readStream("results", checkpoint, func(s *xdr.Stream) error {
+		var entry xdr.TransactionHistoryResultEntry
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// readStream calls next until the gzipped XDR stream of a checkpoint's file
+// is exhausted.
+func (a *Archive) readStream(category string, checkpoint uint32, next func(*xdr.Stream) error) error {
+	f, err := os.Open(filepath.Join(a.root, CategoryPath(category, checkpoint, "xdr.gz")))
+	if err != nil {
+		return err
+	}
+	stream, err := xdr.NewGzStream(f)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to open %s file of checkpoint %d: %w", category, checkpoint, err)
+	}
+	defer stream.Close()
+
+	for {
+		if err := next(stream); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to decode %s file of checkpoint %d: %w", category, checkpoint, err)
+		}
+	}
+}
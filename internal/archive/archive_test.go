@@ -0,0 +1,204 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/network"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envelope(seq int64) xdr.TransactionEnvelope {
+	var source xdr.Uint256
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{Tx: xdr.Transaction{
+			SourceAccount: xdr.MuxedAccount{Type: xdr.CryptoKeyTypeKeyTypeEd25519, Ed25519: &source},
+			Fee:           100,
+			SeqNum:        xdr.SequenceNumber(seq),
+			Cond:          xdr.Preconditions{Type: xdr.PreconditionTypePrecondNone},
+			Memo:          xdr.Memo{Type: xdr.MemoTypeMemoNone},
+			Operations: []xdr.Operation{{Body: xdr.OperationBody{
+				Type:           xdr.OperationTypeBumpSequence,
+				BumpSequenceOp: &xdr.BumpSequenceOp{BumpTo: xdr.SequenceNumber(seq)},
+			}}},
+		}},
+	}
+}
+
+func successResult(hash [32]byte) xdr.TransactionResultPair {
+	return xdr.TransactionResultPair{
+		TransactionHash: hash,
+		Result: xdr.TransactionResult{FeeCharged: 100, Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxSuccess,
+			Results: &[]xdr.OperationResult{{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+				Type:          xdr.OperationTypeBumpSequence,
+				BumpSeqResult: &xdr.BumpSequenceResult{Code: xdr.BumpSequenceResultCodeBumpSequenceSuccess},
+			}}},
+		}},
+	}
+}
+
+func failedResult(hash [32]byte) xdr.TransactionResultPair {
+	return xdr.TransactionResultPair{
+		TransactionHash: hash,
+		Result: xdr.TransactionResult{FeeCharged: 100, Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxBadSeq,
+		}},
+	}
+}
+
+func writeStream(t *testing.T, root, category string, checkpoint uint32, entries ...interface{}) {
+	t.Helper()
+	path := filepath.Join(root, CategoryPath(category, checkpoint, "xdr.gz"))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	for _, e := range entries {
+		require.NoError(t, xdr.MarshalFramed(zw, e))
+	}
+	require.NoError(t, zw.Close())
+}
+
+// writeFixtureArchive writes an archive whose checkpoint 127 holds ledger 100,
+// with a classic transaction set, and ledger 101, with a generalized one.
+// It returns the archive root and the hex hashes of the two transactions.
+func writeFixtureArchive(t *testing.T) (string, string, string) {
+	t.Helper()
+	root := t.TempDir()
+	passphrase := network.TestNetworkPassphrase
+
+	has, err := json.Marshal(HistoryArchiveState{Version: 2, CurrentLedger: 127, NetworkPassphrase: passphrase})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".well-known"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, RootHASPath), has, 0o644))
+
+	classic, soroban := envelope(1), envelope(2)
+	classicHash, err := network.HashTransactionInEnvelope(classic, passphrase)
+	require.NoError(t, err)
+	sorobanHash, err := network.HashTransactionInEnvelope(soroban, passphrase)
+	require.NoError(t, err)
+
+	header := func(seq uint32) xdr.LedgerHeaderHistoryEntry {
+		return xdr.LedgerHeaderHistoryEntry{
+			Hash: xdr.Hash{byte(seq)},
+			Header: xdr.LedgerHeader{
+				LedgerVersion:      22,
+				PreviousLedgerHash: xdr.Hash{byte(seq - 1)},
+				ScpValue: xdr.StellarValue{
+					CloseTime: xdr.TimePoint(1_700_000_000 + seq),
+					Ext:       xdr.StellarValueExt{V: xdr.StellarValueTypeStellarValueBasic},
+				},
+				LedgerSeq:    xdr.Uint32(seq),
+				TotalCoins:   1_000_000_000_000_000,
+				BaseFee:      100,
+				BaseReserve:  5_000_000,
+				MaxTxSetSize: 1000,
+			},
+		}
+	}
+	writeStream(t, root, "ledger", 127, header(100), header(101))
+
+	components := []xdr.TxSetComponent{{
+		Type:                  xdr.TxSetComponentTypeTxsetCompTxsMaybeDiscountedFee,
+		TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{Txs: []xdr.TransactionEnvelope{soroban}},
+	}}
+	writeStream(t, root, "transactions", 127,
+		xdr.TransactionHistoryEntry{LedgerSeq: 100, TxSet: xdr.TransactionSet{Txs: []xdr.TransactionEnvelope{classic}}},
+		xdr.TransactionHistoryEntry{LedgerSeq: 101, Ext: xdr.TransactionHistoryEntryExt{V: 1, GeneralizedTxSet: &xdr.GeneralizedTransactionSet{
+			V:       1,
+			V1TxSet: &xdr.TransactionSetV1{Phases: []xdr.TransactionPhase{{V: 0, V0Components: &components}}},
+		}}},
+	)
+	writeStream(t, root, "results", 127,
+		xdr.TransactionHistoryResultEntry{LedgerSeq: 100, TxResultSet: xdr.TransactionResultSet{Results: []xdr.TransactionResultPair{successResult(classicHash)}}},
+		xdr.TransactionHistoryResultEntry{LedgerSeq: 101, TxResultSet: xdr.TransactionResultSet{Results: []xdr.TransactionResultPair{failedResult(sorobanHash)}}},
+	)
+	return root, hex.EncodeToString(classicHash[:]), hex.EncodeToString(sorobanHash[:])
+}
+
+func TestCategoryPath(t *testing.T) {
+	assert.Equal(t, uint32(63), Checkpoint(1))
+	assert.Equal(t, uint32(127), Checkpoint(64))
+	assert.Equal(t, uint32(127), Checkpoint(127))
+	assert.Equal(t, filepath.Join("ledger", "00", "be", "bc", "ledger-00bebc7f.xdr.gz"), CategoryPath("ledger", 0x00bebc7f, "xdr.gz"))
+}
+
+func TestArchive_GetTransaction(t *testing.T) {
+	root, classicHash, sorobanHash := writeFixtureArchive(t)
+	a, err := Open(root, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), a.State().CurrentLedger)
+
+	ctx := context.Background()
+	resp, err := a.GetTransaction(ctx, classicHash)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(100), resp.Ledger)
+	assert.Empty(t, resp.ResultMetaXdr, "history archives keep no meta")
+
+	var env xdr.TransactionEnvelope
+	require.NoError(t, xdr.SafeUnmarshalBase64(resp.EnvelopeXdr, &env))
+	assert.Equal(t, int64(1), env.SeqNum())
+	var result xdr.TransactionResult
+	require.NoError(t, xdr.SafeUnmarshalBase64(resp.ResultXdr, &result))
+	assert.True(t, result.Successful())
+
+	resp, err = a.GetTransaction(ctx, sorobanHash)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(101), resp.Ledger, "transactions of generalized sets are found")
+
+	_, err = a.GetTransaction(ctx, hex.EncodeToString(make([]byte, 32)))
+	assert.Error(t, err)
+}
+
+func TestArchive_GetLedgerHeader(t *testing.T) {
+	root, _, _ := writeFixtureArchive(t)
+	a, err := Open(root, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	header, err := a.GetLedgerHeader(ctx, 101)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(101), header.Sequence)
+	hash := xdr.Hash{101}
+	assert.Equal(t, hex.EncodeToString(hash[:]), header.Hash)
+	assert.Equal(t, uint32(22), header.ProtocolVersion)
+	assert.Equal(t, int32(100), header.BaseFee)
+	assert.Equal(t, "100000000.0000000", header.TotalCoins)
+	assert.Equal(t, int64(1_700_000_101), header.CloseTime.Unix())
+	assert.Equal(t, int32(1), header.FailedTxCount)
+
+	header, err = a.GetLedgerHeader(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), header.SuccessfulTxCount)
+	assert.Equal(t, int32(1), header.OperationCount)
+
+	_, err = a.GetLedgerHeader(ctx, 102)
+	assert.Error(t, err, "ledger missing from its checkpoint")
+	_, err = a.GetLedgerHeader(ctx, 10)
+	assert.Error(t, err, "checkpoint missing from the archive")
+}
+
+func TestOpen_RequiresPassphrase(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".well-known"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, RootHASPath), []byte(`{"version": 1, "currentLedger": 63}`), 0o644))
+
+	_, err := Open(root, "")
+	assert.Error(t, err)
+	a, err := Open(root, network.PublicNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(63), a.State().CurrentLedger)
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/stellar/go-stellar-sdk/amount"
+	"github.com/stellar/go-stellar-sdk/network"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// txIndex remembers the ledger of every transaction result read so far, so
+// that checkpoints are scanned at most once.
+type txIndex struct {
+	mu      sync.Mutex
+	ledgers map[string]uint32 // hex tx hash -> ledger
+	scanned map[uint32]bool   // checkpoints already indexed
+}
+
+func newTxIndex() *txIndex {
+	return &txIndex{ledgers: make(map[string]uint32), scanned: make(map[uint32]bool)}
+}
+
+// GetTransaction returns the transaction with the given hex hash. Checkpoints
+// are searched from the latest one back. The response has no result meta,
+// which history archives do not keep.
+func (a *Archive) GetTransaction(ctx context.Context, hash string) (*rpc.TransactionResponse, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	ledger, err := a.findLedger(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := Checkpoint(ledger)
+	results, err := a.Results(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	var result *xdr.TransactionResult
+	for _, entry := range results {
+		if uint32(entry.LedgerSeq) != ledger {
+			continue
+		}
+		for i, pair := range entry.TxResultSet.Results {
+			if hex.EncodeToString(pair.TransactionHash[:]) == hash {
+				result = &entry.TxResultSet.Results[i].Result
+			}
+		}
+	}
+
+	txs, err := a.Transactions(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range txs {
+		if uint32(entry.LedgerSeq) != ledger {
+			continue
+		}
+		for _, env := range envelopes(entry) {
+			txHash, err := network.HashTransactionInEnvelope(env, a.passphrase)
+			if err != nil || hex.EncodeToString(txHash[:]) != hash {
+				continue
+			}
+			envelopeXDR, err := xdr.MarshalBase64(env)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode transaction envelope: %w", err)
+			}
+			resp := &rpc.TransactionResponse{EnvelopeXdr: envelopeXDR, Ledger: ledger}
+			if result != nil {
+				if resp.ResultXdr, err = xdr.MarshalBase64(*result); err != nil {
+					return nil, fmt.Errorf("failed to encode transaction result: %w", err)
+				}
+			}
+			return resp, nil
+		}
+	}
+	return nil, errors.WrapTransactionNotFound(
+		fmt.Errorf("transaction %s has a result in ledger %d but no envelope", hash, ledger))
+}
+
+// findLedger returns the ledger of the transaction with the given hash,
+// indexing the results of checkpoints not yet read until it is found.
+func (a *Archive) findLedger(ctx context.Context, hash string) (uint32, error) {
+	a.index.mu.Lock()
+	defer a.index.mu.Unlock()
+	if ledger, ok := a.index.ledgers[hash]; ok {
+		return ledger, nil
+	}
+
+	for checkpoint := Checkpoint(a.state.CurrentLedger); ; checkpoint -= CheckpointFrequency {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if !a.index.scanned[checkpoint] {
+			results, err := a.Results(checkpoint)
+			if err != nil && !os.IsNotExist(err) {
+				return 0, err
+			}
+			for _, entry := range results {
+				for _, pair := range entry.TxResultSet.Results {
+					a.index.ledgers[hex.EncodeToString(pair.TransactionHash[:])] = uint32(entry.LedgerSeq)
+				}
+			}
+			a.index.scanned[checkpoint] = true
+			if ledger, ok := a.index.ledgers[hash]; ok {
+				return ledger, nil
+			}
+		}
+		if checkpoint < CheckpointFrequency {
+			break
+		}
+	}
+	return 0, errors.WrapTransactionNotFound(fmt.Errorf("transaction %s not found in history archive", hash))
+}
+
+// envelopes returns the transactions of a ledger's transaction set.
+func envelopes(entry xdr.TransactionHistoryEntry) []xdr.TransactionEnvelope {
+	set, ok := entry.Ext.GetGeneralizedTxSet()
+	if !ok {
+		return entry.TxSet.Txs
+	}
+	v1, ok := set.GetV1TxSet()
+	if !ok {
+		return nil
+	}
+	var all []xdr.TransactionEnvelope
+	for _, phase := range v1.Phases {
+		if phase.V0Components != nil {
+			for _, component := range *phase.V0Components {
+				if component.TxsMaybeDiscountedFee != nil {
+					all = append(all, component.TxsMaybeDiscountedFee.Txs...)
+				}
+			}
+		}
+		if phase.ParallelTxsComponent != nil {
+			for _, stage := range phase.ParallelTxsComponent.ExecutionStages {
+				for _, cluster := range stage {
+					all = append(all, cluster...)
+				}
+			}
+		}
+	}
+	return all
+}
+
+// GetLedgerHeader returns the header of ledger sequence, with transaction
+// and operation counts taken from the checkpoint's results.
+func (a *Archive) GetLedgerHeader(ctx context.Context, sequence uint32) (*rpc.LedgerHeaderResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	checkpoint := Checkpoint(sequence)
+	headers, err := a.LedgerHeaders(checkpoint)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.WrapLedgerNotFound(sequence)
+		}
+		return nil, err
+	}
+
+	for _, entry := range headers {
+		header := entry.Header
+		if uint32(header.LedgerSeq) != sequence {
+			continue
+		}
+		headerXDR, err := xdr.MarshalBase64(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ledger header: %w", err)
+		}
+		resp := &rpc.LedgerHeaderResponse{
+			Sequence:        sequence,
+			Hash:            hex.EncodeToString(entry.Hash[:]),
+			PrevHash:        hex.EncodeToString(header.PreviousLedgerHash[:]),
+			CloseTime:       time.Unix(int64(header.ScpValue.CloseTime), 0).UTC(),
+			ProtocolVersion: uint32(header.LedgerVersion),
+			BaseFee:         int32(header.BaseFee),
+			BaseReserve:     int32(header.BaseReserve),
+			MaxTxSetSize:    int32(header.MaxTxSetSize),
+			TotalCoins:      amount.String(header.TotalCoins),
+			FeePool:         amount.String(header.FeePool),
+			HeaderXDR:       headerXDR,
+		}
+
+		results, err := a.Results(checkpoint)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, r := range results {
+			if uint32(r.LedgerSeq) != sequence {
+				continue
+			}
+			for _, pair := range r.TxResultSet.Results {
+				if !pair.Successful() {
+					resp.FailedTxCount++
+					continue
+				}
+				resp.SuccessfulTxCount++
+				if ops, ok := pair.OperationResults(); ok {
+					resp.OperationCount += int32(len(ops))
+				}
+			}
+		}
+		return resp, nil
+	}
+	return nil, errors.WrapLedgerNotFound(sequence)
+}
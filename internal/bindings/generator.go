@@ -11,22 +11,25 @@ import (
 	"github.com/stellar/go-stellar-sdk/xdr"
 )
 
-// GeneratorConfig holds configuration for TypeScript bindings generation
+// GeneratorConfig holds configuration for bindings generation
 type GeneratorConfig struct {
 	WasmBytes   []byte
 	OutputDir   string
 	PackageName string
 	ContractID  string
 	Network     string
+	// Language is the language of the bindings: "typescript" (the default)
+	// or "go".
+	Language string
 }
 
-// GeneratedFile represents a generated TypeScript file
+// GeneratedFile represents a generated bindings file
 type GeneratedFile struct {
 	Path    string
 	Content string
 }
 
-// Generator generates TypeScript bindings from Soroban contract specs
+// Generator generates TypeScript or Go bindings from Soroban contract specs
 type Generator struct {
 	config GeneratorConfig
 	spec   *abi.ContractSpec
@@ -39,8 +42,15 @@ func NewGenerator(config GeneratorConfig) *Generator {
 	}
 }
 
-// Generate extracts the contract spec and generates TypeScript bindings
+// Generate extracts the contract spec and generates bindings in the
+// configured language
 func (g *Generator) Generate() ([]GeneratedFile, error) {
+	switch g.config.Language {
+	case "", "typescript", "go":
+	default:
+		return nil, fmt.Errorf("unsupported language: %s (use: typescript, go)", g.config.Language)
+	}
+
 	// Extract contract spec from WASM
 	specBytes, err := abi.ExtractCustomSection(g.config.WasmBytes, "contractspecv0")
 	if err != nil {
@@ -56,6 +66,10 @@ func (g *Generator) Generate() ([]GeneratedFile, error) {
 		return nil, fmt.Errorf("failed to decode contract spec: %w", err)
 	}
 
+	if g.config.Language == "go" {
+		return g.generateGo()
+	}
+
 	// Generate files
 	files := []GeneratedFile{
 		{
@@ -0,0 +1,890 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package bindings
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+const goHeader = "// Code generated by erst generate-bindings. DO NOT EDIT.\n\n"
+
+// goReservedNames are the exported identifiers of the generated package that
+// contract types must not take.
+var goReservedNames = map[string]bool{
+	"Client": true, "NewClient": true, "Simulator": true, "Simulation": true,
+	"RPCSimulator": true, "NewRPCSimulator": true, "SimulateTransactionResponse": true,
+	"SimulateHostFunctionResult": true, "MapEntry": true, "ContractID": true,
+	"DecodeEvent": true, "ErrUnknownEvent": true,
+}
+
+// goReservedParams are the names client methods use for themselves, and the
+// packages and builtins they refer to.
+var goReservedParams = map[string]bool{
+	"c": true, "ctx": true, "args": true, "result": true, "sim": true, "err": true,
+	"make": true, "fmt": true, "xdr": true, "big": true, "context": true, "errors": true,
+	"bytes": true, "json": true, "http": true,
+}
+
+// goImports are the packages generated files may use, by the name they are
+// used under, in groups separated in the import block. Generated packages
+// are built outside this module, so they must not import its packages.
+var goImports = [][]struct {
+	name, path string
+}{
+	{
+		{"bytes", "bytes"},
+		{"context", "context"},
+		{"json", "encoding/json"},
+		{"errors", "errors"},
+		{"fmt", "fmt"},
+		{"big", "math/big"},
+		{"http", "net/http"},
+	},
+	{
+		{"xdr", "github.com/stellar/go-stellar-sdk/xdr"},
+	},
+}
+
+// goType is how a spec type is held in Go: the Go type, and expressions of
+// the functions encoding it to and decoding it from an ScVal.
+type goType struct {
+	name   string
+	encode string
+	decode string
+}
+
+// generateGo generates a Go package: the contract types, a client building
+// and simulating invocations, the contract events and the ScVal conversions
+// they share.
+func (g *Generator) generateGo() ([]GeneratedFile, error) {
+	pkg := goPackageName(g.config.PackageName)
+
+	sources := []struct {
+		path, content string
+	}{
+		{"types.go", g.generateGoTypes()},
+		{"client.go", g.generateGoClient()},
+		{"events.go", g.generateGoEvents()},
+	}
+
+	var files []GeneratedFile
+	for _, s := range sources {
+		if s.content == "" {
+			continue
+		}
+		content, err := formatGo(pkg, s.content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format %s: %w", s.path, err)
+		}
+		files = append(files, GeneratedFile{Path: s.path, Content: content})
+	}
+
+	runtime, err := format.Source([]byte(fmt.Sprintf(goRuntime, pkg)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format scval.go: %w", err)
+	}
+	files = append(files, GeneratedFile{Path: "scval.go", Content: string(runtime)})
+
+	return files, nil
+}
+
+// formatGo adds the header, package clause and imports to the body of a
+// generated file, and formats it.
+func formatGo(pkg, body string) (string, error) {
+	var code strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			code.WriteString(line + "\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(goHeader)
+	b.WriteString(fmt.Sprintf("package %s\n\n", pkg))
+	b.WriteString("import (\n")
+	for i, group := range goImports {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, imp := range group {
+			if regexp.MustCompile(`\b` + imp.name + `\.`).MatchString(code.String()) {
+				b.WriteString(fmt.Sprintf("\t%q\n", imp.path))
+			}
+		}
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(body)
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// generateGoTypes generates the Go types of the contract's structs, enums,
+// unions and error enums, or nothing when it defines none.
+func (g *Generator) generateGoTypes() string {
+	var b strings.Builder
+
+	for _, s := range g.spec.Structs {
+		g.generateGoStruct(&b, s)
+	}
+	for _, e := range g.spec.Enums {
+		cases := make([]xdr.ScSpecUdtErrorEnumCaseV0, len(e.Cases))
+		for i, c := range e.Cases {
+			cases[i] = xdr.ScSpecUdtErrorEnumCaseV0{Doc: c.Doc, Name: c.Name, Value: c.Value}
+		}
+		g.generateGoEnum(&b, e.Name, e.Doc, cases, false)
+	}
+	for _, u := range g.spec.Unions {
+		g.generateGoUnion(&b, u)
+	}
+	for _, e := range g.spec.ErrorEnums {
+		g.generateGoEnum(&b, e.Name, e.Doc, e.Cases, true)
+	}
+
+	return b.String()
+}
+
+func (g *Generator) generateGoStruct(b *strings.Builder, s xdr.ScSpecUdtStructV0) {
+	name := goTypeName(s.Name)
+	tuple := isTupleStruct(s)
+
+	writeGoTypeDoc(b, name, s.Name, s.Doc)
+	b.WriteString(fmt.Sprintf("type %s struct {\n", name))
+	for i, f := range s.Fields {
+		writeGoDoc(b, "\t", f.Doc)
+		b.WriteString(fmt.Sprintf("\t%s %s\n", goFieldName(f.Name, i, tuple), g.mapTypeDefToGo(f.Type).name))
+	}
+	b.WriteString("}\n\n")
+
+	encoding := "a map keyed by field name"
+	if tuple {
+		encoding = "a vector of its fields"
+	}
+	b.WriteString(fmt.Sprintf("// ToScVal encodes the struct as %s.\n", encoding))
+	b.WriteString(fmt.Sprintf("func (v %s) ToScVal() (xdr.ScVal, error) {\n", name))
+	if tuple {
+		b.WriteString("\treturn encodeTuple(\n")
+	} else {
+		b.WriteString("\treturn encodeStruct(\n")
+	}
+	for i, f := range s.Fields {
+		t := g.mapTypeDefToGo(f.Type)
+		if tuple {
+			b.WriteString(fmt.Sprintf("\t\telem(v.%s, %s),\n", goFieldName(f.Name, i, true), t.encode))
+		} else {
+			b.WriteString(fmt.Sprintf("\t\tfield(%q, v.%s, %s),\n", f.Name, goFieldName(f.Name, i, false), t.encode))
+		}
+	}
+	b.WriteString("\t)\n}\n\n")
+
+	b.WriteString(fmt.Sprintf("// %sFromScVal decodes a %s.\n", name, name))
+	b.WriteString(fmt.Sprintf("func %sFromScVal(v xdr.ScVal) (%s, error) {\n", name, name))
+	b.WriteString(fmt.Sprintf("\tvar out %s\n", name))
+	if tuple {
+		b.WriteString("\terr := decodeTuple(v,\n")
+	} else {
+		b.WriteString("\terr := decodeStruct(v,\n")
+	}
+	for i, f := range s.Fields {
+		t := g.mapTypeDefToGo(f.Type)
+		b.WriteString(fmt.Sprintf("\t\tinto(%q, &out.%s, %s),\n", f.Name, goFieldName(f.Name, i, tuple), t.decode))
+	}
+	b.WriteString("\t)\n")
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString(fmt.Sprintf("\t\treturn %s{}, fmt.Errorf(\"%s: %%w\", err)\n", name, s.Name))
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn out, nil\n")
+	b.WriteString("}\n\n")
+}
+
+// generateGoEnum generates an enum, or an error enum, which also implements
+// error and is encoded as a contract error.
+func (g *Generator) generateGoEnum(b *strings.Builder, specName, doc string, cases []xdr.ScSpecUdtErrorEnumCaseV0, isError bool) {
+	name := goTypeName(specName)
+
+	// Cases sharing a value would be duplicate switch cases.
+	var unique []xdr.ScSpecUdtErrorEnumCaseV0
+	seen := make(map[xdr.Uint32]bool)
+	for _, c := range cases {
+		if !seen[c.Value] {
+			seen[c.Value] = true
+			unique = append(unique, c)
+		}
+	}
+
+	writeGoTypeDoc(b, name, specName, doc)
+	b.WriteString(fmt.Sprintf("type %s uint32\n\n", name))
+
+	if len(cases) > 0 {
+		b.WriteString("const (\n")
+		for _, c := range cases {
+			writeGoDoc(b, "\t", c.Doc)
+			b.WriteString(fmt.Sprintf("\t%s%s %s = %d\n", name, goIdentifier(c.Name), name, c.Value))
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString("// String returns the name of the case.\n")
+	b.WriteString(fmt.Sprintf("func (v %s) String() string {\n", name))
+	if len(unique) > 0 {
+		b.WriteString("\tswitch v {\n")
+		for _, c := range unique {
+			b.WriteString(fmt.Sprintf("\tcase %s%s:\n\t\treturn %q\n", name, goIdentifier(c.Name), c.Name))
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString(fmt.Sprintf("\treturn fmt.Sprintf(\"%s(%%d)\", uint32(v))\n", specName))
+	b.WriteString("}\n\n")
+
+	if isError {
+		b.WriteString("// Error implements error.\n")
+		b.WriteString(fmt.Sprintf("func (v %s) Error() string {\n", name))
+		b.WriteString(fmt.Sprintf("\treturn fmt.Sprintf(\"%s::%%s (#%%d)\", v.String(), uint32(v))\n", specName))
+		b.WriteString("}\n\n")
+
+		b.WriteString("// ToScVal encodes the enum as a contract error.\n")
+		b.WriteString(fmt.Sprintf("func (v %s) ToScVal() (xdr.ScVal, error) {\n", name))
+		b.WriteString("\treturn encodeContractError(uint32(v))\n")
+	} else {
+		b.WriteString("// ToScVal encodes the enum as its U32 value.\n")
+		b.WriteString(fmt.Sprintf("func (v %s) ToScVal() (xdr.ScVal, error) {\n", name))
+		b.WriteString("\treturn encodeU32(uint32(v))\n")
+	}
+	b.WriteString("}\n\n")
+
+	decode := "decodeU32"
+	if isError {
+		decode = "decodeContractError"
+	}
+	b.WriteString(fmt.Sprintf("// %sFromScVal decodes a %s, rejecting values that are not one of its cases.\n", name, name))
+	b.WriteString(fmt.Sprintf("func %sFromScVal(v xdr.ScVal) (%s, error) {\n", name, name))
+	b.WriteString(fmt.Sprintf("\tn, err := %s(v)\n", decode))
+	b.WriteString("\tif err != nil {\n\t\treturn 0, err\n\t}\n")
+	if len(unique) > 0 {
+		names := make([]string, len(unique))
+		for i, c := range unique {
+			names[i] = name + goIdentifier(c.Name)
+		}
+		b.WriteString(fmt.Sprintf("\tswitch c := %s(n); c {\n", name))
+		b.WriteString(fmt.Sprintf("\tcase %s:\n\t\treturn c, nil\n", strings.Join(names, ", ")))
+		b.WriteString("\t}\n")
+	}
+	b.WriteString(fmt.Sprintf("\treturn 0, fmt.Errorf(\"unknown %s case %%d\", n)\n", specName))
+	b.WriteString("}\n\n")
+}
+
+// generateGoUnion generates a union as an interface implemented by one
+// struct per case.
+func (g *Generator) generateGoUnion(b *strings.Builder, u xdr.ScSpecUdtUnionV0) {
+	name := goTypeName(u.Name)
+
+	writeGoTypeDoc(b, name, u.Name, u.Doc)
+	b.WriteString(fmt.Sprintf("type %s interface {\n", name))
+	b.WriteString("\tToScVal() (xdr.ScVal, error)\n")
+	b.WriteString(fmt.Sprintf("\tis%s()\n", name))
+	b.WriteString("}\n\n")
+
+	for _, c := range u.Cases {
+		caseName, doc, types := unionCaseParts(c)
+		typeName := name + goIdentifier(caseName)
+
+		if doc != "" {
+			writeGoDoc(b, "", doc)
+		} else {
+			b.WriteString(fmt.Sprintf("// %s is the %s case of %s.\n", typeName, caseName, name))
+		}
+		if len(types) == 0 {
+			b.WriteString(fmt.Sprintf("type %s struct{}\n\n", typeName))
+		} else {
+			b.WriteString(fmt.Sprintf("type %s struct {\n", typeName))
+			for i, t := range types {
+				b.WriteString(fmt.Sprintf("\tV%d %s\n", i, g.mapTypeDefToGo(t).name))
+			}
+			b.WriteString("}\n\n")
+		}
+
+		b.WriteString(fmt.Sprintf("func (%s) is%s() {}\n\n", typeName, name))
+
+		b.WriteString("// ToScVal encodes the case as a vector of its name and values.\n")
+		b.WriteString(fmt.Sprintf("func (v %s) ToScVal() (xdr.ScVal, error) {\n", typeName))
+		b.WriteString(fmt.Sprintf("\treturn encodeUnion(%q", caseName))
+		for i, t := range types {
+			b.WriteString(fmt.Sprintf(", elem(v.V%d, %s)", i, g.mapTypeDefToGo(t).encode))
+		}
+		b.WriteString(")\n}\n\n")
+	}
+
+	b.WriteString(fmt.Sprintf("// %sFromScVal decodes a %s into the type of its case.\n", name, name))
+	b.WriteString(fmt.Sprintf("func %sFromScVal(v xdr.ScVal) (%s, error) {\n", name, name))
+	b.WriteString("\tcaseName, err := unionCase(v)\n")
+	b.WriteString("\tif err != nil {\n")
+	b.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n", u.Name))
+	b.WriteString("\t}\n")
+	if len(u.Cases) > 0 {
+		b.WriteString("\tswitch caseName {\n")
+		seen := make(map[string]bool)
+		for _, c := range u.Cases {
+			caseName, _, types := unionCaseParts(c)
+			if seen[caseName] {
+				continue
+			}
+			seen[caseName] = true
+			typeName := name + goIdentifier(caseName)
+			b.WriteString(fmt.Sprintf("\tcase %q:\n", caseName))
+			b.WriteString(fmt.Sprintf("\t\tvar out %s\n", typeName))
+			b.WriteString("\t\tif err := decodeUnion(v")
+			for i, t := range types {
+				b.WriteString(fmt.Sprintf(", into(\"%d\", &out.V%d, %s)", i, i, g.mapTypeDefToGo(t).decode))
+			}
+			b.WriteString("); err != nil {\n")
+			b.WriteString(fmt.Sprintf("\t\t\treturn nil, fmt.Errorf(\"%s::%s: %%w\", err)\n", u.Name, caseName))
+			b.WriteString("\t\t}\n")
+			b.WriteString("\t\treturn out, nil\n")
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString(fmt.Sprintf("\treturn nil, fmt.Errorf(\"unknown %s case %%q\", caseName)\n", u.Name))
+	b.WriteString("}\n\n")
+}
+
+// generateGoClient generates the client, with a method per contract
+// function, and the mapping of contract error codes to error enums.
+func (g *Generator) generateGoClient() string {
+	var b strings.Builder
+
+	if g.config.ContractID != "" {
+		b.WriteString("// ContractID is the contract the bindings were generated for.\n")
+		b.WriteString(fmt.Sprintf("const ContractID = %q\n\n", g.config.ContractID))
+	}
+
+	b.WriteString(goSimulator)
+
+	b.WriteString("// Client invokes the functions of a contract by simulating transactions\n")
+	b.WriteString("// sent from Source, a G... or M... account.\n")
+	b.WriteString("type Client struct {\n")
+	b.WriteString("\tContractID string\n")
+	b.WriteString("\tSource     string\n")
+	b.WriteString("\tSimulator  Simulator\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// NewClient creates a client of the contract contractID.\n")
+	b.WriteString("func NewClient(contractID, source string, simulator Simulator) *Client {\n")
+	b.WriteString("\treturn &Client{ContractID: contractID, Source: source, Simulator: simulator}\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Simulation is a simulated invocation: the envelope simulated, the\n")
+	b.WriteString("// response, and the return value and events decoded from it.\n")
+	b.WriteString("type Simulation struct {\n")
+	b.WriteString("\tEnvelope string\n")
+	b.WriteString("\tResponse *SimulateTransactionResponse\n")
+	b.WriteString("\tResult   xdr.ScVal\n")
+	b.WriteString("\tEvents   []xdr.DiagnosticEvent\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Envelope builds the base64 transaction envelope of an InvokeHostFunction\n")
+	b.WriteString("// operation calling function with args.\n")
+	b.WriteString("func (c *Client) Envelope(function string, args ...xdr.ScVal) (string, error) {\n")
+	b.WriteString("\tcontract, err := encodeAddress(c.ContractID)\n")
+	b.WriteString("\tif err != nil {\n\t\treturn \"\", fmt.Errorf(\"contract: %w\", err)\n\t}\n")
+	b.WriteString("\tsource, err := xdr.AddressToMuxedAccount(c.Source)\n")
+	b.WriteString("\tif err != nil {\n\t\treturn \"\", fmt.Errorf(\"source: %w\", err)\n\t}\n")
+	b.WriteString("\ttx := xdr.Transaction{\n")
+	b.WriteString("\t\tSourceAccount: source,\n")
+	b.WriteString("\t\tFee:           100,\n")
+	b.WriteString("\t\tCond:          xdr.Preconditions{Type: xdr.PreconditionTypePrecondNone},\n")
+	b.WriteString("\t\tMemo:          xdr.Memo{Type: xdr.MemoTypeMemoNone},\n")
+	b.WriteString("\t\tOperations: []xdr.Operation{{\n")
+	b.WriteString("\t\t\tBody: xdr.OperationBody{\n")
+	b.WriteString("\t\t\t\tType: xdr.OperationTypeInvokeHostFunction,\n")
+	b.WriteString("\t\t\t\tInvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{\n")
+	b.WriteString("\t\t\t\t\tHostFunction: xdr.HostFunction{\n")
+	b.WriteString("\t\t\t\t\t\tType: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,\n")
+	b.WriteString("\t\t\t\t\t\tInvokeContract: &xdr.InvokeContractArgs{\n")
+	b.WriteString("\t\t\t\t\t\t\tContractAddress: *contract.Address,\n")
+	b.WriteString("\t\t\t\t\t\t\tFunctionName:    xdr.ScSymbol(function),\n")
+	b.WriteString("\t\t\t\t\t\t\tArgs:            args,\n")
+	b.WriteString("\t\t\t\t\t\t},\n")
+	b.WriteString("\t\t\t\t\t},\n")
+	b.WriteString("\t\t\t\t},\n")
+	b.WriteString("\t\t\t},\n")
+	b.WriteString("\t\t}},\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn xdr.MarshalBase64(xdr.TransactionEnvelope{\n")
+	b.WriteString("\t\tType: xdr.EnvelopeTypeEnvelopeTypeTx,\n")
+	b.WriteString("\t\tV1:   &xdr.TransactionV1Envelope{Tx: tx},\n")
+	b.WriteString("\t})\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Invoke simulates calling function with args. A failed simulation returns\n")
+	b.WriteString("// the contract's error enum value when it reports a contract error.\n")
+	b.WriteString("func (c *Client) Invoke(ctx context.Context, function string, args ...xdr.ScVal) (*Simulation, error) {\n")
+	b.WriteString("\tenvelope, err := c.Envelope(function, args...)\n")
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\tresp, err := c.Simulator.SimulateTransaction(ctx, envelope)\n")
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\tsim := &Simulation{Envelope: envelope, Response: resp}\n")
+	b.WriteString("\tfor _, e := range resp.Events {\n")
+	b.WriteString("\t\tvar ev xdr.DiagnosticEvent\n")
+	b.WriteString("\t\tif err := xdr.SafeUnmarshalBase64(e, &ev); err != nil {\n")
+	b.WriteString("\t\t\treturn sim, fmt.Errorf(\"decoding simulation event: %w\", err)\n")
+	b.WriteString("\t\t}\n")
+	b.WriteString("\t\tsim.Events = append(sim.Events, ev)\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif resp.Error != \"\" {\n")
+	b.WriteString("\t\treturn sim, simulationError(resp.Error)\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif len(resp.Results) == 0 {\n")
+	b.WriteString("\t\treturn sim, errors.New(\"simulation returned no result\")\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif err := xdr.SafeUnmarshalBase64(resp.Results[0].XDR, &sim.Result); err != nil {\n")
+	b.WriteString("\t\treturn sim, fmt.Errorf(\"decoding simulation result: %w\", err)\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn sim, nil\n")
+	b.WriteString("}\n\n")
+
+	g.generateGoContractError(&b)
+
+	for _, fn := range g.spec.Functions {
+		g.generateGoClientMethod(&b, fn)
+	}
+
+	return b.String()
+}
+
+// generateGoContractError maps contract error codes to the error enum
+// declaring them, the first one when several do.
+func (g *Generator) generateGoContractError(b *strings.Builder) {
+	b.WriteString("// contractError returns the error enum value of a contract error code.\n")
+	b.WriteString("func contractError(code uint32) error {\n")
+	seen := make(map[xdr.Uint32]bool)
+	var cases strings.Builder
+	for _, e := range g.spec.ErrorEnums {
+		for _, c := range e.Cases {
+			if seen[c.Value] {
+				continue
+			}
+			seen[c.Value] = true
+			cases.WriteString(fmt.Sprintf("\tcase %d:\n\t\treturn %s%s\n", c.Value, goTypeName(e.Name), goIdentifier(c.Name)))
+		}
+	}
+	if cases.Len() > 0 {
+		b.WriteString("\tswitch code {\n")
+		b.WriteString(cases.String())
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn fmt.Errorf(\"contract error #%d\", code)\n")
+	b.WriteString("}\n\n")
+}
+
+func (g *Generator) generateGoClientMethod(b *strings.Builder, fn xdr.ScSpecFunctionV0) {
+	name := goIdentifier(string(fn.Name))
+	if name == "Invoke" || name == "Envelope" {
+		name += "Func"
+	}
+
+	params := make([]string, len(fn.Inputs))
+	used := make(map[string]bool)
+	for i, input := range fn.Inputs {
+		p := goParamName(input.Name)
+		for used[p] {
+			p = fmt.Sprintf("%s%d", p, i)
+		}
+		used[p] = true
+		params[i] = p
+	}
+
+	// Result outputs return their Ok value: the error is a failed simulation.
+	var result *goType
+	if len(fn.Outputs) > 0 {
+		out := fn.Outputs[0]
+		if out.Type == xdr.ScSpecTypeScSpecTypeResult && out.Result != nil {
+			out = out.Result.OkType
+		}
+		if out.Type != xdr.ScSpecTypeScSpecTypeVoid {
+			t := g.mapTypeDefToGo(out)
+			result = &t
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("// %s simulates %s.\n", name, abi.FormatFunction(fn)))
+	if fn.Doc != "" {
+		b.WriteString("//\n")
+		writeGoDoc(b, "", fn.Doc)
+	}
+	b.WriteString(fmt.Sprintf("func (c *Client) %s(ctx context.Context", name))
+	for i, input := range fn.Inputs {
+		b.WriteString(fmt.Sprintf(", %s %s", params[i], g.mapTypeDefToGo(input.Type).name))
+	}
+	if result != nil {
+		b.WriteString(fmt.Sprintf(") (result %s, sim *Simulation, err error) {\n", result.name))
+	} else {
+		b.WriteString(") (sim *Simulation, err error) {\n")
+	}
+
+	fail := "nil, "
+	if result != nil {
+		fail = "result, nil, "
+	}
+	if len(fn.Inputs) > 0 {
+		b.WriteString(fmt.Sprintf("\targs := make([]xdr.ScVal, %d)\n", len(fn.Inputs)))
+		for i, input := range fn.Inputs {
+			t := g.mapTypeDefToGo(input.Type)
+			b.WriteString(fmt.Sprintf("\tif args[%d], err = %s(%s); err != nil {\n", i, t.encode, params[i]))
+			b.WriteString(fmt.Sprintf("\t\treturn %sfmt.Errorf(\"%s: %%w\", err)\n", fail, input.Name))
+			b.WriteString("\t}\n")
+		}
+	}
+
+	call := fmt.Sprintf("c.Invoke(ctx, %q)", string(fn.Name))
+	if len(fn.Inputs) > 0 {
+		call = fmt.Sprintf("c.Invoke(ctx, %q, args...)", string(fn.Name))
+	}
+	if result == nil {
+		b.WriteString(fmt.Sprintf("\treturn %s\n", call))
+		b.WriteString("}\n\n")
+		return
+	}
+	b.WriteString(fmt.Sprintf("\tif sim, err = %s; err != nil {\n", call))
+	b.WriteString("\t\treturn result, sim, err\n")
+	b.WriteString("\t}\n")
+	b.WriteString(fmt.Sprintf("\tif result, err = %s(sim.Result); err != nil {\n", result.decode))
+	b.WriteString("\t\treturn result, sim, fmt.Errorf(\"decoding result: %w\", err)\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\treturn result, sim, nil\n")
+	b.WriteString("}\n\n")
+}
+
+// generateGoEvents generates a type per contract event and DecodeEvent, or
+// nothing when the contract declares no events.
+func (g *Generator) generateGoEvents() string {
+	if len(g.spec.Events) == 0 {
+		return ""
+	}
+	var b strings.Builder
+
+	for _, ev := range g.spec.Events {
+		name := goTypeName(string(ev.Name)) + "Event"
+		if ev.Doc != "" {
+			writeGoDoc(&b, "", ev.Doc)
+		} else {
+			b.WriteString(fmt.Sprintf("// %s is the %s event.\n", name, ev.Name))
+		}
+		b.WriteString(fmt.Sprintf("type %s struct {\n", name))
+		for i, p := range ev.Params {
+			writeGoDoc(&b, "\t", p.Doc)
+			b.WriteString(fmt.Sprintf("\t%s %s\n", goFieldName(p.Name, i, false), g.mapTypeDefToGo(p.Type).name))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString("// ErrUnknownEvent is returned by DecodeEvent for events the contract spec\n")
+	b.WriteString("// does not describe.\n")
+	b.WriteString("var ErrUnknownEvent = errors.New(\"unknown event\")\n\n")
+
+	// Events are told apart by their prefix topics, the longest first, and
+	// events without any cannot be.
+	events := make([]xdr.ScSpecEventV0, 0, len(g.spec.Events))
+	for _, ev := range g.spec.Events {
+		if len(ev.PrefixTopics) > 0 {
+			events = append(events, ev)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return len(events[i].PrefixTopics) > len(events[j].PrefixTopics)
+	})
+
+	b.WriteString("// DecodeEvent decodes a contract event into the *Event type of the event it\n")
+	b.WriteString("// is, matched on its prefix topics and number of topics.\n")
+	b.WriteString("func DecodeEvent(ev xdr.ContractEvent) (any, error) {\n")
+	b.WriteString("\tbody, ok := ev.Body.GetV0()\n")
+	b.WriteString("\tif !ok {\n\t\treturn nil, ErrUnknownEvent\n\t}\n")
+	for _, ev := range events {
+		name := goTypeName(string(ev.Name)) + "Event"
+		var topics, data []string
+		for i, p := range ev.Params {
+			d := fmt.Sprintf("into(%q, &out.%s, %s)", p.Name, goFieldName(p.Name, i, false), g.mapTypeDefToGo(p.Type).decode)
+			if p.Location == xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationTopicList {
+				topics = append(topics, d)
+			} else {
+				data = append(data, d)
+			}
+		}
+		prefix := make([]string, len(ev.PrefixTopics))
+		for i, t := range ev.PrefixTopics {
+			prefix[i] = fmt.Sprintf("%q", string(t))
+		}
+
+		format := "dataSingleValue"
+		switch ev.DataFormat {
+		case xdr.ScSpecEventDataFormatScSpecEventDataFormatVec:
+			format = "dataVec"
+		case xdr.ScSpecEventDataFormatScSpecEventDataFormatMap:
+			format = "dataMap"
+		}
+
+		b.WriteString(fmt.Sprintf("\tif topics, ok := eventTopics(body.Topics, %d, %s); ok {\n", len(topics), strings.Join(prefix, ", ")))
+		b.WriteString(fmt.Sprintf("\t\tvar out %s\n", name))
+		b.WriteString(fmt.Sprintf("\t\terr := decodeEvent(topics, %s, body.Data, %s, %s)\n", goDecoders(topics), format, goDecoders(data)))
+		b.WriteString("\t\tif err != nil {\n")
+		b.WriteString(fmt.Sprintf("\t\t\treturn nil, fmt.Errorf(\"%s event: %%w\", err)\n", ev.Name))
+		b.WriteString("\t\t}\n")
+		b.WriteString("\t\treturn out, nil\n")
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn nil, ErrUnknownEvent\n")
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func goDecoders(decoders []string) string {
+	if len(decoders) == 0 {
+		return "nil"
+	}
+	return "[]decoder{" + strings.Join(decoders, ", ") + "}"
+}
+
+// mapTypeDefToGo maps a spec type to its Go type. Types with no natural Go
+// counterpart, such as nested results and tuples, are left as ScVal.
+func (g *Generator) mapTypeDefToGo(td xdr.ScSpecTypeDef) goType {
+	basic := func(name, conv string) goType {
+		return goType{name: name, encode: "encode" + conv, decode: "decode" + conv}
+	}
+
+	switch td.Type {
+	case xdr.ScSpecTypeScSpecTypeBool:
+		return basic("bool", "Bool")
+	case xdr.ScSpecTypeScSpecTypeU32:
+		return basic("uint32", "U32")
+	case xdr.ScSpecTypeScSpecTypeI32:
+		return basic("int32", "I32")
+	case xdr.ScSpecTypeScSpecTypeU64:
+		return basic("uint64", "U64")
+	case xdr.ScSpecTypeScSpecTypeI64:
+		return basic("int64", "I64")
+	case xdr.ScSpecTypeScSpecTypeTimepoint:
+		return basic("uint64", "Timepoint")
+	case xdr.ScSpecTypeScSpecTypeDuration:
+		return basic("uint64", "Duration")
+	case xdr.ScSpecTypeScSpecTypeU128:
+		return basic("*big.Int", "U128")
+	case xdr.ScSpecTypeScSpecTypeI128:
+		return basic("*big.Int", "I128")
+	case xdr.ScSpecTypeScSpecTypeU256:
+		return basic("*big.Int", "U256")
+	case xdr.ScSpecTypeScSpecTypeI256:
+		return basic("*big.Int", "I256")
+	case xdr.ScSpecTypeScSpecTypeBytes:
+		return basic("[]byte", "Bytes")
+	case xdr.ScSpecTypeScSpecTypeBytesN:
+		t := basic("[]byte", "Bytes")
+		if td.BytesN != nil {
+			t.decode = fmt.Sprintf("decodeBytesN(%d)", td.BytesN.N)
+		}
+		return t
+	case xdr.ScSpecTypeScSpecTypeString:
+		return basic("string", "String")
+	case xdr.ScSpecTypeScSpecTypeSymbol:
+		return basic("string", "Symbol")
+	case xdr.ScSpecTypeScSpecTypeAddress, xdr.ScSpecTypeScSpecTypeMuxedAddress:
+		return basic("string", "Address")
+	case xdr.ScSpecTypeScSpecTypeError:
+		return basic("xdr.ScError", "ScError")
+	case xdr.ScSpecTypeScSpecTypeOption:
+		if td.Option != nil {
+			inner := g.mapTypeDefToGo(td.Option.ValueType)
+			return goType{
+				name:   "*" + inner.name,
+				encode: fmt.Sprintf("encodeOption(%s)", inner.encode),
+				decode: fmt.Sprintf("decodeOption(%s)", inner.decode),
+			}
+		}
+	case xdr.ScSpecTypeScSpecTypeVec:
+		if td.Vec != nil {
+			elem := g.mapTypeDefToGo(td.Vec.ElementType)
+			return goType{
+				name:   "[]" + elem.name,
+				encode: fmt.Sprintf("encodeVec(%s)", elem.encode),
+				decode: fmt.Sprintf("decodeVec(%s)", elem.decode),
+			}
+		}
+	case xdr.ScSpecTypeScSpecTypeMap:
+		if td.Map != nil {
+			key := g.mapTypeDefToGo(td.Map.KeyType)
+			val := g.mapTypeDefToGo(td.Map.ValueType)
+			if g.isGoMapKey(td.Map.KeyType) {
+				return goType{
+					name:   fmt.Sprintf("map[%s]%s", key.name, val.name),
+					encode: fmt.Sprintf("encodeMap(%s, %s)", key.encode, val.encode),
+					decode: fmt.Sprintf("decodeMap(%s, %s)", key.decode, val.decode),
+				}
+			}
+			return goType{
+				name:   fmt.Sprintf("[]MapEntry[%s, %s]", key.name, val.name),
+				encode: fmt.Sprintf("encodeEntries(%s, %s)", key.encode, val.encode),
+				decode: fmt.Sprintf("decodeEntries(%s, %s)", key.decode, val.decode),
+			}
+		}
+	case xdr.ScSpecTypeScSpecTypeUdt:
+		if td.Udt != nil {
+			name := goTypeName(td.Udt.Name)
+			return goType{name: name, encode: fmt.Sprintf("encodeUdt[%s]", name), decode: name + "FromScVal"}
+		}
+	}
+	return basic("xdr.ScVal", "Val")
+}
+
+// isGoMapKey reports whether maps keyed by td can be Go maps: its Go type
+// must be comparable, with equal keys encoding to the same ScVal.
+func (g *Generator) isGoMapKey(td xdr.ScSpecTypeDef) bool {
+	switch td.Type {
+	case xdr.ScSpecTypeScSpecTypeBool,
+		xdr.ScSpecTypeScSpecTypeU32, xdr.ScSpecTypeScSpecTypeI32,
+		xdr.ScSpecTypeScSpecTypeU64, xdr.ScSpecTypeScSpecTypeI64,
+		xdr.ScSpecTypeScSpecTypeTimepoint, xdr.ScSpecTypeScSpecTypeDuration,
+		xdr.ScSpecTypeScSpecTypeString, xdr.ScSpecTypeScSpecTypeSymbol,
+		xdr.ScSpecTypeScSpecTypeAddress, xdr.ScSpecTypeScSpecTypeMuxedAddress:
+		return true
+	case xdr.ScSpecTypeScSpecTypeUdt:
+		if td.Udt == nil {
+			return false
+		}
+		for _, e := range g.spec.Enums {
+			if e.Name == td.Udt.Name {
+				return true
+			}
+		}
+		for _, e := range g.spec.ErrorEnums {
+			if e.Name == td.Udt.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTupleStruct reports whether a struct is a tuple struct, its fields
+// named by position.
+func isTupleStruct(s xdr.ScSpecUdtStructV0) bool {
+	if len(s.Fields) == 0 {
+		return false
+	}
+	for i, f := range s.Fields {
+		if f.Name != fmt.Sprintf("%d", i) {
+			return false
+		}
+	}
+	return true
+}
+
+func unionCaseParts(c xdr.ScSpecUdtUnionCaseV0) (name, doc string, types []xdr.ScSpecTypeDef) {
+	switch c.Kind {
+	case xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0:
+		return c.VoidCase.Name, c.VoidCase.Doc, nil
+	default:
+		return c.TupleCase.Name, c.TupleCase.Doc, c.TupleCase.Type
+	}
+}
+
+// goIdentifier converts a contract name, usually snake_case, to an exported
+// Go identifier. Unlike toPascalCase, it keeps the case of the rest of each
+// word, so type names such as DataKey are unchanged.
+func goIdentifier(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "V" + id
+	}
+	return id
+}
+
+// goTypeName is the Go name of a contract type, which must not clash with
+// the names the generated package declares itself.
+func goTypeName(s string) string {
+	name := goIdentifier(s)
+	if goReservedNames[name] {
+		name += "Type"
+	}
+	return name
+}
+
+// goFieldName is the Go name of a struct field or event parameter; fields
+// of tuple structs are named after their position, as union case values.
+func goFieldName(s string, i int, tuple bool) string {
+	if tuple {
+		return fmt.Sprintf("V%d", i)
+	}
+	return goIdentifier(s)
+}
+
+// goParamName is the Go name of a function parameter.
+func goParamName(s string) string {
+	r := []rune(goIdentifier(s))
+	r[0] = unicode.ToLower(r[0])
+	name := string(r)
+	if token.IsKeyword(name) || goReservedParams[name] {
+		name += "Arg"
+	}
+	return name
+}
+
+// goPackageName derives a Go package name from the bindings package name.
+func goPackageName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || token.IsKeyword(name) {
+		return "contract"
+	}
+	return name
+}
+
+// writeGoTypeDoc writes the doc comment of the Go type of a contract type:
+// its spec doc, or a line naming it when it has none.
+func writeGoTypeDoc(b *strings.Builder, name, specName, doc string) {
+	if strings.TrimSpace(doc) == "" {
+		doc = fmt.Sprintf("%s is the contract type %s.", name, specName)
+	}
+	writeGoDoc(b, "", doc)
+}
+
+// writeGoDoc writes a spec doc string as a Go comment.
+func writeGoDoc(b *strings.Builder, indent, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			b.WriteString(indent + "//\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s// %s\n", indent, line))
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package bindings
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// goTestSpec covers every kind of generated declaration.
+func goTestSpec() *abi.ContractSpec {
+	return &abi.ContractSpec{
+		Functions: []xdr.ScSpecFunctionV0{
+			{
+				Name: "transfer",
+				Inputs: []xdr.ScSpecFunctionInputV0{
+					{Name: "from", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeAddress}},
+					{Name: "type", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeI128}},
+				},
+				Outputs: []xdr.ScSpecTypeDef{
+					{
+						Type: xdr.ScSpecTypeScSpecTypeResult,
+						Result: &xdr.ScSpecTypeResult{
+							OkType:    xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeU64},
+							ErrorType: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeUdt, Udt: &xdr.ScSpecTypeUdt{Name: "Error"}},
+						},
+					},
+				},
+			},
+		},
+		Structs: []xdr.ScSpecUdtStructV0{
+			{
+				Name: "TokenInfo",
+				Fields: []xdr.ScSpecUdtStructFieldV0{
+					{Name: "max_supply", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeU128}},
+				},
+			},
+		},
+		Unions: []xdr.ScSpecUdtUnionV0{
+			{
+				Name: "DataKey",
+				Cases: []xdr.ScSpecUdtUnionCaseV0{
+					{
+						Kind:      xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0,
+						TupleCase: &xdr.ScSpecUdtUnionCaseTupleV0{Name: "Balance", Type: []xdr.ScSpecTypeDef{{Type: xdr.ScSpecTypeScSpecTypeAddress}}},
+					},
+				},
+			},
+		},
+		ErrorEnums: []xdr.ScSpecUdtErrorEnumV0{
+			{
+				Name:  "Error",
+				Cases: []xdr.ScSpecUdtErrorEnumCaseV0{{Name: "NotFound", Value: 1}},
+			},
+		},
+		Events: []xdr.ScSpecEventV0{
+			{
+				Name:         "transfer",
+				PrefixTopics: []xdr.ScSymbol{"transfer"},
+				Params: []xdr.ScSpecEventParamV0{
+					{Name: "from", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeAddress}, Location: xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationTopicList},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	generator := &Generator{
+		config: GeneratorConfig{PackageName: "test-contract", Language: "go"},
+		spec:   goTestSpec(),
+	}
+	files, err := generator.generateGo()
+	if err != nil {
+		t.Fatalf("generateGo() error = %v", err)
+	}
+
+	contents := make(map[string]string)
+	for _, f := range files {
+		contents[f.Path] = f.Content
+	}
+	for _, path := range []string{"types.go", "client.go", "events.go", "scval.go"} {
+		if !strings.Contains(contents[path], "package testcontract\n") {
+			t.Errorf("%s: missing package clause", path)
+		}
+	}
+
+	for path, content := range contents {
+		if strings.Contains(content, "github.com/dotandev/hintents/") {
+			t.Errorf("%s: imports a package of this module", path)
+		}
+	}
+
+	checks := map[string][]string{
+		"types.go": {
+			"type TokenInfo struct {\n\tMaxSupply *big.Int\n}",
+			`field("max_supply", v.MaxSupply, encodeU128)`,
+			"type DataKey interface {",
+			"type DataKeyBalance struct {\n\tV0 string\n}",
+			"ErrorNotFound Error = 1",
+			"func ErrorFromScVal(v xdr.ScVal) (Error, error) {",
+		},
+		"client.go": {
+			"func (s *RPCSimulator) SimulateTransaction(ctx context.Context, envelopeXdr string) (*SimulateTransactionResponse, error) {",
+			"func (c *Client) Transfer(ctx context.Context, from string, typeArg *big.Int) (result uint64, sim *Simulation, err error) {",
+			"case 1:\n\t\treturn ErrorNotFound",
+		},
+		"events.go": {
+			"type TransferEvent struct {\n\tFrom string\n}",
+			`eventTopics(body.Topics, 1, "transfer")`,
+		},
+	}
+	for path, want := range checks {
+		for _, s := range want {
+			if !strings.Contains(contents[path], s) {
+				t.Errorf("%s: missing %q", path, s)
+			}
+		}
+	}
+}
+
+// TestGenerateGoBuildsOutsideModule builds generated bindings as a module of
+// their own, the way users consume them.
+func TestGenerateGoBuildsOutsideModule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a separate module")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+	sdk, err := exec.Command(goBin, "list", "-m", "-f", "{{.Version}}", "github.com/stellar/go-stellar-sdk").Output()
+	if err != nil {
+		t.Skipf("cannot resolve the go-stellar-sdk version: %v", err)
+	}
+
+	generator := &Generator{
+		config: GeneratorConfig{PackageName: "token", Language: "go"},
+		spec:   goTestSpec(),
+	}
+	files, err := generator.generateGo()
+	if err != nil {
+		t.Fatalf("generateGo() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.Path), []byte(f.Content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+	if out, err := run("mod", "init", "example.com/token"); err != nil {
+		t.Fatalf("go mod init: %v\n%s", err, out)
+	}
+	if out, err := run("mod", "edit", "-require=github.com/stellar/go-stellar-sdk@"+strings.TrimSpace(string(sdk))); err != nil {
+		t.Fatalf("go mod edit: %v\n%s", err, out)
+	}
+	if out, err := run("mod", "tidy"); err != nil {
+		t.Skipf("cannot resolve dependencies of the generated module: %v\n%s", err, out)
+	}
+	if out, err := run("vet", "./..."); err != nil {
+		t.Fatalf("generated bindings do not build outside this module: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateUnsupportedLanguage(t *testing.T) {
+	_, err := NewGenerator(GeneratorConfig{Language: "rust"}).Generate()
+	if err == nil || !strings.Contains(err.Error(), "unsupported language") {
+		t.Errorf("Generate() error = %v, want unsupported language", err)
+	}
+}
+
+func TestGoIdentifier(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"max_supply", "MaxSupply"},
+		{"DataKey", "DataKey"},
+		{"0", "V0"},
+		{"set-admin", "SetAdmin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := goIdentifier(tt.input)
+			if result != tt.expected {
+				t.Errorf("goIdentifier(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package callgraph builds the static call graph of a WASM contract from its
+// code section, with the same walker the optimizer uses to find live
+// functions. Imported Soroban host functions are resolved to their names,
+// and every function is marked with the security-relevant host operations
+// it can reach: authorization checks, storage writes, token transfers and
+// cross-contract calls.
+package callgraph
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/dotandev/hintents/internal/demangle"
+	"github.com/dotandev/hintents/internal/wasmopt"
+	"github.com/dotandev/hintents/internal/wat"
+)
+
+// Capability is a security-relevant operation a function can reach.
+type Capability string
+
+const (
+	RequireAuth   Capability = "require_auth"
+	StorageWrite  Capability = "storage_write"
+	TokenTransfer Capability = "token_transfer"
+	ContractCall  Capability = "contract_call"
+)
+
+// capabilityOrder is the order capabilities are listed in.
+var capabilityOrder = []Capability{RequireAuth, StorageWrite, TokenTransfer, ContractCall}
+
+// hostCapabilities maps the host functions that grant a capability to it.
+var hostCapabilities = map[string]Capability{
+	"require_auth":          RequireAuth,
+	"require_auth_for_args": RequireAuth,
+	"put_contract_data":     StorageWrite,
+	"del_contract_data":     StorageWrite,
+	"call":                  ContractCall,
+	"try_call":              ContractCall,
+}
+
+// transferSymbol is the Val of the symbol "transfer", the token interface
+// function a contract names when it calls a token contract to move funds.
+var transferSymbol = smallSymbol("transfer")
+
+// Function is a node of the graph.
+type Function struct {
+	Index uint32 `json:"index"`
+	// Name is the host function name of Soroban imports, otherwise the
+	// demangled name from the name section, the import name or func[N].
+	Name string `json:"name"`
+	// Import is "module.field" for imported functions.
+	Import  string   `json:"import,omitempty"`
+	Exports []string `json:"exports,omitempty"`
+	// Calls are the functions called directly.
+	Calls []uint32 `json:"calls,omitempty"`
+	// IndirectCalls are the functions an indirect call may reach: those
+	// placed in a table or referenced whose type matches the call.
+	IndirectCalls []uint32 `json:"indirect_calls,omitempty"`
+	// Reaches lists the capabilities reachable from the function,
+	// through any chain of calls.
+	Reaches []Capability `json:"reaches,omitempty"`
+}
+
+// Imported reports whether the function is imported.
+func (f *Function) Imported() bool {
+	return f.Import != ""
+}
+
+// Exported reports whether the function is exported.
+func (f *Function) Exported() bool {
+	return len(f.Exports) > 0
+}
+
+// ReachesCapability reports whether the function reaches capability c.
+func (f *Function) ReachesCapability(c Capability) bool {
+	for _, r := range f.Reaches {
+		if r == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Graph is the call graph of a module, indexed by function index.
+type Graph struct {
+	Functions []Function `json:"functions"`
+}
+
+// Build decodes wasm and builds its call graph.
+//
+// Token transfers are recognized by the "transfer" symbol: a function that
+// builds it and reaches a cross-contract call is taken to call a token's
+// transfer. Calls that build the symbol elsewhere, or name the function
+// through a symbol built from linear memory, are only seen as contract calls.
+func Build(wasm []byte) (*Graph, error) {
+	m, err := wasmopt.Parse(wasm)
+	if err != nil {
+		return nil, err
+	}
+	taken, err := m.AddressTaken()
+	if err != nil {
+		return nil, err
+	}
+	names := m.FunctionNames()
+	exported := m.ExportedFuncs()
+	imported := m.ImportedFuncs()
+
+	total := imported + uint32(len(m.Code))
+	g := &Graph{Functions: make([]Function, total)}
+	own := make([]Capability, total)
+	transferSite := make([]bool, total)
+	for idx := uint32(0); idx < total; idx++ {
+		f := &g.Functions[idx]
+		f.Index = idx
+		f.Exports = exported[idx]
+		if imp, ok := m.FuncImport(idx); ok {
+			f.Import = imp.Module + "." + imp.Name
+			f.Name, _ = wat.HostFunctionName(imp.Module, imp.Name)
+			own[idx] = hostCapabilities[f.Name]
+		}
+		if f.Name == "" && names[idx] != "" {
+			f.Name = demangle.DemangleSymbol(names[idx])
+		}
+		if f.Name == "" && f.Imported() {
+			f.Name = f.Import
+		}
+		if f.Name == "" {
+			f.Name = fmt.Sprintf("func[%d]", idx)
+		}
+		if f.Imported() {
+			continue
+		}
+
+		scan, err := m.ScanFunction(int(idx - imported))
+		if err != nil {
+			return nil, err
+		}
+		f.Calls = unique(scan.Calls)
+		var targets []uint32
+		for _, typ := range scan.IndirectCalls {
+			for candidate := range taken {
+				if sameType(m, candidate, typ) {
+					targets = append(targets, candidate)
+				}
+			}
+		}
+		f.IndirectCalls = unique(targets)
+		for _, v := range scan.I64Consts {
+			if uint64(v) == transferSymbol {
+				transferSite[idx] = true
+			}
+		}
+	}
+
+	g.resolveCapabilities(own, transferSite)
+	return g, nil
+}
+
+// resolveCapabilities sets Reaches on every function from the capabilities
+// the host functions grant.
+func (g *Graph) resolveCapabilities(own []Capability, transferSite []bool) {
+	reachable := make([][]uint32, len(g.Functions))
+	caps := make([]map[Capability]bool, len(g.Functions))
+	for idx := range g.Functions {
+		reachable[idx] = g.reachable(uint32(idx))
+		caps[idx] = make(map[Capability]bool)
+		for _, r := range reachable[idx] {
+			if own[r] != "" {
+				caps[idx][own[r]] = true
+			}
+		}
+	}
+	for idx := range g.Functions {
+		for _, r := range reachable[idx] {
+			if transferSite[r] && caps[r][ContractCall] {
+				caps[idx][TokenTransfer] = true
+			}
+		}
+		for _, c := range capabilityOrder {
+			if caps[idx][c] {
+				g.Functions[idx].Reaches = append(g.Functions[idx].Reaches, c)
+			}
+		}
+	}
+}
+
+// reachable returns the functions reachable from idx, idx included.
+func (g *Graph) reachable(idx uint32) []uint32 {
+	seen := map[uint32]bool{idx: true}
+	order := []uint32{idx}
+	for i := 0; i < len(order); i++ {
+		f := &g.Functions[order[i]]
+		for _, callees := range [][]uint32{f.Calls, f.IndirectCalls} {
+			for _, c := range callees {
+				if int(c) < len(g.Functions) && !seen[c] {
+					seen[c] = true
+					order = append(order, c)
+				}
+			}
+		}
+	}
+	return order
+}
+
+func sameType(m *wasmopt.Module, fn, typ uint32) bool {
+	got, ok := m.FuncType(fn)
+	if !ok || int(got) >= len(m.Types) || int(typ) >= len(m.Types) {
+		return false
+	}
+	return got == typ || bytes.Equal(m.Types[got], m.Types[typ])
+}
+
+func unique(idx []uint32) []uint32 {
+	if len(idx) == 0 {
+		return nil
+	}
+	seen := make(map[uint32]bool, len(idx))
+	var out []uint32
+	for _, i := range idx {
+		if !seen[i] {
+			seen[i] = true
+			out = append(out, i)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a] < out[b] })
+	return out
+}
+
+// smallSymbol encodes s, at most 9 characters of [_0-9A-Za-z], as a Soroban
+// SymbolSmall Val: 6 bits per character above an 8-bit tag.
+func smallSymbol(s string) uint64 {
+	const tagSymbolSmall = 14
+	var body uint64
+	for _, c := range s {
+		var code uint64
+		switch {
+		case c == '_':
+			code = 1
+		case c >= '0' && c <= '9':
+			code = 2 + uint64(c-'0')
+		case c >= 'A' && c <= 'Z':
+			code = 12 + uint64(c-'A')
+		default:
+			code = 38 + uint64(c-'a')
+		}
+		body = body<<6 | code
+	}
+	return body<<8 | tagSymbolSmall
+}
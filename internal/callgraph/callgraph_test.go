@@ -0,0 +1,172 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package callgraph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func section(id byte, items ...[]byte) []byte {
+	payload := uleb(uint64(len(items)))
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func join(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func body(instrs ...[]byte) []byte {
+	b := append([]byte{0x00}, join(instrs...)...)
+	b = append(b, 0x0b)
+	return append(uleb(uint64(len(b))), b...)
+}
+
+func call(idx uint32) []byte { return append([]byte{0x10}, uleb(uint64(idx))...) }
+
+func i64Const(v uint64) []byte { return append([]byte{0x42}, sleb(int64(v))...) }
+
+// buildContract builds a token-like contract:
+//
+//	0 require_auth (a.0)      3 transfer: require_auth, helper 5
+//	1 put_contract_data (l._) 4 pay: "transfer" symbol, call
+//	2 call (d._)              5 helper: put_contract_data
+//	                          6 dispatch: call_indirect to 7
+//	                          7 guarded: require_auth
+func buildContract() []byte {
+	wasm := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	wasm = append(wasm, section(1,
+		[]byte{0x60, 0x01, 0x7e, 0x01, 0x7e},             // 0: (i64) -> i64
+		[]byte{0x60, 0x03, 0x7e, 0x7e, 0x7e, 0x01, 0x7e}, // 1: (i64 i64 i64) -> i64
+		[]byte{0x60, 0x00, 0x00},                         // 2: () -> ()
+	)...)
+	wasm = append(wasm, section(2,
+		join(name("a"), name("0"), []byte{0x00, 0x00}),
+		join(name("l"), name("_"), []byte{0x00, 0x01}),
+		join(name("d"), name("_"), []byte{0x00, 0x01}),
+	)...)
+	wasm = append(wasm, section(3, []byte{0x02}, []byte{0x02}, []byte{0x02}, []byte{0x02}, []byte{0x02})...)
+	wasm = append(wasm, section(4, []byte{0x70, 0x00, 0x01})...)
+	wasm = append(wasm, section(7,
+		join(name("transfer"), []byte{0x00, 0x03}),
+		join(name("pay"), []byte{0x00, 0x04}),
+		join(name("dispatch"), []byte{0x00, 0x06}),
+	)...)
+	wasm = append(wasm, section(9, join([]byte{0x00, 0x41, 0x00, 0x0b}, uleb(1), uleb(7)))...)
+	wasm = append(wasm, section(10,
+		body(i64Const(0), call(0), []byte{0x1a}, call(5)),
+		body(i64Const(0), i64Const(transferSymbol), i64Const(0), call(2), []byte{0x1a}),
+		body(i64Const(0), i64Const(0), i64Const(0), call(1), []byte{0x1a}),
+		body([]byte{0x41, 0x00, 0x11, 0x02, 0x00}),
+		body(i64Const(0), call(0), []byte{0x1a}),
+	)...)
+	names := section(1, join(uleb(5), name("_ZN8contract6helper17h0123456789abcdefE")))
+	return append(wasm, join([]byte{0x00}, uleb(uint64(len(names)+5)), name("name"), names)...)
+}
+
+func TestBuild(t *testing.T) {
+	g, err := Build(buildContract())
+	require.NoError(t, err)
+	require.Len(t, g.Functions, 8)
+
+	auth := g.Functions[0]
+	assert.Equal(t, "require_auth", auth.Name)
+	assert.Equal(t, "a.0", auth.Import)
+	assert.Equal(t, []Capability{RequireAuth}, auth.Reaches)
+
+	transfer := g.Functions[3]
+	assert.Equal(t, []string{"transfer"}, transfer.Exports)
+	assert.Equal(t, []uint32{0, 5}, transfer.Calls)
+	assert.Equal(t, []Capability{RequireAuth, StorageWrite}, transfer.Reaches)
+
+	pay := g.Functions[4]
+	assert.Equal(t, []Capability{TokenTransfer, ContractCall}, pay.Reaches)
+
+	helper := g.Functions[5]
+	assert.Equal(t, "contract::helper", helper.Name)
+	assert.False(t, helper.Exported())
+	assert.Equal(t, []Capability{StorageWrite}, helper.Reaches)
+
+	dispatch := g.Functions[6]
+	assert.Empty(t, dispatch.Calls)
+	assert.Equal(t, []uint32{7}, dispatch.IndirectCalls)
+	assert.True(t, dispatch.ReachesCapability(RequireAuth))
+
+	assert.Equal(t, "func[7]", g.Functions[7].Name)
+}
+
+func TestBuild_Invalid(t *testing.T) {
+	_, err := Build([]byte("not wasm"))
+	assert.Error(t, err)
+}
+
+func TestSmallSymbol(t *testing.T) {
+	// Symbol::short("a") in the Soroban SDK: code 38 above the tag.
+	assert.Equal(t, uint64(38<<8|14), smallSymbol("a"))
+	assert.Equal(t, uint64((1<<6|2)<<8|14), smallSymbol("_0"))
+}
+
+func TestRender(t *testing.T) {
+	g, err := Build(buildContract())
+	require.NoError(t, err)
+
+	dot := g.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph callgraph {\n"))
+	assert.Contains(t, dot, `f0 [label="require_auth\nimport a.0", shape=ellipse];`)
+	assert.Contains(t, dot, `f3 [label="func[3]\nexport transfer\nreaches require_auth, storage_write", style="bold,filled", fillcolor=lightsalmon];`)
+	assert.Contains(t, dot, "f3 -> f5;\n")
+	assert.Contains(t, dot, "f6 -> f7 [style=dashed];\n")
+
+	mermaid := g.Mermaid()
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"))
+	assert.Contains(t, mermaid, `f0(["require_auth<br/>import a.0"])`)
+	assert.Contains(t, mermaid, "f6 -.-> f7\n")
+	assert.Contains(t, mermaid, "class f3,f4,f6 sensitive\n")
+
+	out, err := g.JSON()
+	require.NoError(t, err)
+	var decoded Graph
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, g, &decoded)
+}
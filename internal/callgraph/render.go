@@ -0,0 +1,120 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package callgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DOT renders the graph in Graphviz format. Host imports are ellipses,
+// exported functions bold and, when they reach a capability, filled and
+// labelled with the capabilities. Indirect calls are dashed.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph callgraph {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	for i := range g.Functions {
+		f := &g.Functions[i]
+		attrs := []string{"label=" + dotQuote(f.labelLines())}
+		switch {
+		case f.Imported():
+			attrs = append(attrs, "shape=ellipse")
+		case f.Exported() && len(f.Reaches) > 0:
+			attrs = append(attrs, `style="bold,filled"`, "fillcolor=lightsalmon")
+		case f.Exported():
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&b, "  f%d [%s];\n", f.Index, strings.Join(attrs, ", "))
+	}
+	for i := range g.Functions {
+		f := &g.Functions[i]
+		for _, c := range f.Calls {
+			fmt.Fprintf(&b, "  f%d -> f%d;\n", f.Index, c)
+		}
+		for _, c := range f.IndirectCalls {
+			fmt.Fprintf(&b, "  f%d -> f%d [style=dashed];\n", f.Index, c)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart that can be pasted into
+// Markdown, styled like DOT.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	var sensitive []string
+	for i := range g.Functions {
+		f := &g.Functions[i]
+		label := escapeMermaidLabel(strings.Join(f.labelLines(), "<br/>"))
+		if f.Imported() {
+			fmt.Fprintf(&b, "  f%d([\"%s\"])\n", f.Index, label)
+		} else {
+			fmt.Fprintf(&b, "  f%d[\"%s\"]\n", f.Index, label)
+		}
+		if f.Exported() && len(f.Reaches) > 0 {
+			sensitive = append(sensitive, fmt.Sprintf("f%d", f.Index))
+		}
+	}
+	for i := range g.Functions {
+		f := &g.Functions[i]
+		for _, c := range f.Calls {
+			fmt.Fprintf(&b, "  f%d --> f%d\n", f.Index, c)
+		}
+		for _, c := range f.IndirectCalls {
+			fmt.Fprintf(&b, "  f%d -.-> f%d\n", f.Index, c)
+		}
+	}
+	if len(sensitive) > 0 {
+		b.WriteString("  classDef sensitive fill:#fa8072,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s sensitive\n", strings.Join(sensitive, ","))
+	}
+	return b.String()
+}
+
+// JSON renders the graph as indented JSON.
+func (g *Graph) JSON() (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// labelLines returns the lines labelling a function: its name, its import
+// or exports, and for exported functions the capabilities they reach.
+func (f *Function) labelLines() []string {
+	lines := []string{f.Name}
+	if f.Imported() && f.Import != f.Name {
+		lines = append(lines, "import "+f.Import)
+	}
+	if f.Exported() {
+		lines = append(lines, "export "+strings.Join(f.Exports, ", "))
+		if len(f.Reaches) > 0 {
+			caps := make([]string, len(f.Reaches))
+			for i, c := range f.Reaches {
+				caps[i] = string(c)
+			}
+			lines = append(lines, "reaches "+strings.Join(caps, ", "))
+		}
+	}
+	return lines
+}
+
+// dotQuote formats lines as a quoted DOT string.
+func dotQuote(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(l)
+	}
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
+
+func escapeMermaidLabel(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/archive"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/spf13/cobra"
+)
+
+// archiveFlag is the shared --archive flag. Commands that look up a
+// transaction bind it to read the transaction from a local history archive
+// instead of the RPC provider.
+var archiveFlag string
+
+// registerArchiveFlag adds --archive to cmd.
+func registerArchiveFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&archiveFlag, "archive", "",
+		"Read the transaction from a local Stellar history archive mirror instead of RPC")
+}
+
+// transactionSource returns the source of transactions: the history archive
+// named by --archive when set, and client otherwise. The archive's network
+// passphrase defaults to the client's network.
+func transactionSource(client *rpc.Client) (rpc.TransactionSource, error) {
+	if archiveFlag == "" {
+		return client, nil
+	}
+	a, err := archive.Open(archiveFlag, client.GetNetworkPassphrase())
+	if err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to open history archive: %v", err))
+	}
+	return a, nil
+}
+
+// transactionSourceName names the source of transactions in progress output.
+func transactionSourceName(network string) string {
+	if archiveFlag != "" {
+		return archiveFlag
+	}
+	return network
+}
+
+// replayResultMeta returns the result meta to replay txResp with.
+func replayResultMeta(txResp *rpc.TransactionResponse) string {
+	if txResp.ResultMetaXdr == "" {
+		return simulator.PlaceholderResultMeta
+	}
+	return txResp.ResultMetaXdr
+}
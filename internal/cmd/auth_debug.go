@@ -49,7 +49,7 @@ Examples:
 			opts = append(opts, rpc.WithHorizonURL(authRPCURLFlag))
 		}
 
-		client, err := rpc.NewClient(opts...)
+		client, err := newRPCClient(opts...)
 		if err != nil {
 			return errors.WrapValidationError(fmt.Sprintf("failed to create client: %v", err))
 		}
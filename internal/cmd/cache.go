@@ -11,7 +11,9 @@ import (
 
 	"github.com/dotandev/hintents/internal/cache"
 	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/logger"
 	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +33,39 @@ func getCacheDir() string {
 	return filepath.Join(homeDir, ".erst", "cache")
 }
 
+// newCachedRunner wraps runner in the simulation result cache unless
+// --no-sim-cache is set. If the cache cannot be opened the bare runner is
+// returned, so caching never blocks a simulation.
+func newCachedRunner(runner simulator.RunnerInterface) simulator.RunnerInterface {
+	if NoSimCacheFlag {
+		return runner
+	}
+
+	var binaryPath string
+	switch r := runner.(type) {
+	case *simulator.Runner:
+		binaryPath = r.BinaryPath
+	case *simulator.PoolRunner:
+		binaryPath = r.BinaryPath
+	default:
+		return runner
+	}
+
+	simVersion, err := simulator.SimBinaryVersion(binaryPath)
+	if err != nil {
+		logger.Logger.Warn("Simulation result cache disabled", "error", err)
+		return runner
+	}
+
+	resultCache, err := simulator.OpenResultCache(0, 0)
+	if err != nil {
+		logger.Logger.Warn("Simulation result cache disabled", "error", err)
+		return runner
+	}
+
+	return simulator.NewCachingRunner(runner, resultCache, simVersion)
+}
+
 var cacheCmd = &cobra.Command{
 	Use:     "cache",
 	GroupID: "management",
@@ -40,6 +75,10 @@ Caching improves performance and enables offline analysis.
 
 Cache location: ~/.erst/cache (configurable via ERST_CACHE_DIR)
 
+Simulation results are cached in ~/.erst/cache.db, keyed by a hash of the
+request, the erst-sim build and the protocol version. Use --no-sim-cache to
+bypass it for a single run.
+
 Available subcommands:
   status  - View cache size and usage statistics
   clean   - Remove old files using LRU strategy
@@ -89,6 +128,20 @@ var cacheStatusCmd = &cobra.Command{
 			fmt.Printf("\n[!]  Cache size exceeds maximum limit. Run 'erst cache clean' to free space.\n")
 		}
 
+		resultCache, err := simulator.OpenResultCache(0, 0)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to open simulation result cache: %v", err))
+		}
+		defer resultCache.Close()
+
+		stats, err := resultCache.Stats()
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to read simulation result cache: %v", err))
+		}
+
+		fmt.Printf("\nSimulation results: %d (%d expired)\n", stats.Entries, stats.Expired)
+		fmt.Printf("Simulation results size: %s / %s\n", formatBytes(stats.SizeBytes), formatBytes(stats.MaxBytes))
+
 		return nil
 	},
 }
@@ -102,6 +155,7 @@ This command will:
   1. Identify the oldest cached files
   2. Prompt for confirmation before deletion
   3. Delete files until cache size is reduced to 50% of maximum
+  4. Evict expired simulation results and trim them to their size cap
 
 Use --force to skip the confirmation prompt.`,
 	Example: `  # Clean cache with confirmation
@@ -123,6 +177,18 @@ Use --force to skip the confirmation prompt.`,
 			fmt.Println("No files needed to be deleted")
 		}
 
+		resultCache, err := simulator.OpenResultCache(0, 0)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to open simulation result cache: %v", err))
+		}
+		defer resultCache.Close()
+
+		removed, err := resultCache.Prune()
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("simulation result cache cleanup failed: %v", err))
+		}
+		fmt.Printf("%d simulation results evicted.\n", removed)
+
 		return nil
 	},
 }
@@ -167,6 +233,16 @@ var cacheClearCmd = &cobra.Command{
 			return errors.WrapValidationError(fmt.Sprintf("failed to clear cache directory: %v", err))
 		}
 
+		resultCache, err := simulator.OpenResultCache(0, 0)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to open simulation result cache: %v", err))
+		}
+		_, err = resultCache.Clear()
+		resultCache.Close()
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to clear simulation result cache: %v", err))
+		}
+
 		fmt.Println("Cache cleared successfully")
 		return nil
 	},
@@ -189,7 +265,6 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %s", size, units[unitIndex])
 }
 
-
 var cacheCleanRPCCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Prune the local SQLite RPC fetch cache by date or network",
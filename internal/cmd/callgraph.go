@@ -0,0 +1,89 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dotandev/hintents/internal/callgraph"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/wasmopt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	callgraphFormat string
+	callgraphOutput string
+)
+
+var callgraphCmd = &cobra.Command{
+	Use:     "callgraph <wasm-file>",
+	GroupID: "utility",
+	Short:   "Build the static call graph of a WASM contract",
+	Long: `Build a function-level call graph from the code section of a compiled
+contract. Imported Soroban host functions are shown by name, and every exported
+function is marked with the security-relevant operations it can reach:
+
+  require_auth     require_auth or require_auth_for_args
+  storage_write    put_contract_data or del_contract_data
+  token_transfer   a cross-contract call naming the token "transfer" function
+  contract_call    call or try_call into another contract
+
+Indirect calls are resolved to every function placed in a table whose type
+matches, and drawn dashed.
+
+Examples:
+  erst callgraph ./contract.wasm | dot -Tsvg > callgraph.svg
+  erst callgraph ./contract.wasm --format mermaid
+  erst callgraph ./contract.wasm --format json -o callgraph.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: callgraphExec,
+}
+
+func callgraphExec(cmd *cobra.Command, args []string) error {
+	wasmBytes, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading WASM file: %w", err)
+	}
+
+	graph, err := callgraph.Build(wasmBytes)
+	if err != nil {
+		if errors.Is(err, wasmopt.ErrInvalidModule) {
+			return errors.WrapWasmInvalid(err.Error())
+		}
+		return err
+	}
+
+	var output string
+	switch callgraphFormat {
+	case "dot":
+		output = graph.DOT()
+	case "mermaid":
+		output = graph.Mermaid()
+	case "json":
+		if output, err = graph.JSON(); err != nil {
+			return err
+		}
+		output += "\n"
+	default:
+		return errors.WrapValidationError(fmt.Sprintf("unsupported format: %s (use: dot, mermaid, json)", callgraphFormat))
+	}
+
+	if callgraphOutput == "" {
+		fmt.Print(output)
+		return nil
+	}
+	if err := os.WriteFile(callgraphOutput, []byte(output), 0644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	fmt.Printf("Written to: %s\n", callgraphOutput)
+	return nil
+}
+
+func init() {
+	callgraphCmd.Flags().StringVar(&callgraphFormat, "format", "dot", "Output format: dot, mermaid or json")
+	callgraphCmd.Flags().StringVarP(&callgraphOutput, "output", "o", "", "Output file path (default: stdout)")
+	rootCmd.AddCommand(callgraphCmd)
+}
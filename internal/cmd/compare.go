@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -35,6 +36,10 @@ var (
 	cmpSimPathFlag   string
 	cmpThemeFlag     string
 	cmpProtoFlag     uint32
+	cmpProtocolsFlag string
+
+	// cmpProtocols is the parsed --protocols list.
+	cmpProtocols []uint32
 )
 
 // compareCmd implements `erst compare`.
@@ -48,8 +53,15 @@ usage, and divergent call paths.
 
 This is the primary tool for "What broke when I updated my contract?" debugging.
 
+With --protocols, the transaction is instead replayed once per protocol version
+with the same envelope and ledger state, and a matrix of status, budget and
+event differences between consecutive versions is shown. Steps where success
+turns into failure are highlighted together with the protocol feature changes
+behind them. --wasm is optional in this mode; when given, every pass uses it.
+
 How it works:
-  1. Fetch the transaction envelope and ledger state from the network.
+  1. Fetch the transaction envelope and ledger state from the network, or
+     read the transaction from a local history archive with --archive.
   2. Run two simulation passes in parallel:
        - Pass A: uses the local WASM file you provide (--wasm).
        - Pass B: uses the on-chain WASM (normal replay, no --wasm flag).
@@ -63,14 +75,34 @@ Examples:
   erst compare <tx-hash> --wasm ./contract.wasm --network testnet --verbose
 
   # Override the protocol version used for both passes
-  erst compare <tx-hash> --wasm ./contract.wasm --protocol-version 22`,
+  erst compare <tx-hash> --wasm ./contract.wasm --protocol-version 22
+
+  # Check a transaction against several protocol versions before an upgrade
+  erst compare <tx-hash> --protocols 20,21,22
+
+  # Replay offline from a history archive mirror and a recorded snapshot
+  erst compare <tx-hash> --wasm ./contract.wasm --archive ./history --state-from snapshot:fixture.json`,
 	Args: cobra.ExactArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if cmpLocalWasmFlag == "" {
+		if cmpProtocolsFlag != "" {
+			if cmpProtoFlag > 0 {
+				return errors.WrapValidationError("--protocols and --protocol-version cannot be used together")
+			}
+			versions, err := parseProtocolList(cmpProtocolsFlag)
+			if err != nil {
+				return err
+			}
+			cmpProtocols = versions
+		} else if cmpLocalWasmFlag == "" {
 			return errors.WrapValidationError("--wasm flag is required for compare mode")
 		}
-		if _, err := os.Stat(cmpLocalWasmFlag); os.IsNotExist(err) {
-			return errors.WrapValidationError(fmt.Sprintf("WASM file not found: %s", cmpLocalWasmFlag))
+		if cmpOptimizeFlag && cmpLocalWasmFlag == "" {
+			return errors.WrapValidationError("--optimize requires --wasm")
+		}
+		if cmpLocalWasmFlag != "" {
+			if _, err := os.Stat(cmpLocalWasmFlag); os.IsNotExist(err) {
+				return errors.WrapValidationError(fmt.Sprintf("WASM file not found: %s", cmpLocalWasmFlag))
+			}
 		}
 		if err := rpc.ValidateTransactionHash(args[0]); err != nil {
 			return errors.WrapValidationError(fmt.Sprintf("invalid transaction hash: %v", err))
@@ -96,7 +128,7 @@ func init() {
 	compareCmd.Flags().StringVar(&cmpLocalWasmFlag, "wasm", "",
 		"Path to local WASM file (required)")
 	compareCmd.Flags().BoolVar(&cmpOptimizeFlag, "optimize", false,
-		"Run dead-code elimination on local WASM before simulation")
+		"Run the erst dce optimizer on local WASM before simulation")
 	compareCmd.Flags().StringSliceVar(&cmpArgsFlag, "args", []string{},
 		"Mock arguments to pass to the local WASM execution")
 	compareCmd.Flags().BoolVarP(&cmpVerboseFlag, "verbose", "v", false,
@@ -107,8 +139,13 @@ func init() {
 		"Colour theme (default, deuteranopia, protanopia, tritanopia, high-contrast)")
 	compareCmd.Flags().Uint32Var(&cmpProtoFlag, "protocol-version", 0,
 		"Override protocol version for both simulation passes (20, 21, 22, …)")
+	compareCmd.Flags().StringVar(&cmpProtocolsFlag, "protocols", "",
+		"Replay under each of these comma-separated protocol versions and diff them (e.g. 20,21,22)")
 	_ = compareCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
 	_ = compareCmd.RegisterFlagCompletionFunc("theme", completeThemeFlag)
+	registerStateSourceFlags(compareCmd)
+	registerArchiveFlag(compareCmd)
+
 	rootCmd.AddCommand(compareCmd)
 }
 
@@ -143,7 +180,12 @@ func runCompare(cmd *cobra.Command, cmdArgs []string) error {
 	fmt.Printf("%s  Compare Replay\n", visualizer.Symbol("chart"))
 	fmt.Printf("Transaction : %s\n", txHash)
 	fmt.Printf("Network     : %s\n", cmpNetworkFlag)
-	fmt.Printf("Local WASM  : %s\n", cmpLocalWasmFlag)
+	if cmpLocalWasmFlag != "" {
+		fmt.Printf("Local WASM  : %s\n", cmpLocalWasmFlag)
+	}
+	if len(cmpProtocols) > 0 {
+		fmt.Printf("Protocols   : %s\n", formatProtocolList(cmpProtocols))
+	}
 	if cmpOptimizeFlag {
 		printOptimizationReport(report)
 	}
@@ -177,39 +219,57 @@ func runCompare(cmd *cobra.Command, cmdArgs []string) error {
 		}
 	}
 
-	client, err := rpc.NewClient(clientOpts...)
+	client, err := newRPCClient(clientOpts...)
 	if err != nil {
 		return errors.WrapValidationError(fmt.Sprintf("failed to create RPC client: %v", err))
 	}
 
 	// ── Fetch transaction ───────────────────────────────────────────────────
-	fmt.Printf("%s Fetching transaction from %s...\n", visualizer.Symbol("pin"), cmpNetworkFlag)
-	txResp, err := client.GetTransaction(ctx, txHash)
+	source, err := transactionSource(client)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Fetching transaction from %s...\n", visualizer.Symbol("pin"), transactionSourceName(cmpNetworkFlag))
+	txResp, err := source.GetTransaction(ctx, txHash)
 	if err != nil {
 		return errors.WrapRPCConnectionFailed(err)
 	}
 	fmt.Printf("%s Fetched (envelope: %d bytes)\n\n", visualizer.Success(), len(txResp.EnvelopeXdr))
 
 	// ── Extract ledger keys & entries ───────────────────────────────────────
-	keys, err := extractLedgerKeys(txResp.ResultMetaXdr)
+	ledgerEntries, err := compareLedgerEntries(cmd, client, txHash, txResp)
 	if err != nil {
-		return errors.WrapUnmarshalFailed(err, "result meta")
-	}
-
-	ledgerEntries, err := rpc.ExtractLedgerEntriesFromMeta(txResp.ResultMetaXdr)
-	if err != nil {
-		logger.Logger.Warn("Falling back to live ledger entry fetch", "error", err)
-		ledgerEntries, err = client.GetLedgerEntries(ctx, keys)
-		if err != nil {
-			return errors.WrapRPCConnectionFailed(err)
-		}
+		return err
 	}
 
 	// ── Build simulator runner ───────────────────────────────────────────────
-	runner, err := simulator.NewRunner(cmpSimPathFlag, cmpVerboseFlag)
+	simRunner, err := simulator.NewRunnerInterface(cmpSimPathFlag, cmpVerboseFlag)
 	if err != nil {
 		return errors.WrapSimulatorNotFound(err.Error())
 	}
+	runner := newCachedRunner(simRunner)
+	defer runner.Close()
+
+	// Event values are compared as rendered with the contracts' specs.
+	contractSpecs.RegisterLedgerEntries(ledgerEntries)
+
+	if len(cmpProtocols) > 0 {
+		fmt.Printf("%s Replaying under %d protocol versions in parallel...\n\n", visualizer.Symbol("play"), len(cmpProtocols))
+		runs, err := runProtocolPasses(ctx, runner, txResp, ledgerEntries, localWasmPath, cmpProtocols)
+		if err != nil {
+			return err
+		}
+		for i := range runs {
+			runs[i].Response = renderEventValues(ctx, client, runs[i].Response)
+		}
+		if cmpVerboseFlag {
+			for _, run := range runs {
+				printVerboseResponse(fmt.Sprintf("PROTOCOL %d", run.Version), run.Response)
+			}
+		}
+		compare.RenderProtocolMatrix(compare.DiffProtocols(runs))
+		return nil
+	}
 
 	// ── Run two simulation passes in parallel ────────────────────────────────
 	fmt.Printf("%s Running two simulation passes in parallel...\n", visualizer.Symbol("play"))
@@ -227,16 +287,49 @@ func runCompare(cmd *cobra.Command, cmdArgs []string) error {
 	}
 
 	// ── Diff & render ────────────────────────────────────────────────────────
+	localResult = renderEventValues(ctx, client, localResult)
+	onChainResult = renderEventValues(ctx, client, onChainResult)
 	diffResult := compare.Diff(localResult, onChainResult)
 	compare.Render(diffResult)
 
 	return nil
 }
 
+// compareLedgerEntries returns the ledger entries both passes replay
+// against. An explicit --state-from replaces the entries embedded in the
+// result meta so that recorded fixtures are replayed as-is. Transactions read
+// from a history archive have no meta, so their footprint is read from the
+// state source.
+func compareLedgerEntries(
+	cmd *cobra.Command,
+	client *rpc.Client,
+	txHash string,
+	txResp *rpc.TransactionResponse,
+) (map[string]string, error) {
+	ctx := cmd.Context()
+	if txResp.ResultMetaXdr == "" {
+		return loadHistoricalState(ctx, client, txHash, txResp, "")
+	}
+
+	keys, err := extractLedgerKeys(txResp.ResultMetaXdr)
+	if err != nil {
+		return nil, errors.WrapUnmarshalFailed(err, "result meta")
+	}
+	if stateSourceChanged(cmd) {
+		return loadLedgerState(ctx, client, keys)
+	}
+	ledgerEntries, err := rpc.ExtractLedgerEntriesFromMeta(txResp.ResultMetaXdr)
+	if err != nil {
+		logger.Logger.Warn("Falling back to live ledger entry fetch", "error", err)
+		return loadLedgerState(ctx, client, keys)
+	}
+	return ledgerEntries, nil
+}
+
 // runBothPasses executes the local and on-chain simulation concurrently.
 func runBothPasses(
 	ctx context.Context,
-	runner *simulator.Runner,
+	runner simulator.RunnerInterface,
 	txResp *rpc.TransactionResponse,
 	ledgerEntries map[string]string,
 	localWasmPath string,
@@ -271,6 +364,83 @@ func runBothPasses(
 	return localResult, onChainResult, nil
 }
 
+// runProtocolPasses replays the transaction once per protocol version, in
+// parallel. Failed executions are kept as runs: erst-sim still returns their
+// response, and a failure is exactly what the matrix has to show.
+func runProtocolPasses(
+	ctx context.Context,
+	runner simulator.RunnerInterface,
+	txResp *rpc.TransactionResponse,
+	ledgerEntries map[string]string,
+	wasmPath string,
+	versions []uint32,
+) ([]compare.ProtocolRun, error) {
+	runs := make([]compare.ProtocolRun, len(versions))
+	errs := make([]error, len(versions))
+
+	var wg sync.WaitGroup
+	for i, version := range versions {
+		wg.Add(1)
+		go func(i int, version uint32) {
+			defer wg.Done()
+			req := buildSimRequest(txResp, ledgerEntries, &wasmPath, cmpArgsFlag)
+			req.ProtocolVersion = &version
+
+			resp, err := runner.Run(ctx, req)
+			if err != nil {
+				var simErr *simulator.SimulatorError
+				if !errors.As(err, &simErr) || simErr.Response == nil {
+					errs[i] = fmt.Errorf("protocol %d simulation failed: %w", version, err)
+					return
+				}
+				resp = simErr.Response
+			}
+			runs[i] = compare.ProtocolRun{Version: version, Response: resp}
+		}(i, version)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return runs, nil
+}
+
+// parseProtocolList parses a comma-separated list of at least two distinct,
+// supported protocol versions.
+func parseProtocolList(s string) ([]uint32, error) {
+	seen := make(map[uint32]bool)
+	var versions []uint32
+	for _, part := range splitTrimmed(s) {
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, errors.WrapValidationError(fmt.Sprintf("invalid protocol version %q in --protocols", part))
+		}
+		version := uint32(v)
+		if err := simulator.Validate(version); err != nil {
+			return nil, err
+		}
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) < 2 {
+		return nil, errors.WrapValidationError("--protocols needs at least two distinct versions")
+	}
+	return versions, nil
+}
+
+func formatProtocolList(versions []uint32) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.FormatUint(uint64(v), 10)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // buildSimRequest constructs a SimulationRequest with optional local WASM override.
 func buildSimRequest(
 	txResp *rpc.TransactionResponse,
@@ -280,7 +450,7 @@ func buildSimRequest(
 ) *simulator.SimulationRequest {
 	req := &simulator.SimulationRequest{
 		EnvelopeXdr:   txResp.EnvelopeXdr,
-		ResultMetaXdr: txResp.ResultMetaXdr,
+		ResultMetaXdr: replayResultMeta(txResp),
 		LedgerEntries: ledgerEntries,
 	}
 	if wasmPath != nil && *wasmPath != "" {
@@ -54,8 +54,8 @@ func TestCompleteXDRTypeFlag(t *testing.T) {
 	if directive != cobra.ShellCompDirectiveNoFileComp {
 		t.Fatalf("expected ShellCompDirectiveNoFileComp, got %v", directive)
 	}
-	if len(completions) != 2 {
-		t.Fatalf("expected 2 xdr type completions, got %d", len(completions))
+	if len(completions) != 3 {
+		t.Fatalf("expected 3 xdr type completions, got %d", len(completions))
 	}
 }
 
@@ -0,0 +1,138 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/decoder"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
+)
+
+var (
+	// contractSpecs caches the specs of every contract seen by this process,
+	// keyed by WASM code hash.
+	contractSpecs = decoder.NewSpecRegistry()
+	// contractErrors names contract error codes using contractSpecs.
+	contractErrors = decoder.NewContractErrorResolverWithSpecs(contractSpecs)
+)
+
+// resolveContractErrors names the "Error(Contract, #N)" codes of a replay. It
+// registers the contract specs found in the request's ledger entries and
+// local WASM, fetches the failing contract's bytecode through provider when
+// its spec is still unknown, and returns a copy of resp annotated with the
+// variant names together with the variant behind resp.Error. provider may
+// be nil.
+func resolveContractErrors(
+	ctx context.Context,
+	provider rpc.LedgerStateProvider,
+	req *simulator.SimulationRequest,
+	resp *simulator.SimulationResponse,
+) (*simulator.SimulationResponse, *decoder.ContractError) {
+	if !hasContractErrorCodes(resp) {
+		return resp, nil
+	}
+
+	registerRequestSpecs(req)
+	contract := failingContract(resp)
+	if contract != "" {
+		fetchContractSpecs(ctx, provider, contract)
+	}
+
+	annotated := *resp
+	annotated.Error = contractErrors.Annotate(contract, resp.Error)
+	annotated.Events = annotateLines(contract, resp.Events)
+	annotated.Logs = annotateLines(contract, resp.Logs)
+	annotated.DiagnosticEvents = make([]simulator.DiagnosticEvent, len(resp.DiagnosticEvents))
+	for i, event := range resp.DiagnosticEvents {
+		eventContract := contract
+		if event.ContractID != nil {
+			eventContract = *event.ContractID
+		}
+		event.Data = contractErrors.Annotate(eventContract, event.Data)
+		annotated.DiagnosticEvents[i] = event
+	}
+
+	if e, ok := contractErrors.ResolveText(contract, resp.Error); ok {
+		return &annotated, &e
+	}
+	return &annotated, nil
+}
+
+// registerRequestSpecs registers the contract specs found in the ledger
+// entries and local WASM of req, which may be nil.
+func registerRequestSpecs(req *simulator.SimulationRequest) {
+	if req == nil {
+		return
+	}
+	contractSpecs.RegisterLedgerEntries(req.LedgerEntries)
+	if req.WasmPath != nil {
+		if wasm, err := os.ReadFile(*req.WasmPath); err == nil {
+			_, _ = contractSpecs.RegisterWasm("", wasm)
+		}
+	}
+}
+
+// fetchContractSpecs fetches, through provider, the bytecode of the given
+// contracts whose specs are still unknown. provider may be nil.
+func fetchContractSpecs(ctx context.Context, provider rpc.LedgerStateProvider, contractIDs ...string) {
+	if provider == nil {
+		return
+	}
+	for _, contract := range contractIDs {
+		if contractSpecs.Known(contract) {
+			continue
+		}
+		id := decoder.NormalizeContractID(contract)
+		entries, err := rpc.FetchContractBytecode(ctx, provider, id)
+		if err != nil {
+			logger.Logger.Debug("Failed to fetch contract spec", "contract_id", id, "error", err)
+			continue
+		}
+		contractSpecs.RegisterLedgerEntries(entries)
+	}
+}
+
+func hasContractErrorCodes(resp *simulator.SimulationResponse) bool {
+	if len(decoder.ContractErrorCodes(resp.Error)) > 0 {
+		return true
+	}
+	for _, event := range resp.DiagnosticEvents {
+		if len(decoder.ContractErrorCodes(event.Data)) > 0 {
+			return true
+		}
+	}
+	for _, line := range append(append([]string(nil), resp.Events...), resp.Logs...) {
+		if len(decoder.ContractErrorCodes(line)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func annotateLines(contract string, lines []string) []string {
+	if lines == nil {
+		return nil
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = contractErrors.Annotate(contract, line)
+	}
+	return out
+}
+
+// failingContract returns the last contract to emit a diagnostic event,
+// which is the contract whose error ended the invocation.
+func failingContract(resp *simulator.SimulationResponse) string {
+	for i := len(resp.DiagnosticEvents) - 1; i >= 0; i-- {
+		if id := resp.DiagnosticEvents[i].ContractID; id != nil && strings.TrimSpace(*id) != "" {
+			return *id
+		}
+	}
+	return ""
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/dotandev/hintents/internal/daemon"
 	"github.com/dotandev/hintents/internal/errors"
@@ -24,6 +26,9 @@ var (
 	daemonAuthToken string
 	daemonTracing   bool
 	daemonOTLPURL   string
+	daemonJobStore  string
+	daemonMaxJobs   int
+	daemonJobTTL    time.Duration
 )
 
 var daemonCmd = &cobra.Command{
@@ -35,10 +40,22 @@ var daemonCmd = &cobra.Command{
 Endpoints:
   - debug_transaction: Debug a failed transaction
   - get_trace: Get execution traces for a transaction
+  - submit_job: Queue a simulation and return its job ID
+  - poll_job: Get the status, progress and result of a job
+  - cancel_job: Cancel a pending or running job
+  - list_jobs: List jobs, optionally filtered by status
+
+Clients can connect to /ws and subscribe to streaming notifications for job
+progress, diagnostic events and completion instead of polling.
+
+Queued jobs are stored in ~/.erst/jobs.db by default, so pending jobs resume
+and finished results remain available after a restart.
 
 Example:
   erst daemon --port 8080 --network testnet
-  erst daemon --port 8080 --auth-token secret123`,
+  erst daemon --port 8080 --auth-token secret123
+  erst daemon --max-jobs 8 --job-ttl 24h
+  erst daemon --job-store memory`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
@@ -64,12 +81,26 @@ Example:
 			return errors.WrapInvalidNetwork(daemonNetwork)
 		}
 
+		jobStorePath, err := resolveJobStorePath(daemonJobStore)
+		if err != nil {
+			return err
+		}
+
+		clientOpts, err := rpcCassetteOptions()
+		if err != nil {
+			return err
+		}
+
 		// Create server
 		server, err := daemon.NewServer(daemon.Config{
-			Port:      daemonPort,
-			Network:   daemonNetwork,
-			RPCURL:    daemonRPCURL,
-			AuthToken: daemonAuthToken,
+			Port:              daemonPort,
+			Network:           daemonNetwork,
+			RPCURL:            daemonRPCURL,
+			AuthToken:         daemonAuthToken,
+			JobStorePath:      jobStorePath,
+			MaxConcurrentJobs: daemonMaxJobs,
+			JobTTL:            daemonJobTTL,
+			ClientOptions:     clientOpts,
 		})
 		if err != nil {
 			return errors.WrapValidationError(fmt.Sprintf("failed to create server: %v", err))
@@ -102,6 +133,23 @@ Example:
 	},
 }
 
+// resolveJobStorePath maps the --job-store flag to a SQLite path. "memory"
+// selects the in-memory store, signalled by an empty path.
+func resolveJobStorePath(flag string) (string, error) {
+	switch flag {
+	case "memory":
+		return "", nil
+	case "":
+		dir, err := rpc.GetCachePath()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "jobs.db"), nil
+	default:
+		return flag, nil
+	}
+}
+
 func init() {
 	daemonCmd.Flags().StringVarP(&daemonPort, "port", "p", "8080", "Port to listen on")
 	daemonCmd.Flags().StringVarP(&daemonNetwork, "network", "n", string(rpc.Mainnet), "Stellar network to use (testnet, mainnet, futurenet)")
@@ -109,6 +157,9 @@ func init() {
 	daemonCmd.Flags().StringVar(&daemonAuthToken, "auth-token", "", "Authentication token for API access")
 	daemonCmd.Flags().BoolVar(&daemonTracing, "tracing", false, "Enable OpenTelemetry tracing")
 	daemonCmd.Flags().StringVar(&daemonOTLPURL, "otlp-url", "http://localhost:4318", "OTLP exporter URL")
+	daemonCmd.Flags().StringVar(&daemonJobStore, "job-store", "", "SQLite file for queued jobs, or 'memory' (default ~/.erst/jobs.db)")
+	daemonCmd.Flags().IntVar(&daemonMaxJobs, "max-jobs", 4, "Maximum number of simulations to run concurrently")
+	daemonCmd.Flags().DurationVar(&daemonJobTTL, "job-ttl", time.Hour, "How long finished jobs are kept before eviction")
 
 	_ = daemonCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
 
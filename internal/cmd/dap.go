@@ -0,0 +1,161 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/dotandev/hintents/internal/dap"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/session"
+	"github.com/dotandev/hintents/internal/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dapListenFlag  string
+	dapTraceFlag   string
+	dapSessionFlag string
+	dapWasmFlag    string
+)
+
+var dapCmd = &cobra.Command{
+	Use:     "dap",
+	GroupID: "development",
+	Short:   "Run a Debug Adapter Protocol server for editor time-travel debugging",
+	Long: `Start a Debug Adapter Protocol (DAP) server so editors such as VS Code,
+Neovim or JetBrains IDEs can step through a replayed transaction.
+
+Trace steps are shown as stack frames. Steps map to source lines when the
+contract WASM carries DWARF debug info, and otherwise to lines of a virtual
+trace document. Host state, memory and call arguments are exposed as
+variables. Breakpoints can be set on source lines and on contract functions
+("transfer", "<contract-id>::transfer" or a contract ID). Step back and
+reverse-continue travel backwards through the trace.
+
+The trace comes from the launch request ("trace": file, or "session": ID of a
+session saved by 'erst debug') or from the --trace/--session flags. "wasm"
+or --wasm supplies a contract binary for source mapping.
+
+By default the adapter speaks DAP over stdin/stdout. Use --listen to accept
+clients over TCP instead.
+
+Example launch.json configuration:
+  {
+    "type": "erst",
+    "request": "launch",
+    "name": "Debug failed transaction",
+    "session": "abc123-1700000000",
+    "wasm": "${workspaceFolder}/target/wasm32-unknown-unknown/debug/token.wasm"
+  }
+
+Examples:
+  erst dap
+  erst dap --listen 127.0.0.1:4711
+  erst dap --trace execution.json --wasm contract.wasm`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		if dapListenFlag == "" {
+			// stdout carries the protocol; diagnostics go to stderr via the logger.
+			return dap.NewSession(os.Stdin, os.Stdout, loadDAPTrace).Serve(ctx)
+		}
+
+		ln, err := net.Listen("tcp", dapListenFlag)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to listen on %s: %v", dapListenFlag, err))
+		}
+		fmt.Fprintf(os.Stderr, "DAP server listening on %s\n", ln.Addr())
+		return dap.ServeListener(ctx, ln, loadDAPTrace)
+	},
+}
+
+// loadDAPTrace resolves a DAP launch request to a trace. Launch arguments
+// take precedence over the command-line flags.
+func loadDAPTrace(ctx context.Context, args dap.LaunchArguments) (*trace.ExecutionTrace, []byte, error) {
+	tracePath := firstNonEmpty(args.Trace, dapTraceFlag)
+	sessionID := firstNonEmpty(args.Session, dapSessionFlag)
+	wasmPath := firstNonEmpty(args.Wasm, dapWasmFlag)
+
+	var (
+		executionTrace *trace.ExecutionTrace
+		err            error
+	)
+	switch {
+	case tracePath != "":
+		executionTrace, err = loadTraceFile(tracePath)
+	case sessionID != "":
+		executionTrace, err = loadSessionTrace(ctx, sessionID)
+	default:
+		return nil, nil, errors.WrapCliArgumentRequired("trace or session")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var wasm []byte
+	if wasmPath != "" {
+		wasm, err = os.ReadFile(wasmPath)
+		if err != nil {
+			return nil, nil, errors.WrapValidationError(fmt.Sprintf("failed to read WASM file: %v", err))
+		}
+	}
+	return executionTrace, wasm, nil
+}
+
+func loadTraceFile(path string) (*trace.ExecutionTrace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to read trace file: %v", err))
+	}
+	executionTrace, err := trace.FromJSON(data)
+	if err != nil {
+		return nil, errors.WrapUnmarshalFailed(err, "trace")
+	}
+	return executionTrace, nil
+}
+
+// loadSessionTrace rebuilds the trace of a replay saved by 'erst debug'.
+func loadSessionTrace(ctx context.Context, id string) (*trace.ExecutionTrace, error) {
+	store, err := session.NewStore()
+	if err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to open session store: %v", err))
+	}
+	defer store.Close()
+
+	data, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, errors.WrapValidationError(err.Error())
+	}
+	resp, err := data.ToSimulationResponse()
+	if err != nil {
+		return nil, errors.WrapValidationError(err.Error())
+	}
+	return buildExecutionTrace(data.TxHash, resp), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func init() {
+	dapCmd.Flags().StringVar(&dapListenFlag, "listen", "", "Accept DAP clients on this TCP address instead of stdio (e.g. 127.0.0.1:4711)")
+	dapCmd.Flags().StringVar(&dapTraceFlag, "trace", "", "Default trace file when the launch request names none")
+	dapCmd.Flags().StringVar(&dapSessionFlag, "session", "", "Default session ID when the launch request names none")
+	dapCmd.Flags().StringVar(&dapWasmFlag, "wasm", "", "Contract WASM with DWARF debug info for source mapping")
+
+	rootCmd.AddCommand(dapCmd)
+}
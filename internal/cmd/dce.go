@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/dotandev/hintents/internal/dce"
 	"github.com/spf13/cobra"
 )
 
@@ -16,8 +15,14 @@ var dceOutput string
 var dceCmd = &cobra.Command{
 	Use:   "dce <wasm-file>",
 	Short: "Eliminate dead code from a WASM binary",
-	Long: `Analyze a compiled WASM binary, build a call graph from exported functions,
-and strip unreachable functions to reduce contract size.
+	Long: `Analyze a compiled WASM binary and strip what the contract cannot use:
+functions unreachable from its exports, then the globals, types and passive
+data segments only they referred to, and custom sections other than the
+Soroban contract sections, the name section and the DWARF .debug_* sections.
+The result is validated and the size saved by each pass is reported.
+
+This is the same optimizer that compare --optimize and
+simulate-upgrade --optimize run.
 
 Without -o, performs a dry run and prints statistics only.
 
@@ -34,21 +39,11 @@ func dceExec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("reading WASM file: %w", err)
 	}
 
-	out, stats, err := dce.Eliminate(wasmBytes)
+	out, report, err := optimizeWasm(wasmBytes)
 	if err != nil {
 		return err
 	}
-
-	fmt.Printf("Total functions:    %d\n", stats.TotalFunctions)
-	fmt.Printf("Removed functions:  %d\n", stats.RemovedFunctions)
-	fmt.Printf("Original size:      %d bytes\n", stats.OriginalSize)
-	fmt.Printf("Optimized size:     %d bytes\n", stats.OptimizedSize)
-
-	if stats.OriginalSize > 0 {
-		saved := stats.OriginalSize - stats.OptimizedSize
-		pct := float64(saved) / float64(stats.OriginalSize) * 100
-		fmt.Printf("Saved:              %d bytes (%.1f%%)\n", saved, pct)
-	}
+	printOptimizationReport(report)
 
 	if dceOutput == "" {
 		return nil
@@ -57,7 +52,7 @@ func dceExec(cmd *cobra.Command, args []string) error {
 	if err := os.WriteFile(dceOutput, out, 0644); err != nil {
 		return fmt.Errorf("writing output: %w", err)
 	}
-	fmt.Printf("Written to:         %s\n", dceOutput)
+	fmt.Printf("Written to: %s\n", dceOutput)
 
 	return nil
 }
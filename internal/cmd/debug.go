@@ -0,0 +1,904 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dotandev/hintents/internal/config"
+	"github.com/dotandev/hintents/internal/decoder"
+	"github.com/dotandev/hintents/internal/dwarf"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/heuristic"
+	"github.com/dotandev/hintents/internal/invariant"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/rules"
+	"github.com/dotandev/hintents/internal/session"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/trace"
+	"github.com/dotandev/hintents/internal/visualizer"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// Shared network flags. Other commands (simulate-upgrade, generate-test,
+// regression-test) bind their own flags to these same variables.
+var (
+	networkFlag  string
+	rpcURLFlag   string
+	rpcTokenFlag string
+	verbose      bool
+
+	// rpcClient is the client used by the most recent network command.
+	rpcClient *rpc.Client
+)
+
+// Flags specific to the debug command
+var (
+	debugWasmFlag            string
+	debugArgsFlag            []string
+	debugInteractiveFlag     bool
+	debugSimPathFlag         string
+	debugOverrideStateFlag   string
+	debugProtocolVersionFlag uint32
+	debugThemeFlag           string
+	debugNoSessionFlag       bool
+	debugLedgerMetaFlag      string
+
+	// debugInvariants is the parsed --invariants file, if any.
+	debugInvariants *invariant.Set
+
+	mockBaseFeeFlag  uint32
+	mockGasPriceFlag uint64
+)
+
+// deprecatedHostFunctions lists host functions that were removed or replaced
+// in recent protocol versions. Contracts still calling them will fail after
+// the next network upgrade.
+var deprecatedHostFunctions = []string{
+	"bytes_copy_from_linear_memory",
+	"bytes_copy_to_linear_memory",
+	"map_unpack_to_linear_memory",
+	"vec_unpack_to_linear_memory",
+	"symbol_index_in_linear_memory",
+}
+
+var debugCmd = &cobra.Command{
+	Use:     "debug [transaction-hash]",
+	GroupID: "core",
+	Short:   "Debug a failed Soroban transaction",
+	Long: `Fetch a transaction from the network, replay it locally through the erst-sim
+simulator and print the decoded call tree together with a plain-English
+explanation of the failure and suggested fixes.
+
+The replay pipeline:
+  1. Fetch the envelope and result meta with getTransaction.
+  2. Reconstruct the ledger footprint as it was before the transaction by
+     undoing the changes in its meta; entries the meta does not cover are
+     looked up in --ledger-meta, then fetched with getLedgerEntries.
+  3. Replay the transaction through erst-sim.
+  4. Save the result as the active session for 'stats', 'export', 'explain'.
+
+When no transaction hash is given, a base64 TransactionEnvelope is read from
+stdin and replayed without fetching its result meta. With --wasm the command
+runs a local contract directly and no network access is needed.
+
+With --archive, the transaction is read from a local history archive mirror
+instead. Archives keep no transaction meta, so the footprint is read from
+--state-from; use a snapshot or the cache to replay without any RPC provider.
+
+With --invariants, the replay is checked against the invariants declared in
+the file, and any violation fails the command.
+
+Examples:
+  erst debug 5c0a1234567890abcdef1234567890abcdef1234567890abcdef1234567890ab
+  erst debug --network testnet <tx-hash>
+  erst debug <tx-hash> --interactive
+  erst debug <tx-hash> --ledger-meta ./ledger-meta
+  erst debug <tx-hash> --archive ./history --state-from snapshot:fixture.json
+  erst debug --profile <tx-hash>
+  erst debug < tx.xdr
+  erst debug --wasm ./contract.wasm --args "hello" --args "world"
+  erst debug <tx-hash> --invariants token-invariants.json`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			if err := rpc.ValidateTransactionHash(args[0]); err != nil {
+				return errors.WrapValidationError(fmt.Sprintf("invalid transaction hash: %v", err))
+			}
+		}
+		switch rpc.Network(networkFlag) {
+		case rpc.Testnet, rpc.Mainnet, rpc.Futurenet:
+		default:
+			return errors.WrapInvalidNetwork(networkFlag)
+		}
+		if debugProtocolVersionFlag > 0 {
+			if err := simulator.Validate(debugProtocolVersionFlag); err != nil {
+				return errors.WrapProtocolUnsupported(debugProtocolVersionFlag)
+			}
+		}
+		return nil
+	},
+	RunE: runDebug,
+}
+
+func init() {
+	debugCmd.Flags().StringVarP(&networkFlag, "network", "n", string(rpc.Mainnet), "Stellar network to use (testnet, mainnet, futurenet)")
+	debugCmd.Flags().StringVar(&rpcURLFlag, "rpc-url", "", "Custom Horizon RPC URL to use")
+	debugCmd.Flags().StringVar(&rpcTokenFlag, "rpc-token", "", "RPC authentication token (can also use ERST_RPC_TOKEN env var)")
+	debugCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print the full simulation request and response")
+	debugCmd.Flags().StringVar(&debugWasmFlag, "wasm", "", "Path to a local WASM file to replay instead of the on-chain code")
+	debugCmd.Flags().StringSliceVar(&debugArgsFlag, "args", []string{}, "Mock arguments for local WASM replay")
+	debugCmd.Flags().BoolVarP(&debugInteractiveFlag, "interactive", "i", false, "Open the interactive trace viewer after replay")
+	debugCmd.Flags().StringVar(&debugSimPathFlag, "sim-path", "", "Path to erst-sim binary (overrides auto-discovery)")
+	debugCmd.Flags().StringVar(&debugOverrideStateFlag, "override-state", "", "JSON file with ledger entries that override fetched state")
+	debugCmd.Flags().Uint32Var(&debugProtocolVersionFlag, "protocol-version", 0, "Override the protocol version used for replay (20, 21, 22, …)")
+	debugCmd.Flags().StringVar(&debugThemeFlag, "theme", "", "Color theme (default, deuteranopia, protanopia, tritanopia, high-contrast)")
+	debugCmd.Flags().BoolVar(&debugNoSessionFlag, "no-session", false, "Do not persist the replay as a session")
+	debugCmd.Flags().StringVar(&debugLedgerMetaFlag, "ledger-meta", "", "Directory of ledger close meta used to reconstruct the exact pre-transaction state")
+	debugCmd.Flags().Uint32Var(&mockBaseFeeFlag, "mock-base-fee", 0, "Override the base inclusion fee (stroops) used for fee checks")
+	debugCmd.Flags().Uint64Var(&mockGasPriceFlag, "mock-gas-price", 0, "Override the resource gas price used for fee checks")
+
+	_ = debugCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
+	_ = debugCmd.RegisterFlagCompletionFunc("theme", completeThemeFlag)
+
+	registerStateSourceFlags(debugCmd)
+	registerInvariantsFlag(debugCmd)
+	registerArchiveFlag(debugCmd)
+
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebug(cmd *cobra.Command, args []string) error {
+	if debugThemeFlag != "" {
+		visualizer.SetTheme(visualizer.Theme(debugThemeFlag))
+	} else {
+		visualizer.SetTheme(visualizer.DetectTheme())
+	}
+
+	invariants, err := loadInvariants()
+	if err != nil {
+		return errors.WrapValidationError(err.Error())
+	}
+	debugInvariants = invariants
+
+	simRunner, err := simulator.NewRunnerWithMockTime(debugSimPathFlag, verbose, TimestampFlag)
+	if err != nil {
+		return errors.WrapSimulatorNotFound(err.Error())
+	}
+	if len(args) == 0 && debugWasmFlag != "" {
+		// Local replay has no envelope or result meta, which the request
+		// validator would reject; erst-sim synthesises both from the WASM and
+		// mock args.
+		simRunner.Validator = nil
+	}
+	runner := newCachedRunner(simRunner)
+	registerRunnerCloseHook("debug-simulator-runner", runner)
+	defer func() { _ = runner.Close() }()
+
+	switch {
+	case len(args) == 1:
+		return debugTransaction(cmd, runner, args[0])
+	case debugWasmFlag != "":
+		return debugLocalWasm(cmd, runner)
+	default:
+		return debugEnvelopeFromStdin(cmd, runner)
+	}
+}
+
+// debugTransaction drives the full fetch → replay → analyse pipeline for an
+// on-chain transaction.
+func debugTransaction(cmd *cobra.Command, runner simulator.RunnerInterface, txHash string) error {
+	ctx := cmd.Context()
+
+	client, err := newDebugClient()
+	if err != nil {
+		return err
+	}
+	rpcClient = client
+	registerCacheFlushHook()
+
+	source, err := transactionSource(client)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Fetching transaction %s from %s...\n", visualizer.Symbol("pin"), txHash, transactionSourceName(networkFlag))
+	txResp, err := source.GetTransaction(ctx, txHash)
+	if err != nil {
+		return errors.WrapRPCConnectionFailed(err)
+	}
+
+	ledgerEntries, err := loadHistoricalState(ctx, client, txHash, txResp, debugLedgerMetaFlag)
+	if err != nil {
+		return err
+	}
+
+	if err := applyOverrideState(ledgerEntries); err != nil {
+		return err
+	}
+	simulator.WarnLedgerEntriesSizeToStderr(ledgerEntries)
+
+	simReq, err := simulator.NewSimulationRequestBuilder().
+		WithEnvelopeXDR(txResp.EnvelopeXdr).
+		WithResultMetaXDR(replayResultMeta(txResp)).
+		WithLedgerEntries(ledgerEntries).
+		Build()
+	if err != nil {
+		return err
+	}
+	if debugWasmFlag != "" {
+		simReq.WasmPath = &debugWasmFlag
+	}
+	applyDebugRequestOptions(simReq)
+
+	fmt.Printf("%s Replaying transaction...\n", visualizer.Symbol("play"))
+	simResp, err := replayDebugRequest(ctx, runner, simReq)
+	if err != nil {
+		return err
+	}
+
+	data := &session.SessionData{
+		TxHash:        txHash,
+		Network:       networkFlag,
+		HorizonURL:    client.HorizonURL,
+		EnvelopeXdr:   txResp.EnvelopeXdr,
+		ResultXdr:     txResp.ResultXdr,
+		ResultMetaXdr: txResp.ResultMetaXdr,
+	}
+	return reportDebugResult(cmd, txHash, simReq, simResp, data)
+}
+
+// debugEnvelopeFromStdin replays an envelope piped on stdin. The transaction has
+// not necessarily been applied on chain, so there is no result meta to use.
+func debugEnvelopeFromStdin(cmd *cobra.Command, runner simulator.RunnerInterface) error {
+	if isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return errors.WrapCliArgumentRequired("transaction-hash")
+	}
+
+	raw, err := io.ReadAll(bufio.NewReader(cmd.InOrStdin()))
+	if err != nil {
+		return errors.WrapValidationError(fmt.Sprintf("failed to read envelope from stdin: %v", err))
+	}
+
+	envelopeXdr, err := parseEnvelopeXDRInput(string(raw))
+	if err != nil {
+		return err
+	}
+
+	ledgerEntries := make(map[string]string)
+	if err := applyOverrideState(ledgerEntries); err != nil {
+		return err
+	}
+
+	// The simulator requires a non-empty result_meta_xdr; envelopes read from
+	// stdin have none, so a placeholder is used as in dry-run.
+	simReq, err := simulator.NewSimulationRequestBuilder().
+		WithEnvelopeXDR(envelopeXdr).
+		WithResultMetaXDR(simulator.PlaceholderResultMeta).
+		WithLedgerEntries(ledgerEntries).
+		Build()
+	if err != nil {
+		return err
+	}
+	if debugWasmFlag != "" {
+		simReq.WasmPath = &debugWasmFlag
+	}
+	applyDebugRequestOptions(simReq)
+
+	fmt.Printf("%s Replaying envelope from stdin (%d bytes)...\n", visualizer.Symbol("play"), len(envelopeXdr))
+	simResp, err := replayDebugRequest(cmd.Context(), runner, simReq)
+	if err != nil {
+		return err
+	}
+
+	data := &session.SessionData{
+		Network:     networkFlag,
+		EnvelopeXdr: envelopeXdr,
+	}
+	return reportDebugResult(cmd, "", simReq, simResp, data)
+}
+
+// debugLocalWasm runs a local contract without any network state.
+func debugLocalWasm(cmd *cobra.Command, runner simulator.RunnerInterface) error {
+	if _, err := os.Stat(debugWasmFlag); err != nil {
+		return errors.WrapValidationError(fmt.Sprintf("WASM file not found: %s", debugWasmFlag))
+	}
+
+	fmt.Printf("%s Local WASM replay: %s\n", visualizer.Symbol("play"), debugWasmFlag)
+	if len(debugArgsFlag) > 0 {
+		fmt.Printf("   Arguments: %s\n", strings.Join(debugArgsFlag, ", "))
+	}
+
+	wasmPath := debugWasmFlag
+	simReq := &simulator.SimulationRequest{
+		WasmPath: &wasmPath,
+	}
+	applyDebugRequestOptions(simReq)
+
+	simResp, err := replayDebugRequest(cmd.Context(), runner, simReq)
+	if err != nil {
+		return err
+	}
+
+	data := &session.SessionData{
+		Network: "local",
+	}
+	return reportDebugResult(cmd, "", simReq, simResp, data)
+}
+
+// reportDebugResult prints the analysis of a completed replay, persists the
+// session and optionally opens the interactive viewer.
+func reportDebugResult(
+	cmd *cobra.Command,
+	txHash string,
+	simReq *simulator.SimulationRequest,
+	simResp *simulator.SimulationResponse,
+	data *session.SessionData,
+) error {
+	if verbose {
+		printVerboseResponse("SIMULATION", simResp)
+	}
+
+	// The display copy renders event values with the contracts' specs and
+	// names contract error codes; the session keeps the raw response.
+	var provider rpc.LedgerStateProvider
+	if rpcClient != nil {
+		provider = rpcClient
+	}
+	registerRequestSpecs(simReq)
+	display := renderEventValues(cmd.Context(), provider, simResp)
+	display, contractErr := resolveContractErrors(cmd.Context(), provider, simReq, display)
+
+	printSimulationResult("Replay", display)
+	if contractErr != nil {
+		fmt.Printf("Contract error: %s\n", contractErr.Describe())
+	}
+	// The same rule set drives the call tree suggestions and the summary.
+	ruleSet, err := rules.Load()
+	if err != nil {
+		logger.Logger.Warn("Failed to load rule packs, using built-in rules", "error", err)
+		ruleSet = rules.Builtin()
+	}
+	printCallTree(display, ruleSet)
+	printDeprecatedHostFunctions(simResp)
+
+	if simResp.Status != "success" {
+		fmt.Println()
+		fmt.Println(heuristic.SummarizeWith(ruleSet, heuristic.Input{
+			TxHash:           txHash,
+			Network:          networkFlag,
+			Status:           display.Status,
+			Error:            display.Error,
+			Events:           display.Events,
+			Logs:             display.Logs,
+			DiagnosticEvents: display.DiagnosticEvents,
+			BudgetUsage:      display.BudgetUsage,
+			ContractError:    contractErr,
+		}))
+	}
+
+	printSourceLocation(simResp)
+
+	var violations []simulator.InvariantViolation
+	if debugInvariants != nil {
+		violations = debugInvariants.Check(simReq, simResp)
+		printInvariantResult(debugInvariants, violations)
+	}
+
+	if ProfileFlag {
+		if err := writeFlamegraph(txHash, simResp); err != nil {
+			return err
+		}
+	}
+
+	if err := saveDebugSession(cmd, simReq, simResp, data); err != nil {
+		// A failed save should not hide the analysis the user asked for.
+		fmt.Fprintf(os.Stderr, "Warning: failed to save session: %v\n", err)
+	}
+
+	if debugInteractiveFlag {
+		executionTrace := buildExecutionTrace(txHash, display)
+		var viewer *trace.InteractiveViewer
+		if wasm := readDebugWasm(); len(wasm) > 0 {
+			viewer = trace.NewInteractiveViewerWithWASM(executionTrace, wasm)
+		} else {
+			viewer = trace.NewInteractiveViewer(executionTrace)
+		}
+		if err := viewer.Start(); err != nil {
+			return err
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%d invariant violation(s)", len(violations))
+	}
+	return nil
+}
+
+// replayDebugRequest replays req. A transaction that failed on chain fails in
+// erst-sim as well: its response is returned like a successful one, so that
+// the failure is analysed rather than aborting the command.
+func replayDebugRequest(ctx context.Context, runner simulator.RunnerInterface, req *simulator.SimulationRequest) (*simulator.SimulationResponse, error) {
+	resp, err := simulationResponse(runner.Run(ctx, req))
+	if err != nil {
+		return nil, errors.WrapSimulationFailed(err, "")
+	}
+	return resp, nil
+}
+
+// simulationResponse returns the response of a replay, including the one
+// carried by a failure that erst-sim reported inside its response.
+func simulationResponse(resp *simulator.SimulationResponse, err error) (*simulator.SimulationResponse, error) {
+	if err != nil {
+		var simErr *simulator.SimulatorError
+		if !errors.As(err, &simErr) || simErr.Response == nil {
+			return nil, err
+		}
+		return simErr.Response, nil
+	}
+	return resp, nil
+}
+
+func newDebugClient() (*rpc.Client, error) {
+	token := rpcTokenFlag
+	if token == "" {
+		token = os.Getenv("ERST_RPC_TOKEN")
+	}
+	if token == "" {
+		if cfg, err := config.Load(); err == nil && cfg.RPCToken != "" {
+			token = cfg.RPCToken
+		}
+	}
+
+	opts := []rpc.ClientOption{
+		rpc.WithNetwork(rpc.Network(networkFlag)),
+		rpc.WithToken(token),
+	}
+	if rpcURLFlag != "" {
+		opts = append(opts, rpc.WithAltURLs(splitTrimmed(rpcURLFlag)))
+	}
+
+	client, err := newRPCClient(opts...)
+	if err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to create client: %v", err))
+	}
+	return client, nil
+}
+
+func applyOverrideState(entries map[string]string) error {
+	if debugOverrideStateFlag == "" {
+		return nil
+	}
+	overrides, err := loadOverrideState(debugOverrideStateFlag)
+	if err != nil {
+		return errors.WrapValidationError(fmt.Sprintf("failed to load override state: %v", err))
+	}
+	for k, v := range overrides {
+		entries[k] = v
+	}
+	fmt.Printf("Applied %d ledger entry overrides from %s\n", len(overrides), debugOverrideStateFlag)
+	return nil
+}
+
+// applyDebugRequestOptions copies the debug flags that tune the replay onto req.
+func applyDebugRequestOptions(req *simulator.SimulationRequest) {
+	if len(debugArgsFlag) > 0 {
+		mockArgs := debugArgsFlag
+		req.MockArgs = &mockArgs
+	}
+	if debugProtocolVersionFlag > 0 {
+		version := debugProtocolVersionFlag
+		req.ProtocolVersion = &version
+	}
+	if TimestampFlag != 0 {
+		req.Timestamp = TimestampFlag
+	}
+	req.Profile = ProfileFlag
+	applySimulationFeeMocks(req)
+}
+
+// applySimulationFeeMocks sets the mock fee parameters on req when the
+// corresponding flags were provided.
+func applySimulationFeeMocks(req *simulator.SimulationRequest) {
+	if mockBaseFeeFlag > 0 {
+		baseFee := mockBaseFeeFlag
+		req.MockBaseFee = &baseFee
+	}
+	if mockGasPriceFlag > 0 {
+		gasPrice := mockGasPriceFlag
+		req.MockGasPrice = &gasPrice
+	}
+}
+
+// parseEnvelopeXDRInput trims and validates a base64 TransactionEnvelope.
+func parseEnvelopeXDRInput(input string) (string, error) {
+	envelopeXdr := strings.TrimSpace(input)
+	if envelopeXdr == "" {
+		return "", errors.WrapValidationError("no transaction envelope provided on stdin")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(envelopeXdr)
+	if err != nil {
+		return "", errors.WrapUnmarshalFailed(err, "envelope base64")
+	}
+
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshal(raw, &envelope); err != nil {
+		return "", errors.WrapUnmarshalFailed(err, "TransactionEnvelope")
+	}
+
+	return envelopeXdr, nil
+}
+
+// extractLedgerKeys returns the base64 ledger keys of every entry touched by a
+// transaction, de-duplicated, from its TransactionResultMeta.
+func extractLedgerKeys(metaXdr string) ([]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(metaXdr)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta xdr.TransactionResultMeta
+	if err := xdr.SafeUnmarshal(raw, &meta); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	collect := func(changes xdr.LedgerEntryChanges) {
+		for i := range changes {
+			key, err := changes[i].LedgerKey()
+			if err != nil {
+				continue
+			}
+			encoded, err := rpc.EncodeLedgerKey(key)
+			if err != nil || seen[encoded] {
+				continue
+			}
+			seen[encoded] = true
+			keys = append(keys, encoded)
+		}
+	}
+
+	collect(meta.FeeProcessing)
+
+	txMeta := meta.TxApplyProcessing
+	switch txMeta.V {
+	case 0:
+		if txMeta.Operations != nil {
+			for _, op := range *txMeta.Operations {
+				collect(op.Changes)
+			}
+		}
+	case 1:
+		if v1 := txMeta.V1; v1 != nil {
+			collect(v1.TxChanges)
+			for _, op := range v1.Operations {
+				collect(op.Changes)
+			}
+		}
+	case 2:
+		if v2 := txMeta.V2; v2 != nil {
+			collect(v2.TxChangesBefore)
+			for _, op := range v2.Operations {
+				collect(op.Changes)
+			}
+			collect(v2.TxChangesAfter)
+		}
+	case 3:
+		if v3 := txMeta.V3; v3 != nil {
+			collect(v3.TxChangesBefore)
+			for _, op := range v3.Operations {
+				collect(op.Changes)
+			}
+			collect(v3.TxChangesAfter)
+		}
+	case 4:
+		if v4 := txMeta.V4; v4 != nil {
+			collect(v4.TxChangesBefore)
+			for _, op := range v4.Operations {
+				collect(op.Changes)
+			}
+			collect(v4.TxChangesAfter)
+		}
+	}
+
+	return keys, nil
+}
+
+// printSimulationResult prints the status, events and budget of a replay.
+func printSimulationResult(label string, result *simulator.SimulationResponse) {
+	fmt.Printf("\n=== %s Result ===\n", label)
+	if result.Status == "success" {
+		fmt.Printf("%s Status: %s\n", visualizer.Success(), result.Status)
+	} else {
+		fmt.Printf("%s Status: %s\n", visualizer.Error(), result.Status)
+	}
+	if result.Error != "" {
+		fmt.Printf("Error: %s\n", result.Error)
+	}
+	if result.ProtocolVersion != nil {
+		fmt.Printf("Protocol: %d\n", *result.ProtocolVersion)
+	}
+
+	if result.BudgetUsage != nil {
+		b := result.BudgetUsage
+		fmt.Printf("Budget: CPU %d/%d (%.1f%%), Memory %d/%d (%.1f%%)\n",
+			b.CPUInstructions, b.CPULimit, b.CPUUsagePercent,
+			b.MemoryBytes, b.MemoryLimit, b.MemoryUsagePercent)
+	}
+
+	if len(result.Events) > 0 {
+		fmt.Printf("Events: %d\n", len(result.Events))
+	}
+	if len(result.Logs) > 0 {
+		fmt.Println("Logs:")
+		for _, l := range result.Logs {
+			fmt.Printf("  %s\n", l)
+		}
+	}
+}
+
+// printCallTree decodes the diagnostic events into a call hierarchy and prints
+// it along with the fix suggestions of set.
+func printCallTree(result *simulator.SimulationResponse, set *rules.Set) {
+	if len(result.Events) == 0 {
+		return
+	}
+
+	root, err := decoder.DecodeEventsWithSpecs(result.Events, contractSpecs)
+	if err != nil {
+		logger.Logger.Warn("Failed to decode call tree", "error", err)
+		return
+	}
+
+	fmt.Println("\n=== Call Tree ===")
+	printCallNode(root, 0)
+
+	engine := decoder.NewSuggestionEngineWithRules(set)
+	if out := decoder.FormatSuggestions(engine.AnalyzeCallTree(root)); out != "" {
+		fmt.Println(out)
+	}
+}
+
+func printCallNode(node *decoder.CallNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	contractID := node.ContractID
+	if len(contractID) > 12 {
+		contractID = contractID[:12] + "..."
+	}
+	fmt.Printf("%s%s %s (%d events)\n", indent, contractID, node.Function, len(node.Events))
+	for _, child := range node.SubCalls {
+		printCallNode(child, depth+1)
+	}
+}
+
+func printDeprecatedHostFunctions(result *simulator.SimulationResponse) {
+	reported := make(map[string]bool)
+	for _, event := range result.DiagnosticEvents {
+		name, ok := deprecatedHostFunctionInDiagnosticEvent(event)
+		if !ok || reported[name] {
+			continue
+		}
+		reported[name] = true
+		fmt.Printf("%s Contract calls deprecated host function %q\n", visualizer.Warning(), name)
+	}
+}
+
+// deprecatedHostFunctionInDiagnosticEvent reports whether a diagnostic event
+// references a deprecated host function in its topics or data.
+func deprecatedHostFunctionInDiagnosticEvent(event simulator.DiagnosticEvent) (string, bool) {
+	for _, topic := range event.Topics {
+		if name, ok := findDeprecatedHostFunction(topic); ok {
+			return name, true
+		}
+	}
+	return findDeprecatedHostFunction(event.Data)
+}
+
+// findDeprecatedHostFunction returns the first deprecated host function name
+// that appears as a whole identifier in text.
+func findDeprecatedHostFunction(text string) (string, bool) {
+	for _, name := range deprecatedHostFunctions {
+		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		if re.MatchString(text) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// printSourceLocation shows the contract source line that trapped, using DWARF
+// information from --wasm when available.
+func printSourceLocation(result *simulator.SimulationResponse) {
+	if result.WasmOffset != nil {
+		if wasm := readDebugWasm(); len(wasm) > 0 {
+			parser, err := dwarf.NewParser(wasm)
+			if err == nil && parser.HasDebugInfo() {
+				if loc, err := parser.GetSourceLocation(*result.WasmOffset); err == nil && loc != nil {
+					displaySourceLocation(&simulator.SourceLocation{
+						File:   loc.File,
+						Line:   uint(loc.Line),
+						Column: uint(loc.Column),
+					})
+					return
+				}
+			}
+		}
+	}
+	if result.SourceLocation != "" {
+		fmt.Printf("\nSource: %s\n", result.SourceLocation)
+	}
+}
+
+// displaySourceLocation prints a source line with the failing span underlined.
+func displaySourceLocation(loc *simulator.SourceLocation) {
+	fmt.Printf("\n--> %s:%d:%d\n", loc.File, loc.Line, loc.Column)
+
+	content, err := os.ReadFile(loc.File)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(content), "\n")
+	if loc.Line == 0 || int(loc.Line) > len(lines) {
+		return
+	}
+
+	prefix := fmt.Sprintf("%d | ", loc.Line)
+	fmt.Printf("%s%s\n", prefix, lines[loc.Line-1])
+
+	width := uint(1)
+	if loc.ColumnEnd != nil && *loc.ColumnEnd > loc.Column {
+		width = *loc.ColumnEnd - loc.Column
+	}
+	padding := len(prefix)
+	if loc.Column > 0 {
+		padding += int(loc.Column) - 1
+	}
+	fmt.Printf("%s%s\n", strings.Repeat(" ", padding), visualizer.Colorize(strings.Repeat("^", int(width)), "red"))
+}
+
+func readDebugWasm() []byte {
+	if debugWasmFlag == "" {
+		return nil
+	}
+	wasm, err := os.ReadFile(debugWasmFlag)
+	if err != nil {
+		return nil
+	}
+	return wasm
+}
+
+func writeFlamegraph(txHash string, result *simulator.SimulationResponse) error {
+	if result.Flamegraph == "" {
+		fmt.Println("Warning: simulator did not return profiling data.")
+		return nil
+	}
+
+	format := visualizer.ExportFormat(ProfileFormatFlag)
+	name := txHash
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(debugWasmFlag), filepath.Ext(debugWasmFlag))
+	}
+	if name == "" {
+		name = "erst"
+	}
+	path := name + format.GetFileExtension()
+
+	if err := os.WriteFile(path, []byte(visualizer.ExportFlamegraph(result.Flamegraph, format)), 0644); err != nil {
+		return errors.WrapValidationError(fmt.Sprintf("failed to write flamegraph: %v", err))
+	}
+	fmt.Printf("\nFlamegraph written to %s\n", path)
+	return nil
+}
+
+func saveDebugSession(
+	cmd *cobra.Command,
+	simReq *simulator.SimulationRequest,
+	simResp *simulator.SimulationResponse,
+	data *session.SessionData,
+) error {
+	reqJSON, err := json.Marshal(simReq)
+	if err != nil {
+		return err
+	}
+	respJSON, err := json.Marshal(simResp)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data.ID = session.GenerateID(data.TxHash)
+	data.CreatedAt = now
+	data.LastAccessAt = now
+	data.Status = "active"
+	data.SimRequestJSON = string(reqJSON)
+	data.SimResponseJSON = string(respJSON)
+	data.ErstVersion = Version
+	data.SchemaVersion = session.SchemaVersion
+
+	SetCurrentSession(data)
+
+	if debugNoSessionFlag {
+		return nil
+	}
+
+	store, err := session.NewStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.Save(cmd.Context(), data); err != nil {
+		return err
+	}
+	fmt.Printf("\nSession saved: %s (resume with 'erst session resume %s')\n", data.ID, data.ID)
+	return nil
+}
+
+// buildExecutionTrace converts the diagnostic events of a replay into a
+// navigable trace for the interactive viewer.
+func buildExecutionTrace(txHash string, result *simulator.SimulationResponse) *trace.ExecutionTrace {
+	executionTrace := trace.NewExecutionTrace(txHash, 0)
+
+	for i, event := range result.DiagnosticEvents {
+		state := trace.ExecutionState{
+			Step:      i,
+			Timestamp: time.Now(),
+			Operation: event.EventType,
+			EventType: event.EventType,
+		}
+		if event.ContractID != nil {
+			state.ContractID = *event.ContractID
+		}
+		isCall := len(event.Topics) > 1 && strings.Contains(event.Topics[0], "fn_call")
+		if isCall {
+			// The host's fn_call topics are the marker, the callee and the
+			// function.
+			state.Function = event.Topics[len(event.Topics)-1]
+		}
+		if event.WasmInstruction != nil {
+			state.WasmInstruction = *event.WasmInstruction
+		}
+		if isCall {
+			state.Arguments, state.RawArguments = eventArguments(event)
+		} else if len(event.Topics) > 1 && strings.Contains(event.Topics[0], "fn_return") {
+			state.Function = event.Topics[1]
+			state.ReturnValue = event.Data
+			state.RawReturnValue = event.DataXDR
+		} else if event.Data != "" {
+			state.Arguments, state.RawArguments = eventArguments(event)
+		}
+		if !event.InSuccessfulContractCall && strings.Contains(strings.ToLower(event.Data), "error") {
+			state.Error = event.Data
+		}
+		executionTrace.AddState(state)
+	}
+
+	if result.Error != "" {
+		executionTrace.AddState(trace.ExecutionState{
+			Step:      len(result.DiagnosticEvents),
+			Timestamp: time.Now(),
+			Operation: "error",
+			EventType: trace.EventTypeTrap,
+			Error:     result.Error,
+		})
+	}
+
+	executionTrace.EndTime = time.Now()
+	return executionTrace
+}
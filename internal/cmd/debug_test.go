@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -262,6 +263,39 @@ func TestMockRunner_ImplementsInterface(t *testing.T) {
 	mockRunner.AssertExpectations(t)
 }
 
+func TestReplayDebugRequest_FailedTransaction(t *testing.T) {
+	mockRunner := new(MockRunner)
+	req := &simulator.SimulationRequest{EnvelopeXdr: "test-envelope"}
+	failed := &simulator.SimulationResponse{
+		Status: "error",
+		Error:  "HostError: Error(Contract, #1)",
+		Events: []string{"test-event"},
+	}
+
+	ctx := context.Background()
+	mockRunner.On("Run", ctx, req).Return((*simulator.SimulationResponse)(nil), &simulator.SimulatorError{
+		Err:      errors.New("contract trapped"),
+		Response: failed,
+	})
+
+	resp, err := replayDebugRequest(ctx, mockRunner, req)
+	assert.NoError(t, err)
+	assert.Equal(t, failed, resp)
+	mockRunner.AssertExpectations(t)
+}
+
+func TestReplayDebugRequest_CrashFails(t *testing.T) {
+	mockRunner := new(MockRunner)
+	req := &simulator.SimulationRequest{EnvelopeXdr: "test-envelope"}
+
+	ctx := context.Background()
+	mockRunner.On("Run", ctx, req).Return((*simulator.SimulationResponse)(nil), errors.New("simulator crashed"))
+
+	resp, err := replayDebugRequest(ctx, mockRunner, req)
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+}
+
 func TestExtractLedgerKeys(t *testing.T) {
 	// Create a dummy LedgerEntry
 	key := xdr.LedgerKey{
@@ -59,6 +59,8 @@ func init() {
 
 	_ = dryRunCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
 
+	registerStateSourceFlags(dryRunCmd)
+
 	rootCmd.AddCommand(dryRunCmd)
 }
 
@@ -92,7 +94,7 @@ func runDryRun(cmd *cobra.Command, args []string) error {
 		opts = append(opts, rpc.WithHorizonURL(dryRunRPCURLFlag))
 	}
 
-	client, err := rpc.NewClient(opts...)
+	client, err := newRPCClient(opts...)
 	if err != nil {
 		return errors.WrapValidationError(fmt.Sprintf("failed to create client: %v", err))
 	}
@@ -129,9 +131,9 @@ func runDryRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.WrapSimulationLogicError(fmt.Sprintf("failed to extract ledger keys from envelope: %v", err))
 	}
-	ledgerEntries, err := client.GetLedgerEntries(ctx, keys)
+	ledgerEntries, err := loadLedgerState(ctx, client, keys)
 	if err != nil {
-		return errors.WrapRPCConnectionFailed(err)
+		return err
 	}
 
 	// Warn if the fetched ledger entries exceed the Soroban network size limit.
@@ -139,7 +141,7 @@ func runDryRun(cmd *cobra.Command, args []string) error {
 	// is no point invoking the simulator — the tx will never land on-chain.
 	simulator.WarnLedgerEntriesSizeToStderr(ledgerEntries)
 
-	runner, err := simulator.NewRunner("", false)
+	runner, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return errors.WrapSimulatorNotFound(err.Error())
 	}
@@ -0,0 +1,114 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/decoder"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// renderEventValues returns a copy of resp whose diagnostic event topics and
+// data are rendered from their XDR, typed by the specs of the contracts
+// involved: struct fields are labelled, enums and unions are printed by
+// variant and token amounts are scaled by the token's decimals. Specs are
+// taken from contractSpecs and, when unknown, fetched through provider,
+// which may be nil. Events without XDR keep erst-sim's rendering.
+func renderEventValues(
+	ctx context.Context,
+	provider rpc.LedgerStateProvider,
+	resp *simulator.SimulationResponse,
+) *simulator.SimulationResponse {
+	type decodedEvent struct {
+		topics []xdr.ScVal
+		data   xdr.ScVal
+		ok     bool
+	}
+	decoded := make([]decodedEvent, len(resp.DiagnosticEvents))
+	found := false
+
+	var contracts []string
+	seen := make(map[string]bool)
+	for i, event := range resp.DiagnosticEvents {
+		if event.DataXDR == "" {
+			continue
+		}
+		topics, data, err := decoder.DecodeEventValues(event.TopicsXDR, event.DataXDR)
+		if err != nil {
+			logger.Logger.Debug("Failed to decode event values", "event", i, "error", err)
+			continue
+		}
+		decoded[i] = decodedEvent{topics: topics, data: data, ok: true}
+		found = true
+		for _, id := range decoder.EventContracts(eventContractID(event), topics) {
+			if !seen[id] {
+				seen[id] = true
+				contracts = append(contracts, id)
+			}
+		}
+	}
+	if !found {
+		return resp
+	}
+	fetchContractSpecs(ctx, provider, contracts...)
+
+	rendered := *resp
+	rendered.DiagnosticEvents = make([]simulator.DiagnosticEvent, len(resp.DiagnosticEvents))
+	for i, event := range resp.DiagnosticEvents {
+		if d := decoded[i]; d.ok {
+			event.Topics, event.Data = contractSpecs.FormatEvent(eventContractID(event), d.topics, d.data)
+		}
+		rendered.DiagnosticEvents[i] = event
+	}
+	return &rendered
+}
+
+func eventContractID(event simulator.DiagnosticEvent) string {
+	if event.ContractID == nil {
+		return ""
+	}
+	return strings.TrimSpace(*event.ContractID)
+}
+
+// eventArguments returns the arguments of a fn_call event rendered as
+// "name: value" pairs together with their base64 XDR, or of any other
+// event its rendered data and the data's XDR.
+func eventArguments(event simulator.DiagnosticEvent) ([]interface{}, []string) {
+	if event.DataXDR == "" {
+		if event.Data == "" {
+			return nil, nil
+		}
+		return []interface{}{event.Data}, []string{event.Data}
+	}
+
+	topics, data, err := decoder.DecodeEventValues(event.TopicsXDR, event.DataXDR)
+	if err == nil {
+		if call, ok := contractSpecs.ParseCall(topics, data); ok {
+			formatted := contractSpecs.Formatter(call.Callee).FormatArgs(call.Function, call.Args)
+			args := make([]interface{}, len(call.Args))
+			raw := make([]string, len(call.Args))
+			for i, arg := range call.Args {
+				args[i] = formatted[i]
+				raw[i] = encodeScVal(arg)
+			}
+			return args, raw
+		}
+	}
+	return []interface{}{event.Data}, []string{event.DataXDR}
+}
+
+func encodeScVal(v xdr.ScVal) string {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -4,13 +4,16 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/dotandev/hintents/internal/config"
+	"github.com/dotandev/hintents/internal/decoder"
 	"github.com/dotandev/hintents/internal/heuristic"
 	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/rules"
 	"github.com/dotandev/hintents/internal/simulator"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,7 @@ var (
 	explainNetworkFlag string
 	explainRPCURLFlag  string
 	explainRPCToken    string
+	explainRulesFlag   []string
 )
 
 var explainCmd = &cobra.Command{
@@ -31,9 +35,15 @@ explanation of the root cause of the failure.
 If a transaction hash is provided the command fetches and simulates it.
 When run immediately after 'erst debug', the active session is used instead.
 
+Explanations and suggested fixes come from rule packs: the built-in pack,
+then *.toml and *.json packs in ~/.erst/rules and .erst/rules, then any
+directory or file passed with --rules. A pack replaces earlier rules of the
+same name.
+
 Examples:
   erst explain 5c0a1234567890abcdef1234567890abcdef1234567890abcdef1234567890ab
   erst explain --network testnet <tx-hash>
+  erst explain --rules ./rules <tx-hash>
   erst debug <tx-hash> && erst explain`,
 	Args: cobra.MaximumNArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -52,13 +62,13 @@ Examples:
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			return explainFromSession()
+			return explainFromSession(cmd)
 		}
 		return explainFromNetwork(cmd, args[0])
 	},
 }
 
-func explainFromSession() error {
+func explainFromSession(cmd *cobra.Command) error {
 	sess := GetCurrentSession()
 	if sess == nil {
 		return fmt.Errorf("no active session; run 'erst debug <tx-hash>' first or provide a transaction hash")
@@ -71,18 +81,21 @@ func explainFromSession() error {
 		}
 	}
 
-	in := heuristic.Input{
-		TxHash:           sess.TxHash,
-		Network:          sess.Network,
-		Status:           simResp.Status,
-		Error:            simResp.Error,
-		Events:           simResp.Events,
-		Logs:             simResp.Logs,
-		DiagnosticEvents: simResp.DiagnosticEvents,
-		BudgetUsage:      simResp.BudgetUsage,
+	// Contract specs come from the ledger entries of the saved request, or
+	// from the session's network when the failing contract is not among them.
+	var simReq *simulator.SimulationRequest
+	if sess.SimRequestJSON != "" {
+		var req simulator.SimulationRequest
+		if err := json.Unmarshal([]byte(sess.SimRequestJSON), &req); err == nil {
+			simReq = &req
+		}
 	}
-	fmt.Println(heuristic.Summarize(in))
-	return nil
+	var provider rpc.LedgerStateProvider
+	if client, err := newRPCClient(rpc.WithNetwork(rpc.Network(sess.Network))); err == nil {
+		provider = client
+	}
+	in := explainInput(cmd.Context(), sess.TxHash, sess.Network, provider, simReq, &simResp)
+	return printExplanation(in)
 }
 
 func explainFromNetwork(cmd *cobra.Command, txHash string) error {
@@ -104,7 +117,7 @@ func explainFromNetwork(cmd *cobra.Command, txHash string) error {
 		opts = append(opts, rpc.WithHorizonURL(explainRPCURLFlag))
 	}
 
-	client, err := rpc.NewClient(opts...)
+	client, err := newRPCClient(opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -119,39 +132,78 @@ func explainFromNetwork(cmd *cobra.Command, txHash string) error {
 		keys = nil
 	}
 
-	ledgerEntries, err := rpc.ExtractLedgerEntriesFromMeta(resp.ResultMetaXdr)
-	if err != nil {
-		ledgerEntries, err = client.GetLedgerEntries(cmd.Context(), keys)
+	var ledgerEntries map[string]string
+	if stateSourceChanged(cmd) {
+		ledgerEntries, err = loadLedgerState(cmd.Context(), client, keys)
+		if err != nil {
+			return err
+		}
+	} else if ledgerEntries, err = rpc.ExtractLedgerEntriesFromMeta(resp.ResultMetaXdr); err != nil {
+		ledgerEntries, err = loadLedgerState(cmd.Context(), client, keys)
 		if err != nil {
 			ledgerEntries = nil
 		}
 	}
 
-	runner, err := simulator.NewRunner("", false)
+	simRunner, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return fmt.Errorf("failed to initialize simulator: %w", err)
 	}
+	runner := newCachedRunner(simRunner)
+	defer runner.Close()
 
-	simResp, err := runner.Run(cmd.Context(), &simulator.SimulationRequest{
+	simReq := &simulator.SimulationRequest{
 		EnvelopeXdr:   resp.EnvelopeXdr,
 		ResultMetaXdr: resp.ResultMetaXdr,
 		LedgerEntries: ledgerEntries,
-	})
+	}
+	simResp, err := simulationResponse(runner.Run(cmd.Context(), simReq))
 	if err != nil {
 		return fmt.Errorf("simulation failed: %w", err)
 	}
 
-	in := heuristic.Input{
+	return printExplanation(explainInput(cmd.Context(), txHash, explainNetworkFlag, client, simReq, simResp))
+}
+
+// explainInput builds the heuristic input of a replay, with contract error
+// codes resolved to their named variants where the contract spec is
+// available.
+func explainInput(
+	ctx context.Context,
+	txHash, network string,
+	provider rpc.LedgerStateProvider,
+	req *simulator.SimulationRequest,
+	resp *simulator.SimulationResponse,
+) heuristic.Input {
+	display, contractErr := resolveContractErrors(ctx, provider, req, resp)
+	return heuristic.Input{
 		TxHash:           txHash,
-		Network:          explainNetworkFlag,
-		Status:           simResp.Status,
-		Error:            simResp.Error,
-		Events:           simResp.Events,
-		Logs:             simResp.Logs,
-		DiagnosticEvents: simResp.DiagnosticEvents,
-		BudgetUsage:      simResp.BudgetUsage,
-	}
-	fmt.Println(heuristic.Summarize(in))
+		Network:          network,
+		Status:           display.Status,
+		Error:            display.Error,
+		Events:           display.Events,
+		Logs:             display.Logs,
+		DiagnosticEvents: display.DiagnosticEvents,
+		BudgetUsage:      display.BudgetUsage,
+		ContractError:    contractErr,
+	}
+}
+
+// printExplanation prints the summary of in followed by the fixes suggested
+// by the loaded rule packs.
+func printExplanation(in heuristic.Input) error {
+	set, err := rules.Load(explainRulesFlag...)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	fmt.Println(heuristic.SummarizeWith(set, in))
+	if in.Status == "success" {
+		return nil
+	}
+	if out := decoder.FormatSuggestions(decoder.SuggestionsFromFindings(set.Fixes(in.RuleInput()))); out != "" {
+		fmt.Println(out)
+	}
 	return nil
 }
 
@@ -159,8 +211,11 @@ func init() {
 	explainCmd.Flags().StringVarP(&explainNetworkFlag, "network", "n", "mainnet", "Stellar network (testnet, mainnet, futurenet)")
 	explainCmd.Flags().StringVar(&explainRPCURLFlag, "rpc-url", "", "Custom RPC URL")
 	explainCmd.Flags().StringVar(&explainRPCToken, "rpc-token", "", "RPC authentication token (can also use ERST_RPC_TOKEN env var)")
+	explainCmd.Flags().StringSliceVar(&explainRulesFlag, "rules", nil, "Rule pack directory or file to load on top of the built-in and configured packs (repeatable)")
 
 	_ = explainCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
 
+	registerStateSourceFlags(explainCmd)
+
 	rootCmd.AddCommand(explainCmd)
 }
@@ -6,6 +6,9 @@ package cmd
 import (
 	"encoding/hex"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/dotandev/hintents/internal/simulator"
 	"github.com/spf13/cobra"
@@ -19,6 +22,11 @@ var (
 	fuzzInputXDR       string
 	fuzzEnableCov      bool
 	fuzzTargetContract string
+	fuzzContractWasm   string
+	fuzzFunction       string
+	fuzzCorpusDir      string
+	fuzzResume         bool
+	fuzzMinimizeCorpus bool
 )
 
 var fuzzCmd = &cobra.Command{
@@ -35,17 +43,53 @@ other issues in contract code.
 Fuzzing can be started with a base XDR input which will be mutated for
 subsequent iterations, or fuzzing can be run on random inputs.
 
+With --contract and --function the fuzzer reads the contract spec embedded in
+the WASM and generates well-typed arguments for the function: integers at
+their boundaries, addresses, vectors, maps and user-defined types built to
+the spec. Each crash is shrunk to a minimal reproduction.
+
+With --coverage or --corpus, erst-sim's coverage report is fed back into the
+fuzzer: inputs that reach new code are kept and mutated further, in the style
+of libFuzzer. --corpus stores these inputs, plus one reproduction per crash
+signature, in a directory so later campaigns can build on them. --resume
+continues the campaign saved there, and --minimize-corpus drops entries whose
+coverage other entries already reach.
+
+--invariants checks every run that does not crash against the invariants
+declared in the file. A violation is reported separately from crashes, with
+the seed and a shrunk reproduction.
+
 Examples:
   erst fuzz --iterations 10000
   erst fuzz --iterations 50000 --workers 8
-  erst fuzz --xdr <hex-encoded-xdr> --iterations 5000`,
+  erst fuzz --xdr <hex-encoded-xdr> --iterations 5000
+  erst fuzz --contract token.wasm --function transfer --iterations 2000
+  erst fuzz --contract token.wasm --function transfer --corpus ./corpus --iterations 10000
+  erst fuzz --contract token.wasm --function transfer --corpus ./corpus --resume --iterations 10000
+  erst fuzz --corpus ./corpus --minimize-corpus`,
 	RunE: runFuzz,
 }
 
 func runFuzz(cmd *cobra.Command, args []string) error {
-	if fuzzIterations == 0 {
+	minimizeOnly := fuzzMinimizeCorpus && fuzzIterations == 0
+	if fuzzIterations == 0 && !minimizeOnly {
 		return fmt.Errorf("--iterations must be specified and greater than 0")
 	}
+	if fuzzCorpusDir == "" && (fuzzResume || fuzzMinimizeCorpus) {
+		return fmt.Errorf("--resume and --minimize-corpus require --corpus")
+	}
+
+	var corpus *simulator.FuzzCorpus
+	if fuzzCorpusDir != "" {
+		var err error
+		corpus, err = simulator.OpenFuzzCorpus(fuzzCorpusDir)
+		if err != nil {
+			return fmt.Errorf("failed to open corpus: %w", err)
+		}
+	}
+	if minimizeOnly {
+		return minimizeFuzzCorpus(corpus)
+	}
 
 	fmt.Printf("Starting fuzzing campaign\n")
 	fmt.Printf("  Iterations: %d\n", fuzzIterations)
@@ -60,23 +104,62 @@ func runFuzz(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Target Contract: %s\n", fuzzTargetContract)
 	}
 
+	if corpus != nil {
+		fmt.Printf("  Corpus: %s (%d entries, %d known crashes)\n", corpus.Dir, len(corpus.Entries()), len(corpus.Crashes()))
+		if state := corpus.State(); fuzzResume && state.Iterations > 0 {
+			fmt.Printf("  Resuming after iteration %d\n", state.Iterations)
+		}
+	}
+
+	if fuzzContractWasm != "" && fuzzFunction == "" {
+		return fmt.Errorf("--function is required with --contract")
+	}
+	if fuzzFunction != "" && fuzzContractWasm == "" {
+		return fmt.Errorf("--contract is required with --function")
+	}
+
+	invariants, err := loadInvariants()
+	if err != nil {
+		return err
+	}
+	if invariants != nil {
+		fmt.Printf("  Invariants: %d from %s\n", len(invariants.Invariants), invariantsFlag)
+	}
+
 	// Initialize simulator runner
-	runner, err := simulator.NewRunner("", false)
+	runner, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return fmt.Errorf("failed to initialize simulator: %w", err)
 	}
+	registerRunnerCloseHook("fuzz-simulator-runner", runner)
+	defer func() { _ = runner.Close() }()
 
 	// Create fuzzing configuration
 	config := simulator.FuzzingConfig{
 		MaxIterations:    fuzzIterations,
 		TimeoutMs:        fuzzTimeout,
 		MaxInputSize:     fuzzMaxSize,
-		EnableCoverage:   fuzzEnableCov,
+		EnableCoverage:   fuzzEnableCov || corpus != nil,
 		TargetContractID: fuzzTargetContract,
+		Resume:           fuzzResume,
 	}
 
 	// Create fuzzing harness
 	harness := simulator.NewFuzzingHarness(runner, config)
+	harness.Corpus = corpus
+	if invariants != nil {
+		harness.Invariants = invariants
+	}
+
+	if fuzzContractWasm != "" {
+		fuzzErr := runSpecFuzz(harness)
+		if fuzzMinimizeCorpus {
+			if err := minimizeFuzzCorpus(corpus); err != nil {
+				return err
+			}
+		}
+		return fuzzErr
+	}
 
 	// If specific XDR is provided, validate and fuzz it
 	if fuzzInputXDR != "" {
@@ -117,22 +200,25 @@ func runFuzz(cmd *cobra.Command, args []string) error {
 	}
 
 	ctx := cmd.Context()
-
-	// Note: This is a simplified version. Production fuzzing would:
-	// 1. Use libFuzzer binaries directly
-	// 2. Have proper corpus management
-	// 3. Integrate with coverage feedback
-
 	_ = ctx // Use context for potential future cancellation
 
 	results, crashingInputs, err := harness.Fuzz(baseInput)
 	if err != nil {
 		return fmt.Errorf("fuzzing campaign failed: %w", err)
 	}
+	if fuzzMinimizeCorpus {
+		if err := minimizeFuzzCorpus(corpus); err != nil {
+			return err
+		}
+	}
 
 	// Print summary
 	fmt.Println("\n" + harness.Summary())
 
+	printFuzzViolations(harness, func(input simulator.FuzzerInput) string {
+		return fmt.Sprintf("seed %d", input.Seed)
+	})
+
 	// Print first few crashing inputs if found
 	if len(crashingInputs) > 0 {
 		fmt.Printf("\n%d unique crash(es) found!\n", len(crashingInputs))
@@ -150,6 +236,9 @@ func runFuzz(cmd *cobra.Command, args []string) error {
 		}
 		return fmt.Errorf("fuzzing found %d crashes", len(crashingInputs))
 	}
+	if len(harness.ViolatingInputs) > 0 {
+		return fmt.Errorf("fuzzing found %d invariant violation(s)", len(harness.ViolatingInputs))
+	}
 
 	if len(results) > 0 {
 		fmt.Printf("\nFuzzing completed: %d/%d tests passed\n",
@@ -161,6 +250,119 @@ func runFuzz(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runSpecFuzz fuzzes --function of the --contract WASM with arguments built
+// from the contract spec.
+func runSpecFuzz(harness *simulator.FuzzingHarness) error {
+	wasm, err := os.ReadFile(fuzzContractWasm)
+	if err != nil {
+		return fmt.Errorf("failed to read contract: %w", err)
+	}
+	mutator, err := simulator.LoadSpecMutator(wasm, fuzzFunction)
+	if err != nil {
+		return fmt.Errorf("failed to load contract spec: %w", err)
+	}
+
+	seed := fuzzSeed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	if harness.Corpus != nil && fuzzResume {
+		if state := harness.Corpus.State(); state.Iterations > 0 {
+			seed = state.Seed
+		}
+	}
+
+	fn := mutator.Function()
+	params := make([]string, len(fn.Inputs))
+	for i, in := range fn.Inputs {
+		params[i] = in.Name
+	}
+	fmt.Printf("  Function: %s(%s)\n", fuzzFunction, strings.Join(params, ", "))
+	fmt.Printf("  Seed: %d\n", seed)
+	fmt.Println("\nStarting spec-aware fuzzing campaign...")
+
+	target := simulator.SpecFuzzTarget{
+		Wasm:     wasm,
+		WasmPath: fuzzContractWasm,
+		Mutator:  mutator,
+	}
+	_, crashingInputs, err := harness.FuzzSpec(target, seed)
+	if err != nil {
+		return fmt.Errorf("fuzzing campaign failed: %w", err)
+	}
+
+	fmt.Println("\n" + harness.Summary())
+
+	printFuzzViolations(harness, formatSpecCall)
+
+	if len(crashingInputs) == 0 {
+		if len(harness.ViolatingInputs) > 0 {
+			return fmt.Errorf("fuzzing found %d invariant violation(s)", len(harness.ViolatingInputs))
+		}
+		return nil
+	}
+
+	fmt.Printf("\n%d unique crash(es) found, shrunk to minimal inputs:\n", len(crashingInputs))
+	for i, input := range crashingInputs {
+		fmt.Printf("  Crash %d (seed %d): %s\n", i+1, input.Seed, formatSpecCall(input))
+		for _, result := range harness.Results {
+			if result.Seed == input.Seed && result.Status == "crash" {
+				fmt.Printf("    %s\n", result.ErrorMessage)
+				fmt.Printf("    signature: %s\n", result.Signature)
+				break
+			}
+		}
+		fmt.Printf("    args: %s\n", strings.Join(input.Args, " "))
+	}
+	return fmt.Errorf("fuzzing found %d crashes", len(crashingInputs))
+}
+
+// formatSpecCall renders a spec-fuzzing input as a call, for example
+// transfer(GAAZ…, 1001).
+func formatSpecCall(input simulator.FuzzerInput) string {
+	args, err := simulator.DecodeScVals(input.Args)
+	if err != nil {
+		return strings.Join(input.Args, " ")
+	}
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		rendered[i] = simulator.FormatScVal(arg)
+	}
+	return fmt.Sprintf("%s(%s)", fuzzFunction, strings.Join(rendered, ", "))
+}
+
+// printFuzzViolations lists one reproduction per violated invariant.
+func printFuzzViolations(harness *simulator.FuzzingHarness, describe func(simulator.FuzzerInput) string) {
+	if len(harness.ViolatingInputs) == 0 {
+		return
+	}
+	fmt.Printf("\n%d invariant violation(s) found:\n", len(harness.ViolatingInputs))
+	for i, input := range harness.ViolatingInputs {
+		fmt.Printf("  Violation %d (seed %d): %s\n", i+1, input.Seed, describe(input))
+		for _, result := range harness.Results {
+			if result.Seed == input.Seed && result.Status == "invariant" {
+				fmt.Printf("    %s\n", result.ErrorMessage)
+				break
+			}
+		}
+		if len(input.Args) > 0 {
+			fmt.Printf("    args: %s\n", strings.Join(input.Args, " "))
+		}
+	}
+}
+
+// minimizeFuzzCorpus drops corpus entries whose coverage is reached by other
+// entries.
+func minimizeFuzzCorpus(corpus *simulator.FuzzCorpus) error {
+	before := len(corpus.Entries())
+	removed, err := corpus.Minimize()
+	if err != nil {
+		return fmt.Errorf("failed to minimize corpus: %w", err)
+	}
+	fmt.Printf("\nCorpus minimized: %d -> %d entries (%d features)\n", before, before-removed, corpus.Covered())
+	return nil
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -201,7 +403,7 @@ func init() {
 		&fuzzEnableCov,
 		"coverage",
 		false,
-		"Enable code coverage tracking (requires instrumented binary)",
+		"Feed erst-sim coverage back into input selection",
 	)
 
 	fuzzCmd.Flags().StringVar(
@@ -211,5 +413,49 @@ func init() {
 		"Optional target contract ID to focus fuzzing on",
 	)
 
+	fuzzCmd.Flags().StringVar(
+		&fuzzContractWasm,
+		"contract",
+		"",
+		"Contract WASM to fuzz with spec-generated arguments",
+	)
+
+	fuzzCmd.Flags().StringVar(
+		&fuzzFunction,
+		"function",
+		"",
+		"Contract function to fuzz (requires --contract)",
+	)
+
+	fuzzCmd.Flags().Uint64Var(
+		&fuzzSeed,
+		"seed",
+		0,
+		"Random seed for reproducible campaigns (default: time-based)",
+	)
+
+	fuzzCmd.Flags().StringVar(
+		&fuzzCorpusDir,
+		"corpus",
+		"",
+		"Directory that keeps inputs reaching new coverage and crash reproductions (enables --coverage)",
+	)
+
+	fuzzCmd.Flags().BoolVar(
+		&fuzzResume,
+		"resume",
+		false,
+		"Resume the campaign saved in --corpus",
+	)
+
+	fuzzCmd.Flags().BoolVar(
+		&fuzzMinimizeCorpus,
+		"minimize-corpus",
+		false,
+		"Drop corpus entries whose coverage other entries reach (runs alone when --iterations is 0)",
+	)
+
+	registerInvariantsFlag(fuzzCmd)
+
 	rootCmd.AddCommand(fuzzCmd)
 }
@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/dotandev/hintents/internal/rpc"
@@ -24,12 +25,17 @@ var generateTestCmd = &cobra.Command{
 	Long: `Generate regression tests from a recorded transaction trace.
 This creates test files that can be used to ensure bugs don't reoccur.
 
-The command fetches the transaction data from the network and generates
-test files in Go and/or Rust that replay the transaction.
+The command fetches the transaction data from the network, or from a local
+history archive mirror with --archive, and generates test files in Go and/or
+Rust that replay the transaction. The ledger entries the transaction read are
+embedded in the tests, reconstructed from its meta and read through
+--state-from.
 
 Example:
   erst generate-test 5c0a1234567890abcdef1234567890abcdef1234567890abcdef1234567890ab
-  erst generate-test --lang go --name my_test <tx-hash>`,
+  erst generate-test --lang go --name my_test <tx-hash>
+  erst generate-test --archive ./history <tx-hash>
+  erst generate-test --state-from snapshot:fixture.json <tx-hash>`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		txHash := args[0]
@@ -43,7 +49,7 @@ Example:
 			opts = append(opts, rpc.WithHorizonURL(rpcURLFlag))
 		}
 
-		client, err := rpc.NewClient(opts...)
+		client, err := newRPCClient(opts...)
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
@@ -53,8 +59,16 @@ Example:
 			genTestOutput = "."
 		}
 
+		source, err := transactionSource(client)
+		if err != nil {
+			return err
+		}
+
 		// Create test generator
-		generator := testgen.NewTestGenerator(client, genTestOutput)
+		generator := testgen.NewTestGenerator(source, genTestOutput)
+		generator.State = func(ctx context.Context, txHash string, tx *rpc.TransactionResponse) (map[string]string, error) {
+			return loadHistoricalState(ctx, client, txHash, tx, "")
+		}
 
 		// Generate tests
 		fmt.Printf("Generating %s regression test(s) for transaction: %s\n", genTestLang, txHash)
@@ -75,5 +89,8 @@ func init() {
 	generateTestCmd.Flags().StringVar(&rpcURLFlag, "rpc-url", "", "Custom Horizon RPC URL to use")
 	generateTestCmd.Flags().StringVar(&rpcTokenFlag, "rpc-token", "", "RPC authentication token (can also use ERST_RPC_TOKEN env var)")
 
+	registerStateSourceFlags(generateTestCmd)
+	registerArchiveFlag(generateTestCmd)
+
 	rootCmd.AddCommand(generateTestCmd)
 }
@@ -0,0 +1,49 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/invariant"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/visualizer"
+	"github.com/spf13/cobra"
+)
+
+// invariantsFlag is the invariants file shared by debug, fuzz and
+// regression-test.
+var invariantsFlag string
+
+// registerInvariantsFlag adds --invariants to cmd.
+func registerInvariantsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&invariantsFlag, "invariants", "",
+		"JSON file of invariants checked against every execution (see docs/CLI.md)")
+}
+
+// loadInvariants reads the --invariants file, returning nil when the flag is
+// not set.
+func loadInvariants() (*invariant.Set, error) {
+	if invariantsFlag == "" {
+		return nil, nil
+	}
+	set, err := invariant.Load(invariantsFlag)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// printInvariantResult prints the outcome of checking set against one
+// execution.
+func printInvariantResult(set *invariant.Set, violations []simulator.InvariantViolation) {
+	fmt.Printf("\n=== Invariants ===\n")
+	if len(violations) == 0 {
+		fmt.Printf("%s All %d invariant(s) hold\n", visualizer.Success(), len(set.Invariants))
+		return
+	}
+	for _, v := range violations {
+		fmt.Printf("%s %s: %s\n", visualizer.Error(), v.Invariant, v.Message)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/regress"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/visualizer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	regressCorpusFlag   string
+	regressHashesFlag   string
+	regressGoldenFlag   bool
+	regressSimPathFlag  string
+	regressJUnitFlag    string
+	regressWorkersFlag  int
+	regressProtocolFlag uint32
+	regressUpdateFlag   bool
+)
+
+var regressCmd = &cobra.Command{
+	Use:     "regress",
+	GroupID: "testing",
+	Short:   "Record and replay an offline regression corpus",
+	Long: `Keep a corpus of recorded transactions and replay it against erst-sim without
+network access, so every erst-sim upgrade can be checked for behavior drift in CI.
+
+Available subcommands:
+  record  - Snapshot transactions into a corpus directory
+  run     - Replay a corpus and diff the results against the recorded outcomes`,
+	Example: `  # Record two transactions into a corpus
+  erst regress record --corpus ./corpus <tx-hash> <tx-hash>
+
+  # Replay it in CI and write a JUnit report
+  erst regress run --corpus ./corpus --junit regress.xml`,
+}
+
+var regressRecordCmd = &cobra.Command{
+	Use:   "record [transaction-hash...]",
+	Short: "Snapshot transactions into a regression corpus",
+	Long: `Fetch each transaction with the ledger entries it touched and store it in the
+corpus directory, together with its on-chain outcome and a golden erst-sim
+response. Recording a transaction again replaces its case.
+
+Hashes are read from the arguments and from --hashes, one per line.`,
+	Example: `  erst regress record --corpus ./corpus <tx-hash>
+  erst regress record --corpus ./corpus --hashes failed-txs.txt --network testnet`,
+	RunE: runRegressRecord,
+}
+
+var regressRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Replay a regression corpus offline",
+	Long: `Replay every case of the corpus against the current erst-sim and diff the
+results with the compare engine. A case fails when the replay no longer matches
+the on-chain outcome, its events or call path drift from the golden response,
+or a --invariants invariant is violated.
+
+--update accepts the current behavior and rewrites the golden responses.`,
+	Example: `  erst regress run --corpus ./corpus
+  erst regress run --corpus ./corpus --junit regress.xml --workers 8
+  erst regress run --corpus ./corpus --update`,
+	Args: cobra.NoArgs,
+	RunE: runRegressRun,
+}
+
+func runRegressRecord(cmd *cobra.Command, args []string) error {
+	hashes, err := regressHashes(args)
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return errors.WrapCliArgumentRequired("transaction-hash")
+	}
+	for _, hash := range hashes {
+		if err := rpc.ValidateTransactionHash(hash); err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("invalid transaction hash %s: %v", hash, err))
+		}
+	}
+
+	corpus, err := regress.CreateCorpus(regressCorpusFlag, networkFlag)
+	if err != nil {
+		return errors.WrapValidationError(err.Error())
+	}
+
+	client, err := newDebugClient()
+	if err != nil {
+		return err
+	}
+
+	var runner simulator.RunnerInterface
+	if regressGoldenFlag {
+		runner, err = simulator.NewRunnerInterface(regressSimPathFlag, false)
+		if err != nil {
+			return errors.WrapSimulatorNotFound(err.Error())
+		}
+		registerRunnerCloseHook("regress-simulator-runner", runner)
+		defer func() { _ = runner.Close() }()
+	}
+
+	fmt.Printf("Recording %d transaction(s) from %s into %s\n", len(hashes), networkFlag, regressCorpusFlag)
+	failures := 0
+	for _, hash := range hashes {
+		c, err := recordRegressCase(cmd, client, runner, hash)
+		if err == nil {
+			err = corpus.Put(c)
+		}
+		if err != nil {
+			failures++
+			fmt.Printf("  %s %s: %v\n", visualizer.Error(), hash, err)
+			continue
+		}
+		outcome := c.Expected.ResultCode
+		if c.Expected.Golden != nil {
+			outcome += ", replay " + c.Expected.Golden.Status
+		}
+		fmt.Printf("  %s %s (%d ledger entries, %s)\n", visualizer.Success(), hash, len(c.LedgerEntries), outcome)
+	}
+
+	fmt.Printf("\nCorpus now holds %d case(s)\n", len(corpus.Manifest.Cases))
+	if failures > 0 {
+		return fmt.Errorf("failed to record %d of %d transaction(s)", failures, len(hashes))
+	}
+	return nil
+}
+
+// recordRegressCase fetches one transaction and its ledger state and, with a
+// runner, replays it once to capture the golden response.
+func recordRegressCase(cmd *cobra.Command, client *rpc.Client, runner simulator.RunnerInterface, hash string) (*regress.Case, error) {
+	ctx := cmd.Context()
+
+	txResp, err := client.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, errors.WrapRPCConnectionFailed(err)
+	}
+	keys, err := extractLedgerKeys(txResp.ResultMetaXdr)
+	if err != nil {
+		return nil, errors.WrapUnmarshalFailed(err, "result meta")
+	}
+	entries, err := loadLedgerState(ctx, client, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := regress.NewCase(hash, networkFlag, txResp.EnvelopeXdr, txResp.ResultXdr, txResp.ResultMetaXdr, entries)
+	if err != nil {
+		return nil, err
+	}
+	if runner == nil {
+		return c, nil
+	}
+
+	resp, err := runner.Run(ctx, c.Request())
+	if err != nil {
+		var simErr *simulator.SimulatorError
+		if !errors.As(err, &simErr) || simErr.Response == nil {
+			return nil, errors.WrapSimulationFailed(err, "")
+		}
+		resp = simErr.Response
+	}
+	c.SetGolden(resp)
+	return c, nil
+}
+
+// regressHashes merges the hashes given as arguments with those listed in
+// --hashes, skipping blank lines and # comments.
+func regressHashes(args []string) ([]string, error) {
+	hashes := append([]string(nil), args...)
+	if regressHashesFlag == "" {
+		return hashes, nil
+	}
+
+	f, err := os.Open(regressHashesFlag)
+	if err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to read hashes file: %v", err))
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to read hashes file: %v", err))
+	}
+	return hashes, nil
+}
+
+func runRegressRun(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	corpus, err := regress.OpenCorpus(regressCorpusFlag)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.WrapValidationError(fmt.Sprintf("no regression corpus in %s (create one with 'erst regress record')", regressCorpusFlag))
+		}
+		return errors.WrapValidationError(err.Error())
+	}
+	cases, err := corpus.Cases()
+	if err != nil {
+		return errors.WrapValidationError(err.Error())
+	}
+	if len(cases) == 0 {
+		return errors.WrapValidationError(fmt.Sprintf("regression corpus %s is empty", regressCorpusFlag))
+	}
+
+	invariants, err := loadInvariants()
+	if err != nil {
+		return errors.WrapValidationError(err.Error())
+	}
+
+	runner, err := simulator.NewRunnerInterface(regressSimPathFlag, false)
+	if err != nil {
+		return errors.WrapSimulatorNotFound(err.Error())
+	}
+	registerRunnerCloseHook("regress-simulator-runner", runner)
+	defer func() { _ = runner.Close() }()
+
+	replayer := &regress.Replayer{Runner: runner, MaxWorkers: regressWorkersFlag}
+	if regressProtocolFlag > 0 {
+		if err := simulator.Validate(regressProtocolFlag); err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("invalid protocol version: %v", err))
+		}
+		replayer.ProtocolVersion = &regressProtocolFlag
+	}
+	if invariants != nil {
+		replayer.Invariants = invariants
+	}
+
+	fmt.Printf("Replaying %d case(s) from %s (recorded on %s)\n", len(cases), regressCorpusFlag, corpus.Manifest.Network)
+	report := replayer.Run(ctx, cases)
+
+	if regressUpdateFlag {
+		return updateRegressGoldens(corpus, cases, report)
+	}
+
+	fmt.Println("\n" + report.Summary())
+
+	if regressJUnitFlag != "" {
+		if err := regress.WriteJUnitFile(regressJUnitFlag, filepath.Base(filepath.Clean(regressCorpusFlag)), report); err != nil {
+			return err
+		}
+		fmt.Printf("JUnit report written to %s\n", regressJUnitFlag)
+	}
+
+	failed := report.FailedResults()
+	if len(failed) == 0 {
+		fmt.Println("\nNo behavior drift detected.")
+		return nil
+	}
+
+	fmt.Printf("\n%d case(s) failed:\n", len(failed))
+	for i, result := range failed {
+		if i == 10 {
+			fmt.Printf("  ... and %d more\n", len(failed)-10)
+			break
+		}
+		fmt.Printf("  [%s] %s: %s\n", result.Status, result.Hash, result.Message)
+		if len(result.Details) > 1 {
+			for _, detail := range result.Details[1:] {
+				fmt.Printf("      %s\n", detail)
+			}
+		}
+	}
+	return fmt.Errorf("regression corpus run failed with %d failure(s) and %d error(s)", report.Failed, report.Errors)
+}
+
+// updateRegressGoldens stores the responses of report as the new golden
+// responses. Cases whose replay produced no response keep their golden.
+func updateRegressGoldens(corpus *regress.Corpus, cases []*regress.Case, report *regress.Report) error {
+	updated := 0
+	for i, result := range report.Results {
+		if result.Response == nil {
+			fmt.Printf("  %s %s: %s\n", visualizer.Warning(), result.Hash, result.Message)
+			continue
+		}
+		if result.Status == regress.StatusPass && cases[i].Expected.Golden != nil {
+			continue
+		}
+		cases[i].SetGolden(result.Response)
+		if err := corpus.Put(cases[i]); err != nil {
+			return err
+		}
+		updated++
+	}
+	fmt.Printf("Updated %d golden response(s)\n", updated)
+	return nil
+}
+
+func init() {
+	regressCmd.PersistentFlags().StringVar(&regressCorpusFlag, "corpus", "", "Regression corpus directory (required)")
+	regressCmd.PersistentFlags().StringVar(&regressSimPathFlag, "sim-path", "", "Path to erst-sim binary (overrides auto-discovery)")
+	_ = regressCmd.MarkPersistentFlagRequired("corpus")
+
+	regressRecordCmd.Flags().StringVarP(&networkFlag, "network", "n", string(rpc.Mainnet), "Stellar network to use (testnet, mainnet, futurenet)")
+	regressRecordCmd.Flags().StringVar(&rpcURLFlag, "rpc-url", "", "Custom Horizon RPC URL to use")
+	regressRecordCmd.Flags().StringVar(&rpcTokenFlag, "rpc-token", "", "RPC authentication token (can also use ERST_RPC_TOKEN env var)")
+	regressRecordCmd.Flags().StringVar(&regressHashesFlag, "hashes", "", "File of transaction hashes to record, one per line")
+	regressRecordCmd.Flags().BoolVar(&regressGoldenFlag, "golden", true, "Replay each transaction once to store a golden erst-sim response")
+	_ = regressRecordCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
+	registerStateSourceFlags(regressRecordCmd)
+
+	regressRunCmd.Flags().StringVar(&regressJUnitFlag, "junit", "", "Write a JUnit XML report to this file")
+	regressRunCmd.Flags().IntVar(&regressWorkersFlag, "workers", 4, "Number of parallel replays")
+	regressRunCmd.Flags().Uint32Var(&regressProtocolFlag, "protocol-version", 0, "Override protocol version for every replay (20, 21, 22, …)")
+	regressRunCmd.Flags().BoolVar(&regressUpdateFlag, "update", false, "Accept the current behavior and rewrite drifted golden responses")
+	registerInvariantsFlag(regressRunCmd)
+
+	regressCmd.AddCommand(regressRecordCmd)
+	regressCmd.AddCommand(regressRunCmd)
+
+	rootCmd.AddCommand(regressCmd)
+}
@@ -30,11 +30,15 @@ simulates them in parallel, verifying that the simulator produces the same
 traps and events as the original network execution.
 
 The tests help ensure that protocol changes don't introduce regressions.
+With --invariants, every replay is also checked against the declared
+invariants, and a violation fails that transaction.
 
 Example:
   erst regression-test --count 100
   erst regression-test --count 1000 --workers 8
-  erst regression-test --count 500 --network mainnet --protocol-version 22`,
+  erst regression-test --count 500 --network mainnet --protocol-version 22
+  erst regression-test --count 100 --invariants token-invariants.json
+  erst regression-test --count 100 --state-from snapshot:fixture.json,rpc`,
 	RunE: runRegressionTest,
 }
 
@@ -60,6 +64,14 @@ func runRegressionTest(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Protocol version override: %d\n", regressionProtocolVersion)
 	}
 
+	invariants, err := loadInvariants()
+	if err != nil {
+		return err
+	}
+	if invariants != nil {
+		fmt.Printf("  Invariants: %d from %s\n", len(invariants.Invariants), invariantsFlag)
+	}
+
 	// Create RPC client
 	opts := []rpc.ClientOption{
 		rpc.WithNetwork(rpc.Network(networkFlag)),
@@ -69,20 +81,32 @@ func runRegressionTest(cmd *cobra.Command, args []string) error {
 		opts = append(opts, rpc.WithHorizonURL(rpcURLFlag))
 	}
 
-	client, err := rpc.NewClient(opts...)
+	client, err := newRPCClient(opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create RPC client: %w", err)
 	}
 
 	// Create simulator runner
-	runner, err := simulator.NewRunner("", false)
+	runner, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return fmt.Errorf("failed to initialize simulator: %w", err)
 	}
+	registerRunnerCloseHook("regression-simulator-runner", runner)
+	defer func() { _ = runner.Close() }()
 
 	// Create regression harness
 	harness := simulator.NewRegressionHarness(runner, client, regressionMaxWorkers)
 	harness.Verbose = verbose
+	if stateSourceChanged(cmd) {
+		provider, err := rpc.ParseStateSource(stateFromFlag, client)
+		if err != nil {
+			return err
+		}
+		harness.StateProvider = provider
+	}
+	if invariants != nil {
+		harness.Invariants = invariants
+	}
 
 	// Run the regression tests
 	ctx := cmd.Context()
@@ -178,5 +202,8 @@ func init() {
 		"Enable verbose output",
 	)
 
+	registerInvariantsFlag(regressionTestCmd)
+	registerStateFromFlag(regressionTestCmd)
+
 	rootCmd.AddCommand(regressionTestCmd)
 }
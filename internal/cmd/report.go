@@ -9,16 +9,19 @@ import (
 	"os"
 	"time"
 
+	"github.com/dotandev/hintents/internal/abi"
 	"github.com/dotandev/hintents/internal/errors"
 	"github.com/dotandev/hintents/internal/report"
 	"github.com/dotandev/hintents/internal/trace"
 	"github.com/spf13/cobra"
+	"github.com/stellar/go-stellar-sdk/xdr"
 )
 
 var (
 	reportFormat string
 	reportOutput string
 	reportFile   string
+	reportWasm   []string
 )
 
 var reportCmd = &cobra.Command{
@@ -34,10 +37,15 @@ Reports include:
   - Risk assessment with detected issues
   - Timeline and event distribution
 
+Contract error codes such as "Error(Contract, #7)" are named after the
+variants of the contract's error enum when --wasm supplies its WASM. Step
+arguments and return values recorded as XDR are rendered with that spec too.
+
 Examples:
   erst report --file trace.json --format html --output reports/
   erst report --file trace.json --format pdf --output reports/
-  erst report --file trace.json --format html,pdf --output reports/`,
+  erst report --file trace.json --format html,pdf --output reports/
+  erst report --file trace.json --wasm token.wasm`,
 	RunE: reportExec,
 }
 
@@ -64,6 +72,28 @@ func reportExec(cmd *cobra.Command, args []string) error {
 		return errors.WrapUnmarshalFailed(err, "trace")
 	}
 
+	var specs []*abi.ContractSpec
+	for _, path := range reportWasm {
+		wasm, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to read WASM file: %v", err))
+		}
+		spec, err := contractSpecs.RegisterWasm("", wasm)
+		if err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to decode contract spec of %s: %v", path, err))
+		}
+		specs = append(specs, spec)
+	}
+	var namedErrors []string
+	seenErrors := make(map[string]bool)
+	for i, state := range executionTrace.States {
+		if e, ok := contractErrors.ResolveText(state.ContractID, state.Error); ok && !seenErrors[e.Describe()] {
+			seenErrors[e.Describe()] = true
+			namedErrors = append(namedErrors, e.Describe())
+		}
+		executionTrace.States[i].Error = contractErrors.Annotate(state.ContractID, state.Error)
+	}
+
 	builder := report.NewBuilder("Execution Trace Report")
 	builder.WithTransactionHash(executionTrace.TransactionHash)
 
@@ -88,12 +118,16 @@ func reportExec(cmd *cobra.Command, args []string) error {
 		}
 
 		builder.AddExecutionStep(i, op, status, state.Error)
+		builder.WithStepValues(stepValues(state, specs))
 	}
 
 	// Analyze for findings
 	if errorCount > 0 {
 		builder.AddKeyFinding(fmt.Sprintf("%d errors detected during execution", errorCount))
 	}
+	for _, name := range namedErrors {
+		builder.AddKeyFinding("Contract error " + name)
+	}
 
 	contractCount := countContracts(executionTrace.States)
 	builder.AddKeyFinding(fmt.Sprintf("%d unique contracts called", contractCount))
@@ -156,6 +190,49 @@ func reportExec(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// stepValues renders the arguments and return value of a trace step. Values
+// already rendered by the replay are kept; raw XDR values are rendered with
+// the spec of the step's contract or, when a single --wasm names no
+// contract, with that spec.
+func stepValues(state trace.ExecutionState, specs []*abi.ContractSpec) ([]string, string) {
+	f := contractSpecs.Formatter(state.ContractID)
+	if f.Spec == nil && len(specs) == 1 {
+		f.Spec = specs[0]
+	}
+
+	var args []string
+	if len(state.Arguments) > 0 {
+		for _, arg := range state.Arguments {
+			args = append(args, fmt.Sprint(arg))
+		}
+	} else if vals, ok := decodeScVals(state.RawArguments); ok {
+		args = f.FormatArgs(state.Function, vals)
+	}
+
+	var ret string
+	if state.ReturnValue != nil {
+		ret = fmt.Sprint(state.ReturnValue)
+	} else if vals, ok := decodeScVals([]string{state.RawReturnValue}); ok {
+		ret = f.FormatReturn(state.Function, vals[0])
+	}
+	return args, ret
+}
+
+// decodeScVals decodes base64 XDR ScVals, reporting false unless every value
+// decodes.
+func decodeScVals(encoded []string) ([]xdr.ScVal, bool) {
+	if len(encoded) == 0 {
+		return nil, false
+	}
+	vals := make([]xdr.ScVal, len(encoded))
+	for i, e := range encoded {
+		if e == "" || xdr.SafeUnmarshalBase64(e, &vals[i]) != nil {
+			return nil, false
+		}
+	}
+	return vals, true
+}
+
 func countErrors(states []trace.ExecutionState) int {
 	count := 0
 	for _, state := range states {
@@ -219,6 +296,7 @@ func init() {
 	reportCmd.Flags().StringVar(&reportFormat, "format", "html", "Output format: html, pdf, json, or html,pdf")
 	reportCmd.Flags().StringVar(&reportOutput, "output", ".", "Output directory for reports")
 	reportCmd.Flags().StringVar(&reportFile, "file", "", "Trace file to analyze")
+	reportCmd.Flags().StringSliceVar(&reportWasm, "wasm", nil, "Contract WASM files whose specs name contract error codes and type step values")
 
 	_ = reportCmd.RegisterFlagCompletionFunc("format", completeReportFormatFlag)
 
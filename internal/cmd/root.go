@@ -10,7 +10,9 @@ import (
 	"sync/atomic"
 	"syscall"
 
+	"github.com/dotandev/hintents/internal/errors"
 	"github.com/dotandev/hintents/internal/localization"
+	"github.com/dotandev/hintents/internal/rpc"
 	"github.com/dotandev/hintents/internal/shutdown"
 	"github.com/dotandev/hintents/internal/updater"
 	"github.com/spf13/cobra"
@@ -18,10 +20,14 @@ import (
 
 // Global flag variables
 var (
-	TimestampFlag       int64
-	WindowFlag          int64
-	ProfileFlag         bool
-	ProfileFormatFlag   string
+	TimestampFlag     int64
+	WindowFlag        int64
+	ProfileFlag       bool
+	ProfileFormatFlag string
+	NoSimCacheFlag    bool
+	RPCRecordFlag     string
+	RPCReplayFlag     string
+	RPCReplayModeFlag string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -53,6 +59,10 @@ Get started with 'erst debug --help' or visit the documentation.`,
 			return err
 		}
 
+		if RPCRecordFlag != "" && RPCReplayFlag != "" {
+			return errors.WrapValidationError("--rpc-record and --rpc-replay cannot be used together")
+		}
+
 		// Show "Upgrade available" banner from last run's cached check (non-blocking)
 		updater.ShowBannerFromCache(Version)
 		// Ping version endpoint asynchronously for next run
@@ -173,6 +183,34 @@ func init() {
 		"Flamegraph export format: 'html' (interactive) or 'svg' (raw)",
 	)
 
+	rootCmd.PersistentFlags().BoolVar(
+		&NoSimCacheFlag,
+		"no-sim-cache",
+		false,
+		"Always run the simulator instead of reusing cached simulation results",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&RPCRecordFlag,
+		"rpc-record",
+		"",
+		"Record every Horizon and Soroban RPC exchange to a cassette file",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&RPCReplayFlag,
+		"rpc-replay",
+		"",
+		"Serve Horizon and Soroban RPC exchanges from a cassette file instead of the network",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&RPCReplayModeFlag,
+		"rpc-replay-mode",
+		string(rpc.CassetteStrict),
+		"Cassette matching: 'strict' (exact request, each served once) or 'lenient' (same path and RPC method, reusable)",
+	)
+
 	// Define command groups for better organization
 	rootCmd.AddGroup(&cobra.Group{
 		ID:    "core",
@@ -0,0 +1,59 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/rpc"
+)
+
+var (
+	cassetteOnce sync.Once
+	cassetteOpts []rpc.ClientOption
+	cassetteErr  error
+)
+
+// rpcCassetteOptions returns the client options for --rpc-record and
+// --rpc-replay. The recorder or replayer is created once per process and
+// shared by every client, so commands that build several clients write to
+// and read from the same cassette.
+func rpcCassetteOptions() ([]rpc.ClientOption, error) {
+	cassetteOnce.Do(func() {
+		switch {
+		case RPCRecordFlag != "":
+			rec, err := rpc.NewCassetteRecorder(RPCRecordFlag)
+			if err != nil {
+				cassetteErr = errors.WrapValidationError(fmt.Sprintf("failed to create RPC cassette: %v", err))
+				return
+			}
+			logger.Logger.Info("Recording RPC exchanges", "cassette", RPCRecordFlag)
+			// The ledger-entry cache would answer some requests without
+			// HTTP, leaving them out of the cassette.
+			cassetteOpts = []rpc.ClientOption{rpc.WithMiddleware(rec.Middleware()), rpc.WithCacheEnabled(false)}
+		case RPCReplayFlag != "":
+			replayer, err := rpc.LoadCassetteReplayer(RPCReplayFlag, rpc.CassetteMode(RPCReplayModeFlag))
+			if err != nil {
+				cassetteErr = errors.WrapValidationError(err.Error())
+				return
+			}
+			logger.Logger.Info("Replaying RPC exchanges", "cassette", RPCReplayFlag, "mode", RPCReplayModeFlag)
+			cassetteOpts = []rpc.ClientOption{rpc.WithMiddleware(replayer.Middleware()), rpc.WithCacheEnabled(false)}
+		}
+	})
+	return cassetteOpts, cassetteErr
+}
+
+// newRPCClient creates an RPC client that honours --rpc-record and
+// --rpc-replay. Network commands use it instead of rpc.NewClient.
+func newRPCClient(opts ...rpc.ClientOption) (*rpc.Client, error) {
+	extra, err := rpcCassetteOptions()
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClient(append(opts, extra...)...)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/dotandev/hintents/internal/errors"
 	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/reconstruct"
 	"github.com/dotandev/hintents/internal/rpc"
 	"github.com/dotandev/hintents/internal/shell"
 	"github.com/dotandev/hintents/internal/simulator"
@@ -23,6 +24,8 @@ var (
 	shellRPCURLFlag  string
 	shellRPCToken    string
 	shellInitState   string
+	shellForkFlag    string
+	shellLedgerMeta  string
 )
 
 var shellCmd = &cobra.Command{
@@ -35,21 +38,50 @@ consecutively without losing the local ledger state between commands.
 The shell maintains a stateful ledger that persists across invocations, allowing
 you to test complex multi-step contract interactions.
 
+With --fork the shell starts from a real network ledger. Ledger entries are
+fetched from RPC the first time they are needed and cached in a local
+copy-on-write overlay; invocations only ever change the overlay. Use
+--state-from to read them from the local cache or a snapshot instead.
+Entries modified after the fork ledger are served at their fork-point value
+when --ledger-meta holds the close meta of the ledgers since; otherwise they
+keep their current value and are reported after 'invoke' and in 'state'.
+
 Examples:
   erst shell                                    Start shell with empty state
   erst shell --network testnet                  Start shell on testnet
   erst shell --init-state snapshot.json         Start with initial state
-  
+  erst shell --fork mainnet@51234567            Fork mainnet at ledger 51234567
+  erst shell --fork mainnet@51234567 --state-from snapshot:fork.json,rpc
+                                                Fork, preferring entries from a snapshot
+
 Shell Commands:
   invoke <contract-id> <function> [args...]    Invoke a contract function
   state                                         Show current ledger state
   state save <file>                             Save current state to file
   state load <file>                             Load state from file
   state reset                                   Reset to initial state
+  state diff                                    Show entries changed in this session
+  state export <file>                           Export current state as a snapshot
   help                                          Show available commands
   exit                                          Exit the shell`,
 	Args: cobra.NoArgs,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if shellForkFlag != "" {
+			point, err := shell.ParseForkPoint(shellForkFlag)
+			if err != nil {
+				return errors.WrapValidationError(err.Error())
+			}
+			if cmd.Flags().Changed("network") && rpc.Network(shellNetworkFlag) != point.Network {
+				return errors.WrapValidationError(fmt.Sprintf(
+					"--network %s conflicts with --fork %s", shellNetworkFlag, shellForkFlag))
+			}
+			shellNetworkFlag = string(point.Network)
+		} else if stateSourceChanged(cmd) {
+			return errors.WrapValidationError("--state-from requires --fork")
+		} else if shellLedgerMeta != "" {
+			return errors.WrapValidationError("--ledger-meta requires --fork")
+		}
+
 		// Validate network flag
 		switch rpc.Network(shellNetworkFlag) {
 		case rpc.Testnet, rpc.Mainnet, rpc.Futurenet:
@@ -66,6 +98,9 @@ func init() {
 	shellCmd.Flags().StringVar(&shellRPCURLFlag, "rpc-url", "", "Custom Horizon RPC URL to use")
 	shellCmd.Flags().StringVar(&shellRPCToken, "rpc-token", "", "RPC authentication token")
 	shellCmd.Flags().StringVar(&shellInitState, "init-state", "", "Initial ledger state file (JSON)")
+	shellCmd.Flags().StringVar(&shellForkFlag, "fork", "", "Fork a network at a ledger, fetching entries on demand: <network>@<ledger>")
+	shellCmd.Flags().StringVar(&shellLedgerMeta, "ledger-meta", "", "Directory of ledger close meta used to serve fork-point values of entries changed since the fork ledger")
+	registerStateFromFlag(shellCmd)
 
 	rootCmd.AddCommand(shellCmd)
 }
@@ -79,23 +114,32 @@ func runShell(cmd *cobra.Command, args []string) error {
 		opts = append(opts, rpc.WithToken(shellRPCToken))
 	}
 	if shellRPCURLFlag != "" {
-		rpcClient = rpc.NewClientWithURLOption(shellRPCURLFlag, rpc.Network(shellNetworkFlag), shellRPCToken)
-	} else {
-		var clientErr error
-		rpcClient, clientErr = rpc.NewClient(rpc.WithNetwork(rpc.Network(shellNetworkFlag)))
-		if clientErr != nil {
-			return fmt.Errorf("failed to create RPC client: %w", clientErr)
-		}
+		opts = append(opts, rpc.WithHorizonURL(shellRPCURLFlag))
+	}
+	var clientErr error
+	rpcClient, clientErr = newRPCClient(opts...)
+	if clientErr != nil {
+		return fmt.Errorf("failed to create RPC client: %w", clientErr)
 	}
 
 	// Initialize simulator runner
-	runner, err := simulator.NewRunner("", false)
+	runner, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return errors.WrapSimulatorNotFound(err.Error())
 	}
+	registerRunnerCloseHook("shell-simulator-runner", runner)
+	defer func() { _ = runner.Close() }()
 
 	// Create shell session
-	session := shell.NewSession(runner, rpcClient, rpc.Network(shellNetworkFlag))
+	var session *shell.Session
+	if shellForkFlag != "" {
+		session, err = newForkedShellSession(ctx, runner)
+		if err != nil {
+			return err
+		}
+	} else {
+		session = shell.NewSession(runner, rpcClient, rpc.Network(shellNetworkFlag))
+	}
 
 	// Load initial state if provided
 	if shellInitState != "" {
@@ -140,6 +184,51 @@ func runShell(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newForkedShellSession creates a session that forks --fork's network at its
+// ledger, reading missing entries from the --state-from provider.
+func newForkedShellSession(ctx context.Context, runner simulator.RunnerInterface) (*shell.Session, error) {
+	point, err := shell.ParseForkPoint(shellForkFlag)
+	if err != nil {
+		return nil, errors.WrapValidationError(err.Error())
+	}
+
+	upstream, err := rpc.ParseStateSource(stateFromFlag, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := rpcClient.GetLedgerHeader(ctx, point.Ledger)
+	if err != nil {
+		logger.Logger.Warn("Could not fetch fork ledger header; using the current time", "ledger", point.Ledger, "error", err)
+	} else {
+		point.CloseTime = header.CloseTime.Unix()
+	}
+
+	fork := shell.NewForkState(point, upstream)
+	fmt.Printf("Forked %s; ledger entries are fetched on demand\n", point)
+	if shellLedgerMeta != "" {
+		mirror, err := reconstruct.OpenMetaDir(shellLedgerMeta)
+		if err != nil {
+			return nil, errors.WrapValidationError(err.Error())
+		}
+		fork.SetLedgerMeta(mirror)
+		fmt.Printf("Entries changed after ledger %d are rolled back with %d ledgers of meta from %s\n",
+			point.Ledger, mirror.Ledgers(), shellLedgerMeta)
+	} else {
+		fmt.Printf("Entries changed after ledger %d keep their current value; pass --ledger-meta to roll them back\n", point.Ledger)
+	}
+	return shell.NewForkedSession(runner, rpcClient, fork), nil
+}
+
+// forkDrift returns how many entries of a forked session were modified after
+// the fork point and could not be rolled back.
+func forkDrift(session *shell.Session) int {
+	if fork := session.Fork(); fork != nil {
+		return fork.Stats().Drifted
+	}
+	return 0
+}
+
 func printWelcome() {
 	fmt.Println("╔═══════════════════════════════════════════════════════════════╗")
 	fmt.Println("║  Erst Interactive Shell                                       ║")
@@ -203,6 +292,13 @@ func printHelp() {
 	fmt.Println("  state reset")
 	fmt.Println("      Reset ledger state to initial state")
 	fmt.Println()
+	fmt.Println("  state diff")
+	fmt.Println("      Show entries changed since the fork point or initial state")
+	fmt.Println()
+	fmt.Println("  state export <file>")
+	fmt.Println("      Export current ledger entries as a snapshot file")
+	fmt.Println("      Example: state export fork.json")
+	fmt.Println()
 	fmt.Println("  clear")
 	fmt.Println("      Clear the terminal screen")
 	fmt.Println()
@@ -225,7 +321,11 @@ func handleInvoke(ctx context.Context, session *shell.Session, args []string) er
 
 	fmt.Printf("Invoking %s.%s(%s)...\n", contractID, function, strings.Join(funcArgs, ", "))
 
+	drifted := forkDrift(session)
 	result, err := session.Invoke(ctx, contractID, function, funcArgs)
+	if n := forkDrift(session) - drifted; n > 0 {
+		fmt.Printf("Warning: %d ledger entries changed after the fork point; using their current value\n", n)
+	}
 	if err != nil {
 		return fmt.Errorf("invocation failed: %w", err)
 	}
@@ -264,6 +364,17 @@ func handleState(session *shell.Session, args []string) error {
 		fmt.Printf("  Sequence: %d\n", summary.LedgerSequence)
 		fmt.Printf("  Timestamp: %d\n", summary.Timestamp)
 		fmt.Printf("  Invocations: %d\n", summary.InvocationCount)
+		if summary.Fork != nil {
+			fmt.Printf("  Fork: %s\n", summary.Fork.Point)
+			fmt.Printf("    Fetched: %d\n", summary.Fork.Fetched)
+			fmt.Printf("    Changed: %d\n", summary.Fork.Changed)
+			if summary.Fork.Rebuilt > 0 {
+				fmt.Printf("    Rolled back to fork point: %d\n", summary.Fork.Rebuilt)
+			}
+			if summary.Fork.Drifted > 0 {
+				fmt.Printf("    Modified after fork point: %d\n", summary.Fork.Drifted)
+			}
+		}
 		fmt.Println()
 		return nil
 	}
@@ -297,7 +408,44 @@ func handleState(session *shell.Session, args []string) error {
 		fmt.Println("State reset to initial state")
 		return nil
 
+	case "diff":
+		printStateDiff(session.Diff())
+		return nil
+
+	case "export":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: state export <file>")
+		}
+		filename := args[1]
+		if err := session.ExportSnapshot(filename); err != nil {
+			return fmt.Errorf("failed to export state: %w", err)
+		}
+		fmt.Printf("State exported to %s\n", filename)
+		return nil
+
 	default:
 		return fmt.Errorf("unknown state subcommand: %s", subcommand)
 	}
 }
+
+func printStateDiff(changes []shell.EntryChange) {
+	fmt.Println()
+	if len(changes) == 0 {
+		fmt.Println("No ledger entries changed")
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("Changed Ledger Entries: %d\n", len(changes))
+	for _, change := range changes {
+		marker := "~"
+		switch change.Kind {
+		case shell.ChangeCreated:
+			marker = "+"
+		case shell.ChangeDeleted:
+			marker = "-"
+		}
+		fmt.Printf("  %s %s (%s)\n", marker, change.Key, change.Kind)
+	}
+	fmt.Println()
+}
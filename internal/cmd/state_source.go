@@ -0,0 +1,127 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/reconstruct"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/visualizer"
+	"github.com/spf13/cobra"
+)
+
+// Shared ledger-state flags. Every command that replays against ledger state
+// binds --state-from and --state-record to these variables.
+var (
+	stateFromFlag   string
+	stateRecordFlag string
+)
+
+// registerStateSourceFlags adds --state-from and --state-record to cmd.
+func registerStateSourceFlags(cmd *cobra.Command) {
+	registerStateFromFlag(cmd)
+	cmd.Flags().StringVar(&stateRecordFlag, "state-record", "",
+		"Save the ledger entries used by this run to a snapshot file for offline replay")
+}
+
+// registerStateFromFlag adds only --state-from to cmd, for commands that read
+// ledger state through a long-lived provider rather than loadLedgerState.
+func registerStateFromFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&stateFromFlag, "state-from", rpc.StateSourceRPC,
+		"Where ledger entries come from: rpc, cache, snapshot:<file>, or a comma-separated overlay such as snapshot:fixture.json,rpc")
+}
+
+// stateSourceChanged reports whether the user picked a state source
+// explicitly, as opposed to relying on the default.
+func stateSourceChanged(cmd *cobra.Command) bool {
+	f := cmd.Flags().Lookup("state-from")
+	return f != nil && f.Changed
+}
+
+// loadLedgerState fetches keys from the provider selected by --state-from and
+// records them to --state-record when set.
+func loadLedgerState(ctx context.Context, client *rpc.Client, keys []string) (map[string]string, error) {
+	provider, err := rpc.ParseStateSource(stateFromFlag, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorder *rpc.RecordingStateProvider
+	if stateRecordFlag != "" {
+		recorder = rpc.NewRecordingStateProvider(provider)
+		provider = recorder
+	}
+
+	entries, err := provider.GetLedgerEntries(ctx, keys)
+	if err != nil {
+		return nil, errors.WrapRPCConnectionFailed(err)
+	}
+	if missing := len(keys) - len(entries); missing > 0 && stateFromFlag != rpc.StateSourceRPC {
+		logger.Logger.Warn("Ledger entries missing from state source", "source", stateFromFlag, "missing", missing)
+	}
+
+	if recorder != nil {
+		if err := recorder.Save(stateRecordFlag); err != nil {
+			return nil, errors.WrapValidationError(fmt.Sprintf("failed to record ledger state: %v", err))
+		}
+		fmt.Printf("Recorded %d ledger entries to %s\n", len(entries), stateRecordFlag)
+	}
+	return entries, nil
+}
+
+// ledgerStateFunc adapts a fetch function to reconstruct.StateProvider.
+type ledgerStateFunc func(ctx context.Context, keys []string) (map[string]string, error)
+
+func (f ledgerStateFunc) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	return f(ctx, keys)
+}
+
+// loadHistoricalState returns the ledger entries txResp saw when it was
+// applied. Entries recorded by its meta, or by the ledger close meta under
+// ledgerMetaDir when set, are exact; the rest are fetched with
+// loadLedgerState and reported as approximated.
+func loadHistoricalState(
+	ctx context.Context,
+	client *rpc.Client,
+	txHash string,
+	txResp *rpc.TransactionResponse,
+	ledgerMetaDir string,
+) (map[string]string, error) {
+	opts := reconstruct.Options{
+		Current: ledgerStateFunc(func(ctx context.Context, keys []string) (map[string]string, error) {
+			return loadLedgerState(ctx, client, keys)
+		}),
+	}
+	if ledgerMetaDir != "" {
+		mirror, err := reconstruct.OpenMetaDir(ledgerMetaDir)
+		if err != nil {
+			return nil, errors.WrapValidationError(err.Error())
+		}
+		opts.Ledgers = mirror
+	}
+
+	state, err := reconstruct.Reconstruct(ctx, reconstruct.Transaction{
+		Hash:          txHash,
+		Ledger:        txResp.Ledger,
+		EnvelopeXDR:   txResp.EnvelopeXdr,
+		ResultMetaXDR: txResp.ResultMetaXdr,
+	}, opts)
+	if err != nil {
+		var erstErr *errors.ErstError
+		if errors.As(err, &erstErr) {
+			return nil, erstErr
+		}
+		return nil, errors.WrapUnmarshalFailed(err, "result meta")
+	}
+
+	fmt.Printf("%s Reconstructed %s\n", visualizer.Success(), state.Summary())
+	for _, e := range state.Approximated() {
+		logger.Logger.Warn("Ledger entry approximated with current state", "key", e.Key, "source", e.Source)
+	}
+	return state.LedgerEntries(), nil
+}
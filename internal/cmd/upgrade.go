@@ -61,7 +61,7 @@ Example:
 			opts = append(opts, rpc.WithHorizonURL(rpcURLFlag))
 		}
 
-		client, err := rpc.NewClient(opts...)
+		client, err := newRPCClient(opts...)
 		if err != nil {
 			return errors.WrapValidationError(fmt.Sprintf("failed to create client: %v", err))
 		}
@@ -80,9 +80,9 @@ Example:
 			return errors.WrapUnmarshalFailed(err, "result meta")
 		}
 
-		entries, err := client.GetLedgerEntries(cmd.Context(), keys)
+		entries, err := loadLedgerState(cmd.Context(), client, keys)
 		if err != nil {
-			return errors.WrapRPCConnectionFailed(err)
+			return err
 		}
 		fmt.Printf("Fetched %d ledger entries\n", len(entries))
 
@@ -99,7 +99,7 @@ Example:
 		fmt.Println("Injected new WASM code into simulation state.")
 
 		// 6. Run Simulation
-		runner, err := simulator.NewRunner("", false)
+		runner, err := simulator.NewRunnerInterface("", false)
 		if err != nil {
 			return errors.WrapSimulatorNotFound(err.Error())
 		}
@@ -126,7 +126,7 @@ Example:
 
 func init() {
 	upgradeCmd.Flags().StringVar(&newWasmPath, "new-wasm", "", "Path to the new WASM file")
-	upgradeCmd.Flags().BoolVar(&upgradeOptimizeFlag, "optimize", false, "Run dead-code elimination on the new WASM before simulation")
+	upgradeCmd.Flags().BoolVar(&upgradeOptimizeFlag, "optimize", false, "Run the erst dce optimizer on the new WASM before simulation")
 	// Reuse network flags from debug.go if possible, but they are var blocks there.
 	// Since they are in the same package, we can reuse the variables 'networkFlag' and 'rpcURLFlag'
 	// BUT we need to register flags for THIS command too.
@@ -135,6 +135,8 @@ func init() {
 
 	_ = upgradeCmd.RegisterFlagCompletionFunc("network", completeNetworkFlag)
 
+	registerStateSourceFlags(upgradeCmd)
+
 	rootCmd.AddCommand(upgradeCmd)
 }
 
@@ -0,0 +1,81 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/wasmdiff"
+	"github.com/dotandev/hintents/internal/wasmopt"
+	"github.com/spf13/cobra"
+)
+
+var wasmDiffFormat string
+
+var wasmDiffCmd = &cobra.Command{
+	Use:     "wasm-diff <old-wasm> <new-wasm>",
+	GroupID: "utility",
+	Short:   "Show what changed between two builds of a contract",
+	Long: `Compare two builds of a Soroban contract for upgrade review.
+
+The report covers:
+  - contract spec changes: functions added, removed or with a new signature,
+    type definitions, error codes and events
+  - Soroban host functions imported or no longer imported
+  - functions whose body changed, matched by export or name-section name,
+    with their size in each build
+  - storage keys: the symbols built by functions that call storage host
+    functions
+
+Function bodies are compared with function and type indices resolved, so
+functions added elsewhere do not make unchanged code show up as changed.
+Builds without a name section can only be matched on exported functions.
+
+Examples:
+  erst wasm-diff ./v1.wasm ./v2.wasm
+  erst wasm-diff ./v1.wasm ./v2.wasm --format json`,
+	Args: cobra.ExactArgs(2),
+	RunE: wasmDiffExec,
+}
+
+func wasmDiffExec(cmd *cobra.Command, args []string) error {
+	oldWasm, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading WASM file: %w", err)
+	}
+	newWasm, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("reading WASM file: %w", err)
+	}
+
+	report, err := wasmdiff.Diff(oldWasm, newWasm)
+	if err != nil {
+		if errors.Is(err, wasmopt.ErrInvalidModule) {
+			return errors.WrapWasmInvalid(err.Error())
+		}
+		return err
+	}
+
+	switch wasmDiffFormat {
+	case "json":
+		output, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	case "text":
+		fmt.Print(report.Text())
+	default:
+		return errors.WrapValidationError(fmt.Sprintf("unsupported format: %s (use: text, json)", wasmDiffFormat))
+	}
+
+	return nil
+}
+
+func init() {
+	wasmDiffCmd.Flags().StringVar(&wasmDiffFormat, "format", "text", "Output format: text or json")
+	rootCmd.AddCommand(wasmDiffCmd)
+}
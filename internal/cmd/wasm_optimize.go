@@ -7,18 +7,28 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/dotandev/hintents/internal/errors"
 	"github.com/dotandev/hintents/internal/wasmopt"
 )
 
+// optimizeWasm runs the default optimizer pipeline, the one shared by
+// erst dce and every --optimize flag.
+func optimizeWasm(input []byte) ([]byte, *wasmopt.Report, error) {
+	optimized, report, err := wasmopt.Optimize(input)
+	if err != nil {
+		if errors.Is(err, wasmopt.ErrInvalidModule) {
+			return nil, nil, errors.WrapWasmInvalid(err.Error())
+		}
+		return nil, nil, err
+	}
+	return optimized, report, nil
+}
+
 func optimizeWasmBytesIfRequested(input []byte, enabled bool) ([]byte, *wasmopt.Report, error) {
 	if !enabled {
 		return input, nil, nil
 	}
-	optimized, report, err := wasmopt.EliminateDeadCode(input)
-	if err != nil {
-		return nil, nil, err
-	}
-	return optimized, &report, nil
+	return optimizeWasm(input)
 }
 
 func optimizeWasmFileIfRequested(path string, enabled bool) (string, *wasmopt.Report, func(), error) {
@@ -31,7 +41,7 @@ func optimizeWasmFileIfRequested(path string, enabled bool) (string, *wasmopt.Re
 	if err != nil {
 		return "", nil, cleanup, err
 	}
-	optimized, report, err := wasmopt.EliminateDeadCode(raw)
+	optimized, report, err := optimizeWasm(raw)
 	if err != nil {
 		return "", nil, cleanup, err
 	}
@@ -52,17 +62,20 @@ func optimizeWasmFileIfRequested(path string, enabled bool) (string, *wasmopt.Re
 	}
 
 	cleanup = func() { _ = os.Remove(tmpPath) }
-	return tmpPath, &report, cleanup, nil
+	return tmpPath, report, cleanup, nil
 }
 
 func printOptimizationReport(report *wasmopt.Report) {
 	if report == nil {
 		return
 	}
-	fmt.Printf(
-		"Optimization (DCE): removed %d/%d functions, kept %d\n",
-		report.RemovedDefinedFunctions,
-		report.OriginalDefinedFunctions,
-		report.KeptDefinedFunctions,
-	)
+	pct := 0.0
+	if report.OriginalSize > 0 {
+		pct = float64(report.Saved()) / float64(report.OriginalSize) * 100
+	}
+	fmt.Printf("Optimization: %d -> %d bytes (saved %d, %.1f%%)\n",
+		report.OriginalSize, report.OptimizedSize, report.Saved(), pct)
+	for _, p := range report.Passes {
+		fmt.Printf("  %-16s removed %d/%d, %d bytes\n", p.Name, p.Removed, p.Total, -p.Saved())
+	}
 }
@@ -0,0 +1,88 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dotandev/hintents/internal/dwarf"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/wat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watOutput      string
+	watFolded      bool
+	watSourceLines bool
+)
+
+var watCmd = &cobra.Command{
+	Use:     "wat <wasm-file>",
+	GroupID: "utility",
+	Short:   "Disassemble a WASM binary to the WebAssembly text format",
+	Long: `Print a complete WAT module for a compiled contract: types, imports, functions,
+tables, memory, globals, exports, element and data segments, and custom
+sections such as the contract spec as @custom annotations.
+
+Imports of Soroban host functions are named after the host function they call
+(for example $require_auth for "a" "0"). Other functions take their demangled
+name from the WASM name section.
+
+With --folded, blocks, loops and ifs are printed as s-expressions. With
+--source-lines, instructions are annotated with the source line they were
+compiled from, using the DWARF debug information in the binary.
+
+Examples:
+  erst wat ./contract.wasm
+  erst wat ./contract.wasm --folded -o contract.wat
+  erst wat ./target/wasm32-unknown-unknown/debug/contract.wasm --source-lines`,
+	Args: cobra.ExactArgs(1),
+	RunE: watExec,
+}
+
+func watExec(cmd *cobra.Command, args []string) error {
+	wasmBytes, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading WASM file: %w", err)
+	}
+
+	opts := wat.Options{Folded: watFolded}
+	if watSourceLines {
+		parser, err := dwarf.NewParser(wasmBytes)
+		if err != nil || !parser.HasDebugInfo() {
+			return errors.WrapValidationError("--source-lines needs a WASM binary built with debug information")
+		}
+		opts.Lines = func(offset uint64) (string, int, bool) {
+			loc, err := parser.GetSourceLocation(offset)
+			if err != nil || loc == nil || loc.Line == 0 {
+				return "", 0, false
+			}
+			return loc.File, loc.Line, true
+		}
+	}
+
+	text, err := wat.NewDisassembler(wasmBytes).WAT(opts)
+	if err != nil {
+		return errors.WrapWasmInvalid(err.Error())
+	}
+
+	if watOutput == "" {
+		fmt.Print(text)
+		return nil
+	}
+	if err := os.WriteFile(watOutput, []byte(text), 0644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	fmt.Printf("Written to: %s\n", watOutput)
+	return nil
+}
+
+func init() {
+	watCmd.Flags().StringVarP(&watOutput, "output", "o", "", "Output file path (default: stdout)")
+	watCmd.Flags().BoolVar(&watFolded, "folded", false, "Print control flow as folded s-expressions")
+	watCmd.Flags().BoolVar(&watSourceLines, "source-lines", false, "Annotate instructions with DWARF source lines")
+	rootCmd.AddCommand(watCmd)
+}
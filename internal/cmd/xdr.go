@@ -5,25 +5,43 @@ package cmd
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/dotandev/hintents/internal/abi"
 	"github.com/dotandev/hintents/internal/decoder"
 	"github.com/dotandev/hintents/internal/errors"
 	"github.com/spf13/cobra"
+	"github.com/stellar/go-stellar-sdk/xdr"
 )
 
 var (
-	xdrFormat string
-	xdrData   string
-	xdrType   string
+	xdrFormat   string
+	xdrData     string
+	xdrType     string
+	xdrWasm     string
+	xdrAs       string
+	xdrDecimals uint32
 )
 
 var xdrCmd = &cobra.Command{
 	Use:     "xdr",
 	GroupID: "utility",
 	Short:   "Format and decode XDR data",
-	Long:    `Decode and format XDR structures to JSON or table format for easy inspection.`,
-	RunE:    xdrExec,
+	Long: `Decode and format XDR structures to JSON or table format for easy inspection.
+
+Soroban values (--type scval) and the topics and data of diagnostic events in
+table format are rendered readably: integers in decimal, addresses as strkeys
+and bytes in hex. With --wasm the contract's spec types them, labelling struct
+fields and printing enums, unions and error enums by variant.
+
+Examples:
+  erst xdr --type scval --data AAAAAwAAAAc=
+  erst xdr --type scval --wasm ./token.wasm --as TokenError --data AAAAAwAAAAc=
+  erst xdr --type diagnostic-event --format table --wasm ./token.wasm --data <base64>`,
+	RunE: xdrExec,
 }
 
 func xdrExec(cmd *cobra.Command, args []string) error {
@@ -36,9 +54,44 @@ func xdrExec(cmd *cobra.Command, args []string) error {
 		return errors.WrapValidationError(fmt.Sprintf("invalid base64 input: %v", err))
 	}
 
+	specs := decoder.NewSpecRegistry()
+	var (
+		wasm []byte
+		spec *abi.ContractSpec
+	)
+	if xdrWasm != "" {
+		if wasm, err = os.ReadFile(xdrWasm); err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to read WASM file: %v", err))
+		}
+		if spec, err = specs.RegisterWasm("", wasm); err != nil {
+			return errors.WrapValidationError(fmt.Sprintf("failed to decode contract spec of %s: %v", xdrWasm, err))
+		}
+	}
+
 	var output interface{}
 
 	switch xdrType {
+	case "scval":
+		var val xdr.ScVal
+		if err := val.UnmarshalBinary(data); err != nil {
+			return errors.WrapUnmarshalFailed(err, "scval")
+		}
+		f := &abi.ValueFormatter{Spec: spec, Decimals: xdrDecimals}
+		rendered := &decoder.RenderedValue{
+			Type:  strings.TrimPrefix(val.Type.String(), "ScValTypeScv"),
+			Value: f.Format(val),
+		}
+		if xdrAs != "" {
+			value, err := f.FormatAs(val, xdrAs)
+			if err != nil {
+				return errors.WrapValidationError(err.Error())
+			}
+			rendered.Type, rendered.Value = xdrAs, value
+		} else if xdrDecimals > 0 {
+			rendered.Value = f.FormatNamed(val, "amount")
+		}
+		output = rendered
+
 	case "ledger-entry":
 		le, err := decoder.DecodeXDRBase64AsLedgerEntry(string(data))
 		if err != nil {
@@ -51,13 +104,31 @@ func xdrExec(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return errors.WrapUnmarshalFailed(err, "diagnostic event")
 		}
+		// The given spec and decimals apply to the emitting contract and to
+		// the callee of a fn_call.
+		var contractID string
+		if event.Event.ContractId != nil {
+			contractID = hex.EncodeToString(event.Event.ContractId[:])
+		}
+		var topics []xdr.ScVal
+		if body, ok := event.Event.Body.GetV0(); ok {
+			topics = body.Topics
+		}
+		for _, id := range decoder.EventContracts(contractID, topics) {
+			if wasm != nil {
+				_, _ = specs.RegisterWasm(id, wasm)
+			}
+			if xdrDecimals > 0 {
+				specs.SetDecimals(id, xdrDecimals)
+			}
+		}
 		output = event
 
 	default:
-		return errors.WrapValidationError(fmt.Sprintf("unsupported XDR type: %s (use: ledger-entry, diagnostic-event)", xdrType))
+		return errors.WrapValidationError(fmt.Sprintf("unsupported XDR type: %s (use: ledger-entry, diagnostic-event, scval)", xdrType))
 	}
 
-	formatter := decoder.NewXDRFormatter(decoder.FormatType(xdrFormat))
+	formatter := decoder.NewXDRFormatterWithSpecs(decoder.FormatType(xdrFormat), specs)
 	result, err := formatter.Format(output)
 	if err != nil {
 		return errors.WrapValidationError(fmt.Sprintf("formatting failed: %v", err))
@@ -72,7 +143,10 @@ func init() {
 
 	xdrCmd.Flags().StringVar(&xdrData, "data", "", "Base64-encoded XDR data to decode")
 	xdrCmd.Flags().StringVar(&xdrFormat, "format", "json", "Output format: json or table")
-	xdrCmd.Flags().StringVar(&xdrType, "type", "ledger-entry", "XDR type: ledger-entry, diagnostic-event")
+	xdrCmd.Flags().StringVar(&xdrType, "type", "ledger-entry", "XDR type: ledger-entry, diagnostic-event, scval")
+	xdrCmd.Flags().StringVar(&xdrWasm, "wasm", "", "Contract WASM file whose spec types the decoded values")
+	xdrCmd.Flags().StringVar(&xdrAs, "as", "", "Render an scval as this type of the contract spec")
+	xdrCmd.Flags().Uint32Var(&xdrDecimals, "decimals", 0, "Token decimals used to scale amounts")
 
 	_ = xdrCmd.MarkFlagRequired("data")
 
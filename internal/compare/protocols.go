@@ -0,0 +1,92 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package compare
+
+import (
+	"sort"
+
+	"github.com/dotandev/hintents/internal/simulator"
+)
+
+// ProtocolRun is one replay of a transaction under a given protocol version.
+type ProtocolRun struct {
+	Version  uint32
+	Response *simulator.SimulationResponse
+}
+
+// ProtocolStep compares the runs of two consecutive protocol versions. The
+// newer version is the local side of Diff and the older the on-chain side.
+type ProtocolStep struct {
+	From uint32
+	To   uint32
+	Diff *DiffResult
+
+	// Flipped is true when the transaction succeeds under From but fails
+	// under To.
+	Flipped bool
+	// Fixed is true when the transaction fails under From but succeeds
+	// under To.
+	Fixed bool
+
+	// Features lists the protocol feature table entries that changed
+	// between From and To.
+	Features []simulator.FeatureChange
+}
+
+// ProtocolMatrix holds the runs of one transaction under several protocol
+// versions and the diff between each consecutive pair.
+type ProtocolMatrix struct {
+	Runs  []ProtocolRun
+	Steps []ProtocolStep
+}
+
+// DiffProtocols sorts runs by version and diffs each run against the one
+// before it. Every run must carry a response.
+func DiffProtocols(runs []ProtocolRun) *ProtocolMatrix {
+	sorted := append([]ProtocolRun(nil), runs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	m := &ProtocolMatrix{Runs: sorted}
+	for i := 1; i < len(sorted); i++ {
+		prev, next := sorted[i-1], sorted[i]
+		prevOK := prev.Response.Status == "success"
+		nextOK := next.Response.Status == "success"
+
+		step := ProtocolStep{
+			From:    prev.Version,
+			To:      next.Version,
+			Diff:    Diff(next.Response, prev.Response),
+			Flipped: prevOK && !nextOK,
+			Fixed:   !prevOK && nextOK,
+		}
+		// Versions missing from the feature tables simply have no listed
+		// changes.
+		step.Features, _ = simulator.FeatureChanges(prev.Version, next.Version)
+		m.Steps = append(m.Steps, step)
+	}
+	return m
+}
+
+// HasDivergence reports whether behavior differs between any two versions.
+func (m *ProtocolMatrix) HasDivergence() bool {
+	for _, step := range m.Steps {
+		if step.Diff.HasDivergence {
+			return true
+		}
+	}
+	return false
+}
+
+// Flips returns the steps where success turned into failure.
+func (m *ProtocolMatrix) Flips() []ProtocolStep {
+	var flips []ProtocolStep
+	for _, step := range m.Steps {
+		if step.Flipped {
+			flips = append(flips, step)
+		}
+	}
+	return flips
+}
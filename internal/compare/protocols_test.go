@@ -0,0 +1,61 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffProtocols_FindsFlip(t *testing.T) {
+	budget := func(cpu uint64) *simulator.BudgetUsage {
+		return &simulator.BudgetUsage{CPUInstructions: cpu, MemoryBytes: 1000}
+	}
+	runs := []ProtocolRun{
+		{Version: 22, Response: makeResp("error", []string{"evt:transfer"}, nil, budget(900))},
+		{Version: 20, Response: makeResp("success", []string{"evt:transfer"}, nil, budget(500))},
+		{Version: 21, Response: makeResp("success", []string{"evt:transfer"}, nil, budget(700))},
+	}
+
+	m := DiffProtocols(runs)
+	require.Len(t, m.Runs, 3)
+	assert.Equal(t, uint32(20), m.Runs[0].Version, "runs are sorted by version")
+	require.Len(t, m.Steps, 2)
+
+	first, second := m.Steps[0], m.Steps[1]
+	assert.Equal(t, uint32(20), first.From)
+	assert.Equal(t, uint32(21), first.To)
+	assert.False(t, first.Flipped)
+	assert.Equal(t, int64(200), first.Diff.BudgetDiff.CPUDelta, "the newer version is the local side")
+	assert.NotEmpty(t, first.Features)
+
+	assert.True(t, second.Flipped)
+	assert.False(t, second.Fixed)
+	assert.True(t, m.HasDivergence())
+	require.Len(t, m.Flips(), 1)
+	assert.Equal(t, uint32(22), m.Flips()[0].To)
+}
+
+func TestDiffProtocols_Identical(t *testing.T) {
+	runs := []ProtocolRun{
+		{Version: 21, Response: makeResp("error", []string{"evt:fail"}, nil, nil)},
+		{Version: 22, Response: makeResp("error", []string{"evt:fail"}, nil, nil)},
+	}
+	m := DiffProtocols(runs)
+	assert.False(t, m.HasDivergence())
+	assert.Empty(t, m.Flips())
+	assert.False(t, m.Steps[0].Fixed)
+}
+
+func TestRenderProtocolMatrix_NoError(t *testing.T) {
+	runs := []ProtocolRun{
+		{Version: 21, Response: makeResp("success", []string{"a"}, nil, nil)},
+		{Version: 22, Response: makeResp("error", []string{"b"}, nil, nil)},
+	}
+	RenderProtocolMatrix(DiffProtocols(runs))
+	RenderProtocolMatrix(nil)
+}
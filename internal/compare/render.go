@@ -167,9 +167,14 @@ func renderDiagnosticDiffs(diffs []DiagnosticDiff) {
 			marker, d.Index+1, colWidth, truncate(localDesc, colWidth),
 			columnSep, colWidth, truncate(onChainDesc, colWidth))
 
-		// Show topic diff inline if both sides have the event but topics differ
+		// Show topic and data diffs inline if both sides have the event but
+		// its values differ
 		if d.Local != nil && d.OnChain != nil && d.Divergent && !d.DivergentPath {
 			renderTopicDiff(d.Local.Topics, d.OnChain.Topics)
+			if d.Local.Data != d.OnChain.Data {
+				fmt.Printf("        %s data: %s  →  %s\n",
+					visualizer.Colorize("↳", "yellow"), d.Local.Data, d.OnChain.Data)
+			}
 		}
 	}
 }
@@ -237,6 +242,135 @@ func renderSummary(result *DiffResult) {
 	fmt.Println(visualizer.Colorize(sep, "dim"))
 }
 
+// RenderProtocolMatrix prints the status, budget and event matrix of a
+// transaction replayed under several protocol versions, followed by the
+// changes between each pair of consecutive versions.
+func RenderProtocolMatrix(m *ProtocolMatrix) {
+	if m == nil || len(m.Runs) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(sectionTitle("Protocol Matrix"))
+	fmt.Printf("  %-10s  %-34s  %-14s  %-14s  %-7s  %s\n",
+		"Protocol", "Status", "CPU", "Memory", "Events", "vs Previous")
+	fmt.Printf("  %s\n", strings.Repeat("-", 100))
+
+	for i, run := range m.Runs {
+		resp := run.Response
+		cpu, mem := "-", "-"
+		if resp.BudgetUsage != nil {
+			cpu = fmt.Sprintf("%d", resp.BudgetUsage.CPUInstructions)
+			mem = fmt.Sprintf("%d", resp.BudgetUsage.MemoryBytes)
+		}
+		change := visualizer.Colorize("baseline", "dim")
+		if i > 0 {
+			change = stepSummary(m.Steps[i-1])
+		}
+		fmt.Printf("  %-10d  %-34s  %-14s  %-14s  %-7d  %s\n",
+			run.Version, statusLine(resp.Status, resp.Error), cpu, mem, len(resp.Events), change)
+	}
+
+	for _, step := range m.Steps {
+		if !step.Diff.HasDivergence && step.Diff.BudgetDiff == nil {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(sectionTitle(fmt.Sprintf("Protocol %d → %d", step.From, step.To)))
+		renderProtocolStep(step)
+	}
+
+	fmt.Println()
+	fmt.Println(sectionTitle("Summary"))
+	fmt.Println()
+	flips := m.Flips()
+	switch {
+	case len(flips) > 0:
+		for _, step := range flips {
+			fmt.Printf("  %s  Protocol %d turned success into failure (passes under %d)\n",
+				visualizer.Error(), step.To, step.From)
+		}
+	case m.HasDivergence():
+		fmt.Printf("  %s  Behavior differs between protocol versions\n", visualizer.Warning())
+	default:
+		fmt.Printf("  %s  Behavior is IDENTICAL across all protocol versions\n", visualizer.Success())
+	}
+	fmt.Println()
+}
+
+// stepSummary is the one-line "vs Previous" cell of the protocol matrix.
+func stepSummary(step ProtocolStep) string {
+	d := step.Diff
+	var parts []string
+	switch {
+	case step.Flipped:
+		parts = append(parts, visualizer.Colorize("[FLIP] now fails", "red"))
+	case step.Fixed:
+		parts = append(parts, visualizer.Colorize("[FIXED] now succeeds", "green"))
+	case !d.StatusDiff.Match:
+		parts = append(parts, visualizer.Colorize("status changed", "red"))
+	}
+	if d.BudgetDiff != nil && d.BudgetDiff.CPUDelta != 0 {
+		parts = append(parts, colorizeDelta(formatDelta(d.BudgetDiff.CPUDelta)+" CPU", d.BudgetDiff.CPUDelta))
+	}
+	if d.DivergentEvents > 0 {
+		parts = append(parts, colorizeDivergentCount(d.DivergentEvents)+" event diff(s)")
+	}
+	if n := len(d.CallPathDivergences); n > 0 {
+		parts = append(parts, colorizeDivergentCount(n)+" path divergence(s)")
+	}
+	if len(parts) == 0 {
+		return visualizer.Colorize("identical", "green")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func renderProtocolStep(step ProtocolStep) {
+	d := step.Diff
+	if !d.StatusDiff.Match {
+		fmt.Printf("  Status   : %s → %s\n",
+			statusLine(d.StatusDiff.OnChainStatus, d.StatusDiff.OnChainError),
+			statusLine(d.StatusDiff.LocalStatus, d.StatusDiff.LocalError))
+	}
+	if d.BudgetDiff != nil {
+		bd := d.BudgetDiff
+		fmt.Printf("  CPU      : %d → %d (%s)\n", bd.OnChainCPU, bd.LocalCPU,
+			colorizePct(budgetDeltaPct(bd.CPUDelta, bd.OnChainCPU)))
+		fmt.Printf("  Memory   : %d → %d (%s)\n", bd.OnChainMem, bd.LocalMem,
+			colorizePct(budgetDeltaPct(bd.MemoryDelta, bd.OnChainMem)))
+	}
+	for _, e := range d.EventDiffs {
+		if e.Divergent {
+			fmt.Printf("  Event %-3d: %s → %s\n", e.Index+1,
+				visualizer.Colorize(truncate(e.OnChainEvent, 40), "magenta"),
+				visualizer.Colorize(truncate(e.LocalEvent, 40), "cyan"))
+		}
+	}
+	for _, div := range d.CallPathDivergences {
+		fmt.Printf("  %s at diagnostic event %d: %s\n",
+			visualizer.Colorize("[PATH]", "red"), div.EventIndex+1, div.Reason)
+	}
+
+	if len(step.Features) == 0 {
+		return
+	}
+	label := "Feature changes"
+	if step.Flipped {
+		label = visualizer.Colorize("Feature changes that may explain the failure", "red")
+	}
+	fmt.Printf("  %s:\n", label)
+	for _, f := range step.Features {
+		from, to := f.From, f.To
+		if from == "" {
+			from = "(unset)"
+		}
+		if to == "" {
+			to = "(removed)"
+		}
+		fmt.Printf("    • %-24s %s → %s\n", f.Key, truncate(from, 40), truncate(to, 40))
+	}
+}
+
 // ─── formatting helpers ───────────────────────────────────────────────────────
 
 func diagnosticSummary(e *simulator.DiagnosticEvent) string {
@@ -132,6 +132,21 @@ func (EnvParser) Parse(cfg *Config) error {
 		cfg.RequestTimeout = n
 	}
 
+	if v := os.Getenv("ERST_SIM_POOL_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return errors.WrapValidationError("ERST_SIM_POOL_SIZE must be a non-negative integer")
+		}
+		cfg.SimulatorPoolSize = n
+	}
+	if v := os.Getenv("ERST_SIM_POOL_MAX_REQUESTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return errors.WrapValidationError("ERST_SIM_POOL_MAX_REQUESTS must be a non-negative integer")
+		}
+		cfg.SimulatorPoolMaxRequests = n
+	}
+
 	switch strings.ToLower(os.Getenv("ERST_CRASH_REPORTING")) {
 	case "":
 	case "1", "true", "yes":
@@ -186,6 +201,12 @@ type Config struct {
 	CrashEndpoint     string   `json:"crash_endpoint,omitempty"`
 	CrashSentryDSN    string   `json:"crash_sentry_dsn,omitempty"`
 	RequestTimeout    int      `json:"request_timeout,omitempty"`
+
+	// SimulatorPoolSize enables the persistent erst-sim worker pool with this
+	// many workers. Zero keeps the one-process-per-simulation runner.
+	SimulatorPoolSize int `json:"simulator_pool_size,omitempty"`
+	// SimulatorPoolMaxRequests recycles a pooled worker after this many requests.
+	SimulatorPoolMaxRequests int `json:"simulator_pool_max_requests,omitempty"`
 }
 
 func GetGeneralConfigPath() (string, error) {
@@ -349,6 +370,18 @@ func (c *Config) parseTOML(content string) error {
 				return errors.WrapValidationError("request_timeout must be an integer")
 			}
 			c.RequestTimeout = n
+		case "simulator_pool_size":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return errors.WrapValidationError("simulator_pool_size must be a non-negative integer")
+			}
+			c.SimulatorPoolSize = n
+		case "simulator_pool_max_requests":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return errors.WrapValidationError("simulator_pool_max_requests must be a non-negative integer")
+			}
+			c.SimulatorPoolMaxRequests = n
 		}
 	}
 
@@ -0,0 +1,397 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/eventbus"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/gorilla/websocket"
+)
+
+// Notification topics published on the daemon event bus and delivered to
+// WebSocket subscribers. The topic is also the JSON-RPC method name of the
+// notification.
+const (
+	TopicSimulationProgress   = "simulation.progress"
+	TopicSimulationDiagnostic = "simulation.diagnostic"
+	TopicSimulationCompleted  = "simulation.completed"
+	TopicSimulationFailed     = "simulation.failed"
+)
+
+// NotificationTopics lists every topic a client may subscribe to.
+var NotificationTopics = []string{
+	TopicSimulationProgress,
+	TopicSimulationDiagnostic,
+	TopicSimulationCompleted,
+	TopicSimulationFailed,
+}
+
+// ProgressNotification is published on TopicSimulationProgress.
+type ProgressNotification struct {
+	JobID    string              `json:"job_id"`
+	Status   simulator.JobStatus `json:"status"`
+	Progress float64             `json:"progress"`
+	Message  string              `json:"message,omitempty"`
+}
+
+// DiagnosticNotification is published on TopicSimulationDiagnostic for each
+// diagnostic event of a running simulation, in emission order, as the runner
+// decodes it.
+type DiagnosticNotification struct {
+	JobID string                    `json:"job_id"`
+	Index int                       `json:"index"`
+	Event simulator.DiagnosticEvent `json:"event"`
+}
+
+// CompletedNotification is published on TopicSimulationCompleted.
+type CompletedNotification struct {
+	JobID    string                        `json:"job_id"`
+	Response *simulator.SimulationResponse `json:"response"`
+}
+
+// FailedNotification is published on TopicSimulationFailed when a job fails
+// or is cancelled. Response is set when erst-sim ran and reported the failure.
+type FailedNotification struct {
+	JobID    string                        `json:"job_id"`
+	Status   simulator.JobStatus           `json:"status"`
+	Error    string                        `json:"error,omitempty"`
+	Response *simulator.SimulationResponse `json:"response,omitempty"`
+}
+
+// publishJobUpdate turns an AsyncRunner job update into bus notifications.
+// Diagnostic events are published separately, as they are decoded, through
+// AsyncConfig.OnDiagnostic.
+func publishJobUpdate(bus *eventbus.EventBus, job *simulator.AsyncJob) {
+	switch job.Status {
+	case simulator.JobStatusPending, simulator.JobStatusRunning:
+		bus.Emit(TopicSimulationProgress, ProgressNotification{
+			JobID:    job.ID,
+			Status:   job.Status,
+			Progress: job.Progress,
+			Message:  job.ProgressMessage,
+		})
+	case simulator.JobStatusCompleted:
+		bus.Emit(TopicSimulationCompleted, CompletedNotification{JobID: job.ID, Response: job.Response})
+	case simulator.JobStatusFailed, simulator.JobStatusCancelled:
+		bus.Emit(TopicSimulationFailed, FailedNotification{JobID: job.ID, Status: job.Status, Error: job.Error, Response: job.Response})
+	}
+}
+
+// publishDiagnostic publishes one diagnostic event of a running job.
+func publishDiagnostic(bus *eventbus.EventBus, jobID string, index int, event simulator.DiagnosticEvent) {
+	bus.Emit(TopicSimulationDiagnostic, DiagnosticNotification{JobID: jobID, Index: index, Event: event})
+}
+
+// -------------------- WebSocket --------------------
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = wsPongTimeout * 9 / 10
+	wsSendBuffer   = 256
+	wsMaxMessage   = 64 * 1024
+)
+
+// JSON-RPC 2.0 error codes used on the WebSocket endpoint.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// IDE clients connect from arbitrary origins; access is controlled by
+	// the auth token instead.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type wsRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type wsResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *wsError        `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type wsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type wsNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// SubscribeParams are the params of the "subscribe" method. An empty Topics
+// subscribes to every topic; a non-empty JobID only delivers notifications
+// for that job.
+type SubscribeParams struct {
+	Topics []string `json:"topics,omitempty"`
+	JobID  string   `json:"job_id,omitempty"`
+}
+
+// SubscribeResult is the result of the "subscribe" method.
+type SubscribeResult struct {
+	Subscription uint64   `json:"subscription"`
+	Topics       []string `json:"topics"`
+}
+
+// UnsubscribeParams are the params of the "unsubscribe" method.
+type UnsubscribeParams struct {
+	Subscription uint64 `json:"subscription"`
+}
+
+type wsHandle struct {
+	topic string
+	id    eventbus.HandlerID
+}
+
+// wsConn is one WebSocket client and its subscriptions.
+type wsConn struct {
+	conn *websocket.Conn
+	bus  *eventbus.EventBus
+	send chan any
+
+	mu      sync.Mutex
+	subs    map[uint64][]wsHandle
+	nextSub uint64
+	closed  bool
+	done    chan struct{}
+}
+
+// handleWebSocket upgrades the request and serves subscribe/unsubscribe
+// calls until the client disconnects. Browsers cannot set headers on a
+// WebSocket handshake, so the token may also be passed as ?token=.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(r) && !s.authenticateToken(r.URL.Query().Get("token")) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Logger.Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	c := &wsConn{
+		conn: conn,
+		bus:  s.bus,
+		send: make(chan any, wsSendBuffer),
+		subs: make(map[uint64][]wsHandle),
+		done: make(chan struct{}),
+	}
+	logger.Logger.Info("WebSocket client connected", "remote", r.RemoteAddr)
+
+	go c.writeLoop()
+	c.readLoop()
+	c.close()
+	logger.Logger.Info("WebSocket client disconnected", "remote", r.RemoteAddr)
+}
+
+func (c *wsConn) readLoop() {
+	c.conn.SetReadLimit(wsMaxMessage)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.reply(nil, nil, &wsError{Code: rpcParseError, Message: "parse error"})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			c.reply(req.ID, nil, &wsError{Code: rpcInvalidRequest, Message: "invalid request"})
+			continue
+		}
+
+		result, rpcErr := c.dispatch(req)
+		c.reply(req.ID, result, rpcErr)
+	}
+}
+
+func (c *wsConn) dispatch(req wsRequest) (any, *wsError) {
+	switch req.Method {
+	case "subscribe":
+		var params SubscribeParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &wsError{Code: rpcInvalidParams, Message: err.Error()}
+			}
+		}
+		return c.subscribe(params)
+
+	case "unsubscribe":
+		var params UnsubscribeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &wsError{Code: rpcInvalidParams, Message: "subscription is required"}
+		}
+		if !c.unsubscribe(params.Subscription) {
+			return nil, &wsError{Code: rpcInvalidParams, Message: "unknown subscription"}
+		}
+		return true, nil
+
+	default:
+		return nil, &wsError{Code: rpcMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (c *wsConn) subscribe(params SubscribeParams) (any, *wsError) {
+	topics := params.Topics
+	if len(topics) == 0 {
+		topics = NotificationTopics
+	}
+	for _, topic := range topics {
+		if !isNotificationTopic(topic) {
+			return nil, &wsError{Code: rpcInvalidParams, Message: "unknown topic: " + topic}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, &wsError{Code: rpcInvalidRequest, Message: "connection closed"}
+	}
+
+	c.nextSub++
+	subID := c.nextSub
+	handles := make([]wsHandle, 0, len(topics))
+	for _, topic := range topics {
+		topic := topic
+		id := c.bus.Subscribe(topic, func(payload any) {
+			if params.JobID != "" && notificationJobID(payload) != params.JobID {
+				return
+			}
+			c.enqueue(wsNotification{JSONRPC: "2.0", Method: topic, Params: payload})
+		})
+		handles = append(handles, wsHandle{topic: topic, id: id})
+	}
+	c.subs[subID] = handles
+
+	return SubscribeResult{Subscription: subID, Topics: topics}, nil
+}
+
+func (c *wsConn) unsubscribe(subID uint64) bool {
+	c.mu.Lock()
+	handles, ok := c.subs[subID]
+	delete(c.subs, subID)
+	c.mu.Unlock()
+
+	for _, h := range handles {
+		c.bus.Unsubscribe(h.topic, h.id)
+	}
+	return ok
+}
+
+func (c *wsConn) reply(id json.RawMessage, result any, rpcErr *wsError) {
+	// Notifications (no id) get no reply unless the request was unparseable.
+	if len(id) == 0 && rpcErr == nil {
+		return
+	}
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	c.enqueue(wsResponse{JSONRPC: "2.0", Result: result, Error: rpcErr, ID: id})
+}
+
+// enqueue hands msg to the write loop. A client that falls too far behind
+// loses messages rather than stalling the publisher.
+func (c *wsConn) enqueue(msg any) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		logger.Logger.Warn("WebSocket client too slow, dropping message")
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case <-c.done:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close drops every subscription and stops the write loop.
+func (c *wsConn) close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	subs := c.subs
+	c.subs = nil
+	close(c.done)
+	c.mu.Unlock()
+
+	for _, handles := range subs {
+		for _, h := range handles {
+			c.bus.Unsubscribe(h.topic, h.id)
+		}
+	}
+}
+
+func isNotificationTopic(topic string) bool {
+	for _, t := range NotificationTopics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func notificationJobID(payload any) string {
+	switch n := payload.(type) {
+	case ProgressNotification:
+		return n.JobID
+	case DiagnosticNotification:
+		return n.JobID
+	case CompletedNotification:
+		return n.JobID
+	case FailedNotification:
+		return n.JobID
+	}
+	return ""
+}
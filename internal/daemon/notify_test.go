@@ -0,0 +1,185 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotandev/hintents/internal/eventbus"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/gorilla/websocket"
+)
+
+func newNotifyTestServer(t *testing.T, token string) (*Server, string) {
+	t.Helper()
+	s := &Server{bus: eventbus.New(), authToken: token}
+	ts := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	t.Cleanup(ts.Close)
+	return s, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func dialWS(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial failed (status %d): %v", status, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func call(t *testing.T, conn *websocket.Conn, id int, method string, params any) map[string]json.RawMessage {
+	t.Helper()
+	if err := conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return readMessage(t, conn)
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]json.RawMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg map[string]json.RawMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return msg
+}
+
+// waitForSubscribers polls until topic has n subscribers. The server drops a
+// connection's subscriptions asynchronously after the client disconnects.
+func waitForSubscribers(t *testing.T, bus *eventbus.EventBus, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for bus.SubscriberCount(topic) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers on %s, got %d", n, topic, bus.SubscriberCount(topic))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebSocket_RequiresAuth(t *testing.T) {
+	_, url := newNotifyTestServer(t, "secret")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected dial without token to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", resp)
+	}
+
+	dialWS(t, url, http.Header{"Authorization": []string{"Bearer secret"}})
+	dialWS(t, url+"?token=secret", nil)
+}
+
+func TestWebSocket_SubscribeAndReceive(t *testing.T) {
+	s, url := newNotifyTestServer(t, "")
+	conn := dialWS(t, url, nil)
+
+	reply := call(t, conn, 1, "subscribe", SubscribeParams{Topics: []string{TopicSimulationProgress}})
+	var result SubscribeResult
+	if err := json.Unmarshal(reply["result"], &result); err != nil {
+		t.Fatalf("bad subscribe result: %s", reply["result"])
+	}
+	if result.Subscription == 0 {
+		t.Fatal("expected a subscription id")
+	}
+	waitForSubscribers(t, s.bus, TopicSimulationProgress, 1)
+
+	publishJobUpdate(s.bus, &simulator.AsyncJob{ID: "job-1", Status: simulator.JobStatusRunning, Progress: 0.5, ProgressMessage: "halfway"})
+
+	msg := readMessage(t, conn)
+	var method string
+	_ = json.Unmarshal(msg["method"], &method)
+	if method != TopicSimulationProgress {
+		t.Fatalf("expected %s notification, got %q", TopicSimulationProgress, method)
+	}
+	if _, ok := msg["id"]; ok {
+		t.Error("notifications must not carry an id")
+	}
+	var params ProgressNotification
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		t.Fatalf("bad params: %v", err)
+	}
+	if params.JobID != "job-1" || params.Progress != 0.5 || params.Message != "halfway" {
+		t.Errorf("unexpected progress notification: %+v", params)
+	}
+
+	reply = call(t, conn, 2, "unsubscribe", UnsubscribeParams{Subscription: result.Subscription})
+	if string(reply["result"]) != "true" {
+		t.Fatalf("unsubscribe failed: %s", reply["error"])
+	}
+	waitForSubscribers(t, s.bus, TopicSimulationProgress, 0)
+}
+
+func TestWebSocket_StreamsDiagnosticsBeforeCompletion(t *testing.T) {
+	s, url := newNotifyTestServer(t, "")
+	conn := dialWS(t, url, nil)
+
+	call(t, conn, 1, "subscribe", SubscribeParams{JobID: "job-2"})
+	waitForSubscribers(t, s.bus, TopicSimulationCompleted, 1)
+
+	// Updates for other jobs are filtered out.
+	publishJobUpdate(s.bus, &simulator.AsyncJob{ID: "other", Status: simulator.JobStatusFailed, Error: "boom"})
+	publishDiagnostic(s.bus, "other", 0, simulator.DiagnosticEvent{EventType: "contract"})
+
+	events := []simulator.DiagnosticEvent{{EventType: "contract"}, {EventType: "diagnostic"}}
+	for i, ev := range events {
+		publishDiagnostic(s.bus, "job-2", i, ev)
+	}
+	publishJobUpdate(s.bus, &simulator.AsyncJob{
+		ID:       "job-2",
+		Status:   simulator.JobStatusCompleted,
+		Response: &simulator.SimulationResponse{Status: "success", DiagnosticEvents: events},
+	})
+
+	want := []string{TopicSimulationDiagnostic, TopicSimulationDiagnostic, TopicSimulationCompleted}
+	for i, topic := range want {
+		msg := readMessage(t, conn)
+		var method string
+		_ = json.Unmarshal(msg["method"], &method)
+		if method != topic {
+			t.Fatalf("message %d: expected %s, got %q", i, topic, method)
+		}
+	}
+}
+
+func TestWebSocket_Errors(t *testing.T) {
+	s, url := newNotifyTestServer(t, "")
+	conn := dialWS(t, url, nil)
+
+	reply := call(t, conn, 1, "subscribe", SubscribeParams{Topics: []string{"nope"}})
+	if _, ok := reply["error"]; !ok {
+		t.Error("expected error for unknown topic")
+	}
+
+	reply = call(t, conn, 2, "unsubscribe", UnsubscribeParams{Subscription: 42})
+	if _, ok := reply["error"]; !ok {
+		t.Error("expected error for unknown subscription")
+	}
+
+	reply = call(t, conn, 3, "frobnicate", nil)
+	var rpcErr wsError
+	_ = json.Unmarshal(reply["error"], &rpcErr)
+	if rpcErr.Code != rpcMethodNotFound {
+		t.Errorf("expected method-not-found, got %+v", rpcErr)
+	}
+
+	// Subscriptions are released when the client goes away.
+	call(t, conn, 4, "subscribe", SubscribeParams{})
+	waitForSubscribers(t, s.bus, TopicSimulationFailed, 1)
+	conn.Close()
+	waitForSubscribers(t, s.bus, TopicSimulationFailed, 0)
+}
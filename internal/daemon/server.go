@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/eventbus"
 	"github.com/dotandev/hintents/internal/logger"
 	stellarrpc "github.com/dotandev/hintents/internal/rpc"
 	"github.com/dotandev/hintents/internal/simulator"
@@ -25,7 +27,9 @@ import (
 // Server represents the JSON-RPC daemon server
 type Server struct {
 	rpcClient *stellarrpc.Client
-	simulator *simulator.Runner
+	simulator simulator.RunnerInterface
+	jobs      *simulator.AsyncRunner
+	bus       *eventbus.EventBus
 	authToken string
 }
 
@@ -35,6 +39,17 @@ type Config struct {
 	Network   string
 	RPCURL    string
 	AuthToken string
+
+	// JobStorePath is the SQLite database backing the async job queue.
+	// Empty keeps jobs in memory only.
+	JobStorePath string
+	// MaxConcurrentJobs bounds the number of simulations run at once.
+	MaxConcurrentJobs int
+	// JobTTL is how long finished jobs are kept.
+	JobTTL time.Duration
+	// ClientOptions are appended when creating the RPC client, e.g. to
+	// record or replay a cassette.
+	ClientOptions []stellarrpc.ClientOption
 }
 
 // DebugTransactionRequest represents the debug_transaction RPC request
@@ -74,6 +89,44 @@ type GetContractCodeResponse struct {
 	Wasm       string `json:"wasm"`
 }
 
+// SubmitJobRequest represents the submit_job RPC request
+type SubmitJobRequest struct {
+	Request  simulator.SimulationRequest `json:"request"`
+	Priority int                         `json:"priority,omitempty"`
+}
+
+// SubmitJobResponse represents the submit_job RPC response
+type SubmitJobResponse struct {
+	JobID string `json:"job_id"`
+}
+
+// JobIDRequest identifies a job for the poll_job and cancel_job RPCs
+type JobIDRequest struct {
+	JobID string `json:"job_id"`
+}
+
+// PollJobResponse represents the poll_job RPC response
+type PollJobResponse struct {
+	Job *simulator.AsyncJob `json:"job"`
+}
+
+// CancelJobResponse represents the cancel_job RPC response
+type CancelJobResponse struct {
+	JobID     string `json:"job_id"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+// ListJobsRequest represents the list_jobs RPC request
+type ListJobsRequest struct {
+	Status []simulator.JobStatus `json:"status,omitempty"`
+	Limit  int                   `json:"limit,omitempty"`
+}
+
+// ListJobsResponse represents the list_jobs RPC response
+type ListJobsResponse struct {
+	Jobs []*simulator.AsyncJob `json:"jobs"`
+}
+
 // NewServer creates a new JSON-RPC server
 func NewServer(config Config) (*Server, error) {
 	opts := []stellarrpc.ClientOption{
@@ -83,20 +136,48 @@ func NewServer(config Config) (*Server, error) {
 	if config.RPCURL != "" {
 		opts = append(opts, stellarrpc.WithHorizonURL(config.RPCURL))
 	}
+	opts = append(opts, config.ClientOptions...)
 
 	client, err := stellarrpc.NewClient(opts...)
 	if err != nil {
 		return nil, errors.WrapValidationError(fmt.Sprintf("failed to create RPC client: %v", err))
 	}
 
-	sim, err := simulator.NewRunner("", false)
+	sim, err := simulator.NewRunnerInterface("", false)
 	if err != nil {
 		return nil, errors.WrapSimulatorNotFound(err.Error())
 	}
 
+	var store simulator.JobStore = simulator.NewMemoryJobStore()
+	if config.JobStorePath != "" {
+		store, err = simulator.OpenSQLiteJobStore(config.JobStorePath)
+		if err != nil {
+			return nil, errors.WrapValidationError(fmt.Sprintf("failed to open job store: %v", err))
+		}
+	}
+
+	bus := eventbus.New()
+	jobs, err := simulator.NewAsyncRunnerWithConfig(sim, simulator.AsyncConfig{
+		Store:         store,
+		MaxConcurrent: config.MaxConcurrentJobs,
+		TTL:           config.JobTTL,
+		OnUpdate: func(job *simulator.AsyncJob) {
+			publishJobUpdate(bus, job)
+		},
+		OnDiagnostic: func(jobID string, index int, event simulator.DiagnosticEvent) {
+			publishDiagnostic(bus, jobID, index, event)
+		},
+	})
+	if err != nil {
+		_ = store.Close()
+		return nil, errors.WrapValidationError(fmt.Sprintf("failed to start job queue: %v", err))
+	}
+
 	return &Server{
 		rpcClient: client,
 		simulator: sim,
+		jobs:      jobs,
+		bus:       bus,
 		authToken: config.AuthToken,
 	}, nil
 }
@@ -107,18 +188,16 @@ func (s *Server) authenticate(r *http.Request) bool {
 		return true // No auth required
 	}
 
-	auth := r.Header.Get("Authorization")
-	if auth == "" {
-		return false
-	}
-
 	// Support "Bearer <token>" format
-	if strings.HasPrefix(auth, "Bearer ") {
-		token := strings.TrimPrefix(auth, "Bearer ")
-		return token == s.authToken
-	}
+	return s.authenticateToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
 
-	return auth == s.authToken
+// authenticateToken checks a raw token against the configured one.
+func (s *Server) authenticateToken(token string) bool {
+	if s.authToken == "" {
+		return true
+	}
+	return token != "" && token == s.authToken
 }
 
 // DebugTransaction handles debug_transaction RPC calls
@@ -215,6 +294,70 @@ func (s *Server) GetContractCode(r *http.Request, req *GetContractCodeRequest, r
 	return nil
 }
 
+// SubmitJob handles submit_job RPC calls by queueing a simulation
+func (s *Server) SubmitJob(r *http.Request, req *SubmitJobRequest, resp *SubmitJobResponse) error {
+	if !s.authenticate(r) {
+		return errors.WrapUnauthorized("")
+	}
+
+	jobID, err := s.jobs.SubmitWithPriority(&req.Request, req.Priority)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info("Processing submit_job RPC", "job_id", jobID, "priority", req.Priority)
+	*resp = SubmitJobResponse{JobID: jobID}
+	return nil
+}
+
+// PollJob handles poll_job RPC calls
+func (s *Server) PollJob(r *http.Request, req *JobIDRequest, resp *PollJobResponse) error {
+	if !s.authenticate(r) {
+		return errors.WrapUnauthorized("")
+	}
+
+	job, err := s.jobs.Poll(req.JobID)
+	if err != nil {
+		return err
+	}
+
+	*resp = PollJobResponse{Job: job}
+	return nil
+}
+
+// CancelJob handles cancel_job RPC calls
+func (s *Server) CancelJob(r *http.Request, req *JobIDRequest, resp *CancelJobResponse) error {
+	if !s.authenticate(r) {
+		return errors.WrapUnauthorized("")
+	}
+
+	logger.Logger.Info("Processing cancel_job RPC", "job_id", req.JobID)
+	if err := s.jobs.Cancel(req.JobID); err != nil {
+		return err
+	}
+
+	*resp = CancelJobResponse{JobID: req.JobID, Cancelled: true}
+	return nil
+}
+
+// ListJobs handles list_jobs RPC calls
+func (s *Server) ListJobs(r *http.Request, req *ListJobsRequest, resp *ListJobsResponse) error {
+	if !s.authenticate(r) {
+		return errors.WrapUnauthorized("")
+	}
+
+	jobs, err := s.jobs.List(simulator.JobFilter{Status: req.Status, Limit: req.Limit})
+	if err != nil {
+		return err
+	}
+	if jobs == nil {
+		jobs = []*simulator.AsyncJob{}
+	}
+
+	*resp = ListJobsResponse{Jobs: jobs}
+	return nil
+}
+
 // Start starts the JSON-RPC server
 func (s *Server) Start(ctx context.Context, port string) error {
 	server := rpc.NewServer()
@@ -227,6 +370,9 @@ func (s *Server) Start(ctx context.Context, port string) error {
 
 	http.Handle("/rpc", server)
 
+	// Streaming notifications
+	http.HandleFunc("/ws", s.handleWebSocket)
+
 	// Health check endpoint
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -252,5 +398,12 @@ func (s *Server) Start(ctx context.Context, port string) error {
 	// Wait for context cancellation
 	<-ctx.Done()
 	logger.Logger.Info("Shutting down JSON-RPC server")
-	return srv.Shutdown(context.Background())
+	err := srv.Shutdown(context.Background())
+	if cerr := s.jobs.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := s.simulator.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
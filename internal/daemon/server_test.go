@@ -210,3 +210,71 @@ func TestServer_GetContractCode_Auth(t *testing.T) {
 		t.Error("Expected auth error without token")
 	}
 }
+
+func TestServer_JobLifecycle(t *testing.T) {
+	t.Setenv("ERST_SIM_PATH", os.Args[0])
+
+	server, err := NewServer(Config{
+		Network: string(stellarrpc.Testnet),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.jobs.Close()
+
+	req := httptest.NewRequest("POST", "/rpc", nil)
+
+	var submitted SubmitJobResponse
+	if err := server.SubmitJob(req, &SubmitJobRequest{Priority: 5}, &submitted); err != nil {
+		t.Fatalf("SubmitJob failed: %v", err)
+	}
+	if submitted.JobID == "" {
+		t.Fatal("Expected a job ID")
+	}
+
+	var polled PollJobResponse
+	if err := server.PollJob(req, &JobIDRequest{JobID: submitted.JobID}, &polled); err != nil {
+		t.Fatalf("PollJob failed: %v", err)
+	}
+	if polled.Job.ID != submitted.JobID || polled.Job.Priority != 5 {
+		t.Errorf("Unexpected job: %+v", polled.Job)
+	}
+
+	var listed ListJobsResponse
+	if err := server.ListJobs(req, &ListJobsRequest{}, &listed); err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(listed.Jobs) != 1 {
+		t.Errorf("Expected 1 job, got %d", len(listed.Jobs))
+	}
+
+	if err := server.PollJob(req, &JobIDRequest{JobID: "missing"}, &polled); err == nil {
+		t.Error("Expected error polling an unknown job")
+	}
+	var cancelled CancelJobResponse
+	if err := server.CancelJob(req, &JobIDRequest{JobID: "missing"}, &cancelled); err == nil {
+		t.Error("Expected error cancelling an unknown job")
+	}
+}
+
+func TestServer_Jobs_Auth(t *testing.T) {
+	t.Setenv("ERST_SIM_PATH", os.Args[0])
+
+	server, err := NewServer(Config{
+		Network:   string(stellarrpc.Testnet),
+		AuthToken: "secret-token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.jobs.Close()
+
+	req := httptest.NewRequest("POST", "/rpc", nil)
+
+	if err := server.SubmitJob(req, &SubmitJobRequest{}, &SubmitJobResponse{}); err == nil {
+		t.Error("Expected SubmitJob to require authentication")
+	}
+	if err := server.ListJobs(req, &ListJobsRequest{}, &ListJobsResponse{}); err == nil {
+		t.Error("Expected ListJobs to require authentication")
+	}
+}
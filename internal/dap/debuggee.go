@@ -0,0 +1,368 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package dap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/dwarf"
+	"github.com/dotandev/hintents/internal/trace"
+)
+
+// traceSourceRef is the sourceReference of the virtual document that lists
+// one trace step per line. It is used whenever a step has no DWARF location.
+const traceSourceRef = 1
+
+// frameEntry is one call frame: the contract function and the step at which
+// it was last active.
+type frameEntry struct {
+	name string
+	step int
+}
+
+// debuggee wraps a loaded trace with everything derived from it: source
+// locations, call stacks and breakpoints.
+type debuggee struct {
+	trace    *trace.ExecutionTrace
+	detector *trace.TrapDetector
+	subprogs []dwarf.SubprogramInfo
+
+	locations []*dwarf.SourceLocation
+	stacks    [][]frameEntry
+
+	lineBPs map[string]map[int]int // source key -> line -> breakpoint ID
+	fnBPs   map[string]int         // function name -> breakpoint ID
+}
+
+func newDebuggee(t *trace.ExecutionTrace, wasm []byte) *debuggee {
+	d := &debuggee{
+		trace:   t,
+		lineBPs: make(map[string]map[int]int),
+		fnBPs:   make(map[string]int),
+	}
+
+	if len(wasm) > 0 {
+		d.detector, _ = trace.NewTrapDetector(wasm)
+		if parser, err := dwarf.NewParser(wasm); err == nil && parser.HasDebugInfo() {
+			d.subprogs, _ = parser.GetSubprograms()
+		}
+	}
+
+	d.locations = make([]*dwarf.SourceLocation, len(t.States))
+	for i := range t.States {
+		d.locations[i] = d.resolveLocation(&t.States[i])
+	}
+	d.buildStacks()
+	return d
+}
+
+func (d *debuggee) steps() int {
+	return len(d.trace.States)
+}
+
+func (d *debuggee) state(step int) *trace.ExecutionState {
+	return &d.trace.States[step]
+}
+
+// resolveLocation maps a step to source using DWARF. Traps use the trap
+// detector, which follows inlined frames to the faulting line; other steps
+// map to the declaration of the function they execute in.
+func (d *debuggee) resolveLocation(state *trace.ExecutionState) *dwarf.SourceLocation {
+	if state.Error != "" && d.detector != nil {
+		if trap := d.detector.DetectTrap(state); trap != nil && trap.SourceLocation != nil {
+			return trap.SourceLocation
+		}
+	}
+	if state.Function == "" {
+		return nil
+	}
+	for _, sp := range d.subprogs {
+		if sp.Name == state.Function || sp.DemangledName == state.Function ||
+			strings.HasSuffix(sp.DemangledName, "::"+state.Function) {
+			if sp.File == "" || sp.Line == 0 {
+				return nil
+			}
+			return &dwarf.SourceLocation{File: sp.File, Line: sp.Line}
+		}
+	}
+	return nil
+}
+
+// buildStacks derives the call stack at every step. A step in a function
+// already on the stack is treated as a return into that frame.
+func (d *debuggee) buildStacks() {
+	d.stacks = make([][]frameEntry, d.steps())
+	var stack []frameEntry
+
+	for i := range d.trace.States {
+		state := d.state(i)
+		if state.Function != "" {
+			name := frameName(state)
+			found := -1
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].name == name {
+					found = j
+					break
+				}
+			}
+			if found >= 0 {
+				stack = stack[:found+1]
+				stack[found].step = i
+			} else {
+				stack = append(stack, frameEntry{name: name, step: i})
+			}
+		} else if len(stack) > 0 {
+			stack[len(stack)-1].step = i
+		}
+
+		d.stacks[i] = append([]frameEntry(nil), stack...)
+	}
+}
+
+func frameName(state *trace.ExecutionState) string {
+	if state.ContractID == "" {
+		return state.Function
+	}
+	return fmt.Sprintf("%s::%s", shortContractID(state.ContractID), state.Function)
+}
+
+func shortContractID(id string) string {
+	if len(id) <= 12 {
+		return id
+	}
+	return id[:4] + "…" + id[len(id)-4:]
+}
+
+func (d *debuggee) depth(step int) int {
+	return len(d.stacks[step])
+}
+
+// source returns the DAP source and line shown for step.
+func (d *debuggee) source(step int) (*Source, int) {
+	if loc := d.locations[step]; loc != nil {
+		return &Source{Name: filepath.Base(loc.File), Path: loc.File}, loc.Line
+	}
+	return d.traceSource(), step + 1
+}
+
+func (d *debuggee) traceSource() *Source {
+	name := d.trace.TransactionHash
+	if name == "" {
+		name = "trace"
+	} else if len(name) > 16 {
+		name = name[:16]
+	}
+	return &Source{Name: name + ".trace", SourceReference: traceSourceRef}
+}
+
+// traceDocument renders the virtual trace source, one step per line.
+func (d *debuggee) traceDocument() string {
+	var sb strings.Builder
+	for i := range d.trace.States {
+		state := d.state(i)
+		fmt.Fprintf(&sb, "%5d  %-14s", i, trace.ClassifyEventType(state))
+		if state.Function != "" {
+			sb.WriteString(" " + frameName(state))
+		} else if state.Operation != "" {
+			sb.WriteString(" " + state.Operation)
+		}
+		if state.Error != "" {
+			sb.WriteString("  !! " + state.Error)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// -------------------- Breakpoints --------------------
+
+// sourceKey identifies a breakpoint source: the virtual trace document or a
+// cleaned file path.
+func sourceKey(src Source) string {
+	if src.SourceReference == traceSourceRef {
+		return "trace"
+	}
+	return filepath.Clean(src.Path)
+}
+
+// lineVerified reports whether any step maps to key:line.
+func (d *debuggee) lineVerified(key string, line int) bool {
+	if key == "trace" {
+		return line >= 1 && line <= d.steps()
+	}
+	for step := range d.locations {
+		if d.matchesLine(step, key, line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *debuggee) matchesLine(step int, key string, line int) bool {
+	if key == "trace" {
+		return step+1 == line
+	}
+	loc := d.locations[step]
+	if loc == nil || loc.Line != line {
+		return false
+	}
+	return samePath(loc.File, key)
+}
+
+// samePath compares a DWARF path with an editor path. DWARF paths are often
+// relative to the build directory, so a suffix match is accepted.
+func samePath(dwarfPath, editorPath string) bool {
+	a := filepath.ToSlash(filepath.Clean(dwarfPath))
+	b := filepath.ToSlash(filepath.Clean(editorPath))
+	return a == b || strings.HasSuffix(b, "/"+a) || strings.HasSuffix(a, "/"+b)
+}
+
+// functionVerified reports whether any step runs the named function.
+func (d *debuggee) functionVerified(name string) bool {
+	for i := range d.trace.States {
+		if matchesFunction(d.state(i), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFunction accepts a bare function name, "<contract>::<function>" or
+// a contract ID, which breaks on every call into that contract.
+func matchesFunction(state *trace.ExecutionState, name string) bool {
+	if state.Function == "" {
+		return false
+	}
+	return name == state.Function ||
+		name == state.ContractID ||
+		name == state.ContractID+"::"+state.Function
+}
+
+// hits returns the IDs of the breakpoints that match step.
+func (d *debuggee) hits(step int) []int {
+	var ids []int
+	for key, lines := range d.lineBPs {
+		for line, id := range lines {
+			if d.matchesLine(step, key, line) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	state := d.state(step)
+	for name, id := range d.fnBPs {
+		if matchesFunction(state, name) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// entryHits returns the breakpoints hit at step that were not already hit at
+// the previous step, so a run of steps on the same line stops only once.
+func (d *debuggee) entryHits(step int) []int {
+	ids := d.hits(step)
+	if len(ids) == 0 || step == 0 {
+		return ids
+	}
+	prev := make(map[int]bool)
+	for _, id := range d.hits(step - 1) {
+		prev[id] = true
+	}
+	var fresh []int
+	for _, id := range ids {
+		if !prev[id] {
+			fresh = append(fresh, id)
+		}
+	}
+	return fresh
+}
+
+// -------------------- Motion --------------------
+
+// stop describes where execution came to rest after a motion request.
+type stop struct {
+	step        int
+	reason      string
+	description string
+	hitIDs      []int
+}
+
+// continueForward runs to the next breakpoint or trap after from. With
+// neither, it stops on the last step so the user can travel back.
+func (d *debuggee) continueForward(from int) stop {
+	for i := from + 1; i < d.steps(); i++ {
+		if ids := d.entryHits(i); len(ids) > 0 {
+			return stop{step: i, reason: "breakpoint", hitIDs: ids}
+		}
+		if d.state(i).Error != "" {
+			return stop{step: i, reason: "exception", description: d.state(i).Error}
+		}
+	}
+	return stop{step: d.steps() - 1, reason: "step", description: "End of trace"}
+}
+
+// continueBackward runs backwards to the previous breakpoint before from,
+// or to the first step.
+func (d *debuggee) continueBackward(from int) stop {
+	for i := from - 1; i >= 0; i-- {
+		if ids := d.entryHits(i); len(ids) > 0 {
+			return stop{step: i, reason: "breakpoint", hitIDs: ids}
+		}
+	}
+	return stop{step: 0, reason: "entry", description: "Start of trace"}
+}
+
+// stepOver moves to the next step that is not deeper in the call stack.
+func (d *debuggee) stepOver(from int) stop {
+	return d.stepUntil(from, func(i int) bool { return d.depth(i) <= d.depth(from) })
+}
+
+// stepOut moves to the next step in a caller of the current frame.
+func (d *debuggee) stepOut(from int) stop {
+	return d.stepUntil(from, func(i int) bool { return d.depth(i) < d.depth(from) })
+}
+
+func (d *debuggee) stepIn(from int) stop {
+	return d.stepUntil(from, func(int) bool { return true })
+}
+
+func (d *debuggee) stepUntil(from int, done func(i int) bool) stop {
+	for i := from + 1; i < d.steps(); i++ {
+		if done(i) || d.state(i).Error != "" {
+			return d.stepStop(i)
+		}
+	}
+	return stop{step: d.steps() - 1, reason: "step", description: "End of trace"}
+}
+
+func (d *debuggee) stepBack(from int) stop {
+	if from <= 0 {
+		return stop{step: 0, reason: "entry", description: "Start of trace"}
+	}
+	return d.stepStop(from - 1)
+}
+
+func (d *debuggee) stepStop(step int) stop {
+	if err := d.state(step).Error; err != "" {
+		return stop{step: step, reason: "exception", description: err}
+	}
+	return stop{step: step, reason: "step"}
+}
+
+// gotoTargets lists the steps shown at key:line.
+func (d *debuggee) gotoTargets(key string, line int) []GotoTarget {
+	var targets []GotoTarget
+	for i := range d.trace.States {
+		if d.matchesLine(i, key, line) {
+			targets = append(targets, GotoTarget{
+				ID:    i + 1,
+				Label: fmt.Sprintf("step %d", i),
+				Line:  line,
+			})
+		}
+	}
+	return targets
+}
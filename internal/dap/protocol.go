@@ -0,0 +1,244 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dap implements a Debug Adapter Protocol server over recorded
+// execution traces, so editors can step forwards and backwards through a
+// replayed transaction.
+//
+// Only the subset of the protocol needed for trace debugging is modelled.
+// See https://microsoft.github.io/debug-adapter-protocol/specification.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// maxMessageSize bounds a single DAP message body.
+const maxMessageSize = 16 << 20
+
+// -------------------- Base protocol --------------------
+
+// Request is a client-to-adapter request.
+type Request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Response answers a Request.
+type Response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+// Event is an adapter-initiated notification.
+type Event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// ReadRequest reads one Content-Length framed request.
+func ReadRequest(r *bufio.Reader) (*Request, error) {
+	tp := textproto.NewReader(r)
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read DAP header: %w", err)
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("invalid DAP Content-Length %q", header.Get("Content-Length"))
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("DAP message too large: %d bytes", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read DAP body: %w", err)
+	}
+
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid DAP message: %w", err)
+	}
+	return &req, nil
+}
+
+// WriteMessage writes msg with a Content-Length header.
+func WriteMessage(w io.Writer, msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// -------------------- Types --------------------
+
+// Capabilities advertises the features this adapter supports.
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsFunctionBreakpoints      bool `json:"supportsFunctionBreakpoints"`
+	SupportsStepBack                 bool `json:"supportsStepBack"`
+	SupportsGotoTargetsRequest       bool `json:"supportsGotoTargetsRequest"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	SupportsExceptionInfoRequest     bool `json:"supportsExceptionInfoRequest"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+// Source identifies a source file, or a virtual document served through the
+// source request when SourceReference is non-zero.
+type Source struct {
+	Name            string `json:"name,omitempty"`
+	Path            string `json:"path,omitempty"`
+	SourceReference int    `json:"sourceReference,omitempty"`
+}
+
+type StackFrame struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Source *Source `json:"source,omitempty"`
+	Line   int     `json:"line"`
+	Column int     `json:"column"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	PresentationHint   string `json:"presentationHint,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Breakpoint struct {
+	ID       int     `json:"id,omitempty"`
+	Verified bool    `json:"verified"`
+	Message  string  `json:"message,omitempty"`
+	Source   *Source `json:"source,omitempty"`
+	Line     int     `json:"line,omitempty"`
+}
+
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+type FunctionBreakpoint struct {
+	Name string `json:"name"`
+}
+
+type GotoTarget struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Line  int    `json:"line"`
+}
+
+// -------------------- Arguments --------------------
+
+// LaunchArguments are the erst-specific launch.json attributes. Exactly one
+// of Trace or Session selects what to debug; Wasm optionally supplies a
+// contract binary with DWARF info for source mapping.
+type LaunchArguments struct {
+	Trace       string `json:"trace,omitempty"`
+	Session     string `json:"session,omitempty"`
+	Wasm        string `json:"wasm,omitempty"`
+	StopOnEntry *bool  `json:"stopOnEntry,omitempty"`
+}
+
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+type SetFunctionBreakpointsArguments struct {
+	Breakpoints []FunctionBreakpoint `json:"breakpoints"`
+}
+
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame,omitempty"`
+	Levels     int `json:"levels,omitempty"`
+}
+
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type SourceArguments struct {
+	Source          *Source `json:"source,omitempty"`
+	SourceReference int     `json:"sourceReference"`
+}
+
+type GotoTargetsArguments struct {
+	Source Source `json:"source"`
+	Line   int    `json:"line"`
+}
+
+type GotoArguments struct {
+	ThreadID int `json:"threadId"`
+	TargetID int `json:"targetId"`
+}
+
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId,omitempty"`
+	Context    string `json:"context,omitempty"`
+}
+
+// -------------------- Bodies --------------------
+
+type StoppedEventBody struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	Text              string `json:"text,omitempty"`
+	HitBreakpointIDs  []int  `json:"hitBreakpointIds,omitempty"`
+}
+
+type OutputEventBody struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
+
+type ExceptionInfoBody struct {
+	ExceptionID string `json:"exceptionId"`
+	Description string `json:"description,omitempty"`
+	BreakMode   string `json:"breakMode"`
+}
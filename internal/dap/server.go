@@ -0,0 +1,51 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package dap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/logger"
+)
+
+// ServeListener accepts DAP clients on ln and serves each in its own session
+// until ctx is cancelled.
+func ServeListener(ctx context.Context, ln net.Listener, load Loader) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			// Unblock the session's read when the server shuts down.
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+
+			logger.Logger.Info("DAP client connected", "remote", conn.RemoteAddr())
+			if err := NewSession(conn, conn, load).Serve(ctx); err != nil && ctx.Err() == nil {
+				logger.Logger.Warn("DAP session ended with error", "remote", conn.RemoteAddr(), "error", err)
+			}
+			logger.Logger.Info("DAP client disconnected", "remote", conn.RemoteAddr())
+		}()
+	}
+}
@@ -0,0 +1,699 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/trace"
+)
+
+// threadID is the single thread reported to the client: the transaction.
+const threadID = 1
+
+// Loader resolves launch arguments to a trace and an optional WASM binary
+// carrying DWARF debug info.
+type Loader func(ctx context.Context, args LaunchArguments) (*trace.ExecutionTrace, []byte, error)
+
+// Session serves one DAP client.
+type Session struct {
+	r    *bufio.Reader
+	w    io.Writer
+	load Loader
+	seq  int
+
+	dbg         *debuggee
+	current     int
+	vars        *variableStore
+	configured  bool
+	stopOnEntry bool
+	started     bool
+	nextBP      int
+
+	// pending is the stop produced by the last motion request. It is
+	// reported after the response, as DAP requires the response to precede
+	// the stopped event.
+	pending *stop
+
+	// Breakpoints are kept as requested so they can be re-verified once the
+	// trace is loaded; clients often send them before launch.
+	lineBPs map[string][]int
+	fnBPs   []string
+}
+
+// NewSession creates a session reading requests from r and writing
+// responses and events to w.
+func NewSession(r io.Reader, w io.Writer, load Loader) *Session {
+	return &Session{
+		r:           bufio.NewReader(r),
+		w:           w,
+		load:        load,
+		vars:        newVariableStore(),
+		stopOnEntry: true,
+		lineBPs:     make(map[string][]int),
+	}
+}
+
+// Serve handles requests until the client disconnects or ctx is cancelled.
+// Cancellation takes effect when the next request arrives.
+func (s *Session) Serve(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		req, err := ReadRequest(s.r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		done, err := s.dispatch(ctx, req)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// dispatch handles one request. It returns true once the session is over.
+func (s *Session) dispatch(ctx context.Context, req *Request) (bool, error) {
+	logger.Logger.Debug("DAP request", "command", req.Command, "seq", req.Seq)
+
+	var (
+		body any
+		err  error
+		done bool
+	)
+	switch req.Command {
+	case "initialize":
+		body = Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsFunctionBreakpoints:      true,
+			SupportsStepBack:                 true,
+			SupportsGotoTargetsRequest:       true,
+			SupportsEvaluateForHovers:        true,
+			SupportsExceptionInfoRequest:     true,
+			SupportsTerminateRequest:         true,
+		}
+		if err := s.respond(req, body, nil); err != nil {
+			return false, err
+		}
+		return false, s.event("initialized", nil)
+	case "launch", "attach":
+		err = s.launch(ctx, req)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req)
+	case "setFunctionBreakpoints":
+		body, err = s.setFunctionBreakpoints(req)
+	case "setExceptionBreakpoints":
+		body = map[string]any{"breakpoints": []Breakpoint{}}
+	case "configurationDone":
+		s.configured = true
+	case "threads":
+		body = map[string]any{"threads": []Thread{{ID: threadID, Name: s.threadName()}}}
+	case "stackTrace":
+		body, err = s.stackTrace(req)
+	case "scopes":
+		body, err = s.scopes(req)
+	case "variables":
+		body, err = s.variables(req)
+	case "source":
+		body, err = s.source()
+	case "evaluate":
+		body, err = s.evaluate(req)
+	case "exceptionInfo":
+		body, err = s.exceptionInfo()
+	case "continue":
+		body = map[string]any{"allThreadsContinued": true}
+		err = s.move(func(from int) stop { return s.dbg.continueForward(from) })
+	case "reverseContinue":
+		err = s.move(func(from int) stop { return s.dbg.continueBackward(from) })
+	case "next":
+		err = s.move(func(from int) stop { return s.dbg.stepOver(from) })
+	case "stepIn":
+		err = s.move(func(from int) stop { return s.dbg.stepIn(from) })
+	case "stepOut":
+		err = s.move(func(from int) stop { return s.dbg.stepOut(from) })
+	case "stepBack":
+		err = s.move(func(from int) stop { return s.dbg.stepBack(from) })
+	case "gotoTargets":
+		body, err = s.gotoTargets(req)
+	case "goto":
+		err = s.gotoStep(req)
+	case "pause":
+		// Execution is never running between requests.
+	case "disconnect", "terminate":
+		done = true
+	default:
+		err = fmt.Errorf("unsupported request %q", req.Command)
+	}
+
+	if werr := s.respond(req, body, err); werr != nil {
+		return false, werr
+	}
+	if err == nil {
+		if werr := s.afterResponse(req.Command); werr != nil {
+			return false, werr
+		}
+	}
+	if done && req.Command == "terminate" {
+		return true, s.event("terminated", nil)
+	}
+	return done, nil
+}
+
+// afterResponse sends the events that must follow a response.
+func (s *Session) afterResponse(command string) error {
+	switch command {
+	case "launch", "attach", "configurationDone":
+		return s.maybeStart()
+	case "continue", "reverseContinue", "next", "stepIn", "stepOut", "stepBack", "goto":
+		return s.reportStop()
+	}
+	return nil
+}
+
+func (s *Session) respond(req *Request, body any, err error) error {
+	s.seq++
+	resp := Response{
+		Seq:        s.seq,
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		resp.Message = err.Error()
+		resp.Body = nil
+	}
+	return WriteMessage(s.w, resp)
+}
+
+func (s *Session) event(name string, body any) error {
+	s.seq++
+	return WriteMessage(s.w, Event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+func (s *Session) output(format string, args ...any) error {
+	return s.event("output", OutputEventBody{Category: "console", Output: fmt.Sprintf(format, args...) + "\n"})
+}
+
+// -------------------- Launch --------------------
+
+func (s *Session) launch(ctx context.Context, req *Request) error {
+	var args LaunchArguments
+	if len(req.Arguments) > 0 {
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return fmt.Errorf("invalid launch arguments: %w", err)
+		}
+	}
+	if args.StopOnEntry != nil {
+		s.stopOnEntry = *args.StopOnEntry
+	}
+
+	t, wasm, err := s.load(ctx, args)
+	if err != nil {
+		return err
+	}
+	if len(t.States) == 0 {
+		return fmt.Errorf("trace has no steps")
+	}
+
+	s.dbg = newDebuggee(t, wasm)
+	s.applyBreakpoints()
+	return nil
+}
+
+// maybeStart reports the first stop once the trace is loaded and the client
+// has finished configuration.
+func (s *Session) maybeStart() error {
+	if s.dbg == nil || !s.configured || s.started {
+		return nil
+	}
+	s.started = true
+
+	if err := s.output("Loaded trace %s with %d steps", s.threadName(), s.dbg.steps()); err != nil {
+		return err
+	}
+	// Breakpoints sent before launch could not be verified until now.
+	if err := s.announceBreakpoints(); err != nil {
+		return err
+	}
+
+	if s.stopOnEntry {
+		s.current = 0
+		return s.sendStopped(stop{step: 0, reason: "entry"})
+	}
+	st := s.dbg.continueForward(-1)
+	s.current = st.step
+	return s.sendStopped(st)
+}
+
+func (s *Session) threadName() string {
+	if s.dbg != nil && s.dbg.trace.TransactionHash != "" {
+		return s.dbg.trace.TransactionHash
+	}
+	return "transaction"
+}
+
+// -------------------- Breakpoints --------------------
+
+func (s *Session) setBreakpoints(req *Request) (any, error) {
+	var args SetBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid setBreakpoints arguments: %w", err)
+	}
+
+	key := sourceKey(args.Source)
+	lines := make([]int, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line
+	}
+	s.lineBPs[key] = lines
+	s.applyBreakpoints()
+
+	bps := make([]Breakpoint, len(lines))
+	for i, line := range lines {
+		src := args.Source
+		bps[i] = s.lineBreakpoint(key, line, &src)
+	}
+	return map[string]any{"breakpoints": bps}, nil
+}
+
+func (s *Session) setFunctionBreakpoints(req *Request) (any, error) {
+	var args SetFunctionBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid setFunctionBreakpoints arguments: %w", err)
+	}
+
+	s.fnBPs = s.fnBPs[:0]
+	for _, bp := range args.Breakpoints {
+		s.fnBPs = append(s.fnBPs, strings.TrimSpace(bp.Name))
+	}
+	s.applyBreakpoints()
+
+	bps := make([]Breakpoint, len(s.fnBPs))
+	for i, name := range s.fnBPs {
+		bps[i] = s.functionBreakpoint(name)
+	}
+	return map[string]any{"breakpoints": bps}, nil
+}
+
+// applyBreakpoints rebuilds the debuggee's breakpoint tables, assigning
+// stable IDs per source line and function name.
+func (s *Session) applyBreakpoints() {
+	if s.dbg == nil {
+		return
+	}
+	old := s.dbg
+	lineBPs := make(map[string]map[int]int)
+	for key, lines := range s.lineBPs {
+		lineBPs[key] = make(map[int]int)
+		for _, line := range lines {
+			id, ok := old.lineBPs[key][line]
+			if !ok {
+				s.nextBP++
+				id = s.nextBP
+			}
+			lineBPs[key][line] = id
+		}
+	}
+	fnBPs := make(map[string]int)
+	for _, name := range s.fnBPs {
+		id, ok := old.fnBPs[name]
+		if !ok {
+			s.nextBP++
+			id = s.nextBP
+		}
+		fnBPs[name] = id
+	}
+	s.dbg.lineBPs = lineBPs
+	s.dbg.fnBPs = fnBPs
+}
+
+func (s *Session) lineBreakpoint(key string, line int, src *Source) Breakpoint {
+	if s.dbg == nil {
+		return Breakpoint{Verified: false, Line: line, Source: src, Message: "Trace not loaded yet"}
+	}
+	bp := Breakpoint{ID: s.dbg.lineBPs[key][line], Line: line, Source: src}
+	bp.Verified = s.dbg.lineVerified(key, line)
+	if !bp.Verified {
+		bp.Message = "No trace step maps to this line"
+	}
+	return bp
+}
+
+func (s *Session) functionBreakpoint(name string) Breakpoint {
+	if s.dbg == nil {
+		return Breakpoint{Verified: false, Message: "Trace not loaded yet"}
+	}
+	bp := Breakpoint{ID: s.dbg.fnBPs[name]}
+	bp.Verified = s.dbg.functionVerified(name)
+	if !bp.Verified {
+		bp.Message = fmt.Sprintf("Function %q is not called in this trace", name)
+	}
+	return bp
+}
+
+func (s *Session) announceBreakpoints() error {
+	keys := make([]string, 0, len(s.lineBPs))
+	for key := range s.lineBPs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, line := range s.lineBPs[key] {
+			var src *Source
+			if key == "trace" {
+				src = s.dbg.traceSource()
+			} else {
+				src = &Source{Path: key}
+			}
+			bp := s.lineBreakpoint(key, line, src)
+			if err := s.event("breakpoint", map[string]any{"reason": "changed", "breakpoint": bp}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range s.fnBPs {
+		bp := s.functionBreakpoint(name)
+		if err := s.event("breakpoint", map[string]any{"reason": "changed", "breakpoint": bp}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// -------------------- Execution --------------------
+
+// motion computes where a request starting at step from comes to rest.
+type motion func(from int) stop
+
+func (s *Session) move(fn motion) error {
+	if s.dbg == nil {
+		return fmt.Errorf("no trace loaded")
+	}
+	st := fn(s.current)
+	s.current = st.step
+	s.pending = &st
+	return nil
+}
+
+func (s *Session) reportStop() error {
+	if s.pending == nil {
+		return nil
+	}
+	st := *s.pending
+	s.pending = nil
+	return s.sendStopped(st)
+}
+
+func (s *Session) sendStopped(st stop) error {
+	s.vars.reset()
+	if _, err := s.dbg.trace.JumpToStep(st.step); err != nil {
+		return err
+	}
+	return s.event("stopped", StoppedEventBody{
+		Reason:            st.reason,
+		Description:       st.description,
+		ThreadID:          threadID,
+		AllThreadsStopped: true,
+		HitBreakpointIDs:  st.hitIDs,
+	})
+}
+
+func (s *Session) gotoTargets(req *Request) (any, error) {
+	if s.dbg == nil {
+		return nil, fmt.Errorf("no trace loaded")
+	}
+	var args GotoTargetsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid gotoTargets arguments: %w", err)
+	}
+	return map[string]any{"targets": s.dbg.gotoTargets(sourceKey(args.Source), args.Line)}, nil
+}
+
+func (s *Session) gotoStep(req *Request) error {
+	if s.dbg == nil {
+		return fmt.Errorf("no trace loaded")
+	}
+	var args GotoArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return fmt.Errorf("invalid goto arguments: %w", err)
+	}
+	step := args.TargetID - 1
+	if step < 0 || step >= s.dbg.steps() {
+		return fmt.Errorf("unknown goto target %d", args.TargetID)
+	}
+	return s.move(func(int) stop {
+		st := s.dbg.stepStop(step)
+		st.reason = "goto"
+		return st
+	})
+}
+
+// -------------------- Inspection --------------------
+
+// frameStep returns the step a frame ID refers to. Frame 1 is the innermost
+// frame at the current step; outer frames are shown at the step where they
+// were last active.
+func (s *Session) frameStep(frameID int) (int, error) {
+	if s.dbg == nil {
+		return 0, fmt.Errorf("no trace loaded")
+	}
+	stack := s.dbg.stacks[s.current]
+	if frameID == 1 {
+		return s.current, nil
+	}
+	idx := len(stack) - frameID
+	if idx < 0 || idx >= len(stack) {
+		return 0, fmt.Errorf("unknown frame %d", frameID)
+	}
+	return stack[idx].step, nil
+}
+
+func (s *Session) stackTrace(req *Request) (any, error) {
+	if s.dbg == nil {
+		return nil, fmt.Errorf("no trace loaded")
+	}
+	var args StackTraceArguments
+	if len(req.Arguments) > 0 {
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid stackTrace arguments: %w", err)
+		}
+	}
+
+	stack := s.dbg.stacks[s.current]
+	var frames []StackFrame
+	if len(stack) == 0 {
+		src, line := s.dbg.source(s.current)
+		frames = append(frames, StackFrame{ID: 1, Name: s.stepName(s.current), Source: src, Line: line, Column: 1})
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		id := len(stack) - i
+		step := stack[i].step
+		if id == 1 {
+			step = s.current
+		}
+		src, line := s.dbg.source(step)
+		frames = append(frames, StackFrame{ID: id, Name: stack[i].name, Source: src, Line: line, Column: 1})
+	}
+
+	total := len(frames)
+	if args.StartFrame > 0 && args.StartFrame < len(frames) {
+		frames = frames[args.StartFrame:]
+	} else if args.StartFrame >= len(frames) {
+		frames = nil
+	}
+	if args.Levels > 0 && args.Levels < len(frames) {
+		frames = frames[:args.Levels]
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": total}, nil
+}
+
+func (s *Session) stepName(step int) string {
+	state := s.dbg.state(step)
+	if state.Operation != "" {
+		return fmt.Sprintf("step %d: %s", step, state.Operation)
+	}
+	return fmt.Sprintf("step %d", step)
+}
+
+func (s *Session) scopes(req *Request) (any, error) {
+	var args ScopesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid scopes arguments: %w", err)
+	}
+	step, err := s.frameStep(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := s.dbg.state(step)
+	scopes := []Scope{
+		{Name: "Locals", PresentationHint: "locals", VariablesReference: s.vars.add(func() []Variable { return s.locals(step) })},
+		{Name: "Step", VariablesReference: s.vars.add(func() []Variable { return s.stepVariables(state) })},
+	}
+
+	// Host state and memory accumulate over the whole trace, so they are
+	// reconstructed at the frame's step.
+	reconstructed, err := s.dbg.trace.ReconstructStateAt(step)
+	if err != nil {
+		return nil, err
+	}
+	scopes = append(scopes,
+		Scope{Name: "Host State", VariablesReference: s.vars.add(func() []Variable { return s.vars.mapVariables(reconstructed.HostState) })},
+		Scope{Name: "Memory", VariablesReference: s.vars.add(func() []Variable { return s.vars.mapVariables(reconstructed.Memory) }), Expensive: len(reconstructed.Memory) > 100},
+	)
+	return map[string]any{"scopes": scopes}, nil
+}
+
+// locals lists the call arguments and return value of a step, followed by
+// the DWARF locals of its function when debug info is available.
+func (s *Session) locals(step int) []Variable {
+	state := s.dbg.state(step)
+	var vars []Variable
+	for i, arg := range state.Arguments {
+		vars = append(vars, s.vars.variable(fmt.Sprintf("arg%d", i), arg))
+	}
+	if len(state.Arguments) == 0 {
+		for i, raw := range state.RawArguments {
+			vars = append(vars, Variable{Name: fmt.Sprintf("arg%d", i), Value: raw, Type: "xdr"})
+		}
+	}
+	if state.ReturnValue != nil {
+		vars = append(vars, s.vars.variable("return", state.ReturnValue))
+	} else if state.RawReturnValue != "" {
+		vars = append(vars, Variable{Name: "return", Value: state.RawReturnValue, Type: "xdr"})
+	}
+
+	if state.Function != "" {
+		for _, sp := range s.dbg.subprogs {
+			if sp.Name != state.Function && sp.DemangledName != state.Function {
+				continue
+			}
+			for _, lv := range sp.LocalVariables {
+				name := lv.DemangledName
+				if name == "" {
+					name = lv.Name
+				}
+				vars = append(vars, Variable{Name: name, Value: formatValue(lv.Value), Type: lv.Type})
+			}
+			break
+		}
+	}
+	return vars
+}
+
+func (s *Session) stepVariables(state *trace.ExecutionState) []Variable {
+	fields := []struct{ name, value string }{
+		{"step", fmt.Sprintf("%d", state.Step)},
+		{"operation", state.Operation},
+		{"event_type", trace.ClassifyEventType(state)},
+		{"contract_id", state.ContractID},
+		{"function", state.Function},
+		{"wasm_instruction", state.WasmInstruction},
+		{"error", state.Error},
+	}
+	var vars []Variable
+	for _, f := range fields {
+		if f.value != "" {
+			vars = append(vars, Variable{Name: f.name, Value: f.value, Type: "string"})
+		}
+	}
+	return vars
+}
+
+func (s *Session) variables(req *Request) (any, error) {
+	var args VariablesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid variables arguments: %w", err)
+	}
+	vars, ok := s.vars.get(args.VariablesReference)
+	if !ok {
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+	if vars == nil {
+		vars = []Variable{}
+	}
+	return map[string]any{"variables": vars}, nil
+}
+
+func (s *Session) source() (any, error) {
+	if s.dbg == nil {
+		return nil, fmt.Errorf("no trace loaded")
+	}
+	return map[string]any{"content": s.dbg.traceDocument(), "mimeType": "text/plain"}, nil
+}
+
+// evaluate resolves hover and watch expressions against the host state,
+// memory and arguments of the selected frame.
+func (s *Session) evaluate(req *Request) (any, error) {
+	var args EvaluateArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid evaluate arguments: %w", err)
+	}
+	frameID := args.FrameID
+	if frameID == 0 {
+		frameID = 1
+	}
+	step, err := s.frameStep(frameID)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := strings.TrimSpace(args.Expression)
+	reconstructed, err := s.dbg.trace.ReconstructStateAt(step)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range []map[string]interface{}{reconstructed.HostState, reconstructed.Memory} {
+		if value, ok := m[expr]; ok {
+			v := s.vars.variable(expr, value)
+			return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+		}
+	}
+	for _, v := range append(s.locals(step), s.stepVariables(s.dbg.state(step))...) {
+		if v.Name == expr {
+			return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not available at step %d", expr, step)
+}
+
+func (s *Session) exceptionInfo() (any, error) {
+	if s.dbg == nil {
+		return nil, fmt.Errorf("no trace loaded")
+	}
+	state := s.dbg.state(s.current)
+	if state.Error == "" {
+		return nil, fmt.Errorf("no exception at step %d", s.current)
+	}
+
+	info := ExceptionInfoBody{ExceptionID: string(trace.TrapUnknown), Description: state.Error, BreakMode: "always"}
+	detector := s.dbg.detector
+	if detector == nil {
+		detector, _ = trace.NewTrapDetector(nil)
+	}
+	if trap := detector.DetectTrap(state); trap != nil {
+		info.ExceptionID = string(trap.Type)
+	}
+	return info, nil
+}
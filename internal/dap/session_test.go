@@ -0,0 +1,334 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotandev/hintents/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTrace is a transfer that calls into a token contract and then traps:
+//
+//	0 transfer (contract A)
+//	1 host call
+//	2 balance  (contract B, nested)
+//	3 transfer (back in A)
+//	4 trap
+func testTrace() *trace.ExecutionTrace {
+	t := trace.NewExecutionTrace("abc123", 2)
+	t.AddState(trace.ExecutionState{Operation: "contract_call", ContractID: "CA", Function: "transfer", Arguments: []interface{}{"alice", float64(10)}})
+	t.AddState(trace.ExecutionState{Operation: "host_fn", HostState: map[string]interface{}{"balance:alice": float64(5)}})
+	t.AddState(trace.ExecutionState{Operation: "contract_call", ContractID: "CB", Function: "balance"})
+	t.AddState(trace.ExecutionState{Operation: "contract_call", ContractID: "CA", Function: "transfer", RawReturnValue: "AAAAAQ==", HostState: map[string]interface{}{"balance:alice": float64(-5)}})
+	t.AddState(trace.ExecutionState{Operation: "trap", Error: "HostError: panic: insufficient balance"})
+	return t
+}
+
+type testClient struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	seq int
+}
+
+func startSession(t *testing.T) *testClient {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+
+	load := func(ctx context.Context, args LaunchArguments) (*trace.ExecutionTrace, []byte, error) {
+		return testTrace(), nil, nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- NewSession(reqR, respW, load).Serve(context.Background())
+		respW.Close()
+	}()
+	t.Cleanup(func() {
+		reqW.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("session did not exit")
+		}
+	})
+
+	return &testClient{t: t, w: reqW, r: bufio.NewReader(respR)}
+}
+
+func (c *testClient) send(command string, args any) {
+	c.t.Helper()
+	c.seq++
+	req := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	require.NoError(c.t, WriteMessage(c.w, req))
+}
+
+func (c *testClient) read() map[string]json.RawMessage {
+	c.t.Helper()
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	require.NoError(c.t, err)
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+	body := make([]byte, n)
+	_, err = io.ReadFull(c.r, body)
+	require.NoError(c.t, err)
+
+	var msg map[string]json.RawMessage
+	require.NoError(c.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+// expectResponse reads messages until the response to command and decodes
+// its body into out.
+func (c *testClient) expectResponse(command string, out any) {
+	c.t.Helper()
+	for {
+		msg := c.read()
+		if string(msg["type"]) != `"response"` {
+			continue
+		}
+		require.Equal(c.t, `"`+command+`"`, string(msg["command"]))
+		require.Equal(c.t, "true", string(msg["success"]), "request %s failed: %s", command, msg["message"])
+		if out != nil {
+			require.NoError(c.t, json.Unmarshal(msg["body"], out))
+		}
+		return
+	}
+}
+
+func (c *testClient) expectEvent(event string, out any) {
+	c.t.Helper()
+	for {
+		msg := c.read()
+		if string(msg["type"]) != `"event"` || string(msg["event"]) != `"`+event+`"` {
+			continue
+		}
+		if out != nil {
+			require.NoError(c.t, json.Unmarshal(msg["body"], out))
+		}
+		return
+	}
+}
+
+func (c *testClient) call(command string, args any, out any) {
+	c.t.Helper()
+	c.send(command, args)
+	c.expectResponse(command, out)
+}
+
+// stopped issues a motion request and returns the resulting stop and top
+// frame.
+func (c *testClient) stopped(command string) (StoppedEventBody, StackFrame) {
+	c.t.Helper()
+	c.call(command, map[string]any{"threadId": threadID}, nil)
+	var ev StoppedEventBody
+	c.expectEvent("stopped", &ev)
+	return ev, c.topFrame()
+}
+
+func (c *testClient) topFrame() StackFrame {
+	c.t.Helper()
+	var st struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.call("stackTrace", map[string]any{"threadId": threadID}, &st)
+	require.NotEmpty(c.t, st.StackFrames)
+	return st.StackFrames[0]
+}
+
+func (c *testClient) launch(stopOnEntry bool) (StoppedEventBody, StackFrame) {
+	c.t.Helper()
+	c.call("launch", map[string]any{"trace": "trace.json", "stopOnEntry": stopOnEntry}, nil)
+	c.call("configurationDone", nil, nil)
+	var ev StoppedEventBody
+	c.expectEvent("stopped", &ev)
+	return ev, c.topFrame()
+}
+
+func (c *testClient) initialize() Capabilities {
+	c.t.Helper()
+	var caps Capabilities
+	c.call("initialize", map[string]any{"adapterID": "erst"}, &caps)
+	c.expectEvent("initialized", nil)
+	return caps
+}
+
+func TestSession_StopOnEntry(t *testing.T) {
+	c := startSession(t)
+	caps := c.initialize()
+	assert.True(t, caps.SupportsStepBack)
+	assert.True(t, caps.SupportsFunctionBreakpoints)
+
+	ev, frame := c.launch(true)
+	assert.Equal(t, "entry", ev.Reason)
+	assert.Equal(t, 1, frame.Line, "without DWARF, steps map to lines of the trace document")
+	assert.Equal(t, traceSourceRef, frame.Source.SourceReference)
+	assert.Contains(t, frame.Name, "transfer")
+
+	var src struct {
+		Content string `json:"content"`
+	}
+	c.call("source", map[string]any{"sourceReference": traceSourceRef}, &src)
+	assert.Contains(t, src.Content, "insufficient balance")
+}
+
+func TestSession_FunctionBreakpointAndReverseContinue(t *testing.T) {
+	c := startSession(t)
+	c.initialize()
+
+	// Set before launch; verified once the trace is loaded.
+	c.call("setFunctionBreakpoints", map[string]any{"breakpoints": []map[string]string{{"name": "balance"}}}, nil)
+
+	ev, frame := c.launch(false)
+	assert.Equal(t, "breakpoint", ev.Reason)
+	assert.Equal(t, 3, frame.Line)
+	assert.NotEmpty(t, ev.HitBreakpointIDs)
+
+	ev, frame = c.stopped("continue")
+	assert.Equal(t, "exception", ev.Reason)
+	assert.Equal(t, 5, frame.Line)
+
+	var info ExceptionInfoBody
+	c.call("exceptionInfo", map[string]any{"threadId": threadID}, &info)
+	assert.Equal(t, string(trace.TrapPanic), info.ExceptionID)
+
+	ev, frame = c.stopped("reverseContinue")
+	assert.Equal(t, "breakpoint", ev.Reason)
+	assert.Equal(t, 3, frame.Line)
+
+	ev, frame = c.stopped("reverseContinue")
+	assert.Equal(t, "entry", ev.Reason)
+	assert.Equal(t, 1, frame.Line)
+}
+
+func TestSession_LineBreakpoints(t *testing.T) {
+	c := startSession(t)
+	c.initialize()
+	c.launch(true)
+
+	var resp struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.call("setBreakpoints", map[string]any{
+		"source":      map[string]any{"sourceReference": traceSourceRef},
+		"breakpoints": []map[string]int{{"line": 4}, {"line": 99}},
+	}, &resp)
+	require.Len(t, resp.Breakpoints, 2)
+	assert.True(t, resp.Breakpoints[0].Verified)
+	assert.False(t, resp.Breakpoints[1].Verified)
+
+	ev, frame := c.stopped("continue")
+	assert.Equal(t, "breakpoint", ev.Reason)
+	assert.Equal(t, 4, frame.Line)
+}
+
+func TestSession_Stepping(t *testing.T) {
+	c := startSession(t)
+	c.initialize()
+	c.launch(true)
+
+	_, frame := c.stopped("stepIn")
+	assert.Equal(t, 2, frame.Line)
+
+	// Step over skips the nested call into contract B.
+	_, frame = c.stopped("next")
+	assert.Equal(t, 4, frame.Line)
+
+	_, frame = c.stopped("stepBack")
+	assert.Equal(t, 3, frame.Line)
+
+	var st struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.call("stackTrace", map[string]any{"threadId": threadID}, &st)
+	require.Len(t, st.StackFrames, 2)
+	assert.Contains(t, st.StackFrames[0].Name, "balance")
+	assert.Contains(t, st.StackFrames[1].Name, "transfer")
+
+	_, frame = c.stopped("stepOut")
+	assert.Equal(t, 4, frame.Line)
+
+	var targets struct {
+		Targets []GotoTarget `json:"targets"`
+	}
+	c.call("gotoTargets", map[string]any{"source": map[string]any{"sourceReference": traceSourceRef}, "line": 1}, &targets)
+	require.Len(t, targets.Targets, 1)
+	c.call("goto", map[string]any{"threadId": threadID, "targetId": targets.Targets[0].ID}, nil)
+	var ev StoppedEventBody
+	c.expectEvent("stopped", &ev)
+	assert.Equal(t, "goto", ev.Reason)
+	assert.Equal(t, 1, c.topFrame().Line)
+}
+
+func TestSession_Variables(t *testing.T) {
+	c := startSession(t)
+	c.initialize()
+	c.call("setBreakpoints", map[string]any{
+		"source":      map[string]any{"sourceReference": traceSourceRef},
+		"breakpoints": []map[string]int{{"line": 4}},
+	}, nil)
+	c.launch(false)
+
+	var scopes struct {
+		Scopes []Scope `json:"scopes"`
+	}
+	c.call("scopes", map[string]any{"frameId": 1}, &scopes)
+	byName := make(map[string]Scope)
+	for _, s := range scopes.Scopes {
+		byName[s.Name] = s
+	}
+	require.Contains(t, byName, "Host State")
+	require.Contains(t, byName, "Locals")
+
+	var vars struct {
+		Variables []Variable `json:"variables"`
+	}
+	c.call("variables", map[string]any{"variablesReference": byName["Host State"].VariablesReference}, &vars)
+	require.Len(t, vars.Variables, 1)
+	assert.Equal(t, "balance:alice", vars.Variables[0].Name)
+	assert.Equal(t, "-5", vars.Variables[0].Value)
+
+	c.call("variables", map[string]any{"variablesReference": byName["Locals"].VariablesReference}, &vars)
+	require.Len(t, vars.Variables, 1)
+	assert.Equal(t, "return", vars.Variables[0].Name)
+
+	var eval struct {
+		Result string `json:"result"`
+	}
+	c.call("evaluate", map[string]any{"expression": "balance:alice", "frameId": 1, "context": "hover"}, &eval)
+	assert.Equal(t, "-5", eval.Result)
+}
+
+func TestSession_EntryArguments(t *testing.T) {
+	c := startSession(t)
+	c.initialize()
+	c.launch(true)
+
+	var eval struct {
+		Result string `json:"result"`
+	}
+	c.call("evaluate", map[string]any{"expression": "arg0", "frameId": 1}, &eval)
+	assert.Equal(t, `"alice"`, eval.Result)
+	c.call("evaluate", map[string]any{"expression": "arg1", "frameId": 1}, &eval)
+	assert.Equal(t, "10", eval.Result)
+}
+
+func TestReadRequest_InvalidHeader(t *testing.T) {
+	_, err := ReadRequest(bufio.NewReader(strings.NewReader("Content-Length: nope\r\n\r\n{}")))
+	assert.Error(t, err)
+}
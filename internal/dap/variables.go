@@ -0,0 +1,105 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// variableStore hands out variablesReference handles for scopes and nested
+// values. Handles are only valid until the next stop, matching the DAP
+// lifetime rules for variable references.
+type variableStore struct {
+	next    int
+	entries map[int]func() []Variable
+}
+
+func newVariableStore() *variableStore {
+	return &variableStore{next: 1, entries: make(map[int]func() []Variable)}
+}
+
+func (s *variableStore) reset() {
+	s.next = 1
+	s.entries = make(map[int]func() []Variable)
+}
+
+func (s *variableStore) add(fn func() []Variable) int {
+	ref := s.next
+	s.next++
+	s.entries[ref] = fn
+	return ref
+}
+
+func (s *variableStore) get(ref int) ([]Variable, bool) {
+	fn, ok := s.entries[ref]
+	if !ok {
+		return nil, false
+	}
+	return fn(), true
+}
+
+// variable converts a decoded trace value to a DAP variable, registering a
+// child reference for maps and slices.
+func (s *variableStore) variable(name string, value interface{}) Variable {
+	v := Variable{Name: name, Value: formatValue(value)}
+	if value != nil {
+		v.Type = reflect.TypeOf(value).String()
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if len(val) > 0 {
+			v.VariablesReference = s.add(func() []Variable { return s.mapVariables(val) })
+		}
+	case []interface{}:
+		if len(val) > 0 {
+			v.VariablesReference = s.add(func() []Variable {
+				vars := make([]Variable, len(val))
+				for i, item := range val {
+					vars[i] = s.variable(strconv.Itoa(i), item)
+				}
+				return vars
+			})
+		}
+	}
+	return v
+}
+
+// mapVariables lists m sorted by key so the order is stable between stops.
+func (s *variableStore) mapVariables(m map[string]interface{}) []Variable {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	vars := make([]Variable, 0, len(keys))
+	for _, k := range keys {
+		vars = append(vars, s.variable(k, m[k]))
+	}
+	return vars
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<unavailable>"
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		return fmt.Sprintf("map[%d]", len(v))
+	case []interface{}:
+		return fmt.Sprintf("[%d]", len(v))
+	case fmt.Stringer:
+		return v.String()
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package decoder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/strkey"
+)
+
+// ContractError is a variant of a contract's error enum, as declared by an
+// ScSpecUdtErrorEnumV0 entry of its spec.
+type ContractError struct {
+	Code uint32 `json:"code"`
+	Enum string `json:"enum,omitempty"`
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// String returns the qualified variant name, such as "TokenError::Paused".
+func (e ContractError) String() string {
+	if e.Enum == "" {
+		return e.Name
+	}
+	return e.Enum + "::" + e.Name
+}
+
+// Describe returns the variant name, its code and its doc string.
+func (e ContractError) Describe() string {
+	s := fmt.Sprintf("%s (#%d)", e, e.Code)
+	if e.Doc != "" {
+		s += ": " + e.Doc
+	}
+	return s
+}
+
+// ContractErrorTable maps the error codes of one contract to their variants.
+type ContractErrorTable map[uint32]ContractError
+
+// ContractErrorsFromSpec builds the error table of a decoded contract spec.
+// When several error enums declare the same code the first one wins.
+func ContractErrorsFromSpec(spec *abi.ContractSpec) ContractErrorTable {
+	table := make(ContractErrorTable)
+	for _, enum := range spec.ErrorEnums {
+		for _, c := range enum.Cases {
+			code := uint32(c.Value)
+			if _, ok := table[code]; ok {
+				continue
+			}
+			table[code] = ContractError{
+				Code: code,
+				Enum: enum.Name,
+				Name: c.Name,
+				Doc:  strings.TrimSpace(c.Doc),
+			}
+		}
+	}
+	return table
+}
+
+// ContractErrorsFromWasm builds the error table from the contractspecv0
+// section of a contract's WASM. A contract without a spec has an empty table.
+func ContractErrorsFromWasm(wasm []byte) (ContractErrorTable, error) {
+	spec, err := SpecFromWasm(wasm)
+	if err != nil {
+		return nil, err
+	}
+	return ContractErrorsFromSpec(spec), nil
+}
+
+var contractErrorRe = regexp.MustCompile(`Error\(\s*Contract\s*,\s*#(\d+)\s*\)`)
+
+// ContractErrorCodes returns the contract error codes that appear in text in
+// the host's "Error(Contract, #N)" form.
+func ContractErrorCodes(text string) []uint32 {
+	var codes []uint32
+	for _, m := range contractErrorRe.FindAllStringSubmatch(text, -1) {
+		if code, err := strconv.ParseUint(m[1], 10, 32); err == nil {
+			codes = append(codes, uint32(code))
+		}
+	}
+	return codes
+}
+
+// ContractErrorResolver resolves contract error codes to the variants
+// declared in each contract's spec. Specs come from a SpecRegistry, so each
+// is decoded once per code hash. It is safe for concurrent use.
+type ContractErrorResolver struct {
+	specs  *SpecRegistry
+	mu     sync.Mutex
+	tables map[*abi.ContractSpec]ContractErrorTable
+}
+
+// NewContractErrorResolver creates a resolver with an empty spec registry.
+func NewContractErrorResolver() *ContractErrorResolver {
+	return NewContractErrorResolverWithSpecs(NewSpecRegistry())
+}
+
+// NewContractErrorResolverWithSpecs creates a resolver that shares specs
+// with other users of the registry.
+func NewContractErrorResolverWithSpecs(specs *SpecRegistry) *ContractErrorResolver {
+	return &ContractErrorResolver{
+		specs:  specs,
+		tables: make(map[*abi.ContractSpec]ContractErrorTable),
+	}
+}
+
+// Specs returns the registry the resolver reads contract specs from.
+func (r *ContractErrorResolver) Specs() *SpecRegistry {
+	return r.specs
+}
+
+// RegisterWasm decodes the error table of wasm, unless the spec of the same
+// code is cached, and associates it with contractID when one is given.
+func (r *ContractErrorResolver) RegisterWasm(contractID string, wasm []byte) (ContractErrorTable, error) {
+	spec, err := r.specs.RegisterWasm(contractID, wasm)
+	if err != nil {
+		return nil, err
+	}
+	return r.table(spec), nil
+}
+
+func (r *ContractErrorResolver) table(spec *abi.ContractSpec) ContractErrorTable {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	table, ok := r.tables[spec]
+	if !ok {
+		table = ContractErrorsFromSpec(spec)
+		r.tables[spec] = table
+	}
+	return table
+}
+
+// RegisterLedgerEntries registers the contract instances and contract code
+// found in ledger entries; see SpecRegistry.RegisterLedgerEntries.
+func (r *ContractErrorResolver) RegisterLedgerEntries(entries map[string]string) {
+	r.specs.RegisterLedgerEntries(entries)
+}
+
+// Known reports whether the error table of contractID has been registered.
+func (r *ContractErrorResolver) Known(contractID string) bool {
+	return r.specs.Known(contractID)
+}
+
+// Resolve returns the variant for code raised by contractID. When
+// contractID is empty or unknown, the code is resolved only if every
+// registered table that declares it agrees on the variant.
+func (r *ContractErrorResolver) Resolve(contractID string, code uint32) (ContractError, bool) {
+	if spec, ok := r.specs.Spec(contractID); ok {
+		e, found := r.table(spec)[code]
+		return e, found
+	}
+
+	var match *ContractError
+	for _, spec := range r.specs.all() {
+		e, ok := r.table(spec)[code]
+		if !ok {
+			continue
+		}
+		if match != nil && *match != e {
+			return ContractError{}, false
+		}
+		match = &e
+	}
+	if match == nil {
+		return ContractError{}, false
+	}
+	return *match, true
+}
+
+// ResolveText returns the variant of the first contract error code in text
+// that resolves.
+func (r *ContractErrorResolver) ResolveText(contractID, text string) (ContractError, bool) {
+	for _, code := range ContractErrorCodes(text) {
+		if e, ok := r.Resolve(contractID, code); ok {
+			return e, true
+		}
+	}
+	return ContractError{}, false
+}
+
+// Annotate appends the variant name to every resolvable contract error code
+// in text, turning "Error(Contract, #7)" into
+// "Error(Contract, #7) [TokenError::Paused]". Codes that are already
+// annotated are left alone.
+func (r *ContractErrorResolver) Annotate(contractID, text string) string {
+	matches := contractErrorRe.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		end := m[1]
+		b.WriteString(text[last:end])
+		last = end
+		if strings.HasPrefix(text[end:], " [") {
+			continue
+		}
+		code, err := strconv.ParseUint(text[m[2]:m[3]], 10, 32)
+		if err != nil {
+			continue
+		}
+		if e, ok := r.Resolve(contractID, uint32(code)); ok {
+			b.WriteString(" [" + e.String() + "]")
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+var contractHexRe = regexp.MustCompile(`^(?:\w+\()*([0-9a-fA-F]{64})\)*$`)
+
+// NormalizeContractID converts a contract ID in 32-byte hex form, bare or
+// wrapped as erst-sim prints it ("Hash(...)"), to its strkey form so that
+// every spelling shares a cache entry. Other IDs are returned unchanged.
+func NormalizeContractID(id string) string {
+	id = strings.TrimSpace(id)
+	m := contractHexRe.FindStringSubmatch(id)
+	if m == nil {
+		return id
+	}
+	raw, err := hex.DecodeString(m[1])
+	if err != nil {
+		return id
+	}
+	if s, err := strkey.Encode(strkey.VersionByteContract, raw); err == nil {
+		return s
+	}
+	return id
+}
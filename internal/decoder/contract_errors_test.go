@@ -0,0 +1,199 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package decoder
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// specWasm builds a minimal WASM module whose contractspecv0 section declares
+// the given error enums.
+func specWasm(t *testing.T, enums ...xdr.ScSpecUdtErrorEnumV0) []byte {
+	t.Helper()
+	entries := make([]xdr.ScSpecEntry, len(enums))
+	for i := range enums {
+		entries[i] = xdr.ScSpecEntry{
+			Kind:           xdr.ScSpecEntryKindScSpecEntryUdtErrorEnumV0,
+			UdtErrorEnumV0: &enums[i],
+		}
+	}
+	return specEntriesWasm(t, entries...)
+}
+
+// specEntriesWasm builds a minimal WASM module whose contractspecv0 section
+// holds the given entries.
+func specEntriesWasm(t *testing.T, entries ...xdr.ScSpecEntry) []byte {
+	t.Helper()
+	var payload []byte
+	for _, entry := range entries {
+		b, err := entry.MarshalBinary()
+		require.NoError(t, err)
+		payload = append(payload, b...)
+	}
+
+	name := "contractspecv0"
+	content := appendULEB128(nil, uint32(len(name)))
+	content = append(content, name...)
+	content = append(content, payload...)
+
+	wasm := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	wasm = append(wasm, 0x00)
+	wasm = appendULEB128(wasm, uint32(len(content)))
+	return append(wasm, content...)
+}
+
+func appendULEB128(buf []byte, v uint32) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if v == 0 {
+			return buf
+		}
+	}
+}
+
+func tokenErrors() xdr.ScSpecUdtErrorEnumV0 {
+	return xdr.ScSpecUdtErrorEnumV0{
+		Name: "TokenError",
+		Cases: []xdr.ScSpecUdtErrorEnumCaseV0{
+			{Name: "InsufficientBalance", Value: 1, Doc: " The sender cannot cover the amount. "},
+			{Name: "Paused", Value: 7},
+		},
+	}
+}
+
+func TestContractErrorsFromWasm(t *testing.T) {
+	table, err := ContractErrorsFromWasm(specWasm(t, tokenErrors(), xdr.ScSpecUdtErrorEnumV0{
+		Name:  "OtherError",
+		Cases: []xdr.ScSpecUdtErrorEnumCaseV0{{Name: "Shadowed", Value: 7}, {Name: "Extra", Value: 9}},
+	}))
+	require.NoError(t, err)
+	require.Len(t, table, 3)
+
+	e := table[1]
+	assert.Equal(t, "TokenError::InsufficientBalance", e.String())
+	assert.Equal(t, "TokenError::InsufficientBalance (#1): The sender cannot cover the amount.", e.Describe())
+	assert.Equal(t, "Paused", table[7].Name, "the first enum declaring a code wins")
+	assert.Equal(t, "OtherError::Extra (#9)", table[9].Describe())
+
+	table, err = ContractErrorsFromWasm([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Empty(t, table, "a module without a spec has no errors")
+
+	_, err = ContractErrorsFromWasm([]byte("not wasm"))
+	assert.Error(t, err)
+}
+
+func TestContractErrorCodes(t *testing.T) {
+	assert.Equal(t, []uint32{7, 12}, ContractErrorCodes("HostError: Error(Contract, #7) then Error( Contract,#12 )"))
+	assert.Empty(t, ContractErrorCodes("Error(Auth, InvalidAction)"))
+}
+
+func TestContractErrorResolver_Annotate(t *testing.T) {
+	r := NewContractErrorResolver()
+	_, err := r.RegisterWasm("CTOKEN", specWasm(t, tokenErrors()))
+	require.NoError(t, err)
+	assert.True(t, r.Known("CTOKEN"))
+	assert.False(t, r.Known("COTHER"))
+
+	text := "HostError: Error(Contract, #7) and Error(Contract, #3)"
+	annotated := r.Annotate("CTOKEN", text)
+	assert.Equal(t, "HostError: Error(Contract, #7) [TokenError::Paused] and Error(Contract, #3)", annotated)
+	assert.Equal(t, annotated, r.Annotate("CTOKEN", annotated), "annotation is idempotent")
+
+	e, ok := r.ResolveText("CTOKEN", text)
+	require.True(t, ok)
+	assert.Equal(t, "Paused", e.Name)
+}
+
+func TestContractErrorResolver_UnknownContract(t *testing.T) {
+	r := NewContractErrorResolver()
+	_, err := r.RegisterWasm("", specWasm(t, tokenErrors()))
+	require.NoError(t, err)
+
+	e, ok := r.Resolve("", 7)
+	require.True(t, ok, "a single table resolves codes of unknown contracts")
+	assert.Equal(t, "TokenError::Paused", e.String())
+
+	_, err = r.RegisterWasm("", specWasm(t, xdr.ScSpecUdtErrorEnumV0{
+		Name:  "VaultError",
+		Cases: []xdr.ScSpecUdtErrorEnumCaseV0{{Name: "Locked", Value: 7}},
+	}))
+	require.NoError(t, err)
+	_, ok = r.Resolve("", 7)
+	assert.False(t, ok, "conflicting tables leave the code unresolved")
+
+	e, ok = r.Resolve("", 1)
+	require.True(t, ok)
+	assert.Equal(t, "InsufficientBalance", e.Name)
+}
+
+func TestContractErrorResolver_RegisterLedgerEntries(t *testing.T) {
+	wasm := specWasm(t, tokenErrors())
+	hash := xdr.Hash(sha256.Sum256(wasm))
+	contractID := xdr.ContractId{1, 2, 3}
+
+	code := xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.ContractCodeEntry{Hash: hash, Code: wasm},
+	}}
+	instance := xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.ContractDataEntry{
+			Contract: xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+			Key:      xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Val: xdr.ScVal{
+				Type: xdr.ScValTypeScvContractInstance,
+				Instance: &xdr.ScContractInstance{Executable: xdr.ContractExecutable{
+					Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+					WasmHash: &hash,
+				}},
+			},
+		},
+	}}
+
+	encode := func(entry xdr.LedgerEntry) string {
+		b, err := entry.MarshalBinary()
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	entries := map[string]string{
+		"code":     encode(code),
+		"instance": encode(instance),
+		"junk":     "not base64",
+	}
+
+	r := NewContractErrorResolver()
+	r.RegisterLedgerEntries(entries)
+
+	id, err := strkey.Encode(strkey.VersionByteContract, contractID[:])
+	require.NoError(t, err)
+	assert.True(t, r.Known(id))
+
+	// erst-sim reports contract IDs in hex; both spellings share the entry.
+	hexID := "Hash(" + hex.EncodeToString(contractID[:]) + ")"
+	assert.Equal(t, id, NormalizeContractID(hexID))
+	assert.True(t, strings.HasSuffix(r.Annotate(hexID, "Error(Contract, #1)"), "[TokenError::InsufficientBalance]"))
+}
+
+func TestNormalizeContractID(t *testing.T) {
+	assert.Equal(t, "CTOKEN", NormalizeContractID(" CTOKEN "))
+	assert.Equal(t, "Hash(xyz)", NormalizeContractID("Hash(xyz)"))
+	zero := strings.Repeat("0", 64)
+	assert.Equal(t, NormalizeContractID(zero), NormalizeContractID("Hash("+zero+")"))
+	assert.True(t, strings.HasPrefix(NormalizeContractID(zero), "C"))
+}
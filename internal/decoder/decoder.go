@@ -31,6 +31,12 @@ type DecodedEvent struct {
 
 // DecodeEvents builds a call hierarchy from a list of base64-encoded XDR DiagnosticEvents
 func DecodeEvents(eventsXdr []string) (*CallNode, error) {
+	return DecodeEventsWithSpecs(eventsXdr, nil)
+}
+
+// DecodeEventsWithSpecs is DecodeEvents with event values rendered using
+// the contract specs of specs, which may be nil.
+func DecodeEventsWithSpecs(eventsXdr []string, specs *SpecRegistry) (*CallNode, error) {
 	root := &CallNode{
 		ContractID: "ROOT",
 		Function:   "TOP_LEVEL",
@@ -47,7 +53,7 @@ func DecodeEvents(eventsXdr []string) (*CallNode, error) {
 			return nil, fmt.Errorf("failed to unmarshal XDR event: %w", err)
 		}
 
-		decoded := parseEvent(diag)
+		decoded := parseEvent(diag, specs)
 
 		// Check for call/return markers in topics
 		// Convention: System events with topics ["fn_call", func_name, ...]
@@ -104,27 +110,13 @@ func DecodeEvents(eventsXdr []string) (*CallNode, error) {
 	return root, nil
 }
 
-func parseEvent(diag xdr.DiagnosticEvent) DecodedEvent {
+func parseEvent(diag xdr.DiagnosticEvent, specs *SpecRegistry) DecodedEvent {
 	var contractID string
 	if diag.Event.ContractId != nil {
 		contractID = hex.EncodeToString(diag.Event.ContractId[:])
 	}
 
-	// Pre-allocate topics slice capacity to reduce re-allocations
-	topics := make([]string, 0, len(diag.Event.Body.V0.Topics))
-	for _, topic := range diag.Event.Body.V0.Topics {
-		// Attempt to convert to string if symbol, otherwise hex/debug
-		if topic.Type == xdr.ScValTypeScvSymbol {
-			topics = append(topics, string(*topic.Sym))
-		} else {
-			// Fallback for other types
-			topics = append(topics, fmt.Sprintf("%v", topic.Type))
-		}
-	}
-
-	// Simple data stringification
-	data := fmt.Sprintf("%v", diag.Event.Body.V0.Data.Type)
-
+	topics, data := specs.FormatEvent(contractID, diag.Event.Body.V0.Topics, diag.Event.Body.V0.Data)
 	return DecodedEvent{
 		ContractID: contractID,
 		Topics:     topics,
@@ -141,6 +133,10 @@ func isFunctionReturn(e DecodedEvent) bool {
 }
 
 func extractFunctionName(e DecodedEvent) string {
+	// The host's fn_call topics are the marker, the callee and the function.
+	if isFunctionCall(e) && len(e.Topics) > 2 {
+		return e.Topics[2]
+	}
 	if len(e.Topics) > 1 {
 		return e.Topics[1]
 	}
@@ -0,0 +1,329 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package decoder
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// SpecRegistry caches decoded contract specs by WASM code hash and records
+// which code each contract runs, so that contracts sharing code and
+// repeated lookups decode a spec once. It also records the decimals of
+// token contracts whose metadata it has seen. It is safe for concurrent use.
+type SpecRegistry struct {
+	mu        sync.Mutex
+	specs     map[string]*abi.ContractSpec // code hash -> spec
+	decodeErr map[string]error             // code hash -> spec decoding error
+	codeHash  map[string]string            // contract ID -> code hash
+	decimals  map[string]uint32            // contract ID -> token decimals
+}
+
+// NewSpecRegistry creates an empty registry.
+func NewSpecRegistry() *SpecRegistry {
+	return &SpecRegistry{
+		specs:     make(map[string]*abi.ContractSpec),
+		decodeErr: make(map[string]error),
+		codeHash:  make(map[string]string),
+		decimals:  make(map[string]uint32),
+	}
+}
+
+// SpecFromWasm decodes the contractspecv0 section of a contract's WASM. A
+// contract without a spec has an empty one.
+func SpecFromWasm(wasm []byte) (*abi.ContractSpec, error) {
+	section, err := abi.ExtractCustomSection(wasm, "contractspecv0")
+	if err != nil {
+		return nil, err
+	}
+	if section == nil {
+		return &abi.ContractSpec{}, nil
+	}
+	return abi.DecodeContractSpec(section)
+}
+
+// RegisterWasm decodes the spec of wasm, unless a spec for the same code
+// hash is cached, and associates it with contractID when one is given.
+func (r *SpecRegistry) RegisterWasm(contractID string, wasm []byte) (*abi.ContractSpec, error) {
+	sum := sha256.Sum256(wasm)
+	hash := hex.EncodeToString(sum[:])
+	spec, err := r.spec(hash, wasm)
+	if err != nil {
+		return nil, err
+	}
+	if contractID != "" {
+		r.mu.Lock()
+		r.codeHash[NormalizeContractID(contractID)] = hash
+		r.mu.Unlock()
+	}
+	return spec, nil
+}
+
+func (r *SpecRegistry) spec(hash string, wasm []byte) (*abi.ContractSpec, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if spec, ok := r.specs[hash]; ok {
+		return spec, nil
+	}
+	if err, ok := r.decodeErr[hash]; ok {
+		return nil, err
+	}
+	spec, err := SpecFromWasm(wasm)
+	if err != nil {
+		r.decodeErr[hash] = err
+		return nil, err
+	}
+	r.specs[hash] = spec
+	return spec, nil
+}
+
+// RegisterLedgerEntries registers the contract instances and contract code
+// found in a map of base64 ledger keys to base64 ledger entries, such as the
+// entries of a simulation request or those returned by
+// rpc.FetchContractBytecode. The decimals of token instances whose METADATA
+// declares them are recorded too. Other entries, and code whose spec cannot
+// be decoded, are skipped.
+func (r *SpecRegistry) RegisterLedgerEntries(entries map[string]string) {
+	for _, encoded := range entries {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		var entry xdr.LedgerEntry
+		if err := entry.UnmarshalBinary(raw); err != nil {
+			continue
+		}
+
+		switch entry.Data.Type {
+		case xdr.LedgerEntryTypeContractCode:
+			code := entry.Data.ContractCode
+			_, _ = r.spec(hex.EncodeToString(code.Hash[:]), code.Code)
+
+		case xdr.LedgerEntryTypeContractData:
+			data := entry.Data.ContractData
+			if data.Val.Type != xdr.ScValTypeScvContractInstance || data.Val.Instance == nil {
+				continue
+			}
+			if data.Contract.Type != xdr.ScAddressTypeScAddressTypeContract || data.Contract.ContractId == nil {
+				continue
+			}
+			id, err := strkey.Encode(strkey.VersionByteContract, data.Contract.ContractId[:])
+			if err != nil {
+				continue
+			}
+
+			instance := data.Val.Instance
+			r.mu.Lock()
+			if exec := instance.Executable; exec.Type == xdr.ContractExecutableTypeContractExecutableWasm && exec.WasmHash != nil {
+				r.codeHash[id] = hex.EncodeToString(exec.WasmHash[:])
+			}
+			if decimals, ok := tokenDecimals(instance); ok {
+				r.decimals[id] = decimals
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// tokenDecimals reads the decimals a token keeps in the METADATA entry of
+// its instance storage, as the Stellar Asset Contract and the SEP-41
+// reference token do.
+func tokenDecimals(instance *xdr.ScContractInstance) (uint32, bool) {
+	if instance.Storage == nil {
+		return 0, false
+	}
+	for _, e := range *instance.Storage {
+		if sym, ok := e.Key.GetSym(); !ok || sym != "METADATA" {
+			continue
+		}
+		metadata, ok := e.Val.GetMap()
+		if !ok || metadata == nil {
+			return 0, false
+		}
+		for _, m := range *metadata {
+			if sym, ok := m.Key.GetSym(); ok && sym == "decimal" {
+				if d, ok := m.Val.GetU32(); ok {
+					return uint32(d), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// SetDecimals records the decimals of a token contract.
+func (r *SpecRegistry) SetDecimals(contractID string, decimals uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decimals[NormalizeContractID(contractID)] = decimals
+}
+
+// Spec returns the spec of contractID.
+func (r *SpecRegistry) Spec(contractID string) (*abi.ContractSpec, bool) {
+	if r == nil || contractID == "" {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hash, ok := r.codeHash[NormalizeContractID(contractID)]
+	if !ok {
+		return nil, false
+	}
+	spec, ok := r.specs[hash]
+	return spec, ok
+}
+
+// Known reports whether the spec of contractID has been registered.
+func (r *SpecRegistry) Known(contractID string) bool {
+	_, ok := r.Spec(contractID)
+	return ok
+}
+
+// all returns every decoded spec.
+func (r *SpecRegistry) all() []*abi.ContractSpec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	specs := make([]*abi.ContractSpec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// Formatter returns a value formatter for contractID, typed by its spec and
+// token decimals when they are known. A nil registry formats generically.
+func (r *SpecRegistry) Formatter(contractID string) *abi.ValueFormatter {
+	f := &abi.ValueFormatter{}
+	if r == nil {
+		return f
+	}
+	f.Spec, _ = r.Spec(contractID)
+	r.mu.Lock()
+	f.Decimals = r.decimals[NormalizeContractID(contractID)]
+	r.mu.Unlock()
+	return f
+}
+
+// FormatEvent renders the topics and data of an event emitted by
+// contractID. The host's fn_call and fn_return diagnostic events are typed
+// by the called function: fn_call data becomes a call such as
+// "transfer(from: G..., to: G..., amount: 1.5)" typed by the callee's inputs,
+// and fn_return data is typed by the function's output.
+func (r *SpecRegistry) FormatEvent(contractID string, topics []xdr.ScVal, data xdr.ScVal) ([]string, string) {
+	if call, ok := r.ParseCall(topics, data); ok {
+		args := r.Formatter(call.Callee).FormatArgs(call.Function, call.Args)
+		return []string{"fn_call", call.Callee, call.Function},
+			call.Function + "(" + strings.Join(args, ", ") + ")"
+	}
+	if len(topics) == 2 {
+		if kind, _ := topics[0].GetSym(); kind == "fn_return" {
+			if fn, ok := topics[1].GetSym(); ok {
+				return []string{"fn_return", string(fn)}, r.Formatter(contractID).FormatReturn(string(fn), data)
+			}
+		}
+	}
+	return r.Formatter(contractID).FormatEvent(topics, data)
+}
+
+// Call is a contract call recorded by the host's fn_call diagnostic event.
+type Call struct {
+	Callee   string // strkey of the called contract
+	Function string
+	Args     []xdr.ScVal
+}
+
+// ParseCall returns the call recorded by a fn_call event, whose topics are
+// the marker, the callee's ID and the function name. The host passes a
+// single argument as the data and several as a vector; the callee's spec,
+// when known, tells the two apart.
+func (r *SpecRegistry) ParseCall(topics []xdr.ScVal, data xdr.ScVal) (Call, bool) {
+	if len(topics) < 3 {
+		return Call{}, false
+	}
+	if kind, _ := topics[0].GetSym(); kind != "fn_call" {
+		return Call{}, false
+	}
+	callee, ok := contractFromBytes(topics[1])
+	if !ok {
+		return Call{}, false
+	}
+	fn, ok := topics[2].GetSym()
+	if !ok {
+		return Call{}, false
+	}
+	spec, _ := r.Spec(callee)
+	return Call{Callee: callee, Function: string(fn), Args: callArgs(spec, string(fn), data)}, true
+}
+
+// EventContracts returns the contracts whose specs type an event emitted by
+// contractID: the emitter and, for fn_call events, the callee.
+func EventContracts(contractID string, topics []xdr.ScVal) []string {
+	var ids []string
+	if contractID != "" {
+		ids = append(ids, NormalizeContractID(contractID))
+	}
+	if len(topics) >= 2 {
+		if kind, _ := topics[0].GetSym(); kind == "fn_call" {
+			if callee, ok := contractFromBytes(topics[1]); ok {
+				ids = append(ids, callee)
+			}
+		}
+	}
+	return ids
+}
+
+// DecodeEventValues decodes the base64 XDR topics and data of an event, as
+// reported in erst-sim's topics_xdr and data_xdr fields.
+func DecodeEventValues(topicsXDR []string, dataXDR string) ([]xdr.ScVal, xdr.ScVal, error) {
+	topics := make([]xdr.ScVal, len(topicsXDR))
+	for i, encoded := range topicsXDR {
+		if err := xdr.SafeUnmarshalBase64(encoded, &topics[i]); err != nil {
+			return nil, xdr.ScVal{}, fmt.Errorf("failed to decode event topic %d: %w", i, err)
+		}
+	}
+	var data xdr.ScVal
+	if err := xdr.SafeUnmarshalBase64(dataXDR, &data); err != nil {
+		return nil, xdr.ScVal{}, fmt.Errorf("failed to decode event data: %w", err)
+	}
+	return topics, data, nil
+}
+
+// contractFromBytes returns the strkey of a contract ID carried as 32 bytes.
+func contractFromBytes(v xdr.ScVal) (string, bool) {
+	b, ok := v.GetBytes()
+	if !ok || len(b) != 32 {
+		return "", false
+	}
+	id, err := strkey.Encode(strkey.VersionByteContract, b)
+	return id, err == nil
+}
+
+// callArgs splits the data of a fn_call event into the call's arguments.
+func callArgs(spec *abi.ContractSpec, fn string, data xdr.ScVal) []xdr.ScVal {
+	inputs := -1
+	if f, ok := spec.Function(fn); ok {
+		inputs = len(f.Inputs)
+	}
+	if inputs == 1 {
+		return []xdr.ScVal{data}
+	}
+	if data.Type == xdr.ScValTypeScvVoid && inputs <= 0 {
+		return nil
+	}
+	if vec, ok := data.GetVec(); ok {
+		if vec == nil {
+			return nil
+		}
+		return *vec
+	}
+	return []xdr.ScVal{data}
+}
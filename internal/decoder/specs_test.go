@@ -0,0 +1,136 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package decoder
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func symVal(s string) xdr.ScVal {
+	sym := xdr.ScSymbol(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+}
+
+func i128Val(n int64) xdr.ScVal {
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Lo: xdr.Uint64(n)}}
+}
+
+// tokenLedgerEntries returns the code and instance entries of a token whose
+// spec declares transfer and whose METADATA declares 7 decimals.
+func tokenLedgerEntries(t *testing.T, contractID xdr.ContractId) map[string]string {
+	t.Helper()
+	address := xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeAddress}
+	wasm := specEntriesWasm(t, xdr.ScSpecEntry{
+		Kind: xdr.ScSpecEntryKindScSpecEntryFunctionV0,
+		FunctionV0: &xdr.ScSpecFunctionV0{
+			Name: "transfer",
+			Inputs: []xdr.ScSpecFunctionInputV0{
+				{Name: "from", Type: address},
+				{Name: "to", Type: address},
+				{Name: "amount", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeI128}},
+			},
+		},
+	})
+	hash := xdr.Hash(sha256.Sum256(wasm))
+
+	decimal := xdr.Uint32(7)
+	metadata := xdr.ScMap{{Key: symVal("decimal"), Val: xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &decimal}}}
+	metadataPtr := &metadata
+	storage := xdr.ScMap{{Key: symVal("METADATA"), Val: xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &metadataPtr}}}
+
+	code := xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.ContractCodeEntry{Hash: hash, Code: wasm},
+	}}
+	instance := xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.ContractDataEntry{
+			Contract: xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+			Key:      xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Val: xdr.ScVal{
+				Type: xdr.ScValTypeScvContractInstance,
+				Instance: &xdr.ScContractInstance{
+					Executable: xdr.ContractExecutable{Type: xdr.ContractExecutableTypeContractExecutableWasm, WasmHash: &hash},
+					Storage:    &storage,
+				},
+			},
+		},
+	}}
+
+	entries := make(map[string]string)
+	for key, entry := range map[string]xdr.LedgerEntry{"code": code, "instance": instance} {
+		b, err := entry.MarshalBinary()
+		require.NoError(t, err)
+		entries[key] = base64.StdEncoding.EncodeToString(b)
+	}
+	return entries
+}
+
+func TestSpecRegistry_FormatCall(t *testing.T) {
+	tokenID := xdr.ContractId{9}
+	token, err := strkey.Encode(strkey.VersionByteContract, tokenID[:])
+	require.NoError(t, err)
+
+	r := NewSpecRegistry()
+	r.RegisterLedgerEntries(tokenLedgerEntries(t, tokenID))
+	require.True(t, r.Known(token))
+
+	var key xdr.Uint256
+	account := xdr.AccountId(xdr.PublicKey{Type: xdr.PublicKeyTypePublicKeyTypeEd25519, Ed25519: &key})
+	from := xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &account}}
+	fromID, err := strkey.Encode(strkey.VersionByteAccountID, key[:])
+	require.NoError(t, err)
+
+	callee := xdr.ScBytes(tokenID[:])
+	args := xdr.ScVec{from, from, i128Val(15_000_000)}
+	argsPtr := &args
+	topics := []xdr.ScVal{symVal("fn_call"), {Type: xdr.ScValTypeScvBytes, Bytes: &callee}, symVal("transfer")}
+	data := xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &argsPtr}
+
+	call, ok := r.ParseCall(topics, data)
+	require.True(t, ok)
+	assert.Equal(t, token, call.Callee)
+	assert.Len(t, call.Args, 3)
+
+	renderedTopics, rendered := r.FormatEvent("", topics, data)
+	assert.Equal(t, []string{"fn_call", token, "transfer"}, renderedTopics)
+	assert.Equal(t, "transfer(from: "+fromID+", to: "+fromID+", amount: 1.5)", rendered)
+	assert.Equal(t, []string{token}, EventContracts("", topics))
+
+	_, rendered = r.FormatEvent(token, []xdr.ScVal{symVal("fn_return"), symVal("transfer")}, xdr.ScVal{Type: xdr.ScValTypeScvVoid})
+	assert.Equal(t, "()", rendered)
+}
+
+func TestDecodeEventsWithSpecs(t *testing.T) {
+	tokenID := xdr.ContractId{9}
+	r := NewSpecRegistry()
+	r.RegisterLedgerEntries(tokenLedgerEntries(t, tokenID))
+
+	event := xdr.DiagnosticEvent{Event: xdr.ContractEvent{
+		ContractId: &tokenID,
+		Type:       xdr.ContractEventTypeContract,
+		Body: xdr.ContractEventBody{V: 0, V0: &xdr.ContractEventV0{
+			Topics: []xdr.ScVal{symVal("mint")},
+			Data:   i128Val(20_000_000),
+		}},
+	}}
+	b, err := event.MarshalBinary()
+	require.NoError(t, err)
+
+	root, err := DecodeEventsWithSpecs([]string{base64.StdEncoding.EncodeToString(b)}, r)
+	require.NoError(t, err)
+	require.Len(t, root.Events, 1)
+	assert.Equal(t, "2", root.Events[0].Data, "token amounts are scaled by the token's decimals")
+
+	root, err = DecodeEvents([]string{base64.StdEncoding.EncodeToString(b)})
+	require.NoError(t, err)
+	assert.Equal(t, "20000000", root.Events[0].Data)
+}
@@ -6,6 +6,8 @@ package decoder
 import (
 	"fmt"
 	"strings"
+
+	"github.com/dotandev/hintents/internal/rules"
 )
 
 // Suggestion represents a potential fix for a Soroban error
@@ -15,12 +17,15 @@ type Suggestion struct {
 	Confidence  string // "high", "medium", "low"
 }
 
-// ErrorPattern defines a heuristic rule for error detection
+// ErrorPattern defines a heuristic rule for error detection. Patterns built
+// from a rule pack carry the Rule and are matched by the rule engine;
+// patterns added from Go code use Keywords and EventChecks.
 type ErrorPattern struct {
 	Name        string
 	Keywords    []string
 	EventChecks []func(DecodedEvent) bool
 	Suggestion  Suggestion
+	Rule        *rules.Rule
 }
 
 // SuggestionEngine provides heuristic-based error suggestions
@@ -28,164 +33,45 @@ type SuggestionEngine struct {
 	rules []ErrorPattern
 }
 
-// NewSuggestionEngine creates a new suggestion engine with predefined rules
+// NewSuggestionEngine creates a new suggestion engine with the built-in rules
 func NewSuggestionEngine() *SuggestionEngine {
+	return NewSuggestionEngineWithRules(rules.Builtin())
+}
+
+// NewSuggestionEngineWithRules creates a suggestion engine from the rules of
+// set that carry a fix
+func NewSuggestionEngineWithRules(set *rules.Set) *SuggestionEngine {
 	engine := &SuggestionEngine{
 		rules: []ErrorPattern{},
 	}
-	engine.loadDefaultRules()
+	for _, r := range set.Rules() {
+		if r.Fix == "" {
+			continue
+		}
+		engine.rules = append(engine.rules, ErrorPattern{
+			Name:       r.Name,
+			Suggestion: Suggestion{Rule: r.Name, Confidence: r.Confidence},
+			Rule:       r,
+		})
+	}
 	return engine
 }
 
-// loadDefaultRules initializes the default heuristic rules
-func (e *SuggestionEngine) loadDefaultRules() {
-	// Rule 1: Uninitialized contract
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "uninitialized_contract",
-		Keywords: []string{"empty", "not found", "missing", "null"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "storage") && strings.Contains(lower, "empty") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "uninitialized_contract",
-			Description: "Potential Fix: Ensure you have called initialize() on this contract before invoking other functions.",
-			Confidence:  "high",
-		},
-	})
-
-	// Rule 2: Insufficient authorization
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "missing_authorization",
-		Keywords: []string{"auth", "unauthorized", "permission", "signature"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "auth") || strings.Contains(lower, "unauthorized") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "missing_authorization",
-			Description: "Potential Fix: Verify that all required signatures are present and the invoker has proper authorization.",
-			Confidence:  "high",
-		},
-	})
-
-	// Rule 3: Insufficient balance
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "insufficient_balance",
-		Keywords: []string{"balance", "insufficient", "underfunded", "funds"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "balance") || strings.Contains(lower, "insufficient") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "insufficient_balance",
-			Description: "Potential Fix: Ensure the account has sufficient balance to cover the transaction and maintain minimum reserves.",
-			Confidence:  "high",
-		},
-	})
-
-	// Rule 4: Invalid parameters
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "invalid_parameters",
-		Keywords: []string{"invalid", "malformed", "bad", "parameter"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "invalid") || strings.Contains(lower, "malformed") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "invalid_parameters",
-			Description: "Potential Fix: Check that all function parameters match the expected types and constraints.",
-			Confidence:  "medium",
-		},
-	})
-
-	// Rule 5: Contract not found
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "contract_not_found",
-		Keywords: []string{"not found", "missing contract", "no contract"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				return e.ContractID == "" || e.ContractID == "0000000000000000000000000000000000000000000000000000000000000000"
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "contract_not_found",
-			Description: "Potential Fix: Verify the contract ID is correct and the contract has been deployed to the network.",
-			Confidence:  "high",
-		},
-	})
-
-	// Rule 6: Exceeded resource limits
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "resource_limit_exceeded",
-		Keywords: []string{"limit", "exceeded", "quota", "budget"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "limit") || strings.Contains(lower, "exceeded") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "resource_limit_exceeded",
-			Description: "Potential Fix: Optimize your contract code to reduce CPU/memory usage, or increase resource limits in the transaction.",
-			Confidence:  "medium",
-		},
-	})
-
-	// Rule 7: Reentrancy issue
-	e.rules = append(e.rules, ErrorPattern{
-		Name:     "reentrancy_detected",
-		Keywords: []string{"reentrant", "recursive", "loop"},
-		EventChecks: []func(DecodedEvent) bool{
-			func(e DecodedEvent) bool {
-				for _, topic := range e.Topics {
-					lower := strings.ToLower(topic)
-					if strings.Contains(lower, "reentrant") || strings.Contains(lower, "recursive") {
-						return true
-					}
-				}
-				return false
-			},
-		},
-		Suggestion: Suggestion{
-			Rule:        "reentrancy_detected",
-			Description: "Potential Fix: Implement reentrancy guards or use the checks-effects-interactions pattern to prevent recursive calls.",
-			Confidence:  "medium",
-		},
-	})
+// SuggestionsFromFindings converts rule findings that carry a fix into
+// suggestions
+func SuggestionsFromFindings(findings []rules.Finding) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, f := range findings {
+		if f.Fix == "" {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Rule:        f.Rule.Name,
+			Description: "Potential Fix: " + f.Fix,
+			Confidence:  f.Rule.Confidence,
+		})
+	}
+	return suggestions
 }
 
 // AnalyzeEvents analyzes decoded events and returns suggestions
@@ -200,6 +86,16 @@ func (e *SuggestionEngine) AnalyzeEvents(events []DecodedEvent) []Suggestion {
 				continue
 			}
 
+			if rule.Rule != nil {
+				if f, ok := rule.Rule.Evaluate(ruleInput(event)); ok {
+					suggestion := rule.Suggestion
+					suggestion.Description = "Potential Fix: " + f.Fix
+					suggestions = append(suggestions, suggestion)
+					seenRules[rule.Name] = true
+				}
+				continue
+			}
+
 			// Check keywords in topics and data
 			keywordMatch := false
 			for _, keyword := range rule.Keywords {
@@ -238,6 +134,20 @@ func (e *SuggestionEngine) AnalyzeEvents(events []DecodedEvent) []Suggestion {
 	return suggestions
 }
 
+// ruleInput exposes an event's topics and data to rule keywords and its
+// structure to topic and contract matchers
+func ruleInput(event DecodedEvent) rules.Input {
+	lines := append(append([]string(nil), event.Topics...), event.Data)
+	return rules.Input{
+		Lines: lines,
+		Events: []rules.Event{{
+			ContractID: event.ContractID,
+			Topics:     event.Topics,
+			Data:       event.Data,
+		}},
+	}
+}
+
 // AnalyzeCallTree analyzes a call tree and returns suggestions
 func (e *SuggestionEngine) AnalyzeCallTree(root *CallNode) []Suggestion {
 	if root == nil {
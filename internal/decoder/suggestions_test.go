@@ -6,6 +6,8 @@ package decoder
 import (
 	"strings"
 	"testing"
+
+	"github.com/dotandev/hintents/internal/rules"
 )
 
 func TestNewSuggestionEngine(t *testing.T) {
@@ -368,3 +370,47 @@ func TestAnalyzeEvents_DuplicateRules(t *testing.T) {
 		t.Errorf("Expected rule to appear only once, got %d times", count)
 	}
 }
+
+func TestNewSuggestionEngineWithRules(t *testing.T) {
+	pack, err := rules.Parse("team.json", []byte(`{"rules": [
+		{"name": "oracle_stale", "topics": ["stale_price"], "fix": "Refresh the {{.Contract}} oracle before calling.", "confidence": "low"},
+		{"name": "summary_only", "topics": ["stale_price"], "explanation": "not a fix"}
+	]}`))
+	if err != nil {
+		t.Fatalf("failed to parse pack: %v", err)
+	}
+	set := rules.Builtin()
+	set.Merge(pack)
+	engine := NewSuggestionEngineWithRules(set)
+
+	suggestions := engine.AnalyzeEvents([]DecodedEvent{
+		{ContractID: "CORACLE", Topics: []string{"stale_price"}, Data: "ScvVoid"},
+	})
+	if len(suggestions) != 1 {
+		t.Fatalf("Expected only the oracle suggestion, got %+v", suggestions)
+	}
+	if suggestions[0].Description != "Potential Fix: Refresh the CORACLE oracle before calling." {
+		t.Errorf("Unexpected description: %s", suggestions[0].Description)
+	}
+	if suggestions[0].Confidence != "low" {
+		t.Errorf("Expected low confidence, got: %s", suggestions[0].Confidence)
+	}
+}
+
+func TestSuggestionsFromFindings(t *testing.T) {
+	findings := rules.Builtin().Fixes(rules.Input{Error: "insufficient balance"})
+	suggestions := SuggestionsFromFindings(findings)
+
+	found := false
+	for _, s := range suggestions {
+		if s.Rule == "insufficient_balance" {
+			found = true
+			if !strings.HasPrefix(s.Description, "Potential Fix: ") {
+				t.Errorf("Expected Potential Fix prefix, got: %s", s.Description)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected insufficient_balance suggestion")
+	}
+}
@@ -5,6 +5,7 @@ package decoder
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"text/tabwriter"
@@ -21,12 +22,26 @@ const (
 
 type XDRFormatter struct {
 	format FormatType
+	specs  *SpecRegistry
 }
 
 func NewXDRFormatter(format FormatType) *XDRFormatter {
 	return &XDRFormatter{format: format}
 }
 
+// NewXDRFormatterWithSpecs creates a formatter whose tables render event
+// values typed by the contract specs of specs.
+func NewXDRFormatterWithSpecs(format FormatType, specs *SpecRegistry) *XDRFormatter {
+	return &XDRFormatter{format: format, specs: specs}
+}
+
+// RenderedValue is an ScVal rendered for display, typed by a contract spec
+// when one is known.
+type RenderedValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
 func (f *XDRFormatter) Format(data interface{}) (string, error) {
 	switch f.format {
 	case FormatJSON:
@@ -53,7 +68,14 @@ func (f *XDRFormatter) formatTable(data interface{}) (string, error) {
 	case *xdr.TransactionEnvelope:
 		return formatTransactionEnvelopeTable(v)
 	case *xdr.DiagnosticEvent:
-		return formatDiagnosticEventTable(v)
+		return formatDiagnosticEventTable(v, f.specs)
+	case *RenderedValue:
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Type:\t%s\n", v.Type)
+		_, _ = fmt.Fprintf(w, "Value:\t%s\n", v.Value)
+		_ = w.Flush()
+		return buf.String(), nil
 	case []interface{}:
 		return formatGenericTable(v)
 	default:
@@ -171,15 +193,25 @@ func formatTransactionEnvelopeTable(env *xdr.TransactionEnvelope) (string, error
 	return buf.String(), nil
 }
 
-func formatDiagnosticEventTable(event *xdr.DiagnosticEvent) (string, error) {
+func formatDiagnosticEventTable(event *xdr.DiagnosticEvent, specs *SpecRegistry) (string, error) {
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
 
 	_, _ = fmt.Fprintf(w, "Successful:\t%v\n", event.InSuccessfulContractCall)
 	_, _ = fmt.Fprintf(w, "Event Type:\t%v\n", event.Event.Type)
 
+	var contractID string
 	if event.Event.ContractId != nil {
 		_, _ = fmt.Fprintf(w, "Contract ID:\t%x\n", event.Event.ContractId)
+		contractID = hex.EncodeToString(event.Event.ContractId[:])
+	}
+
+	if body, ok := event.Event.Body.GetV0(); ok {
+		topics, data := specs.FormatEvent(contractID, body.Topics, body.Data)
+		for i, topic := range topics {
+			_, _ = fmt.Fprintf(w, "Topic %d:\t%s\n", i, topic)
+		}
+		_, _ = fmt.Fprintf(w, "Data:\t%s\n", data)
 	}
 
 	_ = w.Flush()
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dotandev/hintents/internal/decoder"
+	"github.com/dotandev/hintents/internal/rules"
 	"github.com/dotandev/hintents/internal/simulator"
 )
 
@@ -236,3 +238,54 @@ func TestSummarize_ShortHashPassthrough(t *testing.T) {
 		t.Fatalf("expected full short hash in output, got: %s", got)
 	}
 }
+
+func TestSummarizeWith_TeamRulePack(t *testing.T) {
+	pack, err := rules.Parse("team.toml", []byte(`
+[[rules]]
+name = "vault_locked"
+priority = 100
+error_codes = ["Contract, #4"]
+contracts = ["CVAULT"]
+explanation = "Transaction {{.TxHash}} failed because vault {{.Contract}} is locked."
+`))
+	if err != nil {
+		t.Fatalf("failed to parse pack: %v", err)
+	}
+	set := rules.Builtin()
+	set.Merge(pack)
+
+	in := Input{
+		TxHash:  "aaaaaa000000bbbbbb",
+		Network: "mainnet",
+		Status:  "error",
+		Error:   "HostError: Error(Contract, #4), not authorized",
+		DiagnosticEvents: []simulator.DiagnosticEvent{
+			{ContractID: strPtr("CVAULT"), EventType: "contract", Topics: []string{"withdraw"}},
+		},
+	}
+	got := SummarizeWith(set, in)
+	if got != "Transaction aaaaaa...bbbbbb failed because vault CVAULT is locked." {
+		t.Fatalf("expected team rule to outrank built-in auth rule, got: %s", got)
+	}
+	if got := Summarize(in); !strings.Contains(got, "authorization") {
+		t.Fatalf("expected built-in rules without the pack, got: %s", got)
+	}
+}
+
+func TestSummarize_NamedContractError(t *testing.T) {
+	in := Input{
+		TxHash:  "abc",
+		Network: "testnet",
+		Status:  "error",
+		Error:   "HostError: Error(Contract, #7) [TokenError::Paused]",
+		DiagnosticEvents: []simulator.DiagnosticEvent{
+			{ContractID: strPtr("CTOKEN"), EventType: "contract", Topics: []string{"transfer"}},
+		},
+		ContractError: &decoder.ContractError{Code: 7, Enum: "TokenError", Name: "Paused", Doc: "Transfers are paused."},
+	}
+	got := Summarize(in)
+	want := "Transaction abc failed on testnet because contract CTOKEN returned TokenError::Paused (Error(Contract, #7)). Transfers are paused."
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
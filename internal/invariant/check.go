@@ -0,0 +1,276 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package invariant
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/tokenflow"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// check returns a message for each way obs violates the invariant.
+func (inv *Invariant) check(obs *Observation) []string {
+	if obs == nil {
+		return nil
+	}
+	switch inv.Type {
+	case TypeUnchanged:
+		return inv.checkUnchanged(obs)
+	case TypeNonNegative:
+		return inv.checkNonNegative(obs)
+	case TypeConserved:
+		return inv.checkConserved(obs)
+	case TypeNoMint:
+		return inv.checkNoMint(obs)
+	case TypeMaxTransfer:
+		return inv.checkMaxTransfer(obs)
+	case TypeForbiddenEvent:
+		return inv.checkForbiddenEvent(obs)
+	}
+	return nil
+}
+
+func (inv *Invariant) checkUnchanged(obs *Observation) []string {
+	var out []string
+	for _, c := range inv.matchingChanges(obs) {
+		verb := "updated"
+		switch {
+		case c.Before == nil:
+			verb = "created"
+		case c.After == nil:
+			verb = "deleted"
+		}
+		out = append(out, fmt.Sprintf("%s %s", describeEntry(c), verb))
+	}
+	return out
+}
+
+func (inv *Invariant) checkNonNegative(obs *Observation) []string {
+	var out []string
+	for _, c := range inv.matchingChanges(obs) {
+		if c.After == nil {
+			continue
+		}
+		amount, ok := inv.amount(*c.After)
+		if ok && amount.Sign() < 0 {
+			out = append(out, fmt.Sprintf("%s is %s", describeEntry(c), amount))
+		}
+	}
+	return out
+}
+
+func (inv *Invariant) checkConserved(obs *Observation) []string {
+	delta := make(map[string]*big.Int)
+	for _, c := range inv.matchingChanges(obs) {
+		d, ok := delta[c.Contract]
+		if !ok {
+			d = new(big.Int)
+			delta[c.Contract] = d
+		}
+		if c.After != nil {
+			if amount, ok := inv.amount(*c.After); ok {
+				d.Add(d, amount)
+			}
+		}
+		if c.Before != nil {
+			if amount, ok := inv.amount(*c.Before); ok {
+				d.Sub(d, amount)
+			}
+		}
+	}
+
+	contracts := make([]string, 0, len(delta))
+	for contract := range delta {
+		contracts = append(contracts, contract)
+	}
+	sort.Strings(contracts)
+
+	var out []string
+	for _, contract := range contracts {
+		if d := delta[contract]; d.Sign() != 0 {
+			out = append(out, fmt.Sprintf("total %s of %s changed by %s", inv.keyLabel(), shortID(contract), signed(d)))
+		}
+	}
+	return out
+}
+
+func (inv *Invariant) checkNoMint(obs *Observation) []string {
+	var out []string
+	for _, t := range obs.Transfers {
+		if t.Kind == tokenflow.KindMint && inv.matchesToken(t.Token) {
+			out = append(out, fmt.Sprintf("%s %s minted to %s", t.Amount, t.Token.Display(), shortID(t.To)))
+		}
+	}
+	return out
+}
+
+func (inv *Invariant) checkMaxTransfer(obs *Observation) []string {
+	max := inv.max
+	if max == nil {
+		var ok bool
+		if max, ok = new(big.Int).SetString(inv.Max, 10); !ok {
+			return nil
+		}
+	}
+
+	var out []string
+	for _, t := range obs.Transfers {
+		if t.Amount != nil && t.Amount.Cmp(max) > 0 && inv.matchesToken(t.Token) {
+			out = append(out, fmt.Sprintf("%s %s moved from %s to %s exceeds %s", t.Amount, t.Token.Display(), shortID(t.From), shortID(t.To), max))
+		}
+	}
+	return out
+}
+
+func (inv *Invariant) checkForbiddenEvent(obs *Observation) []string {
+	var out []string
+	for i, e := range obs.Events {
+		for _, topic := range e.Topics {
+			if strings.Contains(topic, inv.Topic) {
+				out = append(out, fmt.Sprintf("event %d has topic %s", i, topic))
+				break
+			}
+		}
+	}
+	return out
+}
+
+// -------------------- Selectors --------------------
+
+func (inv *Invariant) matchingChanges(obs *Observation) []EntryChange {
+	var out []EntryChange
+	for _, c := range obs.Changes {
+		if inv.Contract != "" && c.Contract != inv.Contract {
+			continue
+		}
+		if inv.Key != "" && keyName(c.Key) != inv.Key {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (inv *Invariant) matchesToken(t tokenflow.Token) bool {
+	switch inv.Token {
+	case "":
+		return true
+	case "XLM":
+		return t.Symbol == "XLM" && t.ID == ""
+	}
+	return t.ID == inv.Token
+}
+
+// amount reads the integer an entry holds, or the integer in its Field when
+// the value is a struct.
+func (inv *Invariant) amount(v xdr.ScVal) (*big.Int, bool) {
+	if inv.Field != "" {
+		m, ok := v.GetMap()
+		if !ok || m == nil {
+			return nil, false
+		}
+		for _, entry := range *m {
+			if sym, ok := entry.Key.GetSym(); ok && string(sym) == inv.Field {
+				return scValInt(entry.Val)
+			}
+		}
+		return nil, false
+	}
+	return scValInt(v)
+}
+
+func (inv *Invariant) keyLabel() string {
+	if inv.Key != "" {
+		return inv.Key
+	}
+	return "amount"
+}
+
+// keyName returns the symbol naming a contract data key: the key itself when
+// it is a symbol, or the first element of a vector key.
+func keyName(key xdr.ScVal) string {
+	if sym, ok := key.GetSym(); ok {
+		return string(sym)
+	}
+	if vec, ok := key.GetVec(); ok && vec != nil && len(*vec) > 0 {
+		if sym, ok := (*vec)[0].GetSym(); ok {
+			return string(sym)
+		}
+	}
+	return ""
+}
+
+func describeEntry(c EntryChange) string {
+	name := keyName(c.Key)
+	if name == "" {
+		name = c.Key.Type.String()
+	}
+	if vec, ok := c.Key.GetVec(); ok && vec != nil && len(*vec) > 1 {
+		parts := make([]string, 0, len(*vec)-1)
+		for _, v := range (*vec)[1:] {
+			parts = append(parts, describeScVal(v))
+		}
+		name += "(" + strings.Join(parts, ", ") + ")"
+	}
+	if c.Contract == "" {
+		return name
+	}
+	return name + " of " + shortID(c.Contract)
+}
+
+func describeScVal(v xdr.ScVal) string {
+	if n, ok := scValInt(v); ok {
+		return n.String()
+	}
+	if sym, ok := v.GetSym(); ok {
+		return string(sym)
+	}
+	if addr, ok := v.GetAddress(); ok {
+		if s, err := addr.String(); err == nil {
+			return shortID(s)
+		}
+	}
+	return v.Type.String()
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:6] + "…" + id[len(id)-4:]
+	}
+	return id
+}
+
+func signed(n *big.Int) string {
+	if n.Sign() > 0 {
+		return "+" + n.String()
+	}
+	return n.String()
+}
+
+// scValInt converts any Soroban integer value up to 128 bits to a big.Int.
+func scValInt(v xdr.ScVal) (*big.Int, bool) {
+	switch v.Type {
+	case xdr.ScValTypeScvU32:
+		return big.NewInt(int64(*v.U32)), true
+	case xdr.ScValTypeScvI32:
+		return big.NewInt(int64(*v.I32)), true
+	case xdr.ScValTypeScvU64:
+		return new(big.Int).SetUint64(uint64(*v.U64)), true
+	case xdr.ScValTypeScvI64:
+		return big.NewInt(int64(*v.I64)), true
+	case xdr.ScValTypeScvU128:
+		p := v.U128
+		hi := new(big.Int).Lsh(new(big.Int).SetUint64(uint64(p.Hi)), 64)
+		return hi.Or(hi, new(big.Int).SetUint64(uint64(p.Lo))), true
+	case xdr.ScValTypeScvI128:
+		p := v.I128
+		hi := new(big.Int).Lsh(big.NewInt(int64(p.Hi)), 64)
+		return hi.Add(hi, new(big.Int).SetUint64(uint64(p.Lo))), true
+	}
+	return nil, false
+}
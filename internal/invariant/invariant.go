@@ -0,0 +1,175 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package invariant checks domain invariants, such as conserved supply or a
+// fixed admin key, against simulated executions.
+//
+// Invariants are declared in a JSON file:
+//
+//	{
+//	  "invariants": [
+//	    {"name": "admin is fixed", "type": "unchanged", "contract": "CA...", "key": "Admin"},
+//	    {"name": "no negative balances", "type": "non_negative", "key": "Balance"},
+//	    {"name": "supply is conserved", "type": "conserved", "contract": "CA...", "key": "Balance"},
+//	    {"name": "no minting", "type": "no_mint", "token": "CA..."},
+//	    {"name": "bounded transfers", "type": "max_transfer", "max": "1000000000"},
+//	    {"name": "no panics", "type": "forbidden_event", "topic": "panic"}
+//	  ]
+//	}
+//
+// Ledger invariants read the contract data entries an execution changed.
+// Transfer invariants read the token movements found by tokenflow, and event
+// invariants read the diagnostic events of the response.
+package invariant
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/simulator"
+)
+
+// Type is the kind of property an invariant asserts.
+type Type string
+
+const (
+	// TypeUnchanged asserts that matching entries are never created,
+	// updated or deleted.
+	TypeUnchanged Type = "unchanged"
+	// TypeNonNegative asserts that matching entries never hold a negative
+	// amount.
+	TypeNonNegative Type = "non_negative"
+	// TypeConserved asserts that the amounts held by matching entries of
+	// each contract sum to the same total before and after execution.
+	TypeConserved Type = "conserved"
+	// TypeNoMint asserts that no tokens are minted.
+	TypeNoMint Type = "no_mint"
+	// TypeMaxTransfer asserts that no single movement exceeds Max.
+	TypeMaxTransfer Type = "max_transfer"
+	// TypeForbiddenEvent asserts that no event topic contains Topic.
+	TypeForbiddenEvent Type = "forbidden_event"
+)
+
+// Invariant is one property declared in an invariants file.
+type Invariant struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+
+	// Contract restricts ledger invariants to one contract (C... address).
+	Contract string `json:"contract,omitempty"`
+	// Key selects contract data entries by the symbol that names them, as
+	// the Soroban SDK encodes enum keys: Admin matches Symbol(Admin) and
+	// Balance matches Vec[Symbol(Balance), ...].
+	Key string `json:"key,omitempty"`
+	// Field picks the amount out of a struct value, for example "amount".
+	Field string `json:"field,omitempty"`
+
+	// Token restricts transfer invariants to one token: a contract address,
+	// or XLM for native payments.
+	Token string `json:"token,omitempty"`
+	// Max is the largest allowed movement, in the token's smallest unit.
+	Max string `json:"max,omitempty"`
+
+	// Topic is matched against the rendered topics of each event.
+	Topic string `json:"topic,omitempty"`
+
+	max *big.Int
+}
+
+// Set is a parsed invariants file. It implements simulator.InvariantChecker.
+type Set struct {
+	Invariants []Invariant `json:"invariants"`
+}
+
+var _ simulator.InvariantChecker = (*Set)(nil)
+
+// Load reads and validates the invariants file at path.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invariants file: %w", err)
+	}
+	set, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return set, nil
+}
+
+// Parse decodes and validates an invariants file.
+func Parse(data []byte) (*Set, error) {
+	var set Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid invariants file: %w", err)
+	}
+	if len(set.Invariants) == 0 {
+		return nil, fmt.Errorf("no invariants declared")
+	}
+
+	names := make(map[string]bool)
+	for i := range set.Invariants {
+		inv := &set.Invariants[i]
+		if inv.Name == "" {
+			return nil, fmt.Errorf("invariant %d has no name", i+1)
+		}
+		if names[inv.Name] {
+			return nil, fmt.Errorf("duplicate invariant %q", inv.Name)
+		}
+		names[inv.Name] = true
+		if err := inv.validate(); err != nil {
+			return nil, fmt.Errorf("invariant %q: %w", inv.Name, err)
+		}
+	}
+	return &set, nil
+}
+
+func (inv *Invariant) validate() error {
+	switch inv.Type {
+	case TypeUnchanged, TypeNonNegative, TypeConserved:
+		if inv.Contract == "" && inv.Key == "" {
+			return fmt.Errorf("%s needs a contract or key", inv.Type)
+		}
+	case TypeNoMint:
+	case TypeMaxTransfer:
+		max, ok := new(big.Int).SetString(inv.Max, 10)
+		if !ok || max.Sign() < 0 {
+			return fmt.Errorf("max must be a non-negative integer, got %q", inv.Max)
+		}
+		inv.max = max
+	case TypeForbiddenEvent:
+		if inv.Topic == "" {
+			return fmt.Errorf("forbidden_event needs a topic")
+		}
+	case "":
+		return fmt.Errorf("missing type")
+	default:
+		return fmt.Errorf("unknown type %q", inv.Type)
+	}
+	return nil
+}
+
+// Check evaluates every invariant against one execution. When the result
+// meta cannot be read, ledger and transfer invariants see no changes and the
+// problem is logged.
+func (s *Set) Check(req *simulator.SimulationRequest, resp *simulator.SimulationResponse) []simulator.InvariantViolation {
+	obs, err := Observe(req, resp)
+	if err != nil {
+		logger.Logger.Warn("Invariants checked without ledger changes", "error", err)
+	}
+	return s.CheckObservation(obs)
+}
+
+// CheckObservation evaluates every invariant against obs.
+func (s *Set) CheckObservation(obs *Observation) []simulator.InvariantViolation {
+	var violations []simulator.InvariantViolation
+	for i := range s.Invariants {
+		inv := &s.Invariants[i]
+		for _, msg := range inv.check(obs) {
+			violations = append(violations, simulator.InvariantViolation{Invariant: inv.Name, Message: msg})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,303 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package invariant
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/tokenflow"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", `{"invariants": []}`, "no invariants declared"},
+		{"unnamed", `{"invariants": [{"type": "no_mint"}]}`, "has no name"},
+		{"duplicate", `{"invariants": [{"name": "a", "type": "no_mint"}, {"name": "a", "type": "no_mint"}]}`, "duplicate"},
+		{"no type", `{"invariants": [{"name": "a"}]}`, "missing type"},
+		{"unknown type", `{"invariants": [{"name": "a", "type": "sorted"}]}`, "unknown type"},
+		{"no selector", `{"invariants": [{"name": "a", "type": "unchanged"}]}`, "needs a contract or key"},
+		{"bad max", `{"invariants": [{"name": "a", "type": "max_transfer", "max": "-1"}]}`, "non-negative integer"},
+		{"no topic", `{"invariants": [{"name": "a", "type": "forbidden_event"}]}`, "needs a topic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	set, err := Parse([]byte(`{"invariants": [{"name": "bounded", "type": "max_transfer", "max": "100"}]}`))
+	require.NoError(t, err)
+	require.Len(t, set.Invariants, 1)
+}
+
+func TestCheckObservation(t *testing.T) {
+	const token = "CTOKEN"
+	balance := func(holder string) xdr.ScVal {
+		return vec(sym("Balance"), sym(holder))
+	}
+	obs := &Observation{
+		Changes: []EntryChange{
+			{Contract: token, Key: sym("Admin"), Before: ptr(sym("alice")), After: ptr(sym("mallory"))},
+			{Contract: token, Key: balance("alice"), Before: ptr(i128(100)), After: ptr(i128(-20))},
+			{Contract: token, Key: balance("bob"), Before: nil, After: ptr(i128(130))},
+		},
+		Transfers: []tokenflow.Transfer{
+			{From: "alice", To: "bob", Token: tokenflow.Token{Symbol: "SAC", ID: token}, Amount: big.NewInt(120), Kind: tokenflow.KindTransfer},
+			{From: "MINT", To: "bob", Token: tokenflow.Token{Symbol: "SAC", ID: token}, Amount: big.NewInt(10), Kind: tokenflow.KindMint},
+			{From: "alice", To: "bob", Token: tokenflow.Token{Symbol: "XLM"}, Amount: big.NewInt(5), Kind: tokenflow.KindTransfer},
+		},
+		Events: []simulator.DiagnosticEvent{
+			{EventType: "diagnostic", Topics: []string{"Sym(log)"}},
+			{EventType: "diagnostic", Topics: []string{"Sym(panic)", "Sym(overflow)"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		inv  Invariant
+		want []string
+	}{
+		{"unchanged", Invariant{Type: TypeUnchanged, Key: "Admin"}, []string{"Admin of CTOKEN updated"}},
+		{"non negative", Invariant{Type: TypeNonNegative, Key: "Balance"}, []string{"Balance(alice) of CTOKEN is -20"}},
+		{"conserved", Invariant{Type: TypeConserved, Key: "Balance"}, []string{"total Balance of CTOKEN changed by +10"}},
+		{"no mint", Invariant{Type: TypeNoMint, Token: token}, []string{"10 SAC(CTOKEN) minted to bob"}},
+		{"max transfer", Invariant{Type: TypeMaxTransfer, Max: "100"}, []string{"120 SAC(CTOKEN) moved from alice to bob exceeds 100"}},
+		{"max transfer xlm", Invariant{Type: TypeMaxTransfer, Max: "1", Token: "XLM"}, []string{"5 XLM moved from alice to bob exceeds 1"}},
+		{"forbidden event", Invariant{Type: TypeForbiddenEvent, Topic: "panic"}, []string{"event 1 has topic Sym(panic)"}},
+		{"other contract", Invariant{Type: TypeUnchanged, Contract: "COTHER"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.inv.Name = tt.name
+			set := &Set{Invariants: []Invariant{tt.inv}}
+			var got []string
+			for _, v := range set.CheckObservation(obs) {
+				assert.Equal(t, tt.name, v.Invariant)
+				got = append(got, v.Message)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckObservation_StructField(t *testing.T) {
+	inv := Invariant{Name: "positive", Type: TypeNonNegative, Key: "Position", Field: "amount"}
+	position := xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: ptrMap(xdr.ScMap{
+		{Key: sym("amount"), Val: i128(-1)},
+		{Key: sym("owner"), Val: sym("alice")},
+	})}
+	obs := &Observation{Changes: []EntryChange{{Key: sym("Position"), After: &position}}}
+
+	violations := (&Set{Invariants: []Invariant{inv}}).CheckObservation(obs)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Position is -1", violations[0].Message)
+}
+
+func TestObserve_LedgerChangesFromResponse(t *testing.T) {
+	contract := xdr.ContractId{0xAA}
+	admin := contractDataEntry(contract, sym("Admin"), sym("alice"))
+	counter := contractDataEntry(contract, sym("Counter"), i128(1))
+	counterAfter := contractDataEntry(contract, sym("Counter"), i128(2))
+	paused := contractDataEntry(contract, sym("Paused"), xdr.ScVal{Type: xdr.ScValTypeScvBool, B: ptrBool(false)})
+
+	req := &simulator.SimulationRequest{
+		LedgerEntries: map[string]string{
+			encodedKey(t, admin):   encode(t, admin),
+			encodedKey(t, counter): encode(t, counter),
+			encodedKey(t, paused):  encode(t, paused),
+		},
+	}
+	resp := &simulator.SimulationResponse{
+		DiagnosticEvents: []simulator.DiagnosticEvent{{EventType: "contract"}},
+		LedgerChanges: []simulator.LedgerChange{
+			{Key: encodedKey(t, admin)},
+			{Key: encodedKey(t, counter), Entry: encode(t, counterAfter)},
+			{Key: encodedKey(t, paused), Entry: encode(t, paused)},
+		},
+	}
+
+	obs, err := Observe(req, resp)
+	require.NoError(t, err)
+	assert.Len(t, obs.Events, 1)
+	require.Len(t, obs.Changes, 2, "entries whose value did not change are skipped")
+
+	id, err := strkey.Encode(strkey.VersionByteContract, contract[:])
+	require.NoError(t, err)
+
+	assert.Equal(t, id, obs.Changes[0].Contract)
+	assert.Equal(t, "Admin", keyName(obs.Changes[0].Key))
+	assert.NotNil(t, obs.Changes[0].Before)
+	assert.Nil(t, obs.Changes[0].After, "deleted entries have no value after")
+
+	assert.Equal(t, "Counter", keyName(obs.Changes[1].Key))
+	before, _ := scValInt(*obs.Changes[1].Before)
+	after, _ := scValInt(*obs.Changes[1].After)
+	assert.Equal(t, "1", before.String(), "the request's ledger entries are the state before")
+	assert.Equal(t, "2", after.String())
+}
+
+func TestObserve_TransfersFromEvents(t *testing.T) {
+	token := xdr.ContractId{0xBB}
+	from := xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &xdr.ContractId{1}}}
+	to := xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &xdr.ContractId{2}}}
+	event := func(succeeded bool) simulator.DiagnosticEvent {
+		id := "ContractId(Hash(" + hex.EncodeToString(token[:]) + "))"
+		return simulator.DiagnosticEvent{
+			EventType:                "contract",
+			ContractID:               &id,
+			TopicsXDR:                []string{encodeVal(t, sym("transfer")), encodeVal(t, from), encodeVal(t, to)},
+			DataXDR:                  encodeVal(t, i128(250)),
+			InSuccessfulContractCall: succeeded,
+		}
+	}
+	resp := &simulator.SimulationResponse{
+		DiagnosticEvents: []simulator.DiagnosticEvent{event(true), event(false)},
+	}
+
+	obs, err := Observe(&simulator.SimulationRequest{}, resp)
+	require.NoError(t, err)
+	require.Len(t, obs.Transfers, 1, "transfers of reverted calls are skipped")
+
+	id, err := strkey.Encode(strkey.VersionByteContract, token[:])
+	require.NoError(t, err)
+	assert.Equal(t, id, obs.Transfers[0].Token.ID)
+	assert.Equal(t, "250", obs.Transfers[0].Amount.String())
+}
+
+func TestCheck_UndecodableChangesStillChecksEvents(t *testing.T) {
+	set := &Set{Invariants: []Invariant{{Name: "no panics", Type: TypeForbiddenEvent, Topic: "panic"}}}
+	resp := &simulator.SimulationResponse{
+		DiagnosticEvents: []simulator.DiagnosticEvent{{Topics: []string{"Sym(panic)"}}},
+		LedgerChanges:    []simulator.LedgerChange{{Key: "AAAAAQ==", Entry: "AAAAAQ=="}},
+	}
+
+	violations := set.Check(&simulator.SimulationRequest{}, resp)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "no panics", violations[0].Invariant)
+}
+
+func TestFuzzSpec_BalanceInvariant(t *testing.T) {
+	spec := &abi.ContractSpec{
+		Functions: []xdr.ScSpecFunctionV0{{
+			Name: "withdraw",
+			Inputs: []xdr.ScSpecFunctionInputV0{
+				{Name: "amount", Type: xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeU32}},
+			},
+		}},
+	}
+	m, err := simulator.NewSpecMutator(spec, "withdraw")
+	require.NoError(t, err)
+
+	// The contract withdraws from a balance of 100 without checking it.
+	balance := func(amount int64) xdr.LedgerEntry {
+		return contractDataEntry(xdr.ContractId{0xCC}, vec(sym("Balance"), sym("alice")), i128(100-amount))
+	}
+	runner := simulator.NewMockRunner(func(ctx context.Context, req *simulator.SimulationRequest) (*simulator.SimulationResponse, error) {
+		var env xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(req.EnvelopeXdr, &env))
+		amount := int64(*env.V1.Tx.Operations[0].Body.InvokeHostFunctionOp.HostFunction.InvokeContract.Args[0].U32)
+		entry := balance(amount)
+		return &simulator.SimulationResponse{
+			Status:        "success",
+			LedgerChanges: []simulator.LedgerChange{{Key: encodedKey(t, entry), Entry: encode(t, entry)}},
+		}, nil
+	})
+
+	set, err := Parse([]byte(`{"invariants": [{"name": "solvent", "type": "non_negative", "key": "Balance"}]}`))
+	require.NoError(t, err)
+
+	harness := simulator.NewFuzzingHarness(runner, simulator.FuzzingConfig{MaxIterations: 50})
+	harness.Invariants = set
+	target := simulator.SpecFuzzTarget{Wasm: []byte("\x00asm\x01\x00\x00\x00"), Mutator: m}
+	_, crashes, err := harness.FuzzSpec(target, 1)
+	require.NoError(t, err)
+	assert.Empty(t, crashes)
+
+	require.Len(t, harness.ViolatingInputs, 1)
+	args, err := simulator.DecodeScVals(harness.ViolatingInputs[0].Args)
+	require.NoError(t, err)
+	assert.Equal(t, xdr.Uint32(101), *args[0].U32, "the reproducer is shrunk to the smallest overdraft")
+}
+
+// -------------------- Helpers --------------------
+
+func sym(s string) xdr.ScVal {
+	v := xdr.ScSymbol(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &v}
+}
+
+func vec(items ...xdr.ScVal) xdr.ScVal {
+	v := xdr.ScVec(items)
+	p := &v
+	return xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &p}
+}
+
+func i128(n int64) xdr.ScVal {
+	hi := xdr.Int64(0)
+	if n < 0 {
+		hi = -1
+	}
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Hi: hi, Lo: xdr.Uint64(uint64(n))}}
+}
+
+func ptr(v xdr.ScVal) *xdr.ScVal { return &v }
+
+func ptrBool(b bool) *bool { return &b }
+
+func ptrMap(m xdr.ScMap) **xdr.ScMap {
+	p := &m
+	return &p
+}
+
+func contractDataEntry(contract xdr.ContractId, key, val xdr.ScVal) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contract},
+				Key:        key,
+				Durability: xdr.ContractDataDurabilityPersistent,
+				Val:        val,
+			},
+		},
+	}
+}
+
+func encode(t *testing.T, entry xdr.LedgerEntry) string {
+	t.Helper()
+	encoded, err := xdr.MarshalBase64(entry)
+	require.NoError(t, err)
+	return encoded
+}
+
+func encodedKey(t *testing.T, entry xdr.LedgerEntry) string {
+	t.Helper()
+	key, err := entry.LedgerKey()
+	require.NoError(t, err)
+	encoded, err := xdr.MarshalBase64(key)
+	require.NoError(t, err)
+	return encoded
+}
+
+func encodeVal(t *testing.T, v xdr.ScVal) string {
+	t.Helper()
+	encoded, err := xdr.MarshalBase64(v)
+	require.NoError(t, err)
+	return encoded
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package invariant
+
+import (
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/decoder"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/tokenflow"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// EntryChange is a contract data entry modified by an execution.
+type EntryChange struct {
+	Contract string
+	Key      xdr.ScVal
+	Before   *xdr.ScVal // nil when the entry was created
+	After    *xdr.ScVal // nil when the entry was deleted
+}
+
+// Observation is what invariants can inspect about one execution.
+type Observation struct {
+	Changes   []EntryChange
+	Transfers []tokenflow.Transfer
+	Events    []simulator.DiagnosticEvent
+}
+
+// Observe collects the ledger changes, token movements and events of resp,
+// the simulation of req. Ledger changes are the entries the simulation wrote,
+// with the request's ledger entries as the state before execution. Transfers
+// are the native payments of the envelope and the SAC transfers and mints the
+// simulation emitted. The returned observation always holds the response
+// events, even when the changes cannot be decoded and an error is returned.
+func Observe(req *simulator.SimulationRequest, resp *simulator.SimulationResponse) (*Observation, error) {
+	obs := &Observation{}
+	if resp == nil {
+		return obs, nil
+	}
+	obs.Events = resp.DiagnosticEvents
+
+	var entries map[string]string
+	if req != nil {
+		entries = req.LedgerEntries
+	}
+	changes, err := ledgerChanges(entries, resp.LedgerChanges)
+	if err != nil {
+		return obs, err
+	}
+	obs.Changes = changes
+
+	if req != nil && req.EnvelopeXdr != "" {
+		report, err := tokenflow.BuildReport(req.EnvelopeXdr, "")
+		if err != nil {
+			return obs, fmt.Errorf("failed to extract transfers: %w", err)
+		}
+		obs.Transfers = report.Raw
+	}
+	obs.Transfers = append(obs.Transfers, tokenflow.TransfersFromEvents(contractEvents(resp.DiagnosticEvents))...)
+	return obs, nil
+}
+
+// ledgerChanges compares the entries the simulation wrote with the entries
+// known before execution and returns the contract data entries whose value
+// differs.
+func ledgerChanges(entries map[string]string, written []simulator.LedgerChange) ([]EntryChange, error) {
+	var out []EntryChange
+	for _, w := range written {
+		prior, err := decodeEntry(entries[w.Key])
+		if err != nil {
+			return nil, err
+		}
+		written, err := decodeEntry(w.Entry)
+		if err != nil {
+			return nil, err
+		}
+		b, a := contractData(prior), contractData(written)
+		if b == nil && a == nil {
+			continue
+		}
+		change := EntryChange{}
+		data := a
+		if data == nil {
+			data = b
+		}
+		contract, err := contractAddress(data.Contract)
+		if err != nil {
+			return nil, err
+		}
+		change.Contract = contract
+		change.Key = data.Key
+		if b != nil {
+			val := b.Val
+			change.Before = &val
+		}
+		if a != nil {
+			val := a.Val
+			change.After = &val
+		}
+		if change.Before != nil && change.After != nil && scValEqual(*change.Before, *change.After) {
+			continue
+		}
+		out = append(out, change)
+	}
+	return out, nil
+}
+
+// contractEvents converts the contract events erst-sim reported with their
+// XDR back to diagnostic events. Events without XDR are skipped.
+func contractEvents(events []simulator.DiagnosticEvent) []xdr.DiagnosticEvent {
+	var out []xdr.DiagnosticEvent
+	for _, e := range events {
+		if e.EventType != "contract" || e.ContractID == nil || e.DataXDR == "" {
+			continue
+		}
+		raw, err := strkey.Decode(strkey.VersionByteContract, decoder.NormalizeContractID(*e.ContractID))
+		if err != nil || len(raw) != 32 {
+			continue
+		}
+		topics, data, err := decoder.DecodeEventValues(e.TopicsXDR, e.DataXDR)
+		if err != nil {
+			continue
+		}
+		var id xdr.ContractId
+		copy(id[:], raw)
+		out = append(out, xdr.DiagnosticEvent{
+			InSuccessfulContractCall: e.InSuccessfulContractCall,
+			Event: xdr.ContractEvent{
+				ContractId: &id,
+				Type:       xdr.ContractEventTypeContract,
+				Body: xdr.ContractEventBody{
+					V:  0,
+					V0: &xdr.ContractEventV0{Topics: topics, Data: data},
+				},
+			},
+		})
+	}
+	return out
+}
+
+func decodeEntry(encoded string) (*xdr.LedgerEntry, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	var entry xdr.LedgerEntry
+	if err := xdr.SafeUnmarshalBase64(encoded, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func contractData(entry *xdr.LedgerEntry) *xdr.ContractDataEntry {
+	if entry == nil {
+		return nil
+	}
+	return entry.Data.ContractData
+}
+
+func contractAddress(addr xdr.ScAddress) (string, error) {
+	if addr.Type != xdr.ScAddressTypeScAddressTypeContract || addr.ContractId == nil {
+		return "", nil
+	}
+	id, err := strkey.Encode(strkey.VersionByteContract, addr.ContractId[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to encode contract address: %w", err)
+	}
+	return id, nil
+}
+
+func scValEqual(a, b xdr.ScVal) bool {
+	ea, errA := a.MarshalBinary()
+	eb, errB := b.MarshalBinary()
+	return errA == nil && errB == nil && string(ea) == string(eb)
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package ipc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MaxFrameSize bounds a single framed message exchanged with a long-lived
+// erst-sim worker. It matches the stdout ceiling used for one-shot runs.
+const MaxFrameSize = 10 * 1024 * 1024
+
+// frameHeaderSize is the length of the big-endian uint32 payload length prefix.
+const frameHeaderSize = 4
+
+// WriteFrame writes payload to w prefixed with its length as a big-endian
+// uint32. Persistent erst-sim workers exchange one JSON document per frame.
+func WriteFrame(w io.Writer, payload []byte) error {
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds limit of %d bytes", len(payload), MaxFrameSize)
+	}
+
+	buf := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	copy(buf[frameHeaderSize:], payload)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadFrame reads a single length-prefixed frame from r. It returns io.EOF
+// only when the stream ends cleanly on a frame boundary.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds limit of %d bytes", size, MaxFrameSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package ipc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	messages := [][]byte{[]byte(`{"status":"success"}`), {}, []byte(`{"status":"error"}`)}
+
+	for _, m := range messages {
+		if err := WriteFrame(&buf, m); err != nil {
+			t.Fatalf("WriteFrame() error = %v", err)
+		}
+	}
+
+	for i, want := range messages {
+		got, err := ReadFrame(&buf)
+		if err != nil {
+			t.Fatalf("ReadFrame() #%d error = %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFrame() #%d = %q, want %q", i, got, want)
+		}
+	}
+
+	if _, err := ReadFrame(&buf); err != io.EOF {
+		t.Errorf("ReadFrame() on drained stream error = %v, want io.EOF", err)
+	}
+}
+
+func TestReadFrameTruncatedPayload(t *testing.T) {
+	var buf bytes.Buffer
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, 10)
+	buf.Write(header)
+	buf.WriteString("short")
+
+	if _, err := ReadFrame(&buf); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadFrame() error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestReadFrameRejectsOversizedFrame(t *testing.T) {
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, MaxFrameSize+1)
+
+	if _, err := ReadFrame(bytes.NewReader(header)); err == nil {
+		t.Error("ReadFrame() expected error for oversized frame")
+	}
+}
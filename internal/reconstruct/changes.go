@@ -0,0 +1,168 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package reconstruct
+
+import (
+	"encoding/hex"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// applyChanges returns the ledger entry changes of a transaction's
+// application, in the order they were made.
+func applyChanges(txMeta xdr.TransactionMeta) xdr.LedgerEntryChanges {
+	var all xdr.LedgerEntryChanges
+	add := func(changes xdr.LedgerEntryChanges) { all = append(all, changes...) }
+	addOps := func(ops []xdr.OperationMeta) {
+		for _, op := range ops {
+			add(op.Changes)
+		}
+	}
+
+	switch txMeta.V {
+	case 0:
+		if txMeta.Operations != nil {
+			addOps(*txMeta.Operations)
+		}
+	case 1:
+		if v1 := txMeta.V1; v1 != nil {
+			add(v1.TxChanges)
+			addOps(v1.Operations)
+		}
+	case 2:
+		if v2 := txMeta.V2; v2 != nil {
+			add(v2.TxChangesBefore)
+			addOps(v2.Operations)
+			add(v2.TxChangesAfter)
+		}
+	case 3:
+		if v3 := txMeta.V3; v3 != nil {
+			add(v3.TxChangesBefore)
+			addOps(v3.Operations)
+			add(v3.TxChangesAfter)
+		}
+	case 4:
+		if v4 := txMeta.V4; v4 != nil {
+			add(v4.TxChangesBefore)
+			for _, op := range v4.Operations {
+				add(op.Changes)
+			}
+			add(v4.TxChangesAfter)
+		}
+	}
+	return all
+}
+
+// undo returns the state of every entry changes touched before the first of
+// them, keyed by base64 LedgerKey. It walks the changes last to first: the
+// STATE (or RESTORED) change that precedes each update or removal holds the
+// entry's prior value, and an entry the changes created did not exist. A nil
+// value marks an entry that did not exist.
+func undo(changes xdr.LedgerEntryChanges) map[string]*xdr.LedgerEntry {
+	prior := make(map[string]*xdr.LedgerEntry)
+	for i := len(changes) - 1; i >= 0; i-- {
+		key, value, ok := priorState(changes[i])
+		if ok {
+			prior[key] = value
+		}
+	}
+	return prior
+}
+
+// priorValues is like undo but only keeps, for each entry, what its first
+// change tells about its prior state. A first change that does not record it
+// leaves the entry out.
+func priorValues(changes xdr.LedgerEntryChanges) map[string]*xdr.LedgerEntry {
+	prior := make(map[string]*xdr.LedgerEntry)
+	touched := make(map[string]bool)
+	for i := range changes {
+		key, err := encodeKey(changes[i])
+		if err != nil || touched[key] {
+			continue
+		}
+		touched[key] = true
+		if _, value, ok := priorState(changes[i]); ok {
+			prior[key] = value
+		}
+	}
+	return prior
+}
+
+// lastValues returns the state of every entry changes touched after the last
+// of them, keyed by base64 LedgerKey. A nil value marks a removed entry.
+func lastValues(changes xdr.LedgerEntryChanges) map[string]*xdr.LedgerEntry {
+	last := make(map[string]*xdr.LedgerEntry)
+	for i := range changes {
+		key, err := encodeKey(changes[i])
+		if err != nil {
+			continue
+		}
+		if changes[i].Type == xdr.LedgerEntryChangeTypeLedgerEntryRemoved {
+			last[key] = nil
+			continue
+		}
+		if value, ok := changes[i].GetLedgerEntry(); ok {
+			last[key] = &value
+		}
+	}
+	return last
+}
+
+// priorState returns what a change records about the state of its entry
+// before it was made.
+func priorState(change xdr.LedgerEntryChange) (string, *xdr.LedgerEntry, bool) {
+	key, err := encodeKey(change)
+	if err != nil {
+		return "", nil, false
+	}
+	switch change.Type {
+	case xdr.LedgerEntryChangeTypeLedgerEntryState:
+		value := change.MustState()
+		return key, &value, true
+	case xdr.LedgerEntryChangeTypeLedgerEntryRestored:
+		value := change.MustRestored()
+		return key, &value, true
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		return key, nil, true
+	}
+	return "", nil, false
+}
+
+func encodeKey(change xdr.LedgerEntryChange) (string, error) {
+	key, err := change.LedgerKey()
+	if err != nil {
+		return "", err
+	}
+	return xdr.MarshalBase64(key)
+}
+
+// FootprintKeys returns the keys of a Soroban transaction's footprint,
+// read-only first.
+func FootprintKeys(env xdr.TransactionEnvelope) []xdr.LedgerKey {
+	var ext xdr.TransactionExt
+	switch env.Type {
+	case xdr.EnvelopeTypeEnvelopeTypeTx:
+		if env.V1 == nil {
+			return nil
+		}
+		ext = env.V1.Tx.Ext
+	case xdr.EnvelopeTypeEnvelopeTypeTxFeeBump:
+		if env.FeeBump == nil || env.FeeBump.Tx.InnerTx.V1 == nil {
+			return nil
+		}
+		ext = env.FeeBump.Tx.InnerTx.V1.Tx.Ext
+	default:
+		return nil
+	}
+	data, ok := ext.GetSorobanData()
+	if !ok {
+		return nil
+	}
+	footprint := data.Resources.Footprint
+	return append(append([]xdr.LedgerKey{}, footprint.ReadOnly...), footprint.ReadWrite...)
+}
+
+func hexHash(h xdr.Hash) string {
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package reconstruct
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// ErrLedgerNotFound is returned by a LedgerMetaSource that does not hold the
+// requested ledger.
+var ErrLedgerNotFound = errors.New("ledger not found")
+
+// LedgerMetaSource serves the close meta of ledgers.
+type LedgerMetaSource interface {
+	LedgerCloseMeta(ctx context.Context, seq uint32) (*xdr.LedgerCloseMeta, error)
+}
+
+// metaFileName matches the files a MetaDir reads: "<seq>.xdr" holding one
+// LedgerCloseMeta, or a galexie-style "<prefix>--<start>[-<end>].xdr"
+// holding a LedgerCloseMetaBatch, optionally gzip compressed.
+var metaFileName = regexp.MustCompile(`^(?:([0-9A-Fa-f]+)--)?(\d+)(?:-(\d+))?\.xdr(\.gz)?$`)
+
+type metaFile struct {
+	start, end uint32
+	path       string
+	batch      bool
+	gzipped    bool
+}
+
+// MetaDir is a LedgerMetaSource reading a local mirror of ledger close meta,
+// such as a directory exported by galexie or written by hand with one file
+// per ledger. Files are found recursively by name; zstd compressed exports
+// must be decompressed first. It is safe for concurrent use.
+type MetaDir struct {
+	files []metaFile // sorted by start
+
+	mu        sync.Mutex
+	lastPath  string
+	lastBatch []xdr.LedgerCloseMeta
+}
+
+// OpenMetaDir indexes the ledger close meta files under dir.
+func OpenMetaDir(dir string) (*MetaDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger meta directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("ledger meta path %s is not a directory", dir)
+	}
+
+	m := &MetaDir{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		match := metaFileName.FindStringSubmatch(d.Name())
+		if match == nil {
+			return nil
+		}
+		start, err := strconv.ParseUint(match[2], 10, 32)
+		if err != nil {
+			return nil
+		}
+		end := start
+		if match[3] != "" {
+			if end, err = strconv.ParseUint(match[3], 10, 32); err != nil || end < start {
+				return nil
+			}
+		}
+		m.files = append(m.files, metaFile{
+			start:   uint32(start),
+			end:     uint32(end),
+			path:    path,
+			batch:   match[1] != "" || match[3] != "",
+			gzipped: match[4] != "",
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index ledger meta directory: %w", err)
+	}
+	sort.Slice(m.files, func(i, j int) bool { return m.files[i].start < m.files[j].start })
+	return m, nil
+}
+
+// Ledgers returns the number of ledgers the directory holds.
+func (m *MetaDir) Ledgers() int {
+	n := 0
+	for _, f := range m.files {
+		n += int(f.end-f.start) + 1
+	}
+	return n
+}
+
+// LedgerCloseMeta returns the close meta of ledger seq.
+func (m *MetaDir) LedgerCloseMeta(ctx context.Context, seq uint32) (*xdr.LedgerCloseMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(m.files), func(i int) bool { return m.files[i].end >= seq })
+	if i == len(m.files) || m.files[i].start > seq {
+		return nil, fmt.Errorf("%w: %d", ErrLedgerNotFound, seq)
+	}
+	f := m.files[i]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lastPath != f.path {
+		metas, err := readMetaFile(f)
+		if err != nil {
+			return nil, err
+		}
+		m.lastPath, m.lastBatch = f.path, metas
+	}
+	for i := range m.lastBatch {
+		if m.lastBatch[i].LedgerSequence() == seq {
+			lcm := m.lastBatch[i]
+			return &lcm, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrLedgerNotFound, seq)
+}
+
+func readMetaFile(f metaFile) ([]xdr.LedgerCloseMeta, error) {
+	raw, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger meta file: %w", err)
+	}
+	if f.gzipped {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", f.path, err)
+		}
+		defer zr.Close()
+		if raw, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", f.path, err)
+		}
+	}
+
+	if f.batch {
+		var batch xdr.LedgerCloseMetaBatch
+		if err := xdr.SafeUnmarshal(raw, &batch); err != nil {
+			return nil, fmt.Errorf("failed to decode ledger meta batch %s: %w", f.path, err)
+		}
+		return batch.LedgerCloseMetas, nil
+	}
+	var lcm xdr.LedgerCloseMeta
+	if err := xdr.SafeUnmarshal(raw, &lcm); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger meta %s: %w", f.path, err)
+	}
+	return []xdr.LedgerCloseMeta{lcm}, nil
+}
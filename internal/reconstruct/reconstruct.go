@@ -0,0 +1,349 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package reconstruct rebuilds the ledger state a transaction saw when it was
+// applied. Current ledger state may have changed since; the transaction's
+// own meta and the close meta of the surrounding ledgers record the values
+// the entries held at the time.
+package reconstruct
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// Accuracy tells how far a reconstructed entry can be trusted.
+type Accuracy string
+
+const (
+	// Exact entries hold the value the transaction saw, as recorded by
+	// ledger meta.
+	Exact Accuracy = "exact"
+	// Approximate entries hold the current value, which may differ from the
+	// one the transaction saw if the entry changed since.
+	Approximate Accuracy = "approximate"
+)
+
+// Entry is the state of one ledger entry before the transaction executed.
+type Entry struct {
+	Key      string // base64 LedgerKey
+	Entry    string // base64 LedgerEntry, empty when the entry did not exist
+	Accuracy Accuracy
+	Source   string // where the value was read, such as "transaction meta"
+}
+
+// State is the ledger state before a transaction executed.
+type State struct {
+	Ledger  uint32 // the ledger the transaction was applied in
+	Entries []Entry
+}
+
+// LedgerEntries returns the entries that existed before the transaction,
+// keyed by base64 LedgerKey, as expected by a SimulationRequest.
+func (s *State) LedgerEntries() map[string]string {
+	entries := make(map[string]string, len(s.Entries))
+	for _, e := range s.Entries {
+		if e.Entry != "" {
+			entries[e.Key] = e.Entry
+		}
+	}
+	return entries
+}
+
+// Approximated returns the entries whose value could not be confirmed.
+func (s *State) Approximated() []Entry {
+	var out []Entry
+	for _, e := range s.Entries {
+		if e.Accuracy == Approximate {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Summary describes the reconstruction in one line.
+func (s *State) Summary() string {
+	approximated := len(s.Approximated())
+	return fmt.Sprintf("%d ledger entries at ledger %d: %d exact, %d approximated",
+		len(s.Entries), s.Ledger, len(s.Entries)-approximated, approximated)
+}
+
+// StateProvider supplies current ledger entries for the keys that ledger
+// meta does not cover. rpc.LedgerStateProvider satisfies it.
+type StateProvider interface {
+	GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// Transaction identifies the transaction whose state is reconstructed.
+type Transaction struct {
+	Hash          string // hex transaction hash
+	Ledger        uint32 // the ledger the transaction was applied in; 0 if unknown
+	EnvelopeXDR   string
+	ResultMetaXDR string // may be empty, as for transactions read from a history archive
+}
+
+// Options configure a reconstruction.
+type Options struct {
+	// Current serves the entries that ledger meta does not cover. It may be
+	// nil, in which case such entries are reported missing.
+	Current StateProvider
+	// Ledgers serves ledger close meta from a local mirror. It may be nil.
+	Ledgers LedgerMetaSource
+	// MaxLedgers bounds how many ledgers are walked on each side of the
+	// transaction's ledger. Zero uses DefaultMaxLedgers.
+	MaxLedgers int
+}
+
+// DefaultMaxLedgers is the default number of ledgers walked on each side of
+// the transaction's ledger.
+const DefaultMaxLedgers = 128
+
+// Reconstruct returns the state of every entry the transaction read or
+// wrote, as it was when the transaction executed.
+//
+// Entries the transaction changed are recovered by undoing the changes of
+// its meta, last to first; each change carries the entry's prior state.
+// Entries it only read, such as contract code and read-only footprint
+// entries, are looked up in the close meta of Ledgers: the last change
+// before the transaction in its ledger or earlier ones gives the value it
+// saw, as does the prior state recorded by the first change after it. The
+// remaining entries are read from Current and reported as approximate.
+//
+// Without any meta for the transaction, such as for one read from a history
+// archive, only its footprint is known and every entry is approximate.
+func Reconstruct(ctx context.Context, tx Transaction, opts Options) (*State, error) {
+	if opts.MaxLedgers <= 0 {
+		opts.MaxLedgers = DefaultMaxLedgers
+	}
+
+	var env xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(tx.EnvelopeXDR, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction envelope: %w", err)
+	}
+
+	var ledger *xdr.LedgerCloseMeta
+	txIndex := -1
+	if opts.Ledgers != nil && tx.Ledger != 0 {
+		lcm, err := opts.Ledgers.LedgerCloseMeta(ctx, tx.Ledger)
+		switch {
+		case err == nil:
+			ledger = lcm
+			txIndex = findTransaction(*lcm, tx.Hash)
+		case !errors.Is(err, ErrLedgerNotFound):
+			return nil, err
+		}
+	}
+
+	var fees xdr.LedgerEntryChanges
+	var apply xdr.LedgerEntryChanges
+	switch {
+	case tx.ResultMetaXDR != "":
+		var meta xdr.TransactionResultMeta
+		if err := xdr.SafeUnmarshalBase64(tx.ResultMetaXDR, &meta); err != nil {
+			return nil, fmt.Errorf("failed to decode result meta: %w", err)
+		}
+		fees, apply = meta.FeeProcessing, applyChanges(meta.TxApplyProcessing)
+	case txIndex >= 0:
+		fees, apply = ledger.FeeProcessing(txIndex), applyChanges(ledger.TxApplyProcessing(txIndex))
+	}
+
+	keys := newKeySet()
+	for _, key := range FootprintKeys(env) {
+		keys.add(key)
+	}
+	for _, changes := range []xdr.LedgerEntryChanges{fees, apply} {
+		for i := range changes {
+			if key, err := changes[i].LedgerKey(); err == nil {
+				keys.add(key)
+			}
+		}
+	}
+
+	state := &State{Ledger: tx.Ledger}
+	resolved := make(map[string]Entry)
+
+	// The fee was charged before the transaction applied, so an entry only
+	// the fee touched holds its value after fee processing.
+	for key, value := range lastValues(fees) {
+		resolved[key] = entry(key, value, Exact, "fee processing meta")
+	}
+	for key, value := range undo(apply) {
+		resolved[key] = entry(key, value, Exact, "transaction meta")
+	}
+
+	if ledger != nil && txIndex >= 0 {
+		walk(ctx, opts, *ledger, txIndex, keys.missing(resolved), resolved)
+	}
+
+	if missing := keys.missing(resolved); len(missing) > 0 && opts.Current != nil {
+		current, err := opts.Current.GetLedgerEntries(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch current ledger entries: %w", err)
+		}
+		for _, key := range missing {
+			resolved[key] = Entry{Key: key, Entry: current[key], Accuracy: Approximate, Source: "current state"}
+		}
+	}
+
+	for _, key := range keys.order {
+		e, ok := resolved[key]
+		if !ok {
+			e = Entry{Key: key, Accuracy: Approximate, Source: "unavailable"}
+		}
+		state.Entries = append(state.Entries, e)
+	}
+	return state, nil
+}
+
+// walk resolves keys from the close meta of the transaction's ledger and its
+// neighbours. Earlier ledgers are walked backwards for the last value set
+// before the transaction, and later ones forwards for the prior state
+// recorded by the first change after it.
+func walk(ctx context.Context, opts Options, ledger xdr.LedgerCloseMeta, txIndex int, keys []string, resolved map[string]Entry) {
+	if len(keys) == 0 {
+		return
+	}
+	pending := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		pending[key] = true
+	}
+	resolve := func(values map[string]*xdr.LedgerEntry, source string) {
+		for key, value := range values {
+			if pending[key] {
+				delete(pending, key)
+				resolved[key] = entry(key, value, Exact, source)
+			}
+		}
+	}
+
+	seq := ledger.LedgerSequence()
+	source := fmt.Sprintf("ledger %d meta", seq)
+	resolve(lastValues(ledgerChanges(ledger, 0, txIndex)), source)
+	resolve(priorValues(ledgerApplyChanges(ledger, txIndex+1)), source)
+
+	for i := 1; i <= opts.MaxLedgers && len(pending) > 0 && seq > uint32(i); i++ {
+		lcm, err := opts.Ledgers.LedgerCloseMeta(ctx, seq-uint32(i))
+		if err != nil {
+			break
+		}
+		resolve(lastValues(ledgerChanges(*lcm, 0, lcm.CountTransactions())), fmt.Sprintf("ledger %d meta", seq-uint32(i)))
+	}
+	for i := 1; i <= opts.MaxLedgers && len(pending) > 0; i++ {
+		lcm, err := opts.Ledgers.LedgerCloseMeta(ctx, seq+uint32(i))
+		if err != nil {
+			break
+		}
+		resolve(priorValues(ledgerChanges(*lcm, 0, lcm.CountTransactions())), fmt.Sprintf("ledger %d meta", seq+uint32(i)))
+	}
+}
+
+// AtLedger returns the value keys held when ledger seq closed, recovered
+// from the close meta of the ledgers after it up to and including through:
+// the first change to an entry after seq records its prior state. Entries
+// that did not exist yet have an empty Entry. Keys that no walked ledger
+// changes are left out, as are all keys from the first ledger the source does
+// not hold.
+func AtLedger(ctx context.Context, ledgers LedgerMetaSource, seq, through uint32, keys []string) (map[string]Entry, error) {
+	resolved := make(map[string]Entry)
+	pending := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		pending[key] = true
+	}
+
+	for next := seq + 1; next <= through && len(pending) > 0; next++ {
+		lcm, err := ledgers.LedgerCloseMeta(ctx, next)
+		if errors.Is(err, ErrLedgerNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		source := fmt.Sprintf("ledger %d meta", next)
+		for key, value := range priorValues(ledgerChanges(*lcm, 0, lcm.CountTransactions())) {
+			if pending[key] {
+				delete(pending, key)
+				resolved[key] = entry(key, value, Exact, source)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// ledgerChanges returns the changes a ledger made before applying its
+// transaction at index end, in order: every transaction's fee processing,
+// then the application of transactions start to end.
+func ledgerChanges(lcm xdr.LedgerCloseMeta, start, end int) xdr.LedgerEntryChanges {
+	var all xdr.LedgerEntryChanges
+	for i := 0; i < lcm.CountTransactions(); i++ {
+		all = append(all, lcm.FeeProcessing(i)...)
+	}
+	for i := start; i < end; i++ {
+		all = append(all, applyChanges(lcm.TxApplyProcessing(i))...)
+	}
+	return all
+}
+
+// ledgerApplyChanges returns the changes of the transactions a ledger
+// applied from index start on.
+func ledgerApplyChanges(lcm xdr.LedgerCloseMeta, start int) xdr.LedgerEntryChanges {
+	var all xdr.LedgerEntryChanges
+	for i := start; i < lcm.CountTransactions(); i++ {
+		all = append(all, applyChanges(lcm.TxApplyProcessing(i))...)
+	}
+	return all
+}
+
+// findTransaction returns the apply index of the transaction with the given
+// hex hash, or -1.
+func findTransaction(lcm xdr.LedgerCloseMeta, hash string) int {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	for i := 0; i < lcm.CountTransactions(); i++ {
+		if h := lcm.TransactionHash(i); hexHash(h) == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+func entry(key string, value *xdr.LedgerEntry, accuracy Accuracy, source string) Entry {
+	e := Entry{Key: key, Accuracy: accuracy, Source: source}
+	if value != nil {
+		if encoded, err := xdr.MarshalBase64(*value); err == nil {
+			e.Entry = encoded
+		}
+	}
+	return e
+}
+
+// keySet is an ordered set of base64 ledger keys.
+type keySet struct {
+	order []string
+	seen  map[string]bool
+}
+
+func newKeySet() *keySet {
+	return &keySet{seen: make(map[string]bool)}
+}
+
+func (s *keySet) add(key xdr.LedgerKey) {
+	encoded, err := xdr.MarshalBase64(key)
+	if err != nil || s.seen[encoded] {
+		return
+	}
+	s.seen[encoded] = true
+	s.order = append(s.order, encoded)
+}
+
+func (s *keySet) missing(resolved map[string]Entry) []string {
+	var out []string
+	for _, key := range s.order {
+		if _, ok := resolved[key]; !ok {
+			out = append(out, key)
+		}
+	}
+	return out
+}
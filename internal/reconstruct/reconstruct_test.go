@@ -0,0 +1,317 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package reconstruct
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const txLedger = 100
+
+var (
+	contract = xdr.ContractId{0xAA}
+	txHash   = xdr.Hash{0x01}
+	otherTx  = xdr.Hash{0x02}
+)
+
+func dataEntry(name string, value uint32) xdr.LedgerEntry {
+	sym := xdr.ScSymbol(name)
+	v := xdr.Uint32(value)
+	return xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.ContractDataEntry{
+			Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contract},
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+			Durability: xdr.ContractDataDurabilityPersistent,
+			Val:        xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &v},
+		},
+	}}
+}
+
+func accountEntry(balance int64) xdr.LedgerEntry {
+	var key xdr.Uint256
+	return xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeAccount,
+		Account: &xdr.AccountEntry{
+			AccountId: xdr.AccountId(xdr.PublicKey{Type: xdr.PublicKeyTypePublicKeyTypeEd25519, Ed25519: &key}),
+			Balance:   xdr.Int64(balance),
+		},
+	}}
+}
+
+func keyOf(t *testing.T, e xdr.LedgerEntry) xdr.LedgerKey {
+	t.Helper()
+	key, err := e.LedgerKey()
+	require.NoError(t, err)
+	return key
+}
+
+func encode(t *testing.T, v interface{}) string {
+	t.Helper()
+	s, err := xdr.MarshalBase64(v)
+	require.NoError(t, err)
+	return s
+}
+
+func state(e xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &e}
+}
+
+func updated(e xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &e}
+}
+
+func created(e xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &e}
+}
+
+func txMeta(changes ...xdr.LedgerEntryChange) xdr.TransactionMeta {
+	return xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+		Operations: []xdr.OperationMeta{{Changes: changes}},
+	}}
+}
+
+func resultMeta(hash xdr.Hash, fees xdr.LedgerEntryChanges, apply xdr.TransactionMeta) xdr.TransactionResultMeta {
+	return xdr.TransactionResultMeta{
+		Result: xdr.TransactionResultPair{
+			TransactionHash: hash,
+			Result: xdr.TransactionResult{Result: xdr.TransactionResultResult{
+				Code:    xdr.TransactionResultCodeTxSuccess,
+				Results: &[]xdr.OperationResult{},
+			}},
+		},
+		FeeProcessing:     fees,
+		TxApplyProcessing: apply,
+	}
+}
+
+func ledgerMeta(seq uint32, txs ...xdr.TransactionResultMeta) xdr.LedgerCloseMeta {
+	return xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{
+		LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(seq)}},
+		TxSet:        xdr.GeneralizedTransactionSet{V: 1, V1TxSet: &xdr.TransactionSetV1{}},
+		TxProcessing: txs,
+	}}
+}
+
+func envelope(readOnly, readWrite []xdr.LedgerKey) xdr.TransactionEnvelope {
+	var source xdr.Uint256
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{Tx: xdr.Transaction{
+			SourceAccount: xdr.MuxedAccount{Type: xdr.CryptoKeyTypeKeyTypeEd25519, Ed25519: &source},
+			Cond:          xdr.Preconditions{Type: xdr.PreconditionTypePrecondNone},
+			Memo:          xdr.Memo{Type: xdr.MemoTypeMemoNone},
+			Ext: xdr.TransactionExt{V: 1, SorobanData: &xdr.SorobanTransactionData{
+				Resources: xdr.SorobanResources{Footprint: xdr.LedgerFootprint{ReadOnly: readOnly, ReadWrite: readWrite}},
+			}},
+		}},
+	}
+}
+
+type staticState map[string]string
+
+func (s staticState) GetLedgerEntries(_ context.Context, keys []string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := s[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// fixture is a transaction that pays its fee from an account, updates
+// "counter", creates "receipt" and reads "config", "price" and "admin".
+// "config" was last set in the previous ledger, "price" is next changed in
+// the following ledger and "admin" is in no ledger of the mirror.
+type fixture struct {
+	tx      Transaction
+	current staticState
+	ledgers []xdr.LedgerCloseMeta
+	keys    map[string]string // entry name -> base64 key
+	want    map[string]string // entry name -> base64 entry seen by the transaction
+}
+
+func newFixture(t *testing.T) fixture {
+	account, accountCharged := accountEntry(100), accountEntry(90)
+	counter, counterAfter := dataEntry("counter", 1), dataEntry("counter", 2)
+	receipt := dataEntry("receipt", 1)
+	config, configBefore := dataEntry("config", 5), dataEntry("config", 4)
+	price, priceAfter := dataEntry("price", 10), dataEntry("price", 11)
+	admin := dataEntry("admin", 1)
+
+	fees := xdr.LedgerEntryChanges{state(account), updated(accountCharged)}
+	apply := txMeta(state(counter), updated(counterAfter), created(receipt))
+	meta := resultMeta(txHash, fees, apply)
+
+	f := fixture{
+		tx: Transaction{
+			Hash:          hexHash(txHash),
+			Ledger:        txLedger,
+			EnvelopeXDR:   encode(t, envelope([]xdr.LedgerKey{keyOf(t, config), keyOf(t, price), keyOf(t, admin)}, []xdr.LedgerKey{keyOf(t, counter), keyOf(t, receipt)})),
+			ResultMetaXDR: encode(t, meta),
+		},
+		current: staticState{},
+		ledgers: []xdr.LedgerCloseMeta{
+			ledgerMeta(txLedger-1, resultMeta(otherTx, nil, txMeta(state(configBefore), updated(config)))),
+			ledgerMeta(txLedger, meta),
+			ledgerMeta(txLedger+1, resultMeta(otherTx, nil, txMeta(state(price), updated(priceAfter)))),
+		},
+		keys: make(map[string]string),
+		want: map[string]string{
+			"account": encode(t, accountCharged),
+			"counter": encode(t, counter),
+			"receipt": "",
+			"config":  encode(t, config),
+			"price":   encode(t, price),
+			"admin":   encode(t, admin),
+		},
+	}
+	for name, e := range map[string]xdr.LedgerEntry{
+		"account": account, "counter": counter, "receipt": receipt,
+		"config": config, "price": price, "admin": admin,
+	} {
+		f.keys[name] = encode(t, keyOf(t, e))
+	}
+	// Current state has moved on since the transaction.
+	f.current[f.keys["counter"]] = encode(t, counterAfter)
+	f.current[f.keys["receipt"]] = encode(t, receipt)
+	f.current[f.keys["config"]] = encode(t, dataEntry("config", 9))
+	f.current[f.keys["price"]] = encode(t, priceAfter)
+	f.current[f.keys["admin"]] = encode(t, admin)
+	return f
+}
+
+func (f fixture) byName(s *State) map[string]Entry {
+	names := make(map[string]string)
+	for name, key := range f.keys {
+		names[key] = name
+	}
+	out := make(map[string]Entry)
+	for _, e := range s.Entries {
+		out[names[e.Key]] = e
+	}
+	return out
+}
+
+func TestReconstruct_TransactionMetaOnly(t *testing.T) {
+	f := newFixture(t)
+	s, err := Reconstruct(context.Background(), f.tx, Options{Current: f.current})
+	require.NoError(t, err)
+	require.Len(t, s.Entries, 6)
+
+	entries := f.byName(s)
+	for _, name := range []string{"account", "counter", "receipt"} {
+		assert.Equal(t, Exact, entries[name].Accuracy, name)
+		assert.Equal(t, f.want[name], entries[name].Entry, name)
+	}
+	assert.Equal(t, "fee processing meta", entries["account"].Source)
+	assert.Equal(t, "transaction meta", entries["counter"].Source)
+
+	for _, name := range []string{"config", "price", "admin"} {
+		assert.Equal(t, Approximate, entries[name].Accuracy, name)
+		assert.Equal(t, f.current[f.keys[name]], entries[name].Entry, name)
+	}
+	assert.Len(t, s.Approximated(), 3)
+	assert.Equal(t, "6 ledger entries at ledger 100: 3 exact, 3 approximated", s.Summary())
+
+	ledgerEntries := s.LedgerEntries()
+	assert.Len(t, ledgerEntries, 5, "entries the transaction created are left out")
+	assert.NotContains(t, ledgerEntries, f.keys["receipt"])
+
+	// Without meta only the footprint is known.
+	f.tx.ResultMetaXDR = ""
+	s, err = Reconstruct(context.Background(), f.tx, Options{Current: f.current})
+	require.NoError(t, err)
+	assert.Len(t, s.Entries, 5)
+	assert.Len(t, s.Approximated(), 5)
+}
+
+func TestReconstruct_LedgerMirror(t *testing.T) {
+	f := newFixture(t)
+	dir := t.TempDir()
+
+	single, err := f.ledgers[0].MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "99.xdr"), single, 0o644))
+
+	batch, err := xdr.LedgerCloseMetaBatch{StartSequence: txLedger, EndSequence: txLedger + 1, LedgerCloseMetas: f.ledgers[1:]}.MarshalBinary()
+	require.NoError(t, err)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err = zw.Write(batch)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "FFFFFFFF--0-63999"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "FFFFFFFF--0-63999", "FFFFFF9B--100-101.xdr.gz"), gz.Bytes(), 0o644))
+
+	mirror, err := OpenMetaDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, mirror.Ledgers())
+	_, err = mirror.LedgerCloseMeta(context.Background(), 102)
+	assert.ErrorIs(t, err, ErrLedgerNotFound)
+
+	s, err := Reconstruct(context.Background(), f.tx, Options{Current: f.current, Ledgers: mirror})
+	require.NoError(t, err)
+	entries := f.byName(s)
+	for name, want := range f.want {
+		assert.Equal(t, want, entries[name].Entry, name)
+	}
+	assert.Equal(t, "ledger 99 meta", entries["config"].Source)
+	assert.Equal(t, "ledger 101 meta", entries["price"].Source)
+
+	approximated := s.Approximated()
+	require.Len(t, approximated, 1)
+	assert.Equal(t, f.keys["admin"], approximated[0].Key)
+
+	// Without its result meta the transaction is found in its ledger.
+	f.tx.ResultMetaXDR = ""
+	s, err = Reconstruct(context.Background(), f.tx, Options{Ledgers: mirror})
+	require.NoError(t, err)
+	assert.Equal(t, encode(t, dataEntry("counter", 1)), f.byName(s)["counter"].Entry)
+	assert.Equal(t, "unavailable", f.byName(s)["admin"].Source)
+}
+
+func TestAtLedger(t *testing.T) {
+	f := newFixture(t)
+	mirror := ledgerSlice(f.ledgers)
+
+	keys := []string{f.keys["counter"], f.keys["receipt"], f.keys["price"], f.keys["admin"]}
+	entries, err := AtLedger(context.Background(), mirror, txLedger-1, txLedger+5, keys)
+	require.NoError(t, err)
+
+	assert.Equal(t, f.want["counter"], entries[f.keys["counter"]].Entry)
+	assert.Equal(t, "ledger 100 meta", entries[f.keys["counter"]].Source)
+	require.Contains(t, entries, f.keys["receipt"])
+	assert.Empty(t, entries[f.keys["receipt"]].Entry, "created after the ledger")
+	assert.Equal(t, f.want["price"], entries[f.keys["price"]].Entry)
+	assert.Equal(t, Exact, entries[f.keys["price"]].Accuracy)
+	assert.NotContains(t, entries, f.keys["admin"])
+
+	// Ledgers after through are not walked.
+	entries, err = AtLedger(context.Background(), mirror, txLedger-1, txLedger, keys)
+	require.NoError(t, err)
+	assert.NotContains(t, entries, f.keys["price"])
+}
+
+// ledgerSlice serves ledger close meta from memory.
+type ledgerSlice []xdr.LedgerCloseMeta
+
+func (l ledgerSlice) LedgerCloseMeta(_ context.Context, seq uint32) (*xdr.LedgerCloseMeta, error) {
+	for i := range l {
+		if l[i].LedgerSequence() == seq {
+			return &l[i], nil
+		}
+	}
+	return nil, ErrLedgerNotFound
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package regress keeps an offline regression corpus of recorded
+// transactions and replays it against erst-sim without network access.
+//
+// A corpus directory looks like this:
+//
+//	manifest.json       format version, network and the recorded hashes
+//	cases/<hash>.json   envelope, result meta, ledger entries and the
+//	                    expected outcome of one transaction
+//
+// The expected outcome is the on-chain result of the transaction plus a
+// golden simulation response captured when the case was recorded. Replays
+// are diffed against the golden response with the compare engine, so any
+// change in erst-sim behavior shows up as a failing case.
+package regress
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// CorpusVersion is the corpus format written by this package. Corpora with a
+// newer version are rejected rather than misread.
+const CorpusVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	casesDir     = "cases"
+)
+
+// Manifest describes a corpus directory.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Network   string    `json:"network,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Cases     []string  `json:"cases"`
+}
+
+// Case is one recorded transaction with everything needed to replay it.
+type Case struct {
+	Hash          string            `json:"hash"`
+	Network       string            `json:"network,omitempty"`
+	EnvelopeXdr   string            `json:"envelope_xdr"`
+	ResultXdr     string            `json:"result_xdr,omitempty"`
+	ResultMetaXdr string            `json:"result_meta_xdr"`
+	LedgerEntries map[string]string `json:"ledger_entries"`
+	RecordedAt    time.Time         `json:"recorded_at"`
+	Expected      Expected          `json:"expected"`
+}
+
+// Expected is the outcome a replay of a case must reproduce.
+type Expected struct {
+	// Successful is whether the transaction succeeded on chain.
+	Successful bool `json:"successful"`
+	// ResultCode is the on-chain transaction result code, e.g. TxFailed.
+	ResultCode string `json:"result_code,omitempty"`
+	// Golden is the simulation response captured at record time. Cases
+	// without one are only checked against the on-chain outcome.
+	Golden *simulator.SimulationResponse `json:"golden,omitempty"`
+}
+
+// NewCase builds a case from a fetched transaction and the ledger entries it
+// touched. The on-chain outcome is read from resultXdr when present.
+func NewCase(hash, network, envelopeXdr, resultXdr, resultMetaXdr string, entries map[string]string) (*Case, error) {
+	c := &Case{
+		Hash:          hash,
+		Network:       network,
+		EnvelopeXdr:   envelopeXdr,
+		ResultXdr:     resultXdr,
+		ResultMetaXdr: resultMetaXdr,
+		LedgerEntries: entries,
+		RecordedAt:    time.Now().UTC(),
+	}
+	if resultXdr != "" {
+		var result xdr.TransactionResult
+		if err := xdr.SafeUnmarshalBase64(resultXdr, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction result: %w", err)
+		}
+		c.Expected.Successful = result.Successful()
+		c.Expected.ResultCode = strings.TrimPrefix(result.Result.Code.String(), "TransactionResultCode")
+	}
+	return c, nil
+}
+
+// Request returns the simulation request that replays c.
+func (c *Case) Request() *simulator.SimulationRequest {
+	return &simulator.SimulationRequest{
+		EnvelopeXdr:   c.EnvelopeXdr,
+		ResultMetaXdr: c.ResultMetaXdr,
+		LedgerEntries: c.LedgerEntries,
+	}
+}
+
+// SetGolden stores resp as the golden response of c, keeping only the fields
+// that describe behavior. Logs, coverage and timing-dependent output are
+// dropped so they cannot cause spurious drift.
+func (c *Case) SetGolden(resp *simulator.SimulationResponse) {
+	if resp == nil {
+		c.Expected.Golden = nil
+		return
+	}
+	c.Expected.Golden = &simulator.SimulationResponse{
+		Status:           resp.Status,
+		Error:            resp.Error,
+		ErrorCode:        resp.ErrorCode,
+		Events:           resp.Events,
+		DiagnosticEvents: resp.DiagnosticEvents,
+		BudgetUsage:      resp.BudgetUsage,
+		ProtocolVersion:  resp.ProtocolVersion,
+	}
+}
+
+// Corpus is a corpus directory opened for reading and writing.
+type Corpus struct {
+	Dir      string
+	Manifest Manifest
+}
+
+// CreateCorpus opens the corpus in dir, creating an empty one for network
+// when dir holds none yet.
+func CreateCorpus(dir, network string) (*Corpus, error) {
+	c, err := OpenCorpus(dir)
+	if err == nil {
+		if network != "" && c.Manifest.Network != "" && c.Manifest.Network != network {
+			return nil, fmt.Errorf("corpus %s was recorded on %s, not %s", dir, c.Manifest.Network, network)
+		}
+		return c, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, casesDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+	now := time.Now().UTC()
+	c = &Corpus{
+		Dir: dir,
+		Manifest: Manifest{
+			Version:   CorpusVersion,
+			Network:   network,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Cases:     []string{},
+		},
+	}
+	if err := c.saveManifest(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// OpenCorpus opens an existing corpus. The error satisfies os.IsNotExist
+// when dir has no manifest.
+func OpenCorpus(dir string) (*Corpus, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid corpus manifest: %w", err)
+	}
+	if manifest.Version < 1 || manifest.Version > CorpusVersion {
+		return nil, fmt.Errorf("unsupported corpus version %d (this build reads up to %d)", manifest.Version, CorpusVersion)
+	}
+	return &Corpus{Dir: dir, Manifest: manifest}, nil
+}
+
+// Put writes c to the corpus, replacing any earlier recording of the same
+// transaction.
+func (co *Corpus) Put(c *Case) error {
+	if c.Hash == "" {
+		return fmt.Errorf("case has no transaction hash")
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode case %s: %w", c.Hash, err)
+	}
+	if err := os.WriteFile(co.casePath(c.Hash), data, 0644); err != nil {
+		return fmt.Errorf("failed to write case %s: %w", c.Hash, err)
+	}
+
+	known := false
+	for _, hash := range co.Manifest.Cases {
+		if hash == c.Hash {
+			known = true
+			break
+		}
+	}
+	if !known {
+		co.Manifest.Cases = append(co.Manifest.Cases, c.Hash)
+		sort.Strings(co.Manifest.Cases)
+	}
+	co.Manifest.UpdatedAt = time.Now().UTC()
+	return co.saveManifest()
+}
+
+// Case reads the recorded case for hash.
+func (co *Corpus) Case(hash string) (*Case, error) {
+	data, err := os.ReadFile(co.casePath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read case %s: %w", hash, err)
+	}
+	var c Case
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid case %s: %w", hash, err)
+	}
+	return &c, nil
+}
+
+// Cases reads every case listed in the manifest, in hash order.
+func (co *Corpus) Cases() ([]*Case, error) {
+	cases := make([]*Case, 0, len(co.Manifest.Cases))
+	for _, hash := range co.Manifest.Cases {
+		c, err := co.Case(hash)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func (co *Corpus) casePath(hash string) string {
+	return filepath.Join(co.Dir, casesDir, hash+".json")
+}
+
+func (co *Corpus) saveManifest() error {
+	data, err := json.MarshalIndent(co.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode corpus manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(co.Dir, manifestFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write corpus manifest: %w", err)
+	}
+	return nil
+}
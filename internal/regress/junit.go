@@ -0,0 +1,110 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package regress
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// JUnit XML elements, in the subset understood by common CI systems.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes report as JUnit XML, with one test case per transaction
+// in a suite named after the corpus.
+func WriteJUnit(w io.Writer, suite string, report *Report) error {
+	js := junitSuite{
+		Name:      suite,
+		Tests:     len(report.Results),
+		Failures:  report.Failed,
+		Errors:    report.Errors,
+		Time:      seconds(report.Duration),
+		Timestamp: report.Started.UTC().Format(time.RFC3339),
+	}
+	for _, result := range report.Results {
+		jc := junitCase{
+			Name:      result.Hash,
+			ClassName: suite,
+			Time:      seconds(result.Duration),
+		}
+		switch result.Status {
+		case StatusPass:
+		case StatusFail:
+			jc.Failure = &junitProblem{Message: result.Message, Type: "drift", Body: joinDetails(result)}
+		default:
+			jc.Error = &junitProblem{Message: result.Message, Type: "error", Body: result.Message}
+		}
+		js.Cases = append(js.Cases, jc)
+	}
+
+	doc := junitSuites{
+		Tests:    js.Tests,
+		Failures: js.Failures,
+		Errors:   js.Errors,
+		Time:     js.Time,
+		Suites:   []junitSuite{js},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJUnitFile writes report as JUnit XML to path.
+func WriteJUnitFile(path, suite string, report *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create JUnit report: %w", err)
+	}
+	if err := WriteJUnit(f, suite, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
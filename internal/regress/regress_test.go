@@ -0,0 +1,161 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package regress
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func failedResultXdr(t *testing.T) string {
+	t.Helper()
+	results := []xdr.OperationResult{}
+	encoded, err := xdr.MarshalBase64(xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxFailed, Results: &results},
+	})
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestNewCase_ReadsOnChainOutcome(t *testing.T) {
+	c, err := NewCase("abc", "mainnet", "env", failedResultXdr(t), "meta", map[string]string{"k": "v"})
+	require.NoError(t, err)
+	assert.False(t, c.Expected.Successful)
+	assert.Equal(t, "TxFailed", c.Expected.ResultCode)
+
+	_, err = NewCase("abc", "mainnet", "env", "not-xdr", "meta", nil)
+	assert.Error(t, err)
+}
+
+func TestCorpus_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+	corpus, err := CreateCorpus(dir, "testnet")
+	require.NoError(t, err)
+
+	c, err := NewCase("bbb", "testnet", "env", "", "meta", map[string]string{"k": "v"})
+	require.NoError(t, err)
+	c.SetGolden(&simulator.SimulationResponse{Status: "error", Error: "trap", Logs: []string{"noise"}})
+	require.NoError(t, corpus.Put(c))
+	require.NoError(t, corpus.Put(&Case{Hash: "aaa"}))
+	require.NoError(t, corpus.Put(c), "re-recording replaces the case")
+
+	reopened, err := OpenCorpus(dir)
+	require.NoError(t, err)
+	assert.Equal(t, CorpusVersion, reopened.Manifest.Version)
+	assert.Equal(t, []string{"aaa", "bbb"}, reopened.Manifest.Cases)
+
+	cases, err := reopened.Cases()
+	require.NoError(t, err)
+	require.Len(t, cases, 2)
+	assert.Equal(t, map[string]string{"k": "v"}, cases[1].LedgerEntries)
+	assert.Equal(t, "trap", cases[1].Expected.Golden.Error)
+	assert.Empty(t, cases[1].Expected.Golden.Logs, "logs are not part of the golden response")
+
+	_, err = CreateCorpus(dir, "mainnet")
+	assert.Error(t, err, "a corpus belongs to one network")
+}
+
+func TestOpenCorpus_RejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	_, err := OpenCorpus(dir)
+	assert.True(t, os.IsNotExist(err))
+
+	manifest := fmt.Sprintf(`{"version": %d, "cases": []}`, CorpusVersion+1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(manifest), 0644))
+	_, err = OpenCorpus(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported corpus version")
+}
+
+func TestReplayer_DetectsDrift(t *testing.T) {
+	golden := &simulator.SimulationResponse{
+		Status: "error",
+		Error:  "HostError: Error(Contract, #3)",
+		Events: []string{"transfer", "fail"},
+	}
+	responses := map[string]*simulator.SimulationResponse{
+		"same":    {Status: "error", Error: golden.Error, Events: []string{"transfer", "fail"}},
+		"drifted": {Status: "error", Error: golden.Error, Events: []string{"transfer", "burn"}},
+	}
+	runner := simulator.NewMockRunner(func(ctx context.Context, req *simulator.SimulationRequest) (*simulator.SimulationResponse, error) {
+		if req.EnvelopeXdr == "broken" {
+			return nil, fmt.Errorf("erst-sim crashed")
+		}
+		resp := responses[req.EnvelopeXdr]
+		return nil, &simulator.SimulatorError{Err: fmt.Errorf("%s", resp.Error), Response: resp}
+	})
+
+	var cases []*Case
+	for _, name := range []string{"same", "drifted", "broken"} {
+		c, err := NewCase(name, "mainnet", name, failedResultXdr(t), "meta", nil)
+		require.NoError(t, err)
+		c.SetGolden(golden)
+		cases = append(cases, c)
+	}
+
+	report := (&Replayer{Runner: runner}).Run(context.Background(), cases)
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Errors)
+
+	assert.Equal(t, StatusPass, report.Results[0].Status)
+	assert.Equal(t, StatusFail, report.Results[1].Status)
+	assert.Equal(t, []string{"event 1: golden fail, replay burn"}, report.Results[1].Details)
+	assert.Equal(t, StatusError, report.Results[2].Status)
+	assert.Contains(t, report.Results[2].Message, "erst-sim crashed")
+}
+
+func TestReplayer_ChecksOnChainOutcome(t *testing.T) {
+	runner := simulator.NewMockRunner(func(ctx context.Context, req *simulator.SimulationRequest) (*simulator.SimulationResponse, error) {
+		return &simulator.SimulationResponse{Status: "success"}, nil
+	})
+	c, err := NewCase("tx", "mainnet", "env", failedResultXdr(t), "meta", nil)
+	require.NoError(t, err)
+
+	result := (&Replayer{Runner: runner}).Replay(context.Background(), c)
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Equal(t, "on-chain result TxFailed, replay status success", result.Message)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := &Report{
+		Results: []CaseResult{
+			{Hash: "a", Status: StatusPass},
+			{Hash: "b", Status: StatusFail, Message: "status drift", Details: []string{"status drift", "event 0 drift"}},
+			{Hash: "c", Status: StatusError, Message: "simulation failed"},
+		},
+		Passed: 1,
+		Failed: 1,
+		Errors: 1,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, "mainnet-corpus", report))
+
+	var doc junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, 3, doc.Tests)
+	assert.Equal(t, 1, doc.Failures)
+	assert.Equal(t, 1, doc.Errors)
+	require.Len(t, doc.Suites, 1)
+	require.Len(t, doc.Suites[0].Cases, 3)
+
+	cases := doc.Suites[0].Cases
+	assert.Nil(t, cases[0].Failure)
+	require.NotNil(t, cases[1].Failure)
+	assert.Equal(t, "status drift\nevent 0 drift", cases[1].Failure.Body)
+	require.NotNil(t, cases[2].Error)
+	assert.Equal(t, "simulation failed", cases[2].Error.Message)
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package regress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/compare"
+	"github.com/dotandev/hintents/internal/simulator"
+)
+
+// Result statuses, matching simulator.RegressionTestResult.
+const (
+	StatusPass  = "pass"
+	StatusFail  = "fail"
+	StatusError = "error"
+)
+
+// CaseResult is the outcome of replaying one case.
+type CaseResult struct {
+	Hash     string
+	Status   string
+	Message  string
+	Details  []string
+	Duration time.Duration
+
+	// Diff compares the replay (local side) with the golden response
+	// (on-chain side). It is nil when the case has no golden response or the
+	// replay did not produce a response.
+	Diff *compare.DiffResult
+	// Response is the response of the replay, if any.
+	Response *simulator.SimulationResponse
+}
+
+// Report collects the results of a corpus run.
+type Report struct {
+	Results  []CaseResult
+	Passed   int
+	Failed   int
+	Errors   int
+	Started  time.Time
+	Duration time.Duration
+}
+
+// Replayer replays corpus cases against a simulator runner.
+type Replayer struct {
+	Runner     simulator.RunnerInterface
+	MaxWorkers int
+
+	// ProtocolVersion, when set, overrides the protocol version of every
+	// replay.
+	ProtocolVersion *uint32
+	// Invariants, when set, are checked against every replay. A violation
+	// fails the case.
+	Invariants simulator.InvariantChecker
+}
+
+// Run replays cases in parallel and returns their results in case order.
+func (r *Replayer) Run(ctx context.Context, cases []*Case) *Report {
+	workers := r.MaxWorkers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	report := &Report{
+		Results: make([]CaseResult, len(cases)),
+		Started: time.Now(),
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		wg.Add(1)
+		go func(i int, c *Case) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report.Results[i] = r.Replay(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusFail:
+			report.Failed++
+		default:
+			report.Errors++
+		}
+	}
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+// Replay runs one case and checks the response against the recorded
+// on-chain outcome, the golden response and the invariants.
+func (r *Replayer) Replay(ctx context.Context, c *Case) CaseResult {
+	start := time.Now()
+	result := CaseResult{Hash: c.Hash, Status: StatusError}
+
+	req := c.Request()
+	req.ProtocolVersion = r.ProtocolVersion
+
+	resp, err := r.Runner.Run(ctx, req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		// erst-sim reports failed executions as errors but still returns
+		// their response, which is what a failed transaction replays to.
+		var simErr *simulator.SimulatorError
+		if !errors.As(err, &simErr) || simErr.Response == nil {
+			result.Message = fmt.Sprintf("simulation failed: %v", err)
+			return result
+		}
+		resp = simErr.Response
+	}
+	result.Response = resp
+
+	replaySucceeded := resp.Status == "success"
+	if c.ResultXdr != "" && replaySucceeded != c.Expected.Successful {
+		result.Details = append(result.Details, fmt.Sprintf(
+			"on-chain result %s, replay status %s", c.Expected.ResultCode, resp.Status))
+	}
+
+	if c.Expected.Golden != nil {
+		result.Diff = compare.Diff(resp, c.Expected.Golden)
+		result.Details = append(result.Details, describeDrift(result.Diff)...)
+	}
+
+	if r.Invariants != nil {
+		for _, v := range r.Invariants.Check(req, resp) {
+			result.Details = append(result.Details, v.String())
+		}
+	}
+
+	if len(result.Details) == 0 {
+		result.Status = StatusPass
+		return result
+	}
+	result.Status = StatusFail
+	result.Message = result.Details[0]
+	if len(result.Details) > 1 {
+		result.Message += fmt.Sprintf(" (and %d more)", len(result.Details)-1)
+	}
+	return result
+}
+
+// describeDrift lists every way a replay diverged from its golden response.
+// Budget changes are not drift: they move with every host upgrade.
+func describeDrift(d *compare.DiffResult) []string {
+	var out []string
+	sd := d.StatusDiff
+	if !sd.Match {
+		out = append(out, fmt.Sprintf("status: golden %s, replay %s", statusLabel(sd.OnChainStatus, sd.OnChainError), statusLabel(sd.LocalStatus, sd.LocalError)))
+	} else if sd.LocalError != sd.OnChainError {
+		out = append(out, fmt.Sprintf("error: golden %q, replay %q", sd.OnChainError, sd.LocalError))
+	}
+
+	for _, e := range d.EventDiffs {
+		if e.Divergent {
+			out = append(out, fmt.Sprintf("event %d: golden %s, replay %s", e.Index, e.OnChainEvent, e.LocalEvent))
+		}
+	}
+
+	paths := make(map[int]bool)
+	for _, p := range d.CallPathDivergences {
+		paths[p.EventIndex] = true
+		out = append(out, fmt.Sprintf("call path at diagnostic event %d: %s", p.EventIndex, p.Reason))
+	}
+	for _, dd := range d.DiagnosticDiffs {
+		if dd.Divergent && !paths[dd.Index] {
+			out = append(out, fmt.Sprintf("diagnostic event %d: topics or data differ", dd.Index))
+		}
+	}
+	return out
+}
+
+func statusLabel(status, errMsg string) string {
+	if errMsg == "" {
+		return status
+	}
+	return fmt.Sprintf("%s (%s)", status, errMsg)
+}
+
+// Summary returns a formatted summary of the report.
+func (r *Report) Summary() string {
+	total := len(r.Results)
+	rate := 0.0
+	if total > 0 {
+		rate = float64(r.Passed) / float64(total) * 100
+	}
+	return fmt.Sprintf(
+		"Regression Corpus Summary:\n"+
+			"  Total Cases: %d\n"+
+			"  Passed: %d\n"+
+			"  Failed: %d\n"+
+			"  Errors: %d\n"+
+			"  Success Rate: %.1f%%\n"+
+			"  Duration: %s",
+		total, r.Passed, r.Failed, r.Errors, rate, r.Duration.Round(time.Millisecond),
+	)
+}
+
+// FailedResults returns the results that did not pass.
+func (r *Report) FailedResults() []CaseResult {
+	var failed []CaseResult
+	for _, result := range r.Results {
+		if result.Status != StatusPass {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// joinDetails renders the details of a result, one per line.
+func joinDetails(result CaseResult) string {
+	if len(result.Details) == 0 {
+		return result.Message
+	}
+	return strings.Join(result.Details, "\n")
+}
@@ -44,6 +44,22 @@ func (b *Builder) AddExecutionStep(index int, op string, status string, details
 	return b
 }
 
+// WithStepValues records the arguments and return value of the last
+// execution step, as rendered for display. Empty values are omitted.
+func (b *Builder) WithStepValues(args []string, returnValue string) *Builder {
+	if b.report.Execution == nil || len(b.report.Execution.Steps) == 0 {
+		return b
+	}
+	step := &b.report.Execution.Steps[len(b.report.Execution.Steps)-1]
+	if len(args) > 0 {
+		step.Input = map[string]interface{}{"arguments": args}
+	}
+	if returnValue != "" {
+		step.Output = map[string]interface{}{"return": returnValue}
+	}
+	return b
+}
+
 func (b *Builder) AddContractCall(contractID, function, status string) *Builder {
 	if b.report.Execution == nil {
 		b.report.Execution = &ExecutionLog{}
@@ -168,7 +168,7 @@ const htmlTemplate = `<!DOCTYPE html>
 			<h3>Execution Steps</h3>
 			<table>
 				<thead>
-					<tr><th>#</th><th>Operation</th><th>Contract/Function</th><th>Status</th><th>Details</th></tr>
+					<tr><th>#</th><th>Operation</th><th>Contract/Function</th><th>Status</th><th>Values</th><th>Details</th></tr>
 				</thead>
 				<tbody>
 					{{ range .Steps }}
@@ -177,6 +177,7 @@ const htmlTemplate = `<!DOCTYPE html>
 						<td>{{ .Operation }}</td>
 						<td>{{ if .ContractID }}{{ .ContractID }}::{{ .Function }}{{ else }}{{ .Function }}{{ end }}</td>
 						<td><span class="{{ statusClass .Status }}">{{ .Status }}</span></td>
+						<td>{{ with .Input }}{{ range .arguments }}<div><code>{{ print . | escapeHTML }}</code></div>{{ end }}{{ end }}{{ with .Output }}{{ with .return }}<div>&rarr; <code>{{ print . | escapeHTML }}</code></div>{{ end }}{{ end }}</td>
 						<td>{{ .Details }}</td>
 					</tr>
 					{{ end }}
@@ -61,6 +61,34 @@ func TestExecutionStepTracking(t *testing.T) {
 	}
 }
 
+func TestStepValues(t *testing.T) {
+	report := NewBuilder("Test Report").
+		AddExecutionStep(0, "fn_call", "success", "").
+		WithStepValues([]string{"from: GABC", `memo: "<b>"`}, "").
+		AddExecutionStep(1, "fn_return", "success", "").
+		WithStepValues(nil, "Balance { amount: 1.5 }").
+		Build()
+
+	steps := report.Execution.Steps
+	if steps[0].Output != nil {
+		t.Errorf("expected no output on a step without a return value, got %v", steps[0].Output)
+	}
+	if got := steps[1].Output["return"]; got != "Balance { amount: 1.5 }" {
+		t.Errorf("expected return value to be recorded, got %v", got)
+	}
+
+	html, err := NewHTMLRenderer().Render(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	htmlStr := string(html)
+	for _, want := range []string{"from: GABC", "memo: &#34;&lt;b&gt;&#34;", "Balance { amount: 1.5 }"} {
+		if !strings.Contains(htmlStr, want) {
+			t.Errorf("expected %q in HTML output", want)
+		}
+	}
+}
+
 func TestContractMetrics(t *testing.T) {
 	metric := &ContractMetric{
 		CallCount:   50,
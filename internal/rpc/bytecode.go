@@ -104,7 +104,8 @@ func decodeContractID(contractIDStr string) (xdr.ContractId, error) {
 // FetchContractBytecode fetches the un-executed WASM for the given contract ID via getLedgerEntries,
 // and caches it using the existing RPC client cache. contractIDStr can be a strkey (C...) or 32-byte hex.
 // It returns the ledger key->entry map for the instance and code entries; the client also caches them.
-func FetchContractBytecode(ctx context.Context, c *Client, contractIDStr string) (map[string]string, error) {
+// Any LedgerStateProvider works in place of the client, for example a forked shell's overlay.
+func FetchContractBytecode(ctx context.Context, c LedgerStateProvider, contractIDStr string) (map[string]string, error) {
 	cid, err := decodeContractID(contractIDStr)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,439 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/logger"
+)
+
+// CassetteVersion is the on-disk cassette format version.
+const CassetteVersion = 1
+
+// CassetteMode selects how replayed requests are matched to recorded ones.
+type CassetteMode string
+
+const (
+	// CassetteStrict serves each recorded exchange once, and only for a
+	// request with the same method, URL and normalized body.
+	CassetteStrict CassetteMode = "strict"
+	// CassetteLenient also accepts a request whose host, query or params
+	// differ, as long as the path and JSON-RPC method match, and lets
+	// exchanges be served more than once.
+	CassetteLenient CassetteMode = "lenient"
+)
+
+const redactedValue = "REDACTED"
+
+// Cassette is a recording of Horizon and Soroban RPC HTTP exchanges.
+type Cassette struct {
+	Version      int                   `json:"version"`
+	RecordedAt   time.Time             `json:"recorded_at"`
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction is one recorded request and its response.
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded request. URL and Body are normalized and
+// credentials are redacted, so the values double as the replay match key.
+type CassetteRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	RPCMethod string            `json:"rpc_method,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if c.Version != CassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d (expected %d)", c.Version, CassetteVersion)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette atomically so that an interrupted run never
+// leaves a truncated file behind.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// -------------------- Recording --------------------
+
+// CassetteRecorder records every exchange that passes through its
+// middleware and rewrites the cassette file after each one.
+type CassetteRecorder struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewCassetteRecorder starts a new, empty cassette at path.
+func NewCassetteRecorder(path string) (*CassetteRecorder, error) {
+	r := &CassetteRecorder{
+		path:     path,
+		cassette: Cassette{Version: CassetteVersion, RecordedAt: time.Now().UTC(), Interactions: []CassetteInteraction{}},
+	}
+	if err := r.cassette.Save(path); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Middleware returns the recording middleware.
+func (r *CassetteRecorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := drainBody(&req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if reqBody != nil {
+				req.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(reqBody)), nil
+				}
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			respBody, err := drainBody(&resp.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			r.record(CassetteInteraction{
+				Request: newCassetteRequest(req, reqBody),
+				Response: CassetteResponse{
+					Status:  resp.StatusCode,
+					Headers: redactHeaders(resp.Header),
+					Body:    string(respBody),
+				},
+			})
+			return resp, nil
+		})
+	}
+}
+
+func (r *CassetteRecorder) record(in CassetteInteraction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	if err := r.cassette.Save(r.path); err != nil {
+		logger.Logger.Warn("Failed to save RPC cassette", "path", r.path, "error", err)
+	}
+}
+
+// Len returns the number of recorded exchanges.
+func (r *CassetteRecorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cassette.Interactions)
+}
+
+// -------------------- Replay --------------------
+
+// CassetteReplayer serves recorded exchanges without touching the network.
+// A request with no matching recording fails with a *CassetteMissError.
+type CassetteReplayer struct {
+	mode CassetteMode
+
+	mu           sync.Mutex
+	interactions []CassetteInteraction
+	used         []bool
+}
+
+// CassetteMissError reports a request that the cassette cannot answer.
+type CassetteMissError struct {
+	Method    string
+	URL       string
+	RPCMethod string
+}
+
+func (e *CassetteMissError) Error() string {
+	target := e.Method + " " + e.URL
+	if e.RPCMethod != "" {
+		target += " (" + e.RPCMethod + ")"
+	}
+	return "no recorded RPC exchange for " + target
+}
+
+// NewCassetteReplayer replays c. An empty mode means strict.
+func NewCassetteReplayer(c *Cassette, mode CassetteMode) (*CassetteReplayer, error) {
+	switch mode {
+	case "":
+		mode = CassetteStrict
+	case CassetteStrict, CassetteLenient:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q (use %s or %s)", mode, CassetteStrict, CassetteLenient)
+	}
+	return &CassetteReplayer{
+		mode:         mode,
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}, nil
+}
+
+// LoadCassetteReplayer reads the cassette at path and replays it.
+func LoadCassetteReplayer(path string, mode CassetteMode) (*CassetteReplayer, error) {
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCassetteReplayer(c, mode)
+}
+
+// Middleware returns the replay middleware. It never calls next.
+func (p *CassetteReplayer) Middleware() Middleware {
+	return func(http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(p.roundTrip)
+	}
+}
+
+func (p *CassetteReplayer) roundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	body, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	want := newCassetteRequest(req, body)
+
+	in, ok := p.match(want)
+	if !ok {
+		return nil, &CassetteMissError{Method: want.Method, URL: want.URL, RPCMethod: want.RPCMethod}
+	}
+
+	header := make(http.Header, len(in.Response.Headers))
+	for k, v := range in.Response.Headers {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+		StatusCode:    in.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+		ContentLength: int64(len(in.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+type matchPass struct {
+	matches func(CassetteRequest) bool
+	reuse   bool
+}
+
+// match picks the recorded exchange for want. Exact matches are served in
+// recording order; lenient mode then falls back to looser matches and to
+// exchanges that were already served.
+func (p *CassetteReplayer) match(want CassetteRequest) (CassetteInteraction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	exact := func(got CassetteRequest) bool {
+		return got.Method == want.Method && got.URL == want.URL && got.Body == want.Body
+	}
+	loose := func(got CassetteRequest) bool {
+		return got.Method == want.Method && got.RPCMethod == want.RPCMethod && urlPath(got.URL) == urlPath(want.URL)
+	}
+
+	passes := []matchPass{{exact, false}}
+	if p.mode == CassetteLenient {
+		passes = append(passes, matchPass{loose, false}, matchPass{exact, true}, matchPass{loose, true})
+	}
+
+	for _, pass := range passes {
+		for i, in := range p.interactions {
+			if p.used[i] && !pass.reuse {
+				continue
+			}
+			if pass.matches(in.Request) {
+				p.used[i] = true
+				return in, true
+			}
+		}
+	}
+	return CassetteInteraction{}, false
+}
+
+// Unused returns the number of recorded exchanges that were never served.
+func (p *CassetteReplayer) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, u := range p.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// -------------------- Normalization --------------------
+
+func newCassetteRequest(req *http.Request, body []byte) CassetteRequest {
+	normalized, rpcMethod := normalizeCassetteBody(body)
+	return CassetteRequest{
+		Method:    req.Method,
+		URL:       normalizeCassetteURL(req.URL),
+		RPCMethod: rpcMethod,
+		Headers:   redactHeaders(req.Header),
+		Body:      normalized,
+	}
+}
+
+// normalizeCassetteBody canonicalizes JSON bodies (sorted keys, no
+// whitespace) and drops the JSON-RPC id, which varies between runs without
+// changing the request. It also returns the JSON-RPC method, if any.
+func normalizeCassetteBody(body []byte) (string, string) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return "", ""
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return string(trimmed), ""
+	}
+
+	rpcMethod := ""
+	if obj, ok := v.(map[string]interface{}); ok {
+		if _, isRPC := obj["jsonrpc"]; isRPC {
+			delete(obj, "id")
+			rpcMethod, _ = obj["method"].(string)
+		}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(trimmed), rpcMethod
+	}
+	return string(out), rpcMethod
+}
+
+// normalizeCassetteURL sorts query parameters and redacts credentials.
+func normalizeCassetteURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	c.User = nil
+	c.Fragment = ""
+	if c.RawQuery != "" {
+		q := c.Query()
+		for k := range q {
+			if isSensitiveName(k) {
+				q.Set(k, redactedValue)
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+func urlPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Path
+}
+
+// redactHeaders flattens h and replaces credentials.
+func redactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, vs := range h {
+		if isSensitiveName(k) {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = strings.Join(vs, ", ")
+	}
+	return out
+}
+
+func isSensitiveName(name string) bool {
+	n := strings.ToLower(name)
+	for _, s := range []string{"authorization", "cookie", "token", "secret", "api-key", "apikey", "api_key", "password"} {
+		if strings.Contains(n, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// drainBody reads *body fully and replaces it with an in-memory copy.
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rpc
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cassetteClient(mw Middleware) *http.Client {
+	return &http.Client{Transport: mw(http.DefaultTransport)}
+}
+
+func postRPC(t *testing.T, client *http.Client, url, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data), nil
+}
+
+// recordCassette records two getLedgerEntries calls and one getHealth call
+// against a live test server and returns the cassette path.
+func recordCassette(t *testing.T) (string, string) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if strings.Contains(string(body), "getHealth") {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"status":"healthy"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"call":`+string(rune('0'+n))+`}}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := NewCassetteRecorder(path)
+	require.NoError(t, err)
+	client := cassetteClient(rec.Middleware())
+
+	_, body, err := postRPC(t, client, server.URL+"/rpc?api_key=hunter2", `{"jsonrpc":"2.0","id":7,"method":"getLedgerEntries","params":{"keys":["A"]}}`)
+	require.NoError(t, err)
+	assert.Contains(t, body, `"call":1`)
+	_, _, err = postRPC(t, client, server.URL+"/rpc?api_key=hunter2", `{"jsonrpc":"2.0","id":8,"method":"getLedgerEntries","params":{"keys":["A"]}}`)
+	require.NoError(t, err)
+	_, _, err = postRPC(t, client, server.URL+"/rpc?api_key=hunter2", `{"jsonrpc":"2.0","id":9,"method":"getHealth"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rec.Len())
+
+	return path, server.URL
+}
+
+func TestCassette_RecordRedactsAndNormalizes(t *testing.T) {
+	path, _ := recordCassette(t)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret-token")
+	assert.NotContains(t, string(raw), "hunter2")
+	assert.NotContains(t, string(raw), "session=abc")
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 3)
+	req := c.Interactions[0].Request
+	assert.Equal(t, "getLedgerEntries", req.RPCMethod)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"getLedgerEntries","params":{"keys":["A"]}}`, req.Body, "id is dropped and keys sorted")
+	assert.Equal(t, redactedValue, req.Headers["Authorization"])
+}
+
+func TestCassette_StrictReplay(t *testing.T) {
+	path, serverURL := recordCassette(t)
+	replayer, err := LoadCassetteReplayer(path, CassetteStrict)
+	require.NoError(t, err)
+	client := cassetteClient(replayer.Middleware())
+	url := serverURL + "/rpc?api_key=other"
+
+	// The server is closed; every answer comes from the cassette, in order.
+	_, body, err := postRPC(t, client, url, `{"id":99,"method":"getLedgerEntries","jsonrpc":"2.0","params":{"keys":["A"]}}`)
+	require.NoError(t, err)
+	assert.Contains(t, body, `"call":1`)
+	status, body, err := postRPC(t, client, url, `{"jsonrpc":"2.0","id":1,"method":"getLedgerEntries","params":{"keys":["A"]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"call":2`)
+
+	// Each exchange is served once.
+	_, _, err = postRPC(t, client, url, `{"jsonrpc":"2.0","id":1,"method":"getLedgerEntries","params":{"keys":["A"]}}`)
+	var miss *CassetteMissError
+	require.True(t, errors.As(err, &miss), "expected a cassette miss, got %v", err)
+	assert.Equal(t, "getLedgerEntries", miss.RPCMethod)
+
+	// Different params do not match in strict mode.
+	_, _, err = postRPC(t, client, url, `{"jsonrpc":"2.0","id":1,"method":"getHealth","params":{}}`)
+	assert.Error(t, err)
+	assert.Equal(t, 1, replayer.Unused())
+}
+
+func TestCassette_LenientReplay(t *testing.T) {
+	path, _ := recordCassette(t)
+	replayer, err := LoadCassetteReplayer(path, CassetteLenient)
+	require.NoError(t, err)
+	client := cassetteClient(replayer.Middleware())
+
+	// Another host and different params still match by path and method.
+	_, body, err := postRPC(t, client, "http://127.0.0.1:1/rpc", `{"jsonrpc":"2.0","id":1,"method":"getHealth","params":{}}`)
+	require.NoError(t, err)
+	assert.Contains(t, body, "healthy")
+
+	// Exchanges can be served again once exhausted.
+	for i := 0; i < 3; i++ {
+		_, _, err = postRPC(t, client, "http://127.0.0.1:1/rpc", `{"jsonrpc":"2.0","id":1,"method":"getLedgerEntries","params":{"keys":["B"]}}`)
+		require.NoError(t, err)
+	}
+
+	_, _, err = postRPC(t, client, "http://127.0.0.1:1/rpc", `{"jsonrpc":"2.0","id":1,"method":"getTransaction"}`)
+	assert.Error(t, err)
+}
+
+func TestNewCassetteReplayer_InvalidMode(t *testing.T) {
+	_, err := NewCassetteReplayer(&Cassette{Version: CassetteVersion}, "fuzzy")
+	assert.Error(t, err)
+}
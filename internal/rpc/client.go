@@ -331,18 +331,10 @@ func createHTTPClient(token string, timeout time.Duration, middlewares ...Middle
 		}
 	}
 
-	// Apply custom middlewares before the retry transport if you want retries to apply to them,
-	// or after if you want them to wrap the retries.
-	// Usually middlewares wrap the transport.
-	for _, mw := range middlewares {
-		if mw != nil {
-			transport = mw(transport)
-		}
-	}
-
 	transport = NewRetryTransport(cfg, transport)
 
-	// Apply custom middlewares
+	// Apply custom middlewares outside the retry transport so that they see
+	// one exchange per call, not one per attempt.
 	for _, mw := range middlewares {
 		if mw != nil {
 			transport = mw(transport)
@@ -3,13 +3,27 @@
 
 package rpc
 
-import hProtocol "github.com/stellar/go-stellar-sdk/protocols/horizon"
+import (
+	"context"
+
+	hProtocol "github.com/stellar/go-stellar-sdk/protocols/horizon"
+)
+
+// TransactionSource looks up applied transactions and ledger headers. *Client
+// queries the network; archive.Archive reads a history archive on disk.
+type TransactionSource interface {
+	GetTransaction(ctx context.Context, hash string) (*TransactionResponse, error)
+	GetLedgerHeader(ctx context.Context, sequence uint32) (*LedgerHeaderResponse, error)
+}
+
+var _ TransactionSource = (*Client)(nil)
 
 // TransactionResponse holds the XDR data for a transaction
 type TransactionResponse struct {
 	EnvelopeXdr   string
 	ResultXdr     string
 	ResultMetaXdr string
+	Ledger        uint32 // the ledger the transaction was applied in
 }
 
 // ParseTransactionResponse converts a Horizon transaction into a TransactionResponse
@@ -18,6 +32,7 @@ func ParseTransactionResponse(tx hProtocol.Transaction) *TransactionResponse {
 		EnvelopeXdr:   tx.EnvelopeXdr,
 		ResultXdr:     tx.ResultXdr,
 		ResultMetaXdr: tx.ResultMetaXdr,
+		Ledger:        uint32(tx.Ledger),
 	}
 }
 
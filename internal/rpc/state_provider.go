@@ -0,0 +1,233 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/snapshot"
+)
+
+// LedgerStateProvider supplies ledger entries for replay. Keys and values are
+// base64-encoded XDR LedgerKeys and LedgerEntries, as for getLedgerEntries.
+// Keys that the provider does not know are omitted from the result rather
+// than reported as errors, matching the RPC behaviour for missing entries.
+//
+// *Client is the live implementation; the cache, snapshot and layered
+// providers let the same replay run offline.
+type LedgerStateProvider interface {
+	GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+var _ LedgerStateProvider = (*Client)(nil)
+
+// State source names accepted by ParseStateSource.
+const (
+	StateSourceRPC      = "rpc"
+	StateSourceCache    = "cache"
+	StateSourceSnapshot = "snapshot"
+)
+
+// -------------------- Cache --------------------
+
+// CacheStateProvider serves ledger entries from the local SQLite cache that
+// the RPC client fills as it fetches. It never touches the network.
+type CacheStateProvider struct{}
+
+func NewCacheStateProvider() *CacheStateProvider {
+	return &CacheStateProvider{}
+}
+
+func (p *CacheStateProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	entries := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		val, hit, err := Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if hit {
+			entries[key] = val
+		}
+	}
+	logger.Logger.Debug("Ledger entries read from cache", "requested", len(keys), "found", len(entries))
+	return entries, nil
+}
+
+// -------------------- Snapshot --------------------
+
+// SnapshotStateProvider serves ledger entries from a soroban-cli compatible
+// snapshot, typically one recorded by an earlier run.
+type SnapshotStateProvider struct {
+	entries map[string]string
+}
+
+func NewSnapshotStateProvider(snap *snapshot.Snapshot) *SnapshotStateProvider {
+	return &SnapshotStateProvider{entries: snap.ToMap()}
+}
+
+// LoadSnapshotStateProvider reads a snapshot file.
+func LoadSnapshotStateProvider(path string) (*SnapshotStateProvider, error) {
+	snap, err := snapshot.Load(path)
+	if err != nil {
+		return nil, errors.WrapValidationError(err.Error())
+	}
+	return NewSnapshotStateProvider(snap), nil
+}
+
+func (p *SnapshotStateProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	entries := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if val, ok := p.entries[key]; ok {
+			entries[key] = val
+		}
+	}
+	return entries, nil
+}
+
+// Len returns the number of entries in the snapshot.
+func (p *SnapshotStateProvider) Len() int {
+	return len(p.entries)
+}
+
+// -------------------- Layered --------------------
+
+// LayeredStateProvider overlays providers in priority order. Each key is
+// served by the first layer that has it; later layers are asked only for
+// the keys still missing, so a snapshot in front of RPC only fetches what
+// the snapshot lacks.
+type LayeredStateProvider struct {
+	layers []LedgerStateProvider
+}
+
+func NewLayeredStateProvider(layers ...LedgerStateProvider) *LayeredStateProvider {
+	return &LayeredStateProvider{layers: layers}
+}
+
+func (p *LayeredStateProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	entries := make(map[string]string, len(keys))
+	missing := keys
+	for _, layer := range p.layers {
+		if len(missing) == 0 {
+			break
+		}
+		found, err := layer.GetLedgerEntries(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		var still []string
+		for _, key := range missing {
+			if val, ok := found[key]; ok {
+				entries[key] = val
+			} else {
+				still = append(still, key)
+			}
+		}
+		missing = still
+	}
+	return entries, nil
+}
+
+// -------------------- Recording --------------------
+
+// RecordingStateProvider remembers every entry served by the wrapped
+// provider so that a live run can be saved as a snapshot fixture and
+// replayed offline with --state-from snapshot:<file>.
+type RecordingStateProvider struct {
+	inner LedgerStateProvider
+
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func NewRecordingStateProvider(inner LedgerStateProvider) *RecordingStateProvider {
+	return &RecordingStateProvider{inner: inner, entries: make(map[string]string)}
+}
+
+func (p *RecordingStateProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	entries, err := p.inner.GetLedgerEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	for k, v := range entries {
+		p.entries[k] = v
+	}
+	p.mu.Unlock()
+	return entries, nil
+}
+
+// Snapshot returns the recorded entries as a snapshot.
+func (p *RecordingStateProvider) Snapshot() *snapshot.Snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return snapshot.FromMap(p.entries)
+}
+
+// Save writes the recorded entries to a snapshot file.
+func (p *RecordingStateProvider) Save(path string) error {
+	return snapshot.Save(path, p.Snapshot())
+}
+
+// -------------------- Parsing --------------------
+
+// ParseStateSource builds a provider from a --state-from value. The value is
+// a comma-separated list of layers in priority order:
+//
+//	rpc                 live Soroban RPC through client
+//	cache               the local SQLite cache
+//	snapshot:<path>     a snapshot file
+//
+// For example "snapshot:fixture.json,rpc" serves recorded entries and falls
+// back to the network for the rest. client may be nil when no layer is rpc.
+func ParseStateSource(spec string, client *Client) (LedgerStateProvider, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = StateSourceRPC
+	}
+
+	var layers []LedgerStateProvider
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		name, arg, _ := strings.Cut(part, ":")
+		switch strings.ToLower(name) {
+		case StateSourceRPC:
+			if client == nil {
+				return nil, errors.WrapValidationError("state source rpc requires an RPC client")
+			}
+			layers = append(layers, client)
+		case StateSourceCache:
+			layers = append(layers, NewCacheStateProvider())
+		case StateSourceSnapshot:
+			if arg == "" {
+				return nil, errors.WrapValidationError("state source snapshot requires a file: snapshot:<path>")
+			}
+			p, err := LoadSnapshotStateProvider(arg)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, p)
+		default:
+			return nil, errors.WrapValidationError(fmt.Sprintf(
+				"unknown state source %q (use %s)", part, strings.Join(StateSources(), ", ")))
+		}
+	}
+
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	return NewLayeredStateProvider(layers...), nil
+}
+
+// StateSources lists the accepted --state-from layer forms.
+func StateSources() []string {
+	return []string{StateSourceRPC, StateSourceCache, StateSourceSnapshot + ":<path>"}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rpc
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dotandev/hintents/internal/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider is a fixed in-memory provider that records the keys it
+// was asked for.
+type countingProvider struct {
+	entries   map[string]string
+	requested [][]string
+}
+
+func (p *countingProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	p.requested = append(p.requested, keys)
+	out := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := p.entries[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+func TestSnapshotStateProvider(t *testing.T) {
+	p := NewSnapshotStateProvider(snapshot.FromMap(map[string]string{"k1": "v1", "k2": "v2"}))
+
+	entries, err := p.GetLedgerEntries(context.Background(), []string{"k1", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "v1"}, entries)
+	assert.Equal(t, 2, p.Len())
+}
+
+func TestCacheStateProvider(t *testing.T) {
+	setupCleanTestDB(t)
+	require.NoError(t, SetWithTTL("k1", "v1", time.Hour))
+
+	entries, err := NewCacheStateProvider().GetLedgerEntries(context.Background(), []string{"k1", "k2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "v1"}, entries)
+}
+
+func TestLayeredStateProvider_FirstLayerWins(t *testing.T) {
+	front := &countingProvider{entries: map[string]string{"k1": "front"}}
+	back := &countingProvider{entries: map[string]string{"k1": "back", "k2": "back"}}
+
+	entries, err := NewLayeredStateProvider(front, back).GetLedgerEntries(context.Background(), []string{"k1", "k2", "k3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "front", "k2": "back"}, entries)
+	require.Len(t, back.requested, 1)
+	assert.Equal(t, []string{"k2", "k3"}, back.requested[0], "later layers are only asked for missing keys")
+}
+
+func TestRecordingStateProvider_RoundTrip(t *testing.T) {
+	live := &countingProvider{entries: map[string]string{"k1": "v1", "k2": "v2"}}
+	rec := NewRecordingStateProvider(live)
+
+	_, err := rec.GetLedgerEntries(context.Background(), []string{"k1", "k2"})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	require.NoError(t, rec.Save(path))
+
+	offline, err := ParseStateSource("snapshot:"+path, nil)
+	require.NoError(t, err)
+	entries, err := offline.GetLedgerEntries(context.Background(), []string{"k1", "k2"})
+	require.NoError(t, err)
+	assert.Equal(t, live.entries, entries)
+}
+
+func TestParseStateSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	require.NoError(t, snapshot.Save(path, snapshot.FromMap(map[string]string{"k": "v"})))
+
+	p, err := ParseStateSource("cache", nil)
+	require.NoError(t, err)
+	assert.IsType(t, &CacheStateProvider{}, p)
+
+	p, err = ParseStateSource("snapshot:"+path+", cache", nil)
+	require.NoError(t, err)
+	assert.IsType(t, &LayeredStateProvider{}, p)
+
+	client := &Client{}
+	p, err = ParseStateSource("", client)
+	require.NoError(t, err)
+	assert.Same(t, client, p)
+
+	for _, bad := range []string{"rpc", "snapshot", "snapshot:/does/not/exist.json", "archive"} {
+		_, err := ParseStateSource(bad, nil)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed builtin.toml
+var builtinPack []byte
+
+var (
+	builtinOnce  sync.Once
+	builtinRules []*Rule
+)
+
+// pack is the document layout shared by TOML and JSON rule packs.
+type pack struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Builtin returns the rule pack embedded in the binary.
+func Builtin() *Set {
+	builtinOnce.Do(func() {
+		set, err := Parse("builtin.toml", builtinPack)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in rule pack: %v", err))
+		}
+		builtinRules = set.rules
+	})
+	return &Set{rules: append([]*Rule(nil), builtinRules...)}
+}
+
+// DefaultDirs returns the rule directories searched on every run: the user
+// directory ~/.erst/rules followed by the project directory .erst/rules.
+// Project rules override user rules of the same name.
+func DefaultDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".erst", "rules"))
+	}
+	return append(dirs, filepath.Join(".erst", "rules"))
+}
+
+// Load returns the built-in pack merged with the packs found in the default
+// directories and then in paths, in that order. Default directories that do
+// not exist are skipped; every entry of paths must exist and may be a
+// directory or a single pack file.
+func Load(paths ...string) (*Set, error) {
+	set := Builtin()
+	for _, dir := range DefaultDirs() {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		packs, err := LoadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		set.Merge(packs)
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules: %w", err)
+		}
+		var packs *Set
+		if info.IsDir() {
+			packs, err = LoadDir(path)
+		} else {
+			packs, err = LoadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		set.Merge(packs)
+	}
+	return set, nil
+}
+
+// LoadDir loads every .toml and .json pack in dir in lexical order. A later
+// file overrides rules of the same name from an earlier one.
+func LoadDir(dir string) (*Set, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".toml", ".json":
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	set := &Set{}
+	for _, name := range names {
+		pack, err := LoadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		set.Merge(pack)
+	}
+	return set, nil
+}
+
+// LoadFile loads a single pack, choosing the format from the extension.
+func LoadFile(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule pack: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes a pack named name, which must end in .toml or .json.
+func Parse(name string, data []byte) (*Set, error) {
+	var doc []byte
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		doc = data
+	case ".toml":
+		tables, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if doc, err = json.Marshal(map[string]interface{}{"rules": tables}); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: rule packs must be .toml or .json files", name)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	var p pack
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: invalid rule pack: %w", name, err)
+	}
+	for _, r := range p.Rules {
+		r.Source = name
+	}
+	return NewSet(p.Rules...)
+}
@@ -0,0 +1,530 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rules implements the declarative rule engine behind 'erst explain'
+// and the decoder's fix suggestions.
+//
+// A rule pairs a set of matchers (keywords, host error codes, diagnostic
+// event topics, contract IDs, budget ratios and a log regex) with a
+// templated explanation and/or suggested fix. Rules are grouped into packs
+// written in TOML or JSON; the built-in pack is embedded in the binary and
+// further packs are loaded from the user and project rule directories.
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Rule is one entry of a rule pack.
+type Rule struct {
+	Name string `json:"name"`
+	// Priority orders rules; higher priorities are evaluated first. Rules
+	// with equal priority keep their load order.
+	Priority int `json:"priority,omitempty"`
+
+	Match
+
+	// Explanation is a text/template rendered with a Context when the rule
+	// fires. Only rules with an explanation can produce the summary; one that
+	// renders empty defers to the next rule.
+	Explanation string `json:"explanation,omitempty"`
+	// Fix is a text/template describing a suggested fix.
+	Fix string `json:"fix,omitempty"`
+	// Confidence is "high", "medium" or "low".
+	Confidence string `json:"confidence,omitempty"`
+
+	// Source is the file the rule was loaded from.
+	Source string `json:"-"`
+
+	explanation *template.Template
+	fix         *template.Template
+	logRegex    *regexp.Regexp
+}
+
+// Match holds the matchers of a rule. A rule fires when every matcher that
+// is set matches, or any one of them when Any is true.
+type Match struct {
+	// Keywords are case-insensitive substrings of the error message, event
+	// lines or log lines.
+	Keywords []string `json:"keywords,omitempty"`
+	// ErrorCodes are host error codes such as "Contract, #3", a bare error
+	// type such as "Auth", or the name of a contract error variant such as
+	// "InsufficientBalance" when the contract spec is available.
+	ErrorCodes []string `json:"error_codes,omitempty"`
+	// Topics are case-insensitive substrings of a diagnostic event topic.
+	Topics []string `json:"topics,omitempty"`
+	// Contracts are contract IDs that must have emitted an event. When both
+	// Topics and Contracts are set, one event must satisfy both.
+	Contracts []string `json:"contracts,omitempty"`
+	// LogRegex is matched against every log line, event line and the error
+	// message. Its submatches are available to templates as Captures.
+	LogRegex string `json:"log_regex,omitempty"`
+	// MinCPURatio and MinMemoryRatio are the minimum fraction of the CPU and
+	// memory budget consumed, where 1.0 is the full budget.
+	MinCPURatio    float64 `json:"min_cpu_ratio,omitempty"`
+	MinMemoryRatio float64 `json:"min_memory_ratio,omitempty"`
+
+	Any bool `json:"any,omitempty"`
+}
+
+// Event is the part of a diagnostic event that rules can match.
+type Event struct {
+	ContractID string
+	Topics     []string
+	Data       string
+}
+
+// Input collects the signals of one execution that rules are matched
+// against.
+type Input struct {
+	TxHash  string
+	Network string
+	Error   string
+	// ContractError and ContractErrorDoc are the named contract error
+	// variant behind an "Error(Contract, #N)" code and its doc string, when
+	// the contract spec is available.
+	ContractError    string
+	ContractErrorDoc string
+	// Lines are free-form event and log lines.
+	Lines  []string
+	Events []Event
+	// CPURatio and MemoryRatio are the fraction of each budget consumed, or
+	// zero when unknown.
+	CPURatio    float64
+	MemoryRatio float64
+}
+
+// Context is the data available to explanation and fix templates.
+type Context struct {
+	// TxHash is abbreviated for display.
+	TxHash  string
+	Network string
+	Error   string
+	// ErrorCode is the first host error code found in the input, in the form
+	// "Error(Contract, #3)".
+	ErrorCode string
+	// ContractError is the named variant of a contract error code, such as
+	// "TokenError::Paused", and ContractErrorDoc its doc string.
+	ContractError    string
+	ContractErrorDoc string
+	// Contract is the contract of the event that matched the rule, or the
+	// last contract to emit an event.
+	Contract string
+	// Caller and Callee are the last two distinct contracts to emit events.
+	Caller string
+	Callee string
+
+	CPUPercent    float64
+	MemoryPercent float64
+
+	// Captures holds the LogRegex match followed by its submatches.
+	Captures []string
+}
+
+// Finding is a rule that fired, with its templates rendered.
+type Finding struct {
+	Rule        *Rule
+	Explanation string
+	Fix         string
+}
+
+var hostErrorRe = regexp.MustCompile(`(?i)error\(\s*(\w+)\s*,\s*(#?\w+)\s*\)`)
+
+var confidences = map[string]bool{"": true, "high": true, "medium": true, "low": true}
+
+// compile validates r and prepares its templates and regex.
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule has no name")
+	}
+	m := r.Match
+	if len(m.Keywords) == 0 && len(m.ErrorCodes) == 0 && len(m.Topics) == 0 &&
+		len(m.Contracts) == 0 && m.LogRegex == "" && m.MinCPURatio == 0 && m.MinMemoryRatio == 0 {
+		return fmt.Errorf("rule %q has no matchers", r.Name)
+	}
+	if r.Explanation == "" && r.Fix == "" {
+		return fmt.Errorf("rule %q needs an explanation or a fix", r.Name)
+	}
+	if !confidences[r.Confidence] {
+		return fmt.Errorf("rule %q: confidence must be high, medium or low, got %q", r.Name, r.Confidence)
+	}
+
+	if m.LogRegex != "" {
+		re, err := regexp.Compile(m.LogRegex)
+		if err != nil {
+			return fmt.Errorf("rule %q: invalid log_regex: %w", r.Name, err)
+		}
+		r.logRegex = re
+	}
+
+	// Templates are executed once against an empty Context so that
+	// references to unknown fields are reported at load time.
+	probe := Context{}
+	if r.logRegex != nil {
+		probe.Captures = make([]string, r.logRegex.NumSubexp()+1)
+	}
+	var err error
+	if r.explanation, err = parseTemplate(r.Name, "explanation", r.Explanation, probe); err != nil {
+		return err
+	}
+	if r.fix, err = parseTemplate(r.Name, "fix", r.Fix, probe); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseTemplate parses text and checks that it executes against probe.
+func parseTemplate(rule, field, text string, probe Context) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(rule + "." + field).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid %s template: %w", rule, field, err)
+	}
+	if err := tmpl.Execute(io.Discard, probe); err != nil {
+		return nil, fmt.Errorf("rule %q: invalid %s template: %w", rule, field, err)
+	}
+	return tmpl, nil
+}
+
+// Evaluate matches r against in and renders its templates when it fires.
+func (r *Rule) Evaluate(in Input) (Finding, bool) {
+	m := r.Match
+	ctx := Context{
+		TxHash:           shortHash(in.TxHash),
+		Network:          in.Network,
+		Error:            sanitize(in.Error),
+		ContractError:    in.ContractError,
+		ContractErrorDoc: in.ContractErrorDoc,
+		CPUPercent:       in.CPURatio * 100,
+		MemoryPercent:    in.MemoryRatio * 100,
+	}
+	ctx.Caller, ctx.Callee = callerCallee(in.Events)
+	ctx.Contract = ctx.Callee
+
+	lines := make([]string, 0, len(in.Lines)+1)
+	lines = append(lines, in.Lines...)
+	if in.Error != "" {
+		lines = append(lines, in.Error)
+	}
+	codes := hostErrorCodes(lines)
+	if len(codes) > 0 {
+		ctx.ErrorCode = codes[0]
+	}
+
+	var results []bool
+	if len(m.Keywords) > 0 {
+		results = append(results, containsAny(lines, m.Keywords))
+	}
+	if len(m.ErrorCodes) > 0 {
+		results = append(results, matchErrorCodes(codes, m.ErrorCodes) ||
+			matchContractError(in.ContractError, m.ErrorCodes))
+	}
+	if len(m.Topics) > 0 || len(m.Contracts) > 0 {
+		contract, ok := m.matchEvents(in.Events)
+		if ok {
+			ctx.Contract = contract
+		}
+		results = append(results, ok)
+	}
+	if r.logRegex != nil {
+		ok := false
+		for _, line := range lines {
+			if sub := r.logRegex.FindStringSubmatch(line); sub != nil {
+				ctx.Captures = sub
+				ok = true
+				break
+			}
+		}
+		results = append(results, ok)
+	}
+	if m.MinCPURatio > 0 {
+		results = append(results, in.CPURatio >= m.MinCPURatio)
+	}
+	if m.MinMemoryRatio > 0 {
+		results = append(results, in.MemoryRatio >= m.MinMemoryRatio)
+	}
+
+	if !combine(results, m.Any) {
+		return Finding{}, false
+	}
+	return Finding{
+		Rule:        r,
+		Explanation: render(r.explanation, r.Explanation, ctx),
+		Fix:         render(r.fix, r.Fix, ctx),
+	}, true
+}
+
+// matchEvents returns the contract of the first event that satisfies the
+// topic and contract matchers.
+func (m *Match) matchEvents(events []Event) (string, bool) {
+	for _, ev := range events {
+		topicOK := len(m.Topics) > 0 && containsAny(ev.Topics, m.Topics)
+		contractOK := len(m.Contracts) > 0 && equalsAny(ev.ContractID, m.Contracts)
+
+		var ok bool
+		switch {
+		case m.Any:
+			ok = topicOK || contractOK
+		case len(m.Topics) > 0 && len(m.Contracts) > 0:
+			ok = topicOK && contractOK
+		default:
+			ok = topicOK || contractOK
+		}
+		if ok {
+			return ev.ContractID, true
+		}
+	}
+	return "", false
+}
+
+func combine(results []bool, anyOf bool) bool {
+	if anyOf {
+		for _, ok := range results {
+			if ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// render executes tmpl, falling back to the raw template text if execution
+// fails at runtime.
+func render(tmpl *template.Template, text string, ctx Context) string {
+	if tmpl == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return text
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// Set is an ordered collection of compiled rules.
+type Set struct {
+	rules []*Rule
+}
+
+// NewSet compiles rules into a set, keeping their order.
+func NewSet(rules ...*Rule) (*Set, error) {
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			if r.Source != "" {
+				return nil, fmt.Errorf("%s: %w", r.Source, err)
+			}
+			return nil, err
+		}
+	}
+	s := &Set{rules: append([]*Rule(nil), rules...)}
+	s.sort()
+	return s, nil
+}
+
+// Merge adds the rules of other to s. Rules of other replace every rule of
+// s with the same name, so a pack can override a built-in rule. Several
+// rules with the same name within one pack are alternatives: the first to
+// fire wins.
+func (s *Set) Merge(other *Set) {
+	if other == nil {
+		return
+	}
+	replaced := make(map[string]bool)
+	for _, r := range other.rules {
+		replaced[r.Name] = true
+	}
+	kept := s.rules[:0:0]
+	for _, r := range s.rules {
+		if !replaced[r.Name] {
+			kept = append(kept, r)
+		}
+	}
+	s.rules = append(kept, other.rules...)
+	s.sort()
+}
+
+func (s *Set) sort() {
+	sort.SliceStable(s.rules, func(i, j int) bool {
+		return s.rules[i].Priority > s.rules[j].Priority
+	})
+}
+
+// Rules returns the rules of s in evaluation order.
+func (s *Set) Rules() []*Rule {
+	return append([]*Rule(nil), s.rules...)
+}
+
+// Len returns the number of rules in s.
+func (s *Set) Len() int {
+	return len(s.rules)
+}
+
+// Evaluate returns every rule that fires on in, in priority order. Only
+// the first rule to fire under a given name is reported.
+func (s *Set) Evaluate(in Input) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, r := range s.rules {
+		if seen[r.Name] {
+			continue
+		}
+		if f, ok := r.Evaluate(in); ok {
+			seen[r.Name] = true
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Explain returns the highest-priority finding that carries a non-empty
+// explanation.
+func (s *Set) Explain(in Input) (Finding, bool) {
+	for _, r := range s.rules {
+		if r.Explanation == "" {
+			continue
+		}
+		if f, ok := r.Evaluate(in); ok && f.Explanation != "" {
+			return f, true
+		}
+	}
+	return Finding{}, false
+}
+
+// Fixes returns the findings that carry a suggested fix.
+func (s *Set) Fixes(in Input) []Finding {
+	var fixes []Finding
+	for _, f := range s.Evaluate(in) {
+		if f.Fix != "" {
+			fixes = append(fixes, f)
+		}
+	}
+	return fixes
+}
+
+// hostErrorCodes extracts host error codes from lines, normalized to
+// "Error(Type, Code)".
+func hostErrorCodes(lines []string) []string {
+	var codes []string
+	for _, line := range lines {
+		for _, m := range hostErrorRe.FindAllStringSubmatch(line, -1) {
+			codes = append(codes, fmt.Sprintf("Error(%s, %s)", m[1], m[2]))
+		}
+	}
+	return codes
+}
+
+// matchErrorCodes reports whether any code matches any pattern. A pattern
+// is either a full code ("Contract, #3", optionally wrapped in "Error(...)")
+// or an error type alone ("Auth").
+func matchErrorCodes(codes, patterns []string) bool {
+	for _, code := range codes {
+		normCode := normalizeCode(code)
+		codeType, _, _ := strings.Cut(normCode, ",")
+		for _, p := range patterns {
+			normPattern := normalizeCode(p)
+			if normPattern == normCode || (!strings.Contains(normPattern, ",") && normPattern == codeType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchContractError reports whether name, a variant such as
+// "TokenError::Paused", matches any pattern by qualified or bare name.
+func matchContractError(name string, patterns []string) bool {
+	if name == "" {
+		return false
+	}
+	_, bare, qualified := strings.Cut(name, "::")
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if strings.EqualFold(p, name) || (qualified && strings.EqualFold(p, bare)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	if strings.HasPrefix(code, "error(") && strings.HasSuffix(code, ")") {
+		code = code[len("error(") : len(code)-1]
+	}
+	return code
+}
+
+func containsAny(haystack, needles []string) bool {
+	for _, h := range haystack {
+		lh := strings.ToLower(h)
+		for _, n := range needles {
+			if strings.Contains(lh, strings.ToLower(n)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalsAny(s string, candidates []string) bool {
+	for _, c := range candidates {
+		if strings.EqualFold(s, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerCallee returns the last two distinct contract IDs encountered in
+// events, treating the earlier one as the caller and the later one as the
+// callee that triggered the failure.
+func callerCallee(events []Event) (caller, callee string) {
+	seen := make([]string, 0, 4)
+	dedup := make(map[string]struct{})
+	for _, e := range events {
+		if e.ContractID == "" {
+			continue
+		}
+		if _, ok := dedup[e.ContractID]; !ok {
+			dedup[e.ContractID] = struct{}{}
+			seen = append(seen, e.ContractID)
+		}
+	}
+	switch len(seen) {
+	case 0:
+		return "", ""
+	case 1:
+		return "", seen[0]
+	default:
+		return seen[len(seen)-2], seen[len(seen)-1]
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) <= 12 {
+		return hash
+	}
+	return hash[:6] + "..." + hash[len(hash)-6:]
+}
+
+func sanitize(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, name, src string) *Set {
+	t.Helper()
+	set, err := Parse(name, []byte(src))
+	require.NoError(t, err)
+	return set
+}
+
+func TestBuiltin_Parses(t *testing.T) {
+	set := Builtin()
+	require.NotZero(t, set.Len())
+	assert.Equal(t, "contract_error", set.Rules()[0].Name, "rules are ordered by priority")
+	for _, r := range set.Rules() {
+		assert.Equal(t, "builtin.toml", r.Source)
+	}
+}
+
+func TestParseTOML_Values(t *testing.T) {
+	tables, err := parseTOML(`
+# comment
+[[rules]]
+name = "a" # trailing comment
+priority = 1_000
+keywords = [
+  "x", 'y\z',  # literal strings keep backslashes
+]
+min_cpu_ratio = 0.5
+any = true
+fix = """
+line one
+line two"""
+
+[[rules]]
+name = "b"
+`)
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+	assert.Equal(t, "a", tables[0]["name"])
+	assert.Equal(t, int64(1000), tables[0]["priority"])
+	assert.Equal(t, []interface{}{"x", `y\z`}, tables[0]["keywords"])
+	assert.Equal(t, 0.5, tables[0]["min_cpu_ratio"])
+	assert.Equal(t, true, tables[0]["any"])
+	assert.Equal(t, "line one\nline two", tables[0]["fix"])
+	assert.Equal(t, "b", tables[1]["name"])
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"other table", "[rules]\nname = \"a\"", "line 1: unsupported table [rules]"},
+		{"key outside table", "name = \"a\"", "outside a [[rules]] table"},
+		{"duplicate key", "[[rules]]\nname = \"a\"\nname = \"b\"", "line 3: duplicate key"},
+		{"unterminated string", "[[rules]]\nname = \"a", "line 2: unterminated string"},
+		{"unterminated array", "[[rules]]\nkeywords = [\"a\",", "unterminated array"},
+		{"bad value", "[[rules]]\npriority = high", "invalid value \"high\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.src)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParse_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unknown field", `{"rules": [{"name": "a", "keyword": ["x"], "fix": "f"}]}`, "unknown field"},
+		{"no matchers", `{"rules": [{"name": "a", "fix": "f"}]}`, "has no matchers"},
+		{"no output", `{"rules": [{"name": "a", "keywords": ["x"]}]}`, "needs an explanation or a fix"},
+		{"bad confidence", `{"rules": [{"name": "a", "keywords": ["x"], "fix": "f", "confidence": "certain"}]}`, "confidence"},
+		{"bad regex", `{"rules": [{"name": "a", "log_regex": "(", "fix": "f"}]}`, "invalid log_regex"},
+		{"unknown template field", `{"rules": [{"name": "a", "keywords": ["x"], "fix": "{{.Nope}}"}]}`, "invalid fix template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("pack.json", []byte(tt.src))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "pack.json")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	_, err := Parse("pack.yaml", nil)
+	assert.Error(t, err)
+}
+
+func TestEvaluate_Matchers(t *testing.T) {
+	set := mustParse(t, "team.toml", `
+[[rules]]
+name = "paused"
+error_codes = ["Contract, #7"]
+topics = ["paused"]
+contracts = ["CTOKEN"]
+explanation = "{{.Contract}} is paused ({{.ErrorCode}}) on {{.Network}}."
+
+[[rules]]
+name = "oracle_stale"
+log_regex = 'oracle price age (\d+)s'
+fix = "Refresh the oracle; the price was {{index .Captures 1}}s old."
+confidence = "low"
+
+[[rules]]
+name = "hot_loop"
+min_cpu_ratio = 0.9
+fix = "CPU at {{printf \"%.0f\" .CPUPercent}}%."
+`)
+
+	in := Input{
+		TxHash:  "aaaaaa000000bbbbbb",
+		Network: "testnet",
+		Error:   "HostError: Error(Contract, #7)",
+		Lines:   []string{"oracle price age 900s"},
+		Events: []Event{
+			{ContractID: "CROUTER", Topics: []string{"paused"}},
+			{ContractID: "CTOKEN", Topics: []string{"transfer", "paused"}},
+		},
+		CPURatio: 0.95,
+	}
+
+	findings := set.Evaluate(in)
+	require.Len(t, findings, 3)
+	assert.Equal(t, "CTOKEN is paused (Error(Contract, #7)) on testnet.", findings[0].Explanation,
+		"topics and contracts must match on the same event")
+	assert.Equal(t, "Refresh the oracle; the price was 900s old.", findings[1].Fix)
+	assert.Equal(t, "CPU at 95%.", findings[2].Fix)
+
+	in.Error = "HostError: Error(Contract, #8)"
+	in.CPURatio = 0.5
+	findings = set.Evaluate(in)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "oracle_stale", findings[0].Rule.Name)
+}
+
+func TestEvaluate_ErrorTypeAndAny(t *testing.T) {
+	set := mustParse(t, "pack.json", `{"rules": [
+		{"name": "auth", "error_codes": ["Auth"], "explanation": "auth"},
+		{"name": "budget", "any": true, "keywords": ["cpulimitexceeded"], "min_cpu_ratio": 1, "explanation": "budget"}
+	]}`)
+
+	f, ok := set.Explain(Input{Error: "Error(Auth, InvalidAction)"})
+	require.True(t, ok)
+	assert.Equal(t, "auth", f.Explanation)
+
+	f, ok = set.Explain(Input{CPURatio: 1.2})
+	require.True(t, ok)
+	assert.Equal(t, "budget", f.Explanation)
+
+	_, ok = set.Explain(Input{Error: "Error(Storage, MissingValue)"})
+	assert.False(t, ok)
+}
+
+func TestEvaluate_ContractErrorNames(t *testing.T) {
+	set := mustParse(t, "team.toml", `
+[[rules]]
+name = "paused"
+priority = 100
+error_codes = ["Paused"]
+explanation = "{{.ContractError}}: {{.ContractErrorDoc}}"
+
+[[rules]]
+name = "vault"
+error_codes = ["VaultError::Locked"]
+fix = "Unlock the vault."
+`)
+
+	in := Input{
+		Error:            "Error(Contract, #7)",
+		ContractError:    "TokenError::Paused",
+		ContractErrorDoc: "Transfers are paused.",
+	}
+	f, ok := set.Explain(in)
+	require.True(t, ok)
+	assert.Equal(t, "TokenError::Paused: Transfers are paused.", f.Explanation)
+	assert.Empty(t, set.Fixes(in))
+
+	in.ContractError = "VaultError::Locked"
+	_, ok = set.Explain(in)
+	assert.False(t, ok)
+	assert.Len(t, set.Fixes(in), 1)
+}
+
+func TestBuiltin_ContractError(t *testing.T) {
+	in := Input{
+		TxHash:  "abc",
+		Network: "testnet",
+		Error:   "HostError: Error(Contract, #7)",
+		Events:  []Event{{ContractID: "CTOKEN", Topics: []string{"fn_call"}}},
+	}
+
+	_, ok := Builtin().Explain(in)
+	assert.False(t, ok, "without a spec the code has no builtin explanation")
+
+	in.ContractError = "TokenError::Paused"
+	in.ContractErrorDoc = "Transfers are paused."
+	f, ok := Builtin().Explain(in)
+	require.True(t, ok)
+	assert.Equal(t, "contract_error", f.Rule.Name)
+	assert.Equal(t, "Transaction abc failed on testnet because contract CTOKEN returned TokenError::Paused (Error(Contract, #7)). Transfers are paused.", f.Explanation)
+}
+
+func TestMerge_OverridesByNameAndOrdersByPriority(t *testing.T) {
+	base := mustParse(t, "base.toml", `
+[[rules]]
+name = "a"
+priority = 10
+keywords = ["x"]
+explanation = "base a"
+
+[[rules]]
+name = "b"
+priority = 5
+keywords = ["x"]
+explanation = "base b"
+`)
+	base.Merge(mustParse(t, "team.toml", `
+[[rules]]
+name = "b"
+priority = 20
+keywords = ["y"]
+explanation = "team b, first alternative"
+
+[[rules]]
+name = "b"
+priority = 20
+keywords = ["x"]
+explanation = "team b, second alternative"
+`))
+
+	require.Equal(t, 3, base.Len())
+	f, ok := base.Explain(Input{Error: "x"})
+	require.True(t, ok)
+	assert.Equal(t, "team b, second alternative", f.Explanation)
+
+	findings := base.Evaluate(Input{Error: "x y"})
+	require.Len(t, findings, 2, "only the first alternative of a name is reported")
+	assert.Equal(t, "team b, first alternative", findings[0].Explanation)
+	assert.Equal(t, "base a", findings[1].Explanation)
+}
+
+func TestLoad_ReadsPackDirectories(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.toml"), []byte(`
+[[rules]]
+name = "wasm_trap"
+priority = 10
+keywords = ["unreachable"]
+explanation = "Team-specific trap explanation."
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"rules": [
+		{"name": "vault_locked", "priority": 100, "topics": ["locked"], "explanation": "Vault {{.Contract}} is locked."}
+	]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0644))
+
+	set, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, Builtin().Len()+1, set.Len())
+
+	f, ok := set.Explain(Input{Events: []Event{{ContractID: "CVAULT", Topics: []string{"locked"}}}})
+	require.True(t, ok)
+	assert.Equal(t, "Vault CVAULT is locked.", f.Explanation)
+
+	f, ok = set.Explain(Input{Error: "wasm trap: unreachable"})
+	require.True(t, ok)
+	assert.Equal(t, "Team-specific trap explanation.", f.Explanation)
+
+	_, err = Load(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML decodes the subset of TOML used by rule packs: a sequence of
+// [[rules]] tables whose keys hold strings (basic, literal or """multi-line"""),
+// integers, floats, booleans or arrays of those. Comments and arrays spanning
+// several lines are supported; other tables are rejected.
+func parseTOML(src string) ([]map[string]interface{}, error) {
+	p := &tomlParser{src: src}
+	var tables []map[string]interface{}
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return tables, nil
+		}
+
+		if p.hasPrefix("[") {
+			start := p.pos
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			header := strings.TrimSpace(stripComment(p.src[p.pos : p.pos+end]))
+			if header != "[[rules]]" {
+				return nil, p.errorf(start, "unsupported table %s; rule packs contain only [[rules]] tables", header)
+			}
+			p.pos += end
+			tables = append(tables, map[string]interface{}{})
+			continue
+		}
+
+		keyPos := p.pos
+		key := p.readKey()
+		if key == "" {
+			return nil, p.errorf(keyPos, "expected a key")
+		}
+		p.skipSpaces()
+		if !p.hasPrefix("=") {
+			return nil, p.errorf(p.pos, "expected '=' after key %q", key)
+		}
+		p.pos++
+		p.skipSpaces()
+
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if !p.eof() && !p.hasPrefix("\n") && !p.hasPrefix("\r") && !p.hasPrefix("#") {
+			return nil, p.errorf(p.pos, "unexpected text after value of %q", key)
+		}
+
+		if len(tables) == 0 {
+			return nil, p.errorf(keyPos, "key %q outside a [[rules]] table", key)
+		}
+		table := tables[len(tables)-1]
+		if _, dup := table[key]; dup {
+			return nil, p.errorf(keyPos, "duplicate key %q", key)
+		}
+		table[key] = value
+	}
+}
+
+type tomlParser struct {
+	src string
+	pos int
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *tomlParser) errorf(pos int, format string, args ...interface{}) error {
+	line := strings.Count(p.src[:pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpaces skips spaces and tabs.
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for !p.eof() && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) readKey() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *tomlParser) readValue() (interface{}, error) {
+	start := p.pos
+	switch {
+	case p.eof():
+		return nil, p.errorf(start, "expected a value")
+
+	case p.hasPrefix(`"""`):
+		p.pos += 3
+		end := strings.Index(p.src[p.pos:], `"""`)
+		if end < 0 {
+			return nil, p.errorf(start, "unterminated multi-line string")
+		}
+		s := p.src[p.pos : p.pos+end]
+		p.pos += end + 3
+		// A newline immediately after the opening delimiter is trimmed.
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "\r"), "\n")
+		return s, nil
+
+	case p.hasPrefix(`"`):
+		for i := p.pos + 1; i < len(p.src); i++ {
+			switch p.src[i] {
+			case '\\':
+				i++
+			case '\n':
+				return nil, p.errorf(start, "unterminated string")
+			case '"':
+				s, err := strconv.Unquote(p.src[p.pos : i+1])
+				if err != nil {
+					return nil, p.errorf(start, "invalid string: %v", err)
+				}
+				p.pos = i + 1
+				return s, nil
+			}
+		}
+		return nil, p.errorf(start, "unterminated string")
+
+	case p.hasPrefix("'"):
+		end := strings.IndexAny(p.src[p.pos+1:], "'\n")
+		if end < 0 || p.src[p.pos+1+end] != '\'' {
+			return nil, p.errorf(start, "unterminated string")
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return s, nil
+
+	case p.hasPrefix("["):
+		p.pos++
+		values := []interface{}{}
+		for {
+			p.skipBlank()
+			if p.eof() {
+				return nil, p.errorf(start, "unterminated array")
+			}
+			if p.hasPrefix("]") {
+				p.pos++
+				return values, nil
+			}
+			v, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			p.skipBlank()
+			if p.hasPrefix(",") {
+				p.pos++
+				continue
+			}
+			if !p.hasPrefix("]") {
+				return nil, p.errorf(p.pos, "expected ',' or ']' in array")
+			}
+		}
+	}
+
+	end := p.pos
+	for end < len(p.src) && !strings.ContainsRune(" \t\r\n,]#", rune(p.src[end])) {
+		end++
+	}
+	token := p.src[p.pos:end]
+	p.pos = end
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	number := strings.ReplaceAll(token, "_", "")
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, p.errorf(start, "invalid value %q", token)
+}
+
+// stripComment removes a trailing # comment from a header line.
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
@@ -0,0 +1,391 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package shell
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/reconstruct"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/snapshot"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// ForkPoint identifies the network ledger a forked session starts from.
+type ForkPoint struct {
+	Network rpc.Network
+	Ledger  uint32
+	// CloseTime is the close time of the fork ledger in Unix seconds, or
+	// zero when it is not known.
+	CloseTime int64
+}
+
+// ParseForkPoint parses a --fork value of the form <network>@<ledger>,
+// for example "mainnet@51234567".
+func ParseForkPoint(spec string) (ForkPoint, error) {
+	name, seq, ok := strings.Cut(strings.TrimSpace(spec), "@")
+	if !ok || name == "" || seq == "" {
+		return ForkPoint{}, fmt.Errorf("invalid fork point %q (expected <network>@<ledger>)", spec)
+	}
+
+	network := rpc.Network(strings.ToLower(name))
+	switch network {
+	case rpc.Testnet, rpc.Mainnet, rpc.Futurenet:
+	default:
+		return ForkPoint{}, fmt.Errorf("invalid fork network %q (use testnet, mainnet or futurenet)", name)
+	}
+
+	ledger, err := strconv.ParseUint(seq, 10, 32)
+	if err != nil || ledger == 0 {
+		return ForkPoint{}, fmt.Errorf("invalid fork ledger %q", seq)
+	}
+	return ForkPoint{Network: network, Ledger: uint32(ledger)}, nil
+}
+
+func (p ForkPoint) String() string {
+	return fmt.Sprintf("%s@%d", p.Network, p.Ledger)
+}
+
+// ChangeKind classifies an entry in a state diff.
+type ChangeKind string
+
+const (
+	ChangeCreated ChangeKind = "created"
+	ChangeUpdated ChangeKind = "updated"
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// EntryChange describes how one ledger entry differs from the base state.
+// Before is empty for created entries and After is empty for deleted ones.
+type EntryChange struct {
+	Key    string
+	Kind   ChangeKind
+	Before string
+	After  string
+}
+
+// ForkStats summarises a forked session's state.
+type ForkStats struct {
+	Point ForkPoint
+	// Fetched is the number of entries pulled from the network.
+	Fetched int
+	// Changed is the number of entries that differ from the fork point.
+	Changed int
+	// Drifted is the number of fetched entries last modified after the fork
+	// ledger, whose values are newer than the fork point.
+	Drifted int
+	// Rebuilt is the number of entries modified or deleted after the fork
+	// ledger whose fork-point value was recovered from ledger close meta.
+	Rebuilt int
+}
+
+// ForkState is a copy-on-write view of a network's ledger. Entries are
+// fetched lazily from upstream the first time they are read and kept as the
+// fork-point base; local writes and deletions go to an overlay and never
+// reach upstream. ForkState is itself a LedgerStateProvider, so it can be
+// handed to anything that reads ledger state.
+//
+// Upstream serves current values. An entry modified after the fork ledger is
+// rolled back to its fork-point value when ledger close meta covering the
+// change is available (see SetLedgerMeta), and counted as drifted otherwise.
+// An entry deleted since is recovered the same way; without meta it reads as
+// absent.
+type ForkState struct {
+	point    ForkPoint
+	upstream rpc.LedgerStateProvider
+	ledgers  reconstruct.LedgerMetaSource
+
+	mu      sync.Mutex
+	base    map[string]string
+	absent  map[string]bool
+	overlay map[string]string
+	deleted map[string]bool
+	drifted map[string]bool
+	rebuilt int
+}
+
+var _ rpc.LedgerStateProvider = (*ForkState)(nil)
+
+// NewForkState creates a fork of upstream at point.
+func NewForkState(point ForkPoint, upstream rpc.LedgerStateProvider) *ForkState {
+	return &ForkState{
+		point:    point,
+		upstream: upstream,
+		base:     make(map[string]string),
+		absent:   make(map[string]bool),
+		overlay:  make(map[string]string),
+		deleted:  make(map[string]bool),
+		drifted:  make(map[string]bool),
+	}
+}
+
+// SetLedgerMeta lets the fork recover the fork-point value of entries
+// modified after the fork ledger from the close meta of the ledgers since.
+func (f *ForkState) SetLedgerMeta(ledgers reconstruct.LedgerMetaSource) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ledgers = ledgers
+}
+
+// Point returns the fork point.
+func (f *ForkState) Point() ForkPoint {
+	return f.point
+}
+
+// GetLedgerEntries returns the current value of each key, fetching keys that
+// have not been seen before from upstream.
+func (f *ForkState) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var unknown []string
+	for _, key := range keys {
+		if _, ok := f.overlay[key]; ok || f.deleted[key] || f.absent[key] {
+			continue
+		}
+		if _, ok := f.base[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		fetched, err := f.upstream.GetLedgerEntries(ctx, unknown)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ledger entries from %s: %w", f.point, err)
+		}
+		var newer, missing []string
+		var through uint32
+		for _, key := range unknown {
+			val, ok := fetched[key]
+			if !ok {
+				f.absent[key] = true
+				missing = append(missing, key)
+				continue
+			}
+			f.base[key] = val
+			if seq, ok := lastModifiedLedger(val); ok && seq > f.point.Ledger {
+				newer = append(newer, key)
+				if seq > through {
+					through = seq
+				}
+			}
+		}
+		logger.Logger.Debug("Fetched ledger entries for fork", "fork", f.point.String(), "requested", len(unknown), "found", len(fetched))
+
+		if err := f.rollBack(ctx, newer, through); err != nil {
+			return nil, err
+		}
+		if err := f.restore(ctx, missing); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if val, ok := f.lookup(key); ok {
+			entries[key] = val
+		}
+	}
+	return entries, nil
+}
+
+// rollBack replaces the current values of keys, which were modified after the
+// fork ledger, with their fork-point values where ledger meta records them.
+// The rest are marked drifted. f.mu must be held.
+func (f *ForkState) rollBack(ctx context.Context, keys []string, through uint32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var recovered map[string]reconstruct.Entry
+	if f.ledgers != nil {
+		var err error
+		recovered, err = reconstruct.AtLedger(ctx, f.ledgers, f.point.Ledger, through, keys)
+		if err != nil {
+			return fmt.Errorf("failed to read ledger meta after %s: %w", f.point, err)
+		}
+	}
+
+	for _, key := range keys {
+		e, ok := recovered[key]
+		switch {
+		case !ok:
+			f.drifted[key] = true
+			logger.Logger.Warn("Ledger entry changed after the fork point; using its current value",
+				"fork", f.point.String(), "key", key)
+		case e.Entry == "":
+			// Created after the fork point.
+			delete(f.base, key)
+			f.absent[key] = true
+			f.rebuilt++
+		default:
+			f.base[key] = e.Entry
+			f.rebuilt++
+		}
+	}
+	return nil
+}
+
+// restore recovers the fork-point value of keys upstream no longer holds,
+// because they were deleted after the fork ledger, from every ledger meta
+// available. Keys the meta does not record stay absent. f.mu must be held.
+func (f *ForkState) restore(ctx context.Context, keys []string) error {
+	if len(keys) == 0 || f.ledgers == nil {
+		return nil
+	}
+
+	recovered, err := reconstruct.AtLedger(ctx, f.ledgers, f.point.Ledger, math.MaxUint32, keys)
+	if err != nil {
+		return fmt.Errorf("failed to read ledger meta after %s: %w", f.point, err)
+	}
+	for _, key := range keys {
+		// An empty entry was created and deleted again after the fork point.
+		if e, ok := recovered[key]; ok && e.Entry != "" {
+			delete(f.absent, key)
+			f.base[key] = e.Entry
+			f.rebuilt++
+		}
+	}
+	return nil
+}
+
+// lookup returns the current value of key without fetching. f.mu must be held.
+func (f *ForkState) lookup(key string) (string, bool) {
+	if val, ok := f.overlay[key]; ok {
+		return val, true
+	}
+	if f.deleted[key] {
+		return "", false
+	}
+	val, ok := f.base[key]
+	return val, ok
+}
+
+// Set writes an entry to the overlay.
+func (f *ForkState) Set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.overlay[key] = value
+	delete(f.deleted, key)
+}
+
+// Delete removes an entry from the forked view. The fork-point value is kept
+// so that Diff can report the deletion.
+func (f *ForkState) Delete(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.overlay, key)
+	f.deleted[key] = true
+}
+
+// Reset discards local changes. Entries already fetched stay cached.
+func (f *ForkState) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.overlay = make(map[string]string)
+	f.deleted = make(map[string]bool)
+}
+
+// Entries returns every entry the fork currently knows: fetched entries with
+// local changes applied.
+func (f *ForkState) Entries() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries := make(map[string]string, len(f.base)+len(f.overlay))
+	for key, val := range f.base {
+		if !f.deleted[key] {
+			entries[key] = val
+		}
+	}
+	for key, val := range f.overlay {
+		entries[key] = val
+	}
+	return entries
+}
+
+// Diff returns the local changes relative to the fork point, sorted by key.
+func (f *ForkState) Diff() []EntryChange {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := make(map[string]string, len(f.overlay))
+	for key, val := range f.overlay {
+		current[key] = val
+	}
+	base := make(map[string]string, len(f.overlay)+len(f.deleted))
+	for key := range f.overlay {
+		if val, ok := f.base[key]; ok {
+			base[key] = val
+		}
+	}
+	for key := range f.deleted {
+		if val, ok := f.base[key]; ok {
+			base[key] = val
+		}
+	}
+	return diffEntries(base, current)
+}
+
+// Snapshot exports the forked view as a snapshot that can be replayed with
+// --state-from snapshot:<file>.
+func (f *ForkState) Snapshot() *snapshot.Snapshot {
+	return snapshot.FromMap(f.Entries())
+}
+
+// Stats summarises the fork.
+func (f *ForkState) Stats() ForkStats {
+	changed := len(f.Diff())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return ForkStats{
+		Point:   f.point,
+		Fetched: len(f.base),
+		Changed: changed,
+		Drifted: len(f.drifted),
+		Rebuilt: f.rebuilt,
+	}
+}
+
+// diffEntries compares after against before. Keys in before but not after are
+// deletions.
+func diffEntries(before, after map[string]string) []EntryChange {
+	var changes []EntryChange
+	for key, val := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, EntryChange{Key: key, Kind: ChangeCreated, After: val})
+		case old != val:
+			changes = append(changes, EntryChange{Key: key, Kind: ChangeUpdated, Before: old, After: val})
+		}
+	}
+	for key, old := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, EntryChange{Key: key, Kind: ChangeDeleted, Before: old})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// lastModifiedLedger decodes the last-modified sequence of a base64 XDR
+// LedgerEntry.
+func lastModifiedLedger(entryXDR string) (uint32, bool) {
+	var entry xdr.LedgerEntry
+	if err := xdr.SafeUnmarshalBase64(entryXDR, &entry); err != nil {
+		return 0, false
+	}
+	return uint32(entry.LastModifiedLedgerSeq), true
+}
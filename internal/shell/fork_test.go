@@ -0,0 +1,359 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package shell
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/reconstruct"
+	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/snapshot"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// countingProvider serves a fixed set of entries and records every key it is
+// asked for.
+type countingProvider struct {
+	entries   map[string]string
+	requested []string
+}
+
+func (p *countingProvider) GetLedgerEntries(ctx context.Context, keys []string) (map[string]string, error) {
+	p.requested = append(p.requested, keys...)
+	found := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := p.entries[k]; ok {
+			found[k] = v
+		}
+	}
+	return found, nil
+}
+
+func ledgerEntryXDR(t *testing.T, lastModified uint32) string {
+	t.Helper()
+	entry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: xdr.Uint32(lastModified),
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId: xdr.MustAddress("GAAZI4TCR3TY5OJHCTJC2A4QSY6CJWJH5IAJTGKIN2ER7LBNVKOCCWN7"),
+				Balance:   100,
+			},
+		},
+	}
+	encoded, err := xdr.MarshalBase64(entry)
+	if err != nil {
+		t.Fatalf("failed to encode ledger entry: %v", err)
+	}
+	return encoded
+}
+
+func TestParseForkPoint(t *testing.T) {
+	point, err := ParseForkPoint("Mainnet@51234567")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if point.Network != rpc.Mainnet || point.Ledger != 51234567 {
+		t.Errorf("unexpected fork point: %+v", point)
+	}
+	if point.String() != "mainnet@51234567" {
+		t.Errorf("unexpected string form: %s", point)
+	}
+
+	for _, spec := range []string{"", "mainnet", "mainnet@", "@5", "devnet@5", "testnet@0", "testnet@abc"} {
+		if _, err := ParseForkPoint(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestForkState_FetchesOnceAndKeepsWritesLocal(t *testing.T) {
+	upstream := &countingProvider{entries: map[string]string{"a": "a0", "b": "b0"}}
+	fork := NewForkState(ForkPoint{Network: rpc.Testnet, Ledger: 100}, upstream)
+	ctx := context.Background()
+
+	got, err := fork.GetLedgerEntries(ctx, []string{"a", "missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["a"] != "a0" || len(got) != 1 {
+		t.Errorf("unexpected entries: %v", got)
+	}
+
+	// Known and known-missing keys are not fetched again.
+	if _, err := fork.GetLedgerEntries(ctx, []string{"a", "missing"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(upstream.requested) != 2 {
+		t.Errorf("expected 2 upstream lookups, got %v", upstream.requested)
+	}
+
+	fork.Set("a", "a1")
+	fork.Set("c", "c1")
+	fork.Delete("b")
+
+	got, err = fork.GetLedgerEntries(ctx, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["a"] != "a1" || got["c"] != "c1" {
+		t.Errorf("overlay not applied: %v", got)
+	}
+	if _, ok := got["b"]; ok {
+		t.Error("deleted entry should not be visible")
+	}
+	if len(upstream.requested) != 2 {
+		t.Errorf("overlay reads should not reach upstream, got %v", upstream.requested)
+	}
+	if upstream.entries["a"] != "a0" {
+		t.Error("upstream must not be modified")
+	}
+
+	diff := fork.Diff()
+	want := []EntryChange{
+		{Key: "a", Kind: ChangeUpdated, Before: "a0", After: "a1"},
+		{Key: "c", Kind: ChangeCreated, After: "c1"},
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i], diff[i])
+		}
+	}
+
+	fork.Reset()
+	if len(fork.Diff()) != 0 {
+		t.Error("reset should discard local changes")
+	}
+	if got := fork.Entries(); got["a"] != "a0" {
+		t.Errorf("reset should restore fork-point values, got %v", got)
+	}
+}
+
+func TestForkState_DiffReportsDeletion(t *testing.T) {
+	upstream := &countingProvider{entries: map[string]string{"b": "b0"}}
+	fork := NewForkState(ForkPoint{Network: rpc.Testnet, Ledger: 100}, upstream)
+
+	if _, err := fork.GetLedgerEntries(context.Background(), []string{"b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fork.Delete("b")
+
+	diff := fork.Diff()
+	if len(diff) != 1 || diff[0].Kind != ChangeDeleted || diff[0].Before != "b0" {
+		t.Errorf("expected deletion of b, got %+v", diff)
+	}
+}
+
+func TestForkState_StatsCountsDrift(t *testing.T) {
+	upstream := &countingProvider{entries: map[string]string{
+		"old": ledgerEntryXDR(t, 90),
+		"new": ledgerEntryXDR(t, 150),
+	}}
+	fork := NewForkState(ForkPoint{Network: rpc.Mainnet, Ledger: 100}, upstream)
+
+	if _, err := fork.GetLedgerEntries(context.Background(), []string{"old", "new"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fork.Set("local", "x")
+
+	stats := fork.Stats()
+	if stats.Fetched != 2 || stats.Changed != 1 || stats.Drifted != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestForkedSession_StateCommands(t *testing.T) {
+	upstream := &countingProvider{entries: map[string]string{"a": "a0"}}
+	fork := NewForkState(ForkPoint{Network: rpc.Mainnet, Ledger: 100, CloseTime: 1700000000}, upstream)
+	session := NewForkedSession(&MockRunner{}, mustTestRPCClient(t), fork)
+
+	if session.ledgerSequence != 101 || session.timestamp != 1700000000 {
+		t.Errorf("session should start after the fork ledger, got seq=%d ts=%d", session.ledgerSequence, session.timestamp)
+	}
+	if _, err := fork.GetLedgerEntries(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	local := NewSession(&MockRunner{}, mustTestRPCClient(t), rpc.Mainnet)
+	local.ledgerEntries["b"] = "b1"
+	local.ledgerSequence = 200
+	if err := local.SaveState(statePath); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	if err := session.LoadState(statePath); err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	summary := session.GetStateSummary()
+	if summary.EntryCount != 2 || summary.Fork == nil || summary.Fork.Changed != 1 {
+		t.Errorf("loaded entries should sit on top of the fork, got %+v", summary)
+	}
+
+	snapPath := filepath.Join(dir, "fork.json")
+	if err := session.ExportSnapshot(snapPath); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	snap, err := snapshot.Load(snapPath)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	entries := snap.ToMap()
+	if entries["a"] != "a0" || entries["b"] != "b1" {
+		t.Errorf("unexpected exported entries: %v", entries)
+	}
+
+	fork.Set("c", "c1")
+	session.ResetState()
+	diff := session.Diff()
+	if len(diff) != 1 || diff[0].Key != "b" {
+		t.Errorf("reset should return to the loaded state, got %+v", diff)
+	}
+}
+
+func TestForkedSession_UpdateLedgerStateWritesOverlay(t *testing.T) {
+	upstream := &countingProvider{entries: map[string]string{"a": "a0", "b": "b0"}}
+	fork := NewForkState(ForkPoint{Network: rpc.Mainnet, Ledger: 100, CloseTime: 1700000000}, upstream)
+	session := NewForkedSession(&MockRunner{}, mustTestRPCClient(t), fork)
+	if _, err := fork.GetLedgerEntries(context.Background(), []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	session.updateLedgerState(&simulator.SimulationResponse{
+		Status: "success",
+		LedgerChanges: []simulator.LedgerChange{
+			{Key: "a", Entry: "a1"},
+			{Key: "b"},
+			{Key: "c", Entry: "c1"},
+		},
+	})
+
+	want := []EntryChange{
+		{Key: "a", Kind: ChangeUpdated, Before: "a0", After: "a1"},
+		{Key: "b", Kind: ChangeDeleted, Before: "b0"},
+		{Key: "c", Kind: ChangeCreated, After: "c1"},
+	}
+	if diff := session.Diff(); !reflect.DeepEqual(diff, want) {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+	if session.ledgerSequence != 102 || session.timestamp != 1700000000+ledgerCloseSeconds {
+		t.Errorf("ledger should close after the fork ledger, got seq=%d ts=%d", session.ledgerSequence, session.timestamp)
+	}
+	if len(upstream.requested) != 2 {
+		t.Errorf("local writes should not reach upstream, requested %v", upstream.requested)
+	}
+}
+
+// metaLedgers serves ledger close meta from memory.
+type metaLedgers map[uint32]xdr.LedgerCloseMeta
+
+func (m metaLedgers) LedgerCloseMeta(_ context.Context, seq uint32) (*xdr.LedgerCloseMeta, error) {
+	lcm, ok := m[seq]
+	if !ok {
+		return nil, reconstruct.ErrLedgerNotFound
+	}
+	return &lcm, nil
+}
+
+func TestForkState_RollsBackDriftFromLedgerMeta(t *testing.T) {
+	current := ledgerEntryXDR(t, 150)
+	var atFork, now xdr.LedgerEntry
+	if err := xdr.SafeUnmarshalBase64(ledgerEntryXDR(t, 90), &atFork); err != nil {
+		t.Fatal(err)
+	}
+	if err := xdr.SafeUnmarshalBase64(current, &now); err != nil {
+		t.Fatal(err)
+	}
+	ledgerKey, err := now.LedgerKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := xdr.MarshalBase64(ledgerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &atFork},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &now},
+	}
+	ledgers := metaLedgers{150: {V: 1, V1: &xdr.LedgerCloseMetaV1{
+		LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 150}},
+		TxSet:        xdr.GeneralizedTransactionSet{V: 1, V1TxSet: &xdr.TransactionSetV1{}},
+		TxProcessing: []xdr.TransactionResultMeta{{
+			TxApplyProcessing: xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+				Operations: []xdr.OperationMeta{{Changes: changes}},
+			}},
+		}},
+	}}}
+
+	// The mirror must hold every ledger after the fork point.
+	fork := NewForkState(ForkPoint{Network: rpc.Mainnet, Ledger: 149}, &countingProvider{entries: map[string]string{key: current}})
+	fork.SetLedgerMeta(ledgers)
+
+	got, err := fork.GetLedgerEntries(context.Background(), []string{key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := ledgerEntryXDR(t, 90); got[key] != want {
+		t.Errorf("expected the fork-point value, got %q", got[key])
+	}
+
+	stats := fork.Stats()
+	if stats.Drifted != 0 || stats.Rebuilt != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestForkState_RestoresEntriesDeletedAfterFork(t *testing.T) {
+	var atFork xdr.LedgerEntry
+	if err := xdr.SafeUnmarshalBase64(ledgerEntryXDR(t, 90), &atFork); err != nil {
+		t.Fatal(err)
+	}
+	ledgerKey, err := atFork.LedgerKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := xdr.MarshalBase64(ledgerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &atFork},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &ledgerKey},
+	}
+	ledgers := metaLedgers{150: {V: 1, V1: &xdr.LedgerCloseMetaV1{
+		LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 150}},
+		TxSet:        xdr.GeneralizedTransactionSet{V: 1, V1TxSet: &xdr.TransactionSetV1{}},
+		TxProcessing: []xdr.TransactionResultMeta{{
+			TxApplyProcessing: xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+				Operations: []xdr.OperationMeta{{Changes: changes}},
+			}},
+		}},
+	}}}
+
+	// Upstream no longer holds the entry.
+	fork := NewForkState(ForkPoint{Network: rpc.Mainnet, Ledger: 149}, &countingProvider{entries: map[string]string{}})
+	fork.SetLedgerMeta(ledgers)
+
+	got, err := fork.GetLedgerEntries(context.Background(), []string{key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := ledgerEntryXDR(t, 90); got[key] != want {
+		t.Errorf("expected the fork-point value, got %q", got[key])
+	}
+	if stats := fork.Stats(); stats.Rebuilt != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
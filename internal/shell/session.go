@@ -10,10 +10,17 @@ import (
 	"os"
 	"time"
 
+	"github.com/dotandev/hintents/internal/reconstruct"
 	"github.com/dotandev/hintents/internal/rpc"
 	"github.com/dotandev/hintents/internal/simulator"
+	"github.com/dotandev/hintents/internal/snapshot"
+	"github.com/stellar/go-stellar-sdk/xdr"
 )
 
+// ledgerCloseSeconds is how far the ledger close time advances per
+// invocation, matching the network's target close time.
+const ledgerCloseSeconds = 5
+
 // Session represents an interactive shell session with persistent ledger state
 type Session struct {
 	runner          simulator.RunnerInterface
@@ -24,6 +31,9 @@ type Session struct {
 	timestamp       int64
 	invocationCount int
 	initialState    *LedgerState
+	// fork holds the ledger state of a forked session; ledgerEntries is
+	// unused when it is set.
+	fork *ForkState
 }
 
 // LedgerState represents the state of the ledger at a point in time
@@ -39,6 +49,8 @@ type StateSummary struct {
 	LedgerSequence  uint32
 	Timestamp       int64
 	InvocationCount int
+	// Fork is set for forked sessions.
+	Fork *ForkStats
 }
 
 // InvocationResult represents the result of a contract invocation
@@ -67,6 +79,46 @@ func NewSession(runner simulator.RunnerInterface, rpcClient *rpc.Client, network
 	}
 }
 
+// NewForkedSession creates a session on top of a network fork. Ledger
+// entries are fetched on demand through fork and local changes stay in its
+// overlay.
+func NewForkedSession(runner simulator.RunnerInterface, rpcClient *rpc.Client, fork *ForkState) *Session {
+	point := fork.Point()
+	timestamp := point.CloseTime
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+	// The first local invocation runs in the ledger after the fork point.
+	sequence := point.Ledger + 1
+	return &Session{
+		runner:         runner,
+		rpcClient:      rpcClient,
+		network:        point.Network,
+		ledgerEntries:  make(map[string]string),
+		ledgerSequence: sequence,
+		timestamp:      timestamp,
+		initialState: &LedgerState{
+			Entries:        make(map[string]string),
+			LedgerSequence: sequence,
+			Timestamp:      timestamp,
+		},
+		fork: fork,
+	}
+}
+
+// Fork returns the fork state, or nil if the session is not forked.
+func (s *Session) Fork() *ForkState {
+	return s.fork
+}
+
+// entries returns the ledger entries visible to the next invocation.
+func (s *Session) entries() map[string]string {
+	if s.fork != nil {
+		return s.fork.Entries()
+	}
+	return s.ledgerEntries
+}
+
 // Invoke executes a contract function and updates the ledger state
 func (s *Session) Invoke(ctx context.Context, contractID, function string, args []string) (*InvocationResult, error) {
 	// Build transaction envelope for the invocation
@@ -75,11 +127,18 @@ func (s *Session) Invoke(ctx context.Context, contractID, function string, args
 		return nil, fmt.Errorf("failed to build envelope: %w", err)
 	}
 
+	// Pull the state the invocation reads into a forked session.
+	if s.fork != nil {
+		if err := s.loadContractState(ctx, contractID, envelopeXDR); err != nil {
+			return nil, fmt.Errorf("failed to load contract from %s: %w", s.fork.Point(), err)
+		}
+	}
+
 	// Create simulation request
 	req := &simulator.SimulationRequest{
 		EnvelopeXdr:    envelopeXDR,
 		ResultMetaXdr:  "",
-		LedgerEntries:  s.ledgerEntries,
+		LedgerEntries:  s.entries(),
 		Timestamp:      s.timestamp,
 		LedgerSequence: s.ledgerSequence,
 	}
@@ -105,6 +164,33 @@ func (s *Session) Invoke(ctx context.Context, contractID, function string, args
 	return result, nil
 }
 
+// loadContractState fetches the contract's instance and code, and every
+// storage entry in the envelope's footprint, through the fork. Entries already
+// fetched are served from the fork's cache.
+func (s *Session) loadContractState(ctx context.Context, contractID, envelopeXDR string) error {
+	if _, err := rpc.FetchContractBytecode(ctx, s.fork, contractID); err != nil {
+		return err
+	}
+
+	var env xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXDR, &env); err != nil {
+		return fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	var keys []string
+	for _, key := range reconstruct.FootprintKeys(env) {
+		encoded, err := xdr.MarshalBase64(key)
+		if err != nil {
+			return fmt.Errorf("failed to encode footprint key: %w", err)
+		}
+		keys = append(keys, encoded)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.fork.GetLedgerEntries(ctx, keys)
+	return err
+}
+
 // buildInvocationEnvelope creates a transaction envelope for contract invocation
 func (s *Session) buildInvocationEnvelope(contractID, function string, args []string) (string, error) {
 	// This is a simplified version - in production, you'd use stellar-sdk to build proper XDR
@@ -120,36 +206,83 @@ func (s *Session) buildInvocationEnvelope(contractID, function string, args []st
 	return "", fmt.Errorf("envelope building not yet implemented - requires stellar-sdk integration")
 }
 
-// updateLedgerState updates the session's ledger state based on simulation results
+// updateLedgerState closes the invocation's ledger: it applies the entries
+// the simulation wrote and advances the sequence and the close time.
 func (s *Session) updateLedgerState(resp *simulator.SimulationResponse) {
-	// Increment ledger sequence
+	s.applyChanges(resp.LedgerChanges)
+
+	// Each invocation closes one ledger, so time moves on from the session's
+	// start (the fork ledger's close time when forked) rather than the clock.
 	s.ledgerSequence++
+	s.timestamp += ledgerCloseSeconds
+}
 
-	// Update timestamp
-	now := time.Now().Unix()
-	if now <= s.timestamp {
-		now = s.timestamp + 1
+// applyChanges writes the entries an invocation created or updated and drops
+// the ones it deleted. A forked session only writes to the overlay.
+func (s *Session) applyChanges(changes []simulator.LedgerChange) {
+	for _, change := range changes {
+		switch {
+		case s.fork != nil && change.Entry == "":
+			s.fork.Delete(change.Key)
+		case s.fork != nil:
+			s.fork.Set(change.Key, change.Entry)
+		case change.Entry == "":
+			delete(s.ledgerEntries, change.Key)
+		default:
+			s.ledgerEntries[change.Key] = change.Entry
+		}
 	}
-	s.timestamp = now
+}
 
-	// TODO: Extract and update ledger entries from simulation response
-	// This would involve parsing the ResultMetaXDR to get state changes
+// setEntries replaces the session's local entries.
+func (s *Session) setEntries(entries map[string]string) {
+	if s.fork != nil {
+		s.fork.Reset()
+		for k, v := range entries {
+			s.fork.Set(k, v)
+		}
+		return
+	}
+	s.ledgerEntries = make(map[string]string, len(entries))
+	for k, v := range entries {
+		s.ledgerEntries[k] = v
+	}
 }
 
 // GetStateSummary returns a summary of the current ledger state
 func (s *Session) GetStateSummary() StateSummary {
-	return StateSummary{
-		EntryCount:      len(s.ledgerEntries),
+	summary := StateSummary{
+		EntryCount:      len(s.entries()),
 		LedgerSequence:  s.ledgerSequence,
 		Timestamp:       s.timestamp,
 		InvocationCount: s.invocationCount,
 	}
+	if s.fork != nil {
+		stats := s.fork.Stats()
+		summary.Fork = &stats
+	}
+	return summary
+}
+
+// Diff returns the changes made in this session. A forked session is
+// compared with the fork point, any other session with its initial state.
+func (s *Session) Diff() []EntryChange {
+	if s.fork != nil {
+		return s.fork.Diff()
+	}
+	return diffEntries(s.initialState.Entries, s.ledgerEntries)
+}
+
+// ExportSnapshot writes the current ledger entries to a snapshot file that
+// other commands can replay with --state-from snapshot:<file>.
+func (s *Session) ExportSnapshot(filename string) error {
+	return snapshot.Save(filename, snapshot.FromMap(s.entries()))
 }
 
 // SaveState saves the current ledger state to a file
 func (s *Session) SaveState(filename string) error {
 	state := &LedgerState{
-		Entries:        s.ledgerEntries,
+		Entries:        s.entries(),
 		LedgerSequence: s.ledgerSequence,
 		Timestamp:      s.timestamp,
 	}
@@ -178,8 +311,12 @@ func (s *Session) LoadState(filename string) error {
 		return fmt.Errorf("failed to unmarshal state: %w", err)
 	}
 
-	// Update session state
-	s.ledgerEntries = state.Entries
+	// Update session state. A forked session keeps the loaded entries in
+	// its overlay, on top of the fork point.
+	if state.Entries == nil {
+		state.Entries = make(map[string]string)
+	}
+	s.setEntries(state.Entries)
 	s.ledgerSequence = state.LedgerSequence
 	s.timestamp = state.Timestamp
 
@@ -191,10 +328,7 @@ func (s *Session) LoadState(filename string) error {
 
 // ResetState resets the ledger state to the initial state
 func (s *Session) ResetState() {
-	s.ledgerEntries = make(map[string]string)
-	for k, v := range s.initialState.Entries {
-		s.ledgerEntries[k] = v
-	}
+	s.setEntries(s.initialState.Entries)
 	s.ledgerSequence = s.initialState.LedgerSequence
 	s.timestamp = s.initialState.Timestamp
 	s.invocationCount = 0
@@ -4,6 +4,7 @@
 package simulator
 
 import (
+	"container/heap"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -12,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dotandev/hintents/internal/errors"
 	"github.com/dotandev/hintents/internal/logger"
 )
 
@@ -22,28 +24,155 @@ const (
 	JobStatusRunning   JobStatus = "running"
 	JobStatusCompleted JobStatus = "completed"
 	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
 )
 
+// Finished reports whether the status is terminal.
+func (s JobStatus) Finished() bool {
+	switch s {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	}
+	return false
+}
+
 type AsyncJob struct {
-	ID          string              `json:"id"`
-	Status      JobStatus           `json:"status"`
-	SubmittedAt time.Time           `json:"submitted_at"`
-	CompletedAt *time.Time          `json:"completed_at,omitempty"`
-	Response    *SimulationResponse `json:"response,omitempty"`
-	Error       string              `json:"error,omitempty"`
+	ID              string              `json:"id"`
+	Status          JobStatus           `json:"status"`
+	Priority        int                 `json:"priority"`
+	Progress        float64             `json:"progress"`
+	ProgressMessage string              `json:"progress_message,omitempty"`
+	SubmittedAt     time.Time           `json:"submitted_at"`
+	StartedAt       *time.Time          `json:"started_at,omitempty"`
+	CompletedAt     *time.Time          `json:"completed_at,omitempty"`
+	Request         *SimulationRequest  `json:"request,omitempty"`
+	Response        *SimulationResponse `json:"response,omitempty"`
+	Error           string              `json:"error,omitempty"`
+}
+
+// clone returns a copy of the job. Request and Response are shared; they are
+// never modified once attached to a job.
+func (j *AsyncJob) clone() *AsyncJob {
+	c := *j
+	if j.StartedAt != nil {
+		t := *j.StartedAt
+		c.StartedAt = &t
+	}
+	if j.CompletedAt != nil {
+		t := *j.CompletedAt
+		c.CompletedAt = &t
+	}
+	return &c
+}
+
+const (
+	// DefaultAsyncMaxConcurrent is the number of jobs run at once when
+	// AsyncConfig.MaxConcurrent is unset.
+	DefaultAsyncMaxConcurrent = 4
+
+	// DefaultAsyncJobTTL is how long finished jobs are kept when
+	// AsyncConfig.TTL is unset.
+	DefaultAsyncJobTTL = time.Hour
+)
+
+// AsyncConfig configures an AsyncRunner.
+type AsyncConfig struct {
+	// Store persists jobs. Defaults to a MemoryJobStore.
+	Store JobStore
+	// MaxConcurrent bounds the number of simulations running at once.
+	MaxConcurrent int
+	// TTL is how long completed, failed and cancelled jobs are kept before
+	// being evicted. A negative TTL disables eviction.
+	TTL time.Duration
+	// EvictInterval is how often expired jobs are evicted. Defaults to TTL/4,
+	// at most one minute.
+	EvictInterval time.Duration
+	// OnUpdate, if set, receives a snapshot of a job after every status or
+	// progress change. It is called from worker goroutines and must not block.
+	OnUpdate func(job *AsyncJob)
+	// OnDiagnostic, if set, receives each diagnostic event of a running job
+	// as the runner decodes it, before the job finishes. Events a runner does
+	// not stream, such as those of a cached result, are delivered once it
+	// returns. It is called from worker goroutines and must not block.
+	OnDiagnostic func(jobID string, index int, event DiagnosticEvent)
 }
 
+// AsyncRunner queues simulations and runs them in the background with
+// bounded concurrency. Higher priority jobs run first; jobs of equal
+// priority run in submission order. Jobs that were pending or running when
+// the runner was closed are resumed by the next runner using the same store.
 type AsyncRunner struct {
 	runner RunnerInterface
-	jobs   map[string]*AsyncJob
-	mu     sync.RWMutex
+	store  JobStore
+	cfg    AsyncConfig
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     jobQueue
+	queued    map[string]*queueItem
+	seq       uint64
+	running   map[string]context.CancelFunc
+	cancelled map[string]bool
+	closed    bool
+
+	// jobMu serialises read-modify-write updates of stored jobs.
+	jobMu sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
+// NewAsyncRunner creates an AsyncRunner with an in-memory job store and
+// default limits.
 func NewAsyncRunner(runner RunnerInterface) *AsyncRunner {
-	return &AsyncRunner{
-		runner: runner,
-		jobs:   make(map[string]*AsyncJob),
+	a, _ := NewAsyncRunnerWithConfig(runner, AsyncConfig{})
+	return a
+}
+
+// NewAsyncRunnerWithConfig creates an AsyncRunner and re-queues any pending
+// or interrupted jobs found in cfg.Store.
+func NewAsyncRunnerWithConfig(runner RunnerInterface, cfg AsyncConfig) (*AsyncRunner, error) {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryJobStore()
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultAsyncMaxConcurrent
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = DefaultAsyncJobTTL
+	}
+	if cfg.EvictInterval <= 0 && cfg.TTL > 0 {
+		cfg.EvictInterval = cfg.TTL / 4
+		if cfg.EvictInterval > time.Minute {
+			cfg.EvictInterval = time.Minute
+		}
+	}
+
+	a := &AsyncRunner{
+		runner:    runner,
+		store:     cfg.Store,
+		cfg:       cfg,
+		queued:    make(map[string]*queueItem),
+		running:   make(map[string]context.CancelFunc),
+		cancelled: make(map[string]bool),
+		stop:      make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+
+	if err := a.recover(); err != nil {
+		return nil, err
 	}
+
+	for i := 0; i < cfg.MaxConcurrent; i++ {
+		a.wg.Add(1)
+		go a.worker()
+	}
+	if cfg.TTL > 0 {
+		a.wg.Add(1)
+		go a.evictLoop()
+	}
+
+	return a, nil
 }
 
 func generateJobID() string {
@@ -52,69 +181,111 @@ func generateJobID() string {
 	return hex.EncodeToString(b)
 }
 
+// recover re-queues jobs left pending or running by a previous runner.
+func (a *AsyncRunner) recover() error {
+	jobs, err := a.store.List(JobFilter{Status: []JobStatus{JobStatusPending, JobStatusRunning}})
+	if err != nil {
+		return fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		if job.Request == nil {
+			t := time.Now()
+			job.Status = JobStatusFailed
+			job.Error = "job request was not persisted"
+			job.CompletedAt = &t
+		} else {
+			job.Status = JobStatusPending
+			job.StartedAt = nil
+			job.Progress = 0
+			job.ProgressMessage = ""
+		}
+		if err := a.store.Put(job); err != nil {
+			return err
+		}
+		if job.Status == JobStatusPending {
+			a.enqueue(job.ID, job.Priority)
+		}
+	}
+
+	if len(jobs) > 0 {
+		logger.Logger.Info("Async jobs recovered", "count", len(jobs))
+	}
+	return nil
+}
+
+// -------------------- Submission --------------------
+
+// Submit queues req with priority 0.
 func (a *AsyncRunner) Submit(req *SimulationRequest) (string, error) {
+	return a.SubmitWithPriority(req, 0)
+}
+
+// SubmitWithPriority queues req. Jobs with a higher priority run first.
+func (a *AsyncRunner) SubmitWithPriority(req *SimulationRequest, priority int) (string, error) {
+	// Snapshot the request so later changes by the caller cannot leak into
+	// the queued job.
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
+	var snapshot SimulationRequest
+	if err := json.Unmarshal(reqBytes, &snapshot); err != nil {
+		return "", fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	a.mu.Lock()
+	closed := a.closed
+	a.mu.Unlock()
+	if closed {
+		return "", fmt.Errorf("async runner is closed")
+	}
 
-	jobID := generateJobID()
-	now := time.Now()
 	job := &AsyncJob{
-		ID:          jobID,
+		ID:          generateJobID(),
 		Status:      JobStatusPending,
-		SubmittedAt: now,
+		Priority:    priority,
+		SubmittedAt: time.Now(),
+		Request:     &snapshot,
+	}
+	if err := a.store.Put(job); err != nil {
+		return "", err
 	}
 
 	a.mu.Lock()
-	a.jobs[jobID] = job
+	a.enqueue(job.ID, priority)
 	a.mu.Unlock()
 
-	logger.Logger.Info("Async simulation submitted", "job_id", jobID)
-
-	go func() {
-		a.mu.Lock()
-		job.Status = JobStatusRunning
-		a.mu.Unlock()
-
-		var simReq SimulationRequest
-		if err := json.Unmarshal(reqBytes, &simReq); err != nil {
-			a.mu.Lock()
-			job.Status = JobStatusFailed
-			job.Error = fmt.Sprintf("failed to unmarshal request: %v", err)
-			t := time.Now()
-			job.CompletedAt = &t
-			a.mu.Unlock()
-			return
-		}
-
-		resp, err := a.runner.Run(&simReq)
-
-		a.mu.Lock()
-		t := time.Now()
-		job.CompletedAt = &t
-		if err != nil {
-			job.Status = JobStatusFailed
-			job.Error = err.Error()
-		} else {
-			job.Status = JobStatusCompleted
-			job.Response = resp
-		}
-		a.mu.Unlock()
-	}()
+	logger.Logger.Info("Async simulation submitted", "job_id", job.ID, "priority", priority)
+	return job.ID, nil
+}
 
-	return jobID, nil
+// enqueue adds a job to the run queue. The caller must hold a.mu, except
+// during construction.
+func (a *AsyncRunner) enqueue(id string, priority int) {
+	a.seq++
+	item := &queueItem{id: id, priority: priority, seq: a.seq}
+	heap.Push(&a.queue, item)
+	a.queued[id] = item
+	if a.cond != nil {
+		a.cond.Signal()
+	}
 }
 
-func (a *AsyncRunner) Poll(jobID string) (*AsyncJob, error) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+// -------------------- Queries --------------------
 
-	job, exists := a.jobs[jobID]
-	if !exists {
+// Poll returns a snapshot of the job.
+func (a *AsyncRunner) Poll(jobID string) (*AsyncJob, error) {
+	job, err := a.store.Get(jobID)
+	if errors.Is(err, ErrJobNotFound) {
 		return nil, fmt.Errorf("job %s not found", jobID)
 	}
-	return job, nil
+	return job, err
+}
+
+// List returns the jobs matching filter, oldest first.
+func (a *AsyncRunner) List(filter JobFilter) ([]*AsyncJob, error) {
+	return a.store.List(filter)
 }
 
 type PollConfig struct {
@@ -145,16 +316,322 @@ func (a *AsyncRunner) Wait(ctx context.Context, jobID string, cfg PollConfig) (*
 			if err != nil {
 				return nil, err
 			}
-			switch job.Status {
-			case JobStatusCompleted, JobStatusFailed:
+			if job.Status.Finished() {
 				return job, nil
 			}
 		}
 	}
 }
 
+// -------------------- Control --------------------
+
+// Cancel stops a pending or running job. Cancelling a finished job is an error.
+func (a *AsyncRunner) Cancel(jobID string) error {
+	a.mu.Lock()
+	if item, ok := a.queued[jobID]; ok {
+		heap.Remove(&a.queue, item.index)
+		delete(a.queued, jobID)
+		a.mu.Unlock()
+
+		return a.update(jobID, func(job *AsyncJob) {
+			t := time.Now()
+			job.Status = JobStatusCancelled
+			job.CompletedAt = &t
+		})
+	}
+	if cancel, ok := a.running[jobID]; ok {
+		a.cancelled[jobID] = true
+		cancel()
+		a.mu.Unlock()
+		return nil
+	}
+	a.mu.Unlock()
+
+	job, err := a.Poll(jobID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("job %s is already %s", jobID, job.Status)
+}
+
+// Cleanup removes a job from the store.
 func (a *AsyncRunner) Cleanup(jobID string) {
+	if err := a.store.Delete(jobID); err != nil {
+		logger.Logger.Warn("Failed to delete async job", "job_id", jobID, "error", err)
+	}
+}
+
+// Close stops the workers and closes the job store. Running jobs are
+// interrupted and left pending so that a new runner on the same store
+// resumes them.
+func (a *AsyncRunner) Close() error {
 	a.mu.Lock()
-	delete(a.jobs, jobID)
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	for _, cancel := range a.running {
+		cancel()
+	}
+	a.cond.Broadcast()
 	a.mu.Unlock()
+
+	close(a.stop)
+	a.wg.Wait()
+	return a.store.Close()
+}
+
+// -------------------- Execution --------------------
+
+func (a *AsyncRunner) worker() {
+	defer a.wg.Done()
+
+	for {
+		a.mu.Lock()
+		for a.queue.Len() == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.closed {
+			a.mu.Unlock()
+			return
+		}
+		item := heap.Pop(&a.queue).(*queueItem)
+		delete(a.queued, item.id)
+		ctx, cancel := context.WithCancel(context.Background())
+		a.running[item.id] = cancel
+		a.mu.Unlock()
+
+		a.execute(ctx, item.id)
+		cancel()
+
+		a.mu.Lock()
+		delete(a.running, item.id)
+		delete(a.cancelled, item.id)
+		a.mu.Unlock()
+	}
+}
+
+func (a *AsyncRunner) execute(ctx context.Context, jobID string) {
+	var req *SimulationRequest
+	err := a.update(jobID, func(job *AsyncJob) {
+		t := time.Now()
+		job.Status = JobStatusRunning
+		job.StartedAt = &t
+		req = job.Request
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to start async job", "job_id", jobID, "error", err)
+		return
+	}
+
+	ctx = WithProgress(ctx, func(fraction float64, message string) {
+		_ = a.update(jobID, func(job *AsyncJob) {
+			if job.Status != JobStatusRunning {
+				return
+			}
+			job.Progress = clampProgress(fraction)
+			job.ProgressMessage = message
+		})
+	})
+
+	reported := 0
+	if a.cfg.OnDiagnostic != nil {
+		ctx = WithDiagnostics(ctx, func(index int, event DiagnosticEvent) {
+			a.cfg.OnDiagnostic(jobID, index, event)
+			reported = index + 1
+		})
+	}
+
+	resp, runErr := a.runner.Run(ctx, req)
+	if runErr != nil {
+		// Keep what erst-sim reported about a failed run: its diagnostic
+		// events, logs and budget.
+		resp = nil
+		var simErr *SimulatorError
+		if errors.As(runErr, &simErr) {
+			resp = simErr.Response
+		}
+	}
+
+	a.mu.Lock()
+	cancelled := a.cancelled[jobID]
+	shuttingDown := a.closed
+	a.mu.Unlock()
+
+	if a.cfg.OnDiagnostic != nil && resp != nil && !cancelled && !(shuttingDown && ctx.Err() != nil) {
+		for i := reported; i < len(resp.DiagnosticEvents); i++ {
+			a.cfg.OnDiagnostic(jobID, i, resp.DiagnosticEvents[i])
+		}
+	}
+
+	err = a.update(jobID, func(job *AsyncJob) {
+		t := time.Now()
+		switch {
+		case cancelled:
+			job.Status = JobStatusCancelled
+			job.CompletedAt = &t
+		case shuttingDown && ctx.Err() != nil:
+			// Interrupted by Close; leave it for the next runner.
+			job.Status = JobStatusPending
+			job.StartedAt = nil
+			job.Progress = 0
+			job.ProgressMessage = ""
+		case runErr != nil:
+			job.Status = JobStatusFailed
+			job.Error = runErr.Error()
+			job.Response = resp
+			job.CompletedAt = &t
+		default:
+			job.Status = JobStatusCompleted
+			job.Response = resp
+			job.Progress = 1
+			job.CompletedAt = &t
+		}
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to record async job result", "job_id", jobID, "error", err)
+	}
+}
+
+// update applies fn to the stored job, writes it back and notifies
+// cfg.OnUpdate.
+func (a *AsyncRunner) update(jobID string, fn func(job *AsyncJob)) error {
+	a.jobMu.Lock()
+	job, err := a.store.Get(jobID)
+	if err == nil {
+		fn(job)
+		err = a.store.Put(job)
+	}
+	a.jobMu.Unlock()
+
+	if err == nil && a.cfg.OnUpdate != nil {
+		a.cfg.OnUpdate(job)
+	}
+	return err
+}
+
+func (a *AsyncRunner) evictLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.cfg.EvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			removed, err := a.store.DeleteFinishedBefore(time.Now().Add(-a.cfg.TTL))
+			if err != nil {
+				logger.Logger.Warn("Async job eviction failed", "error", err)
+			} else if removed > 0 {
+				logger.Logger.Debug("Async jobs evicted", "count", removed)
+			}
+		}
+	}
+}
+
+// -------------------- Progress --------------------
+
+// ProgressFunc receives progress updates for a running job. fraction is in
+// the range [0, 1].
+type ProgressFunc func(fraction float64, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context carrying fn for ReportProgress.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the job running under ctx, if any.
+// Runner and PoolRunner call it from Run once the request is prepared, when
+// the simulation starts and when it finishes; it is a no-op outside an
+// AsyncRunner.
+func ReportProgress(ctx context.Context, fraction float64, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(fraction, message)
+	}
+}
+
+func clampProgress(fraction float64) float64 {
+	switch {
+	case fraction < 0:
+		return 0
+	case fraction > 1:
+		return 1
+	}
+	return fraction
+}
+
+// -------------------- Diagnostics --------------------
+
+// DiagnosticFunc receives the diagnostic events of a running simulation one
+// at a time, in emission order.
+type DiagnosticFunc func(index int, event DiagnosticEvent)
+
+type diagnosticKey struct{}
+
+// WithDiagnostics returns a context carrying fn for ReportDiagnostic.
+func WithDiagnostics(ctx context.Context, fn DiagnosticFunc) context.Context {
+	return context.WithValue(ctx, diagnosticKey{}, fn)
+}
+
+// ReportDiagnostic reports a diagnostic event to the job running under ctx,
+// if any. Runner and PoolRunner call it from Run for each event as they decode
+// erst-sim's response; it is a no-op outside an AsyncRunner.
+func ReportDiagnostic(ctx context.Context, index int, event DiagnosticEvent) {
+	if fn, ok := ctx.Value(diagnosticKey{}).(DiagnosticFunc); ok {
+		fn(index, event)
+	}
+}
+
+// wantsDiagnostics reports whether ctx carries a DiagnosticFunc.
+func wantsDiagnostics(ctx context.Context) bool {
+	_, ok := ctx.Value(diagnosticKey{}).(DiagnosticFunc)
+	return ok
+}
+
+// -------------------- Queue --------------------
+
+type queueItem struct {
+	id       string
+	priority int
+	seq      uint64
+	index    int
+}
+
+// jobQueue is a container/heap ordered by priority (highest first) and then
+// by submission order.
+type jobQueue []*queueItem
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *jobQueue) Push(x any) {
+	item := x.(*queueItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *jobQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
 }
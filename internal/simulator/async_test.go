@@ -5,12 +5,15 @@ package simulator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestAsyncRunner_SubmitAndPoll(t *testing.T) {
-	mock := NewMockRunner(func(req *SimulationRequest) (*SimulationResponse, error) {
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
 		return &SimulationResponse{
 			Status: "success",
 			Events: []string{"event1"},
@@ -48,7 +51,7 @@ func TestAsyncRunner_SubmitAndPoll(t *testing.T) {
 }
 
 func TestAsyncRunner_SubmitFailure(t *testing.T) {
-	mock := NewMockRunner(func(req *SimulationRequest) (*SimulationResponse, error) {
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
 		return nil, &ValidationError{Field: "envelope_xdr", Message: "invalid"}
 	})
 
@@ -73,8 +76,80 @@ func TestAsyncRunner_SubmitFailure(t *testing.T) {
 	}
 }
 
+func TestAsyncRunner_FailureKeepsResponse(t *testing.T) {
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		resp := &SimulationResponse{
+			Status:           "error",
+			Error:            "HostError: Error(Contract, #1)",
+			DiagnosticEvents: []DiagnosticEvent{{EventType: "diagnostic"}},
+			Logs:             []string{"panicked"},
+		}
+		return nil, &SimulatorError{Err: errors.New(resp.Error), Response: resp}
+	})
+
+	async := NewAsyncRunner(mock)
+	jobID, err := async.Submit(&SimulationRequest{EnvelopeXdr: "AAAA"})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	job, err := async.Wait(context.Background(), jobID, PollConfig{
+		Interval: 10 * time.Millisecond,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if job.Status != JobStatusFailed {
+		t.Errorf("expected failed, got %s", job.Status)
+	}
+	if job.Response == nil || len(job.Response.DiagnosticEvents) != 1 || len(job.Response.Logs) != 1 {
+		t.Errorf("expected the simulator's response on the failed job, got %+v", job.Response)
+	}
+}
+
+func TestAsyncRunner_Diagnostics(t *testing.T) {
+	events := []DiagnosticEvent{{EventType: "contract"}, {EventType: "diagnostic"}}
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		// Stream the first event; the second is only in the response.
+		ReportDiagnostic(ctx, 0, events[0])
+		return &SimulationResponse{Status: "success", DiagnosticEvents: events}, nil
+	})
+
+	var mu sync.Mutex
+	var got []string
+	async, err := NewAsyncRunnerWithConfig(mock, AsyncConfig{
+		OnDiagnostic: func(jobID string, index int, event DiagnosticEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, fmt.Sprintf("%d:%s", index, event.EventType))
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer async.Close()
+
+	jobID, err := async.Submit(&SimulationRequest{EnvelopeXdr: "AAAA"})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if _, err := async.Wait(context.Background(), jobID, PollConfig{
+		Interval: 10 * time.Millisecond,
+		Timeout:  5 * time.Second,
+	}); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"0:contract", "1:diagnostic"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected diagnostics %v, got %v", want, got)
+	}
+}
+
 func TestAsyncRunner_Timeout(t *testing.T) {
-	mock := NewMockRunner(func(req *SimulationRequest) (*SimulationResponse, error) {
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
 		time.Sleep(2 * time.Second)
 		return &SimulationResponse{Status: "success"}, nil
 	})
@@ -126,7 +201,7 @@ func TestAsyncRunner_PollNonExistent(t *testing.T) {
 }
 
 func TestAsyncRunner_ContextCancel(t *testing.T) {
-	mock := NewMockRunner(func(req *SimulationRequest) (*SimulationResponse, error) {
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
 		time.Sleep(10 * time.Second)
 		return &SimulationResponse{Status: "success"}, nil
 	})
@@ -151,3 +226,237 @@ func TestAsyncRunner_ContextCancel(t *testing.T) {
 		t.Fatal("expected context cancelled error")
 	}
 }
+
+func waitForStatus(t *testing.T, async *AsyncRunner, jobID string, status JobStatus) *AsyncJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := async.Poll(jobID)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s never reached status %s", jobID, status)
+	return nil
+}
+
+// blockingRunner blocks every Run until release is closed or ctx is done.
+func blockingRunner(release <-chan struct{}, started chan<- string) *MockRunner {
+	return NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		if started != nil {
+			started <- req.EnvelopeXdr
+		}
+		select {
+		case <-release:
+			return &SimulationResponse{Status: "success"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+func TestAsyncRunner_PriorityOrder(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 8)
+	async, err := NewAsyncRunnerWithConfig(blockingRunner(release, started), AsyncConfig{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer async.Close()
+
+	// Occupy the only worker so the remaining jobs queue up.
+	first, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "first"})
+	if got := <-started; got != "first" {
+		t.Fatalf("expected first job to start, got %s", got)
+	}
+
+	_, _ = async.SubmitWithPriority(&SimulationRequest{EnvelopeXdr: "low"}, 0)
+	_, _ = async.SubmitWithPriority(&SimulationRequest{EnvelopeXdr: "high"}, 10)
+	_, _ = async.SubmitWithPriority(&SimulationRequest{EnvelopeXdr: "low2"}, 0)
+
+	close(release)
+	waitForStatus(t, async, first, JobStatusCompleted)
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		order = append(order, <-started)
+	}
+	want := []string{"high", "low", "low2"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected run order %v, got %v", want, order)
+		}
+	}
+}
+
+func TestAsyncRunner_BoundedConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return &SimulationResponse{Status: "success"}, nil
+	})
+
+	async, err := NewAsyncRunnerWithConfig(mock, AsyncConfig{MaxConcurrent: 2})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer async.Close()
+
+	var ids []string
+	for i := 0; i < 6; i++ {
+		id, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "AAAA"})
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		waitForStatus(t, async, id, JobStatusCompleted)
+	}
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent jobs, saw %d", peak)
+	}
+}
+
+func TestAsyncRunner_CancelPendingAndRunning(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan string, 4)
+	async, err := NewAsyncRunnerWithConfig(blockingRunner(release, started), AsyncConfig{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer async.Close()
+
+	running, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "running"})
+	<-started
+	pending, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "pending"})
+
+	if err := async.Cancel(pending); err != nil {
+		t.Fatalf("Cancel pending failed: %v", err)
+	}
+	waitForStatus(t, async, pending, JobStatusCancelled)
+
+	if err := async.Cancel(running); err != nil {
+		t.Fatalf("Cancel running failed: %v", err)
+	}
+	waitForStatus(t, async, running, JobStatusCancelled)
+
+	if err := async.Cancel(running); err == nil {
+		t.Error("expected error cancelling a finished job")
+	}
+	if err := async.Cancel("nonexistent"); err == nil {
+		t.Error("expected error cancelling an unknown job")
+	}
+}
+
+func TestAsyncRunner_Progress(t *testing.T) {
+	reported := make(chan struct{})
+	release := make(chan struct{})
+	mock := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		ReportProgress(ctx, 0.5, "halfway")
+		close(reported)
+		<-release
+		return &SimulationResponse{Status: "success"}, nil
+	})
+
+	async := NewAsyncRunner(mock)
+	defer async.Close()
+
+	id, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "AAAA"})
+	<-reported
+
+	job, err := async.Poll(id)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if job.Progress != 0.5 || job.ProgressMessage != "halfway" {
+		t.Errorf("expected progress 0.5 halfway, got %v %q", job.Progress, job.ProgressMessage)
+	}
+
+	close(release)
+	job = waitForStatus(t, async, id, JobStatusCompleted)
+	if job.Progress != 1 {
+		t.Errorf("expected progress 1 on completion, got %v", job.Progress)
+	}
+}
+
+func TestAsyncRunner_TTLEviction(t *testing.T) {
+	async, err := NewAsyncRunnerWithConfig(NewDefaultMockRunner(), AsyncConfig{
+		TTL:           20 * time.Millisecond,
+		EvictInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer async.Close()
+
+	id, _ := async.Submit(&SimulationRequest{EnvelopeXdr: "AAAA"})
+	waitForStatus(t, async, id, JobStatusCompleted)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := async.Poll(id); err != nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected finished job to be evicted")
+}
+
+func TestAsyncRunner_ResumesPendingJobs(t *testing.T) {
+	store := NewMemoryJobStore()
+	release := make(chan struct{})
+	started := make(chan string, 4)
+
+	first, err := NewAsyncRunnerWithConfig(blockingRunner(release, started), AsyncConfig{Store: store, MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	running, _ := first.Submit(&SimulationRequest{EnvelopeXdr: "running"})
+	<-started
+	queued, _ := first.Submit(&SimulationRequest{EnvelopeXdr: "queued"})
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for _, id := range []string{running, queued} {
+		job, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if job.Status != JobStatusPending {
+			t.Fatalf("expected %s to be pending after Close, got %s", id, job.Status)
+		}
+	}
+
+	close(release)
+	second, err := NewAsyncRunnerWithConfig(blockingRunner(release, nil), AsyncConfig{Store: store})
+	if err != nil {
+		t.Fatalf("NewAsyncRunnerWithConfig failed: %v", err)
+	}
+	defer second.Close()
+
+	waitForStatus(t, second, running, JobStatusCompleted)
+	waitForStatus(t, second, queued, JobStatusCompleted)
+
+	jobs, err := second.List(JobFilter{Status: []JobStatus{JobStatusCompleted}})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Errorf("expected 2 completed jobs, got %d", len(jobs))
+	}
+}
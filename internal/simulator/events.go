@@ -10,6 +10,10 @@ type DiagnosticEvent struct {
 	Data                     string   `json:"data"`
 	InSuccessfulContractCall bool     `json:"in_successful_contract_call"`
 	WasmInstruction          *string  `json:"wasm_instruction,omitempty"`
+	// TopicsXDR and DataXDR hold the base64 XDR ScVals behind Topics and
+	// Data, for rendering with the contract's spec.
+	TopicsXDR []string `json:"topics_xdr,omitempty"`
+	DataXDR   string   `json:"data_xdr,omitempty"`
 }
 
 type CategorizedEvent struct {
@@ -0,0 +1,497 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// -------------------- Coverage --------------------
+
+// CoverageReport is the coverage of one simulator run, parsed from the LCOV
+// report erst-sim emits when coverage is enabled.
+type CoverageReport struct {
+	// Features are the covered functions, lines and branches, each tagged
+	// with a hit-count bucket. A run that produces a feature no earlier run
+	// produced has reached new code or exercised known code a new number of
+	// times.
+	Features       map[string]struct{}
+	FunctionsFound int
+	FunctionsHit   int
+	LinesFound     int
+	LinesHit       int
+}
+
+// ParseLCOV extracts the coverage features of an LCOV tracefile.
+func ParseLCOV(report string) (*CoverageReport, error) {
+	cov := &CoverageReport{Features: make(map[string]struct{})}
+	source := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(report))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch tag {
+		case "SF":
+			source = value
+		case "FNDA":
+			count, name, ok := strings.Cut(value, ",")
+			if !ok {
+				return nil, fmt.Errorf("malformed LCOV record %q", line)
+			}
+			cov.addFeature(source, "fn:"+name, count)
+		case "DA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("malformed LCOV record %q", line)
+			}
+			cov.addFeature(source, "line:"+fields[0], fields[1])
+		case "BRDA":
+			fields := strings.Split(value, ",")
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("malformed LCOV record %q", line)
+			}
+			cov.addFeature(source, "br:"+strings.Join(fields[:3], "."), fields[3])
+		case "FNF":
+			cov.FunctionsFound += atoiOrZero(value)
+		case "FNH":
+			cov.FunctionsHit += atoiOrZero(value)
+		case "LF":
+			cov.LinesFound += atoiOrZero(value)
+		case "LH":
+			cov.LinesHit += atoiOrZero(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read LCOV report: %w", err)
+	}
+	return cov, nil
+}
+
+// Percent returns the share of functions hit, or of lines when the report
+// has no function records.
+func (c *CoverageReport) Percent() uint32 {
+	switch {
+	case c.FunctionsFound > 0:
+		return uint32(c.FunctionsHit * 100 / c.FunctionsFound)
+	case c.LinesFound > 0:
+		return uint32(c.LinesHit * 100 / c.LinesFound)
+	}
+	return 0
+}
+
+func (c *CoverageReport) addFeature(source, site, count string) {
+	n, err := strconv.ParseUint(count, 10, 64)
+	if err != nil || n == 0 {
+		// "-" marks a branch that was never evaluated.
+		return
+	}
+	c.Features[fmt.Sprintf("%s|%s|%d", source, site, hitBucket(n))] = struct{}{}
+}
+
+// hitBucket groups hit counts the way AFL does, so a loop running a few more
+// times is new coverage but each extra iteration is not.
+func hitBucket(n uint64) uint64 {
+	switch {
+	case n <= 3:
+		return n
+	case n < 8:
+		return 4
+	case n < 16:
+		return 8
+	case n < 32:
+		return 16
+	case n < 128:
+		return 32
+	}
+	return 128
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// loadCoverage reads the LCOV report of resp, inline or from the path
+// erst-sim wrote it to.
+func loadCoverage(resp *SimulationResponse) (*CoverageReport, error) {
+	report := resp.LcovReport
+	if report == "" && resp.LcovReportPath != "" {
+		data, err := os.ReadFile(resp.LcovReportPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LCOV report: %w", err)
+		}
+		report = string(data)
+	}
+	if report == "" {
+		return nil, nil
+	}
+	return ParseLCOV(report)
+}
+
+// -------------------- Crash signatures --------------------
+
+// crashSignatureFrames is how many frames from the trap site identify a crash.
+const crashSignatureFrames = 5
+
+var volatileDigits = regexp.MustCompile(`\d+`)
+
+// CrashSignature identifies a crash independently of the input that caused
+// it. It hashes the trap kind and the innermost frames of trace, so inputs
+// that trap at the same place share a signature. Without frames it falls
+// back to message with the numbers masked out.
+func CrashSignature(trace *WasmStackTrace, message string) string {
+	var parts []string
+	if trace != nil && len(trace.Frames) > 0 {
+		if trace.TrapKind != nil {
+			kind, _ := json.Marshal(trace.TrapKind)
+			parts = append(parts, string(kind))
+		}
+		for i, frame := range trace.Frames {
+			if i == crashSignatureFrames {
+				break
+			}
+			parts = append(parts, frameSignature(frame))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, volatileDigits.ReplaceAllString(message, "N"))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// InvariantSignature identifies violations of the named invariant, so each
+// invariant is reported once per campaign.
+func InvariantSignature(name string) string {
+	sum := sha256.Sum256([]byte("invariant\n" + name))
+	return hex.EncodeToString(sum[:8])
+}
+
+func frameSignature(f StackFrame) string {
+	var b strings.Builder
+	if f.Module != nil {
+		b.WriteString(*f.Module)
+		b.WriteString("::")
+	}
+	switch {
+	case f.FuncName != nil:
+		b.WriteString(*f.FuncName)
+	case f.FuncIndex != nil:
+		fmt.Fprintf(&b, "func[%d]", *f.FuncIndex)
+	default:
+		b.WriteString("?")
+	}
+	if f.WasmOffset != nil {
+		fmt.Fprintf(&b, "@%#x", *f.WasmOffset)
+	}
+	return b.String()
+}
+
+// -------------------- Corpus --------------------
+
+// CorpusEntry is an input kept because it reached new coverage.
+type CorpusEntry struct {
+	ID              string      `json:"id"`
+	Input           FuzzerInput `json:"input"`
+	Features        []string    `json:"features"`
+	ExecutionTimeMs uint64      `json:"execution_time_ms"`
+}
+
+// CrashRecord is the stored reproduction of one distinct crash or invariant
+// violation.
+type CrashRecord struct {
+	Signature string      `json:"signature"`
+	Message   string      `json:"message"`
+	Input     FuzzerInput `json:"input"`
+}
+
+// CampaignState is the progress of a campaign, saved so it can be resumed.
+type CampaignState struct {
+	Seed       uint64 `json:"seed"`
+	Iterations uint64 `json:"iterations"`
+}
+
+// FuzzCorpus is an on-disk fuzzing corpus in the style of libFuzzer's corpus
+// directories:
+//
+//	<dir>/queue/<id>.json        inputs that reached new coverage
+//	<dir>/crashes/<sig>.json     one reproduction per crash signature
+//	<dir>/campaign.json          progress for --resume
+//
+// A corpus with an empty Dir is kept in memory only.
+type FuzzCorpus struct {
+	Dir string
+
+	entries []*CorpusEntry
+	covered map[string]struct{}
+	crashes map[string]*CrashRecord
+	state   CampaignState
+}
+
+func newMemoryCorpus() *FuzzCorpus {
+	return &FuzzCorpus{
+		covered: make(map[string]struct{}),
+		crashes: make(map[string]*CrashRecord),
+	}
+}
+
+// OpenFuzzCorpus opens the corpus in dir, creating it if needed, and loads
+// any entries, crashes and campaign state left by earlier runs.
+func OpenFuzzCorpus(dir string) (*FuzzCorpus, error) {
+	for _, sub := range []string{"queue", "crashes"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create corpus directory: %w", err)
+		}
+	}
+
+	c := newMemoryCorpus()
+	c.Dir = dir
+
+	if err := loadJSONFiles(filepath.Join(dir, "queue"), func(data []byte) error {
+		var entry CorpusEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		c.entries = append(c.entries, &entry)
+		for _, f := range entry.Features {
+			c.covered[f] = struct{}{}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := loadJSONFiles(filepath.Join(dir, "crashes"), func(data []byte) error {
+		var crash CrashRecord
+		if err := json.Unmarshal(data, &crash); err != nil {
+			return err
+		}
+		c.crashes[crash.Signature] = &crash
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "campaign.json"))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &c.state); err != nil {
+			return nil, fmt.Errorf("failed to parse campaign state: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read campaign state: %w", err)
+	}
+
+	return c, nil
+}
+
+// Entries returns the corpus inputs.
+func (c *FuzzCorpus) Entries() []*CorpusEntry {
+	return c.entries
+}
+
+// Covered returns the number of distinct features reached by the corpus.
+func (c *FuzzCorpus) Covered() int {
+	return len(c.covered)
+}
+
+// Crashes returns the stored crashes sorted by signature.
+func (c *FuzzCorpus) Crashes() []*CrashRecord {
+	out := make([]*CrashRecord, 0, len(c.crashes))
+	for _, crash := range c.crashes {
+		out = append(out, crash)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Signature < out[j].Signature })
+	return out
+}
+
+// HasCrash reports whether a crash with signature is already stored.
+func (c *FuzzCorpus) HasCrash(signature string) bool {
+	_, ok := c.crashes[signature]
+	return ok
+}
+
+// State returns the saved campaign progress.
+func (c *FuzzCorpus) State() CampaignState {
+	return c.state
+}
+
+// Add keeps input if cov reaches a feature the corpus has not, and reports
+// whether it did.
+func (c *FuzzCorpus) Add(input FuzzerInput, cov *CoverageReport, execMs uint64) (bool, error) {
+	if cov == nil {
+		return false, nil
+	}
+	features := make([]string, 0, len(cov.Features))
+	novel := false
+	for f := range cov.Features {
+		features = append(features, f)
+		if _, ok := c.covered[f]; !ok {
+			novel = true
+		}
+	}
+	if !novel {
+		return false, nil
+	}
+	sort.Strings(features)
+
+	id, err := inputID(input)
+	if err != nil {
+		return false, err
+	}
+	entry := &CorpusEntry{ID: id, Input: input, Features: features, ExecutionTimeMs: execMs}
+	if err := c.write(filepath.Join("queue", id+".json"), entry); err != nil {
+		return false, err
+	}
+	c.entries = append(c.entries, entry)
+	for _, f := range features {
+		c.covered[f] = struct{}{}
+	}
+	return true, nil
+}
+
+// AddCrash stores input as the reproduction for signature unless that crash
+// is already known, and reports whether it was new.
+func (c *FuzzCorpus) AddCrash(signature, message string, input FuzzerInput) (bool, error) {
+	if c.HasCrash(signature) {
+		return false, nil
+	}
+	crash := &CrashRecord{Signature: signature, Message: message, Input: input}
+	if err := c.write(filepath.Join("crashes", signature+".json"), crash); err != nil {
+		return false, err
+	}
+	c.crashes[signature] = crash
+	return true, nil
+}
+
+// SaveState records campaign progress so a later run can resume it.
+func (c *FuzzCorpus) SaveState(state CampaignState) error {
+	c.state = state
+	return c.write("campaign.json", state)
+}
+
+// Minimize drops entries whose features are all covered by other entries,
+// like libFuzzer's -merge=1. Entries with the most features are kept first,
+// with ties going to the faster and smaller input. It returns the number of
+// entries removed.
+func (c *FuzzCorpus) Minimize() (int, error) {
+	ranked := make([]*CorpusEntry, len(c.entries))
+	copy(ranked, c.entries)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if len(a.Features) != len(b.Features) {
+			return len(a.Features) > len(b.Features)
+		}
+		if a.ExecutionTimeMs != b.ExecutionTimeMs {
+			return a.ExecutionTimeMs < b.ExecutionTimeMs
+		}
+		return inputSize(a.Input) < inputSize(b.Input)
+	})
+
+	covered := make(map[string]struct{})
+	keep := make(map[string]bool)
+	for _, entry := range ranked {
+		for _, f := range entry.Features {
+			if _, ok := covered[f]; !ok {
+				keep[entry.ID] = true
+				break
+			}
+		}
+		if keep[entry.ID] {
+			for _, f := range entry.Features {
+				covered[f] = struct{}{}
+			}
+		}
+	}
+
+	kept := c.entries[:0]
+	removed := 0
+	for _, entry := range c.entries {
+		if keep[entry.ID] {
+			kept = append(kept, entry)
+			continue
+		}
+		if c.Dir != "" {
+			if err := os.Remove(filepath.Join(c.Dir, "queue", entry.ID+".json")); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("failed to remove corpus entry: %w", err)
+			}
+		}
+		removed++
+	}
+	c.entries = kept
+	return removed, nil
+}
+
+// inputID names an input by its content, ignoring the seed that produced it.
+func inputID(input FuzzerInput) (string, error) {
+	input.Seed = 0
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode fuzz input: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+func inputSize(input FuzzerInput) int {
+	n := len(input.EnvelopeXdr)
+	for _, arg := range input.Args {
+		n += len(arg)
+	}
+	for k, v := range input.LedgerEntries {
+		n += len(k) + len(v)
+	}
+	return n
+}
+
+func loadJSONFiles(dir string, load func([]byte) error) error {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := load(data); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// write stores v as JSON at name under the corpus directory. In-memory
+// corpora skip the write.
+func (c *FuzzCorpus) write(name string, v interface{}) error {
+	if c.Dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	path := filepath.Join(c.Dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLCOV = `TN:
+SF:/tmp/contract.wasm
+FN:1,InvokeContract::deposit
+FN:2,InvokeContract::withdraw
+FNDA:5,InvokeContract::deposit
+FNDA:0,InvokeContract::withdraw
+FNF:2
+FNH:1
+DA:10,1
+DA:11,0
+BRDA:10,0,0,1
+BRDA:10,0,1,-
+LF:2
+LH:1
+end_of_record
+`
+
+func TestParseLCOV(t *testing.T) {
+	cov, err := ParseLCOV(testLCOV)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]struct{}{
+		"/tmp/contract.wasm|fn:InvokeContract::deposit|4": {},
+		"/tmp/contract.wasm|line:10|1":                    {},
+		"/tmp/contract.wasm|br:10.0.0|1":                  {},
+	}, cov.Features)
+	assert.Equal(t, uint32(50), cov.Percent())
+
+	_, err = ParseLCOV("SF:x\nBRDA:1,2\n")
+	assert.Error(t, err)
+}
+
+func TestHitBucket(t *testing.T) {
+	assert.Equal(t, hitBucket(5), hitBucket(7), "counts in one bucket are the same feature")
+	assert.NotEqual(t, hitBucket(7), hitBucket(8))
+	assert.Equal(t, uint64(128), hitBucket(100000))
+}
+
+func TestCrashSignature(t *testing.T) {
+	name := "token::transfer"
+	idx := uint32(42)
+	offset := uint64(0xa3c)
+	trace := func(off uint64) *WasmStackTrace {
+		return &WasmStackTrace{
+			TrapKind:   "OutOfBoundsMemoryAccess",
+			RawMessage: "wasm trap: out of bounds memory access",
+			Frames: []StackFrame{
+				{Index: 0, FuncIndex: &idx, WasmOffset: &off},
+				{Index: 1, FuncName: &name},
+			},
+		}
+	}
+
+	a := CrashSignature(trace(offset), "trap with amount 5")
+	b := CrashSignature(trace(offset), "trap with amount 900")
+	c := CrashSignature(trace(offset+4), "trap with amount 5")
+	assert.Equal(t, a, b, "the message does not matter when frames are present")
+	assert.NotEqual(t, a, c, "a different trap site is a different crash")
+
+	assert.Equal(t,
+		CrashSignature(nil, "index 3 out of bounds"),
+		CrashSignature(&WasmStackTrace{}, "index 17 out of bounds"),
+		"numbers are masked in message signatures")
+}
+
+func TestFuzzCorpus_PersistsAndResumes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+	corpus, err := OpenFuzzCorpus(dir)
+	require.NoError(t, err)
+
+	cov := func(features ...string) *CoverageReport {
+		r := &CoverageReport{Features: make(map[string]struct{})}
+		for _, f := range features {
+			r.Features[f] = struct{}{}
+		}
+		return r
+	}
+
+	added, err := corpus.Add(FuzzerInput{Args: []string{"a"}, Seed: 1}, cov("f1"), 3)
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = corpus.Add(FuzzerInput{Args: []string{"b"}, Seed: 2}, cov("f1"), 3)
+	require.NoError(t, err)
+	assert.False(t, added, "inputs without new coverage are dropped")
+	added, err = corpus.Add(FuzzerInput{Args: []string{"c"}, Seed: 3}, cov("f2"), 3)
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	isNew, err := corpus.AddCrash("abcd", "trap", FuzzerInput{Args: []string{"x"}})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	require.NoError(t, corpus.SaveState(CampaignState{Seed: 9, Iterations: 200}))
+
+	reopened, err := OpenFuzzCorpus(dir)
+	require.NoError(t, err)
+	assert.Len(t, reopened.Entries(), 2)
+	assert.Equal(t, 2, reopened.Covered())
+	assert.True(t, reopened.HasCrash("abcd"))
+	assert.Equal(t, CampaignState{Seed: 9, Iterations: 200}, reopened.State())
+}
+
+func TestFuzzCorpus_Minimize(t *testing.T) {
+	dir := t.TempDir()
+	corpus, err := OpenFuzzCorpus(dir)
+	require.NoError(t, err)
+
+	add := func(arg string, features ...string) {
+		r := &CoverageReport{Features: make(map[string]struct{})}
+		for _, f := range features {
+			r.Features[f] = struct{}{}
+		}
+		_, err := corpus.Add(FuzzerInput{Args: []string{arg}}, r, 1)
+		require.NoError(t, err)
+	}
+	add("small", "f1")
+	add("medium", "f2")
+	add("large", "f1", "f2", "f3")
+
+	removed, err := corpus.Minimize()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	require.Len(t, corpus.Entries(), 1)
+	assert.Equal(t, []string{"large"}, corpus.Entries()[0].Input.Args)
+
+	files, err := filepath.Glob(filepath.Join(dir, "queue", "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestFuzzSpec_CoverageGuidedCorpus(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	frame := uint32(7)
+	offset := uint64(0x40)
+	runner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		assert.True(t, req.EnableCoverage)
+
+		var env xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(req.EnvelopeXdr, &env))
+		args := env.V1.Tx.Operations[0].Body.InvokeHostFunctionOp.HostFunction.InvokeContract.Args
+		amount, _ := scValInt(args[0])
+
+		// Each order of magnitude of amount reaches a different line.
+		line := len(amount.String())
+		resp := &SimulationResponse{
+			Status:     "success",
+			LcovReport: fmt.Sprintf("SF:c.wasm\nFNF:1\nFNH:1\nDA:%d,1\n", line),
+		}
+		if amount.Cmp(big.NewInt(1000)) > 0 {
+			resp.Error = fmt.Sprintf("wasm trap: unreachable (amount %s)", amount)
+			resp.StackTrace = &WasmStackTrace{
+				TrapKind: "Unreachable",
+				Frames:   []StackFrame{{FuncIndex: &frame, WasmOffset: &offset}},
+			}
+			return nil, &SimulatorError{Err: errors.NewSimErrorMsg(errors.CodeSimCrash, resp.Error), Response: resp}
+		}
+		return resp, nil
+	})
+
+	dir := t.TempDir()
+	corpus, err := OpenFuzzCorpus(dir)
+	require.NoError(t, err)
+
+	harness := NewFuzzingHarness(runner, FuzzingConfig{MaxIterations: 60, EnableCoverage: true})
+	harness.Corpus = corpus
+	target := SpecFuzzTarget{Wasm: []byte("\x00asm\x01\x00\x00\x00"), Mutator: m}
+	results, crashes, err := harness.FuzzSpec(target, 5)
+	require.NoError(t, err)
+
+	assert.Greater(t, len(corpus.Entries()), 1, "inputs reaching new lines are kept")
+	require.Len(t, crashes, 1, "crashes with the same stack share a signature")
+	assert.Len(t, corpus.Crashes(), 1)
+	for _, r := range results {
+		assert.Equal(t, uint32(100), r.CodeCoverage)
+	}
+
+	// Resuming continues the campaign without re-reporting the known crash.
+	resumed, err := OpenFuzzCorpus(dir)
+	require.NoError(t, err)
+	assert.Equal(t, CampaignState{Seed: 5, Iterations: 60}, resumed.State())
+
+	harness = NewFuzzingHarness(runner, FuzzingConfig{MaxIterations: 20, EnableCoverage: true, Resume: true})
+	harness.Corpus = resumed
+	results, crashes, err = harness.FuzzSpec(target, 999)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5+60), results[0].Seed)
+	assert.Empty(t, crashes)
+	assert.Equal(t, CampaignState{Seed: 5, Iterations: 80}, resumed.State())
+
+	_, err = os.Stat(filepath.Join(dir, "campaign.json"))
+	assert.NoError(t, err)
+}
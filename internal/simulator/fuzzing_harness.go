@@ -8,15 +8,21 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/stellar/go-stellar-sdk/xdr"
 )
 
 // FuzzerInput represents a single fuzz test input
 type FuzzerInput struct {
-	EnvelopeXdr   string
-	LedgerEntries map[string]string
-	Timestamp     int64
-	Args          []string
-	Seed          uint64
+	EnvelopeXdr   string            `json:"envelope_xdr"`
+	LedgerEntries map[string]string `json:"ledger_entries,omitempty"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	Args          []string          `json:"args,omitempty"`
+	Seed          uint64            `json:"seed"`
 }
 
 // FuzzingConfig contains configuration for fuzzing operations
@@ -26,15 +32,19 @@ type FuzzingConfig struct {
 	TimeoutMs        uint64
 	EnableCoverage   bool
 	TargetContractID string
+	// Resume continues the iteration count and seed saved in the corpus.
+	Resume bool
 }
 
 // FuzzingResult represents the outcome of a fuzz test
 type FuzzingResult struct {
 	Seed            uint64
-	Status          string // "pass", "crash", "slow", "error"
+	Status          string // "pass", "crash", "slow", "error", "invariant"
 	ErrorMessage    string
 	ExecutionTimeMs uint64
 	CodeCoverage    uint32
+	Signature       string // crash or invariant signature
+	NewCoverage     bool   // the input reached coverage no earlier input did
 }
 
 // FuzzingHarness manages fuzzing operations for XDR inputs
@@ -43,6 +53,16 @@ type FuzzingHarness struct {
 	Config         FuzzingConfig
 	Results        []FuzzingResult
 	CrashingInputs []FuzzerInput
+	// ViolatingInputs holds one reproduction per violated invariant.
+	ViolatingInputs []FuzzerInput
+	// Invariants, when set, are checked after every run that neither crashed
+	// nor timed out. A violation is reported with status "invariant".
+	Invariants InvariantChecker
+	// Corpus holds the inputs that reached new coverage and the crashes
+	// found. When nil, a corpus is kept in memory for the campaign.
+	Corpus *FuzzCorpus
+
+	seenCrashes map[string]bool
 }
 
 // NewFuzzingHarness creates a new fuzzing harness for contract testing
@@ -73,26 +93,54 @@ func (h *FuzzingHarness) Fuzz(baseInput *FuzzerInput) ([]FuzzingResult, []Fuzzer
 
 	results := make([]FuzzingResult, 0)
 	crashingInputs := make([]FuzzerInput, 0)
+	h.ViolatingInputs = nil
+	start := h.startIteration(0)
 
 	for i := uint64(0); i < h.Config.MaxIterations; i++ {
-		// Generate mutated input based on base
-		mutated := h.mutateInput(baseInput, i)
+		iter := start + i
+
+		// Mutate the base input or, once coverage has been found, an input
+		// from the corpus
+		parent := baseInput
+		if entries := h.corpus().Entries(); len(entries) > 0 {
+			rng := rand.New(rand.NewSource(int64(iter)))
+			if rng.Float64() < corpusPickChance {
+				parent = &entries[rng.Intn(len(entries))].Input
+			}
+		}
+		mutated := h.mutateInput(parent, iter)
 
 		// Run simulation with timeout
 		result := h.testFuzzerInput(&mutated)
 
 		results = append(results, result)
 
-		// Track crashing inputs
-		if result.Status == "crash" {
-			crashingInputs = append(crashingInputs, mutated)
+		// Track one crashing input per crash signature
+		if result.Status == "crash" || result.Status == "invariant" {
+			isNew, err := h.recordCrash(result, mutated)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch {
+			case !isNew:
+			case result.Status == "crash":
+				crashingInputs = append(crashingInputs, mutated)
+			default:
+				h.ViolatingInputs = append(h.ViolatingInputs, mutated)
+			}
 		}
 
 		// Report progress every 100 iterations
 		if (i+1)%100 == 0 {
-			fmt.Printf("Fuzz progress: %d/%d iterations\n", i+1, h.Config.MaxIterations)
+			fmt.Printf("Fuzz progress: %d/%d iterations (corpus: %d)\n", i+1, h.Config.MaxIterations, len(h.corpus().Entries()))
+			if err := h.saveProgress(0, iter+1); err != nil {
+				return nil, nil, err
+			}
 		}
 	}
+	if err := h.saveProgress(0, start+h.Config.MaxIterations); err != nil {
+		return nil, nil, err
+	}
 
 	h.Results = results
 	h.CrashingInputs = crashingInputs
@@ -199,26 +247,280 @@ func (h *FuzzingHarness) testFuzzerInput(input *FuzzerInput) FuzzingResult {
 	}
 
 	// Run simulation with timeout context
-	simResp, err := h.Runner.Run(context.Background(), simReq)
-	if err != nil {
+	result, simResp, err := h.execute(input, simReq)
+	switch {
+	case result.Status == "slow":
+	case err != nil:
 		result.Status = "crash"
 		result.ErrorMessage = fmt.Sprintf("execution error: %v", err)
-		return result
-	}
-
-	// Analyze response
-	if simResp.Status == "error" {
+		result.Signature = CrashSignature(stackTraceOf(simResp), err.Error())
+	case simResp.Status == "error":
+		// Analyze response
 		result.Status = "error"
 		result.ErrorMessage = simResp.Error
 	}
+	h.checkInvariants(simReq, simResp, &result)
+
+	return result
+}
+
+// checkInvariants turns result into an invariant failure when the run broke
+// one of the harness invariants. Crashes and timeouts are not checked.
+func (h *FuzzingHarness) checkInvariants(req *SimulationRequest, resp *SimulationResponse, result *FuzzingResult) {
+	if h.Invariants == nil || resp == nil || (result.Status != "pass" && result.Status != "error") {
+		return
+	}
+	violations := h.Invariants.Check(req, resp)
+	if len(violations) == 0 {
+		return
+	}
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.String()
+	}
+	result.Status = "invariant"
+	result.ErrorMessage = strings.Join(messages, "; ")
+	result.Signature = InvariantSignature(violations[0].Invariant)
+}
+
+// corpusPickChance is the probability that an iteration mutates a corpus
+// entry rather than the base input or a freshly generated one.
+const corpusPickChance = 0.8
+
+// execute runs req with the configured timeout, timing the run on the wall
+// clock. With coverage enabled, input joins the corpus when its run reaches
+// new coverage. The response is returned even when erst-sim reported an
+// error, so callers can read its stack trace.
+func (h *FuzzingHarness) execute(input *FuzzerInput, req *SimulationRequest) (FuzzingResult, *SimulationResponse, error) {
+	if h.Config.EnableCoverage {
+		req.EnableCoverage = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.Config.TimeoutMs)*time.Millisecond)
+	defer cancel()
 
-	// Check for slow execution (would need actual timing in production)
-	if result.ExecutionTimeMs > h.Config.TimeoutMs {
+	start := time.Now()
+	resp, err := h.Runner.Run(ctx, req)
+	result := FuzzingResult{
+		Seed:            input.Seed,
+		Status:          "pass",
+		ExecutionTimeMs: uint64(time.Since(start).Milliseconds()),
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
 		result.Status = "slow"
 		result.ErrorMessage = fmt.Sprintf("execution time exceeded %dms", h.Config.TimeoutMs)
+		return result, nil, err
 	}
 
-	return result
+	var simErr *SimulatorError
+	if resp == nil && errors.As(err, &simErr) {
+		resp = simErr.Response
+	}
+	if h.Config.EnableCoverage && resp != nil {
+		cov, covErr := loadCoverage(resp)
+		if covErr != nil {
+			logger.Logger.Warn("Failed to read fuzz coverage", "error", covErr)
+		} else if cov != nil {
+			result.CodeCoverage = cov.Percent()
+			added, addErr := h.corpus().Add(*input, cov, result.ExecutionTimeMs)
+			if addErr != nil {
+				logger.Logger.Warn("Failed to add input to fuzz corpus", "error", addErr)
+			}
+			result.NewCoverage = added
+		}
+	}
+	return result, resp, err
+}
+
+// corpus returns the harness corpus, creating an in-memory one if needed.
+func (h *FuzzingHarness) corpus() *FuzzCorpus {
+	if h.Corpus == nil {
+		h.Corpus = newMemoryCorpus()
+	}
+	return h.Corpus
+}
+
+// startIteration returns the iteration a campaign with seed begins at. A
+// resumed campaign continues after the iterations saved in the corpus.
+func (h *FuzzingHarness) startIteration(seed uint64) uint64 {
+	state := h.corpus().State()
+	if h.Config.Resume && state.Seed == seed {
+		return state.Iterations
+	}
+	return 0
+}
+
+// saveProgress records campaign progress in the corpus.
+func (h *FuzzingHarness) saveProgress(seed, iterations uint64) error {
+	return h.corpus().SaveState(CampaignState{Seed: seed, Iterations: iterations})
+}
+
+// recordCrash reports whether result is the first crash with its signature,
+// storing input in the corpus as its reproduction if so. Crashes already in
+// the corpus from an earlier run are not reported again.
+func (h *FuzzingHarness) recordCrash(result FuzzingResult, input FuzzerInput) (bool, error) {
+	if h.seenCrashes == nil {
+		h.seenCrashes = make(map[string]bool)
+	}
+	if h.seenCrashes[result.Signature] {
+		return false, nil
+	}
+	h.seenCrashes[result.Signature] = true
+	return h.corpus().AddCrash(result.Signature, result.ErrorMessage, input)
+}
+
+func stackTraceOf(resp *SimulationResponse) *WasmStackTrace {
+	if resp == nil {
+		return nil
+	}
+	return resp.StackTrace
+}
+
+// SpecFuzzTarget is a local contract function fuzzed with arguments generated
+// from its spec.
+type SpecFuzzTarget struct {
+	Wasm     []byte
+	WasmPath string
+	Mutator  *SpecMutator
+}
+
+// FuzzSpec runs the campaign against target, generating well-typed arguments
+// for every iteration. Contract errors are expected outcomes; traps and
+// panics are crashes. Each distinct crash or invariant violation is shrunk
+// to a minimal argument list before it is reported, so CrashingInputs and
+// ViolatingInputs hold one reproduction per signature.
+//
+// Once runs have reached coverage, most iterations mutate an input from the
+// corpus instead of generating a new one, so the campaign builds on inputs
+// that got deeper into the contract.
+func (h *FuzzingHarness) FuzzSpec(target SpecFuzzTarget, seed uint64) ([]FuzzingResult, []FuzzerInput, error) {
+	if target.Mutator == nil || len(target.Wasm) == 0 {
+		return nil, nil, fmt.Errorf("spec fuzzing requires a contract WASM and spec")
+	}
+
+	if state := h.corpus().State(); h.Config.Resume && state.Iterations > 0 {
+		seed = state.Seed
+	}
+	start := h.startIteration(seed)
+
+	results := make([]FuzzingResult, 0, h.Config.MaxIterations)
+	crashingInputs := make([]FuzzerInput, 0)
+	h.ViolatingInputs = nil
+
+	for i := uint64(0); i < h.Config.MaxIterations; i++ {
+		iterSeed := seed + start + i
+		args, err := h.nextSpecArgs(target.Mutator, rand.New(rand.NewSource(int64(iterSeed))))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		input, result, err := h.runSpecArgs(target, args, iterSeed)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, result)
+
+		failed := result.Status == "crash" || result.Status == "invariant"
+		if failed && !h.seenCrashes[result.Signature] && !h.corpus().HasCrash(result.Signature) {
+			minimal := target.Mutator.Shrink(args, func(candidate []xdr.ScVal) bool {
+				_, r, err := h.runSpecArgs(target, candidate, iterSeed)
+				return err == nil && r.Status == result.Status && r.Signature == result.Signature
+			})
+			input, _, err = h.runSpecArgs(target, minimal, iterSeed)
+			if err != nil {
+				return nil, nil, err
+			}
+			isNew, err := h.recordCrash(result, input)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch {
+			case !isNew:
+			case result.Status == "crash":
+				crashingInputs = append(crashingInputs, input)
+			default:
+				h.ViolatingInputs = append(h.ViolatingInputs, input)
+			}
+		}
+
+		if (i+1)%100 == 0 {
+			fmt.Printf("Fuzz progress: %d/%d iterations (corpus: %d)\n", i+1, h.Config.MaxIterations, len(h.corpus().Entries()))
+			if err := h.saveProgress(seed, start+i+1); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := h.saveProgress(seed, start+h.Config.MaxIterations); err != nil {
+		return nil, nil, err
+	}
+
+	h.Results = results
+	h.CrashingInputs = crashingInputs
+
+	return results, crashingInputs, nil
+}
+
+// nextSpecArgs mutates the arguments of a corpus entry or, while the corpus
+// is empty, generates fresh ones. Entries whose arguments do not fit the
+// function, such as those left by another campaign, are skipped.
+func (h *FuzzingHarness) nextSpecArgs(m *SpecMutator, rng *rand.Rand) ([]xdr.ScVal, error) {
+	if entries := h.corpus().Entries(); len(entries) > 0 && rng.Float64() < corpusPickChance {
+		entry := entries[rng.Intn(len(entries))]
+		if args, err := DecodeScVals(entry.Input.Args); err == nil {
+			if mutated, err := m.Mutate(rng, args); err == nil {
+				return mutated, nil
+			}
+		}
+	}
+	return m.Generate(rng)
+}
+
+// runSpecArgs invokes target with args and classifies the outcome.
+func (h *FuzzingHarness) runSpecArgs(target SpecFuzzTarget, args []xdr.ScVal, seed uint64) (FuzzerInput, FuzzingResult, error) {
+	fn := string(target.Mutator.Function().Name)
+	inv, err := BuildLocalInvocation(target.Wasm, fn, args)
+	if err != nil {
+		return FuzzerInput{}, FuzzingResult{}, err
+	}
+	encoded, err := EncodeScVals(args)
+	if err != nil {
+		return FuzzerInput{}, FuzzingResult{}, err
+	}
+
+	input := FuzzerInput{
+		EnvelopeXdr:   inv.EnvelopeXdr,
+		LedgerEntries: inv.LedgerEntries,
+		Args:          encoded,
+		Seed:          seed,
+	}
+	req := &SimulationRequest{
+		EnvelopeXdr:   input.EnvelopeXdr,
+		LedgerEntries: input.LedgerEntries,
+	}
+	if target.WasmPath != "" {
+		path := target.WasmPath
+		req.WasmPath = &path
+	}
+
+	result, resp, err := h.execute(&input, req)
+	switch {
+	case result.Status == "slow":
+	case errors.IsErstCode(err, errors.CodeSimCrash):
+		result.Status = "crash"
+		result.ErrorMessage = err.Error()
+		result.Signature = CrashSignature(stackTraceOf(resp), err.Error())
+	case err != nil:
+		// The simulator reported a contract or host error, which is an
+		// expected way for a call with arbitrary arguments to fail.
+		result.Status = "error"
+		result.ErrorMessage = err.Error()
+	case resp.Status == "error":
+		result.Status = "error"
+		result.ErrorMessage = resp.Error
+	}
+	h.checkInvariants(req, resp, &result)
+	return input, result, nil
 }
 
 // CorpusCoverage returns statistics about code coverage across all fuzz runs
@@ -256,11 +558,15 @@ func (h *FuzzingHarness) Summary() string {
 			"  Passes: %d\n"+
 			"  Crashes Found: %d\n"+
 			"  Avg Code Coverage: %d%%\n"+
-			"  Unique Crashes: %d",
+			"  Corpus Entries: %d\n"+
+			"  Unique Crashes: %d\n"+
+			"  Invariant Violations: %d",
 		len(h.Results),
 		passes,
 		crashes,
 		avgCov,
+		len(h.corpus().Entries()),
 		len(h.CrashingInputs),
+		len(h.ViolatingInputs),
 	)
 }
@@ -5,6 +5,10 @@ package simulator
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/config"
+	"github.com/dotandev/hintents/internal/errors"
 )
 
 // RunnerInterface defines the contract for simulator execution
@@ -13,10 +17,50 @@ type RunnerInterface interface {
 	Close() error
 }
 
+// InvariantChecker evaluates domain invariants against one simulated
+// execution. The fuzzing and regression harnesses report every violation it
+// returns as a failure.
+type InvariantChecker interface {
+	Check(req *SimulationRequest, resp *SimulationResponse) []InvariantViolation
+}
+
+// InvariantViolation describes one invariant that did not hold.
+type InvariantViolation struct {
+	Invariant string
+	Message   string
+}
+
+func (v InvariantViolation) String() string {
+	return fmt.Sprintf("invariant %q violated: %s", v.Invariant, v.Message)
+}
+
 // NewRunnerInterface creates a RunnerInterface implementation
-// This allows for easy swapping between real and mock implementations
-func NewRunnerInterface() (RunnerInterface, error) {
-	return NewRunner("", false)
+// This allows for easy swapping between real and mock implementations.
+// When simulator_pool_size is configured, simulations run on a PoolRunner
+// of persistent erst-sim workers instead of one process per request.
+// simPathOverride and debug are as for NewRunner.
+func NewRunnerInterface(simPathOverride string, debug bool) (RunnerInterface, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, errors.WrapConfigError("failed to load simulator configuration", err)
+	}
+	if cfg.SimulatorPoolSize <= 0 {
+		runner, err := NewRunner(simPathOverride, debug)
+		if err != nil {
+			return nil, err
+		}
+		return runner, nil
+	}
+	pool, err := NewPoolRunner(PoolConfig{
+		BinaryPath:           simPathOverride,
+		Size:                 cfg.SimulatorPoolSize,
+		MaxRequestsPerWorker: cfg.SimulatorPoolMaxRequests,
+		Debug:                debug,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
 }
 
 // ExampleUsage of how commands can accept the interface
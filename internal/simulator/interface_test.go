@@ -21,7 +21,7 @@ func TestRunnerInterface_CompileTimeCheck(t *testing.T) {
 
 func TestNewRunnerInterface(t *testing.T) {
 	// Test the factory function
-	runner, err := NewRunnerInterface()
+	runner, err := NewRunnerInterface("", false)
 
 	// Note: This will fail in the current environment due to missing binary
 	// but the interface structure is correct
@@ -0,0 +1,124 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/errors"
+)
+
+// ErrJobNotFound is returned by a JobStore when no job has the requested ID.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStore persists AsyncJobs. Implementations must be safe for concurrent
+// use and must return copies, so callers never share state with the store.
+type JobStore interface {
+	// Put inserts job or replaces the stored job with the same ID.
+	Put(job *AsyncJob) error
+	// Get returns the job with the given ID or ErrJobNotFound.
+	Get(id string) (*AsyncJob, error)
+	// List returns the jobs matching filter, oldest submission first.
+	List(filter JobFilter) ([]*AsyncJob, error)
+	// Delete removes a job. Deleting an unknown ID is not an error.
+	Delete(id string) error
+	// DeleteFinishedBefore removes completed, failed and cancelled jobs that
+	// finished before cutoff and returns how many were removed.
+	DeleteFinishedBefore(cutoff time.Time) (int, error)
+	Close() error
+}
+
+// JobFilter selects jobs in JobStore.List. Zero values match everything.
+type JobFilter struct {
+	Status []JobStatus `json:"status,omitempty"`
+	Limit  int         `json:"limit,omitempty"`
+}
+
+func (f JobFilter) matches(job *AsyncJob) bool {
+	if len(f.Status) == 0 {
+		return true
+	}
+	for _, s := range f.Status {
+		if job.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// -------------------- In-memory store --------------------
+
+// MemoryJobStore keeps jobs in a map. Jobs do not survive a restart.
+type MemoryJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*AsyncJob
+}
+
+var _ JobStore = (*MemoryJobStore)(nil)
+
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]*AsyncJob)}
+}
+
+func (s *MemoryJobStore) Put(job *AsyncJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+func (s *MemoryJobStore) Get(id string) (*AsyncJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job.clone(), nil
+}
+
+func (s *MemoryJobStore) List(filter JobFilter) ([]*AsyncJob, error) {
+	s.mu.RLock()
+	var jobs []*AsyncJob
+	for _, job := range s.jobs {
+		if filter.matches(job) {
+			jobs = append(jobs, job.clone())
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].SubmittedAt.Before(jobs[j].SubmittedAt)
+	})
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+func (s *MemoryJobStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryJobStore) DeleteFinishedBefore(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, job := range s.jobs {
+		if job.Status.Finished() && job.CompletedAt != nil && job.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *MemoryJobStore) Close() error {
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// jobStoreSchema creates the async_jobs table. The full job is stored as
+// JSON; the indexed columns exist only for filtering and eviction.
+const jobStoreSchema = `
+CREATE TABLE IF NOT EXISTS async_jobs (
+	id           TEXT PRIMARY KEY,
+	status       TEXT NOT NULL,
+	submitted_at INTEGER NOT NULL,
+	completed_at INTEGER NOT NULL DEFAULT 0,
+	job          TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_async_jobs_status    ON async_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_async_jobs_completed ON async_jobs(completed_at);
+`
+
+// SQLiteJobStore persists jobs in a SQLite database so pending and finished
+// jobs survive a daemon restart.
+type SQLiteJobStore struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+var _ JobStore = (*SQLiteJobStore)(nil)
+
+// OpenSQLiteJobStore opens (or creates) a job store at path.
+func OpenSQLiteJobStore(path string) (*SQLiteJobStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store: %w", err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
+	}
+
+	s, err := NewSQLiteJobStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.ownsDB = true
+	return s, nil
+}
+
+// NewSQLiteJobStore uses an already-open *sql.DB (e.g. an in-memory database
+// for testing). The caller is responsible for closing it.
+func NewSQLiteJobStore(db *sql.DB) (*SQLiteJobStore, error) {
+	if _, err := db.Exec(jobStoreSchema); err != nil {
+		return nil, fmt.Errorf("failed to initialize job store schema: %w", err)
+	}
+	return &SQLiteJobStore{db: db}, nil
+}
+
+func (s *SQLiteJobStore) Put(job *AsyncJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("job store encode failed: %w", err)
+	}
+
+	var completedAt int64
+	if job.CompletedAt != nil {
+		completedAt = job.CompletedAt.UnixNano()
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO async_jobs (id, status, submitted_at, completed_at, job)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET
+		   status       = excluded.status,
+		   completed_at = excluded.completed_at,
+		   job          = excluded.job`,
+		job.ID, string(job.Status), job.SubmittedAt.UnixNano(), completedAt, string(payload),
+	)
+	if err != nil {
+		return fmt.Errorf("job store write failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteJobStore) Get(id string) (*AsyncJob, error) {
+	var payload string
+	err := s.db.QueryRow("SELECT job FROM async_jobs WHERE id = ?", id).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("job store read failed: %w", err)
+	}
+	return decodeJob(payload)
+}
+
+func (s *SQLiteJobStore) List(filter JobFilter) ([]*AsyncJob, error) {
+	query := "SELECT job FROM async_jobs"
+	var args []any
+	if len(filter.Status) > 0 {
+		placeholders := make([]string, len(filter.Status))
+		for i, status := range filter.Status {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		query += " WHERE status IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY submitted_at ASC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("job store list failed: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*AsyncJob
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("job store list failed: %w", err)
+		}
+		job, err := decodeJob(payload)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *SQLiteJobStore) Delete(id string) error {
+	if _, err := s.db.Exec("DELETE FROM async_jobs WHERE id = ?", id); err != nil {
+		return fmt.Errorf("job store delete failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteJobStore) DeleteFinishedBefore(cutoff time.Time) (int, error) {
+	result, err := s.db.Exec(
+		"DELETE FROM async_jobs WHERE status IN (?, ?, ?) AND completed_at > 0 AND completed_at < ?",
+		string(JobStatusCompleted), string(JobStatusFailed), string(JobStatusCancelled), cutoff.UnixNano(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("job store eviction failed: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
+
+// Close closes the underlying database if it was opened by OpenSQLiteJobStore.
+func (s *SQLiteJobStore) Close() error {
+	if s.ownsDB {
+		return s.db.Close()
+	}
+	return nil
+}
+
+func decodeJob(payload string) (*AsyncJob, error) {
+	var job AsyncJob
+	if err := json.Unmarshal([]byte(payload), &job); err != nil {
+		return nil, fmt.Errorf("job store decode failed: %w", err)
+	}
+	return &job, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func jobStores(t *testing.T) map[string]JobStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	sqliteStore, err := NewSQLiteJobStore(db)
+	require.NoError(t, err)
+
+	return map[string]JobStore{
+		"memory": NewMemoryJobStore(),
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestJobStore_PutGetDelete(t *testing.T) {
+	for name, store := range jobStores(t) {
+		t.Run(name, func(t *testing.T) {
+			job := &AsyncJob{
+				ID:          "job-1",
+				Status:      JobStatusPending,
+				Priority:    3,
+				SubmittedAt: time.Now(),
+				Request:     &SimulationRequest{EnvelopeXdr: "AAAA"},
+			}
+			require.NoError(t, store.Put(job))
+
+			got, err := store.Get("job-1")
+			require.NoError(t, err)
+			assert.Equal(t, JobStatusPending, got.Status)
+			assert.Equal(t, 3, got.Priority)
+			assert.Equal(t, "AAAA", got.Request.EnvelopeXdr)
+
+			// Mutating the returned job must not affect the store.
+			got.Status = JobStatusFailed
+			again, err := store.Get("job-1")
+			require.NoError(t, err)
+			assert.Equal(t, JobStatusPending, again.Status)
+
+			require.NoError(t, store.Delete("job-1"))
+			_, err = store.Get("job-1")
+			assert.ErrorIs(t, err, ErrJobNotFound)
+		})
+	}
+}
+
+func TestJobStore_ListFilter(t *testing.T) {
+	for name, store := range jobStores(t) {
+		t.Run(name, func(t *testing.T) {
+			base := time.Now()
+			statuses := []JobStatus{JobStatusPending, JobStatusCompleted, JobStatusPending, JobStatusFailed}
+			for i, status := range statuses {
+				require.NoError(t, store.Put(&AsyncJob{
+					ID:          string(rune('a' + i)),
+					Status:      status,
+					SubmittedAt: base.Add(time.Duration(i) * time.Second),
+				}))
+			}
+
+			all, err := store.List(JobFilter{})
+			require.NoError(t, err)
+			require.Len(t, all, 4)
+			assert.Equal(t, "a", all[0].ID)
+			assert.Equal(t, "d", all[3].ID)
+
+			pending, err := store.List(JobFilter{Status: []JobStatus{JobStatusPending}})
+			require.NoError(t, err)
+			require.Len(t, pending, 2)
+			assert.Equal(t, "a", pending[0].ID)
+			assert.Equal(t, "c", pending[1].ID)
+
+			limited, err := store.List(JobFilter{Limit: 1})
+			require.NoError(t, err)
+			assert.Len(t, limited, 1)
+		})
+	}
+}
+
+func TestJobStore_DeleteFinishedBefore(t *testing.T) {
+	for name, store := range jobStores(t) {
+		t.Run(name, func(t *testing.T) {
+			old := time.Now().Add(-2 * time.Hour)
+			recent := time.Now()
+
+			require.NoError(t, store.Put(&AsyncJob{ID: "old-done", Status: JobStatusCompleted, SubmittedAt: old, CompletedAt: &old}))
+			require.NoError(t, store.Put(&AsyncJob{ID: "old-cancelled", Status: JobStatusCancelled, SubmittedAt: old, CompletedAt: &old}))
+			require.NoError(t, store.Put(&AsyncJob{ID: "recent-done", Status: JobStatusFailed, SubmittedAt: recent, CompletedAt: &recent}))
+			require.NoError(t, store.Put(&AsyncJob{ID: "old-pending", Status: JobStatusPending, SubmittedAt: old}))
+
+			removed, err := store.DeleteFinishedBefore(time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 2, removed)
+
+			remaining, err := store.List(JobFilter{})
+			require.NoError(t, err)
+			assert.Len(t, remaining, 2)
+		})
+	}
+}
+
+func TestSQLiteJobStore_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+
+	store, err := OpenSQLiteJobStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(&AsyncJob{
+		ID:          "persisted",
+		Status:      JobStatusPending,
+		SubmittedAt: time.Now(),
+		Request:     &SimulationRequest{EnvelopeXdr: "AAAA"},
+	}))
+	require.NoError(t, store.Close())
+
+	reopened, err := OpenSQLiteJobStore(path)
+	require.NoError(t, err)
+
+	// Closing the runner closes the store.
+	async, err := NewAsyncRunnerWithConfig(NewDefaultMockRunner(), AsyncConfig{Store: reopened})
+	require.NoError(t, err)
+	defer async.Close()
+
+	job, err := async.Wait(context.Background(), "persisted", PollConfig{
+		Interval: 10 * time.Millisecond,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusCompleted, job.Status)
+}
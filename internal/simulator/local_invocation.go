@@ -0,0 +1,131 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// LocalInvocation is a self-contained call into a local WASM: the envelope
+// plus the ledger entries that deploy the contract.
+type LocalInvocation struct {
+	ContractID    xdr.ContractId
+	EnvelopeXdr   string
+	LedgerEntries map[string]string
+}
+
+// BuildLocalInvocation deploys wasm at a synthetic contract ID, derived from
+// the code hash, and builds an envelope that calls function with args. The
+// returned ledger entries hold the contract code and instance, so the
+// invocation runs without any network state.
+func BuildLocalInvocation(wasm []byte, function string, args []xdr.ScVal) (*LocalInvocation, error) {
+	codeHash := xdr.Hash(sha256.Sum256(wasm))
+	contractID := xdr.ContractId(codeHash)
+	contract := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID}
+
+	codeKey := xdr.LedgerKey{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.LedgerKeyContractCode{Hash: codeHash},
+	}
+	codeEntry := xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type:         xdr.LedgerEntryTypeContractCode,
+			ContractCode: &xdr.ContractCodeEntry{Hash: codeHash, Code: wasm},
+		},
+	}
+
+	instanceKey := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			Contract:   contract,
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Durability: xdr.ContractDataDurabilityPersistent,
+		},
+	}
+	instanceEntry := xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract:   contract,
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+				Durability: xdr.ContractDataDurabilityPersistent,
+				Val: xdr.ScVal{
+					Type: xdr.ScValTypeScvContractInstance,
+					Instance: &xdr.ScContractInstance{
+						Executable: xdr.ContractExecutable{
+							Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+							WasmHash: &codeHash,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	entries := make(map[string]string, 2)
+	for _, kv := range []struct {
+		key   xdr.LedgerKey
+		entry xdr.LedgerEntry
+	}{{codeKey, codeEntry}, {instanceKey, instanceEntry}} {
+		k, err := xdr.MarshalBase64(kv.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ledger key: %w", err)
+		}
+		v, err := xdr.MarshalBase64(kv.entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ledger entry: %w", err)
+		}
+		entries[k] = v
+	}
+
+	var source xdr.Uint256
+	tx := xdr.Transaction{
+		SourceAccount: xdr.MuxedAccount{Type: xdr.CryptoKeyTypeKeyTypeEd25519, Ed25519: &source},
+		Fee:           100,
+		SeqNum:        1,
+		Cond:          xdr.Preconditions{Type: xdr.PreconditionTypePrecondNone},
+		Memo:          xdr.Memo{Type: xdr.MemoTypeMemoNone},
+		Operations: []xdr.Operation{{
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+					HostFunction: xdr.HostFunction{
+						Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+						InvokeContract: &xdr.InvokeContractArgs{
+							ContractAddress: contract,
+							FunctionName:    xdr.ScSymbol(function),
+							Args:            args,
+						},
+					},
+				},
+			},
+		}},
+		Ext: xdr.TransactionExt{
+			V: 1,
+			SorobanData: &xdr.SorobanTransactionData{
+				Resources: xdr.SorobanResources{
+					Footprint: xdr.LedgerFootprint{ReadOnly: []xdr.LedgerKey{codeKey, instanceKey}},
+				},
+			},
+		},
+	}
+	env := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1:   &xdr.TransactionV1Envelope{Tx: tx},
+	}
+	envBytes, err := env.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	return &LocalInvocation{
+		ContractID:    contractID,
+		EnvelopeXdr:   base64.StdEncoding.EncodeToString(envBytes),
+		LedgerEntries: entries,
+	}, nil
+}
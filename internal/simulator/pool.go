@@ -0,0 +1,421 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/dotandev/hintents/internal/ipc"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/metrics"
+)
+
+// WorkerModeArg is the argument that starts erst-sim as a long-lived worker.
+// In worker mode erst-sim reads length-prefixed JSON SimulationRequest frames
+// from stdin and answers each with one SimulationResponse frame on stdout
+// (see ipc.WriteFrame), instead of handling a single request and exiting.
+const WorkerModeArg = "--worker"
+
+const (
+	// DefaultPoolSize is the number of workers used when PoolConfig.Size is unset.
+	DefaultPoolSize = 4
+
+	// DefaultPoolRequestTimeout bounds a single request when
+	// PoolConfig.RequestTimeout is unset.
+	DefaultPoolRequestTimeout = 2 * time.Minute
+)
+
+// PoolConfig configures a PoolRunner.
+type PoolConfig struct {
+	// BinaryPath overrides erst-sim discovery (same search order as NewRunner).
+	BinaryPath string
+	// Size is the number of long-lived workers. Defaults to DefaultPoolSize.
+	Size int
+	// RequestTimeout bounds each request. A worker that exceeds it is killed
+	// and replaced. Defaults to DefaultPoolRequestTimeout.
+	RequestTimeout time.Duration
+	// MaxRequestsPerWorker recycles a worker after it has served this many
+	// requests, bounding memory growth inside erst-sim. Zero disables recycling.
+	MaxRequestsPerWorker int
+	// Args are passed to every worker. Defaults to []string{WorkerModeArg}.
+	Args  []string
+	Debug bool
+	// MockTime, when non-zero, overrides Timestamp in every request.
+	MockTime int64
+}
+
+// PoolRunner runs simulations on a fixed set of persistent erst-sim workers,
+// avoiding process startup and WASM compilation costs on every request.
+// When every worker is busy, Run blocks until one is released or the
+// request context is cancelled.
+type PoolRunner struct {
+	BinaryPath string
+	Validator  *Validator
+
+	cfg PoolConfig
+
+	// slots holds one entry per worker slot. A nil entry is a slot whose
+	// worker has not been started yet or was retired; it is (re)started
+	// lazily by the next request that takes it.
+	slots chan *poolWorker
+	done  chan struct{}
+
+	mu      sync.Mutex
+	workers map[*poolWorker]struct{}
+	closed  bool
+
+	// retiring tracks retired workers that are still being stopped.
+	retiring sync.WaitGroup
+}
+
+// Compile-time check to ensure PoolRunner implements RunnerInterface
+var _ RunnerInterface = (*PoolRunner)(nil)
+
+type poolWorker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *syncBuffer
+	served int
+	exited chan struct{}
+
+	closeOutput func() error
+}
+
+// NewPoolRunner resolves the erst-sim binary and prepares a pool of workers.
+// Workers are started on first use.
+func NewPoolRunner(cfg PoolConfig) (*PoolRunner, error) {
+	path, source, err := findSimBinary(cfg.BinaryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultPoolSize
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = DefaultPoolRequestTimeout
+	}
+	if cfg.Args == nil {
+		cfg.Args = []string{WorkerModeArg}
+	}
+
+	if cfg.Debug {
+		logger.Logger.Debug(
+			"Simulator worker pool configured",
+			"path", path,
+			"source", source,
+			"size", cfg.Size,
+			"max_requests_per_worker", cfg.MaxRequestsPerWorker,
+		)
+	}
+
+	p := &PoolRunner{
+		BinaryPath: path,
+		Validator:  NewValidator(false),
+		cfg:        cfg,
+		slots:      make(chan *poolWorker, cfg.Size),
+		done:       make(chan struct{}),
+		workers:    make(map[*poolWorker]struct{}),
+	}
+	for i := 0; i < cfg.Size; i++ {
+		p.slots <- nil
+	}
+	return p, nil
+}
+
+// -------------------- Execution --------------------
+
+func (p *PoolRunner) Run(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+	success := false
+	defer func() {
+		metrics.RecordSimulationExecution(success)
+	}()
+
+	if req == nil {
+		return nil, errors.NewSimErrorMsg(errors.CodeValidationFailed, "simulation request cannot be nil")
+	}
+
+	proto, err := prepareRequest(req, p.Validator, p.cfg.MockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	inputBytes, err := json.Marshal(req)
+	if err != nil {
+		logger.Logger.Error("Failed to marshal simulation request", "error", err)
+		return nil, errors.WrapMarshalFailed(err)
+	}
+	ReportProgress(ctx, 0.1, "Request prepared")
+
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ReportProgress(ctx, 0.2, "Simulating")
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.cfg.RequestTimeout)
+	defer cancel()
+
+	output, err := p.exchange(reqCtx, w, inputBytes)
+	if err != nil {
+		// The worker's protocol state is unknown after a failed exchange, so
+		// it is never reused.
+		p.retire(w, 0)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if reqCtx.Err() == context.DeadlineExceeded {
+			return nil, errors.WrapSimCrash(
+				fmt.Errorf("simulator worker exceeded request timeout of %s", p.cfg.RequestTimeout),
+				w.stderr.String(),
+			)
+		}
+		logger.Logger.Error("Simulator worker failed", "error", err, "stderr", w.stderr.String())
+		return nil, errors.WrapSimCrash(err, w.stderr.String())
+	}
+	ReportProgress(ctx, 0.9, "Simulation finished")
+
+	w.served++
+	if p.cfg.MaxRequestsPerWorker > 0 && w.served >= p.cfg.MaxRequestsPerWorker {
+		p.retire(w, 1500*time.Millisecond)
+	} else {
+		p.release(w)
+	}
+
+	if wantsDiagnostics(ctx) {
+		reportDiagnostics(ctx, bytes.NewReader(output))
+	}
+
+	resp, err := decodeResponse(output, proto)
+	if err != nil {
+		return nil, err
+	}
+	success = true
+
+	return resp, nil
+}
+
+// exchange sends one request frame and waits for the matching response frame.
+func (p *PoolRunner) exchange(ctx context.Context, w *poolWorker, input []byte) ([]byte, error) {
+	w.stderr.Reset()
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	resultCh := make(chan result, 1)
+
+	go func() {
+		if err := ipc.WriteFrame(w.stdin, input); err != nil {
+			resultCh <- result{err: fmt.Errorf("write request: %w", err)}
+			return
+		}
+		output, err := ipc.ReadFrame(w.stdout)
+		if err != nil {
+			err = fmt.Errorf("read response: %w", err)
+		}
+		resultCh <- result{output: output, err: err}
+	}()
+
+	// A worker that dies mid-request closes its end of the stdout pipe, which
+	// surfaces here as a read error.
+	select {
+	case r := <-resultCh:
+		return r.output, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquire takes a worker slot, starting a worker for it if needed. It blocks
+// while every worker is busy.
+func (p *PoolRunner) acquire(ctx context.Context) (*poolWorker, error) {
+	var w *poolWorker
+	select {
+	case w = <-p.slots:
+	case <-p.done:
+		return nil, fmt.Errorf("runner is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if w != nil {
+		select {
+		case <-w.exited:
+			// Crashed while idle; replace it below.
+			p.untrack(w)
+			_ = w.closeOutput()
+			w = nil
+		default:
+			return w, nil
+		}
+	}
+
+	w, err := p.startWorker()
+	if err != nil {
+		p.slots <- nil
+		return nil, err
+	}
+	return w, nil
+}
+
+func (p *PoolRunner) release(w *poolWorker) {
+	p.slots <- w
+}
+
+// retire frees w's slot for a fresh worker and stops w in the background,
+// allowing it grace to exit on its own, so that the request that retired it
+// does not wait for the process to exit.
+func (p *PoolRunner) retire(w *poolWorker, grace time.Duration) {
+	p.untrack(w)
+	p.slots <- nil
+
+	p.retiring.Add(1)
+	go func() {
+		defer p.retiring.Done()
+		_ = w.stop(grace)
+	}()
+}
+
+func (p *PoolRunner) startWorker() (*poolWorker, error) {
+	cmd := exec.Command(p.BinaryPath, p.cfg.Args...)
+	prepareCommand(cmd)
+	cmd.Env = simulatorEnv()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.WrapSimCrash(err, "failed to open simulator worker stdin")
+	}
+	// stdout is read while a separate goroutine waits on the process, so it
+	// must not be a cmd.StdoutPipe, which Wait closes underneath the reader.
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, errors.WrapSimCrash(err, "failed to open simulator worker stdout")
+	}
+	cmd.Stdout = stdoutWriter
+	stderr := &syncBuffer{buf: limitedBuffer{Buffer: bytes.Buffer{}, limit: 1 * 1024 * 1024}}
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	_ = stdoutWriter.Close()
+	if err != nil {
+		_ = stdout.Close()
+		return nil, errors.WrapSimCrash(err, "failed to start simulator worker")
+	}
+
+	w := &poolWorker{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		stderr: stderr,
+		exited: make(chan struct{}),
+	}
+	go func() {
+		_ = cmd.Wait()
+		close(w.exited)
+	}()
+	w.closeOutput = stdout.Close
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		_ = terminateCommand(cmd, 100*time.Millisecond)
+		<-w.exited
+		_ = stdout.Close()
+		return nil, fmt.Errorf("runner is closed")
+	}
+	p.workers[w] = struct{}{}
+	p.mu.Unlock()
+
+	if p.cfg.Debug {
+		logger.Logger.Debug("Simulator worker started", "pid", cmd.Process.Pid)
+	}
+	return w, nil
+}
+
+func (p *PoolRunner) untrack(w *poolWorker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.workers, w)
+}
+
+// Close terminates every worker. Requests waiting for a worker fail; requests
+// already in flight fail once their worker is terminated.
+func (p *PoolRunner) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+
+	workers := make([]*poolWorker, 0, len(p.workers))
+	for w := range p.workers {
+		workers = append(workers, w)
+	}
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, w := range workers {
+		if err := w.stop(1500 * time.Millisecond); err != nil && firstErr == nil {
+			logger.Logger.Error("Failed to terminate simulator worker", "error", err)
+			firstErr = err
+		}
+	}
+	p.retiring.Wait()
+	return firstErr
+}
+
+// stop closes the worker's stdin, which asks a well-behaved worker to exit,
+// and terminates it if it is still running after grace.
+func (w *poolWorker) stop(grace time.Duration) error {
+	_ = w.stdin.Close()
+
+	var err error
+	select {
+	case <-w.exited:
+	case <-time.After(grace):
+		err = terminateCommand(w.cmd, 100*time.Millisecond)
+		<-w.exited
+	}
+	_ = w.closeOutput()
+	return err
+}
+
+// syncBuffer is a limitedBuffer safe for the concurrent writes of a worker's
+// stderr and the reads made when reporting a failure.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf limitedBuffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotandev/hintents/internal/ipc"
+)
+
+const poolHelperEnv = "ERST_POOL_TEST_WORKER"
+
+// TestPoolFakeWorker is not a real test: it is re-executed as a fake erst-sim
+// worker by the pool tests. It echoes the worker PID and the number of
+// requests it has served in the response's Events.
+func TestPoolFakeWorker(t *testing.T) {
+	if os.Getenv(poolHelperEnv) != "1" {
+		t.Skip("helper process")
+	}
+
+	served := 0
+	for {
+		frame, err := ipc.ReadFrame(os.Stdin)
+		if err != nil {
+			os.Exit(0)
+		}
+		var req SimulationRequest
+		if err := json.Unmarshal(frame, &req); err != nil {
+			os.Exit(2)
+		}
+		served++
+
+		switch req.EnvelopeXdr {
+		case "crash":
+			os.Exit(3)
+		case "hang":
+			time.Sleep(time.Minute)
+		}
+
+		resp := SimulationResponse{
+			Status: "success",
+			Events: []string{
+				strconv.Itoa(served),
+				strconv.Itoa(os.Getpid()),
+			},
+		}
+		out, _ := json.Marshal(resp)
+		if err := ipc.WriteFrame(os.Stdout, out); err != nil {
+			os.Exit(4)
+		}
+	}
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig) *PoolRunner {
+	t.Helper()
+	t.Setenv(poolHelperEnv, "1")
+
+	cfg.BinaryPath = os.Args[0]
+	cfg.Args = []string{"-test.run=^TestPoolFakeWorker$"}
+	p, err := NewPoolRunner(cfg)
+	if err != nil {
+		t.Fatalf("NewPoolRunner failed: %v", err)
+	}
+	p.Validator = nil
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func poolRequest(envelope string) *SimulationRequest {
+	return &SimulationRequest{EnvelopeXdr: envelope, ResultMetaXdr: "AAAA"}
+}
+
+func TestPoolRunner_ReusesWorker(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1})
+
+	first, err := p.Run(context.Background(), poolRequest("AAAA"))
+	if err != nil {
+		t.Fatalf("first Run failed: %v", err)
+	}
+	second, err := p.Run(context.Background(), poolRequest("AAAA"))
+	if err != nil {
+		t.Fatalf("second Run failed: %v", err)
+	}
+
+	if first.Events[1] != second.Events[1] {
+		t.Errorf("expected the same worker, got pids %s and %s", first.Events[1], second.Events[1])
+	}
+	if second.Events[0] != "2" {
+		t.Errorf("expected worker to have served 2 requests, got %s", second.Events[0])
+	}
+}
+
+func TestPoolRunner_RecyclesAfterMaxRequests(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1, MaxRequestsPerWorker: 2})
+
+	var pids []string
+	for i := 0; i < 3; i++ {
+		resp, err := p.Run(context.Background(), poolRequest("AAAA"))
+		if err != nil {
+			t.Fatalf("Run %d failed: %v", i, err)
+		}
+		pids = append(pids, resp.Events[1])
+	}
+
+	if pids[0] != pids[1] {
+		t.Errorf("expected first two requests on one worker, got %v", pids)
+	}
+	if pids[2] == pids[1] {
+		t.Errorf("expected a fresh worker after recycling, got %v", pids)
+	}
+}
+
+func TestPoolRunner_RestartsCrashedWorker(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1})
+
+	if _, err := p.Run(context.Background(), poolRequest("crash")); err == nil {
+		t.Fatal("expected error from crashed worker")
+	}
+
+	resp, err := p.Run(context.Background(), poolRequest("AAAA"))
+	if err != nil {
+		t.Fatalf("Run after crash failed: %v", err)
+	}
+	if resp.Events[0] != "1" {
+		t.Errorf("expected a fresh worker, got served count %s", resp.Events[0])
+	}
+}
+
+func TestPoolRunner_RequestTimeout(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1, RequestTimeout: 200 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := p.Run(context.Background(), poolRequest("hang")); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout took too long: %s", elapsed)
+	}
+
+	if _, err := p.Run(context.Background(), poolRequest("AAAA")); err != nil {
+		t.Fatalf("Run after timeout failed: %v", err)
+	}
+}
+
+func TestPoolRunner_BlocksWhenSaturated(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1})
+
+	w, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := p.Run(ctx, poolRequest("AAAA")); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded while pool is saturated, got %v", err)
+	}
+
+	p.release(w)
+	if _, err := p.Run(context.Background(), poolRequest("AAAA")); err != nil {
+		t.Fatalf("Run after release failed: %v", err)
+	}
+}
+
+func TestPoolRunner_ConcurrentRuns(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 2})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Run(context.Background(), poolRequest("AAAA")); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent Run failed: %v", err)
+	}
+}
+
+func TestPoolRunner_CloseRejectsRuns(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Size: 1})
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := p.Run(context.Background(), poolRequest("AAAA")); err == nil {
+		t.Fatal("expected error after Close")
+	}
+}
@@ -6,6 +6,7 @@ package simulator
 import (
 	"fmt"
 	"maps"
+	"reflect"
 	"sort"
 
 	"github.com/dotandev/hintents/internal/errors"
@@ -152,3 +153,60 @@ func MergeFeatures(version uint32, custom map[string]interface{}) map[string]int
 	}
 	return result
 }
+
+// FeatureChange is a feature whose value differs between two protocols.
+type FeatureChange struct {
+	Key  string
+	From string // "" when the feature is new
+	To   string // "" when the feature was removed
+}
+
+// FeatureChanges lists the features that differ from protocol from to
+// protocol to, sorted by key.
+func FeatureChanges(from, to uint32) ([]FeatureChange, error) {
+	a, err := Get(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := Get(to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{})
+	for k := range a.Features {
+		keys[k] = struct{}{}
+	}
+	for k := range b.Features {
+		keys[k] = struct{}{}
+	}
+
+	var changes []FeatureChange
+	for k := range keys {
+		av, inA := a.Features[k]
+		bv, inB := b.Features[k]
+		if inA && inB && reflect.DeepEqual(av, bv) {
+			continue
+		}
+		change := FeatureChange{Key: k}
+		if inA {
+			change.From = formatFeature(av)
+		}
+		if inB {
+			change.To = formatFeature(bv)
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+func formatFeature(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		return fmt.Sprintf("%+v", rv.Elem().Interface())
+	}
+	return fmt.Sprintf("%v", v)
+}
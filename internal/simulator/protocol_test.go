@@ -188,3 +188,33 @@ func TestKeccakTinyInputEstimateIsLower(t *testing.T) {
 		t.Fatalf("expected updated tiny-input estimate to be lower: updated=%d old=%d", updatedTinyEstimate, oldTinyEstimate)
 	}
 }
+
+func TestFeatureChanges(t *testing.T) {
+	changes, err := FeatureChanges(21, 22)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byKey := make(map[string]FeatureChange)
+	for _, c := range changes {
+		byKey[c.Key] = c
+	}
+	if c, ok := byKey["max_instruction_limit"]; !ok || c.From != "150000000" || c.To != "200000000" {
+		t.Errorf("expected max_instruction_limit 150000000 -> 200000000, got %+v", c)
+	}
+	if c, ok := byKey["optimized_storage"]; !ok || c.From != "" || c.To != "true" {
+		t.Errorf("expected optimized_storage to be new in 22, got %+v", c)
+	}
+	if _, ok := byKey["resource_calibration"]; ok {
+		t.Error("unchanged calibration should not be reported")
+	}
+	for i := 1; i < len(changes); i++ {
+		if changes[i-1].Key > changes[i].Key {
+			t.Errorf("changes not sorted: %q before %q", changes[i-1].Key, changes[i].Key)
+		}
+	}
+
+	if _, err := FeatureChanges(21, 99); err == nil {
+		t.Error("expected error for unsupported protocol")
+	}
+}
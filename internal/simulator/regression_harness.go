@@ -6,6 +6,7 @@ package simulator
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -22,6 +23,8 @@ type RegressionTestResult struct {
 	EventCount      int
 	ExpectedCount   int
 	TrapsMatch      bool
+
+	InvariantViolations []InvariantViolation
 }
 
 // RegressionTestSuite holds results from a batch of regression tests
@@ -40,6 +43,14 @@ type RegressionHarness struct {
 	RPCClient  *rpc.Client
 	MaxWorkers int
 	Verbose    bool
+
+	// StateProvider supplies ledger entries for replay. When nil, entries
+	// are fetched live through RPCClient.
+	StateProvider rpc.LedgerStateProvider
+
+	// Invariants, when set, are checked against every replay. A violation
+	// fails the test.
+	Invariants InvariantChecker
 }
 
 // NewRegressionHarness creates a new regression test harness
@@ -162,8 +173,12 @@ func (h *RegressionHarness) testTransaction(
 		return result
 	}
 
-	// Fetch ledger entries from network
-	ledgerEntries, err := h.RPCClient.GetLedgerEntries(ctx, keys)
+	// Fetch ledger entries from the configured state source
+	var state rpc.LedgerStateProvider = h.RPCClient
+	if h.StateProvider != nil {
+		state = h.StateProvider
+	}
+	ledgerEntries, err := state.GetLedgerEntries(ctx, keys)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("failed to fetch ledger entries: %v", err)
 		return result
@@ -212,6 +227,18 @@ func (h *RegressionHarness) testTransaction(
 		result.ErrorMessage = "unexpected simulation status: " + simResp.Status
 	}
 
+	if h.Invariants != nil {
+		if violations := h.Invariants.Check(simReq, simResp); len(violations) > 0 {
+			messages := make([]string, len(violations))
+			for i, v := range violations {
+				messages[i] = v.String()
+			}
+			result.Status = "fail"
+			result.InvariantViolations = violations
+			result.ErrorMessage = strings.Join(messages, "; ")
+		}
+	}
+
 	return result
 }
 
@@ -3,6 +3,11 @@
 
 package simulator
 
+// PlaceholderResultMeta stands in for the result meta of transactions that
+// have none, such as envelopes read from stdin or transactions read from a
+// history archive. erst-sim requires a non-empty result_meta_xdr.
+const PlaceholderResultMeta = "AAAAAQ=="
+
 type SimulationRequest struct {
 	EnvelopeXdr     string            `json:"envelope_xdr"`
 	ResultMetaXdr   string            `json:"result_meta_xdr"`
@@ -15,6 +20,10 @@ type SimulationRequest struct {
 	ProtocolVersion *uint32           `json:"protocol_version,omitempty"`
 	MockBaseFee     *uint32           `json:"mock_base_fee,omitempty"`
 	MockGasPrice    *uint64           `json:"mock_gas_price,omitempty"`
+	MemoryLimit     *uint64           `json:"memory_limit,omitempty"`
+
+	EnableCoverage   bool    `json:"enable_coverage,omitempty"`
+	CoverageLCOVPath *string `json:"coverage_lcov_path,omitempty"`
 
 	AuthTraceOpts       *AuthTraceOptions      `json:"auth_trace_opts,omitempty"`
 	CustomAuthCfg       map[string]interface{} `json:"custom_auth_config,omitempty"`
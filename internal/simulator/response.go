@@ -8,6 +8,7 @@ import "github.com/dotandev/hintents/internal/authtrace"
 type SimulationResponse struct {
 	Status            string               `json:"status"`
 	Error             string               `json:"error,omitempty"`
+	ErrorCode         string               `json:"error_code,omitempty"`
 	Events            []string             `json:"events,omitempty"`
 	DiagnosticEvents  []DiagnosticEvent    `json:"diagnostic_events,omitempty"`
 	Logs              []string             `json:"logs,omitempty"`
@@ -19,6 +20,33 @@ type SimulationResponse struct {
 	StackTrace        *WasmStackTrace      `json:"stack_trace,omitempty"`
 	SourceLocation    string               `json:"source_location,omitempty"`
 	WasmOffset        *uint64              `json:"wasm_offset,omitempty"`
+	LcovReport        string               `json:"lcov_report,omitempty"`
+	LcovReportPath    string               `json:"lcov_report_path,omitempty"`
+	LedgerChanges     []LedgerChange       `json:"ledger_changes,omitempty"`
+}
+
+// LedgerChange is a ledger entry the simulated transaction wrote. Key is the
+// base64 LedgerKey XDR and Entry the base64 LedgerEntry XDR after execution,
+// empty when the entry was deleted.
+type LedgerChange struct {
+	Key   string `json:"key"`
+	Entry string `json:"entry,omitempty"`
+}
+
+// SimulatorError is returned when erst-sim reports an error in its response.
+// It wraps the classified error and keeps the decoded response, so callers
+// can still inspect the stack trace and coverage of a failed run.
+type SimulatorError struct {
+	Err      error
+	Response *SimulationResponse
+}
+
+func (e *SimulatorError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SimulatorError) Unwrap() error {
+	return e.Err
 }
 
 type BudgetUsage struct {
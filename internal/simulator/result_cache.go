@@ -0,0 +1,431 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotandev/hintents/internal/ipc"
+	"github.com/dotandev/hintents/internal/logger"
+	"github.com/dotandev/hintents/internal/rpc"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// DefaultResultCacheTTL is how long a cached simulation result stays valid.
+	DefaultResultCacheTTL = 7 * 24 * time.Hour
+
+	// DefaultResultCacheMaxBytes caps the total size of cached responses.
+	// Least recently used entries are evicted once it is exceeded.
+	DefaultResultCacheMaxBytes int64 = 256 * 1024 * 1024
+)
+
+// resultCacheSchema creates the sim_result_cache table. It lives in the same
+// SQLite database as the rpc_cache table (see rpc.CacheDBName).
+const resultCacheSchema = `
+CREATE TABLE IF NOT EXISTS sim_result_cache (
+	key_hash         TEXT PRIMARY KEY,
+	sim_version      TEXT NOT NULL,
+	protocol_version INTEGER NOT NULL,
+	response         TEXT NOT NULL,
+	size_bytes       INTEGER NOT NULL,
+	created_at       INTEGER NOT NULL,
+	accessed_at      INTEGER NOT NULL,
+	expires_at       INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sim_result_cache_expires  ON sim_result_cache(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sim_result_cache_accessed ON sim_result_cache(accessed_at);
+`
+
+// ResultCache stores SimulationResponses keyed by ResultCacheKey.
+type ResultCache struct {
+	db       *sql.DB
+	ownsDB   bool
+	ttl      time.Duration
+	maxBytes int64
+}
+
+// ResultCacheStats summarises the contents of a ResultCache.
+type ResultCacheStats struct {
+	Entries   int   `json:"entries"`
+	Expired   int   `json:"expired"`
+	SizeBytes int64 `json:"size_bytes"`
+	MaxBytes  int64 `json:"max_bytes"`
+}
+
+// OpenResultCache opens the result cache in the default cache database
+// (~/.erst/cache.db). A non-positive ttl or maxBytes selects the default.
+func OpenResultCache(ttl time.Duration, maxBytes int64) (*ResultCache, error) {
+	dir, err := rpc.GetCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, rpc.CacheDBName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
+	}
+
+	c, err := NewResultCache(db, ttl, maxBytes)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	c.ownsDB = true
+	return c, nil
+}
+
+// NewResultCache uses an already-open *sql.DB (e.g. an in-memory database
+// for testing). The caller is responsible for closing it.
+func NewResultCache(db *sql.DB, ttl time.Duration, maxBytes int64) (*ResultCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultResultCacheTTL
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultResultCacheMaxBytes
+	}
+
+	if _, err := db.Exec(resultCacheSchema); err != nil {
+		return nil, fmt.Errorf("failed to initialize result cache schema: %w", err)
+	}
+
+	return &ResultCache{db: db, ttl: ttl, maxBytes: maxBytes}, nil
+}
+
+// Get returns the cached response for key, if present and not expired.
+func (c *ResultCache) Get(key string) (*SimulationResponse, bool, error) {
+	now := time.Now().UnixNano()
+
+	var payload string
+	err := c.db.QueryRow(
+		"SELECT response FROM sim_result_cache WHERE key_hash = ? AND expires_at > ?",
+		key, now,
+	).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("result cache read failed: %w", err)
+	}
+
+	var resp SimulationResponse
+	if err := json.Unmarshal([]byte(payload), &resp); err != nil {
+		// A row written by an incompatible build; drop it and treat as a miss.
+		_, _ = c.db.Exec("DELETE FROM sim_result_cache WHERE key_hash = ?", key)
+		return nil, false, nil
+	}
+
+	if _, err := c.db.Exec("UPDATE sim_result_cache SET accessed_at = ? WHERE key_hash = ?", now, key); err != nil {
+		logger.Logger.Warn("Failed to update result cache access time", "error", err)
+	}
+
+	return &resp, true, nil
+}
+
+// Put stores resp under key and evicts least recently used entries if the
+// cache exceeds its size cap.
+func (c *ResultCache) Put(key, simVersion string, protocolVersion uint32, resp *SimulationResponse) error {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("result cache encode failed: %w", err)
+	}
+	if int64(len(payload)) > c.maxBytes {
+		return nil
+	}
+
+	now := time.Now()
+	_, err = c.db.Exec(
+		`INSERT INTO sim_result_cache
+		   (key_hash, sim_version, protocol_version, response, size_bytes, created_at, accessed_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(key_hash) DO UPDATE SET
+		   response    = excluded.response,
+		   size_bytes  = excluded.size_bytes,
+		   created_at  = excluded.created_at,
+		   accessed_at = excluded.accessed_at,
+		   expires_at  = excluded.expires_at`,
+		key, simVersion, protocolVersion, string(payload), len(payload),
+		now.UnixNano(), now.UnixNano(), now.Add(c.ttl).UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("result cache write failed: %w", err)
+	}
+
+	_, err = c.evictOverCap()
+	return err
+}
+
+// Prune removes expired entries and then evicts least recently used entries
+// until the cache fits its size cap. Returns the number of rows removed.
+func (c *ResultCache) Prune() (int, error) {
+	result, err := c.db.Exec("DELETE FROM sim_result_cache WHERE expires_at <= ?", time.Now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("result cache prune failed: %w", err)
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	evicted, err := c.evictOverCap()
+	if err != nil {
+		return int(expired), err
+	}
+
+	removed := int(expired) + evicted
+	if removed > 0 {
+		logger.Logger.Info("Simulation result cache pruned", "entries_removed", removed)
+	}
+	return removed, nil
+}
+
+// Clear removes every cached result. Returns the number of rows removed.
+func (c *ResultCache) Clear() (int, error) {
+	result, err := c.db.Exec("DELETE FROM sim_result_cache")
+	if err != nil {
+		return 0, fmt.Errorf("result cache clear failed: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
+
+// Stats reports the number of entries and their total size.
+func (c *ResultCache) Stats() (ResultCacheStats, error) {
+	stats := ResultCacheStats{MaxBytes: c.maxBytes}
+	err := c.db.QueryRow(
+		`SELECT COUNT(*),
+		        COALESCE(SUM(CASE WHEN expires_at <= ? THEN 1 ELSE 0 END), 0),
+		        COALESCE(SUM(size_bytes), 0)
+		   FROM sim_result_cache`,
+		time.Now().UnixNano(),
+	).Scan(&stats.Entries, &stats.Expired, &stats.SizeBytes)
+	if err != nil {
+		return stats, fmt.Errorf("result cache stats failed: %w", err)
+	}
+	return stats, nil
+}
+
+// Close closes the underlying database if it was opened by OpenResultCache.
+func (c *ResultCache) Close() error {
+	if c.ownsDB {
+		return c.db.Close()
+	}
+	return nil
+}
+
+// evictOverCap deletes least recently used rows until the total size of the
+// cache is within maxBytes.
+func (c *ResultCache) evictOverCap() (int, error) {
+	var total int64
+	if err := c.db.QueryRow("SELECT COALESCE(SUM(size_bytes), 0) FROM sim_result_cache").Scan(&total); err != nil {
+		return 0, fmt.Errorf("result cache size query failed: %w", err)
+	}
+	if total <= c.maxBytes {
+		return 0, nil
+	}
+
+	rows, err := c.db.Query("SELECT key_hash, size_bytes FROM sim_result_cache ORDER BY accessed_at ASC")
+	if err != nil {
+		return 0, fmt.Errorf("result cache eviction failed: %w", err)
+	}
+	var victims []string
+	for rows.Next() && total > c.maxBytes {
+		var key string
+		var size int64
+		if err := rows.Scan(&key, &size); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("result cache eviction failed: %w", err)
+		}
+		victims = append(victims, key)
+		total -= size
+	}
+	rows.Close()
+
+	for _, key := range victims {
+		if _, err := c.db.Exec("DELETE FROM sim_result_cache WHERE key_hash = ?", key); err != nil {
+			return 0, fmt.Errorf("result cache eviction failed: %w", err)
+		}
+	}
+	return len(victims), nil
+}
+
+// -------------------- Keys --------------------
+
+// ResultCacheKey returns the content address of req: a SHA-256 over the
+// canonicalized request, the erst-sim version and the resolved protocol
+// version. Requests that reference a local WASM file are keyed by the file's
+// contents rather than its path.
+func ResultCacheKey(req *SimulationRequest, simVersion string) (string, uint32, error) {
+	canonical := *req
+	canonical.EnvelopeXdr = strings.TrimSpace(req.EnvelopeXdr)
+	canonical.ResultMetaXdr = strings.TrimSpace(req.ResultMetaXdr)
+
+	protocolVersion := GetOrDefault(req.ProtocolVersion).Version
+	canonical.ProtocolVersion = &protocolVersion
+
+	var wasmDigest string
+	if req.WasmPath != nil {
+		digest, err := hashFile(*req.WasmPath)
+		if err != nil {
+			return "", 0, err
+		}
+		wasmDigest = digest
+		canonical.WasmPath = nil
+	}
+
+	// encoding/json sorts map keys, so equal requests marshal identically.
+	payload, err := json.Marshal(&canonical)
+	if err != nil {
+		return "", 0, err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "erst-sim-result/v1\x00%s\x00%d\x00%s\x00", simVersion, protocolVersion, wasmDigest)
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), protocolVersion, nil
+}
+
+// SimBinaryVersion identifies an erst-sim build by the digest of its binary,
+// so cached results are invalidated whenever the simulator is rebuilt.
+func SimBinaryVersion(binaryPath string) (string, error) {
+	digest, err := hashFile(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + digest, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// -------------------- Runner --------------------
+
+// CachingRunner decorates a RunnerInterface with a ResultCache. Completed
+// simulations are cached, including ones erst-sim reported as failed (a
+// *SimulatorError carrying a response); crashes and other errors always reach
+// the caller fresh.
+type CachingRunner struct {
+	inner      RunnerInterface
+	cache      *ResultCache
+	simVersion string
+}
+
+// Compile-time check to ensure CachingRunner implements RunnerInterface
+var _ RunnerInterface = (*CachingRunner)(nil)
+
+// requestPreparer is implemented by runners that apply defaults to a request
+// before handing it to erst-sim. CachingRunner applies them first so that the
+// cache key reflects the request the simulator actually sees.
+type requestPreparer interface {
+	prepare(req *SimulationRequest) error
+}
+
+func (r *Runner) prepare(req *SimulationRequest) error {
+	_, err := prepareRequest(req, r.Validator, r.MockTime)
+	return err
+}
+
+func (p *PoolRunner) prepare(req *SimulationRequest) error {
+	_, err := prepareRequest(req, p.Validator, p.cfg.MockTime)
+	return err
+}
+
+// NewCachingRunner wraps inner. simVersion should identify the erst-sim build
+// behind inner (see SimBinaryVersion).
+func NewCachingRunner(inner RunnerInterface, cache *ResultCache, simVersion string) *CachingRunner {
+	return &CachingRunner{inner: inner, cache: cache, simVersion: simVersion}
+}
+
+func (c *CachingRunner) Run(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+	if req == nil {
+		return c.inner.Run(ctx, req)
+	}
+
+	if p, ok := c.inner.(requestPreparer); ok {
+		if err := p.prepare(req); err != nil {
+			// The inner runner rejects the request the same way.
+			return c.inner.Run(ctx, req)
+		}
+	}
+
+	key, protocolVersion, err := ResultCacheKey(req, c.simVersion)
+	if err != nil {
+		logger.Logger.Warn("Simulation result cache bypassed", "error", err)
+		return c.inner.Run(ctx, req)
+	}
+
+	resp, ok, err := c.cache.Get(key)
+	if err != nil {
+		logger.Logger.Warn("Simulation result cache lookup failed", "error", err)
+	} else if ok {
+		logger.Logger.Debug("Simulation result cache hit", "key", key)
+		return cachedResult(resp)
+	}
+
+	resp, err = c.inner.Run(ctx, req)
+	if err != nil {
+		var simErr *SimulatorError
+		if !errors.As(err, &simErr) || simErr.Response == nil {
+			return nil, err
+		}
+		resp = simErr.Response
+	}
+
+	if perr := c.cache.Put(key, c.simVersion, protocolVersion, resp); perr != nil {
+		logger.Logger.Warn("Failed to store simulation result", "error", perr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cachedResult returns a cached response the way the runner returned it
+// originally: a run erst-sim reported as failed comes back as a
+// *SimulatorError.
+func cachedResult(resp *SimulationResponse) (*SimulationResponse, error) {
+	if resp.Error == "" {
+		return resp, nil
+	}
+	classified := (&ipc.Error{Code: resp.ErrorCode, Message: resp.Error}).ToErstError()
+	return nil, &SimulatorError{Err: classified, Response: resp}
+}
+
+// Close closes the wrapped runner and the cache.
+func (c *CachingRunner) Close() error {
+	err := c.inner.Close()
+	if cerr := c.cache.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
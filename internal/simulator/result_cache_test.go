@@ -0,0 +1,270 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupResultCache(t *testing.T, ttl time.Duration, maxBytes int64) *ResultCache {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	c, err := NewResultCache(db, ttl, maxBytes)
+	require.NoError(t, err)
+	return c
+}
+
+func TestResultCacheKey_Canonical(t *testing.T) {
+	a := &SimulationRequest{
+		EnvelopeXdr:   "AAAA",
+		ResultMetaXdr: "BBBB",
+		LedgerEntries: map[string]string{"k1": "v1", "k2": "v2"},
+	}
+	b := &SimulationRequest{
+		EnvelopeXdr:   "  AAAA\n",
+		ResultMetaXdr: "BBBB",
+		LedgerEntries: map[string]string{"k2": "v2", "k1": "v1"},
+	}
+	latest := LatestVersion()
+	c := &SimulationRequest{
+		EnvelopeXdr:     "AAAA",
+		ResultMetaXdr:   "BBBB",
+		LedgerEntries:   map[string]string{"k1": "v1", "k2": "v2"},
+		ProtocolVersion: &latest,
+	}
+
+	keyA, protoA, err := ResultCacheKey(a, "v1")
+	require.NoError(t, err)
+	keyB, _, err := ResultCacheKey(b, "v1")
+	require.NoError(t, err)
+	keyC, _, err := ResultCacheKey(c, "v1")
+	require.NoError(t, err)
+
+	assert.Equal(t, keyA, keyB)
+	assert.Equal(t, keyA, keyC, "unset protocol version resolves to the default")
+	assert.Equal(t, latest, protoA)
+	assert.Nil(t, a.ProtocolVersion, "key computation must not mutate the request")
+}
+
+func TestResultCacheKey_Varies(t *testing.T) {
+	base := &SimulationRequest{EnvelopeXdr: "AAAA", ResultMetaXdr: "BBBB"}
+	baseKey, _, err := ResultCacheKey(base, "v1")
+	require.NoError(t, err)
+
+	otherSim, _, err := ResultCacheKey(base, "v2")
+	require.NoError(t, err)
+	assert.NotEqual(t, baseKey, otherSim)
+
+	v21 := uint32(21)
+	otherProto, _, err := ResultCacheKey(&SimulationRequest{
+		EnvelopeXdr:     "AAAA",
+		ResultMetaXdr:   "BBBB",
+		ProtocolVersion: &v21,
+	}, "v1")
+	require.NoError(t, err)
+	assert.NotEqual(t, baseKey, otherProto)
+
+	otherEntries, _, err := ResultCacheKey(&SimulationRequest{
+		EnvelopeXdr:   "AAAA",
+		ResultMetaXdr: "BBBB",
+		LedgerEntries: map[string]string{"k": "v"},
+	}, "v1")
+	require.NoError(t, err)
+	assert.NotEqual(t, baseKey, otherEntries)
+}
+
+func TestResultCacheKey_WasmContents(t *testing.T) {
+	dir := t.TempDir()
+	wasmPath := filepath.Join(dir, "contract.wasm")
+	require.NoError(t, os.WriteFile(wasmPath, []byte{0x00, 0x61, 0x73, 0x6d, 0x01}, 0600))
+
+	req := &SimulationRequest{EnvelopeXdr: "AAAA", WasmPath: &wasmPath}
+	first, _, err := ResultCacheKey(req, "v1")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(wasmPath, []byte{0x00, 0x61, 0x73, 0x6d, 0x02}, 0600))
+	second, _, err := ResultCacheKey(req, "v1")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	missing := filepath.Join(dir, "missing.wasm")
+	_, _, err = ResultCacheKey(&SimulationRequest{WasmPath: &missing}, "v1")
+	assert.Error(t, err)
+}
+
+func TestResultCache_PutGet(t *testing.T) {
+	c := setupResultCache(t, time.Hour, 0)
+
+	_, ok, err := c.Get("missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Put("k", "v1", 22, &SimulationResponse{Status: "success", Events: []string{"e1"}}))
+
+	resp, ok, err := c.Get("k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "success", resp.Status)
+	assert.Equal(t, []string{"e1"}, resp.Events)
+}
+
+func TestResultCache_Expiry(t *testing.T) {
+	c := setupResultCache(t, time.Millisecond, 0)
+	require.NoError(t, c.Put("k", "v1", 22, &SimulationResponse{Status: "success"}))
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, err := c.Get("k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, 1, stats.Expired)
+
+	removed, err := c.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestResultCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	resp := &SimulationResponse{Status: "success", Events: []string{"0123456789"}}
+	payload, err := json.Marshal(resp)
+	require.NoError(t, err)
+	// Room for two entries but not three.
+	maxBytes := int64(len(payload))*2 + int64(len(payload))/2
+	c := setupResultCache(t, time.Hour, maxBytes)
+
+	require.NoError(t, c.Put("old", "v1", 22, resp))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, c.Put("new", "v1", 22, resp))
+	time.Sleep(time.Millisecond)
+	// Touch "old" so "new" becomes the least recently used entry.
+	_, ok, err := c.Get("old")
+	require.NoError(t, err)
+	require.True(t, ok)
+	time.Sleep(time.Millisecond)
+	require.NoError(t, c.Put("newest", "v1", 22, resp))
+
+	_, ok, _ = c.Get("new")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok, _ = c.Get("old")
+	assert.True(t, ok)
+	_, ok, _ = c.Get("newest")
+	assert.True(t, ok)
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, stats.SizeBytes, maxBytes)
+}
+
+func TestResultCache_Clear(t *testing.T) {
+	c := setupResultCache(t, time.Hour, 0)
+	require.NoError(t, c.Put("a", "v1", 22, &SimulationResponse{Status: "success"}))
+	require.NoError(t, c.Put("b", "v1", 22, &SimulationResponse{Status: "success"}))
+
+	removed, err := c.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+}
+
+func TestCachingRunner_ReusesResult(t *testing.T) {
+	calls := 0
+	inner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		calls++
+		return &SimulationResponse{Status: "success"}, nil
+	})
+	runner := NewCachingRunner(inner, setupResultCache(t, time.Hour, 0), "v1")
+
+	for i := 0; i < 3; i++ {
+		resp, err := runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "AAAA", ResultMetaXdr: "BBBB"})
+		require.NoError(t, err)
+		assert.Equal(t, "success", resp.Status)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, err := runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "CCCC", ResultMetaXdr: "BBBB"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCachingRunner_DoesNotCacheErrors(t *testing.T) {
+	calls := 0
+	inner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		calls++
+		return nil, errors.New("boom")
+	})
+	runner := NewCachingRunner(inner, setupResultCache(t, time.Hour, 0), "v1")
+
+	for i := 0; i < 2; i++ {
+		_, err := runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "AAAA"})
+		require.Error(t, err)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestCachingRunner_CachesFailedSimulations(t *testing.T) {
+	calls := 0
+	inner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		calls++
+		resp := &SimulationResponse{Status: "error", Error: "HostError: Error(Contract, #1)"}
+		return nil, &SimulatorError{Err: errors.New(resp.Error), Response: resp}
+	})
+	runner := NewCachingRunner(inner, setupResultCache(t, time.Hour, 0), "v1")
+
+	for i := 0; i < 2; i++ {
+		resp, err := runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "AAAA"})
+		assert.Nil(t, resp)
+		var simErr *SimulatorError
+		require.ErrorAs(t, err, &simErr)
+		require.NotNil(t, simErr.Response)
+		assert.Equal(t, "HostError: Error(Contract, #1)", simErr.Response.Error)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+// preparingRunner stamps requests the way Runner does with a mock time.
+type preparingRunner struct {
+	*MockRunner
+	timestamp int64
+}
+
+func (p *preparingRunner) prepare(req *SimulationRequest) error {
+	req.Timestamp = p.timestamp
+	return nil
+}
+
+func TestCachingRunner_KeysPreparedRequest(t *testing.T) {
+	calls := 0
+	inner := &preparingRunner{
+		MockRunner: NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+			calls++
+			return &SimulationResponse{Status: "success"}, nil
+		}),
+		timestamp: 1700000000,
+	}
+	runner := NewCachingRunner(inner, setupResultCache(t, time.Hour, 0), "v1")
+
+	_, err := runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "AAAA"})
+	require.NoError(t, err)
+	_, err = runner.Run(context.Background(), &SimulationRequest{EnvelopeXdr: "AAAA", Timestamp: 1700000000})
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
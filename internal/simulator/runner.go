@@ -7,8 +7,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"io"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -203,50 +203,17 @@ func (r *Runner) Run(ctx context.Context, req *SimulationRequest) (*SimulationRe
 		return nil, errors.NewSimErrorMsg(errors.CodeValidationFailed, "simulation request cannot be nil")
 	}
 
-	if req.MemoryLimit == nil {
-		req.MemoryLimit = getSimulatorMemoryLimit(req)
-	}
-	if req.CoverageLCOVPath == nil {
-		req.CoverageLCOVPath = getSimulatorCoverageLCOVPath(req)
-	}
-	if req.CoverageLCOVPath != nil {
-		req.EnableCoverage = true
-	}
-	// Enforce sandbox native token cap when set (local/sandbox economic constraint)
-	if capStroops := getSandboxNativeTokenCap(req); capStroops != nil {
-		if err := EnforceSandboxNativeTokenCap(req.EnvelopeXdr, *capStroops); err != nil {
-			logger.Logger.Error("Sandbox native token cap exceeded", "error", err)
-			return nil, err
-		}
-	}
-
-	// Validate request before processing
-	if r.Validator != nil {
-		if err := r.Validator.ValidateRequest(req); err != nil {
-			logger.Logger.Error("Request validation failed", "error", err)
-			return nil, err
-		}
-	}
-	proto := GetOrDefault(req.ProtocolVersion)
-	if req.ProtocolVersion != nil {
-		if err := Validate(*req.ProtocolVersion); err != nil {
-			return nil, err
-		}
-	}
-
-	if err := r.applyProtocolConfig(req, proto); err != nil {
+	proto, err := prepareRequest(req, r.Validator, r.MockTime)
+	if err != nil {
 		return nil, err
 	}
 
-	if r.MockTime != 0 {
-		req.Timestamp = r.MockTime
-	}
-
 	inputBytes, err := json.Marshal(req)
 	if err != nil {
 		logger.Logger.Error("Failed to marshal simulation request", "error", err)
 		return nil, errors.WrapMarshalFailed(err)
 	}
+	ReportProgress(ctx, 0.1, "Request prepared")
 
 	cmd := exec.Command(r.BinaryPath)
 	prepareCommand(cmd)
@@ -260,6 +227,14 @@ func (r *Runner) Run(ctx context.Context, req *SimulationRequest) (*SimulationRe
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	// Decode diagnostic events while erst-sim writes its response so that
+	// an AsyncRunner job can publish them before the run finishes.
+	if wantsDiagnostics(ctx) {
+		stream := newDiagnosticStream(ctx)
+		defer stream.Close()
+		cmd.Stdout = io.MultiWriter(&stdout, stream)
+	}
+
 	if err := cmd.Start(); err != nil {
 		return nil, errors.WrapSimCrash(err, "failed to start simulator")
 	}
@@ -270,6 +245,7 @@ func (r *Runner) Run(ctx context.Context, req *SimulationRequest) (*SimulationRe
 		return nil, err
 	}
 	defer r.untrackCommand(cmd)
+	ReportProgress(ctx, 0.2, "Simulating")
 
 	waitCh := make(chan error, 1)
 	go func() {
@@ -290,11 +266,71 @@ func (r *Runner) Run(ctx context.Context, req *SimulationRequest) (*SimulationRe
 		<-waitCh
 		return nil, ctx.Err()
 	}
+	ReportProgress(ctx, 0.9, "Simulation finished")
+
+	resp, err := decodeResponse(stdout.Bytes(), proto)
+	if err != nil {
+		return nil, err
+	}
+	success = true
+
+	return resp, nil
+}
 
+// prepareRequest applies environment defaults, sandbox limits, validation and
+// protocol configuration to req before it is handed to erst-sim. It is shared by
+// every RunnerInterface implementation that talks to the binary.
+func prepareRequest(req *SimulationRequest, validator *Validator, mockTime int64) (*Protocol, error) {
+	if req.MemoryLimit == nil {
+		req.MemoryLimit = getSimulatorMemoryLimit(req)
+	}
+	if req.CoverageLCOVPath == nil {
+		req.CoverageLCOVPath = getSimulatorCoverageLCOVPath(req)
+	}
+	if req.CoverageLCOVPath != nil {
+		req.EnableCoverage = true
+	}
+	// Enforce sandbox native token cap when set (local/sandbox economic constraint)
+	if capStroops := getSandboxNativeTokenCap(req); capStroops != nil {
+		if err := EnforceSandboxNativeTokenCap(req.EnvelopeXdr, *capStroops); err != nil {
+			logger.Logger.Error("Sandbox native token cap exceeded", "error", err)
+			return nil, err
+		}
+	}
+
+	// Validate request before processing
+	if validator != nil {
+		if err := validator.ValidateRequest(req); err != nil {
+			logger.Logger.Error("Request validation failed", "error", err)
+			return nil, err
+		}
+	}
+	proto := GetOrDefault(req.ProtocolVersion)
+	if req.ProtocolVersion != nil {
+		if err := Validate(*req.ProtocolVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := configureProtocol(req, proto); err != nil {
+		return nil, err
+	}
+
+	if mockTime != 0 {
+		req.Timestamp = mockTime
+	}
+
+	return proto, nil
+}
+
+// decodeResponse parses the JSON emitted by erst-sim and classifies any logical
+// error it reports into a unified ErstError, wrapped in a SimulatorError that
+// carries the response.
+func decodeResponse(output []byte, proto *Protocol) (*SimulationResponse, error) {
 	var resp SimulationResponse
-	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+	if err := json.Unmarshal(output, &resp); err != nil {
 		logger.Logger.Error("Failed to unmarshal response", "error", err)
-		return nil, errors.WrapUnmarshalFailed(err, stdout.String())
+		return nil, errors.WrapUnmarshalFailed(err, string(output))
 	}
 
 	// If the simulator returned a logical error inside the response payload,
@@ -305,15 +341,80 @@ func (r *Runner) Run(ctx context.Context, req *SimulationRequest) (*SimulationRe
 			"code", classified.Code,
 			"original", classified.OriginalError,
 		)
-		return nil, classified
+		resp.ProtocolVersion = &proto.Version
+		return nil, &SimulatorError{Err: classified, Response: &resp}
 	}
 
 	resp.ProtocolVersion = &proto.Version
-	success = true
-
 	return &resp, nil
 }
 
+// diagnosticStream is an io.Writer that decodes the erst-sim response written
+// to it and reports each diagnostic event with ReportDiagnostic as soon as it
+// is complete.
+type diagnosticStream struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+}
+
+func newDiagnosticStream(ctx context.Context) *diagnosticStream {
+	pr, pw := io.Pipe()
+	s := &diagnosticStream{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		reportDiagnostics(ctx, pr)
+		// Keep draining so that the writer never blocks.
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	return s
+}
+
+func (s *diagnosticStream) Write(p []byte) (int, error) {
+	return s.pw.Write(p)
+}
+
+// Close ends the stream and waits until every decoded event was reported.
+func (s *diagnosticStream) Close() error {
+	err := s.pw.Close()
+	<-s.done
+	return err
+}
+
+// reportDiagnostics reads a JSON SimulationResponse from r and reports the
+// elements of its diagnostic_events with ReportDiagnostic one by one, as they
+// are decoded. It stops at the end of the array or at the first malformed
+// token; decodeResponse reports malformed output.
+func reportDiagnostics(ctx context.Context, r io.Reader) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		if tok != "diagnostic_events" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return
+			}
+			continue
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return
+		}
+		for i := 0; dec.More(); i++ {
+			var event DiagnosticEvent
+			if err := dec.Decode(&event); err != nil {
+				return
+			}
+			ReportDiagnostic(ctx, i, event)
+		}
+		return
+	}
+}
+
 // limitedBuffer wraps bytes.Buffer with a size limit to prevent memory leaks
 type limitedBuffer struct {
 	bytes.Buffer
@@ -326,6 +427,8 @@ func (lb *limitedBuffer) Write(p []byte) (n int, err error) {
 		return len(p), nil
 	}
 	return lb.Buffer.Write(p)
+}
+
 func (r *Runner) Close() error {
 	r.mu.Lock()
 	if r.closed {
@@ -379,6 +482,12 @@ func (r *Runner) terminateProcessGroup(cmd *exec.Cmd, graceTimeout time.Duration
 }
 
 func (r *Runner) applyProtocolConfig(req *SimulationRequest, proto *Protocol) error {
+	return configureProtocol(req, proto)
+}
+
+// configureProtocol copies the protocol feature table into the request fields
+// erst-sim reads its limits and calibration from.
+func configureProtocol(req *SimulationRequest, proto *Protocol) error {
 	if req.CustomAuthCfg == nil {
 		req.CustomAuthCfg = make(map[string]interface{})
 	}
@@ -0,0 +1,96 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package simulator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunnerRun_ReportsProgress(t *testing.T) {
+	simPath := filepath.Join(t.TempDir(), "fake-erst-sim.sh")
+	script := "#!/bin/sh\ncat >/dev/null\necho '{\"status\":\"success\"}'\n"
+	if err := os.WriteFile(simPath, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	runner := &Runner{
+		BinaryPath: simPath,
+		activeCmds: make(map[*exec.Cmd]struct{}),
+	}
+	defer runner.Close()
+
+	var fractions []float64
+	var messages []string
+	ctx := WithProgress(context.Background(), func(fraction float64, message string) {
+		fractions = append(fractions, fraction)
+		messages = append(messages, message)
+	})
+
+	if _, err := runner.Run(ctx, &SimulationRequest{EnvelopeXdr: "x", ResultMetaXdr: "y"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []string{"Request prepared", "Simulating", "Simulation finished"}
+	if len(messages) != len(want) {
+		t.Fatalf("expected progress %v, got %v", want, messages)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("progress %d: expected %q, got %q", i, want[i], messages[i])
+		}
+		if i > 0 && fractions[i] <= fractions[i-1] {
+			t.Errorf("progress did not increase: %v", fractions)
+		}
+	}
+}
+
+func TestRunnerRun_StreamsDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "first-event-seen")
+	simPath := filepath.Join(dir, "fake-erst-sim.sh")
+	// The second event records whether the first one was reported while
+	// erst-sim was still writing its response.
+	script := "#!/bin/sh\ncat >/dev/null\n" +
+		"printf '{\"status\":\"success\",\"diagnostic_events\":[{\"event_type\":\"contract\"}'\n" +
+		"i=0; while [ ! -f '" + marker + "' ] && [ $i -lt 50 ]; do sleep 0.1; i=$((i+1)); done\n" +
+		"if [ -f '" + marker + "' ]; then seen=streamed; else seen=batched; fi\n" +
+		"printf ',{\"event_type\":\"%s\"}]}\\n' \"$seen\"\n"
+	if err := os.WriteFile(simPath, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	runner := &Runner{
+		BinaryPath: simPath,
+		activeCmds: make(map[*exec.Cmd]struct{}),
+	}
+	defer runner.Close()
+
+	var types []string
+	ctx := WithDiagnostics(context.Background(), func(index int, event DiagnosticEvent) {
+		if index != len(types) {
+			t.Errorf("expected event %d, got %d", len(types), index)
+		}
+		types = append(types, event.EventType)
+		if index == 0 {
+			_ = os.WriteFile(marker, nil, 0644)
+		}
+	})
+
+	resp, err := runner.Run(ctx, &SimulationRequest{EnvelopeXdr: "x", ResultMetaXdr: "y"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(types) != 2 || types[0] != "contract" || types[1] != "streamed" {
+		t.Errorf("expected events to stream as they were written, got %v", types)
+	}
+	if len(resp.DiagnosticEvents) != 2 {
+		t.Errorf("expected the response to keep its events, got %+v", resp.DiagnosticEvents)
+	}
+}
@@ -0,0 +1,1016 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/strkey"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+const (
+	// specMaxDepth bounds how deeply nested vectors, maps and UDTs are
+	// generated, so recursive types terminate.
+	specMaxDepth = 4
+	// specMaxCollection is the largest vector or map generated.
+	specMaxCollection = 4
+	// specBoundaryChance is the probability of picking a boundary value
+	// rather than a random one.
+	specBoundaryChance = 0.5
+	// specShrinkBudget caps the number of candidate runs spent shrinking one
+	// crashing input.
+	specShrinkBudget = 500
+)
+
+const symbolChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+// SpecMutator generates well-typed ScVal arguments for one contract function
+// from the contract's spec. Unlike byte-level mutation, every input it
+// produces decodes and converts cleanly, so fuzz runs reach contract logic.
+type SpecMutator struct {
+	function xdr.ScSpecFunctionV0
+	structs  map[string]xdr.ScSpecUdtStructV0
+	unions   map[string]xdr.ScSpecUdtUnionV0
+	enums    map[string]xdr.ScSpecUdtEnumV0
+	errEnums map[string]xdr.ScSpecUdtErrorEnumV0
+}
+
+// NewSpecMutator creates a mutator for function in spec.
+func NewSpecMutator(spec *abi.ContractSpec, function string) (*SpecMutator, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("contract spec required")
+	}
+
+	m := &SpecMutator{
+		structs:  make(map[string]xdr.ScSpecUdtStructV0),
+		unions:   make(map[string]xdr.ScSpecUdtUnionV0),
+		enums:    make(map[string]xdr.ScSpecUdtEnumV0),
+		errEnums: make(map[string]xdr.ScSpecUdtErrorEnumV0),
+	}
+	found := false
+	for _, fn := range spec.Functions {
+		if string(fn.Name) == function {
+			m.function = fn
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("function %q not found in contract spec", function)
+	}
+	for _, s := range spec.Structs {
+		m.structs[s.Name] = s
+	}
+	for _, u := range spec.Unions {
+		m.unions[u.Name] = u
+	}
+	for _, e := range spec.Enums {
+		m.enums[e.Name] = e
+	}
+	for _, e := range spec.ErrorEnums {
+		m.errEnums[e.Name] = e
+	}
+	return m, nil
+}
+
+// LoadSpecMutator reads the contract spec embedded in a WASM binary and
+// creates a mutator for function.
+func LoadSpecMutator(wasm []byte, function string) (*SpecMutator, error) {
+	section, err := abi.ExtractCustomSection(wasm, "contractspecv0")
+	if err != nil {
+		return nil, err
+	}
+	if section == nil {
+		return nil, fmt.Errorf("no contractspecv0 section found in WASM")
+	}
+	spec, err := abi.DecodeContractSpec(section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode contract spec: %w", err)
+	}
+	return NewSpecMutator(spec, function)
+}
+
+// Function returns the spec of the function being fuzzed.
+func (m *SpecMutator) Function() xdr.ScSpecFunctionV0 {
+	return m.function
+}
+
+// Generate returns one well-typed argument list for the function.
+func (m *SpecMutator) Generate(rng *rand.Rand) ([]xdr.ScVal, error) {
+	args := make([]xdr.ScVal, len(m.function.Inputs))
+	for i, in := range m.function.Inputs {
+		v, err := m.generate(rng, in.Type, 0)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", in.Name, err)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func (m *SpecMutator) generate(rng *rand.Rand, t xdr.ScSpecTypeDef, depth int) (xdr.ScVal, error) {
+	switch t.Type {
+	case xdr.ScSpecTypeScSpecTypeVal:
+		return m.generateAny(rng), nil
+	case xdr.ScSpecTypeScSpecTypeBool:
+		b := rng.Intn(2) == 1
+		return xdr.ScVal{Type: xdr.ScValTypeScvBool, B: &b}, nil
+	case xdr.ScSpecTypeScSpecTypeVoid:
+		return xdr.ScVal{Type: xdr.ScValTypeScvVoid}, nil
+	case xdr.ScSpecTypeScSpecTypeError:
+		return scvContractError(uint32(rng.Intn(16))), nil
+	case xdr.ScSpecTypeScSpecTypeU32, xdr.ScSpecTypeScSpecTypeI32,
+		xdr.ScSpecTypeScSpecTypeU64, xdr.ScSpecTypeScSpecTypeI64,
+		xdr.ScSpecTypeScSpecTypeTimepoint, xdr.ScSpecTypeScSpecTypeDuration,
+		xdr.ScSpecTypeScSpecTypeU128, xdr.ScSpecTypeScSpecTypeI128,
+		xdr.ScSpecTypeScSpecTypeU256, xdr.ScSpecTypeScSpecTypeI256:
+		valType := specIntValType[t.Type]
+		return intToScVal(valType, generateInt(rng, valType)), nil
+	case xdr.ScSpecTypeScSpecTypeBytes:
+		return scvBytes(generateBytes(rng)), nil
+	case xdr.ScSpecTypeScSpecTypeString:
+		s := xdr.ScString(generateString(rng))
+		return xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &s}, nil
+	case xdr.ScSpecTypeScSpecTypeSymbol:
+		return scvSymbol(generateSymbol(rng)), nil
+	case xdr.ScSpecTypeScSpecTypeAddress:
+		return generateAddress(rng, false), nil
+	case xdr.ScSpecTypeScSpecTypeMuxedAddress:
+		return generateAddress(rng, true), nil
+	case xdr.ScSpecTypeScSpecTypeBytesN:
+		return scvBytes(generateBytesN(rng, int(t.BytesN.N))), nil
+	case xdr.ScSpecTypeScSpecTypeOption:
+		if depth >= specMaxDepth || rng.Float64() < 0.3 {
+			return xdr.ScVal{Type: xdr.ScValTypeScvVoid}, nil
+		}
+		return m.generate(rng, t.Option.ValueType, depth+1)
+	case xdr.ScSpecTypeScSpecTypeResult:
+		if rng.Float64() < 0.2 {
+			return m.generate(rng, t.Result.ErrorType, depth+1)
+		}
+		return m.generate(rng, t.Result.OkType, depth+1)
+	case xdr.ScSpecTypeScSpecTypeVec:
+		n := collectionLen(rng, depth)
+		vals := make([]xdr.ScVal, n)
+		for i := range vals {
+			v, err := m.generate(rng, t.Vec.ElementType, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			vals[i] = v
+		}
+		return scvVec(vals), nil
+	case xdr.ScSpecTypeScSpecTypeMap:
+		n := collectionLen(rng, depth)
+		entries := make([]xdr.ScMapEntry, 0, n)
+		for i := 0; i < n; i++ {
+			k, err := m.generate(rng, t.Map.KeyType, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			v, err := m.generate(rng, t.Map.ValueType, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			entries = append(entries, xdr.ScMapEntry{Key: k, Val: v})
+		}
+		return scvMap(entries), nil
+	case xdr.ScSpecTypeScSpecTypeTuple:
+		vals := make([]xdr.ScVal, len(t.Tuple.ValueTypes))
+		for i, vt := range t.Tuple.ValueTypes {
+			v, err := m.generate(rng, vt, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			vals[i] = v
+		}
+		return scvVec(vals), nil
+	case xdr.ScSpecTypeScSpecTypeUdt:
+		return m.generateUdt(rng, t.Udt.Name, depth)
+	default:
+		return xdr.ScVal{}, fmt.Errorf("unsupported spec type %v", t.Type)
+	}
+}
+
+func (m *SpecMutator) generateUdt(rng *rand.Rand, name string, depth int) (xdr.ScVal, error) {
+	if s, ok := m.structs[name]; ok {
+		vals := make([]xdr.ScVal, len(s.Fields))
+		for i, f := range s.Fields {
+			v, err := m.generate(rng, f.Type, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			vals[i] = v
+		}
+		return structToScVal(s, vals), nil
+	}
+	if u, ok := m.unions[name]; ok {
+		if len(u.Cases) == 0 {
+			return xdr.ScVal{}, fmt.Errorf("union %s has no cases", name)
+		}
+		// Past the depth limit prefer a void case so recursive unions end.
+		c := u.Cases[rng.Intn(len(u.Cases))]
+		if depth >= specMaxDepth {
+			for _, vc := range u.Cases {
+				if vc.Kind == xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0 {
+					c = vc
+					break
+				}
+			}
+		}
+		if c.Kind == xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0 {
+			return scvVec([]xdr.ScVal{scvSymbol(c.VoidCase.Name)}), nil
+		}
+		vals := []xdr.ScVal{scvSymbol(c.TupleCase.Name)}
+		for _, vt := range c.TupleCase.Type {
+			v, err := m.generate(rng, vt, depth+1)
+			if err != nil {
+				return xdr.ScVal{}, err
+			}
+			vals = append(vals, v)
+		}
+		return scvVec(vals), nil
+	}
+	if e, ok := m.enums[name]; ok {
+		if len(e.Cases) == 0 {
+			return xdr.ScVal{}, fmt.Errorf("enum %s has no cases", name)
+		}
+		v := xdr.Uint32(e.Cases[rng.Intn(len(e.Cases))].Value)
+		return xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &v}, nil
+	}
+	if e, ok := m.errEnums[name]; ok {
+		if len(e.Cases) == 0 {
+			return xdr.ScVal{}, fmt.Errorf("error enum %s has no cases", name)
+		}
+		return scvContractError(uint32(e.Cases[rng.Intn(len(e.Cases))].Value)), nil
+	}
+	return xdr.ScVal{}, fmt.Errorf("unknown user-defined type %s", name)
+}
+
+// generateAny picks a value of a random primitive type for spec type Val.
+func (m *SpecMutator) generateAny(rng *rand.Rand) xdr.ScVal {
+	switch rng.Intn(6) {
+	case 0:
+		return xdr.ScVal{Type: xdr.ScValTypeScvVoid}
+	case 1:
+		return intToScVal(xdr.ScValTypeScvU32, generateInt(rng, xdr.ScValTypeScvU32))
+	case 2:
+		return intToScVal(xdr.ScValTypeScvI128, generateInt(rng, xdr.ScValTypeScvI128))
+	case 3:
+		return scvSymbol(generateSymbol(rng))
+	case 4:
+		return scvBytes(generateBytes(rng))
+	default:
+		return generateAddress(rng, false)
+	}
+}
+
+// -------------------- Shrinking --------------------
+
+// Mutate returns a well-typed neighbour of args for coverage-guided fuzzing.
+// One argument is either regenerated from the spec or replaced by one of its
+// shrink candidates, so the rest of an interesting input is kept.
+func (m *SpecMutator) Mutate(rng *rand.Rand, args []xdr.ScVal) ([]xdr.ScVal, error) {
+	if len(args) != len(m.function.Inputs) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(m.function.Inputs), len(args))
+	}
+	mutated := append([]xdr.ScVal(nil), args...)
+	if len(mutated) == 0 {
+		return mutated, nil
+	}
+
+	i := rng.Intn(len(mutated))
+	in := m.function.Inputs[i]
+	if rng.Intn(2) == 0 {
+		if cands := m.shrinkCandidates(in.Type, mutated[i]); len(cands) > 0 {
+			mutated[i] = cands[rng.Intn(len(cands))]
+			return mutated, nil
+		}
+	}
+	v, err := m.generate(rng, in.Type, 0)
+	if err != nil {
+		return nil, fmt.Errorf("argument %s: %w", in.Name, err)
+	}
+	mutated[i] = v
+	return mutated, nil
+}
+
+// Shrink reduces a failing argument list to a smaller one that still fails.
+// fails runs a candidate and reports whether it reproduces the failure.
+// Candidates stay well-typed: numbers move towards zero, collections lose
+// elements, options become None and unions fall back to a unit case.
+func (m *SpecMutator) Shrink(args []xdr.ScVal, fails func([]xdr.ScVal) bool) []xdr.ScVal {
+	current := append([]xdr.ScVal(nil), args...)
+	budget := specShrinkBudget
+
+	for improved := true; improved; {
+		improved = false
+		for i, in := range m.function.Inputs {
+			if i >= len(current) {
+				break
+			}
+			for _, cand := range m.shrinkCandidates(in.Type, current[i]) {
+				if budget == 0 {
+					return current
+				}
+				budget--
+
+				trial := append([]xdr.ScVal(nil), current...)
+				trial[i] = cand
+				if fails(trial) {
+					current = trial
+					improved = true
+					break
+				}
+			}
+		}
+	}
+	return current
+}
+
+// shrinkCandidates returns smaller values of type t than v, most aggressive
+// first.
+func (m *SpecMutator) shrinkCandidates(t xdr.ScSpecTypeDef, v xdr.ScVal) []xdr.ScVal {
+	switch t.Type {
+	case xdr.ScSpecTypeScSpecTypeBool:
+		if v.B != nil && *v.B {
+			f := false
+			return []xdr.ScVal{{Type: xdr.ScValTypeScvBool, B: &f}}
+		}
+	case xdr.ScSpecTypeScSpecTypeBytes:
+		if v.Bytes != nil && len(*v.Bytes) > 0 {
+			b := *v.Bytes
+			return []xdr.ScVal{scvBytes(nil), scvBytes(b[:len(b)/2])}
+		}
+	case xdr.ScSpecTypeScSpecTypeBytesN:
+		if v.Bytes != nil && !allZero(*v.Bytes) {
+			return []xdr.ScVal{scvBytes(make([]byte, len(*v.Bytes)))}
+		}
+	case xdr.ScSpecTypeScSpecTypeString:
+		if v.Str != nil && len(*v.Str) > 0 {
+			empty, half := xdr.ScString(""), (*v.Str)[:len(*v.Str)/2]
+			return []xdr.ScVal{
+				{Type: xdr.ScValTypeScvString, Str: &empty},
+				{Type: xdr.ScValTypeScvString, Str: &half},
+			}
+		}
+	case xdr.ScSpecTypeScSpecTypeSymbol:
+		if v.Sym != nil && len(*v.Sym) > 0 {
+			s := string(*v.Sym)
+			return []xdr.ScVal{scvSymbol(""), scvSymbol(s[:len(s)/2])}
+		}
+	case xdr.ScSpecTypeScSpecTypeAddress, xdr.ScSpecTypeScSpecTypeMuxedAddress:
+		zero := scvAccountAddress(xdr.Uint256{})
+		if !scValEqual(v, zero) {
+			return []xdr.ScVal{zero}
+		}
+	case xdr.ScSpecTypeScSpecTypeOption:
+		if v.Type == xdr.ScValTypeScvVoid {
+			return nil
+		}
+		return append([]xdr.ScVal{{Type: xdr.ScValTypeScvVoid}}, m.shrinkCandidates(t.Option.ValueType, v)...)
+	case xdr.ScSpecTypeScSpecTypeVec:
+		elems := scValVec(v)
+		types := make([]xdr.ScSpecTypeDef, len(elems))
+		for i := range types {
+			types[i] = t.Vec.ElementType
+		}
+		return m.shrinkSequence(types, elems, true)
+	case xdr.ScSpecTypeScSpecTypeTuple:
+		return m.shrinkSequence(t.Tuple.ValueTypes, scValVec(v), false)
+	case xdr.ScSpecTypeScSpecTypeMap:
+		return m.shrinkMap(v, func(xdr.ScVal) (xdr.ScSpecTypeDef, bool) { return t.Map.ValueType, true }, true)
+	case xdr.ScSpecTypeScSpecTypeUdt:
+		return m.shrinkUdt(t.Udt.Name, v)
+	default:
+		if valType, ok := specIntValType[t.Type]; ok {
+			return shrinkInt(valType, v)
+		}
+	}
+	return nil
+}
+
+func (m *SpecMutator) shrinkUdt(name string, v xdr.ScVal) []xdr.ScVal {
+	if s, ok := m.structs[name]; ok {
+		if isTupleStruct(s) {
+			types := make([]xdr.ScSpecTypeDef, len(s.Fields))
+			for i, f := range s.Fields {
+				types[i] = f.Type
+			}
+			return m.shrinkSequence(types, scValVec(v), false)
+		}
+		fields := make(map[string]xdr.ScSpecTypeDef, len(s.Fields))
+		for _, f := range s.Fields {
+			fields[f.Name] = f.Type
+		}
+		return m.shrinkMap(v, func(key xdr.ScVal) (xdr.ScSpecTypeDef, bool) {
+			if key.Sym == nil {
+				return xdr.ScSpecTypeDef{}, false
+			}
+			ft, ok := fields[string(*key.Sym)]
+			return ft, ok
+		}, false)
+	}
+	if u, ok := m.unions[name]; ok {
+		elems := scValVec(v)
+		if len(elems) == 0 || elems[0].Sym == nil {
+			return nil
+		}
+		var out []xdr.ScVal
+		for _, c := range u.Cases {
+			if c.Kind == xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0 {
+				if c.VoidCase.Name != string(*elems[0].Sym) {
+					out = append(out, scvVec([]xdr.ScVal{scvSymbol(c.VoidCase.Name)}))
+				}
+				break
+			}
+		}
+		for _, c := range u.Cases {
+			if c.Kind == xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0 && c.TupleCase.Name == string(*elems[0].Sym) {
+				for _, tail := range m.shrinkSequence(c.TupleCase.Type, elems[1:], false) {
+					out = append(out, scvVec(append([]xdr.ScVal{elems[0]}, scValVec(tail)...)))
+				}
+			}
+		}
+		return out
+	}
+	if e, ok := m.enums[name]; ok && len(e.Cases) > 0 && v.U32 != nil && uint32(*v.U32) != uint32(e.Cases[0].Value) {
+		first := xdr.Uint32(e.Cases[0].Value)
+		return []xdr.ScVal{{Type: xdr.ScValTypeScvU32, U32: &first}}
+	}
+	return nil
+}
+
+// shrinkSequence shrinks a vector or tuple. Elements are only removed when
+// resizable is set, since tuples and structs have a fixed arity.
+func (m *SpecMutator) shrinkSequence(types []xdr.ScSpecTypeDef, elems []xdr.ScVal, resizable bool) []xdr.ScVal {
+	var out []xdr.ScVal
+	if resizable && len(elems) > 0 {
+		out = append(out, scvVec(nil))
+		if len(elems) > 1 {
+			out = append(out, scvVec(elems[:len(elems)/2]))
+		}
+		for i := range elems {
+			out = append(out, scvVec(removeAt(elems, i)))
+		}
+	}
+	for i := range elems {
+		if i >= len(types) {
+			break
+		}
+		for _, cand := range m.shrinkCandidates(types[i], elems[i]) {
+			next := append([]xdr.ScVal(nil), elems...)
+			next[i] = cand
+			out = append(out, scvVec(next))
+		}
+	}
+	return out
+}
+
+// shrinkMap shrinks map values in place. Keys are left alone so the map stays
+// sorted and duplicate-free.
+func (m *SpecMutator) shrinkMap(v xdr.ScVal, valueType func(key xdr.ScVal) (xdr.ScSpecTypeDef, bool), resizable bool) []xdr.ScVal {
+	entries := scValMap(v)
+	var out []xdr.ScVal
+	if resizable && len(entries) > 0 {
+		out = append(out, scvMap(nil))
+		for i := range entries {
+			next := append(append([]xdr.ScMapEntry(nil), entries[:i]...), entries[i+1:]...)
+			out = append(out, scvMap(next))
+		}
+	}
+	for i, e := range entries {
+		vt, ok := valueType(e.Key)
+		if !ok {
+			continue
+		}
+		for _, cand := range m.shrinkCandidates(vt, e.Val) {
+			next := append([]xdr.ScMapEntry(nil), entries...)
+			next[i] = xdr.ScMapEntry{Key: e.Key, Val: cand}
+			out = append(out, scvMap(next))
+		}
+	}
+	return out
+}
+
+// EncodeScVals returns the base64 XDR encoding of each value.
+func EncodeScVals(vals []xdr.ScVal) ([]string, error) {
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		s, err := xdr.MarshalBase64(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode argument %d: %w", i, err)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// DecodeScVals reverses EncodeScVals.
+func DecodeScVals(encoded []string) ([]xdr.ScVal, error) {
+	out := make([]xdr.ScVal, len(encoded))
+	for i, s := range encoded {
+		if err := xdr.SafeUnmarshalBase64(s, &out[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode argument %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// FormatScVal renders a value compactly for crash reports, for example
+// [sym("Transfer"), 0, GAAA...].
+func FormatScVal(v xdr.ScVal) string {
+	if n, ok := scValInt(v); ok {
+		return n.String()
+	}
+	switch v.Type {
+	case xdr.ScValTypeScvVoid:
+		return "()"
+	case xdr.ScValTypeScvBool:
+		return strconv.FormatBool(*v.B)
+	case xdr.ScValTypeScvSymbol:
+		return fmt.Sprintf("sym(%q)", string(*v.Sym))
+	case xdr.ScValTypeScvString:
+		return strconv.Quote(string(*v.Str))
+	case xdr.ScValTypeScvBytes:
+		return "0x" + hex.EncodeToString(*v.Bytes)
+	case xdr.ScValTypeScvError:
+		if v.Error.Type == xdr.ScErrorTypeSceContract && v.Error.ContractCode != nil {
+			return fmt.Sprintf("Error(Contract, #%d)", *v.Error.ContractCode)
+		}
+		return fmt.Sprintf("Error(%v)", v.Error.Type)
+	case xdr.ScValTypeScvAddress:
+		return formatAddress(*v.Address)
+	case xdr.ScValTypeScvVec:
+		parts := make([]string, 0)
+		for _, e := range scValVec(v) {
+			parts = append(parts, FormatScVal(e))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case xdr.ScValTypeScvMap:
+		parts := make([]string, 0)
+		for _, e := range scValMap(v) {
+			parts = append(parts, FormatScVal(e.Key)+": "+FormatScVal(e.Val))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	default:
+		return v.Type.String()
+	}
+}
+
+func formatAddress(addr xdr.ScAddress) string {
+	switch addr.Type {
+	case xdr.ScAddressTypeScAddressTypeAccount:
+		if s, err := strkey.Encode(strkey.VersionByteAccountID, addr.AccountId.Ed25519[:]); err == nil {
+			return s
+		}
+	case xdr.ScAddressTypeScAddressTypeContract:
+		if s, err := strkey.Encode(strkey.VersionByteContract, addr.ContractId[:]); err == nil {
+			return s
+		}
+	case xdr.ScAddressTypeScAddressTypeMuxedAccount:
+		if s, err := strkey.Encode(strkey.VersionByteAccountID, addr.MuxedAccount.Ed25519[:]); err == nil {
+			return fmt.Sprintf("%s#%d", s, addr.MuxedAccount.Id)
+		}
+	}
+	return addr.Type.String()
+}
+
+// -------------------- Integers --------------------
+
+var specIntValType = map[xdr.ScSpecType]xdr.ScValType{
+	xdr.ScSpecTypeScSpecTypeU32:       xdr.ScValTypeScvU32,
+	xdr.ScSpecTypeScSpecTypeI32:       xdr.ScValTypeScvI32,
+	xdr.ScSpecTypeScSpecTypeU64:       xdr.ScValTypeScvU64,
+	xdr.ScSpecTypeScSpecTypeI64:       xdr.ScValTypeScvI64,
+	xdr.ScSpecTypeScSpecTypeTimepoint: xdr.ScValTypeScvTimepoint,
+	xdr.ScSpecTypeScSpecTypeDuration:  xdr.ScValTypeScvDuration,
+	xdr.ScSpecTypeScSpecTypeU128:      xdr.ScValTypeScvU128,
+	xdr.ScSpecTypeScSpecTypeI128:      xdr.ScValTypeScvI128,
+	xdr.ScSpecTypeScSpecTypeU256:      xdr.ScValTypeScvU256,
+	xdr.ScSpecTypeScSpecTypeI256:      xdr.ScValTypeScvI256,
+}
+
+// intWidth returns the bit width and signedness of an integer ScVal type.
+func intWidth(t xdr.ScValType) (uint, bool) {
+	switch t {
+	case xdr.ScValTypeScvU32:
+		return 32, false
+	case xdr.ScValTypeScvI32:
+		return 32, true
+	case xdr.ScValTypeScvU64, xdr.ScValTypeScvTimepoint, xdr.ScValTypeScvDuration:
+		return 64, false
+	case xdr.ScValTypeScvI64:
+		return 64, true
+	case xdr.ScValTypeScvU128:
+		return 128, false
+	case xdr.ScValTypeScvI128:
+		return 128, true
+	case xdr.ScValTypeScvU256:
+		return 256, false
+	default:
+		return 256, true
+	}
+}
+
+func intRange(t xdr.ScValType) (lo, hi *big.Int) {
+	bits, signed := intWidth(t)
+	one := big.NewInt(1)
+	if !signed {
+		return big.NewInt(0), new(big.Int).Sub(new(big.Int).Lsh(one, bits), one)
+	}
+	half := new(big.Int).Lsh(one, bits-1)
+	return new(big.Int).Neg(half), new(big.Int).Sub(half, one)
+}
+
+// intBoundaries lists the values most likely to break arithmetic: the ends
+// of the range, their neighbours, zero and the word-size crossover.
+func intBoundaries(t xdr.ScValType) []*big.Int {
+	lo, hi := intRange(t)
+	bits, signed := intWidth(t)
+	one := big.NewInt(1)
+	vals := []*big.Int{
+		lo, new(big.Int).Add(lo, one),
+		big.NewInt(0), big.NewInt(1),
+		new(big.Int).Sub(hi, one), hi,
+		new(big.Int).Rsh(hi, 1),
+	}
+	if signed {
+		vals = append(vals, big.NewInt(-1))
+	}
+	if bits > 64 {
+		vals = append(vals, new(big.Int).Lsh(one, 64), new(big.Int).Sub(new(big.Int).Lsh(one, 64), one))
+	}
+	return vals
+}
+
+func generateInt(rng *rand.Rand, t xdr.ScValType) *big.Int {
+	if rng.Float64() < specBoundaryChance {
+		b := intBoundaries(t)
+		return b[rng.Intn(len(b))]
+	}
+
+	lo, hi := intRange(t)
+	if rng.Intn(2) == 0 {
+		// Small values exercise ordinary code paths.
+		v := big.NewInt(int64(rng.Intn(201) - 100))
+		if v.Cmp(lo) < 0 {
+			v.Neg(v)
+		}
+		return v
+	}
+	span := new(big.Int).Sub(hi, lo)
+	span.Add(span, big.NewInt(1))
+	return new(big.Int).Add(lo, new(big.Int).Rand(rng, span))
+}
+
+// shrinkInt proposes zero, then values ever closer to v: v minus half its
+// distance from zero, a quarter, and so on down to one step. Accepting the
+// first failing candidate each round binary-searches the failure boundary.
+func shrinkInt(t xdr.ScValType, v xdr.ScVal) []xdr.ScVal {
+	n, ok := scValInt(v)
+	if !ok || n.Sign() == 0 {
+		return nil
+	}
+
+	out := []xdr.ScVal{intToScVal(t, big.NewInt(0))}
+	delta := new(big.Int).Quo(n, big.NewInt(2))
+	for delta.Sign() != 0 {
+		out = append(out, intToScVal(t, new(big.Int).Sub(n, delta)))
+		delta.Quo(delta, big.NewInt(2))
+	}
+	return out
+}
+
+// intToScVal encodes n, which must be in range for t.
+func intToScVal(t xdr.ScValType, n *big.Int) xdr.ScVal {
+	bits, _ := intWidth(t)
+	u := new(big.Int).Set(n)
+	if u.Sign() < 0 {
+		u.Add(u, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	words := make([]uint64, 4)
+	mask := new(big.Int).SetUint64(^uint64(0))
+	for i := range words {
+		words[i] = new(big.Int).And(new(big.Int).Rsh(u, uint(64*i)), mask).Uint64()
+	}
+
+	v := xdr.ScVal{Type: t}
+	switch t {
+	case xdr.ScValTypeScvU32:
+		x := xdr.Uint32(words[0])
+		v.U32 = &x
+	case xdr.ScValTypeScvI32:
+		x := xdr.Int32(int32(uint32(words[0])))
+		v.I32 = &x
+	case xdr.ScValTypeScvU64:
+		x := xdr.Uint64(words[0])
+		v.U64 = &x
+	case xdr.ScValTypeScvI64:
+		x := xdr.Int64(int64(words[0]))
+		v.I64 = &x
+	case xdr.ScValTypeScvTimepoint:
+		x := xdr.TimePoint(words[0])
+		v.Timepoint = &x
+	case xdr.ScValTypeScvDuration:
+		x := xdr.Duration(words[0])
+		v.Duration = &x
+	case xdr.ScValTypeScvU128:
+		v.U128 = &xdr.UInt128Parts{Hi: xdr.Uint64(words[1]), Lo: xdr.Uint64(words[0])}
+	case xdr.ScValTypeScvI128:
+		v.I128 = &xdr.Int128Parts{Hi: xdr.Int64(int64(words[1])), Lo: xdr.Uint64(words[0])}
+	case xdr.ScValTypeScvU256:
+		v.U256 = &xdr.UInt256Parts{
+			HiHi: xdr.Uint64(words[3]), HiLo: xdr.Uint64(words[2]),
+			LoHi: xdr.Uint64(words[1]), LoLo: xdr.Uint64(words[0]),
+		}
+	case xdr.ScValTypeScvI256:
+		v.I256 = &xdr.Int256Parts{
+			HiHi: xdr.Int64(int64(words[3])), HiLo: xdr.Uint64(words[2]),
+			LoHi: xdr.Uint64(words[1]), LoLo: xdr.Uint64(words[0]),
+		}
+	}
+	return v
+}
+
+// scValInt decodes an integer ScVal of any width.
+func scValInt(v xdr.ScVal) (*big.Int, bool) {
+	var words [4]uint64
+	switch v.Type {
+	case xdr.ScValTypeScvU32:
+		return big.NewInt(int64(*v.U32)), true
+	case xdr.ScValTypeScvI32:
+		return big.NewInt(int64(*v.I32)), true
+	case xdr.ScValTypeScvU64:
+		return new(big.Int).SetUint64(uint64(*v.U64)), true
+	case xdr.ScValTypeScvI64:
+		return big.NewInt(int64(*v.I64)), true
+	case xdr.ScValTypeScvTimepoint:
+		return new(big.Int).SetUint64(uint64(*v.Timepoint)), true
+	case xdr.ScValTypeScvDuration:
+		return new(big.Int).SetUint64(uint64(*v.Duration)), true
+	case xdr.ScValTypeScvU128:
+		words = [4]uint64{uint64(v.U128.Lo), uint64(v.U128.Hi)}
+	case xdr.ScValTypeScvI128:
+		words = [4]uint64{uint64(v.I128.Lo), uint64(v.I128.Hi)}
+	case xdr.ScValTypeScvU256:
+		words = [4]uint64{uint64(v.U256.LoLo), uint64(v.U256.LoHi), uint64(v.U256.HiLo), uint64(v.U256.HiHi)}
+	case xdr.ScValTypeScvI256:
+		words = [4]uint64{uint64(v.I256.LoLo), uint64(v.I256.LoHi), uint64(v.I256.HiLo), uint64(v.I256.HiHi)}
+	default:
+		return nil, false
+	}
+
+	n := new(big.Int)
+	for i := len(words) - 1; i >= 0; i-- {
+		n.Lsh(n, 64).Or(n, new(big.Int).SetUint64(words[i]))
+	}
+	bits, signed := intWidth(v.Type)
+	if signed && n.Bit(int(bits)-1) == 1 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	return n, true
+}
+
+// -------------------- Primitives --------------------
+
+func generateBytes(rng *rand.Rand) []byte {
+	if rng.Float64() < specBoundaryChance {
+		switch rng.Intn(3) {
+		case 0:
+			return nil
+		case 1:
+			return bytes.Repeat([]byte{0xff}, 1+rng.Intn(64))
+		default:
+			return make([]byte, 256)
+		}
+	}
+	b := make([]byte, rng.Intn(33))
+	rng.Read(b)
+	return b
+}
+
+func generateBytesN(rng *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	switch {
+	case rng.Float64() >= specBoundaryChance:
+		rng.Read(b)
+	case rng.Intn(2) == 0:
+		for i := range b {
+			b[i] = 0xff
+		}
+	}
+	return b
+}
+
+func generateString(rng *rand.Rand) string {
+	if rng.Float64() < specBoundaryChance {
+		boundaries := []string{"", "\x00", "é∑😀", string(bytes.Repeat([]byte("a"), 256))}
+		return boundaries[rng.Intn(len(boundaries))]
+	}
+	b := make([]byte, rng.Intn(17))
+	for i := range b {
+		b[i] = byte(0x20 + rng.Intn(0x5f))
+	}
+	return string(b)
+}
+
+func generateSymbol(rng *rand.Rand) string {
+	n := 1 + rng.Intn(12)
+	if rng.Float64() < specBoundaryChance {
+		// Symbols hold at most 32 characters.
+		n = []int{0, 1, 32}[rng.Intn(3)]
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = symbolChars[rng.Intn(len(symbolChars))]
+	}
+	return string(b)
+}
+
+func generateAddress(rng *rand.Rand, allowMuxed bool) xdr.ScVal {
+	var key xdr.Uint256
+	if rng.Float64() < specBoundaryChance {
+		if rng.Intn(2) == 0 {
+			for i := range key {
+				key[i] = 0xff
+			}
+		}
+	} else {
+		rng.Read(key[:])
+	}
+
+	kinds := 2
+	if allowMuxed {
+		kinds = 3
+	}
+	switch rng.Intn(kinds) {
+	case 0:
+		return scvAccountAddress(key)
+	case 1:
+		id := xdr.ContractId(key)
+		return scvAddress(xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &id})
+	default:
+		return scvAddress(xdr.ScAddress{
+			Type:         xdr.ScAddressTypeScAddressTypeMuxedAccount,
+			MuxedAccount: &xdr.MuxedEd25519Account{Id: xdr.Uint64(rng.Uint64()), Ed25519: key},
+		})
+	}
+}
+
+func collectionLen(rng *rand.Rand, depth int) int {
+	if depth >= specMaxDepth {
+		return 0
+	}
+	return rng.Intn(specMaxCollection + 1)
+}
+
+// -------------------- ScVal helpers --------------------
+
+// structToScVal encodes a struct the way the Soroban SDK does: a vector for
+// tuple structs and a symbol-keyed map, sorted by field name, otherwise.
+func structToScVal(s xdr.ScSpecUdtStructV0, vals []xdr.ScVal) xdr.ScVal {
+	if isTupleStruct(s) {
+		return scvVec(vals)
+	}
+	entries := make([]xdr.ScMapEntry, len(s.Fields))
+	for i, f := range s.Fields {
+		entries[i] = xdr.ScMapEntry{Key: scvSymbol(f.Name), Val: vals[i]}
+	}
+	return scvMap(entries)
+}
+
+func isTupleStruct(s xdr.ScSpecUdtStructV0) bool {
+	if len(s.Fields) == 0 {
+		return false
+	}
+	for _, f := range s.Fields {
+		if _, err := strconv.Atoi(f.Name); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func scvSymbol(s string) xdr.ScVal {
+	sym := xdr.ScSymbol(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+}
+
+func scvBytes(b []byte) xdr.ScVal {
+	sb := xdr.ScBytes(append([]byte{}, b...))
+	return xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &sb}
+}
+
+func scvAddress(addr xdr.ScAddress) xdr.ScVal {
+	return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &addr}
+}
+
+func scvAccountAddress(key xdr.Uint256) xdr.ScVal {
+	id := xdr.AccountId(xdr.PublicKey{Type: xdr.PublicKeyTypePublicKeyTypeEd25519, Ed25519: &key})
+	return scvAddress(xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &id})
+}
+
+func scvContractError(code uint32) xdr.ScVal {
+	c := xdr.Uint32(code)
+	return xdr.ScVal{Type: xdr.ScValTypeScvError, Error: &xdr.ScError{Type: xdr.ScErrorTypeSceContract, ContractCode: &c}}
+}
+
+func scvVec(vals []xdr.ScVal) xdr.ScVal {
+	vec := xdr.ScVec(append([]xdr.ScVal{}, vals...))
+	p := &vec
+	return xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &p}
+}
+
+// scvMap builds a map the host accepts: keys deduplicated and sorted.
+func scvMap(entries []xdr.ScMapEntry) xdr.ScVal {
+	sorted := make([]xdr.ScMapEntry, 0, len(entries))
+	for _, e := range entries {
+		dup := false
+		for _, s := range sorted {
+			if scValEqual(s.Key, e.Key) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareScVal(sorted[i].Key, sorted[j].Key) < 0
+	})
+	m := xdr.ScMap(sorted)
+	p := &m
+	return xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &p}
+}
+
+func scValVec(v xdr.ScVal) []xdr.ScVal {
+	if v.Type != xdr.ScValTypeScvVec || v.Vec == nil || *v.Vec == nil {
+		return nil
+	}
+	return **v.Vec
+}
+
+func scValMap(v xdr.ScVal) []xdr.ScMapEntry {
+	if v.Type != xdr.ScValTypeScvMap || v.Map == nil || *v.Map == nil {
+		return nil
+	}
+	return **v.Map
+}
+
+func removeAt(vals []xdr.ScVal, i int) []xdr.ScVal {
+	return append(append([]xdr.ScVal(nil), vals[:i]...), vals[i+1:]...)
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func scValEqual(a, b xdr.ScVal) bool {
+	ab, errA := a.MarshalBinary()
+	bb, errB := b.MarshalBinary()
+	return errA == nil && errB == nil && bytes.Equal(ab, bb)
+}
+
+// compareScVal orders map keys the way the host does for the key types specs
+// use: numerically for integers and bytewise for symbols, strings and bytes.
+// Other keys fall back to their XDR encoding.
+func compareScVal(a, b xdr.ScVal) int {
+	if a.Type != b.Type {
+		if a.Type < b.Type {
+			return -1
+		}
+		return 1
+	}
+	if x, ok := scValInt(a); ok {
+		y, _ := scValInt(b)
+		return x.Cmp(y)
+	}
+	switch a.Type {
+	case xdr.ScValTypeScvSymbol:
+		return bytes.Compare([]byte(*a.Sym), []byte(*b.Sym))
+	case xdr.ScValTypeScvString:
+		return bytes.Compare([]byte(*a.Str), []byte(*b.Str))
+	case xdr.ScValTypeScvBytes:
+		return bytes.Compare(*a.Bytes, *b.Bytes)
+	}
+	ab, _ := a.MarshalBinary()
+	bb, _ := b.MarshalBinary()
+	return bytes.Compare(ab, bb)
+}
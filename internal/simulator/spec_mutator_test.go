@@ -0,0 +1,335 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/dotandev/hintents/internal/errors"
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func specType(t xdr.ScSpecType) xdr.ScSpecTypeDef {
+	return xdr.ScSpecTypeDef{Type: t}
+}
+
+func udtType(name string) xdr.ScSpecTypeDef {
+	return xdr.ScSpecTypeDef{Type: xdr.ScSpecTypeScSpecTypeUdt, Udt: &xdr.ScSpecTypeUdt{Name: name}}
+}
+
+// testContractSpec describes
+//
+//	fn deposit(amount: u32, delta: i128, owners: Vec<Address>, memo: Option<Symbol>,
+//	           order: Order, action: Action, side: Side, hash: BytesN<32>, limits: Map<Symbol, u32>)
+func testContractSpec() *abi.ContractSpec {
+	return &abi.ContractSpec{
+		Functions: []xdr.ScSpecFunctionV0{{
+			Name: "deposit",
+			Inputs: []xdr.ScSpecFunctionInputV0{
+				{Name: "amount", Type: specType(xdr.ScSpecTypeScSpecTypeU32)},
+				{Name: "delta", Type: specType(xdr.ScSpecTypeScSpecTypeI128)},
+				{Name: "owners", Type: xdr.ScSpecTypeDef{
+					Type: xdr.ScSpecTypeScSpecTypeVec,
+					Vec:  &xdr.ScSpecTypeVec{ElementType: specType(xdr.ScSpecTypeScSpecTypeAddress)},
+				}},
+				{Name: "memo", Type: xdr.ScSpecTypeDef{
+					Type:   xdr.ScSpecTypeScSpecTypeOption,
+					Option: &xdr.ScSpecTypeOption{ValueType: specType(xdr.ScSpecTypeScSpecTypeSymbol)},
+				}},
+				{Name: "order", Type: udtType("Order")},
+				{Name: "action", Type: udtType("Action")},
+				{Name: "side", Type: udtType("Side")},
+				{Name: "hash", Type: xdr.ScSpecTypeDef{
+					Type:   xdr.ScSpecTypeScSpecTypeBytesN,
+					BytesN: &xdr.ScSpecTypeBytesN{N: 32},
+				}},
+				{Name: "limits", Type: xdr.ScSpecTypeDef{
+					Type: xdr.ScSpecTypeScSpecTypeMap,
+					Map: &xdr.ScSpecTypeMap{
+						KeyType:   specType(xdr.ScSpecTypeScSpecTypeSymbol),
+						ValueType: specType(xdr.ScSpecTypeScSpecTypeU32),
+					},
+				}},
+			},
+		}},
+		Structs: []xdr.ScSpecUdtStructV0{{
+			Name: "Order",
+			Fields: []xdr.ScSpecUdtStructFieldV0{
+				{Name: "price", Type: specType(xdr.ScSpecTypeScSpecTypeI64)},
+				{Name: "maker", Type: specType(xdr.ScSpecTypeScSpecTypeAddress)},
+			},
+		}},
+		Unions: []xdr.ScSpecUdtUnionV0{{
+			Name: "Action",
+			Cases: []xdr.ScSpecUdtUnionCaseV0{
+				{Kind: xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0, VoidCase: &xdr.ScSpecUdtUnionCaseVoidV0{Name: "Hold"}},
+				{Kind: xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0, TupleCase: &xdr.ScSpecUdtUnionCaseTupleV0{
+					Name: "Move",
+					Type: []xdr.ScSpecTypeDef{specType(xdr.ScSpecTypeScSpecTypeU64)},
+				}},
+			},
+		}},
+		Enums: []xdr.ScSpecUdtEnumV0{{
+			Name:  "Side",
+			Cases: []xdr.ScSpecUdtEnumCaseV0{{Name: "Buy", Value: 1}, {Name: "Sell", Value: 5}},
+		}},
+	}
+}
+
+func TestSpecMutator_GeneratesWellTypedArguments(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	for seed := int64(0); seed < 200; seed++ {
+		args, err := m.Generate(rand.New(rand.NewSource(seed)))
+		require.NoError(t, err)
+		require.Len(t, args, 9)
+
+		assert.Equal(t, xdr.ScValTypeScvU32, args[0].Type)
+		assert.Equal(t, xdr.ScValTypeScvI128, args[1].Type)
+
+		require.Equal(t, xdr.ScValTypeScvVec, args[2].Type)
+		for _, owner := range scValVec(args[2]) {
+			assert.Equal(t, xdr.ScValTypeScvAddress, owner.Type)
+		}
+
+		assert.Contains(t, []xdr.ScValType{xdr.ScValTypeScvVoid, xdr.ScValTypeScvSymbol}, args[3].Type)
+		if args[3].Sym != nil {
+			assert.LessOrEqual(t, len(*args[3].Sym), 32)
+		}
+
+		// Named structs are symbol-keyed maps sorted by field name.
+		fields := scValMap(args[4])
+		require.Len(t, fields, 2)
+		assert.Equal(t, "maker", string(*fields[0].Key.Sym))
+		assert.Equal(t, xdr.ScValTypeScvAddress, fields[0].Val.Type)
+		assert.Equal(t, "price", string(*fields[1].Key.Sym))
+		assert.Equal(t, xdr.ScValTypeScvI64, fields[1].Val.Type)
+
+		action := scValVec(args[5])
+		require.NotEmpty(t, action)
+		switch string(*action[0].Sym) {
+		case "Hold":
+			assert.Len(t, action, 1)
+		case "Move":
+			require.Len(t, action, 2)
+			assert.Equal(t, xdr.ScValTypeScvU64, action[1].Type)
+		default:
+			t.Fatalf("unexpected union case %s", *action[0].Sym)
+		}
+
+		require.NotNil(t, args[6].U32)
+		assert.Contains(t, []uint32{1, 5}, uint32(*args[6].U32))
+
+		require.NotNil(t, args[7].Bytes)
+		assert.Len(t, *args[7].Bytes, 32)
+
+		limits := scValMap(args[8])
+		for i := 1; i < len(limits); i++ {
+			assert.Negative(t, compareScVal(limits[i-1].Key, limits[i].Key), "map keys must be sorted and unique")
+		}
+
+		_, err = EncodeScVals(args)
+		require.NoError(t, err)
+	}
+}
+
+func TestSpecMutator_HitsBoundaries(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 300; seed++ {
+		args, err := m.Generate(rand.New(rand.NewSource(seed)))
+		require.NoError(t, err)
+		amount, _ := scValInt(args[0])
+		delta, _ := scValInt(args[1])
+		seen["u32:"+amount.String()] = true
+		seen["i128:"+delta.String()] = true
+	}
+
+	minI128 := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	for _, want := range []string{"u32:0", "u32:4294967295", "i128:-1", "i128:" + minI128.String()} {
+		assert.True(t, seen[want], "expected boundary %s to be generated", want)
+	}
+}
+
+func TestSpecMutator_IntRoundTrip(t *testing.T) {
+	for _, typ := range []xdr.ScValType{
+		xdr.ScValTypeScvU32, xdr.ScValTypeScvI32, xdr.ScValTypeScvU64, xdr.ScValTypeScvI64,
+		xdr.ScValTypeScvU128, xdr.ScValTypeScvI128, xdr.ScValTypeScvU256, xdr.ScValTypeScvI256,
+	} {
+		for _, n := range intBoundaries(typ) {
+			got, ok := scValInt(intToScVal(typ, n))
+			require.True(t, ok)
+			assert.Equal(t, 0, n.Cmp(got), "%v: %s round-tripped to %s", typ, n, got)
+		}
+	}
+
+	v, _ := scValInt(intToScVal(xdr.ScValTypeScvI32, big.NewInt(math.MinInt32)))
+	assert.Equal(t, int64(math.MinInt32), v.Int64())
+}
+
+func TestSpecMutator_ShrinkFindsMinimalInput(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	args, err := m.Generate(rand.New(rand.NewSource(7)))
+	require.NoError(t, err)
+	args[0] = intToScVal(xdr.ScValTypeScvU32, big.NewInt(3_000_000_000))
+
+	// The contract "panics" whenever amount exceeds 1000.
+	fails := func(candidate []xdr.ScVal) bool {
+		amount, _ := scValInt(candidate[0])
+		return amount.Cmp(big.NewInt(1000)) > 0
+	}
+	minimal := m.Shrink(args, fails)
+
+	amount, _ := scValInt(minimal[0])
+	assert.Equal(t, int64(1001), amount.Int64())
+	delta, _ := scValInt(minimal[1])
+	assert.Equal(t, int64(0), delta.Int64())
+	assert.Empty(t, scValVec(minimal[2]))
+	assert.Equal(t, xdr.ScValTypeScvVoid, minimal[3].Type)
+	assert.Equal(t, []xdr.ScVal{scvSymbol("Hold")}, scValVec(minimal[5]))
+	assert.Equal(t, uint32(1), uint32(*minimal[6].U32))
+	assert.True(t, allZero(*minimal[7].Bytes))
+	assert.Empty(t, scValMap(minimal[8]))
+}
+
+func TestSpecMutator_MutateChangesOneArgument(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(3))
+	args, err := m.Generate(rng)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		mutated, err := m.Mutate(rng, args)
+		require.NoError(t, err)
+		require.Len(t, mutated, len(args))
+
+		changed := 0
+		for j := range args {
+			if !scValEqual(args[j], mutated[j]) {
+				changed++
+			}
+		}
+		assert.LessOrEqual(t, changed, 1)
+	}
+
+	_, err = m.Mutate(rng, args[:2])
+	assert.Error(t, err)
+}
+
+func TestFuzzSpec_ReportsShrunkCrashes(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	runner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		var env xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(req.EnvelopeXdr, &env))
+		args := env.V1.Tx.Operations[0].Body.InvokeHostFunctionOp.HostFunction.InvokeContract.Args
+		amount, _ := scValInt(args[0])
+		if amount.Cmp(big.NewInt(1000)) > 0 {
+			return nil, errors.NewSimErrorMsg(errors.CodeSimCrash, "wasm trap: unreachable")
+		}
+		if amount.Sign() == 0 {
+			return nil, errors.NewSimErrorMsg(errors.CodeSimExecFailed, "Error(Contract, #3)")
+		}
+		return &SimulationResponse{Status: "success"}, nil
+	})
+
+	harness := NewFuzzingHarness(runner, FuzzingConfig{MaxIterations: 50})
+	target := SpecFuzzTarget{Wasm: []byte("\x00asm\x01\x00\x00\x00"), Mutator: m}
+	results, crashes, err := harness.FuzzSpec(target, 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 50)
+
+	require.Len(t, crashes, 1, "identical crashes should be reported once")
+	args, err := DecodeScVals(crashes[0].Args)
+	require.NoError(t, err)
+	amount, _ := scValInt(args[0])
+	assert.Equal(t, int64(1001), amount.Int64())
+	assert.True(t, strings.HasPrefix(FormatScVal(args[0]), "1001"))
+
+	statuses := make(map[string]int)
+	for _, r := range results {
+		statuses[r.Status]++
+	}
+	assert.Positive(t, statuses["crash"])
+	assert.Positive(t, statuses["error"], "contract errors are not crashes")
+}
+
+// amountChecker reports a violation when the first argument exceeds limit.
+type amountChecker struct {
+	t     *testing.T
+	limit int64
+}
+
+func (c amountChecker) Check(req *SimulationRequest, resp *SimulationResponse) []InvariantViolation {
+	var env xdr.TransactionEnvelope
+	require.NoError(c.t, xdr.SafeUnmarshalBase64(req.EnvelopeXdr, &env))
+	args := env.V1.Tx.Operations[0].Body.InvokeHostFunctionOp.HostFunction.InvokeContract.Args
+	amount, _ := scValInt(args[0])
+	if amount.Cmp(big.NewInt(c.limit)) > 0 {
+		return []InvariantViolation{{Invariant: "bounded deposit", Message: "deposit of " + amount.String()}}
+	}
+	return nil
+}
+
+func TestFuzzSpec_ReportsInvariantViolations(t *testing.T) {
+	m, err := NewSpecMutator(testContractSpec(), "deposit")
+	require.NoError(t, err)
+
+	runner := NewMockRunner(func(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
+		return &SimulationResponse{Status: "success"}, nil
+	})
+
+	harness := NewFuzzingHarness(runner, FuzzingConfig{MaxIterations: 50})
+	harness.Invariants = amountChecker{t: t, limit: 500}
+	target := SpecFuzzTarget{Wasm: []byte("\x00asm\x01\x00\x00\x00"), Mutator: m}
+	results, crashes, err := harness.FuzzSpec(target, 1)
+	require.NoError(t, err)
+	assert.Empty(t, crashes, "violations are not crashes")
+
+	require.Len(t, harness.ViolatingInputs, 1, "violations of one invariant should be reported once")
+	args, err := DecodeScVals(harness.ViolatingInputs[0].Args)
+	require.NoError(t, err)
+	amount, _ := scValInt(args[0])
+	assert.Equal(t, int64(501), amount.Int64())
+
+	var violated int
+	for _, r := range results {
+		if r.Status == "invariant" {
+			violated++
+			assert.Contains(t, r.ErrorMessage, `invariant "bounded deposit" violated`)
+		}
+	}
+	assert.Positive(t, violated)
+	assert.Contains(t, harness.Summary(), "Invariant Violations: 1")
+}
+
+func TestBuildLocalInvocation(t *testing.T) {
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	inv, err := BuildLocalInvocation(wasm, "deposit", []xdr.ScVal{scvSymbol("x")})
+	require.NoError(t, err)
+	assert.Len(t, inv.LedgerEntries, 2)
+
+	var env xdr.TransactionEnvelope
+	require.NoError(t, xdr.SafeUnmarshalBase64(inv.EnvelopeXdr, &env))
+	call := env.V1.Tx.Operations[0].Body.InvokeHostFunctionOp.HostFunction.InvokeContract
+	assert.Equal(t, "deposit", string(call.FunctionName))
+	assert.Equal(t, inv.ContractID, *call.ContractAddress.ContractId)
+	assert.Len(t, env.V1.Tx.Ext.SorobanData.Resources.Footprint.ReadOnly, 2)
+}
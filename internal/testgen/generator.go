@@ -9,10 +9,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/dotandev/hintents/internal/rpc"
+	"github.com/dotandev/hintents/internal/simulator"
 )
 
 // Formal schema validation regex
@@ -23,10 +25,17 @@ var (
 
 // TestGenerator handles the generation of regression tests
 type TestGenerator struct {
-	RPCClient *rpc.Client
+	Source rpc.TransactionSource // an RPC client or a history archive
+	// State, when set, supplies the ledger entries embedded in the
+	// generated tests.
+	State     LedgerStateFunc
 	OutputDir string
 }
 
+// LedgerStateFunc returns the ledger entries a transaction read when it was
+// applied, keyed by base64 LedgerKey XDR.
+type LedgerStateFunc func(ctx context.Context, txHash string, tx *rpc.TransactionResponse) (map[string]string, error)
+
 // TestData contains the data needed to generate a test.
 // Struct tags added to reflect formal schema for internal documentation.
 type TestData struct {
@@ -58,9 +67,9 @@ type LedgerEntry struct {
 }
 
 // NewTestGenerator creates a new test generator
-func NewTestGenerator(client *rpc.Client, outputDir string) *TestGenerator {
+func NewTestGenerator(source rpc.TransactionSource, outputDir string) *TestGenerator {
 	return &TestGenerator{
-		RPCClient: client,
+		Source:    source,
 		OutputDir: outputDir,
 	}
 }
@@ -94,25 +103,41 @@ func (g *TestGenerator) GenerateTests(ctx context.Context, txHash string, lang s
 	}
 }
 
-// fetchTransactionData fetches transaction data from the RPC client
+// fetchTransactionData fetches transaction data from the transaction source
 func (g *TestGenerator) fetchTransactionData(ctx context.Context, txHash string, testName string) (*TestData, error) {
-	resp, err := g.RPCClient.GetTransaction(ctx, txHash)
+	resp, err := g.Source.GetTransaction(ctx, txHash)
 	if err != nil {
 		return nil, err
 	}
 
+	// History archives keep no result meta.
+	resultMetaXdr := resp.ResultMetaXdr
+	if resultMetaXdr == "" {
+		resultMetaXdr = simulator.PlaceholderResultMeta
+	}
+
 	if testName == "" {
 		testName = sanitizeTestName(txHash)
 	}
 
-	// TODO: Fetch ledger entries from transaction footprint
 	ledgerEntries := []LedgerEntry{}
+	if g.State != nil {
+		entries, err := g.State(ctx, txHash, resp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ledger entries: %w", err)
+		}
+		for key, value := range entries {
+			ledgerEntries = append(ledgerEntries, LedgerEntry{Key: key, Value: value})
+		}
+		// Sorted so that regenerating a test yields the same file.
+		sort.Slice(ledgerEntries, func(i, j int) bool { return ledgerEntries[i].Key < ledgerEntries[j].Key })
+	}
 
 	return &TestData{
 		TestName:      testName,
 		TxHash:        txHash,
 		EnvelopeXdr:   resp.EnvelopeXdr,
-		ResultMetaXdr: resp.ResultMetaXdr,
+		ResultMetaXdr: resultMetaXdr,
 		LedgerEntries: ledgerEntries,
 	}, nil
 }
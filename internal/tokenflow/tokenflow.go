@@ -173,7 +173,12 @@ func extractSACTransfersAndMints(resultMetaXdrB64 string) ([]Transfer, error) {
 		return nil, fmt.Errorf("unmarshal TransactionResultMeta: %w", err)
 	}
 
-	diag := extractDiagnosticEvents(rm.TxApplyProcessing)
+	return TransfersFromEvents(extractDiagnosticEvents(rm.TxApplyProcessing)), nil
+}
+
+// TransfersFromEvents extracts SAC transfers and mints from diagnostic events.
+// Events of reverted calls are skipped.
+func TransfersFromEvents(diag []xdr.DiagnosticEvent) []Transfer {
 	var out []Transfer
 
 	for _, de := range diag {
@@ -250,7 +255,7 @@ func extractSACTransfersAndMints(resultMetaXdrB64 string) ([]Transfer, error) {
 		}
 	}
 
-	return out, nil
+	return out
 }
 
 func extractDiagnosticEvents(tm xdr.TransactionMeta) []xdr.DiagnosticEvent {
@@ -0,0 +1,312 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BreakpointKind selects what a Breakpoint matches.
+type BreakpointKind string
+
+const (
+	BreakOnContract  BreakpointKind = "contract"
+	BreakOnFunction  BreakpointKind = "function"
+	BreakOnEvent     BreakpointKind = "event"
+	BreakOnError     BreakpointKind = "error"
+	BreakOnCondition BreakpointKind = "condition"
+)
+
+// Breakpoint stops continue and reverse-continue at matching steps.
+//
+// Contract, function, event and error breakpoints match every step they
+// describe. Condition breakpoints are evaluated over the reconstructed host
+// state and match only where the condition becomes true, so `balance < 0`
+// stops once when the balance goes negative rather than on every later step.
+type Breakpoint struct {
+	ID      int
+	Kind    BreakpointKind
+	Value   string
+	Enabled bool
+	Hits    int
+
+	expr *Expr
+}
+
+// ParseBreakpoint builds a breakpoint from viewer arguments:
+//
+//	contract <id-or-prefix>
+//	function <name>
+//	event <trap|contract_call|host_function|auth>
+//	error <substring>
+//	if <expression>
+//
+// Anything else is treated as a condition expression.
+func ParseBreakpoint(args []string) (*Breakpoint, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing breakpoint")
+	}
+
+	kind := BreakpointKind(strings.ToLower(args[0]))
+	rest := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch kind {
+	case "fn", "func":
+		kind = BreakOnFunction
+	case "if", "when", "cond":
+		kind = BreakOnCondition
+	case BreakOnContract, BreakOnFunction, BreakOnEvent, BreakOnError, BreakOnCondition:
+	default:
+		kind = BreakOnCondition
+		rest = strings.Join(args, " ")
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("missing value for %s breakpoint", kind)
+	}
+
+	bp := &Breakpoint{Kind: kind, Value: rest, Enabled: true}
+	switch kind {
+	case BreakOnEvent:
+		if normalizeEventType(rest) == EventTypeOther {
+			return nil, fmt.Errorf("unknown event type %q (use one of %s)", rest, strings.Join(AllFilterableEventTypes(), ", "))
+		}
+		bp.Value = normalizeEventType(rest)
+	case BreakOnCondition:
+		expr, err := ParseExpr(rest)
+		if err != nil {
+			return nil, err
+		}
+		bp.expr = expr
+	}
+	return bp, nil
+}
+
+func (b *Breakpoint) String() string {
+	return fmt.Sprintf("%s %s", b.Kind, b.Value)
+}
+
+// matchesStep reports whether the breakpoint describes state. Condition
+// breakpoints need the reconstructed state and are handled by the scanner.
+func (b *Breakpoint) matchesStep(state *ExecutionState) bool {
+	switch b.Kind {
+	case BreakOnContract:
+		return state.ContractID != "" && strings.HasPrefix(state.ContractID, b.Value)
+	case BreakOnFunction:
+		return state.Function != "" &&
+			(state.Function == b.Value || strings.HasSuffix(state.Function, "::"+b.Value))
+	case BreakOnEvent:
+		return ClassifyEventType(state) == b.Value
+	case BreakOnError:
+		return state.Error != "" && strings.Contains(strings.ToLower(state.Error), strings.ToLower(b.Value))
+	}
+	return false
+}
+
+// BreakpointSet holds the breakpoints of a viewer session.
+type BreakpointSet struct {
+	breakpoints []*Breakpoint
+	nextID      int
+}
+
+func NewBreakpointSet() *BreakpointSet {
+	return &BreakpointSet{nextID: 1}
+}
+
+// Add assigns bp an ID and adds it to the set.
+func (s *BreakpointSet) Add(bp *Breakpoint) *Breakpoint {
+	bp.ID = s.nextID
+	s.nextID++
+	s.breakpoints = append(s.breakpoints, bp)
+	return bp
+}
+
+// Remove deletes the breakpoint with the given ID.
+func (s *BreakpointSet) Remove(id int) bool {
+	for i, bp := range s.breakpoints {
+		if bp.ID == id {
+			s.breakpoints = append(s.breakpoints[:i], s.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetEnabled enables or disables the breakpoint with the given ID.
+func (s *BreakpointSet) SetEnabled(id int, enabled bool) bool {
+	for _, bp := range s.breakpoints {
+		if bp.ID == id {
+			bp.Enabled = enabled
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BreakpointSet) Clear() {
+	s.breakpoints = nil
+}
+
+func (s *BreakpointSet) List() []*Breakpoint {
+	return s.breakpoints
+}
+
+func (s *BreakpointSet) Len() int {
+	return len(s.breakpoints)
+}
+
+// -------------------- Continue --------------------
+
+// breakScanner evaluates a breakpoint set over a trace, reconstructing state
+// only when a condition breakpoint needs it.
+type breakScanner struct {
+	trace *ExecutionTrace
+	set   *BreakpointSet
+	conds map[int]map[int]bool // breakpoint ID -> step -> condition value
+}
+
+func newBreakScanner(t *ExecutionTrace, set *BreakpointSet) *breakScanner {
+	return &breakScanner{trace: t, set: set, conds: make(map[int]map[int]bool)}
+}
+
+func (sc *breakScanner) condition(bp *Breakpoint, step int) bool {
+	if step < 0 {
+		return false
+	}
+	cache, ok := sc.conds[bp.ID]
+	if !ok {
+		cache = make(map[int]bool)
+		sc.conds[bp.ID] = cache
+	}
+	if v, ok := cache[step]; ok {
+		return v
+	}
+	state, err := sc.trace.ReconstructStateAt(step)
+	v := err == nil && bp.expr.Matches(state)
+	cache[step] = v
+	return v
+}
+
+// hit returns the first enabled breakpoint that matches step.
+func (sc *breakScanner) hit(step int) *Breakpoint {
+	state := &sc.trace.States[step]
+	for _, bp := range sc.set.breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		if bp.Kind == BreakOnCondition {
+			if sc.condition(bp, step) && !sc.condition(bp, step-1) {
+				return bp
+			}
+			continue
+		}
+		if bp.matchesStep(state) {
+			return bp
+		}
+	}
+	return nil
+}
+
+// Continue moves forward to the next step that hits an enabled breakpoint
+// and returns it with the breakpoint. With no further hit it moves to the
+// last step and returns a nil breakpoint.
+func (t *ExecutionTrace) Continue(set *BreakpointSet) (*ExecutionState, *Breakpoint, error) {
+	if len(t.States) == 0 {
+		return nil, nil, fmt.Errorf("trace has no steps")
+	}
+	sc := newBreakScanner(t, set)
+	for i := t.CurrentStep + 1; i < len(t.States); i++ {
+		if bp := sc.hit(i); bp != nil {
+			bp.Hits++
+			t.CurrentStep = i
+			return &t.States[i], bp, nil
+		}
+	}
+	t.CurrentStep = len(t.States) - 1
+	return &t.States[t.CurrentStep], nil, nil
+}
+
+// ReverseContinue moves backward to the previous step that hits an enabled
+// breakpoint. With no earlier hit it moves to the first step and returns a
+// nil breakpoint.
+func (t *ExecutionTrace) ReverseContinue(set *BreakpointSet) (*ExecutionState, *Breakpoint, error) {
+	if len(t.States) == 0 {
+		return nil, nil, fmt.Errorf("trace has no steps")
+	}
+	sc := newBreakScanner(t, set)
+	for i := t.CurrentStep - 1; i >= 0; i-- {
+		if bp := sc.hit(i); bp != nil {
+			bp.Hits++
+			t.CurrentStep = i
+			return &t.States[i], bp, nil
+		}
+	}
+	t.CurrentStep = 0
+	return &t.States[0], nil, nil
+}
+
+// -------------------- Watches --------------------
+
+// Watch is an expression re-evaluated and shown at every step.
+type Watch struct {
+	ID   int
+	Expr *Expr
+}
+
+// WatchList holds the watch expressions of a viewer session.
+type WatchList struct {
+	watches []*Watch
+	nextID  int
+}
+
+func NewWatchList() *WatchList {
+	return &WatchList{nextID: 1}
+}
+
+// Add parses source and appends it to the list.
+func (l *WatchList) Add(source string) (*Watch, error) {
+	expr, err := ParseExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watch{ID: l.nextID, Expr: expr}
+	l.nextID++
+	l.watches = append(l.watches, w)
+	return w, nil
+}
+
+func (l *WatchList) Remove(id int) bool {
+	for i, w := range l.watches {
+		if w.ID == id {
+			l.watches = append(l.watches[:i], l.watches[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *WatchList) List() []*Watch {
+	return l.watches
+}
+
+// WatchValue is the value of a watch at one step.
+type WatchValue struct {
+	Watch *Watch
+	Value interface{}
+}
+
+// Evaluate evaluates every watch against the reconstructed state at step.
+func (l *WatchList) Evaluate(t *ExecutionTrace, step int) ([]WatchValue, error) {
+	if len(l.watches) == 0 {
+		return nil, nil
+	}
+	state, err := t.ReconstructStateAt(step)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]WatchValue, len(l.watches))
+	for i, w := range l.watches {
+		values[i] = WatchValue{Watch: w, Value: w.Expr.Eval(state)}
+	}
+	return values, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import (
+	"testing"
+)
+
+// breakpointTrace builds a transfer whose balance goes negative at step 3
+// before trapping at step 5.
+func breakpointTrace() *ExecutionTrace {
+	trace := NewExecutionTrace("bp-tx", 2)
+	states := []ExecutionState{
+		{Operation: "contract_call", ContractID: "CTOKEN", Function: "transfer", HostState: map[string]interface{}{"balance": float64(10)}},
+		{Operation: "host_fn", Function: "get_balance"},
+		{Operation: "contract_call", ContractID: "CORACLE", Function: "oracle::price"},
+		{Operation: "host_fn", HostState: map[string]interface{}{"balance": float64(-5)}},
+		{Operation: "host_fn", Function: "require_auth"},
+		{Operation: "trap", Error: "HostError: insufficient balance"},
+	}
+	for _, s := range states {
+		trace.AddState(s)
+	}
+	return trace
+}
+
+func TestParseBreakpoint(t *testing.T) {
+	tests := []struct {
+		args  []string
+		kind  BreakpointKind
+		value string
+	}{
+		{[]string{"contract", "CTOKEN"}, BreakOnContract, "CTOKEN"},
+		{[]string{"fn", "transfer"}, BreakOnFunction, "transfer"},
+		{[]string{"event", "host_fn"}, BreakOnEvent, EventTypeHostFunction},
+		{[]string{"error", "insufficient", "balance"}, BreakOnError, "insufficient balance"},
+		{[]string{"if", "balance", "<", "0"}, BreakOnCondition, "balance < 0"},
+		{[]string{"balance<0"}, BreakOnCondition, "balance<0"},
+	}
+	for _, tt := range tests {
+		bp, err := ParseBreakpoint(tt.args)
+		if err != nil {
+			t.Fatalf("ParseBreakpoint(%v) failed: %v", tt.args, err)
+		}
+		if bp.Kind != tt.kind || bp.Value != tt.value {
+			t.Errorf("ParseBreakpoint(%v) = %s %q, expected %s %q", tt.args, bp.Kind, bp.Value, tt.kind, tt.value)
+		}
+	}
+
+	for _, args := range [][]string{nil, {"contract"}, {"event", "bogus"}, {"if", "balance", "<"}} {
+		if _, err := ParseBreakpoint(args); err == nil {
+			t.Errorf("ParseBreakpoint(%v) should fail", args)
+		}
+	}
+}
+
+func mustBreakpoint(t *testing.T, set *BreakpointSet, args ...string) *Breakpoint {
+	t.Helper()
+	bp, err := ParseBreakpoint(args)
+	if err != nil {
+		t.Fatalf("ParseBreakpoint(%v) failed: %v", args, err)
+	}
+	return set.Add(bp)
+}
+
+func TestContinue_StopsAtBreakpoints(t *testing.T) {
+	trace := breakpointTrace()
+	set := NewBreakpointSet()
+	price := mustBreakpoint(t, set, "function", "price")
+	trap := mustBreakpoint(t, set, "event", "trap")
+
+	state, bp, err := trace.Continue(set)
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if bp != price || state.Step != 2 {
+		t.Fatalf("Expected function breakpoint at step 2, got %v at step %d", bp, state.Step)
+	}
+
+	state, bp, _ = trace.Continue(set)
+	if bp != trap || state.Step != 5 {
+		t.Fatalf("Expected trap breakpoint at step 5, got %v at step %d", bp, state.Step)
+	}
+
+	state, bp, _ = trace.Continue(set)
+	if bp != nil || state.Step != 5 {
+		t.Errorf("Expected no hit at end of trace, got %v at step %d", bp, state.Step)
+	}
+
+	state, bp, _ = trace.ReverseContinue(set)
+	if bp != price || state.Step != 2 {
+		t.Errorf("Expected reverse-continue to step 2, got %v at step %d", bp, state.Step)
+	}
+	if price.Hits != 2 {
+		t.Errorf("Expected 2 hits, got %d", price.Hits)
+	}
+
+	state, bp, _ = trace.ReverseContinue(set)
+	if bp != nil || state.Step != 0 {
+		t.Errorf("Expected no hit at start of trace, got %v at step %d", bp, state.Step)
+	}
+}
+
+func TestContinue_ConditionUsesReconstructedState(t *testing.T) {
+	trace := breakpointTrace()
+	set := NewBreakpointSet()
+	cond := mustBreakpoint(t, set, "if", "balance", "<", "0")
+
+	state, bp, err := trace.Continue(set)
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if bp != cond || state.Step != 3 {
+		t.Fatalf("Expected condition hit at step 3, got %v at step %d", bp, state.Step)
+	}
+
+	// The condition stays true afterwards but only triggers where it changes.
+	state, bp, _ = trace.Continue(set)
+	if bp != nil || state.Step != 5 {
+		t.Errorf("Expected no further hit, got %v at step %d", bp, state.Step)
+	}
+
+	state, bp, _ = trace.ReverseContinue(set)
+	if bp != cond || state.Step != 3 {
+		t.Errorf("Expected reverse-continue to step 3, got %v at step %d", bp, state.Step)
+	}
+}
+
+func TestContinue_DisabledBreakpoint(t *testing.T) {
+	trace := breakpointTrace()
+	set := NewBreakpointSet()
+	bp := mustBreakpoint(t, set, "contract", "CORACLE")
+	set.SetEnabled(bp.ID, false)
+
+	state, hit, _ := trace.Continue(set)
+	if hit != nil || state.Step != 5 {
+		t.Errorf("Disabled breakpoint should not hit, got %v at step %d", hit, state.Step)
+	}
+
+	if !set.Remove(bp.ID) || set.Len() != 0 {
+		t.Error("Expected breakpoint to be removed")
+	}
+}
+
+func TestWatchList_Evaluate(t *testing.T) {
+	trace := breakpointTrace()
+	watches := NewWatchList()
+	if _, err := watches.Add("balance"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := watches.Add("balance < 0"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	values, err := watches.Evaluate(trace, 2)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if values[0].Value != float64(10) || values[1].Value != false {
+		t.Errorf("Unexpected values at step 2: %v, %v", values[0].Value, values[1].Value)
+	}
+
+	values, _ = watches.Evaluate(trace, 4)
+	if values[0].Value != float64(-5) || values[1].Value != true {
+		t.Errorf("Unexpected values at step 4: %v, %v", values[0].Value, values[1].Value)
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Expr is a parsed watch or breakpoint expression over an ExecutionState.
+//
+// The grammar is deliberately small:
+//
+//	expr    = and { "||" and }
+//	and     = cmp { "&&" cmp }
+//	cmp     = operand [ op operand ]
+//	op      = "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains"
+//	operand = number | "quoted string" | true | false | null | path
+//
+// A path names a HostState key, e.g. balance or balance:alice. Dots
+// descend into nested maps (balances.alice). The step fields step,
+// contract_id, function, operation, event_type and error are available when
+// no HostState key of that name exists. Numbers compare exactly, so i128
+// values stored as strings work.
+type Expr struct {
+	source string
+	root   exprNode
+}
+
+type exprNode interface {
+	eval(state *ExecutionState) interface{}
+}
+
+// ParseExpr parses an expression.
+func ParseExpr(source string) (*Expr, error) {
+	p := &exprParser{src: source}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression", p.tokens[p.pos].text)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against state. Unknown paths evaluate to
+// nil, so a comparison with a missing key is simply false.
+func (e *Expr) Eval(state *ExecutionState) interface{} {
+	if state == nil {
+		return nil
+	}
+	return e.root.eval(state)
+}
+
+// Matches reports whether the expression is truthy for state.
+func (e *Expr) Matches(state *ExecutionState) bool {
+	return truthy(e.Eval(state))
+}
+
+// -------------------- Nodes --------------------
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(*ExecutionState) interface{} { return n.value }
+
+type pathNode struct{ path string }
+
+func (n pathNode) eval(state *ExecutionState) interface{} {
+	if v, ok := state.HostState[n.path]; ok {
+		return v
+	}
+
+	// Descend into nested maps one dotted segment at a time.
+	if parts := strings.Split(n.path, "."); len(parts) > 1 {
+		if v, ok := lookupPath(state.HostState, parts); ok {
+			return v
+		}
+	}
+
+	switch n.path {
+	case "step":
+		return state.Step
+	case "contract_id", "contract":
+		return state.ContractID
+	case "function":
+		return state.Function
+	case "operation":
+		return state.Operation
+	case "event_type":
+		return ClassifyEventType(state)
+	case "error":
+		return state.Error
+	}
+	return nil
+}
+
+func lookupPath(m map[string]interface{}, parts []string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, part := range parts {
+		next, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = next[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+type logicalNode struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n logicalNode) eval(state *ExecutionState) interface{} {
+	l := truthy(n.left.eval(state))
+	if n.and {
+		return l && truthy(n.right.eval(state))
+	}
+	return l || truthy(n.right.eval(state))
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n compareNode) eval(state *ExecutionState) interface{} {
+	l := n.left.eval(state)
+	r := n.right.eval(state)
+
+	if n.op == "contains" {
+		return l != nil && r != nil && strings.Contains(exprString(l), exprString(r))
+	}
+
+	if lr, ok := toRat(l); ok {
+		if rr, ok := toRat(r); ok {
+			c := lr.Cmp(rr)
+			switch n.op {
+			case "==":
+				return c == 0
+			case "!=":
+				return c != 0
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			case ">=":
+				return c >= 0
+			}
+		}
+	}
+
+	switch n.op {
+	case "==":
+		return exprEqual(l, r)
+	case "!=":
+		return !exprEqual(l, r)
+	}
+	// Ordering is only defined for numbers.
+	return false
+}
+
+func exprEqual(l, r interface{}) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	return exprString(l) == exprString(r)
+}
+
+func exprString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// toRat converts numeric values, including numeric strings, to an exact
+// rational.
+func toRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case uint32:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case uint64:
+		return new(big.Rat).SetUint64(n), true
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(n) == nil {
+			return nil, false
+		}
+		return r, true
+	case json.Number:
+		return new(big.Rat).SetString(n.String())
+	case string:
+		return new(big.Rat).SetString(strings.TrimSpace(n))
+	}
+	return nil, false
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	case string:
+		return b != ""
+	}
+	if r, ok := toRat(v); ok {
+		return r.Sign() != 0
+	}
+	return true
+}
+
+// -------------------- Parser --------------------
+
+type exprToken struct {
+	kind string // "op", "str", "word"
+	text string
+}
+
+type exprParser struct {
+	src    string
+	tokens []exprToken
+	pos    int
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">"}
+
+func (p *exprParser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return fmt.Errorf("unterminated string in expression")
+			}
+			p.tokens = append(p.tokens, exprToken{kind: "str", text: s[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			if op := matchOperator(s[i:]); op != "" {
+				p.tokens = append(p.tokens, exprToken{kind: "op", text: op})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' && matchOperator(s[i:]) == "" {
+				i++
+			}
+			word := s[start:i]
+			if word == "contains" {
+				p.tokens = append(p.tokens, exprToken{kind: "op", text: word})
+			} else {
+				p.tokens = append(p.tokens, exprToken{kind: "word", text: word})
+			}
+		}
+	}
+	return nil
+}
+
+func matchOperator(s string) string {
+	for _, op := range exprOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *exprParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peekOp("==", "!=", "<=", ">=", "<", ">", "contains"); op != "" {
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseOperand() (exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expression ends unexpectedly")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case "str":
+		return literalNode{value: tok.text}, nil
+	case "op":
+		return nil, fmt.Errorf("unexpected operator %q", tok.text)
+	}
+
+	switch tok.text {
+	case "true":
+		return literalNode{value: true}, nil
+	case "false":
+		return literalNode{value: false}, nil
+	case "null", "nil":
+		return literalNode{value: nil}, nil
+	}
+	if _, ok := new(big.Rat).SetString(tok.text); ok {
+		return literalNode{value: json.Number(tok.text)}, nil
+	}
+	return pathNode{path: tok.text}, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseExpr_Evaluate(t *testing.T) {
+	state := &ExecutionState{
+		Step:       4,
+		ContractID: "CABC",
+		Function:   "transfer",
+		Error:      "HostError: insufficient balance",
+		HostState: map[string]interface{}{
+			"balance":       float64(-5),
+			"supply":        "170141183460469231731687303715884105727",
+			"balance:alice": json.Number("10"),
+			"admin":         "GADMIN",
+			"allowances":    map[string]interface{}{"bob": float64(3)},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"balance < 0", true},
+		{"balance >= 0", false},
+		{"balance:alice == 10", true},
+		{"balance:alice != 10", false},
+		{"supply > 170141183460469231731687303715884105726", true},
+		{"admin == 'GADMIN'", true},
+		{"admin == \"GOTHER\"", false},
+		{"allowances.bob <= 3", true},
+		{"function == transfer", false}, // bare word is a path, not a string
+		{"function == 'transfer'", true},
+		{"error contains 'insufficient'", true},
+		{"step == 4 && contract_id == 'CABC'", true},
+		{"missing > 0 || balance < 0", true},
+		{"missing > 0", false},
+		{"missing == null", true},
+		{"balance", true},
+	}
+	for _, tt := range tests {
+		expr, err := ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q) failed: %v", tt.expr, err)
+		}
+		if got := expr.Matches(state); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestParseExpr_Value(t *testing.T) {
+	expr, err := ParseExpr("balance:alice")
+	if err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+	state := &ExecutionState{HostState: map[string]interface{}{"balance:alice": float64(7)}}
+	if got := expr.Eval(state); got != float64(7) {
+		t.Errorf("Expected 7, got %v", got)
+	}
+	if got := expr.Eval(&ExecutionState{}); got != nil {
+		t.Errorf("Expected nil for a missing key, got %v", got)
+	}
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	for _, src := range []string{"", "balance <", "< 0", "admin == 'open", "a b"} {
+		if _, err := ParseExpr(src); err == nil {
+			t.Errorf("ParseExpr(%q) should fail", src)
+		}
+	}
+}
@@ -25,6 +25,8 @@ type InteractiveViewer struct {
 	hideStdLib  bool
 	trap        *TrapInfo
 	dwarfParser *dwarf.Parser
+	breakpoints *BreakpointSet
+	watches     *WatchList
 }
 
 // NewInteractiveViewer creates a new interactive trace viewer
@@ -34,6 +36,8 @@ func NewInteractiveViewer(trace *ExecutionTrace) *InteractiveViewer {
 		reader:      bufio.NewReader(os.Stdin),
 		eventFilter: "",
 		filterCycle: []string{"", EventTypeTrap, EventTypeContractCall, EventTypeHostFunction, EventTypeAuth},
+		breakpoints: NewBreakpointSet(),
+		watches:     NewWatchList(),
 	}
 
 	// Detect any traps in the trace
@@ -50,6 +54,8 @@ func NewInteractiveViewerWithWASM(trace *ExecutionTrace, wasmData []byte) *Inter
 		reader:      bufio.NewReader(os.Stdin),
 		eventFilter: "",
 		filterCycle: []string{"", EventTypeTrap, EventTypeContractCall, EventTypeHostFunction, EventTypeAuth},
+		breakpoints: NewBreakpointSet(),
+		watches:     NewWatchList(),
 	}
 
 	// Initialize DWARF parser if WASM data is provided
@@ -186,6 +192,44 @@ func (v *InteractiveViewer) handleCommand(command string) bool {
 		} else {
 			v.listSteps("10")
 		}
+	case "b", "break":
+		if len(parts) > 1 {
+			v.addBreakpoint(parts[1:])
+		} else {
+			v.listBreakpoints()
+		}
+	case "bl", "breakpoints":
+		v.listBreakpoints()
+	case "d", "delete":
+		if len(parts) > 1 {
+			v.deleteBreakpoint(parts[1])
+		} else {
+			fmt.Println("Usage: delete <id|all>")
+		}
+	case "enable", "disable":
+		if len(parts) > 1 {
+			v.toggleBreakpoint(parts[1], cmd == "enable")
+		} else {
+			fmt.Printf("Usage: %s <id>\n", cmd)
+		}
+	case "cont", "continue":
+		v.continueForward()
+	case "rc", "reverse-continue":
+		v.continueBackward()
+	case "w", "watch":
+		if len(parts) > 1 {
+			v.addWatch(strings.Join(parts[1:], " "))
+		} else {
+			v.displayWatches()
+		}
+	case "watches":
+		v.displayWatches()
+	case "unwatch":
+		if len(parts) > 1 {
+			v.removeWatch(parts[1])
+		} else {
+			fmt.Println("Usage: unwatch <id>")
+		}
 	case "?", "h", "help":
 		v.showHelp()
 	case "q", "quit", "exit":
@@ -289,6 +333,136 @@ func (v *InteractiveViewer) jumpToStep(stepStr string) {
 	v.displayCurrentState()
 }
 
+// addBreakpoint parses and registers a breakpoint
+func (v *InteractiveViewer) addBreakpoint(args []string) {
+	bp, err := ParseBreakpoint(args)
+	if err != nil {
+		fmt.Printf("%s %s\n", visualizer.Error(), err)
+		return
+	}
+	v.breakpoints.Add(bp)
+	fmt.Printf("%s Breakpoint %d: %s\n", visualizer.Symbol("pin"), bp.ID, bp)
+}
+
+// listBreakpoints shows all breakpoints with their hit counts
+func (v *InteractiveViewer) listBreakpoints() {
+	if v.breakpoints.Len() == 0 {
+		fmt.Println("No breakpoints set. Use 'break <spec>' to add one.")
+		return
+	}
+	for _, bp := range v.breakpoints.List() {
+		status := "enabled"
+		if !bp.Enabled {
+			status = "disabled"
+		}
+		fmt.Printf("  %d: %-40s %s, %d hits\n", bp.ID, bp, status, bp.Hits)
+	}
+}
+
+// deleteBreakpoint removes one breakpoint, or all of them
+func (v *InteractiveViewer) deleteBreakpoint(idStr string) {
+	if idStr == "all" {
+		v.breakpoints.Clear()
+		fmt.Println("Deleted all breakpoints")
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || !v.breakpoints.Remove(id) {
+		fmt.Printf("%s No breakpoint %s\n", visualizer.Error(), idStr)
+		return
+	}
+	fmt.Printf("Deleted breakpoint %d\n", id)
+}
+
+// toggleBreakpoint enables or disables a breakpoint
+func (v *InteractiveViewer) toggleBreakpoint(idStr string, enabled bool) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil || !v.breakpoints.SetEnabled(id, enabled) {
+		fmt.Printf("%s No breakpoint %s\n", visualizer.Error(), idStr)
+		return
+	}
+	if enabled {
+		fmt.Printf("Enabled breakpoint %d\n", id)
+	} else {
+		fmt.Printf("Disabled breakpoint %d\n", id)
+	}
+}
+
+// continueForward runs forward to the next breakpoint hit
+func (v *InteractiveViewer) continueForward() {
+	state, bp, err := v.trace.Continue(v.breakpoints)
+	if err != nil {
+		fmt.Printf("%s %s\n", visualizer.Error(), err)
+		return
+	}
+	v.reportStop(state, bp, "end of trace")
+}
+
+// continueBackward runs backward to the previous breakpoint hit
+func (v *InteractiveViewer) continueBackward() {
+	state, bp, err := v.trace.ReverseContinue(v.breakpoints)
+	if err != nil {
+		fmt.Printf("%s %s\n", visualizer.Error(), err)
+		return
+	}
+	v.reportStop(state, bp, "start of trace")
+}
+
+func (v *InteractiveViewer) reportStop(state *ExecutionState, bp *Breakpoint, boundary string) {
+	if bp != nil {
+		fmt.Printf("%s Breakpoint %d (%s) hit at step %d\n", visualizer.Symbol("target"), bp.ID, bp, state.Step)
+	} else {
+		fmt.Printf("%s No breakpoint hit, stopped at %s (step %d)\n", visualizer.Symbol("target"), boundary, state.Step)
+	}
+	v.displayCurrentState()
+}
+
+// addWatch registers a watch expression and shows its current value
+func (v *InteractiveViewer) addWatch(source string) {
+	w, err := v.watches.Add(source)
+	if err != nil {
+		fmt.Printf("%s %s\n", visualizer.Error(), err)
+		return
+	}
+	fmt.Printf("%s Watch %d: %s\n", visualizer.Symbol("eye"), w.ID, w.Expr)
+	v.printWatchValues(getTermWidth())
+}
+
+// removeWatch deletes a watch expression
+func (v *InteractiveViewer) removeWatch(idStr string) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil || !v.watches.Remove(id) {
+		fmt.Printf("%s No watch %s\n", visualizer.Error(), idStr)
+		return
+	}
+	fmt.Printf("Removed watch %d\n", id)
+}
+
+// displayWatches shows every watch expression evaluated at the current step
+func (v *InteractiveViewer) displayWatches() {
+	if len(v.watches.List()) == 0 {
+		fmt.Println("No watches set. Use 'watch <expr>' to add one.")
+		return
+	}
+	v.printWatchValues(getTermWidth())
+}
+
+func (v *InteractiveViewer) printWatchValues(termW int) {
+	values, err := v.watches.Evaluate(v.trace, v.trace.CurrentStep)
+	if err != nil {
+		fmt.Printf("%s Failed to evaluate watches: %s\n", visualizer.Error(), err)
+		return
+	}
+	for _, wv := range values {
+		value := "<undefined>"
+		if wv.Value != nil {
+			value = fmt.Sprintf("%v", wv.Value)
+		}
+		label := fmt.Sprintf("%d: %s", wv.Watch.ID, wv.Watch.Expr)
+		fmt.Printf("  %s\n", wrapField(label, value, termW-2))
+	}
+}
+
 // displayCurrentState shows the current execution state, reflowing long
 // contract IDs and XDR strings to fit the current terminal width.
 func (v *InteractiveViewer) displayCurrentState() {
@@ -328,7 +502,7 @@ func (v *InteractiveViewer) displayCurrentState() {
 		fmt.Println(wrapField("Function", state.Function, termW))
 	}
 	if len(state.Arguments) > 0 {
-		fmt.Println(wrapField("Arguments", fmt.Sprintf("%v", state.Arguments), termW))
+		fmt.Println(wrapField("Arguments", formatArguments(state.Arguments), termW))
 	}
 	if state.ReturnValue != nil {
 		fmt.Println(wrapField("Return", fmt.Sprintf("%v", state.ReturnValue), termW))
@@ -359,6 +533,11 @@ func (v *InteractiveViewer) displayCurrentState() {
 	if len(state.Memory) > 0 {
 		fmt.Printf("Memory: %d entries\n", len(state.Memory))
 	}
+
+	if len(v.watches.List()) > 0 {
+		fmt.Println("\nWatches:")
+		v.printWatchValues(termW)
+	}
 }
 
 // reconstructCurrentState reconstructs and displays the current state
@@ -584,6 +763,16 @@ func (v *InteractiveViewer) showHelp() {
 	fmt.Println("  c, collapse             - Collapse current node")
 	fmt.Println("  E                       - Toggle expand/collapse all")
 	fmt.Println()
+	fmt.Println("Breakpoints:")
+	fmt.Println("  b, break <spec>         - Break on contract <id>, function <name>, event <type>, error <text> or if <expr>")
+	fmt.Println("  bl, breakpoints         - List breakpoints")
+	fmt.Println("  d, delete <id|all>      - Delete breakpoints")
+	fmt.Println("  enable/disable <id>     - Enable or disable a breakpoint")
+	fmt.Println("  cont, continue          - Run forward to the next breakpoint")
+	fmt.Println("  rc, reverse-continue    - Run backward to the previous breakpoint")
+	fmt.Println("  w, watch <expr>         - Watch an expression (e.g. balance, balance < 0)")
+	fmt.Println("  unwatch <id>            - Remove a watch")
+	fmt.Println()
 	fmt.Println("Filter:")
 	fmt.Println("  f, filter               - Cycle filter by event type (trap, contract_call, host_function, auth)")
 	fmt.Println()
@@ -653,6 +842,16 @@ func (v *InteractiveViewer) handleYank(args []string) {
 	fmt.Printf("%s Copied raw XDR to clipboard\n", visualizer.Symbol("sparkles"))
 }
 
+// formatArguments joins arguments, which the replay renders as
+// "name: value" pairs when the contract's spec is known.
+func formatArguments(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprint(arg)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Helper functions
 func max(a, b int) int {
 	if a > b {
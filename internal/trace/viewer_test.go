@@ -63,3 +63,30 @@ func TestInteractiveViewer_HandleCommand_HelpAlias(t *testing.T) {
 		t.Errorf("help alias '?' did not display help overlay: %s", out)
 	}
 }
+
+func TestInteractiveViewer_BreakpointsAndWatches(t *testing.T) {
+	viewer := NewInteractiveViewer(breakpointTrace())
+
+	out := captureOutput(func() {
+		viewer.handleCommand("break if balance < 0")
+		viewer.handleCommand("watch balance")
+		viewer.handleCommand("continue")
+	})
+	if !strings.Contains(out, "Breakpoint 1 (condition balance < 0) hit at step 3") {
+		t.Errorf("continue did not stop at the condition: %s", out)
+	}
+	if !strings.Contains(out, "1: balance: -5") {
+		t.Errorf("watch not re-evaluated after continue: %s", out)
+	}
+
+	out = captureOutput(func() {
+		viewer.handleCommand("delete 1")
+		viewer.handleCommand("reverse-continue")
+	})
+	if !strings.Contains(out, "stopped at start of trace (step 0)") {
+		t.Errorf("reverse-continue without breakpoints should stop at step 0: %s", out)
+	}
+	if !strings.Contains(out, "1: balance: 10") {
+		t.Errorf("watch not re-evaluated after reverse-continue: %s", out)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// changeMarks are the diff marks that prefix each change in text output.
+var changeMarks = map[Change]string{Added: "+", Removed: "-", Changed: "~"}
+
+// Text renders the report for reading, one section per kind of difference,
+// leaving out the sections with none.
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Size: %d -> %d bytes (%+d)\n", r.OldSize, r.NewSize, r.NewSize-r.OldSize)
+	if r.Empty() {
+		b.WriteString("\nNo differences.\n")
+		return b.String()
+	}
+
+	if len(r.Spec) > 0 {
+		fmt.Fprintf(&b, "\nSpec (%d):\n", len(r.Spec))
+		for _, c := range r.Spec {
+			switch c.Change {
+			case Added:
+				fmt.Fprintf(&b, "  + %s %s: %s\n", c.Kind, c.Name, c.New)
+			case Removed:
+				fmt.Fprintf(&b, "  - %s %s: %s\n", c.Kind, c.Name, c.Old)
+			default:
+				fmt.Fprintf(&b, "  ~ %s %s\n      was: %s\n      now: %s\n", c.Kind, c.Name, c.Old, c.New)
+			}
+		}
+	}
+
+	writeSet(&b, "Host functions", r.HostFunctions)
+
+	if len(r.Functions) > 0 {
+		fmt.Fprintf(&b, "\nFunctions (%d differ, %d unchanged", len(r.Functions), r.Unchanged)
+		if r.OldUnnamed > 0 || r.NewUnnamed > 0 {
+			fmt.Fprintf(&b, ", %d -> %d unnamed", r.OldUnnamed, r.NewUnnamed)
+		}
+		b.WriteString("):\n")
+		width := 0
+		for _, f := range r.Functions {
+			width = max(width, len(f.Name))
+		}
+		for _, f := range r.Functions {
+			fmt.Fprintf(&b, "  %s %-*s  %6d -> %6d (%+d)\n", changeMarks[f.Change], width, f.Name, f.OldSize, f.NewSize, f.Delta())
+		}
+	}
+
+	writeSet(&b, "Storage keys", r.StorageKeys)
+	return b.String()
+}
+
+// JSON renders the report as indented JSON.
+func (r *Report) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func writeSet(b *strings.Builder, title string, d SetDiff) {
+	if d.Empty() {
+		return
+	}
+	fmt.Fprintf(b, "\n%s (%d):\n", title, len(d.Added)+len(d.Removed))
+	for _, k := range d.Added {
+		fmt.Fprintf(b, "  + %s\n", k)
+	}
+	for _, k := range d.Removed {
+		fmt.Fprintf(b, "  - %s\n", k)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/stellar/go-stellar-sdk/xdr"
+)
+
+// SpecKind is the kind of contract spec entry a SpecChange is about.
+type SpecKind string
+
+const (
+	SpecFunction SpecKind = "function"
+	SpecType     SpecKind = "type"
+	SpecError    SpecKind = "error"
+	SpecEvent    SpecKind = "event"
+)
+
+// specKindOrder is the order spec changes are listed in.
+var specKindOrder = []SpecKind{SpecFunction, SpecType, SpecError, SpecEvent}
+
+// SpecChange is a contract spec entry added, removed or changed. Old and
+// New describe the entry: the signature of a function, the definition of a
+// type, the code of an error and the parameters of an event.
+type SpecChange struct {
+	Kind   SpecKind `json:"kind"`
+	Name   string   `json:"name"`
+	Change Change   `json:"change"`
+	Old    string   `json:"old,omitempty"`
+	New    string   `json:"new,omitempty"`
+}
+
+// diffSpecs compares two specs entry by entry, matching entries by name and
+// error codes by "Enum::Case".
+func diffSpecs(before, after *abi.ContractSpec) []SpecChange {
+	var changes []SpecChange
+	oldEntries, newEntries := specEntries(before), specEntries(after)
+	for _, kind := range specKindOrder {
+		var kindChanges []SpecChange
+		o, n := oldEntries[kind], newEntries[kind]
+		for name, desc := range o {
+			switch newDesc, ok := n[name]; {
+			case !ok:
+				kindChanges = append(kindChanges, SpecChange{Kind: kind, Name: name, Change: Removed, Old: desc})
+			case newDesc != desc:
+				kindChanges = append(kindChanges, SpecChange{Kind: kind, Name: name, Change: Changed, Old: desc, New: newDesc})
+			}
+		}
+		for name, desc := range n {
+			if _, ok := o[name]; !ok {
+				kindChanges = append(kindChanges, SpecChange{Kind: kind, Name: name, Change: Added, New: desc})
+			}
+		}
+		sort.Slice(kindChanges, func(i, j int) bool { return kindChanges[i].Name < kindChanges[j].Name })
+		changes = append(changes, kindChanges...)
+	}
+	return changes
+}
+
+// specEntries describes each entry of spec, by kind and name.
+func specEntries(spec *abi.ContractSpec) map[SpecKind]map[string]string {
+	entries := make(map[SpecKind]map[string]string)
+	for _, kind := range specKindOrder {
+		entries[kind] = make(map[string]string)
+	}
+
+	for _, fn := range spec.Functions {
+		entries[SpecFunction][string(fn.Name)] = abi.FormatFunction(fn)
+	}
+	for _, s := range spec.Structs {
+		fields := make([]string, len(s.Fields))
+		for i, f := range s.Fields {
+			fields[i] = fmt.Sprintf("%s: %s", f.Name, abi.FormatTypeDef(f.Type))
+		}
+		entries[SpecType][string(s.Name)] = fmt.Sprintf("struct %s { %s }", s.Name, strings.Join(fields, ", "))
+	}
+	for _, e := range spec.Enums {
+		cases := make([]string, len(e.Cases))
+		for i, c := range e.Cases {
+			cases[i] = fmt.Sprintf("%s = %d", c.Name, c.Value)
+		}
+		entries[SpecType][string(e.Name)] = fmt.Sprintf("enum %s { %s }", e.Name, strings.Join(cases, ", "))
+	}
+	for _, u := range spec.Unions {
+		cases := make([]string, 0, len(u.Cases))
+		for _, c := range u.Cases {
+			switch c.Kind {
+			case xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseVoidV0:
+				cases = append(cases, c.VoidCase.Name)
+			case xdr.ScSpecUdtUnionCaseV0KindScSpecUdtUnionCaseTupleV0:
+				types := make([]string, len(c.TupleCase.Type))
+				for i, t := range c.TupleCase.Type {
+					types[i] = abi.FormatTypeDef(t)
+				}
+				cases = append(cases, fmt.Sprintf("%s(%s)", c.TupleCase.Name, strings.Join(types, ", ")))
+			}
+		}
+		entries[SpecType][string(u.Name)] = fmt.Sprintf("union %s { %s }", u.Name, strings.Join(cases, ", "))
+	}
+	for _, e := range spec.ErrorEnums {
+		for _, c := range e.Cases {
+			entries[SpecError][string(e.Name)+"::"+c.Name] = fmt.Sprintf("%d", c.Value)
+		}
+	}
+	for _, ev := range spec.Events {
+		params := make([]string, len(ev.Params))
+		for i, p := range ev.Params {
+			loc := "data"
+			if p.Location == xdr.ScSpecEventParamLocationV0ScSpecEventParamLocationTopicList {
+				loc = "topic"
+			}
+			params[i] = fmt.Sprintf("%s %s: %s", loc, p.Name, abi.FormatTypeDef(p.Type))
+		}
+		entries[SpecEvent][string(ev.Name)] = fmt.Sprintf("%s(%s)", ev.Name, strings.Join(params, ", "))
+	}
+	return entries
+}
@@ -0,0 +1,319 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wasmdiff compares two builds of a Soroban contract for upgrade
+// review: their contract specs, the host functions they import, the bodies
+// of their functions and the storage keys they use.
+package wasmdiff
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/dotandev/hintents/internal/abi"
+	"github.com/dotandev/hintents/internal/demangle"
+	"github.com/dotandev/hintents/internal/wasmopt"
+	"github.com/dotandev/hintents/internal/wat"
+)
+
+// Change is how an item differs between the old and new build.
+type Change string
+
+const (
+	Added   Change = "added"
+	Removed Change = "removed"
+	Changed Change = "changed"
+)
+
+// SetDiff lists the members added to and removed from a set.
+type SetDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether the set is unchanged.
+func (d SetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// FunctionChange is a function whose body differs, matched by name.
+type FunctionChange struct {
+	Name   string `json:"name"`
+	Change Change `json:"change"`
+	// OldSize and NewSize are the sizes in bytes of the body in each
+	// build, 0 when the function is absent.
+	OldSize int `json:"old_size"`
+	NewSize int `json:"new_size"`
+}
+
+// Delta is the change in size of the body.
+func (f FunctionChange) Delta() int {
+	return f.NewSize - f.OldSize
+}
+
+// Report is the difference between two builds of a contract.
+type Report struct {
+	OldSize int `json:"old_size"`
+	NewSize int `json:"new_size"`
+	// Spec lists the changes to the contract spec.
+	Spec []SpecChange `json:"spec,omitempty"`
+	// HostFunctions are the Soroban host functions imported.
+	HostFunctions SetDiff `json:"host_functions"`
+	// Functions lists the functions whose body was added, removed or
+	// changed.
+	Functions []FunctionChange `json:"functions,omitempty"`
+	// Unchanged counts the functions whose body is the same in both.
+	Unchanged int `json:"unchanged_functions"`
+	// Unnamed counts, for each build, the functions that have neither an
+	// export nor a name-section name, and so cannot be matched.
+	OldUnnamed int `json:"old_unnamed_functions"`
+	NewUnnamed int `json:"new_unnamed_functions"`
+	// StorageKeys are the symbols used by the functions that call the
+	// storage host functions.
+	StorageKeys SetDiff `json:"storage_keys"`
+}
+
+// Empty reports whether the builds have no difference the report covers.
+func (r *Report) Empty() bool {
+	return len(r.Spec) == 0 && r.HostFunctions.Empty() && len(r.Functions) == 0 && r.StorageKeys.Empty()
+}
+
+// storageFunctions are the host functions that take a storage key.
+var storageFunctions = map[string]bool{
+	"put_contract_data":        true,
+	"has_contract_data":        true,
+	"get_contract_data":        true,
+	"del_contract_data":        true,
+	"extend_contract_data_ttl": true,
+}
+
+// Diff compares the old and new builds of a contract.
+//
+// Functions are matched by export name, then by demangled name-section
+// name, and compared on their bodies with function and type indices
+// resolved, so that a body only differs when its code or what it calls
+// does. Storage keys are recognized as the small symbols, such as the
+// variant names of a DataKey enum, built by a function that calls a storage
+// host function; keys built elsewhere and passed in are not seen.
+func Diff(oldWasm, newWasm []byte) (*Report, error) {
+	oldBuild, err := load(oldWasm)
+	if err != nil {
+		return nil, fmt.Errorf("old WASM: %w", err)
+	}
+	newBuild, err := load(newWasm)
+	if err != nil {
+		return nil, fmt.Errorf("new WASM: %w", err)
+	}
+
+	r := &Report{
+		OldSize:       len(oldWasm),
+		NewSize:       len(newWasm),
+		Spec:          diffSpecs(oldBuild.spec, newBuild.spec),
+		HostFunctions: diffSets(oldBuild.hostFunctions, newBuild.hostFunctions),
+		OldUnnamed:    oldBuild.unnamed,
+		NewUnnamed:    newBuild.unnamed,
+		StorageKeys:   diffSets(oldBuild.storageKeys, newBuild.storageKeys),
+	}
+	for name, o := range oldBuild.functions {
+		n, ok := newBuild.functions[name]
+		switch {
+		case !ok:
+			r.Functions = append(r.Functions, FunctionChange{Name: name, Change: Removed, OldSize: o.size})
+		case o.hash != n.hash:
+			r.Functions = append(r.Functions, FunctionChange{Name: name, Change: Changed, OldSize: o.size, NewSize: n.size})
+		default:
+			r.Unchanged++
+		}
+	}
+	for name, n := range newBuild.functions {
+		if _, ok := oldBuild.functions[name]; !ok {
+			r.Functions = append(r.Functions, FunctionChange{Name: name, Change: Added, NewSize: n.size})
+		}
+	}
+	sort.Slice(r.Functions, func(i, j int) bool { return r.Functions[i].Name < r.Functions[j].Name })
+	return r, nil
+}
+
+// function is a defined function of a build.
+type function struct {
+	hash [sha256.Size]byte
+	size int
+}
+
+// build is what Diff compares of one build.
+type build struct {
+	spec          *abi.ContractSpec
+	hostFunctions map[string]bool
+	functions     map[string]function
+	unnamed       int
+	storageKeys   map[string]bool
+}
+
+func load(wasm []byte) (*build, error) {
+	m, err := wasmopt.Parse(wasm)
+	if err != nil {
+		return nil, err
+	}
+	b := &build{
+		spec:          &abi.ContractSpec{},
+		hostFunctions: make(map[string]bool),
+		functions:     make(map[string]function),
+		storageKeys:   make(map[string]bool),
+	}
+	if section, ok := m.Custom("contractspecv0"); ok {
+		if b.spec, err = abi.DecodeContractSpec(section.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	imported := m.ImportedFuncs()
+	names := functionNames(m)
+	storage := make(map[uint32]bool)
+	for idx := uint32(0); idx < imported; idx++ {
+		imp, _ := m.FuncImport(idx)
+		b.hostFunctions[names[idx]] = true
+		if host, ok := wat.HostFunctionName(imp.Module, imp.Name); ok && storageFunctions[host] {
+			storage[idx] = true
+		}
+	}
+
+	for def := range m.Code {
+		idx := imported + uint32(def)
+		scan, err := m.ScanFunction(def)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range scan.Calls {
+			if !storage[c] {
+				continue
+			}
+			for _, v := range scan.I64Consts {
+				if key, ok := decodeSmallSymbol(uint64(v)); ok {
+					b.storageKeys[key] = true
+				}
+			}
+			break
+		}
+
+		name := names[idx]
+		if name == "" {
+			b.unnamed++
+			continue
+		}
+		body, err := m.NormalizedBody(def)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		h.Write(body)
+		if typ, ok := m.FuncType(idx); ok && int(typ) < len(m.Types) {
+			h.Write(m.Types[typ])
+		}
+		for _, c := range scan.Calls {
+			fmt.Fprintf(h, "\x00call %s", names[c])
+		}
+		for _, typ := range scan.IndirectCalls {
+			if int(typ) < len(m.Types) {
+				fmt.Fprintf(h, "\x00call_indirect %x", m.Types[typ])
+			}
+		}
+		for _, c := range scan.FuncRefs {
+			fmt.Fprintf(h, "\x00ref.func %s", names[c])
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], h.Sum(nil))
+		b.functions[name] = function{hash: sum, size: len(m.Code[def])}
+	}
+	return b, nil
+}
+
+// functionNames names the functions of m the way Diff matches them:
+// imports by host function name, or "module.field" when unknown, and
+// defined functions by export name, then by demangled name-section name.
+// Defined functions sharing a name are told apart by a "#N" suffix in index
+// order. Unnamed functions are left out.
+func functionNames(m *wasmopt.Module) map[uint32]string {
+	names := make(map[uint32]string)
+	imported := m.ImportedFuncs()
+	for idx := uint32(0); idx < imported; idx++ {
+		imp, _ := m.FuncImport(idx)
+		if host, ok := wat.HostFunctionName(imp.Module, imp.Name); ok {
+			names[idx] = host
+		} else {
+			names[idx] = imp.Module + "." + imp.Name
+		}
+	}
+
+	exported := m.ExportedFuncs()
+	sectionNames := m.FunctionNames()
+	seen := make(map[string]int)
+	for def := range m.Code {
+		idx := imported + uint32(def)
+		var name string
+		if exports := exported[idx]; len(exports) > 0 {
+			name = exports[0]
+		} else if n := sectionNames[idx]; n != "" {
+			name = demangle.DemangleSymbol(n)
+		}
+		if name == "" {
+			continue
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+		names[idx] = name
+	}
+	return names
+}
+
+func diffSets(before, after map[string]bool) SetDiff {
+	var d SetDiff
+	for k := range after {
+		if !before[k] {
+			d.Added = append(d.Added, k)
+		}
+	}
+	for k := range before {
+		if !after[k] {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	return d
+}
+
+// decodeSmallSymbol decodes a Soroban SymbolSmall Val: up to 9 characters
+// of [_0-9A-Za-z], 6 bits each, above an 8-bit tag.
+func decodeSmallSymbol(v uint64) (string, bool) {
+	const tagSymbolSmall = 14
+	if v&0xff != tagSymbolSmall {
+		return "", false
+	}
+	body := v >> 8
+	if body == 0 || body>>54 != 0 {
+		return "", false
+	}
+	var chars []byte
+	for ; body != 0; body >>= 6 {
+		code := byte(body & 0x3f)
+		switch {
+		case code == 0:
+			return "", false
+		case code == 1:
+			chars = append(chars, '_')
+		case code < 12:
+			chars = append(chars, '0'+code-2)
+		case code < 38:
+			chars = append(chars, 'A'+code-12)
+		default:
+			chars = append(chars, 'a'+code-38)
+		}
+	}
+	for i, j := 0, len(chars)-1; i < j; i, j = i+1, j-1 {
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars), true
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stellar/go-stellar-sdk/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func section(id byte, items ...[]byte) []byte {
+	payload := uleb(uint64(len(items)))
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func custom(name string, payload []byte) []byte {
+	content := append(wasmName(name), payload...)
+	return append(append([]byte{0x00}, uleb(uint64(len(content)))...), content...)
+}
+
+func wasmName(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func join(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func call(idx uint32) []byte { return append([]byte{0x10}, uleb(uint64(idx))...) }
+
+// symbolVal returns the SymbolSmall Val of s, made of letters.
+func symbolVal(s string) uint64 {
+	var body uint64
+	for _, c := range s {
+		switch {
+		case c >= 'a':
+			body = body<<6 | uint64(38+c-'a')
+		default:
+			body = body<<6 | uint64(12+c-'A')
+		}
+	}
+	return body<<8 | 14
+}
+
+// symbol returns i64.const of the SymbolSmall Val of s.
+func symbol(s string) []byte {
+	return append([]byte{0x42}, sleb(int64(symbolVal(s)))...)
+}
+
+// testFunc is a defined function of a test contract, exported when export
+// is set and otherwise named in the name section when name is set.
+type testFunc struct {
+	export, name string
+	body         []byte
+}
+
+// buildContract builds a contract importing the given host functions, all
+// of type (i64 i64) -> i64, with its functions of type (i64) -> i64.
+func buildContract(imports [][2]string, funcs []testFunc, spec []byte) []byte {
+	wasm := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	wasm = append(wasm, section(1,
+		[]byte{0x60, 0x02, 0x7e, 0x7e, 0x01, 0x7e},
+		[]byte{0x60, 0x01, 0x7e, 0x01, 0x7e},
+	)...)
+	var importEntries, funcEntries, exports, bodies, names [][]byte
+	for _, imp := range imports {
+		importEntries = append(importEntries, join(wasmName(imp[0]), wasmName(imp[1]), []byte{0x00, 0x00}))
+	}
+	for i, f := range funcs {
+		idx := uint64(len(imports) + i)
+		funcEntries = append(funcEntries, []byte{0x01})
+		if f.export != "" {
+			exports = append(exports, join(wasmName(f.export), []byte{0x00}, uleb(idx)))
+		}
+		if f.name != "" {
+			names = append(names, join(uleb(idx), wasmName(f.name)))
+		}
+		b := join([]byte{0x00}, f.body, []byte{0x0b})
+		bodies = append(bodies, append(uleb(uint64(len(b))), b...))
+	}
+	wasm = append(wasm, section(2, importEntries...)...)
+	wasm = append(wasm, section(3, funcEntries...)...)
+	wasm = append(wasm, section(7, exports...)...)
+	wasm = append(wasm, section(10, bodies...)...)
+	wasm = append(wasm, custom("name", section(1, names...))...)
+	if spec != nil {
+		wasm = append(wasm, custom("contractspecv0", spec)...)
+	}
+	return wasm
+}
+
+func marshalEntries(t *testing.T, entries ...xdr.ScSpecEntry) []byte {
+	t.Helper()
+	var out []byte
+	for _, e := range entries {
+		b, err := e.MarshalBinary()
+		require.NoError(t, err)
+		out = append(out, b...)
+	}
+	return out
+}
+
+func specFunction(name string, inputs ...xdr.ScSpecFunctionInputV0) xdr.ScSpecEntry {
+	return xdr.ScSpecEntry{
+		Kind:       xdr.ScSpecEntryKindScSpecEntryFunctionV0,
+		FunctionV0: &xdr.ScSpecFunctionV0{Name: xdr.ScSymbol(name), Inputs: inputs},
+	}
+}
+
+func specInput(name string, typ xdr.ScSpecType) xdr.ScSpecFunctionInputV0 {
+	return xdr.ScSpecFunctionInputV0{Name: name, Type: xdr.ScSpecTypeDef{Type: typ}}
+}
+
+func specErrors(cases ...xdr.ScSpecUdtErrorEnumCaseV0) xdr.ScSpecEntry {
+	return xdr.ScSpecEntry{
+		Kind:           xdr.ScSpecEntryKindScSpecEntryUdtErrorEnumV0,
+		UdtErrorEnumV0: &xdr.ScSpecUdtErrorEnumV0{Name: "Error", Cases: cases},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	helper := "_ZN8contract6helper17h0123456789abcdefE"
+	oldWasm := buildContract(
+		[][2]string{{"l", "1"}, {"l", "_"}},
+		[]testFunc{
+			{export: "balance", body: join(symbol("Balance"), call(0))},
+			{name: helper, body: join(symbol("Balance"), call(1))},
+			{export: "transfer", body: call(3)},
+			{export: "burn", body: []byte{0x01}},
+			{body: []byte{0x01}},
+		},
+		marshalEntries(t,
+			specFunction("balance", specInput("id", xdr.ScSpecTypeScSpecTypeAddress)),
+			specFunction("transfer", specInput("amount", xdr.ScSpecTypeScSpecTypeI64)),
+			specFunction("burn"),
+			specErrors(xdr.ScSpecUdtErrorEnumCaseV0{Name: "NotFound", Value: 1}),
+		),
+	)
+	// require_auth is imported first, shifting every function index, and a
+	// different helper is built with its own rustc hash.
+	newWasm := buildContract(
+		[][2]string{{"a", "0"}, {"l", "1"}, {"l", "_"}},
+		[]testFunc{
+			{export: "balance", body: join(symbol("Balance"), call(1))},
+			{name: "_ZN8contract6helper17hfedcba9876543210E", body: join(symbol("Balance"), call(2))},
+			{export: "transfer", body: join(call(0), call(4))},
+			{export: "mint", body: join(symbol("Supply"), call(2))},
+		},
+		marshalEntries(t,
+			specFunction("balance", specInput("id", xdr.ScSpecTypeScSpecTypeAddress)),
+			specFunction("transfer", specInput("amount", xdr.ScSpecTypeScSpecTypeI128)),
+			specFunction("mint"),
+			specErrors(
+				xdr.ScSpecUdtErrorEnumCaseV0{Name: "NotFound", Value: 1},
+				xdr.ScSpecUdtErrorEnumCaseV0{Name: "Paused", Value: 2},
+			),
+		),
+	)
+
+	r, err := Diff(oldWasm, newWasm)
+	require.NoError(t, err)
+
+	assert.Equal(t, []SpecChange{
+		{Kind: SpecFunction, Name: "burn", Change: Removed, Old: "burn() -> Void"},
+		{Kind: SpecFunction, Name: "mint", Change: Added, New: "mint() -> Void"},
+		{Kind: SpecFunction, Name: "transfer", Change: Changed, Old: "transfer(amount: I64) -> Void", New: "transfer(amount: I128) -> Void"},
+		{Kind: SpecError, Name: "Error::Paused", Change: Added, New: "2"},
+	}, r.Spec)
+	assert.Equal(t, SetDiff{Added: []string{"require_auth"}}, r.HostFunctions)
+
+	assert.Equal(t, []FunctionChange{
+		{Name: "burn", Change: Removed, OldSize: 3},
+		{Name: "mint", Change: Added, NewSize: 12},
+		{Name: "transfer", Change: Changed, OldSize: 4, NewSize: 6},
+	}, r.Functions)
+	assert.Equal(t, 2, r.Unchanged)
+	assert.Equal(t, 1, r.OldUnnamed)
+	assert.Equal(t, 0, r.NewUnnamed)
+	assert.Equal(t, SetDiff{Added: []string{"Supply"}}, r.StorageKeys)
+	assert.False(t, r.Empty())
+
+	text := r.Text()
+	assert.Contains(t, text, "  ~ function transfer\n      was: transfer(amount: I64) -> Void\n      now: transfer(amount: I128) -> Void\n")
+	assert.Contains(t, text, "\nHost functions (1):\n  + require_auth\n")
+	assert.Contains(t, text, "\nFunctions (3 differ, 2 unchanged, 1 -> 0 unnamed):\n")
+	assert.Contains(t, text, "  ~ transfer       4 ->      6 (+2)\n")
+	assert.Contains(t, text, "\nStorage keys (1):\n  + Supply\n")
+
+	out, err := r.JSON()
+	require.NoError(t, err)
+	var decoded Report
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, r, &decoded)
+}
+
+func TestDiff_Identical(t *testing.T) {
+	wasm := buildContract([][2]string{{"l", "_"}}, []testFunc{{export: "set", body: join(symbol("Admin"), call(0))}}, nil)
+	r, err := Diff(wasm, wasm)
+	require.NoError(t, err)
+	assert.True(t, r.Empty())
+	assert.Equal(t, 1, r.Unchanged)
+	assert.Equal(t, fmt.Sprintf("Size: %d -> %d bytes (+0)\n\nNo differences.\n", len(wasm), len(wasm)), r.Text())
+}
+
+func TestDiff_Invalid(t *testing.T) {
+	wasm := buildContract(nil, nil, nil)
+	_, err := Diff([]byte("not wasm"), wasm)
+	assert.ErrorContains(t, err, "old WASM")
+	_, err = Diff(wasm, buildContract(nil, nil, []byte{0xff}))
+	assert.ErrorContains(t, err, "new WASM")
+}
+
+func TestDecodeSmallSymbol(t *testing.T) {
+	s, ok := decodeSmallSymbol(symbolVal("DataKey"))
+	assert.True(t, ok)
+	assert.Equal(t, "DataKey", s)
+
+	_, ok = decodeSmallSymbol(38<<8 | 4)
+	assert.False(t, ok, "not a symbol tag")
+	_, ok = decodeSmallSymbol((38<<12|38)<<8 | 14)
+	assert.False(t, ok, "zero code between characters")
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "fmt"
+
+// Scan is what the body of a function refers to, in instruction order. It
+// is built by the same walker the passes use to find live functions.
+type Scan struct {
+	// Calls are the functions called directly, by call or return_call.
+	Calls []uint32
+	// IndirectCalls are the type indices of call_indirect and
+	// return_call_indirect.
+	IndirectCalls []uint32
+	// FuncRefs are the functions whose reference ref.func takes.
+	FuncRefs []uint32
+	// I64Consts are the values of i64.const. Soroban contracts build the
+	// host values they pass around, symbols included, from them.
+	I64Consts []int64
+}
+
+// ScanFunction walks the body of the defined function def, an index into
+// Code.
+func (m *Module) ScanFunction(def int) (*Scan, error) {
+	if def < 0 || def >= len(m.Code) {
+		return nil, fmt.Errorf("defined function %d out of range", def)
+	}
+	scan := &Scan{}
+	_, err := walkBody(m.Code[def], identity, func(op byte, imm int64) {
+		switch op {
+		case 0x10, 0x12:
+			scan.Calls = append(scan.Calls, uint32(imm))
+		case 0x11, 0x13:
+			scan.IndirectCalls = append(scan.IndirectCalls, uint32(imm))
+		case 0xd2:
+			scan.FuncRefs = append(scan.FuncRefs, uint32(imm))
+		case 0x42:
+			scan.I64Consts = append(scan.I64Consts, imm)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("function %d: %w", m.ImportedFuncs()+uint32(def), err)
+	}
+	return scan, nil
+}
+
+// NormalizedBody returns the body of the defined function def with every
+// function and type index zeroed, so that the bodies of two builds of the
+// same code compare equal when functions or types were added around them.
+// What the zeroed indices referred to is left to the caller, from the
+// function's Scan.
+func (m *Module) NormalizedBody(def int) ([]byte, error) {
+	if def < 0 || def >= len(m.Code) {
+		return nil, fmt.Errorf("defined function %d out of range", def)
+	}
+	body, err := rewriteBody(m.Code[def], func(s space, idx uint32) (uint32, bool) {
+		if s == funcSpace || s == typeSpace {
+			return 0, true
+		}
+		return idx, true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("function %d: %w", m.ImportedFuncs()+uint32(def), err)
+	}
+	return body, nil
+}
+
+// AddressTaken returns the functions an indirect call may reach: those
+// element segments place in tables and those whose reference a global
+// initializer or function body takes.
+func (m *Module) AddressTaken() (map[uint32]bool, error) {
+	taken := make(map[uint32]bool)
+	exprs := make([][]byte, 0, len(m.Globals))
+	for _, g := range m.Globals {
+		exprs = append(exprs, g.Init)
+	}
+	for _, e := range m.Elements {
+		for _, idx := range e.Funcs {
+			taken[idx] = true
+		}
+		exprs = append(exprs, e.Exprs...)
+	}
+	for _, expr := range exprs {
+		if _, _, err := rewriteExpr(expr, 0, collect(funcSpace, taken)); err != nil {
+			return nil, err
+		}
+	}
+	for def := range m.Code {
+		scan, err := m.ScanFunction(def)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range scan.FuncRefs {
+			taken[idx] = true
+		}
+	}
+	return taken, nil
+}
+
+// FuncImport returns the import of function idx, when it is imported.
+func (m *Module) FuncImport(idx uint32) (Import, bool) {
+	n := uint32(0)
+	for _, imp := range m.Imports {
+		if imp.Kind != kindFunc {
+			continue
+		}
+		if n == idx {
+			return imp, true
+		}
+		n++
+	}
+	return Import{}, false
+}
+
+// FuncType returns the type index of function idx, imported or defined.
+func (m *Module) FuncType(idx uint32) (uint32, bool) {
+	if imp, ok := m.FuncImport(idx); ok {
+		return imp.Type, true
+	}
+	def := idx - m.ImportedFuncs()
+	if idx < m.ImportedFuncs() || def >= uint32(len(m.Funcs)) {
+		return 0, false
+	}
+	return m.Funcs[def], true
+}
+
+// ExportedFuncs returns the export names of each exported function.
+func (m *Module) ExportedFuncs() map[uint32][]string {
+	exported := make(map[uint32][]string)
+	for _, e := range m.Exports {
+		if e.Kind == kindFunc {
+			exported[e.Index] = append(exported[e.Index], e.Name)
+		}
+	}
+	return exported
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanFunction(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFuncImport("env", "log", 0).
+		addTable().
+		addFunction(0, []byte{
+			0x10, 0x00, // call 0
+			0x42, 0x7f, 0x1a, // i64.const -1, drop
+			0x41, 0x00, 0x11, 0x00, 0x00, // i32.const 0, call_indirect (type 0)
+			0xd2, 0x02, 0x1a, // ref.func 2, drop
+			0x12, 0x02, // return_call 2
+		}).
+		addFunction(0, []byte{0x01}).
+		addExport("main", 1).
+		addExport("alias", 1).
+		addElementSegment([]uint32{0}).
+		build()
+
+	m, err := Parse(wasm)
+	require.NoError(t, err)
+
+	scan, err := m.ScanFunction(0)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 2}, scan.Calls)
+	assert.Equal(t, []uint32{0}, scan.IndirectCalls)
+	assert.Equal(t, []uint32{2}, scan.FuncRefs)
+	assert.Equal(t, []int64{-1}, scan.I64Consts)
+
+	_, err = m.ScanFunction(2)
+	assert.Error(t, err)
+
+	taken, err := m.AddressTaken()
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]bool{0: true, 2: true}, taken)
+
+	imp, ok := m.FuncImport(0)
+	require.True(t, ok)
+	assert.Equal(t, "log", imp.Name)
+	_, ok = m.FuncImport(1)
+	assert.False(t, ok)
+
+	typ, ok := m.FuncType(2)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(0), typ)
+	_, ok = m.FuncType(3)
+	assert.False(t, ok)
+
+	assert.Equal(t, map[uint32][]string{1: {"main", "alias"}}, m.ExportedFuncs())
+}
+
+func TestFunctionNames(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x01}).
+		addFunction(0, []byte{0x01}).
+		addCustomSection("name", nameSection(map[uint32]string{0: "main", 1: "helper"}, 0, 1)).
+		build()
+
+	m, err := Parse(wasm)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]string{0: "main", 1: "helper"}, m.FunctionNames())
+
+	m.Customs[0].Payload = []byte{0x01, 0x05, 0x01}
+	assert.Empty(t, m.FunctionNames())
+}
+
+func TestNormalizedBody(t *testing.T) {
+	// The same function, calling the import, before and after a second
+	// import shifts the function index space.
+	before := newTestModule().
+		addFuncType().
+		addFuncImport("env", "log", 0).
+		addFunction(0, []byte{0x10, 0x00, 0x41, 0x00, 0x11, 0x00, 0x00}).
+		addTable().
+		build()
+	after := newTestModule().
+		addFuncType().
+		addFuncImport("env", "abort", 0).
+		addFuncImport("env", "log", 0).
+		addFunction(0, []byte{0x10, 0x01, 0x41, 0x00, 0x11, 0x00, 0x00}).
+		addTable().
+		build()
+
+	var bodies [][]byte
+	for _, wasm := range [][]byte{before, after} {
+		m, err := Parse(wasm)
+		require.NoError(t, err)
+		body, err := m.NormalizedBody(0)
+		require.NoError(t, err)
+		bodies = append(bodies, body)
+
+		_, err = m.NormalizedBody(1)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, []byte{0x00, 0x10, 0x00, 0x41, 0x00, 0x11, 0x00, 0x00, 0x0b}, bodies[0])
+}
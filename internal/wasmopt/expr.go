@@ -0,0 +1,301 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "fmt"
+
+// space is an index space that instructions and sections refer into.
+type space int
+
+const (
+	funcSpace space = iota
+	globalSpace
+	typeSpace
+	dataSpace
+)
+
+func (s space) String() string {
+	switch s {
+	case funcSpace:
+		return "function"
+	case globalSpace:
+		return "global"
+	case typeSpace:
+		return "type"
+	default:
+		return "data segment"
+	}
+}
+
+// remapFunc returns the new value of an index of space s, and false when the
+// index was removed.
+type remapFunc func(s space, idx uint32) (uint32, bool)
+
+func identity(_ space, idx uint32) (uint32, bool) {
+	return idx, true
+}
+
+// collect returns a remapFunc that keeps every index and records those of
+// space s in seen.
+func collect(s space, seen map[uint32]bool) remapFunc {
+	return func(got space, idx uint32) (uint32, bool) {
+		if got == s {
+			seen[idx] = true
+		}
+		return idx, true
+	}
+}
+
+// rewriteBody rewrites the index immediates of a function body through remap.
+func rewriteBody(body []byte, remap remapFunc) ([]byte, error) {
+	return walkBody(body, remap, nil)
+}
+
+// walkBody is rewriteBody, calling visit, when not nil, on the way.
+func walkBody(body []byte, remap remapFunc, visit visitFunc) ([]byte, error) {
+	pos := 0
+	localDeclCount, n, err := readU32(body, pos)
+	if err != nil {
+		return nil, err
+	}
+	pos += n
+	for i := uint32(0); i < localDeclCount; i++ {
+		_, n, err := readU32(body, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		if pos >= len(body) {
+			return nil, fmt.Errorf("local decl truncated")
+		}
+		pos++
+	}
+
+	expr, end, err := walkExpr(body, pos, remap, visit)
+	if err != nil {
+		return nil, err
+	}
+	if end != len(body) {
+		return nil, fmt.Errorf("function body has trailing bytes")
+	}
+	out := make([]byte, 0, pos+len(expr))
+	out = append(out, body[:pos]...)
+	return append(out, expr...), nil
+}
+
+// readConstExpr returns a copy of the constant expression starting at pos,
+// with its end, and the position after it.
+func readConstExpr(data []byte, pos int) ([]byte, int, error) {
+	return rewriteExpr(data, pos, identity)
+}
+
+// rewriteExpr decodes the expression starting at pos up to the end that
+// closes it, and returns it with its index immediates rewritten through
+// remap, along with the position after it. Immediates that keep their value
+// are copied verbatim.
+func rewriteExpr(data []byte, pos int, remap remapFunc) ([]byte, int, error) {
+	return walkExpr(data, pos, remap, nil)
+}
+
+// visitFunc is called by walkExpr for the instructions Scan reports, with
+// their original immediate: the function index of call, return_call and
+// ref.func, the type index of call_indirect and return_call_indirect, and
+// the value of i64.const.
+type visitFunc func(op byte, imm int64)
+
+// walkExpr is rewriteExpr, calling visit, when not nil, on the way.
+func walkExpr(data []byte, pos int, remap remapFunc, visit visitFunc) ([]byte, int, error) {
+	var out []byte
+	observe := func(op byte, imm int64) {
+		if visit != nil {
+			visit(op, imm)
+		}
+	}
+	peekU32 := func() int64 {
+		idx, _, _ := readU32(data, pos)
+		return int64(idx)
+	}
+	copyU32 := func(count int) error {
+		for i := 0; i < count; i++ {
+			_, n, err := readU32(data, pos)
+			if err != nil {
+				return err
+			}
+			out = append(out, data[pos:pos+n]...)
+			pos += n
+		}
+		return nil
+	}
+	index := func(s space) error {
+		idx, n, err := readU32(data, pos)
+		if err != nil {
+			return err
+		}
+		newIdx, ok := remap(s, idx)
+		if !ok {
+			return fmt.Errorf("%s index %d was removed", s, idx)
+		}
+		if newIdx == idx {
+			out = append(out, data[pos:pos+n]...)
+		} else {
+			out = append(out, encodeU32(newIdx)...)
+		}
+		pos += n
+		return nil
+	}
+	blockType := func() error {
+		if pos >= len(data) {
+			return fmt.Errorf("blocktype truncated")
+		}
+		switch data[pos] {
+		case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+			out = append(out, data[pos])
+			pos++
+			return nil
+		}
+		idx, n, err := readSLEB(data, pos, 33)
+		if err != nil {
+			return err
+		}
+		if idx < 0 {
+			return fmt.Errorf("invalid blocktype %d", idx)
+		}
+		newIdx, ok := remap(typeSpace, uint32(idx))
+		if !ok {
+			return fmt.Errorf("type index %d was removed", idx)
+		}
+		if int64(newIdx) == idx {
+			out = append(out, data[pos:pos+n]...)
+		} else {
+			out = append(out, encodeSLEB(int64(newIdx))...)
+		}
+		pos += n
+		return nil
+	}
+	fixed := func(size int, what string) error {
+		if pos+size > len(data) {
+			return fmt.Errorf("%s truncated", what)
+		}
+		out = append(out, data[pos:pos+size]...)
+		pos += size
+		return nil
+	}
+
+	depth := 0
+	for {
+		if pos >= len(data) {
+			return nil, 0, fmt.Errorf("expression has no end")
+		}
+		op := data[pos]
+		pos++
+		out = append(out, op)
+
+		var err error
+		switch op {
+		case 0x0b: // end
+			if depth == 0 {
+				return out, pos, nil
+			}
+			depth--
+		case 0x02, 0x03, 0x04: // block, loop, if
+			depth++
+			err = blockType()
+		case 0x0c, 0x0d, 0x20, 0x21, 0x22, 0x25, 0x26, 0x3f, 0x40:
+			err = copyU32(1)
+		case 0x0e: // br_table
+			var count uint32
+			var n int
+			count, n, err = readU32(data, pos)
+			if err == nil {
+				out = append(out, data[pos:pos+n]...)
+				pos += n
+				err = copyU32(int(count) + 1)
+			}
+		case 0x10, 0x12, 0xd2: // call, return_call, ref.func
+			observe(op, peekU32())
+			err = index(funcSpace)
+		case 0x11, 0x13: // call_indirect, return_call_indirect
+			observe(op, peekU32())
+			if err = index(typeSpace); err == nil {
+				err = copyU32(1)
+			}
+		case 0x1c: // select t*
+			var count uint32
+			var n int
+			count, n, err = readU32(data, pos)
+			if err == nil {
+				out = append(out, data[pos:pos+n]...)
+				pos += n
+				err = fixed(int(count), "select type vector")
+			}
+		case 0x23, 0x24: // global.get, global.set
+			err = index(globalSpace)
+		case 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+			0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
+			0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e:
+			err = copyU32(2)
+		case 0x41, 0x42:
+			bits := uint(32)
+			if op == 0x42 {
+				bits = 64
+			}
+			var value int64
+			var n int
+			if value, n, err = readSLEB(data, pos, bits); err == nil {
+				if op == 0x42 {
+					observe(op, value)
+				}
+				err = fixed(n, "const")
+			}
+		case 0x43:
+			err = fixed(4, "f32.const")
+		case 0x44:
+			err = fixed(8, "f64.const")
+		case 0xd0:
+			err = fixed(1, "ref.null")
+		case 0xfc:
+			var sub uint32
+			var n int
+			if sub, n, err = readU32(data, pos); err != nil {
+				break
+			}
+			out = append(out, data[pos:pos+n]...)
+			pos += n
+			switch sub {
+			case 0, 1, 2, 3, 4, 5, 6, 7:
+			case 8: // memory.init
+				if err = index(dataSpace); err == nil {
+					err = copyU32(1)
+				}
+			case 9: // data.drop
+				err = index(dataSpace)
+			case 10, 12, 14:
+				err = copyU32(2)
+			case 11, 13, 15, 16, 17:
+				err = copyU32(1)
+			default:
+				err = fmt.Errorf("unsupported 0xfc subopcode %d", sub)
+			}
+		case 0xfd:
+			err = fmt.Errorf("unsupported SIMD opcode prefix 0xfd")
+		case 0xfe:
+			err = fmt.Errorf("unsupported atomic opcode prefix 0xfe")
+		default:
+			if !isNoImmediateOpcode(op) {
+				err = fmt.Errorf("unsupported opcode 0x%02x", op)
+			}
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+func isNoImmediateOpcode(op byte) bool {
+	switch op {
+	case 0x00, 0x01, 0x05, 0x0f, 0x1a, 0x1b, 0xd1:
+		return true
+	}
+	return op >= 0x45 && op <= 0xc4
+}
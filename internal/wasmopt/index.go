@@ -0,0 +1,141 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "fmt"
+
+// references returns every index of space s that the module refers to,
+// leaving out the name section.
+func (m *Module) references(s space) (map[uint32]bool, error) {
+	seen := make(map[uint32]bool)
+	if err := m.rewrite(collect(s, seen)); err != nil {
+		return nil, err
+	}
+	return seen, nil
+}
+
+// remap rewrites every index the module refers to through fn. Entries whose
+// index fn removes must already have been dropped by the caller; a remaining
+// reference to one is an error. The name section, when present, is
+// renumbered too, and names of removed entries are dropped.
+func (m *Module) remap(fn remapFunc) error {
+	if err := m.rewrite(fn); err != nil {
+		return err
+	}
+	m.remapNames(fn)
+	return nil
+}
+
+// rewrite rewrites the references of every section but the name section.
+func (m *Module) rewrite(fn remapFunc) error {
+	index := func(s space, idx uint32) (uint32, error) {
+		newIdx, ok := fn(s, idx)
+		if !ok {
+			return 0, fmt.Errorf("%s index %d was removed", s, idx)
+		}
+		return newIdx, nil
+	}
+	expr := func(e []byte, what string) ([]byte, error) {
+		out, _, err := rewriteExpr(e, 0, fn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", what, err)
+		}
+		return out, nil
+	}
+
+	var err error
+	for i := range m.Imports {
+		if m.Imports[i].Kind == kindFunc || m.Imports[i].Kind == kindTag {
+			if m.Imports[i].Type, err = index(typeSpace, m.Imports[i].Type); err != nil {
+				return fmt.Errorf("import %d: %w", i, err)
+			}
+		}
+	}
+	for i := range m.Funcs {
+		if m.Funcs[i], err = index(typeSpace, m.Funcs[i]); err != nil {
+			return fmt.Errorf("function %d: %w", i, err)
+		}
+	}
+	for i := range m.Globals {
+		if m.Globals[i].Init, err = expr(m.Globals[i].Init, fmt.Sprintf("global %d", i)); err != nil {
+			return err
+		}
+	}
+	for i := range m.Exports {
+		s := funcSpace
+		switch m.Exports[i].Kind {
+		case kindFunc:
+		case kindGlobal:
+			s = globalSpace
+		default:
+			continue
+		}
+		if m.Exports[i].Index, err = index(s, m.Exports[i].Index); err != nil {
+			return fmt.Errorf("export %q: %w", m.Exports[i].Name, err)
+		}
+	}
+	if m.Start != nil {
+		start, err := index(funcSpace, *m.Start)
+		if err != nil {
+			return fmt.Errorf("start function: %w", err)
+		}
+		m.Start = &start
+	}
+	for i := range m.Elements {
+		e := &m.Elements[i]
+		what := fmt.Sprintf("element segment %d", i)
+		if e.Offset != nil {
+			if e.Offset, err = expr(e.Offset, what); err != nil {
+				return err
+			}
+		}
+		for j := range e.Funcs {
+			if e.Funcs[j], err = index(funcSpace, e.Funcs[j]); err != nil {
+				return fmt.Errorf("%s: %w", what, err)
+			}
+		}
+		for j := range e.Exprs {
+			if e.Exprs[j], err = expr(e.Exprs[j], what); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range m.Data {
+		if m.Data[i].Offset != nil {
+			if m.Data[i].Offset, err = expr(m.Data[i].Offset, fmt.Sprintf("data segment %d", i)); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range m.Code {
+		if m.Code[i], err = rewriteBody(m.Code[i], fn); err != nil {
+			return fmt.Errorf("code body %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// keepMap returns a remapFunc for space s that drops the indices from first
+// on whose keep entry is false and shifts the others down. Indices of other
+// spaces are left alone.
+func keepMap(s space, first uint32, keep []bool) remapFunc {
+	newIdx := make([]uint32, len(keep))
+	next := first
+	for i, k := range keep {
+		if k {
+			newIdx[i] = next
+			next++
+		}
+	}
+	return func(got space, idx uint32) (uint32, bool) {
+		if got != s || idx < first {
+			return idx, true
+		}
+		i := idx - first
+		if int(i) >= len(keep) || !keep[i] {
+			return 0, false
+		}
+		return newIdx[i], true
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "fmt"
+
+func readU32(data []byte, pos int) (uint32, int, error) {
+	var v uint32
+	shift := uint(0)
+	for i := 0; i < 5; i++ {
+		if pos+i >= len(data) {
+			return 0, 0, fmt.Errorf("uleb128 out of bounds")
+		}
+		b := data[pos+i]
+		v |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, i + 1, nil
+		}
+		shift += 7
+	}
+	return 0, 0, fmt.Errorf("uleb128 overflow")
+}
+
+func readSLEB(data []byte, pos int, bits uint) (int64, int, error) {
+	var result int64
+	shift := uint(0)
+	var b byte
+	for i := 0; i < 10; i++ {
+		if pos+i >= len(data) {
+			return 0, 0, fmt.Errorf("sleb128 out of bounds")
+		}
+		b = data[pos+i]
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < bits && (b&0x40) != 0 {
+				result |= ^0 << shift
+			}
+			return result, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("sleb128 overflow")
+}
+
+func encodeU32(v uint32) []byte {
+	var out [5]byte
+	i := 0
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		out[i] = b
+		i++
+		if v == 0 {
+			break
+		}
+	}
+	return out[:i]
+}
+
+// readName reads a length-prefixed UTF-8 name.
+func readName(data []byte, pos int) (string, int, error) {
+	l, n, err := readU32(data, pos)
+	if err != nil {
+		return "", 0, err
+	}
+	pos += n
+	if pos+int(l) > len(data) {
+		return "", 0, fmt.Errorf("name out of bounds")
+	}
+	return string(data[pos : pos+int(l)]), pos + int(l), nil
+}
+
+func appendName(out []byte, name string) []byte {
+	out = append(out, encodeU32(uint32(len(name)))...)
+	return append(out, name...)
+}
+
+// readLimits returns the end of the limits starting at pos.
+func readLimits(data []byte, pos int) (int, error) {
+	flags, n, err := readU32(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	pos += n
+	if _, n, err = readU32(data, pos); err != nil {
+		return 0, err
+	}
+	pos += n
+	if flags&0x01 != 0 {
+		if _, n, err = readU32(data, pos); err != nil {
+			return 0, err
+		}
+		pos += n
+	}
+	return pos, nil
+}
+
+func encodeSLEB(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
@@ -0,0 +1,651 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidModule is returned, wrapped, when a binary cannot be decoded as
+// a WASM module.
+var ErrInvalidModule = errors.New("invalid wasm module")
+
+const (
+	sectionCustom    byte = 0
+	sectionType      byte = 1
+	sectionImport    byte = 2
+	sectionFunction  byte = 3
+	sectionTable     byte = 4
+	sectionMemory    byte = 5
+	sectionGlobal    byte = 6
+	sectionExport    byte = 7
+	sectionStart     byte = 8
+	sectionElement   byte = 9
+	sectionCode      byte = 10
+	sectionData      byte = 11
+	sectionDataCount byte = 12
+	sectionTag       byte = 13
+)
+
+// sectionOrder is the order non-custom sections appear in a module.
+var sectionOrder = []byte{
+	sectionType, sectionImport, sectionFunction, sectionTable, sectionMemory,
+	sectionTag, sectionGlobal, sectionExport, sectionStart, sectionElement,
+	sectionDataCount, sectionCode, sectionData,
+}
+
+// External kinds of imports and exports.
+const (
+	kindFunc   byte = 0x00
+	kindTable  byte = 0x01
+	kindMemory byte = 0x02
+	kindGlobal byte = 0x03
+	kindTag    byte = 0x04
+)
+
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// Module is a decoded WASM module. Sections that refer to functions,
+// globals, types or data segments are decoded so that passes can remove
+// entries and renumber the references to them; tables, memories and tags
+// are kept as raw section payloads.
+type Module struct {
+	Types     [][]byte // encoded function types
+	Imports   []Import
+	Funcs     []uint32 // type index of each defined function
+	Table     []byte   // raw table section payload
+	Memory    []byte   // raw memory section payload
+	Tag       []byte   // raw tag section payload
+	Globals   []Global
+	Exports   []Export
+	Start     *uint32
+	Elements  []Element
+	DataCount *uint32
+	Code      [][]byte // function bodies, with their local declarations
+	Data      []DataSegment
+	Customs   []CustomSection
+}
+
+// Import is an entry of the import section.
+type Import struct {
+	Module string
+	Name   string
+	Kind   byte
+	// Type is the type index of a function or tag import.
+	Type uint32
+	// Desc is the raw descriptor of a table, memory or global import.
+	Desc []byte
+}
+
+// Export is an entry of the export section.
+type Export struct {
+	Name  string
+	Kind  byte
+	Index uint32
+}
+
+// Global is a defined global.
+type Global struct {
+	Type []byte // value type and mutability
+	Init []byte // constant expression, with its end
+}
+
+// Element is an element segment. Flags selects which of the other fields
+// are encoded, as in the binary format.
+type Element struct {
+	Flags  uint32
+	Table  uint32
+	Offset []byte   // constant expression of active segments
+	Kind   byte     // elemkind or reftype
+	Funcs  []uint32 // function indices, when flags&4 == 0
+	Exprs  [][]byte // constant expressions, when flags&4 != 0
+}
+
+// DataSegment is a data segment. Flags is 0 for an active segment of memory
+// 0, 1 for a passive segment and 2 for an active segment of Memory.
+type DataSegment struct {
+	Flags  uint32
+	Memory uint32
+	Offset []byte
+	Bytes  []byte
+}
+
+// Passive reports whether the segment is only copied by memory.init.
+func (d DataSegment) Passive() bool {
+	return d.Flags == 1
+}
+
+// CustomSection is a custom section. After is the id of the non-custom
+// section it follows, or 0 when it precedes them all.
+type CustomSection struct {
+	Name    string
+	Payload []byte
+	After   byte
+}
+
+// ImportedFuncs returns the number of imported functions, which come first
+// in the function index space.
+func (m *Module) ImportedFuncs() uint32 {
+	return m.imported(kindFunc)
+}
+
+// ImportedGlobals returns the number of imported globals, which come first
+// in the global index space.
+func (m *Module) ImportedGlobals() uint32 {
+	return m.imported(kindGlobal)
+}
+
+func (m *Module) imported(kind byte) uint32 {
+	var n uint32
+	for _, imp := range m.Imports {
+		if imp.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Custom returns the custom section with the given name.
+func (m *Module) Custom(name string) (*CustomSection, bool) {
+	for i := range m.Customs {
+		if m.Customs[i].Name == name {
+			return &m.Customs[i], true
+		}
+	}
+	return nil, false
+}
+
+// Parse decodes a WASM binary.
+func Parse(wasm []byte) (*Module, error) {
+	if len(wasm) < len(wasmMagic) || !bytes.Equal(wasm[:len(wasmMagic)], wasmMagic) {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidModule)
+	}
+	m := &Module{}
+	pos := len(wasmMagic)
+	last := sectionCustom
+	for pos < len(wasm) {
+		id := wasm[pos]
+		pos++
+		size, n, err := readU32(wasm, pos)
+		if err != nil {
+			return nil, fmt.Errorf("%w: section %d: %v", ErrInvalidModule, id, err)
+		}
+		pos += n
+		if pos+int(size) > len(wasm) {
+			return nil, fmt.Errorf("%w: section %d extends past end of file", ErrInvalidModule, id)
+		}
+		payload := wasm[pos : pos+int(size)]
+		pos += int(size)
+
+		if id != sectionCustom {
+			if order(id) < 0 {
+				return nil, fmt.Errorf("%w: unknown section %d", ErrInvalidModule, id)
+			}
+			if order(id) <= order(last) {
+				return nil, fmt.Errorf("%w: section %d out of order", ErrInvalidModule, id)
+			}
+			last = id
+		}
+		if err := m.parseSection(id, payload, last); err != nil {
+			return nil, fmt.Errorf("%w: section %d: %v", ErrInvalidModule, id, err)
+		}
+	}
+	if len(m.Funcs) != len(m.Code) {
+		return nil, fmt.Errorf("%w: function/code section length mismatch: %d vs %d",
+			ErrInvalidModule, len(m.Funcs), len(m.Code))
+	}
+	return m, nil
+}
+
+// order returns the position of a non-custom section in sectionOrder, or -1.
+func order(id byte) int {
+	if id == sectionCustom {
+		return -1
+	}
+	for i, s := range sectionOrder {
+		if s == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Module) parseSection(id byte, payload []byte, last byte) error {
+	var err error
+	switch id {
+	case sectionCustom:
+		name, pos, err := readName(payload, 0)
+		if err != nil {
+			return err
+		}
+		m.Customs = append(m.Customs, CustomSection{
+			Name:    name,
+			Payload: append([]byte(nil), payload[pos:]...),
+			After:   last,
+		})
+		return nil
+	case sectionType:
+		err = readVec(payload, func(data []byte, pos int) (int, error) {
+			end, err := skipFuncType(data, pos)
+			if err == nil {
+				m.Types = append(m.Types, append([]byte(nil), data[pos:end]...))
+			}
+			return end, err
+		})
+	case sectionImport:
+		err = readVec(payload, m.readImport)
+	case sectionFunction:
+		err = readVec(payload, func(data []byte, pos int) (int, error) {
+			idx, n, err := readU32(data, pos)
+			m.Funcs = append(m.Funcs, idx)
+			return pos + n, err
+		})
+	case sectionTable:
+		m.Table = append([]byte(nil), payload...)
+	case sectionMemory:
+		m.Memory = append([]byte(nil), payload...)
+	case sectionTag:
+		m.Tag = append([]byte(nil), payload...)
+	case sectionGlobal:
+		err = readVec(payload, func(data []byte, pos int) (int, error) {
+			if pos+2 > len(data) {
+				return 0, fmt.Errorf("global type truncated")
+			}
+			init, end, err := readConstExpr(data, pos+2)
+			if err == nil {
+				m.Globals = append(m.Globals, Global{Type: append([]byte(nil), data[pos:pos+2]...), Init: init})
+			}
+			return end, err
+		})
+	case sectionExport:
+		err = readVec(payload, func(data []byte, pos int) (int, error) {
+			name, pos, err := readName(data, pos)
+			if err != nil {
+				return 0, err
+			}
+			if pos >= len(data) {
+				return 0, fmt.Errorf("export entry truncated")
+			}
+			idx, n, err := readU32(data, pos+1)
+			m.Exports = append(m.Exports, Export{Name: name, Kind: data[pos], Index: idx})
+			return pos + 1 + n, err
+		})
+	case sectionStart:
+		idx, n, err := readU32(payload, 0)
+		if err != nil {
+			return err
+		}
+		if n != len(payload) {
+			return fmt.Errorf("trailing bytes")
+		}
+		m.Start = &idx
+		return nil
+	case sectionElement:
+		err = readVec(payload, m.readElement)
+	case sectionDataCount:
+		count, n, err := readU32(payload, 0)
+		if err != nil {
+			return err
+		}
+		if n != len(payload) {
+			return fmt.Errorf("trailing bytes")
+		}
+		m.DataCount = &count
+		return nil
+	case sectionCode:
+		err = readVec(payload, func(data []byte, pos int) (int, error) {
+			size, n, err := readU32(data, pos)
+			if err != nil {
+				return 0, err
+			}
+			pos += n
+			if pos+int(size) > len(data) {
+				return 0, fmt.Errorf("code body out of bounds")
+			}
+			m.Code = append(m.Code, append([]byte(nil), data[pos:pos+int(size)]...))
+			return pos + int(size), nil
+		})
+	case sectionData:
+		err = readVec(payload, m.readData)
+	}
+	return err
+}
+
+// readVec calls read for each entry of the vector that makes up payload.
+// read returns the position after the entry.
+func readVec(payload []byte, read func(data []byte, pos int) (int, error)) error {
+	count, pos, err := readU32(payload, 0)
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		if pos, err = read(payload, pos); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+	if pos != len(payload) {
+		return fmt.Errorf("trailing bytes")
+	}
+	return nil
+}
+
+func skipFuncType(data []byte, pos int) (int, error) {
+	if pos >= len(data) || data[pos] != 0x60 {
+		return 0, fmt.Errorf("unsupported type form")
+	}
+	pos++
+	for i := 0; i < 2; i++ { // params, results
+		count, n, err := readU32(data, pos)
+		if err != nil {
+			return 0, err
+		}
+		pos += n + int(count)
+		if pos > len(data) {
+			return 0, fmt.Errorf("function type truncated")
+		}
+	}
+	return pos, nil
+}
+
+func (m *Module) readImport(data []byte, pos int) (int, error) {
+	module, pos, err := readName(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	name, pos, err := readName(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	if pos >= len(data) {
+		return 0, fmt.Errorf("import entry truncated")
+	}
+	imp := Import{Module: module, Name: name, Kind: data[pos]}
+	pos++
+	start := pos
+	switch imp.Kind {
+	case kindFunc:
+		idx, n, err := readU32(data, pos)
+		if err != nil {
+			return 0, err
+		}
+		imp.Type = idx
+		pos += n
+	case kindTable:
+		if pos >= len(data) {
+			return 0, fmt.Errorf("table import truncated")
+		}
+		if pos, err = readLimits(data, pos+1); err != nil {
+			return 0, err
+		}
+	case kindMemory:
+		if pos, err = readLimits(data, pos); err != nil {
+			return 0, err
+		}
+	case kindGlobal:
+		if pos+2 > len(data) {
+			return 0, fmt.Errorf("global import truncated")
+		}
+		pos += 2
+	case kindTag:
+		if pos >= len(data) {
+			return 0, fmt.Errorf("tag import truncated")
+		}
+		idx, n, err := readU32(data, pos+1)
+		if err != nil {
+			return 0, err
+		}
+		imp.Type = idx
+		pos += 1 + n
+	default:
+		return 0, fmt.Errorf("unsupported import kind %d", imp.Kind)
+	}
+	if imp.Kind != kindFunc && imp.Kind != kindTag {
+		imp.Desc = append([]byte(nil), data[start:pos]...)
+	}
+	m.Imports = append(m.Imports, imp)
+	return pos, nil
+}
+
+func (m *Module) readElement(data []byte, pos int) (int, error) {
+	flags, n, err := readU32(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	if flags > 7 {
+		return 0, fmt.Errorf("unsupported element flags %d", flags)
+	}
+	pos += n
+	e := Element{Flags: flags}
+	if flags&0x02 != 0 && flags&0x01 == 0 {
+		if e.Table, n, err = readU32(data, pos); err != nil {
+			return 0, err
+		}
+		pos += n
+	}
+	if flags&0x01 == 0 {
+		if e.Offset, pos, err = readConstExpr(data, pos); err != nil {
+			return 0, err
+		}
+	}
+	if flags&0x03 != 0 {
+		if pos >= len(data) {
+			return 0, fmt.Errorf("element kind truncated")
+		}
+		e.Kind = data[pos]
+		pos++
+	}
+	if flags&0x04 == 0 {
+		err = readVec2(data, &pos, func() error {
+			idx, n, err := readU32(data, pos)
+			e.Funcs = append(e.Funcs, idx)
+			pos += n
+			return err
+		})
+	} else {
+		err = readVec2(data, &pos, func() error {
+			expr, end, err := readConstExpr(data, pos)
+			e.Exprs = append(e.Exprs, expr)
+			pos = end
+			return err
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	m.Elements = append(m.Elements, e)
+	return pos, nil
+}
+
+func (m *Module) readData(data []byte, pos int) (int, error) {
+	flags, n, err := readU32(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	if flags > 2 {
+		return 0, fmt.Errorf("unsupported data segment flags %d", flags)
+	}
+	pos += n
+	d := DataSegment{Flags: flags}
+	if flags == 2 {
+		if d.Memory, n, err = readU32(data, pos); err != nil {
+			return 0, err
+		}
+		pos += n
+	}
+	if flags != 1 {
+		if d.Offset, pos, err = readConstExpr(data, pos); err != nil {
+			return 0, err
+		}
+	}
+	size, n, err := readU32(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	pos += n
+	if pos+int(size) > len(data) {
+		return 0, fmt.Errorf("data segment out of bounds")
+	}
+	d.Bytes = append([]byte(nil), data[pos:pos+int(size)]...)
+	m.Data = append(m.Data, d)
+	return pos + int(size), nil
+}
+
+// readVec2 reads the count of a vector nested in an entry at *pos and calls
+// read, which advances *pos, once per element.
+func readVec2(data []byte, pos *int, read func() error) error {
+	count, n, err := readU32(data, *pos)
+	if err != nil {
+		return err
+	}
+	*pos += n
+	for i := uint32(0); i < count; i++ {
+		if err := read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode re-emits the module. Sections without entries are left out, and
+// custom sections keep their place relative to the other sections.
+func (m *Module) Encode() []byte {
+	out := append([]byte(nil), wasmMagic...)
+	emit := func(id byte, payload []byte) {
+		out = append(out, id)
+		out = append(out, encodeU32(uint32(len(payload)))...)
+		out = append(out, payload...)
+	}
+	customs := func(after byte) {
+		for _, c := range m.Customs {
+			if c.After == after {
+				emit(sectionCustom, append(appendName(nil, c.Name), c.Payload...))
+			}
+		}
+	}
+
+	customs(sectionCustom)
+	for _, id := range sectionOrder {
+		if payload, ok := m.encodeSection(id); ok {
+			emit(id, payload)
+		}
+		customs(id)
+	}
+	return out
+}
+
+func (m *Module) encodeSection(id byte) ([]byte, bool) {
+	var out []byte
+	vec := func(count int, entry func(i int)) ([]byte, bool) {
+		if count == 0 {
+			return nil, false
+		}
+		out = encodeU32(uint32(count))
+		for i := 0; i < count; i++ {
+			entry(i)
+		}
+		return out, true
+	}
+	raw := func(payload []byte) ([]byte, bool) {
+		return payload, payload != nil
+	}
+
+	switch id {
+	case sectionType:
+		return vec(len(m.Types), func(i int) { out = append(out, m.Types[i]...) })
+	case sectionImport:
+		return vec(len(m.Imports), func(i int) {
+			imp := m.Imports[i]
+			out = appendName(out, imp.Module)
+			out = appendName(out, imp.Name)
+			out = append(out, imp.Kind)
+			switch imp.Kind {
+			case kindFunc:
+				out = append(out, encodeU32(imp.Type)...)
+			case kindTag:
+				out = append(out, 0x00)
+				out = append(out, encodeU32(imp.Type)...)
+			default:
+				out = append(out, imp.Desc...)
+			}
+		})
+	case sectionFunction:
+		return vec(len(m.Funcs), func(i int) { out = append(out, encodeU32(m.Funcs[i])...) })
+	case sectionTable:
+		return raw(m.Table)
+	case sectionMemory:
+		return raw(m.Memory)
+	case sectionTag:
+		return raw(m.Tag)
+	case sectionGlobal:
+		return vec(len(m.Globals), func(i int) {
+			out = append(out, m.Globals[i].Type...)
+			out = append(out, m.Globals[i].Init...)
+		})
+	case sectionExport:
+		return vec(len(m.Exports), func(i int) {
+			out = appendName(out, m.Exports[i].Name)
+			out = append(out, m.Exports[i].Kind)
+			out = append(out, encodeU32(m.Exports[i].Index)...)
+		})
+	case sectionStart:
+		if m.Start == nil {
+			return nil, false
+		}
+		return encodeU32(*m.Start), true
+	case sectionElement:
+		return vec(len(m.Elements), func(i int) { out = m.Elements[i].append(out) })
+	case sectionDataCount:
+		if m.DataCount == nil {
+			return nil, false
+		}
+		return encodeU32(*m.DataCount), true
+	case sectionCode:
+		return vec(len(m.Code), func(i int) {
+			out = append(out, encodeU32(uint32(len(m.Code[i])))...)
+			out = append(out, m.Code[i]...)
+		})
+	case sectionData:
+		return vec(len(m.Data), func(i int) { out = m.Data[i].append(out) })
+	}
+	return nil, false
+}
+
+func (e Element) append(out []byte) []byte {
+	out = append(out, encodeU32(e.Flags)...)
+	if e.Flags&0x02 != 0 && e.Flags&0x01 == 0 {
+		out = append(out, encodeU32(e.Table)...)
+	}
+	if e.Flags&0x01 == 0 {
+		out = append(out, e.Offset...)
+	}
+	if e.Flags&0x03 != 0 {
+		out = append(out, e.Kind)
+	}
+	if e.Flags&0x04 == 0 {
+		out = append(out, encodeU32(uint32(len(e.Funcs)))...)
+		for _, idx := range e.Funcs {
+			out = append(out, encodeU32(idx)...)
+		}
+		return out
+	}
+	out = append(out, encodeU32(uint32(len(e.Exprs)))...)
+	for _, expr := range e.Exprs {
+		out = append(out, expr...)
+	}
+	return out
+}
+
+func (d DataSegment) append(out []byte) []byte {
+	out = append(out, encodeU32(d.Flags)...)
+	if d.Flags == 2 {
+		out = append(out, encodeU32(d.Memory)...)
+	}
+	if d.Flags != 1 {
+		out = append(out, d.Offset...)
+	}
+	out = append(out, encodeU32(uint32(len(d.Bytes)))...)
+	return append(out, d.Bytes...)
+}
@@ -0,0 +1,346 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Test WASM module builder
+// =============================================================================
+
+// testModuleBuilder constructs synthetic WASM binaries for testing.
+type testModuleBuilder struct {
+	types    [][]byte // raw type entries
+	imports  [][]byte // raw import entries
+	funcIdxs []uint32 // type indices for local functions
+	bodies   [][]byte // function bodies (local decls + code + end)
+	tables   []byte   // raw table section payload
+	memories []byte   // raw memory section payload
+	globals  [][]byte // raw global entries
+	exports  [][]byte // raw export entries
+	start    *uint32
+	elements [][]byte // raw element segment entries
+	data     [][]byte // raw data segment entries
+	custom   [][]byte // raw custom section payloads (each preceded by name)
+}
+
+func newTestModule() *testModuleBuilder {
+	return &testModuleBuilder{}
+}
+
+// addFuncType adds a () -> () function type.
+func (b *testModuleBuilder) addFuncType() *testModuleBuilder {
+	b.types = append(b.types, []byte{0x60, 0x00, 0x00})
+	return b
+}
+
+// addResultType adds a () -> i32 function type.
+func (b *testModuleBuilder) addResultType() *testModuleBuilder {
+	b.types = append(b.types, []byte{0x60, 0x00, 0x01, 0x7f})
+	return b
+}
+
+// addFuncImport adds a function import.
+func (b *testModuleBuilder) addFuncImport(module, name string, typeIdx uint32) *testModuleBuilder {
+	entry := appendName(nil, module)
+	entry = appendName(entry, name)
+	entry = append(entry, kindFunc)
+	entry = append(entry, encodeU32(typeIdx)...)
+	b.imports = append(b.imports, entry)
+	return b
+}
+
+// addGlobalImport adds an immutable i32 global import.
+func (b *testModuleBuilder) addGlobalImport(module, name string) *testModuleBuilder {
+	entry := appendName(nil, module)
+	entry = appendName(entry, name)
+	entry = append(entry, kindGlobal, 0x7f, 0x00)
+	b.imports = append(b.imports, entry)
+	return b
+}
+
+// addFunction adds a local function with the given body instructions.
+// The body should NOT include local declarations or end byte.
+func (b *testModuleBuilder) addFunction(typeIdx uint32, bodyInstructions []byte) *testModuleBuilder {
+	b.funcIdxs = append(b.funcIdxs, typeIdx)
+	body := []byte{0x00} // 0 local declarations
+	body = append(body, bodyInstructions...)
+	body = append(body, 0x0b) // end
+	b.bodies = append(b.bodies, body)
+	return b
+}
+
+// addGlobal adds a mutable i32 global with the given initializer
+// instructions, without their end byte.
+func (b *testModuleBuilder) addGlobal(init []byte) *testModuleBuilder {
+	entry := []byte{0x7f, 0x01}
+	entry = append(entry, init...)
+	b.globals = append(b.globals, append(entry, 0x0b))
+	return b
+}
+
+// addExport adds an export of the given kind.
+func (b *testModuleBuilder) addExport(name string, idx uint32) *testModuleBuilder {
+	return b.addExportKind(name, kindFunc, idx)
+}
+
+func (b *testModuleBuilder) addExportKind(name string, kind byte, idx uint32) *testModuleBuilder {
+	entry := appendName(nil, name)
+	entry = append(entry, kind)
+	entry = append(entry, encodeU32(idx)...)
+	b.exports = append(b.exports, entry)
+	return b
+}
+
+// setStart sets the start function index.
+func (b *testModuleBuilder) setStart(funcIdx uint32) *testModuleBuilder {
+	b.start = &funcIdx
+	return b
+}
+
+// addElementSegment adds an active element segment of table 0 at offset 0.
+func (b *testModuleBuilder) addElementSegment(funcIdxs []uint32) *testModuleBuilder {
+	entry := []byte{0x00, 0x41, 0x00, 0x0b} // flags 0, i32.const 0, end
+	entry = append(entry, encodeU32(uint32(len(funcIdxs)))...)
+	for _, idx := range funcIdxs {
+		entry = append(entry, encodeU32(idx)...)
+	}
+	b.elements = append(b.elements, entry)
+	return b
+}
+
+// addActiveData adds a data segment of memory 0 at the given offset.
+func (b *testModuleBuilder) addActiveData(offset byte, bytes []byte) *testModuleBuilder {
+	entry := []byte{0x00, 0x41, offset, 0x0b}
+	entry = append(entry, encodeU32(uint32(len(bytes)))...)
+	b.data = append(b.data, append(entry, bytes...))
+	return b
+}
+
+// addPassiveData adds a passive data segment.
+func (b *testModuleBuilder) addPassiveData(bytes []byte) *testModuleBuilder {
+	entry := []byte{0x01}
+	entry = append(entry, encodeU32(uint32(len(bytes)))...)
+	b.data = append(b.data, append(entry, bytes...))
+	return b
+}
+
+// addCustomSection adds a custom section with the given name and payload.
+func (b *testModuleBuilder) addCustomSection(name string, payload []byte) *testModuleBuilder {
+	b.custom = append(b.custom, append(appendName(nil, name), payload...))
+	return b
+}
+
+// addTable adds a table section.
+func (b *testModuleBuilder) addTable() *testModuleBuilder {
+	// 1 table, funcref (0x70), limits: min=0, no max
+	b.tables = []byte{0x01, 0x70, 0x00, 0x00}
+	return b
+}
+
+// addMemory adds a memory section.
+func (b *testModuleBuilder) addMemory() *testModuleBuilder {
+	// 1 memory, limits: min=1, no max
+	b.memories = []byte{0x01, 0x00, 0x01}
+	return b
+}
+
+// build constructs the final WASM binary.
+func (b *testModuleBuilder) build() []byte {
+	out := append([]byte(nil), wasmMagic...)
+	emitSection := func(id byte, payload []byte) {
+		out = append(out, id)
+		out = append(out, encodeU32(uint32(len(payload)))...)
+		out = append(out, payload...)
+	}
+	emitVec := func(id byte, entries [][]byte) {
+		if len(entries) == 0 {
+			return
+		}
+		payload := encodeU32(uint32(len(entries)))
+		for _, e := range entries {
+			payload = append(payload, e...)
+		}
+		emitSection(id, payload)
+	}
+
+	emitVec(sectionType, b.types)
+	emitVec(sectionImport, b.imports)
+	var funcs [][]byte
+	for _, idx := range b.funcIdxs {
+		funcs = append(funcs, encodeU32(idx))
+	}
+	emitVec(sectionFunction, funcs)
+	if b.tables != nil {
+		emitSection(sectionTable, b.tables)
+	}
+	if b.memories != nil {
+		emitSection(sectionMemory, b.memories)
+	}
+	emitVec(sectionGlobal, b.globals)
+	emitVec(sectionExport, b.exports)
+	if b.start != nil {
+		emitSection(sectionStart, encodeU32(*b.start))
+	}
+	emitVec(sectionElement, b.elements)
+	var bodies [][]byte
+	for _, body := range b.bodies {
+		bodies = append(bodies, append(encodeU32(uint32(len(body))), body...))
+	}
+	emitVec(sectionCode, bodies)
+	emitVec(sectionData, b.data)
+	for _, sec := range b.custom {
+		emitSection(sectionCustom, sec)
+	}
+	return out
+}
+
+// nameSection encodes a name section with the given function names.
+func nameSection(names map[uint32]string, order ...uint32) []byte {
+	sub := encodeU32(uint32(len(order)))
+	for _, idx := range order {
+		sub = append(sub, encodeU32(idx)...)
+		sub = appendName(sub, names[idx])
+	}
+	return append(append([]byte{0x01}, encodeU32(uint32(len(sub)))...), sub...)
+}
+
+// functionNames decodes the function names of a name section.
+func functionNames(t *testing.T, payload []byte) map[uint32]string {
+	t.Helper()
+	require.Equal(t, byte(0x01), payload[0])
+	_, pos, err := readU32(payload, 1)
+	require.NoError(t, err)
+	count, n, err := readU32(payload, 1+pos)
+	require.NoError(t, err)
+	pos += 1 + n
+	names := make(map[uint32]string)
+	for i := uint32(0); i < count; i++ {
+		idx, n, err := readU32(payload, pos)
+		require.NoError(t, err)
+		var name string
+		name, pos, err = readName(payload, pos+n)
+		require.NoError(t, err)
+		names[idx] = name
+	}
+	return names
+}
+
+// =============================================================================
+// Tests
+// =============================================================================
+
+func TestParse_RoundTrip(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFuncImport("env", "abort", 0).
+		addGlobalImport("env", "base").
+		addFunction(0, []byte{0x10, 0x00}). // call imported func
+		addTable().
+		addMemory().
+		addGlobal([]byte{0x23, 0x00}). // global.get 0
+		addExport("main", 1).
+		addElementSegment([]uint32{1}).
+		addActiveData(8, []byte("hello")).
+		addPassiveData([]byte("world")).
+		addCustomSection("contractspecv0", []byte{0x01, 0x02}).
+		build()
+
+	m, err := Parse(wasm)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), m.ImportedFuncs())
+	assert.Equal(t, uint32(1), m.ImportedGlobals())
+	assert.Len(t, m.Code, 1)
+	assert.Len(t, m.Data, 2)
+	assert.True(t, m.Data[1].Passive())
+	spec, ok := m.Custom("contractspecv0")
+	require.True(t, ok)
+	assert.Equal(t, sectionData, spec.After)
+
+	assert.Equal(t, wasm, m.Encode(), "round-trip should produce identical binary")
+}
+
+func TestParse_CustomSectionPositions(t *testing.T) {
+	wasm := append([]byte(nil), wasmMagic...)
+	section := func(id byte, payload []byte) {
+		wasm = append(wasm, id)
+		wasm = append(wasm, encodeU32(uint32(len(payload)))...)
+		wasm = append(wasm, payload...)
+	}
+	section(sectionCustom, appendName(nil, "first"))
+	section(sectionType, []byte{0x01, 0x60, 0x00, 0x00})
+	section(sectionCustom, appendName(nil, "after-types"))
+	section(sectionFunction, []byte{0x01, 0x00})
+	section(sectionCode, []byte{0x01, 0x02, 0x00, 0x0b})
+
+	m, err := Parse(wasm)
+	require.NoError(t, err)
+	require.Len(t, m.Customs, 2)
+	assert.Equal(t, sectionCustom, m.Customs[0].After)
+	assert.Equal(t, sectionType, m.Customs[1].After)
+	assert.Equal(t, wasm, m.Encode())
+
+	// The custom section keeps its place once the section it follows is gone.
+	m.Types = nil
+	m.Funcs, m.Code = nil, nil
+	out, err := Parse(m.Encode())
+	require.NoError(t, err)
+	assert.Len(t, out.Customs, 2)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, wasm := range map[string][]byte{
+		"too short":      {0xFF, 0xFF},
+		"bad version":    {0x00, 0x61, 0x73, 0x6d, 0x02, 0x00, 0x00, 0x00},
+		"truncated":      append(append([]byte(nil), wasmMagic...), sectionType, 0x05, 0x01),
+		"unknown":        append(append([]byte(nil), wasmMagic...), 0x20, 0x00),
+		"out of order":   append(append([]byte(nil), wasmMagic...), sectionCode, 0x01, 0x00, sectionType, 0x01, 0x00),
+		"missing bodies": append(append([]byte(nil), wasmMagic...), sectionType, 0x04, 0x01, 0x60, 0x00, 0x00, sectionFunction, 0x02, 0x01, 0x00),
+	} {
+		_, err := Parse(wasm)
+		assert.ErrorIs(t, err, ErrInvalidModule, name)
+	}
+}
+
+func TestRewriteExpr(t *testing.T) {
+	body := []byte{
+		0x01,       // 1 local declaration
+		0x01, 0x7f, // 1 i32
+		0x02, 0x40, // block
+		0x10, 0x03, // call 3
+		0x23, 0x01, // global.get 1
+		0x11, 0x02, 0x00, // call_indirect type 2, table 0
+		0x41, 0x0b, // i32.const 11
+		0x0b,                               // end
+		0x10, 0x83, 0x80, 0x80, 0x80, 0x00, // call 3, padded
+		0x0b, // end
+	}
+
+	calls := make(map[uint32]bool)
+	same, err := rewriteBody(body, collect(funcSpace, calls))
+	require.NoError(t, err)
+	assert.Equal(t, body, same, "unchanged immediates are copied verbatim")
+	assert.Equal(t, map[uint32]bool{3: true}, calls)
+
+	out, err := rewriteBody(body, func(s space, idx uint32) (uint32, bool) {
+		if s == funcSpace {
+			return idx - 2, true
+		}
+		return idx, true
+	})
+	require.NoError(t, err)
+	calls = make(map[uint32]bool)
+	_, err = rewriteBody(out, collect(funcSpace, calls))
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]bool{1: true}, calls)
+	assert.Len(t, out, len(body)-4, "rewritten indices are re-encoded minimally")
+
+	_, err = rewriteBody([]byte{0x00, 0xfd, 0x00, 0x0b}, identity)
+	assert.Error(t, err, "SIMD is not supported")
+}
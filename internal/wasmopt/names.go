@@ -0,0 +1,172 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "fmt"
+
+// nameSpaces maps the name section's subsections that are keyed by an index
+// to the index space of the key.
+var nameSpaces = map[byte]space{
+	1:  funcSpace,   // function names
+	2:  funcSpace,   // local names, by function
+	3:  funcSpace,   // label names, by function
+	4:  typeSpace,   // type names
+	7:  globalSpace, // global names
+	9:  dataSpace,   // data segment names
+	10: typeSpace,   // field names, by type
+}
+
+// indirectNames lists the subsections whose entries are name maps.
+var indirectNames = map[byte]bool{2: true, 3: true, 10: true}
+
+// remapNames renumbers the entries of the name section through fn, so that
+// names survive the removal of the entries they do not describe. A name
+// section that cannot be decoded is dropped rather than left pointing at the
+// wrong entries.
+func (m *Module) remapNames(fn remapFunc) {
+	for i := range m.Customs {
+		if m.Customs[i].Name != "name" {
+			continue
+		}
+		payload, err := remapNameSection(m.Customs[i].Payload, fn)
+		if err != nil {
+			m.Customs = append(m.Customs[:i], m.Customs[i+1:]...)
+			return
+		}
+		m.Customs[i].Payload = payload
+		return
+	}
+}
+
+func remapNameSection(payload []byte, fn remapFunc) ([]byte, error) {
+	var out []byte
+	pos := 0
+	for pos < len(payload) {
+		id := payload[pos]
+		size, n, err := readU32(payload, pos+1)
+		if err != nil {
+			return nil, err
+		}
+		start := pos + 1 + n
+		end := start + int(size)
+		if end > len(payload) {
+			return nil, fmt.Errorf("name subsection %d out of bounds", id)
+		}
+		sub := payload[start:end]
+		pos = end
+
+		if s, ok := nameSpaces[id]; ok {
+			if sub, err = remapNameMap(sub, s, fn, indirectNames[id]); err != nil {
+				return nil, fmt.Errorf("name subsection %d: %w", id, err)
+			}
+		}
+		out = append(out, id)
+		out = append(out, encodeU32(uint32(len(sub)))...)
+		out = append(out, sub...)
+	}
+	return out, nil
+}
+
+// remapNameMap renumbers the keys of a name map, or of an indirect name map
+// when indirect is set, dropping the entries of removed indices.
+func remapNameMap(data []byte, s space, fn remapFunc, indirect bool) ([]byte, error) {
+	count, pos, err := readU32(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	var entries []byte
+	kept := uint32(0)
+	for i := uint32(0); i < count; i++ {
+		idx, n, err := readU32(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		start := pos
+		if indirect {
+			if pos, err = skipNameMap(data, pos); err != nil {
+				return nil, err
+			}
+		} else if _, pos, err = readName(data, pos); err != nil {
+			return nil, err
+		}
+		newIdx, ok := fn(s, idx)
+		if !ok {
+			continue
+		}
+		entries = append(entries, encodeU32(newIdx)...)
+		entries = append(entries, data[start:pos]...)
+		kept++
+	}
+	if pos != len(data) {
+		return nil, fmt.Errorf("trailing bytes")
+	}
+	return append(encodeU32(kept), entries...), nil
+}
+
+func skipNameMap(data []byte, pos int) (int, error) {
+	count, n, err := readU32(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	pos += n
+	for i := uint32(0); i < count; i++ {
+		if _, n, err = readU32(data, pos); err != nil {
+			return 0, err
+		}
+		if _, pos, err = readName(data, pos+n); err != nil {
+			return 0, err
+		}
+	}
+	return pos, nil
+}
+
+// FunctionNames returns the function names of the name section, keyed by
+// function index. A module without a name section, or with one that cannot
+// be decoded, has no names.
+func (m *Module) FunctionNames() map[uint32]string {
+	names := make(map[uint32]string)
+	section, ok := m.Custom("name")
+	if !ok {
+		return names
+	}
+	payload := section.Payload
+	for pos := 0; pos < len(payload); {
+		size, n, err := readU32(payload, pos+1)
+		if err != nil {
+			return map[uint32]string{}
+		}
+		start := pos + 1 + n
+		end := start + int(size)
+		if end > len(payload) {
+			return map[uint32]string{}
+		}
+		if payload[pos] == 1 {
+			if err := readNameMap(payload[start:end], names); err != nil {
+				return map[uint32]string{}
+			}
+		}
+		pos = end
+	}
+	return names
+}
+
+func readNameMap(data []byte, names map[uint32]string) error {
+	count, pos, err := readU32(data, 0)
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		idx, n, err := readU32(data, pos)
+		if err != nil {
+			return err
+		}
+		var name string
+		if name, pos, err = readName(data, pos+n); err != nil {
+			return err
+		}
+		names[idx] = name
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import "strings"
+
+// SorobanSections are the custom sections the Soroban host reads from a
+// contract: its interface spec, the environment it was built against and
+// its metadata. StripCustomSections keeps them whatever it is told.
+var SorobanSections = []string{"contractspecv0", "contractenvmetav0", "contractmetav0"}
+
+// EliminateFunctions returns a pass that removes the defined functions that
+// cannot be reached from the exports, the start function, element segments
+// or global initializers, and renumbers the rest.
+func EliminateFunctions() Pass {
+	return functionsPass{}
+}
+
+// RemoveUnusedGlobals returns a pass that removes the defined globals that
+// nothing exported, no live global and no code refers to.
+func RemoveUnusedGlobals() Pass {
+	return globalsPass{}
+}
+
+// RemoveUnusedTypes returns a pass that removes the function types that no
+// import, function, indirect call or block refers to.
+func RemoveUnusedTypes() Pass {
+	return typesPass{}
+}
+
+// RemoveUnusedData returns a pass that removes the passive data segments no
+// memory.init or data.drop refers to. Active segments initialize memory and
+// are always kept.
+func RemoveUnusedData() Pass {
+	return dataPass{}
+}
+
+// StripCustomSections returns a pass that removes every custom section but
+// SorobanSections and those named in keep. A name ending in "*" keeps every
+// section with that prefix, so ".debug_*" keeps the DWARF sections.
+func StripCustomSections(keep ...string) Pass {
+	p := stripPass{keep: make(map[string]bool)}
+	for _, name := range append(append([]string(nil), SorobanSections...), keep...) {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			p.prefixes = append(p.prefixes, prefix)
+			continue
+		}
+		p.keep[name] = true
+	}
+	return p
+}
+
+type functionsPass struct{}
+
+func (functionsPass) Name() string { return "functions" }
+
+func (functionsPass) Run(m *Module) (Result, error) {
+	imported := m.ImportedFuncs()
+	result := Result{Total: len(m.Code)}
+
+	roots := make(map[uint32]bool)
+	for _, exp := range m.Exports {
+		if exp.Kind == kindFunc {
+			roots[exp.Index] = true
+		}
+	}
+	if m.Start != nil {
+		roots[*m.Start] = true
+	}
+	var consts [][]byte
+	for _, g := range m.Globals {
+		consts = append(consts, g.Init)
+	}
+	for _, e := range m.Elements {
+		for _, idx := range e.Funcs {
+			roots[idx] = true
+		}
+		consts = append(consts, e.Exprs...)
+	}
+	for _, expr := range consts {
+		if _, _, err := rewriteExpr(expr, 0, collect(funcSpace, roots)); err != nil {
+			return Result{}, err
+		}
+	}
+
+	reachable := make([]bool, len(m.Code))
+	var queue []uint32
+	visit := func(idx uint32) {
+		if idx < imported || int(idx-imported) >= len(m.Code) || reachable[idx-imported] {
+			return
+		}
+		reachable[idx-imported] = true
+		queue = append(queue, idx-imported)
+	}
+	for idx := range roots {
+		visit(idx)
+	}
+	for len(queue) > 0 {
+		def := queue[0]
+		queue = queue[1:]
+		calls := make(map[uint32]bool)
+		if _, err := rewriteBody(m.Code[def], collect(funcSpace, calls)); err != nil {
+			return Result{}, err
+		}
+		for idx := range calls {
+			visit(idx)
+		}
+	}
+
+	funcs, code := m.Funcs[:0], m.Code[:0]
+	for i, live := range reachable {
+		if !live {
+			result.Removed++
+			continue
+		}
+		funcs = append(funcs, m.Funcs[i])
+		code = append(code, m.Code[i])
+	}
+	if result.Removed == 0 {
+		return result, nil
+	}
+	m.Funcs, m.Code = funcs, code
+	return result, m.remap(keepMap(funcSpace, imported, reachable))
+}
+
+type globalsPass struct{}
+
+func (globalsPass) Name() string { return "globals" }
+
+func (globalsPass) Run(m *Module) (Result, error) {
+	imported := m.ImportedGlobals()
+	result := Result{Total: len(m.Globals)}
+
+	// An initializer only keeps the globals it refers to alive when its own
+	// global is live, so initializers are left out of the first scan.
+	globals := m.Globals
+	m.Globals = nil
+	used, err := m.references(globalSpace)
+	m.Globals = globals
+	if err != nil {
+		return Result{}, err
+	}
+
+	live := make([]bool, len(m.Globals))
+	var queue []uint32
+	for idx := range used {
+		if idx >= imported && int(idx-imported) < len(live) {
+			live[idx-imported] = true
+			queue = append(queue, idx-imported)
+		}
+	}
+	for len(queue) > 0 {
+		def := queue[0]
+		queue = queue[1:]
+		refs := make(map[uint32]bool)
+		if _, _, err := rewriteExpr(m.Globals[def].Init, 0, collect(globalSpace, refs)); err != nil {
+			return Result{}, err
+		}
+		for idx := range refs {
+			if idx >= imported && int(idx-imported) < len(live) && !live[idx-imported] {
+				live[idx-imported] = true
+				queue = append(queue, idx-imported)
+			}
+		}
+	}
+
+	kept := m.Globals[:0]
+	for i, g := range m.Globals {
+		if !live[i] {
+			result.Removed++
+			continue
+		}
+		kept = append(kept, g)
+	}
+	if result.Removed == 0 {
+		return result, nil
+	}
+	m.Globals = kept
+	return result, m.remap(keepMap(globalSpace, imported, live))
+}
+
+type typesPass struct{}
+
+func (typesPass) Name() string { return "types" }
+
+func (typesPass) Run(m *Module) (Result, error) {
+	result := Result{Total: len(m.Types)}
+	if m.Tag != nil {
+		// Tags refer to types from a section the model keeps raw.
+		return result, nil
+	}
+	used, err := m.references(typeSpace)
+	if err != nil {
+		return Result{}, err
+	}
+
+	keep := make([]bool, len(m.Types))
+	kept := m.Types[:0]
+	for i, t := range m.Types {
+		if !used[uint32(i)] {
+			result.Removed++
+			continue
+		}
+		keep[i] = true
+		kept = append(kept, t)
+	}
+	if result.Removed == 0 {
+		return result, nil
+	}
+	m.Types = kept
+	return result, m.remap(keepMap(typeSpace, 0, keep))
+}
+
+type dataPass struct{}
+
+func (dataPass) Name() string { return "data" }
+
+func (dataPass) Run(m *Module) (Result, error) {
+	result := Result{Total: len(m.Data)}
+	used, err := m.references(dataSpace)
+	if err != nil {
+		return Result{}, err
+	}
+
+	keep := make([]bool, len(m.Data))
+	kept := m.Data[:0]
+	for i, d := range m.Data {
+		if d.Passive() && !used[uint32(i)] {
+			result.Removed++
+			continue
+		}
+		keep[i] = true
+		kept = append(kept, d)
+	}
+	if result.Removed == 0 {
+		return result, nil
+	}
+	m.Data = kept
+	if m.DataCount != nil {
+		count := uint32(len(m.Data))
+		m.DataCount = &count
+	}
+	return result, m.remap(keepMap(dataSpace, 0, keep))
+}
+
+type stripPass struct {
+	keep     map[string]bool
+	prefixes []string
+}
+
+func (p stripPass) keeps(name string) bool {
+	if p.keep[name] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (stripPass) Name() string { return "custom-sections" }
+
+func (p stripPass) Run(m *Module) (Result, error) {
+	result := Result{Total: len(m.Customs)}
+	kept := m.Customs[:0]
+	for _, c := range m.Customs {
+		if !p.keeps(c.Name) {
+			result.Removed++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	m.Customs = kept
+	return result, nil
+}
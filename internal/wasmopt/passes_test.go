@@ -0,0 +1,241 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPass parses wasm, runs pass over it and checks the result re-encodes to
+// a valid module.
+func runPass(t *testing.T, pass Pass, wasm []byte) (*Module, Result) {
+	t.Helper()
+	m, err := Parse(wasm)
+	require.NoError(t, err)
+	result, err := pass.Run(m)
+	require.NoError(t, err)
+	require.NoError(t, validate(m.Encode()))
+	return m, result
+}
+
+func calls(t *testing.T, body []byte) map[uint32]bool {
+	t.Helper()
+	targets := make(map[uint32]bool)
+	_, err := rewriteBody(body, collect(funcSpace, targets))
+	require.NoError(t, err)
+	return targets
+}
+
+func TestEliminateFunctions_NoDeadCode(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x01}).       // func 0: nop
+		addFunction(0, []byte{0x10, 0x00}). // func 1: call func 0
+		addExport("f0", 0).
+		addExport("f1", 1).
+		build()
+
+	m, result := runPass(t, EliminateFunctions(), wasm)
+	assert.Equal(t, Result{Total: 2}, result)
+	assert.Equal(t, wasm, m.Encode())
+}
+
+func TestEliminateFunctions_ChainedCalls(t *testing.T) {
+	// A -> B -> C chain. Only A is exported. B and C should survive.
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x10, 0x01}). // func 0: call func 1
+		addFunction(0, []byte{0x10, 0x02}). // func 1: call func 2
+		addFunction(0, []byte{0x01}).       // func 2: nop
+		addFunction(0, []byte{0x01}).       // func 3: nop (dead)
+		addExport("main", 0).
+		build()
+
+	m, result := runPass(t, EliminateFunctions(), wasm)
+	assert.Equal(t, Result{Total: 4, Removed: 1}, result)
+	assert.Len(t, m.Code, 3)
+
+	// A second run finds nothing left to remove.
+	_, result = runPass(t, EliminateFunctions(), m.Encode())
+	assert.Equal(t, 0, result.Removed)
+}
+
+func TestEliminateFunctions_AllDead(t *testing.T) {
+	// No exports, no start, no elements. All locals should be removed.
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x01}).
+		addFunction(0, []byte{0x01}).
+		build()
+
+	m, result := runPass(t, EliminateFunctions(), wasm)
+	assert.Equal(t, 2, result.Removed)
+	assert.Empty(t, m.Code)
+	assert.Empty(t, m.Funcs)
+}
+
+func TestEliminateFunctions_Roots(t *testing.T) {
+	// Func 1 is referenced by an element segment, func 3 is the start
+	// function and func 4 is referenced by a global; the others are dead.
+	wasm := newTestModule().
+		addFuncType().
+		addTable().
+		addFunction(0, []byte{0x01}). // func 0: dead
+		addFunction(0, []byte{0x01}). // func 1: element
+		addFunction(0, []byte{0x01}). // func 2: dead
+		addFunction(0, []byte{0x01}). // func 3: start
+		addFunction(0, []byte{0x01}). // func 4: ref.func in a global
+		addGlobal([]byte{0xd2, 0x04}).
+		setStart(3).
+		addElementSegment([]uint32{1}).
+		build()
+
+	m, result := runPass(t, EliminateFunctions(), wasm)
+	assert.Equal(t, 2, result.Removed)
+	require.NotNil(t, m.Start)
+	assert.Equal(t, uint32(1), *m.Start, "start should be reindexed to 1")
+	assert.Equal(t, []uint32{0}, m.Elements[0].Funcs)
+	assert.Equal(t, []byte{0xd2, 0x02, 0x0b}, m.Globals[0].Init)
+}
+
+func TestEliminateFunctions_ImportsAndIndexRewriting(t *testing.T) {
+	// Import func 0. func 1 (exported) calls the import and func 3; func 2
+	// is dead, so func 3 becomes func 2 and func 4 (exported) becomes func 3.
+	wasm := newTestModule().
+		addFuncType().
+		addFuncImport("env", "log", 0).
+		addFunction(0, []byte{0x10, 0x00, 0x10, 0x03}). // func 1
+		addFunction(0, []byte{0x01}).                   // func 2: dead
+		addFunction(0, []byte{0x01}).                   // func 3
+		addFunction(0, []byte{0x01}).                   // func 4
+		addExport("main", 1).
+		addExport("helper", 4).
+		build()
+
+	m, result := runPass(t, EliminateFunctions(), wasm)
+	assert.Equal(t, Result{Total: 4, Removed: 1}, result)
+	assert.Len(t, m.Imports, 1)
+	assert.Equal(t, map[uint32]bool{0: true, 2: true}, calls(t, m.Code[0]))
+	assert.Equal(t, []Export{{Name: "main", Kind: kindFunc, Index: 1}, {Name: "helper", Kind: kindFunc, Index: 3}}, m.Exports)
+}
+
+func TestEliminateFunctions_PreservesNames(t *testing.T) {
+	names := map[uint32]string{0: "main", 1: "dead", 2: "helper"}
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x10, 0x02}).
+		addFunction(0, []byte{0x01}).
+		addFunction(0, []byte{0x01}).
+		addExport("main", 0).
+		addCustomSection("name", nameSection(names, 0, 1, 2)).
+		build()
+
+	m, _ := runPass(t, EliminateFunctions(), wasm)
+	section, ok := m.Custom("name")
+	require.True(t, ok)
+	assert.Equal(t, map[uint32]string{0: "main", 1: "helper"}, functionNames(t, section.Payload))
+}
+
+func TestRemoveUnusedGlobals(t *testing.T) {
+	// Global 0 is imported. Global 1 is used by code, global 2 only by the
+	// initializer of global 3, which nothing uses, and global 4 is exported.
+	wasm := newTestModule().
+		addFuncType().
+		addGlobalImport("env", "base").
+		addFunction(0, []byte{0x23, 0x01, 0x1a}). // global.get 1, drop
+		addGlobal([]byte{0x41, 0x00}).            // global 1
+		addGlobal([]byte{0x41, 0x01}).            // global 2
+		addGlobal([]byte{0x23, 0x02}).            // global 3
+		addGlobal([]byte{0x23, 0x00}).            // global 4
+		addExport("main", 0).
+		addExportKind("base", kindGlobal, 4).
+		build()
+
+	m, result := runPass(t, RemoveUnusedGlobals(), wasm)
+	assert.Equal(t, Result{Total: 4, Removed: 2}, result)
+	require.Len(t, m.Globals, 2)
+	assert.Equal(t, uint32(2), m.Exports[1].Index)
+}
+
+func TestRemoveUnusedTypes(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().   // type 0: unused
+		addResultType(). // type 1: used by func 0
+		addFuncType().   // type 2: used by the import and a block
+		addFuncImport("env", "log", 2).
+		addFunction(1, []byte{0x02, 0x02, 0x0b, 0x41, 0x00}). // block (type 2) end, i32.const 0
+		addExport("main", 1).
+		build()
+
+	m, result := runPass(t, RemoveUnusedTypes(), wasm)
+	assert.Equal(t, Result{Total: 3, Removed: 1}, result)
+	assert.Equal(t, uint32(1), m.Imports[0].Type)
+	assert.Equal(t, []uint32{0}, m.Funcs)
+	assert.Equal(t, []byte{0x00, 0x02, 0x01, 0x0b, 0x41, 0x00, 0x0b}, m.Code[0])
+}
+
+func TestRemoveUnusedData(t *testing.T) {
+	// Segment 0 is active, segment 1 passive and unused, segment 2 passive
+	// and dropped by code.
+	wasm := newTestModule().
+		addFuncType().
+		addMemory().
+		addFunction(0, []byte{0xfc, 0x09, 0x02}). // data.drop 2
+		addExport("main", 0).
+		addActiveData(0, []byte("kept")).
+		addPassiveData([]byte("unused")).
+		addPassiveData([]byte("dropped")).
+		build()
+
+	m, result := runPass(t, RemoveUnusedData(), wasm)
+	assert.Equal(t, Result{Total: 3, Removed: 1}, result)
+	require.Len(t, m.Data, 2)
+	assert.Equal(t, []byte("dropped"), m.Data[1].Bytes)
+	assert.Equal(t, []byte{0x00, 0xfc, 0x09, 0x01, 0x0b}, m.Code[0])
+}
+
+func TestStripCustomSections(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x01}).
+		addExport("main", 0).
+		addCustomSection("contractspecv0", []byte{0xDE, 0xAD}).
+		addCustomSection("contractenvmetav0", []byte{0xBE, 0xEF}).
+		addCustomSection("producers", []byte{0x00}).
+		addCustomSection("name", nameSection(map[uint32]string{0: "main"}, 0)).
+		build()
+
+	m, result := runPass(t, StripCustomSections(), wasm)
+	assert.Equal(t, Result{Total: 4, Removed: 2}, result)
+	_, ok := m.Custom("contractspecv0")
+	assert.True(t, ok)
+	_, ok = m.Custom("contractenvmetav0")
+	assert.True(t, ok)
+
+	m, result = runPass(t, StripCustomSections("name"), wasm)
+	assert.Equal(t, 1, result.Removed)
+	_, ok = m.Custom("name")
+	assert.True(t, ok)
+}
+
+func TestStripCustomSections_Prefix(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x01}).
+		addExport("main", 0).
+		addCustomSection(".debug_info", []byte{0x01}).
+		addCustomSection(".debug_line", []byte{0x02}).
+		addCustomSection("producers", []byte{0x00}).
+		build()
+
+	m, result := runPass(t, StripCustomSections(".debug_*"), wasm)
+	assert.Equal(t, Result{Total: 3, Removed: 1}, result)
+	_, ok := m.Custom(".debug_info")
+	assert.True(t, ok)
+	_, ok = m.Custom(".debug_line")
+	assert.True(t, ok)
+}
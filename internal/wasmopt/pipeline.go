@@ -0,0 +1,158 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wasmopt shrinks WASM contracts. A binary is decoded into a Module,
+// a Pipeline of passes removes what the contract cannot use, and the module
+// is re-emitted and validated. Every pass renumbers the references to the
+// entries it removes, the name section included, so that debug names keep
+// describing the right functions.
+package wasmopt
+
+import (
+	"fmt"
+
+	"github.com/dotandev/hintents/internal/wat"
+)
+
+// Pass is one step of a Pipeline. Run mutates the module in place.
+type Pass interface {
+	Name() string
+	Run(m *Module) (Result, error)
+}
+
+// Result counts the entries a pass looked at and removed.
+type Result struct {
+	Total   int
+	Removed int
+}
+
+// PassReport is the outcome of one pass, with the size of the module before
+// and after it.
+type PassReport struct {
+	Name string
+	Result
+	SizeBefore int
+	SizeAfter  int
+}
+
+// Saved returns the number of bytes the pass saved.
+func (r PassReport) Saved() int {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Report summarizes a pipeline run.
+type Report struct {
+	OriginalSize  int
+	OptimizedSize int
+	Passes        []PassReport
+}
+
+// Saved returns the number of bytes the pipeline saved.
+func (r *Report) Saved() int {
+	return r.OriginalSize - r.OptimizedSize
+}
+
+// Pass returns the report of the named pass.
+func (r *Report) Pass(name string) (PassReport, bool) {
+	for _, p := range r.Passes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PassReport{}, false
+}
+
+// Pipeline runs passes in order over a module.
+type Pipeline struct {
+	passes []Pass
+}
+
+// NewPipeline returns a pipeline running passes in order.
+func NewPipeline(passes ...Pass) *Pipeline {
+	return &Pipeline{passes: passes}
+}
+
+// DefaultPipeline returns the pipeline used by erst dce and --optimize.
+// Functions go first, since dead code is what keeps most globals, types and
+// data segments referenced. Custom sections other than SorobanSections, the
+// name section and the DWARF .debug_* sections are stripped, so source
+// mapping keeps working on optimized contracts.
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(
+		EliminateFunctions(),
+		RemoveUnusedGlobals(),
+		RemoveUnusedTypes(),
+		RemoveUnusedData(),
+		StripCustomSections("name", ".debug_*"),
+	)
+}
+
+// Optimize runs the DefaultPipeline over wasm.
+func Optimize(wasm []byte) ([]byte, *Report, error) {
+	return DefaultPipeline().Run(wasm)
+}
+
+// Run decodes wasm, runs every pass and re-emits the module. When no pass
+// removes anything, wasm is returned as is.
+func (p *Pipeline) Run(wasm []byte) ([]byte, *Report, error) {
+	m, err := Parse(wasm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &Report{OriginalSize: len(wasm), OptimizedSize: len(wasm)}
+	size := len(m.Encode())
+	changed := false
+	for _, pass := range p.passes {
+		result, err := pass.Run(m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s pass: %w", pass.Name(), err)
+		}
+		before := size
+		if result.Removed > 0 {
+			size = len(m.Encode())
+			changed = true
+		}
+		report.Passes = append(report.Passes, PassReport{
+			Name:       pass.Name(),
+			Result:     result,
+			SizeBefore: before,
+			SizeAfter:  size,
+		})
+	}
+	if !changed {
+		return wasm, report, nil
+	}
+
+	out := m.Encode()
+	if err := validate(out); err != nil {
+		return nil, nil, err
+	}
+	report.OptimizedSize = len(out)
+	return out, report, nil
+}
+
+// validate checks that an optimized module still decodes and that every
+// index it refers to is in range.
+func validate(wasm []byte) error {
+	if !wat.NewDisassembler(wasm).IsValidWasm() {
+		return fmt.Errorf("optimized module failed validation: bad header")
+	}
+	m, err := Parse(wasm)
+	if err != nil {
+		return fmt.Errorf("optimized module failed validation: %w", err)
+	}
+	bounds := map[space]uint32{
+		funcSpace:   m.ImportedFuncs() + uint32(len(m.Funcs)),
+		globalSpace: m.ImportedGlobals() + uint32(len(m.Globals)),
+		typeSpace:   uint32(len(m.Types)),
+		dataSpace:   uint32(len(m.Data)),
+	}
+	inRange := func(s space, idx uint32) (uint32, bool) {
+		return idx, idx < bounds[s]
+	}
+	if err := m.rewrite(inRange); err != nil {
+		return fmt.Errorf("optimized module failed validation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmopt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addResultType().
+		addMemory().
+		addFunction(0, []byte{0x10, 0x02}).       // func 0: call func 2
+		addFunction(1, []byte{0x23, 0x00}).       // func 1: dead, global.get 0
+		addFunction(0, []byte{0x01}).             // func 2: nop
+		addGlobal([]byte{0x41, 0x2a}).            // only used by func 1
+		addPassiveData([]byte("unused segment")). // never copied
+		addExport("main", 0).
+		addCustomSection("contractspecv0", []byte{0x01}).
+		addCustomSection(".debug_info", []byte{0x02}).
+		addCustomSection("producers", []byte("rustc")).
+		build()
+
+	out, report, err := Optimize(wasm)
+	require.NoError(t, err)
+	assert.Equal(t, len(wasm), report.OriginalSize)
+	assert.Equal(t, len(out), report.OptimizedSize)
+	assert.Less(t, report.OptimizedSize, report.OriginalSize)
+
+	var names []string
+	saved := 0
+	for _, p := range report.Passes {
+		names = append(names, p.Name)
+		assert.Equal(t, 1, p.Removed, p.Name)
+		assert.Positive(t, p.Saved(), p.Name)
+		saved += p.Saved()
+	}
+	assert.Equal(t, []string{"functions", "globals", "types", "data", "custom-sections"}, names)
+	assert.Equal(t, report.Saved(), saved, "canonical input: pass deltas add up")
+
+	functions, ok := report.Pass("functions")
+	require.True(t, ok)
+	assert.Equal(t, 3, functions.Total)
+
+	m, err := Parse(out)
+	require.NoError(t, err)
+	assert.Len(t, m.Code, 2)
+	assert.Empty(t, m.Globals)
+	assert.Len(t, m.Types, 1)
+	assert.Empty(t, m.Data)
+	require.Len(t, m.Customs, 2)
+	assert.Equal(t, "contractspecv0", m.Customs[0].Name)
+	assert.Equal(t, ".debug_info", m.Customs[1].Name, "DWARF is kept for source mapping")
+
+	// Optimizing again is a no-op.
+	again, report, err := Optimize(out)
+	require.NoError(t, err)
+	assert.Equal(t, out, again)
+	assert.Zero(t, report.Saved())
+}
+
+func TestOptimize_Unchanged(t *testing.T) {
+	wasm := newTestModule().build()
+	out, report, err := Optimize(wasm)
+	require.NoError(t, err)
+	assert.Equal(t, wasm, out)
+	for _, p := range report.Passes {
+		assert.Zero(t, p.Removed, p.Name)
+	}
+}
+
+func TestOptimize_Invalid(t *testing.T) {
+	_, _, err := Optimize([]byte{0xFF, 0xFF})
+	assert.ErrorIs(t, err, ErrInvalidModule)
+}
+
+type brokenPass struct{}
+
+func (brokenPass) Name() string { return "broken" }
+
+func (brokenPass) Run(m *Module) (Result, error) {
+	// Drops a function without renumbering the calls to it.
+	m.Funcs, m.Code = m.Funcs[:1], m.Code[:1]
+	return Result{Total: 2, Removed: 1}, nil
+}
+
+func TestPipeline_ValidatesOutput(t *testing.T) {
+	wasm := newTestModule().
+		addFuncType().
+		addFunction(0, []byte{0x10, 0x01}).
+		addFunction(0, []byte{0x01}).
+		addExport("main", 0).
+		build()
+
+	_, _, err := NewPipeline(brokenPass{}).Run(wasm)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed validation")
+}
@@ -511,6 +511,11 @@ func decodeOpcode(opcode byte, rest []byte) (string, string, int) {
 		return "select", "", 0
 
 	default:
+		// Everything else comes from the full opcode table the WAT printer
+		// uses, formatted without module context.
+		if in, err := decodeInstr(append([]byte{opcode}, rest...), 0); err == nil {
+			return in.op.name, (&printer{}).immediates(in), in.size - 1
+		}
 		return fmt.Sprintf("unknown_0x%02x", opcode), "", 0
 	}
 }
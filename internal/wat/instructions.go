@@ -0,0 +1,269 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// immKind says which immediates follow an opcode.
+type immKind int
+
+const (
+	immNone immKind = iota
+	immBlock
+	immElse
+	immEnd
+	immLabel
+	immBrTable
+	immFunc
+	immCallIndirect
+	immLocal
+	immGlobal
+	immTable
+	immMemArg
+	immMemory
+	immI32
+	immI64
+	immF32
+	immF64
+	immSelect
+	immRefNull
+	immMemInit
+	immData
+	immMemCopy
+	immTableInit
+	immElem
+	immTableCopy
+)
+
+type opInfo struct {
+	name string
+	imm  immKind
+}
+
+// opcodes covers the MVP instruction set plus the sign-extension,
+// non-trapping conversion, bulk memory, reference types and tail call
+// proposals. SIMD, threads and exceptions are not decoded.
+var opcodes = func() [256]opInfo {
+	var ops [256]opInfo
+	set := func(code byte, name string, imm immKind) { ops[code] = opInfo{name, imm} }
+
+	set(0x00, "unreachable", immNone)
+	set(0x01, "nop", immNone)
+	set(0x02, "block", immBlock)
+	set(0x03, "loop", immBlock)
+	set(0x04, "if", immBlock)
+	set(0x05, "else", immElse)
+	set(0x0b, "end", immEnd)
+	set(0x0c, "br", immLabel)
+	set(0x0d, "br_if", immLabel)
+	set(0x0e, "br_table", immBrTable)
+	set(0x0f, "return", immNone)
+	set(0x10, "call", immFunc)
+	set(0x11, "call_indirect", immCallIndirect)
+	set(0x12, "return_call", immFunc)
+	set(0x13, "return_call_indirect", immCallIndirect)
+	set(0x1a, "drop", immNone)
+	set(0x1b, "select", immNone)
+	set(0x1c, "select", immSelect)
+	set(0x20, "local.get", immLocal)
+	set(0x21, "local.set", immLocal)
+	set(0x22, "local.tee", immLocal)
+	set(0x23, "global.get", immGlobal)
+	set(0x24, "global.set", immGlobal)
+	set(0x25, "table.get", immTable)
+	set(0x26, "table.set", immTable)
+
+	for i, name := range []string{
+		"i32.load", "i64.load", "f32.load", "f64.load",
+		"i32.load8_s", "i32.load8_u", "i32.load16_s", "i32.load16_u",
+		"i64.load8_s", "i64.load8_u", "i64.load16_s", "i64.load16_u",
+		"i64.load32_s", "i64.load32_u",
+		"i32.store", "i64.store", "f32.store", "f64.store",
+		"i32.store8", "i32.store16", "i64.store8", "i64.store16", "i64.store32",
+	} {
+		set(0x28+byte(i), name, immMemArg)
+	}
+	set(0x3f, "memory.size", immMemory)
+	set(0x40, "memory.grow", immMemory)
+	set(0x41, "i32.const", immI32)
+	set(0x42, "i64.const", immI64)
+	set(0x43, "f32.const", immF32)
+	set(0x44, "f64.const", immF64)
+
+	// 0x45 to 0xc4 are numeric instructions without immediates.
+	for i, name := range []string{
+		"i32.eqz", "i32.eq", "i32.ne", "i32.lt_s", "i32.lt_u", "i32.gt_s",
+		"i32.gt_u", "i32.le_s", "i32.le_u", "i32.ge_s", "i32.ge_u",
+		"i64.eqz", "i64.eq", "i64.ne", "i64.lt_s", "i64.lt_u", "i64.gt_s",
+		"i64.gt_u", "i64.le_s", "i64.le_u", "i64.ge_s", "i64.ge_u",
+		"f32.eq", "f32.ne", "f32.lt", "f32.gt", "f32.le", "f32.ge",
+		"f64.eq", "f64.ne", "f64.lt", "f64.gt", "f64.le", "f64.ge",
+		"i32.clz", "i32.ctz", "i32.popcnt", "i32.add", "i32.sub", "i32.mul",
+		"i32.div_s", "i32.div_u", "i32.rem_s", "i32.rem_u", "i32.and",
+		"i32.or", "i32.xor", "i32.shl", "i32.shr_s", "i32.shr_u",
+		"i32.rotl", "i32.rotr",
+		"i64.clz", "i64.ctz", "i64.popcnt", "i64.add", "i64.sub", "i64.mul",
+		"i64.div_s", "i64.div_u", "i64.rem_s", "i64.rem_u", "i64.and",
+		"i64.or", "i64.xor", "i64.shl", "i64.shr_s", "i64.shr_u",
+		"i64.rotl", "i64.rotr",
+		"f32.abs", "f32.neg", "f32.ceil", "f32.floor", "f32.trunc",
+		"f32.nearest", "f32.sqrt", "f32.add", "f32.sub", "f32.mul",
+		"f32.div", "f32.min", "f32.max", "f32.copysign",
+		"f64.abs", "f64.neg", "f64.ceil", "f64.floor", "f64.trunc",
+		"f64.nearest", "f64.sqrt", "f64.add", "f64.sub", "f64.mul",
+		"f64.div", "f64.min", "f64.max", "f64.copysign",
+		"i32.wrap_i64", "i32.trunc_f32_s", "i32.trunc_f32_u",
+		"i32.trunc_f64_s", "i32.trunc_f64_u", "i64.extend_i32_s",
+		"i64.extend_i32_u", "i64.trunc_f32_s", "i64.trunc_f32_u",
+		"i64.trunc_f64_s", "i64.trunc_f64_u", "f32.convert_i32_s",
+		"f32.convert_i32_u", "f32.convert_i64_s", "f32.convert_i64_u",
+		"f32.demote_f64", "f64.convert_i32_s", "f64.convert_i32_u",
+		"f64.convert_i64_s", "f64.convert_i64_u", "f64.promote_f32",
+		"i32.reinterpret_f32", "i64.reinterpret_f64",
+		"f32.reinterpret_i32", "f64.reinterpret_i64",
+		"i32.extend8_s", "i32.extend16_s", "i64.extend8_s",
+		"i64.extend16_s", "i64.extend32_s",
+	} {
+		set(0x45+byte(i), name, immNone)
+	}
+
+	set(0xd0, "ref.null", immRefNull)
+	set(0xd1, "ref.is_null", immNone)
+	set(0xd2, "ref.func", immFunc)
+	return ops
+}()
+
+// miscOpcodes are the instructions behind the 0xfc prefix, indexed by their
+// sub-opcode.
+var miscOpcodes = []opInfo{
+	{"i32.trunc_sat_f32_s", immNone},
+	{"i32.trunc_sat_f32_u", immNone},
+	{"i32.trunc_sat_f64_s", immNone},
+	{"i32.trunc_sat_f64_u", immNone},
+	{"i64.trunc_sat_f32_s", immNone},
+	{"i64.trunc_sat_f32_u", immNone},
+	{"i64.trunc_sat_f64_s", immNone},
+	{"i64.trunc_sat_f64_u", immNone},
+	{"memory.init", immMemInit},
+	{"data.drop", immData},
+	{"memory.copy", immMemCopy},
+	{"memory.fill", immMemory},
+	{"table.init", immTableInit},
+	{"elem.drop", immElem},
+	{"table.copy", immTableCopy},
+	{"table.grow", immTable},
+	{"table.size", immTable},
+	{"table.fill", immTable},
+}
+
+// instr is one decoded instruction with its immediates.
+type instr struct {
+	op     opInfo
+	offset int
+	size   int
+
+	// idx holds index immediates in binary order: the label, function,
+	// local, global, table, memory, data or element index, the labels and
+	// default of a br_table, or the type and table of call_indirect.
+	idx []uint32
+	// value holds integer constants and the block type, an s33.
+	value int64
+	// bits holds float constants.
+	bits uint64
+	// align is log2 of the alignment of a memory access.
+	align     uint32
+	memOffset uint64
+	// types holds the operand types of a typed select or the heap type
+	// of ref.null.
+	types []byte
+}
+
+// decodeInstr decodes the instruction at data[pos:].
+func decodeInstr(data []byte, pos int) (instr, error) {
+	r := &reader{data: data, pos: pos}
+	code := r.byte()
+	op := opcodes[code]
+	if code == 0xfc {
+		sub := r.u32()
+		op = opInfo{}
+		if int(sub) < len(miscOpcodes) {
+			op = miscOpcodes[sub]
+		}
+		if op.name == "" && r.err == nil {
+			return instr{}, fmt.Errorf("offset %d: unsupported opcode 0xfc %d", pos, sub)
+		}
+	}
+	if r.err != nil {
+		return instr{}, r.err
+	}
+	if op.name == "" {
+		return instr{}, fmt.Errorf("offset %d: unsupported opcode 0x%02x", pos, code)
+	}
+
+	in := instr{op: op, offset: pos}
+	switch op.imm {
+	case immBlock:
+		in.value = r.sleb(33)
+	case immLabel, immFunc, immLocal, immGlobal, immTable, immMemory, immData, immElem:
+		in.idx = []uint32{r.u32()}
+	case immBrTable:
+		for n := uint64(r.u32()) + 1; n > 0 && r.err == nil; n-- {
+			in.idx = append(in.idx, r.u32())
+		}
+	case immCallIndirect, immMemInit, immMemCopy, immTableInit, immTableCopy:
+		in.idx = []uint32{r.u32(), r.u32()}
+	case immMemArg:
+		in.align = r.u32()
+		in.memOffset = r.uleb(64)
+	case immI32:
+		in.value = r.sleb(32)
+	case immI64:
+		in.value = r.sleb(64)
+	case immF32:
+		if b := r.bytes(4); b != nil {
+			in.bits = uint64(binary.LittleEndian.Uint32(b))
+		}
+	case immF64:
+		if b := r.bytes(8); b != nil {
+			in.bits = binary.LittleEndian.Uint64(b)
+		}
+	case immSelect:
+		in.types = r.bytes(uint64(r.u32()))
+	case immRefNull:
+		in.types = []byte{r.byte()}
+	}
+	if r.err != nil {
+		return instr{}, r.err
+	}
+	in.size = r.pos - pos
+	return in, nil
+}
+
+// naturalAlign returns log2 of the natural alignment of a memory access,
+// the alignment WAT leaves implicit.
+func naturalAlign(name string) uint32 {
+	switch {
+	case hasAny(name, "load8", "store8"):
+		return 0
+	case hasAny(name, "load16", "store16"):
+		return 1
+	case hasAny(name, "load32", "store32"), strings.HasPrefix(name, "i32"), strings.HasPrefix(name, "f32"):
+		return 2
+	default:
+		return 3
+	}
+}
+
+func hasAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
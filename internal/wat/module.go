@@ -0,0 +1,559 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wat
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Section IDs not covered by the disassembler, which only needs the code
+// section.
+const (
+	sectionDataCount byte = 12
+	sectionTag       byte = 13
+)
+
+// maxLocals is the limit engines put on the locals of a function; the
+// printer lists every local, so bodies declaring more are rejected.
+const maxLocals = 50000
+
+// External kinds used by imports and exports.
+const (
+	kindFunc   byte = 0
+	kindTable  byte = 1
+	kindMemory byte = 2
+	kindGlobal byte = 3
+	kindTag    byte = 4
+)
+
+// module is a WASM binary decoded for printing. Unlike the optimizer's
+// model, which keeps what it does not rewrite as raw bytes, every section
+// is decoded, and code bodies remember where they sit in the code section
+// so that instructions can be mapped to DWARF line information.
+type module struct {
+	types    []funcType
+	imports  []importEntry
+	funcs    []uint32
+	tables   []tableType
+	memories []limits
+	tags     []uint32
+	globals  []global
+	exports  []export
+	start    *uint32
+	elements []element
+	code     []body
+	data     []dataSegment
+	customs  []customSection
+
+	name      string
+	funcNames map[uint32]string
+}
+
+type funcType struct {
+	params  []byte
+	results []byte
+}
+
+type limits struct {
+	flags byte
+	min   uint64
+	max   uint64
+}
+
+func (l limits) hasMax() bool { return l.flags&0x01 != 0 }
+func (l limits) shared() bool { return l.flags&0x02 != 0 }
+func (l limits) is64() bool   { return l.flags&0x04 != 0 }
+
+type tableType struct {
+	elem byte
+	limits
+}
+
+type globalType struct {
+	valType byte
+	mutable bool
+}
+
+type importEntry struct {
+	module string
+	field  string
+	kind   byte
+	typ    uint32 // function and tag imports
+	table  tableType
+	memory limits
+	global globalType
+}
+
+type global struct {
+	globalType
+	init []byte
+}
+
+type export struct {
+	name  string
+	kind  byte
+	index uint32
+}
+
+type element struct {
+	flags  uint32
+	table  uint32
+	offset []byte
+	typ    byte // element kind or reference type
+	funcs  []uint32
+	exprs  [][]byte
+}
+
+func (e element) passive() bool     { return e.flags&0x01 != 0 && e.flags&0x02 == 0 }
+func (e element) declarative() bool { return e.flags&0x03 == 0x03 }
+func (e element) usesExprs() bool   { return e.flags&0x04 != 0 }
+
+type body struct {
+	locals []localGroup
+	// offset is the position of the first instruction relative to the
+	// start of the code section payload.
+	offset uint64
+	instrs []byte
+}
+
+type localGroup struct {
+	count   uint32
+	valType byte
+}
+
+type dataSegment struct {
+	flags  uint32
+	memory uint32
+	offset []byte
+	bytes  []byte
+}
+
+type customSection struct {
+	name    string
+	payload []byte
+	// after is the ID of the last non-custom section preceding it, or
+	// SectionCustom when it comes first.
+	after byte
+}
+
+// reader decodes the binary format. The first error sticks: later reads
+// return zero values, so decoding code can check r.err once per section.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *reader) done() bool {
+	return r.err != nil || r.pos >= len(r.data)
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("length %d out of bounds", n)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+func (r *reader) uleb(bits uint) uint64 {
+	var result uint64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift+7 > bits && b>>(bits-shift) != 0 {
+			r.fail("integer too large")
+			return 0
+		}
+		result |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+}
+
+func (r *reader) sleb(bits uint) int64 {
+	var result int64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift >= bits {
+			r.fail("integer too large")
+			return 0
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			return result
+		}
+	}
+}
+
+func (r *reader) u32() uint32 { return uint32(r.uleb(32)) }
+
+func (r *reader) name() string {
+	b := r.bytes(uint64(r.u32()))
+	if r.err == nil && !utf8.Valid(b) {
+		r.fail("name is not valid UTF-8")
+	}
+	return string(b)
+}
+
+func (r *reader) limits() limits {
+	l := limits{flags: r.byte()}
+	bits := uint(32)
+	if l.is64() {
+		bits = 64
+	}
+	l.min = r.uleb(bits)
+	if l.hasMax() {
+		l.max = r.uleb(bits)
+	}
+	return l
+}
+
+func (r *reader) tableType() tableType {
+	elem := r.byte()
+	return tableType{elem: elem, limits: r.limits()}
+}
+
+func (r *reader) globalType() globalType {
+	t := globalType{valType: r.byte()}
+	t.mutable = r.byte() == 0x01
+	return t
+}
+
+// expr reads a constant expression up to and including its end opcode.
+func (r *reader) expr() []byte {
+	if r.err != nil {
+		return nil
+	}
+	start := r.pos
+	for depth := 0; ; {
+		in, err := decodeInstr(r.data, r.pos)
+		if err != nil {
+			r.err = err
+			return nil
+		}
+		r.pos += in.size
+		switch in.op.imm {
+		case immBlock:
+			depth++
+		case immEnd:
+			if depth == 0 {
+				return r.data[start:r.pos]
+			}
+			depth--
+		}
+	}
+}
+
+// parseModule decodes every section of a WASM binary.
+func parseModule(wasm []byte) (*module, error) {
+	if !NewDisassembler(wasm).IsValidWasm() {
+		return nil, fmt.Errorf("not a valid WASM module")
+	}
+
+	m := &module{}
+	r := &reader{data: wasm, pos: 8}
+	last := SectionCustom
+	var codeFuncs int
+	for !r.done() {
+		id := r.byte()
+		size := r.u32()
+		payload := r.bytes(uint64(size))
+		if r.err != nil {
+			break
+		}
+		sr := &reader{data: payload}
+		switch id {
+		case SectionCustom:
+			c := customSection{name: sr.name(), after: last}
+			c.payload = payload[sr.pos:]
+			m.customs = append(m.customs, c)
+		case SectionType:
+			m.readTypes(sr)
+		case SectionImport:
+			m.readImports(sr)
+		case SectionFunction:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				m.funcs = append(m.funcs, sr.u32())
+			}
+		case SectionTable:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				m.tables = append(m.tables, sr.tableType())
+			}
+		case SectionMemory:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				m.memories = append(m.memories, sr.limits())
+			}
+		case sectionTag:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				sr.byte() // attribute, always 0 (exception)
+				m.tags = append(m.tags, sr.u32())
+			}
+		case SectionGlobal:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				g := global{globalType: sr.globalType()}
+				g.init = sr.expr()
+				m.globals = append(m.globals, g)
+			}
+		case SectionExport:
+			for n := sr.u32(); n > 0 && sr.err == nil; n-- {
+				e := export{name: sr.name(), kind: sr.byte()}
+				e.index = sr.u32()
+				m.exports = append(m.exports, e)
+			}
+		case SectionStart:
+			start := sr.u32()
+			m.start = &start
+		case SectionElement:
+			m.readElements(sr)
+		case sectionDataCount:
+			sr.u32()
+		case SectionCode:
+			// Body offsets are relative to the section payload, which is
+			// what DWARF line programs for WASM address.
+			codeFuncs = m.readCode(sr)
+		case SectionData:
+			m.readData(sr)
+		default:
+			sr.fail("unknown section %d", id)
+		}
+		if sr.err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, sr.err)
+		}
+		if id != SectionCustom {
+			if sr.pos != len(payload) {
+				return nil, fmt.Errorf("section %d: %d trailing bytes", id, len(payload)-sr.pos)
+			}
+			last = id
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if codeFuncs != len(m.funcs) {
+		return nil, fmt.Errorf("function section declares %d functions, code section has %d", len(m.funcs), codeFuncs)
+	}
+
+	if c, ok := m.custom("name"); ok {
+		m.readNames(c.payload)
+	}
+	return m, nil
+}
+
+func (m *module) readTypes(r *reader) {
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		if form := r.byte(); form != 0x60 {
+			r.fail("unsupported type form 0x%02x", form)
+			return
+		}
+		var t funcType
+		t.params = r.bytes(uint64(r.u32()))
+		t.results = r.bytes(uint64(r.u32()))
+		m.types = append(m.types, t)
+	}
+}
+
+func (m *module) readImports(r *reader) {
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		imp := importEntry{module: r.name(), field: r.name(), kind: r.byte()}
+		switch imp.kind {
+		case kindFunc:
+			imp.typ = r.u32()
+		case kindTable:
+			imp.table = r.tableType()
+		case kindMemory:
+			imp.memory = r.limits()
+		case kindGlobal:
+			imp.global = r.globalType()
+		case kindTag:
+			r.byte()
+			imp.typ = r.u32()
+		default:
+			r.fail("unknown import kind %d", imp.kind)
+		}
+		m.imports = append(m.imports, imp)
+	}
+}
+
+func (m *module) readElements(r *reader) {
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		e := element{flags: r.u32(), typ: 0x70}
+		if e.flags > 7 {
+			r.fail("unsupported element segment flags %d", e.flags)
+			return
+		}
+		if e.flags&0x02 != 0 && e.flags&0x01 == 0 {
+			e.table = r.u32()
+		}
+		if e.flags&0x01 == 0 {
+			e.offset = r.expr()
+		}
+		if e.flags&0x03 != 0 {
+			// Element kind for function indices, reference type for
+			// expressions; segments without it hold funcrefs.
+			e.typ = r.byte()
+			if !e.usesExprs() {
+				e.typ = 0x70
+			}
+		}
+		for count := r.u32(); count > 0 && r.err == nil; count-- {
+			if e.usesExprs() {
+				e.exprs = append(e.exprs, r.expr())
+			} else {
+				e.funcs = append(e.funcs, r.u32())
+			}
+		}
+		m.elements = append(m.elements, e)
+	}
+}
+
+func (m *module) readCode(r *reader) int {
+	count := 0
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		size := r.u32()
+		end := r.pos + int(size)
+		if r.err != nil || uint64(size) > uint64(len(r.data)-r.pos) {
+			r.fail("function body out of bounds")
+			return count
+		}
+		var b body
+		var total uint64
+		for groups := r.u32(); groups > 0 && r.err == nil; groups-- {
+			g := localGroup{count: r.u32(), valType: r.byte()}
+			if total += uint64(g.count); total > maxLocals {
+				r.fail("too many locals")
+				return count
+			}
+			b.locals = append(b.locals, g)
+		}
+		b.offset = uint64(r.pos)
+		if r.pos > end {
+			r.fail("function body out of bounds")
+			return count
+		}
+		b.instrs = r.data[r.pos:end]
+		r.pos = end
+		m.code = append(m.code, b)
+		count++
+	}
+	return count
+}
+
+func (m *module) readData(r *reader) {
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		d := dataSegment{flags: r.u32()}
+		switch d.flags {
+		case 0:
+			d.offset = r.expr()
+		case 1:
+		case 2:
+			d.memory = r.u32()
+			d.offset = r.expr()
+		default:
+			r.fail("unsupported data segment flags %d", d.flags)
+			return
+		}
+		d.bytes = r.bytes(uint64(r.u32()))
+		m.data = append(m.data, d)
+	}
+}
+
+// readNames takes the module name and function names from the name section.
+// A malformed name section is ignored; it is debug information only.
+func (m *module) readNames(payload []byte) {
+	names := make(map[uint32]string)
+	var moduleName string
+	r := &reader{data: payload}
+	for !r.done() {
+		id := r.byte()
+		sub := &reader{data: r.bytes(uint64(r.u32()))}
+		switch id {
+		case 0:
+			moduleName = sub.name()
+		case 1:
+			for n := sub.u32(); n > 0 && sub.err == nil; n-- {
+				idx := sub.u32()
+				names[idx] = sub.name()
+			}
+		}
+		if sub.err != nil {
+			return
+		}
+	}
+	if r.err != nil {
+		return
+	}
+	m.name, m.funcNames = moduleName, names
+}
+
+func (m *module) custom(name string) (customSection, bool) {
+	for _, c := range m.customs {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return customSection{}, false
+}
+
+// importedFuncs returns the imports in the function index space, in order.
+func (m *module) importedFuncs() []importEntry {
+	var funcs []importEntry
+	for _, imp := range m.imports {
+		if imp.kind == kindFunc {
+			funcs = append(funcs, imp)
+		}
+	}
+	return funcs
+}
+
+// typeOf returns the type index of function idx, imported or defined.
+func (m *module) typeOf(idx uint32) (uint32, bool) {
+	imported := m.importedFuncs()
+	if int(idx) < len(imported) {
+		return imported[idx].typ, true
+	}
+	def := int(idx) - len(imported)
+	if def < len(m.funcs) {
+		return m.funcs[def], true
+	}
+	return 0, false
+}
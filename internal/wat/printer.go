@@ -0,0 +1,672 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wat
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dotandev/hintents/internal/demangle"
+)
+
+// Options controls how Disassembler.WAT prints a module.
+type Options struct {
+	// Folded prints blocks, loops and ifs as s-expressions instead of flat
+	// block/loop/if ... end sequences.
+	Folded bool
+	// Symbols names the functions the name section leaves unnamed. Names
+	// from either source are demangled.
+	Symbols demangle.SymbolTable
+	// Lines maps an offset relative to the start of the code section
+	// payload, the addresses DWARF line tables for WASM use, to a source
+	// location. When set, instructions are annotated with the source line
+	// they were compiled from.
+	Lines func(offset uint64) (file string, line int, ok bool)
+}
+
+// WAT decodes the whole module and renders it in the WebAssembly text
+// format: types, imports, functions, tables, memories, globals, exports,
+// the start function, element and data segments, and custom sections as
+// @custom annotations. Imports of Soroban host functions are named after
+// the host function, other functions after the name section or
+// opts.Symbols. The output assembles back to an equivalent module.
+func (d *Disassembler) WAT(opts Options) (string, error) {
+	m, err := parseModule(d.data)
+	if err != nil {
+		return "", err
+	}
+	p := &printer{m: m, opts: opts}
+	p.nameFunctions()
+	if err := p.module(); err != nil {
+		return "", err
+	}
+	return p.b.String(), nil
+}
+
+// printer renders a decoded module. With a nil module it can still format
+// the immediates of a single instruction, which is how decodeOpcode uses it.
+type printer struct {
+	m       *module
+	opts    Options
+	b       strings.Builder
+	indent  int
+	funcIDs map[uint32]string
+
+	// depth is the number of enclosing blocks in the function being
+	// printed, frames the instruction that opened each of them.
+	depth  int
+	frames []string
+
+	file string
+	line int
+}
+
+func (p *printer) linef(format string, args ...any) {
+	p.b.WriteString(strings.Repeat("  ", p.indent))
+	fmt.Fprintf(&p.b, format, args...)
+	p.b.WriteByte('\n')
+}
+
+// nameFunctions assigns a unique WAT identifier to every function a name
+// can be found for.
+func (p *printer) nameFunctions() {
+	p.funcIDs = make(map[uint32]string)
+	used := make(map[string]bool)
+	imported := p.m.importedFuncs()
+	for i := 0; i < len(imported)+len(p.m.funcs); i++ {
+		idx := uint32(i)
+		var name string
+		if i < len(imported) {
+			name, _ = HostFunctionName(imported[i].module, imported[i].field)
+		}
+		if name == "" {
+			name = p.m.funcNames[idx]
+		}
+		if name == "" {
+			name = p.opts.Symbols[idx]
+		}
+		id := identifier(demangle.DemangleSymbol(name))
+		if id == "" {
+			continue
+		}
+		if used[id] {
+			id = fmt.Sprintf("%s.%d", id, idx)
+		}
+		used[id] = true
+		p.funcIDs[idx] = id
+	}
+}
+
+// identifier turns name into a WAT identifier, replacing the characters
+// identifiers cannot contain. It returns "" for an empty name.
+func identifier(name string) string {
+	if name == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, c := range name {
+		if c < 0x7f && c > ' ' && !strings.ContainsRune(`"(),;[]{}`, c) {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func (p *printer) funcRef(idx uint32) string {
+	if id, ok := p.funcIDs[idx]; ok {
+		return id
+	}
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+// funcDecl returns the identifier and index comment opening a function.
+func (p *printer) funcDecl(idx uint32) string {
+	if id, ok := p.funcIDs[idx]; ok {
+		return fmt.Sprintf("%s (;%d;)", id, idx)
+	}
+	return fmt.Sprintf("(;%d;)", idx)
+}
+
+func (p *printer) module() error {
+	m := p.m
+	if id := identifier(m.name); id != "" {
+		p.linef("(module %s", id)
+	} else {
+		p.linef("(module")
+	}
+	p.indent++
+
+	for i, t := range m.types {
+		p.linef("(type (;%d;) (func%s))", i, signature(t))
+	}
+
+	var funcs, tables, memories, globals, tags uint32
+	for _, imp := range m.imports {
+		var desc string
+		switch imp.kind {
+		case kindFunc:
+			desc = fmt.Sprintf("(func %s %s)", p.funcDecl(funcs), p.typeUse(imp.typ))
+			funcs++
+		case kindTable:
+			desc = fmt.Sprintf("(table (;%d;) %s)", tables, formatTableType(imp.table))
+			tables++
+		case kindMemory:
+			desc = fmt.Sprintf("(memory (;%d;) %s)", memories, formatLimits(imp.memory))
+			memories++
+		case kindGlobal:
+			desc = fmt.Sprintf("(global (;%d;) %s)", globals, formatGlobalType(imp.global))
+			globals++
+		case kindTag:
+			desc = fmt.Sprintf("(tag (;%d;) (type %d))", tags, imp.typ)
+			tags++
+		}
+		p.linef("(import %s %s %s)", quote([]byte(imp.module)), quote([]byte(imp.field)), desc)
+	}
+
+	for i, typ := range m.funcs {
+		if err := p.function(funcs+uint32(i), typ, m.code[i]); err != nil {
+			return err
+		}
+	}
+	for i, t := range m.tables {
+		p.linef("(table (;%d;) %s)", tables+uint32(i), formatTableType(t))
+	}
+	for i, l := range m.memories {
+		p.linef("(memory (;%d;) %s)", memories+uint32(i), formatLimits(l))
+	}
+	for i, typ := range m.tags {
+		p.linef("(tag (;%d;) (type %d))", tags+uint32(i), typ)
+	}
+	for i, g := range m.globals {
+		init, err := p.constExpr(g.init)
+		if err != nil {
+			return err
+		}
+		p.linef("(global (;%d;) %s %s)", globals+uint32(i), formatGlobalType(g.globalType), init)
+	}
+	for _, e := range m.exports {
+		ref := strconv.FormatUint(uint64(e.index), 10)
+		if e.kind == kindFunc {
+			ref = p.funcRef(e.index)
+		}
+		p.linef("(export %s (%s %s))", quote([]byte(e.name)), kindName(e.kind), ref)
+	}
+	if m.start != nil {
+		p.linef("(start %s)", p.funcRef(*m.start))
+	}
+	for i, e := range m.elements {
+		if err := p.element(i, e); err != nil {
+			return err
+		}
+	}
+	for i, d := range m.data {
+		if err := p.dataSegment(i, d); err != nil {
+			return err
+		}
+	}
+	for _, c := range m.customs {
+		if c.name == "name" {
+			// Carried by the function identifiers.
+			continue
+		}
+		p.linef("(@custom %s %s %s)", quote([]byte(c.name)), placement(c.after), quote(c.payload))
+	}
+
+	p.indent--
+	p.linef(")")
+	return nil
+}
+
+func (p *printer) typeUse(typ uint32) string {
+	use := fmt.Sprintf("(type %d)", typ)
+	if int(typ) < len(p.m.types) {
+		use += signature(p.m.types[typ])
+	}
+	return use
+}
+
+func (p *printer) function(idx, typ uint32, b body) error {
+	p.linef("(func %s %s", p.funcDecl(idx), p.typeUse(typ))
+	p.indent++
+	var locals []string
+	for _, g := range b.locals {
+		for n := uint32(0); n < g.count; n++ {
+			locals = append(locals, valType(g.valType))
+		}
+	}
+	if len(locals) > 0 {
+		p.linef("(local %s)", strings.Join(locals, " "))
+	}
+
+	p.depth, p.frames = 0, p.frames[:0]
+	p.file, p.line = "", 0
+	for pos := 0; pos < len(b.instrs); {
+		in, err := decodeInstr(b.instrs, pos)
+		if err != nil {
+			return fmt.Errorf("function %d: %w", idx, err)
+		}
+		pos += in.size
+		if in.op.imm == immEnd && p.depth == 0 {
+			if pos != len(b.instrs) {
+				return fmt.Errorf("function %d: instructions after the end of the body", idx)
+			}
+			p.indent--
+			p.linef(")")
+			return nil
+		}
+		p.sourceLine(b.offset + uint64(in.offset))
+		p.instruction(in)
+	}
+	return fmt.Errorf("function %d: body is missing its end", idx)
+}
+
+// sourceLine prints the source location of the instruction at offset when
+// it differs from the previous instruction's.
+func (p *printer) sourceLine(offset uint64) {
+	if p.opts.Lines == nil {
+		return
+	}
+	file, line, ok := p.opts.Lines(offset)
+	if !ok || (file == p.file && line == p.line) {
+		return
+	}
+	p.file, p.line = file, line
+	p.linef(";; %s:%d", file, line)
+}
+
+// instruction prints one instruction of a function body, opening and
+// closing blocks as needed.
+func (p *printer) instruction(in instr) {
+	imm := p.immediates(in)
+	switch in.op.imm {
+	case immBlock:
+		p.depth++
+		p.frames = append(p.frames, in.op.name)
+		label := fmt.Sprintf("(;@%d;)", p.depth)
+		if imm != "" {
+			label += " " + imm
+		}
+		if p.opts.Folded {
+			p.linef("(%s %s", in.op.name, label)
+			if in.op.name == "if" {
+				p.indent++
+				p.linef("(then")
+			}
+		} else {
+			p.linef("%s %s", in.op.name, label)
+		}
+		p.indent++
+	case immElse:
+		p.indent--
+		if p.opts.Folded {
+			p.linef(")")
+			p.linef("(else")
+		} else {
+			p.linef("else")
+		}
+		p.indent++
+	case immEnd:
+		frame := p.frames[len(p.frames)-1]
+		p.frames = p.frames[:len(p.frames)-1]
+		p.depth--
+		p.indent--
+		if !p.opts.Folded {
+			p.linef("end")
+			return
+		}
+		p.linef(")")
+		if frame == "if" {
+			p.indent--
+			p.linef(")")
+		}
+	default:
+		if imm != "" {
+			p.linef("%s %s", in.op.name, imm)
+		} else {
+			p.linef("%s", in.op.name)
+		}
+	}
+}
+
+// immediates formats the immediates of an instruction.
+func (p *printer) immediates(in instr) string {
+	switch in.op.imm {
+	case immBlock:
+		return p.blockType(in.value)
+	case immLabel:
+		return p.label(in.idx[0])
+	case immBrTable:
+		labels := make([]string, len(in.idx))
+		for i, l := range in.idx {
+			labels[i] = p.label(l)
+		}
+		return strings.Join(labels, " ")
+	case immFunc:
+		return p.funcRef(in.idx[0])
+	case immCallIndirect:
+		use := fmt.Sprintf("(type %d)", in.idx[0])
+		if in.idx[1] != 0 {
+			use = fmt.Sprintf("%d %s", in.idx[1], use)
+		}
+		return use
+	case immLocal, immGlobal, immTable, immData, immElem:
+		return strconv.FormatUint(uint64(in.idx[0]), 10)
+	case immMemory:
+		if in.idx[0] != 0 {
+			return strconv.FormatUint(uint64(in.idx[0]), 10)
+		}
+	case immMemArg:
+		var parts []string
+		if in.memOffset != 0 {
+			parts = append(parts, fmt.Sprintf("offset=%d", in.memOffset))
+		}
+		if in.align != naturalAlign(in.op.name) {
+			parts = append(parts, fmt.Sprintf("align=%d", uint64(1)<<in.align))
+		}
+		return strings.Join(parts, " ")
+	case immI32:
+		return strconv.FormatInt(int64(int32(in.value)), 10)
+	case immI64:
+		return strconv.FormatInt(in.value, 10)
+	case immF32:
+		return formatF32(uint32(in.bits))
+	case immF64:
+		return formatF64(in.bits)
+	case immSelect:
+		types := make([]string, len(in.types))
+		for i, t := range in.types {
+			types[i] = valType(t)
+		}
+		return "(result " + strings.Join(types, " ") + ")"
+	case immRefNull:
+		if in.types[0] == 0x6f {
+			return "extern"
+		}
+		return "func"
+	case immMemInit, immTableInit:
+		// Binary order is segment then memory or table; text order is the
+		// reverse, with a zero memory or table left implicit.
+		if in.idx[1] != 0 {
+			return fmt.Sprintf("%d %d", in.idx[1], in.idx[0])
+		}
+		return strconv.FormatUint(uint64(in.idx[0]), 10)
+	case immMemCopy, immTableCopy:
+		if in.idx[0] != 0 || in.idx[1] != 0 {
+			return fmt.Sprintf("%d %d", in.idx[0], in.idx[1])
+		}
+	}
+	return ""
+}
+
+// label formats a branch depth with a comment naming the block it targets,
+// @0 being the function body.
+func (p *printer) label(depth uint32) string {
+	if int(depth) > p.depth {
+		return strconv.FormatUint(uint64(depth), 10)
+	}
+	return fmt.Sprintf("%d (;@%d;)", depth, p.depth-int(depth))
+}
+
+func (p *printer) blockType(bt int64) string {
+	switch {
+	case bt == -64: // 0x40, no result
+		return ""
+	case bt < 0:
+		return "(result " + valType(byte(bt&0x7f)) + ")"
+	case p.m != nil && bt < int64(len(p.m.types)):
+		return p.typeUse(uint32(bt))
+	default:
+		return fmt.Sprintf("(type %d)", bt)
+	}
+}
+
+// constExpr formats a constant expression as a sequence of folded
+// instructions.
+func (p *printer) constExpr(expr []byte) (string, error) {
+	var parts []string
+	for pos := 0; pos < len(expr); {
+		in, err := decodeInstr(expr, pos)
+		if err != nil {
+			return "", err
+		}
+		pos += in.size
+		if in.op.imm == immEnd {
+			break
+		}
+		if imm := p.immediates(in); imm != "" {
+			parts = append(parts, fmt.Sprintf("(%s %s)", in.op.name, imm))
+		} else {
+			parts = append(parts, fmt.Sprintf("(%s)", in.op.name))
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// offsetExpr formats the offset of an active segment, abbreviated when it
+// is a single instruction.
+func (p *printer) offsetExpr(expr []byte) (string, error) {
+	text, err := p.constExpr(expr)
+	if err != nil || strings.Count(text, "(") == 1 {
+		return text, err
+	}
+	return "(offset " + text + ")", nil
+}
+
+func (p *printer) element(i int, e element) error {
+	parts := []string{fmt.Sprintf("(;%d;)", i)}
+	if e.declarative() {
+		parts = append(parts, "declare")
+	}
+	if !e.passive() && !e.declarative() {
+		if e.flags&0x02 != 0 {
+			parts = append(parts, fmt.Sprintf("(table %d)", e.table))
+		}
+		offset, err := p.offsetExpr(e.offset)
+		if err != nil {
+			return fmt.Errorf("element segment %d: %w", i, err)
+		}
+		parts = append(parts, offset)
+	}
+	if e.usesExprs() {
+		parts = append(parts, valType(e.typ))
+		for _, expr := range e.exprs {
+			item, err := p.constExpr(expr)
+			if err != nil {
+				return fmt.Errorf("element segment %d: %w", i, err)
+			}
+			parts = append(parts, "(item "+item+")")
+		}
+	} else {
+		parts = append(parts, "func")
+		for _, idx := range e.funcs {
+			parts = append(parts, p.funcRef(idx))
+		}
+	}
+	p.linef("(elem %s)", strings.Join(parts, " "))
+	return nil
+}
+
+func (p *printer) dataSegment(i int, d dataSegment) error {
+	parts := []string{fmt.Sprintf("(;%d;)", i)}
+	if d.flags == 2 {
+		parts = append(parts, fmt.Sprintf("(memory %d)", d.memory))
+	}
+	if d.flags != 1 {
+		offset, err := p.offsetExpr(d.offset)
+		if err != nil {
+			return fmt.Errorf("data segment %d: %w", i, err)
+		}
+		parts = append(parts, offset)
+	}
+	parts = append(parts, quote(d.bytes))
+	p.linef("(data %s)", strings.Join(parts, " "))
+	return nil
+}
+
+func signature(t funcType) string {
+	var s string
+	if len(t.params) > 0 {
+		s += " (param " + valTypes(t.params) + ")"
+	}
+	if len(t.results) > 0 {
+		s += " (result " + valTypes(t.results) + ")"
+	}
+	return s
+}
+
+func valTypes(types []byte) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = valType(t)
+	}
+	return strings.Join(names, " ")
+}
+
+func valType(t byte) string {
+	switch t {
+	case 0x7f:
+		return "i32"
+	case 0x7e:
+		return "i64"
+	case 0x7d:
+		return "f32"
+	case 0x7c:
+		return "f64"
+	case 0x7b:
+		return "v128"
+	case 0x70:
+		return "funcref"
+	case 0x6f:
+		return "externref"
+	default:
+		return fmt.Sprintf("(;unknown type 0x%02x;)", t)
+	}
+}
+
+func formatLimits(l limits) string {
+	s := strconv.FormatUint(l.min, 10)
+	if l.is64() {
+		s = "i64 " + s
+	}
+	if l.hasMax() {
+		s += " " + strconv.FormatUint(l.max, 10)
+	}
+	if l.shared() {
+		s += " shared"
+	}
+	return s
+}
+
+func formatTableType(t tableType) string {
+	return formatLimits(t.limits) + " " + valType(t.elem)
+}
+
+func formatGlobalType(t globalType) string {
+	if t.mutable {
+		return "(mut " + valType(t.valType) + ")"
+	}
+	return valType(t.valType)
+}
+
+func kindName(kind byte) string {
+	switch kind {
+	case kindFunc:
+		return "func"
+	case kindTable:
+		return "table"
+	case kindMemory:
+		return "memory"
+	case kindGlobal:
+		return "global"
+	default:
+		return "tag"
+	}
+}
+
+// placement formats where a custom section goes relative to the known
+// sections, in @custom annotation syntax.
+func placement(after byte) string {
+	names := map[byte]string{
+		SectionType:      "type",
+		SectionImport:    "import",
+		SectionFunction:  "func",
+		SectionTable:     "table",
+		SectionMemory:    "memory",
+		SectionGlobal:    "global",
+		SectionExport:    "export",
+		SectionStart:     "start",
+		SectionElement:   "elem",
+		SectionCode:      "code",
+		SectionData:      "data",
+		sectionDataCount: "datacount",
+		sectionTag:       "tag",
+	}
+	if name, ok := names[after]; ok {
+		return "(after " + name + ")"
+	}
+	return "(before first)"
+}
+
+// quote formats bytes as a WAT string literal, escaping everything but
+// printable ASCII.
+func quote(data []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range data {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "\\%02x", c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func formatF32(bits uint32) string {
+	f := float64(math.Float32frombits(bits))
+	if math.IsNaN(f) {
+		return formatNaN(bits>>31 != 0, uint64(bits&0x7fffff), 0x400000)
+	}
+	return formatFloat(f, 32)
+}
+
+func formatF64(bits uint64) string {
+	f := math.Float64frombits(bits)
+	if math.IsNaN(f) {
+		return formatNaN(bits>>63 != 0, bits&0xfffffffffffff, 0x8000000000000)
+	}
+	return formatFloat(f, 64)
+}
+
+// formatFloat prints the shortest decimal that parses back to f.
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+func formatNaN(negative bool, payload, canonical uint64) string {
+	s := "nan"
+	if payload != canonical {
+		s = fmt.Sprintf("nan:0x%x", payload)
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dotandev/hintents/internal/demangle"
+)
+
+// =============================================================================
+// Test module builder
+// =============================================================================
+
+func wasmSection(id byte, payload ...byte) []byte {
+	out := append([]byte{id}, encodeULEB128(uint64(len(payload)))...)
+	return append(out, payload...)
+}
+
+func wasmVec(items ...[]byte) []byte {
+	out := encodeULEB128(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func wasmName(s string) []byte {
+	return append(encodeULEB128(uint64(len(s))), s...)
+}
+
+func wasmBody(locals []byte, instrs ...byte) []byte {
+	b := append(append([]byte{}, locals...), instrs...)
+	return append(encodeULEB128(uint64(len(b))), b...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// buildContractWasm builds a small contract-like module: a Soroban host
+// import, two functions with nested control flow, memory, a global, exports,
+// a data segment, a contract spec section and, optionally, a name section.
+func buildContractWasm(names map[uint32]string) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(SectionType, wasmVec(
+		[]byte{0x60, 0x01, 0x7e, 0x01, 0x7e}, // (i64) -> i64
+		[]byte{0x60, 0x00, 0x00},             // () -> ()
+	)...)...)
+	module = append(module, wasmSection(SectionImport, wasmVec(
+		concat(wasmName("a"), wasmName("0"), []byte{kindFunc, 0x00}),
+	)...)...)
+	module = append(module, wasmSection(SectionFunction, wasmVec([]byte{0x00}, []byte{0x01})...)...)
+	module = append(module, wasmSection(SectionMemory, wasmVec([]byte{0x00, 0x11})...)...)
+	module = append(module, wasmSection(SectionGlobal, wasmVec(
+		[]byte{0x7f, 0x01, 0x41, 0x80, 0x80, 0xc0, 0x00, 0x0b}, // (mut i32) (i32.const 1048576)
+	)...)...)
+	module = append(module, wasmSection(SectionExport, wasmVec(
+		concat(wasmName("check"), []byte{kindFunc, 0x01}),
+		concat(wasmName("memory"), []byte{kindMemory, 0x00}),
+	)...)...)
+	module = append(module, wasmSection(SectionCode, wasmVec(
+		wasmBody([]byte{0x01, 0x01, 0x7f}, // (local i32)
+			0x02, 0x40, // block
+			0x20, 0x01, // local.get 1
+			0x0d, 0x00, // br_if 0
+			0x20, 0x00, // local.get 0
+			0x10, 0x00, // call 0
+			0x1a,       // drop
+			0x0b,       // end
+			0x20, 0x00, // local.get 0
+			0x0b,
+		),
+		wasmBody([]byte{0x00},
+			0x41, 0x00, // i32.const 0
+			0x04, 0x40, // if
+			0x01, // nop
+			0x05, // else
+			0x00, // unreachable
+			0x0b, // end
+			0x0b,
+		),
+	)...)...)
+	module = append(module, wasmSection(SectionData, wasmVec(
+		concat([]byte{0x00, 0x41, 0x80, 0x80, 0xc0, 0x00, 0x0b}, wasmName("hi\x00")),
+	)...)...)
+	if names != nil {
+		var entries [][]byte
+		for idx := uint32(0); idx < 3; idx++ {
+			if name, ok := names[idx]; ok {
+				entries = append(entries, concat(encodeULEB128(uint64(idx)), wasmName(name)))
+			}
+		}
+		sub := wasmVec(entries...)
+		payload := concat(wasmName("name"), []byte{0x01}, encodeULEB128(uint64(len(sub))), sub)
+		module = append(module, wasmSection(SectionCustom, payload...)...)
+	}
+	module = append(module, wasmSection(SectionCustom, concat(wasmName("contractspecv0"), []byte{0x00, 0x01})...)...)
+	return module
+}
+
+// =============================================================================
+// WAT Tests
+// =============================================================================
+
+func TestWAT_Module(t *testing.T) {
+	wasm := buildContractWasm(map[uint32]string{1: "_ZN8contract5check17h0123456789abcdefE"})
+
+	got, err := NewDisassembler(wasm).WAT(Options{})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+
+	want := `(module
+  (type (;0;) (func (param i64) (result i64)))
+  (type (;1;) (func))
+  (import "a" "0" (func $require_auth (;0;) (type 0) (param i64) (result i64)))
+  (func $contract::check (;1;) (type 0) (param i64) (result i64)
+    (local i32)
+    block (;@1;)
+      local.get 1
+      br_if 0 (;@1;)
+      local.get 0
+      call $require_auth
+      drop
+    end
+    local.get 0
+  )
+  (func (;2;) (type 1)
+    i32.const 0
+    if (;@1;)
+      nop
+    else
+      unreachable
+    end
+  )
+  (memory (;0;) 17)
+  (global (;0;) (mut i32) (i32.const 1048576))
+  (export "check" (func $contract::check))
+  (export "memory" (memory 0))
+  (data (;0;) (i32.const 1048576) "hi\00")
+  (@custom "contractspecv0" (after data) "\00\01")
+)
+`
+	if got != want {
+		t.Errorf("WAT output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWAT_Folded(t *testing.T) {
+	got, err := NewDisassembler(buildContractWasm(nil)).WAT(Options{Folded: true})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+
+	want := `  (func (;2;) (type 1)
+    i32.const 0
+    (if (;@1;)
+      (then
+        nop
+      )
+      (else
+        unreachable
+      )
+    )
+  )
+`
+	if !strings.Contains(got, want) {
+		t.Errorf("folded if not found in:\n%s", got)
+	}
+	if !strings.Contains(got, "    (block (;@1;)\n      local.get 1\n") {
+		t.Errorf("folded block not found in:\n%s", got)
+	}
+}
+
+func TestWAT_Symbols(t *testing.T) {
+	// The name section wins over the symbol table, which only fills gaps.
+	wasm := buildContractWasm(map[uint32]string{1: "check"})
+	symbols := demangle.SymbolTable{
+		1: "ignored",
+		2: "_ZN8contract6helper17h0123456789abcdefE",
+	}
+
+	got, err := NewDisassembler(wasm).WAT(Options{Symbols: symbols})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+	for _, want := range []string{"(func $check (;1;)", "(func $contract::helper (;2;)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ignored") {
+		t.Errorf("symbol table should not override the name section:\n%s", got)
+	}
+}
+
+func TestWAT_DuplicateNames(t *testing.T) {
+	wasm := buildContractWasm(map[uint32]string{1: "f", 2: "f"})
+	got, err := NewDisassembler(wasm).WAT(Options{})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+	if !strings.Contains(got, "(func $f (;1;)") || !strings.Contains(got, "(func $f.2 (;2;)") {
+		t.Errorf("expected unique identifiers in:\n%s", got)
+	}
+}
+
+func TestWAT_SourceLines(t *testing.T) {
+	var offsets []uint64
+	lines := func(offset uint64) (string, int, bool) {
+		offsets = append(offsets, offset)
+		if offset < 10 {
+			return "src/lib.rs", 12, true
+		}
+		return "src/lib.rs", 13, true
+	}
+
+	got, err := NewDisassembler(buildContractWasm(nil)).WAT(Options{Lines: lines})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+
+	// Code section payload: function count, body size, one local group.
+	if len(offsets) == 0 || offsets[0] != 5 {
+		t.Fatalf("first instruction should be at code offset 5, got %v", offsets)
+	}
+	if n := strings.Count(got, ";; src/lib.rs:12\n"); n != 1 {
+		t.Errorf("expected one annotation for line 12, got %d:\n%s", n, got)
+	}
+	// Line 13 starts inside the first function and again in the second.
+	if n := strings.Count(got, ";; src/lib.rs:13\n"); n != 2 {
+		t.Errorf("expected two annotations for line 13, got %d:\n%s", n, got)
+	}
+}
+
+func TestWAT_Segments(t *testing.T) {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(SectionType, wasmVec([]byte{0x60, 0x00, 0x00})...)...)
+	module = append(module, wasmSection(SectionFunction, wasmVec([]byte{0x00})...)...)
+	module = append(module, wasmSection(SectionTable, wasmVec([]byte{0x70, 0x01, 0x01, 0x02})...)...)
+	module = append(module, wasmSection(SectionStart, 0x00)...)
+	module = append(module, wasmSection(SectionElement, wasmVec(
+		concat([]byte{0x00, 0x41, 0x01, 0x0b}, wasmVec([]byte{0x00})), // active
+		concat([]byte{0x01, 0x00}, wasmVec([]byte{0x00})),             // passive
+	)...)...)
+	module = append(module, wasmSection(SectionCode, wasmVec(wasmBody([]byte{0x00},
+		0xfc, 0x0d, 0x01, // elem.drop 1
+		0x43, 0x00, 0x00, 0xc0, 0x7f, // f32.const nan
+		0x1a,                                                 // drop
+		0x44, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f, // f64.const 1.5
+		0x1a,                         // drop
+		0x28, 0x00, 0x08, 0x1a, 0x0b, // i32.load offset=8 align=1, drop
+	))...)...)
+	module = append(module, wasmSection(SectionData, wasmVec(
+		concat([]byte{0x01}, wasmName("\"quoted\"")),
+	)...)...)
+
+	got, err := NewDisassembler(module).WAT(Options{})
+	if err != nil {
+		t.Fatalf("WAT failed: %v", err)
+	}
+	for _, want := range []string{
+		"(table (;0;) 1 2 funcref)",
+		"(start 0)",
+		"(elem (;0;) (i32.const 1) func 0)",
+		"(elem (;1;) func 0)",
+		"elem.drop 1",
+		"f32.const nan\n",
+		"f64.const 1.5\n",
+		"i32.load offset=8 align=1\n",
+		`(data (;0;) "\"quoted\"")`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestWAT_InvalidModule(t *testing.T) {
+	if _, err := NewDisassembler([]byte{0x00, 0x61}).WAT(Options{}); err == nil {
+		t.Error("expected error for invalid module")
+	}
+
+	// A function body using an opcode the decoder does not know.
+	if _, err := NewDisassembler(buildMinimalWasm([]byte{0xfd, 0x00})).WAT(Options{}); err == nil {
+		t.Error("expected error for unsupported opcode")
+	}
+
+	// A body without its final end.
+	wasm := buildMinimalWasm([]byte{0x02, 0x40})
+	if _, err := NewDisassembler(wasm).WAT(Options{}); err == nil {
+		t.Error("expected error for unterminated block")
+	}
+}
+
+func TestDecodeOpcode_FromTable(t *testing.T) {
+	m, op, n := decodeOpcode(0x0e, []byte{0x02, 0x00, 0x01, 0x02})
+	if m != "br_table" || n != 4 {
+		t.Errorf("br_table: got %q %q %d", m, op, n)
+	}
+
+	m, _, _ = decodeOpcode(0x5a, nil)
+	if m != "i64.ge_u" {
+		t.Errorf("expected 'i64.ge_u', got %q", m)
+	}
+
+	m, op, n = decodeOpcode(0xfc, []byte{0x0a, 0x00, 0x00})
+	if m != "memory.copy" || op != "" || n != 3 {
+		t.Errorf("memory.copy: got %q %q %d", m, op, n)
+	}
+}
+
+func TestHostFunctionName(t *testing.T) {
+	tests := []struct {
+		module, field, want string
+	}{
+		{"a", "0", "require_auth"},
+		{"l", "_", "put_contract_data"},
+		{"d", "_", "call"},
+		{"x", "1", "contract_event"},
+	}
+	for _, tt := range tests {
+		got, ok := HostFunctionName(tt.module, tt.field)
+		if !ok || got != tt.want {
+			t.Errorf("HostFunctionName(%q, %q) = %q, %v; want %q", tt.module, tt.field, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := HostFunctionName("env", "abort"); ok {
+		t.Error("expected no host function for env.abort")
+	}
+}
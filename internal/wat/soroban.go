@@ -0,0 +1,153 @@
+// Copyright 2025 Erst Users
+// SPDX-License-Identifier: Apache-2.0
+
+package wat
+
+// hostFunctions maps the import modules and fields a Soroban contract uses
+// to call into the host to the names of the host functions, as listed in
+// the env.json interface of soroban-env-common. Contracts import host
+// functions under these short names to keep binaries small.
+var hostFunctions = map[string]map[string]string{
+	// context
+	"x": {
+		"_": "log_from_linear_memory",
+		"0": "obj_cmp",
+		"1": "contract_event",
+		"2": "get_ledger_version",
+		"3": "get_ledger_sequence",
+		"4": "get_ledger_timestamp",
+		"5": "fail_with_error",
+		"6": "get_ledger_network_id",
+		"7": "get_current_contract_address",
+		"8": "get_max_live_until_ledger",
+	},
+	// int
+	"i": {
+		"_": "obj_from_u64",
+		"0": "obj_to_u64",
+		"1": "obj_from_i64",
+		"2": "obj_to_i64",
+		"3": "obj_from_u128_pieces",
+		"4": "obj_to_u128_lo64",
+		"5": "obj_to_u128_hi64",
+		"6": "obj_from_i128_pieces",
+		"7": "obj_to_i128_lo64",
+		"8": "obj_to_i128_hi64",
+	},
+	// map
+	"m": {
+		"_": "map_new",
+		"0": "map_put",
+		"1": "map_get",
+		"2": "map_del",
+		"3": "map_len",
+		"4": "map_has",
+		"5": "map_key_by_pos",
+		"6": "map_val_by_pos",
+		"7": "map_keys",
+		"8": "map_values",
+		"9": "map_new_from_linear_memory",
+		"a": "map_unpack_to_linear_memory",
+	},
+	// vec
+	"v": {
+		"_": "vec_new",
+		"0": "vec_put",
+		"1": "vec_get",
+		"2": "vec_del",
+		"3": "vec_len",
+		"4": "vec_push_front",
+		"5": "vec_pop_front",
+		"6": "vec_push_back",
+		"7": "vec_pop_back",
+		"8": "vec_front",
+		"9": "vec_back",
+		"a": "vec_insert",
+		"b": "vec_append",
+		"c": "vec_slice",
+		"d": "vec_first_index_of",
+		"e": "vec_last_index_of",
+		"f": "vec_binary_search",
+		"g": "vec_new_from_linear_memory",
+		"h": "vec_unpack_to_linear_memory",
+	},
+	// ledger
+	"l": {
+		"_": "put_contract_data",
+		"0": "has_contract_data",
+		"1": "get_contract_data",
+		"2": "del_contract_data",
+		"3": "create_contract",
+		"4": "create_asset_contract",
+		"5": "upload_wasm",
+		"6": "update_current_contract_wasm",
+		"7": "extend_contract_data_ttl",
+		"8": "extend_current_contract_instance_and_code_ttl",
+		"9": "extend_contract_instance_ttl",
+		"a": "extend_contract_code_ttl",
+		"b": "get_contract_id",
+		"c": "get_asset_contract_id",
+	},
+	// call
+	"d": {
+		"_": "call",
+		"0": "try_call",
+	},
+	// buf
+	"b": {
+		"_": "serialize_to_bytes",
+		"0": "deserialize_from_bytes",
+		"1": "bytes_copy_to_linear_memory",
+		"2": "bytes_copy_from_linear_memory",
+		"3": "bytes_new_from_linear_memory",
+		"4": "bytes_new",
+		"5": "bytes_put",
+		"6": "bytes_get",
+		"7": "bytes_del",
+		"8": "bytes_len",
+		"9": "bytes_push",
+		"a": "bytes_pop",
+		"b": "bytes_front",
+		"c": "bytes_back",
+		"d": "bytes_insert",
+		"e": "bytes_append",
+		"f": "bytes_slice",
+		"g": "string_copy_to_linear_memory",
+		"h": "symbol_copy_to_linear_memory",
+		"i": "string_new_from_linear_memory",
+		"j": "symbol_new_from_linear_memory",
+		"k": "string_len",
+		"l": "symbol_len",
+		"m": "symbol_index_in_linear_memory",
+	},
+	// crypto
+	"c": {
+		"_": "compute_hash_sha256",
+		"0": "verify_sig_ed25519",
+		"1": "compute_hash_keccak256",
+		"2": "recover_key_ecdsa_secp256k1",
+		"3": "verify_sig_ecdsa_secp256r1",
+	},
+	// address
+	"a": {
+		"_": "require_auth_for_args",
+		"0": "require_auth",
+		"1": "strkey_to_address",
+		"2": "address_to_strkey",
+		"3": "authorize_as_curr_contract",
+	},
+	// prng
+	"p": {
+		"_": "prng_reseed",
+		"0": "prng_bytes_new",
+		"1": "prng_u64_in_inclusive_range",
+		"2": "prng_vec_shuffle",
+	},
+}
+
+// HostFunctionName returns the name of the Soroban host function a contract
+// imports as module.field, e.g. "require_auth" for "a" "0".
+func HostFunctionName(module, field string) (string, bool) {
+	name, ok := hostFunctions[module][field]
+	return name, ok
+}
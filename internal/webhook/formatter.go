@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dotandev/hintents/internal/decoder"
 	"github.com/dotandev/hintents/internal/simulator"
 )
 
@@ -23,6 +24,9 @@ type ReportData struct {
 	AuditLogURL      string
 	DiagnosticEvents []simulator.DiagnosticEvent
 	Logs             []string
+	// ContractError is the named variant behind a contract error code in
+	// Error, when the contract spec is known.
+	ContractError *decoder.ContractError
 }
 
 // SlackMessage represents Slack webhook payload
@@ -119,6 +123,16 @@ func FormatSlackMessage(report ReportData) SlackMessage {
 		blocks = append(blocks, errorBlock)
 	}
 
+	if report.ContractError != nil {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*Contract Error:*\n%s", truncateString(report.ContractError.Describe(), 500)),
+			},
+		})
+	}
+
 	// Add diagnostic events summary
 	if len(report.DiagnosticEvents) > 0 {
 		eventsText := formatSlackEventsText(report.DiagnosticEvents)
@@ -207,6 +221,14 @@ func FormatDiscordMessage(report ReportData) DiscordMessage {
 		})
 	}
 
+	if report.ContractError != nil {
+		fields = append(fields, DiscordEmbedField{
+			Name:   "Contract Error",
+			Value:  truncateString(report.ContractError.Describe(), 400),
+			Inline: false,
+		})
+	}
+
 	// Add diagnostic events summary
 	if len(report.DiagnosticEvents) > 0 {
 		eventsValue := formatDiscordEventsValue(report.DiagnosticEvents)
@@ -7,15 +7,17 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/dotandev/hintents/internal/decoder"
 	"github.com/dotandev/hintents/internal/logger"
 	"github.com/dotandev/hintents/internal/simulator"
 )
 
 // SimulatorNotifier handles notifications for CI session failures
 type SimulatorNotifier struct {
-	clients   []*Client
-	enabled   bool
-	errorOnly bool
+	clients        []*Client
+	enabled        bool
+	errorOnly      bool
+	contractErrors *decoder.ContractErrorResolver
 }
 
 // NotifierConfig contains configuration for the notifier
@@ -23,6 +25,11 @@ type NotifierConfig struct {
 	Enabled   bool
 	ErrorOnly bool
 	Webhooks  []Config
+
+	// ContractErrors, when set, names the contract error codes of reports
+	// using the contract specs it has cached and those found in the
+	// request's ledger entries.
+	ContractErrors *decoder.ContractErrorResolver
 }
 
 // NewSimulatorNotifier creates a notifier for simulator session events
@@ -52,9 +59,10 @@ func NewSimulatorNotifier(config NotifierConfig) (*SimulatorNotifier, error) {
 	}
 
 	return &SimulatorNotifier{
-		clients:   clients,
-		enabled:   true,
-		errorOnly: config.ErrorOnly,
+		clients:        clients,
+		enabled:        true,
+		errorOnly:      config.ErrorOnly,
+		contractErrors: config.ContractErrors,
 	}, nil
 }
 
@@ -123,9 +131,47 @@ func (sn *SimulatorNotifier) buildReportData(
 		Logs:             resp.Logs,
 	}
 
+	if sn.contractErrors != nil {
+		sn.resolveContractErrors(&report, req)
+	}
+
 	return report
 }
 
+// resolveContractErrors annotates the contract error codes of report with
+// their variant names and records the variant behind report.Error.
+func (sn *SimulatorNotifier) resolveContractErrors(report *ReportData, req *simulator.SimulationRequest) {
+	if req != nil {
+		sn.contractErrors.RegisterLedgerEntries(req.LedgerEntries)
+	}
+
+	// The last contract to emit an event is the one whose error ended the
+	// invocation.
+	var contract string
+	for i := len(report.DiagnosticEvents) - 1; i >= 0; i-- {
+		if id := report.DiagnosticEvents[i].ContractID; id != nil && *id != "" {
+			contract = *id
+			break
+		}
+	}
+
+	if e, ok := sn.contractErrors.ResolveText(contract, report.Error); ok {
+		report.ContractError = &e
+	}
+	report.Error = sn.contractErrors.Annotate(contract, report.Error)
+
+	events := make([]simulator.DiagnosticEvent, len(report.DiagnosticEvents))
+	for i, event := range report.DiagnosticEvents {
+		eventContract := contract
+		if event.ContractID != nil {
+			eventContract = *event.ContractID
+		}
+		event.Data = sn.contractErrors.Annotate(eventContract, event.Data)
+		events[i] = event
+	}
+	report.DiagnosticEvents = events
+}
+
 // notifyAll sends the report to all configured webhooks
 func (sn *SimulatorNotifier) notifyAll(report ReportData) {
 	if len(sn.clients) == 0 {
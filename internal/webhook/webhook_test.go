@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dotandev/hintents/internal/decoder"
 	"github.com/dotandev/hintents/internal/simulator"
 )
 
@@ -50,6 +52,34 @@ func TestSlackMessageFormatting(t *testing.T) {
 	}
 }
 
+func TestContractErrorFormatting(t *testing.T) {
+	report := ReportData{
+		TraceID:       "trace-789",
+		Status:        "error",
+		Error:         "HostError: Error(Contract, #7) [TokenError::Paused]",
+		ContractError: &decoder.ContractError{Code: 7, Enum: "TokenError", Name: "Paused", Doc: "Transfers are paused."},
+	}
+	want := "TokenError::Paused (#7): Transfers are paused."
+
+	slack, err := json.Marshal(FormatSlackMessage(report))
+	if err != nil {
+		t.Fatalf("Failed to marshal Slack message: %v", err)
+	}
+	if !strings.Contains(string(slack), want) {
+		t.Errorf("Slack message missing contract error: %s", slack)
+	}
+
+	found := false
+	for _, field := range FormatDiscordMessage(report).Embeds[0].Fields {
+		if field.Name == "Contract Error" && field.Value == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Discord message missing contract error field")
+	}
+}
+
 func TestDiscordMessageFormatting(t *testing.T) {
 	report := ReportData{
 		TraceID:     "trace-456",
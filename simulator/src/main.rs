@@ -21,7 +21,7 @@ use crate::source_mapper::SourceMapper;
 use crate::stack_trace::WasmStackTrace;
 use crate::types::*;
 use base64::Engine as _;
-use soroban_env_host::xdr::ReadXdr;
+use soroban_env_host::xdr::{ReadXdr, WriteXdr};
 use soroban_env_host::{
     xdr::{Operation, OperationBody},
     Host, HostError,
@@ -29,7 +29,8 @@ use soroban_env_host::{
 use std::collections::HashMap;
 use std::env;
 use std::fs;
-use std::io::{self, Read};
+use std::io::{self, Read, Write};
+use std::sync::atomic::{AtomicBool, Ordering};
 use tracing_subscriber::{fmt, EnvFilter};
 
 // Use types::SimulationRequest directly
@@ -77,14 +78,80 @@ fn send_error(msg: String) {
         stack_trace: Some(trace),
         wasm_offset: None,
         linear_memory_dump: None,
+        ledger_changes: Vec::new(),
     };
     if let Ok(json) = serde_json::to_string(&res) {
-        println!("{}", json);
+        emit_response(&json);
     } else {
         eprintln!("Failed to serialize error response");
-        println!("{{\"status\": \"error\", \"error\": \"Internal serialization error\"}}");
+        emit_response(INTERNAL_SERIALIZATION_ERROR);
+    }
+    // A worker answers the request and stays up for the next one.
+    if !WORKER_MODE.load(Ordering::SeqCst) {
+        std::process::exit(1);
+    }
+}
+
+/// Argument that starts erst-sim as a long-lived worker of the Go PoolRunner.
+const WORKER_MODE_ARG: &str = "--worker";
+
+/// Largest request frame a worker accepts, as ipc.MaxFrameSize.
+const MAX_FRAME_SIZE: usize = 10 * 1024 * 1024;
+
+const INTERNAL_SERIALIZATION_ERROR: &str =
+    "{\"status\": \"error\", \"error\": \"Internal serialization error\"}";
+
+/// Set when running in worker mode, where responses are written as frames.
+static WORKER_MODE: AtomicBool = AtomicBool::new(false);
+
+/// Writes the JSON response to a request: a line on stdout, or in worker mode
+/// a frame of its 4-byte big-endian length followed by the JSON.
+fn emit_response(json: &str) {
+    if !WORKER_MODE.load(Ordering::SeqCst) {
+        println!("{}", json);
+        return;
+    }
+    if let Err(e) = write_frame(&mut io::stdout().lock(), json.as_bytes()) {
+        eprintln!("Failed to write response frame: {e}");
+        std::process::exit(1);
+    }
+}
+
+fn write_frame(out: &mut impl Write, payload: &[u8]) -> io::Result<()> {
+    out.write_all(&(payload.len() as u32).to_be_bytes())?;
+    out.write_all(payload)?;
+    out.flush()
+}
+
+/// Serves requests as a worker of the Go PoolRunner: each request is a JSON
+/// SimulationRequest in a frame of its 4-byte big-endian length followed by the
+/// JSON, answered by one response frame, until stdin is closed.
+fn run_worker() {
+    WORKER_MODE.store(true, Ordering::SeqCst);
+    let mut input = io::stdin().lock();
+    loop {
+        let mut header = [0u8; 4];
+        if let Err(e) = input.read_exact(&mut header) {
+            if e.kind() != io::ErrorKind::UnexpectedEof {
+                eprintln!("Failed to read request frame: {e}");
+            }
+            return;
+        }
+        let size = u32::from_be_bytes(header) as usize;
+        if size > MAX_FRAME_SIZE {
+            eprintln!("Request frame of {size} bytes exceeds limit of {MAX_FRAME_SIZE} bytes");
+            return;
+        }
+        let mut payload = vec![0u8; size];
+        if let Err(e) = input.read_exact(&mut payload) {
+            eprintln!("Failed to read request frame: {e}");
+            return;
+        }
+        match String::from_utf8(payload) {
+            Ok(buffer) => handle_request(&buffer),
+            Err(e) => send_error(format!("Invalid UTF-8 in request: {e}")),
+        }
     }
-    std::process::exit(1);
 }
 
 #[derive(Default)]
@@ -287,6 +354,7 @@ fn categorize_events(events: &soroban_env_host::events::Events) -> Vec<Categoriz
             let data = match &e.event.body {
                 soroban_env_host::xdr::ContractEventBody::V0(v0) => format!("{:?}", v0.data),
             };
+            let (topics_xdr, data_xdr) = event_xdr(&e.event.body);
 
             let wasm_instruction = extract_wasm_instruction(&topics, &data);
             CategorizedEvent {
@@ -304,6 +372,8 @@ fn categorize_events(events: &soroban_env_host::events::Events) -> Vec<Categoriz
                     contract_id,
                     topics,
                     data,
+                    topics_xdr,
+                    data_xdr,
                     wasm_instruction,
                     // failed_call=true means the call that emitted this event
                     // actually failed; so a successful call is the inverse.
@@ -314,6 +384,58 @@ fn categorize_events(events: &soroban_env_host::events::Events) -> Vec<Categoriz
         .collect()
 }
 
+/// Encodes the topics and data of an event as base64 XDR so that erst can
+/// render them using the emitting contract's spec. The topics are dropped
+/// together if any of them cannot be encoded.
+fn event_xdr(body: &soroban_env_host::xdr::ContractEventBody) -> (Vec<String>, Option<String>) {
+    let soroban_env_host::xdr::ContractEventBody::V0(body) = body;
+    let encode = |value: &soroban_env_host::xdr::ScVal| {
+        value
+            .to_xdr(soroban_env_host::xdr::Limits::none())
+            .ok()
+            .map(|bytes| base64::engine::general_purpose::STANDARD.encode(bytes))
+    };
+    let topics = body
+        .topics
+        .iter()
+        .map(encode)
+        .collect::<Option<Vec<String>>>()
+        .unwrap_or_default();
+    (topics, encode(&body.data))
+}
+
+/// Returns the ledger entries the host wrote, as base64 XDR. Entries whose
+/// value matches the one the request supplied were only read and are skipped.
+fn ledger_changes(host: &Host, before: Option<&HashMap<String, String>>) -> Vec<LedgerChange> {
+    let budget = host.budget_cloned();
+    let encode = |bytes: Vec<u8>| base64::engine::general_purpose::STANDARD.encode(bytes);
+    let changes = host.with_mut_storage(|storage| {
+        let mut changes = Vec::new();
+        for (key, value) in storage.map.iter(&budget)? {
+            let Ok(key_bytes) = key.to_xdr(soroban_env_host::xdr::Limits::none()) else {
+                continue;
+            };
+            let key = encode(key_bytes);
+            let entry = match value {
+                Some((entry, _)) => match entry.to_xdr(soroban_env_host::xdr::Limits::none()) {
+                    Ok(bytes) => Some(encode(bytes)),
+                    Err(_) => continue,
+                },
+                None => None,
+            };
+            if before.and_then(|entries| entries.get(&key)) == entry.as_ref() {
+                continue;
+            }
+            changes.push(LedgerChange { key, entry });
+        }
+        Ok(changes)
+    });
+    changes.unwrap_or_else(|e| {
+        eprintln!("Failed to read ledger changes: {:?}", e);
+        Vec::new()
+    })
+}
+
 fn extract_wasm_instruction(topics: &[String], data: &str) -> Option<String> {
     if !topics
         .iter()
@@ -348,6 +470,11 @@ fn main() {
     // 2. Log that we started
     tracing::info!(event = "simulator_started", "Simulator initializing...");
 
+    if env::args().any(|arg| arg == WORKER_MODE_ARG) {
+        run_worker();
+        return;
+    }
+
     // Read JSON from Stdin
     let mut buffer = String::new();
     if let Err(e) = io::stdin().read_to_string(&mut buffer) {
@@ -368,6 +495,7 @@ fn main() {
             stack_trace: None,
             wasm_offset: None,
             linear_memory_dump: None,
+            ledger_changes: Vec::new(),
         };
         if let Ok(json) = serde_json::to_string(&res) {
             println!("{}", json);
@@ -379,8 +507,13 @@ fn main() {
         return;
     }
 
+    handle_request(&buffer);
+}
+
+/// Runs one simulation request and writes its response.
+fn handle_request(buffer: &str) {
     // Parse Request
-    let request: SimulationRequest = match serde_json::from_str(&buffer) {
+    let request: SimulationRequest = match serde_json::from_str(buffer) {
         Ok(req) => req,
         Err(e) => {
             let res = SimulationResponse {
@@ -400,10 +533,10 @@ fn main() {
                 stack_trace: None,
                 wasm_offset: None,
                 linear_memory_dump: None,
+                ledger_changes: Vec::new(),
             };
-            println!(
-                "{}",
-                serde_json::to_string(&res).expect("Failed to serialize error response")
+            emit_response(
+                &serde_json::to_string(&res).expect("Failed to serialize error response"),
             );
             return;
         }
@@ -508,7 +641,7 @@ fn main() {
                 // We still validate local WASM readability here.
                 eprintln!("Successfully loaded local WASM from path");
             }
-            Err(e) => send_error(format!("Local WASM loading failed: {}", e)),
+            Err(e) => return send_error(format!("Local WASM loading failed: {}", e)),
         }
     }
     // --- END: Local WASM Loading Integration ---
@@ -689,6 +822,7 @@ fn main() {
                                         (topics, data)
                                     }
                                 };
+                                let (topics_xdr, data_xdr) = event_xdr(&event.event.body);
 
                                 let wasm_instruction = extract_wasm_instruction(&topics, &data);
                                 DiagnosticEvent {
@@ -696,6 +830,8 @@ fn main() {
                                     contract_id,
                                     topics,
                                     data,
+                                    topics_xdr,
+                                    data_xdr,
                                     wasm_instruction,
                                     in_successful_contract_call: !event.failed_call,
                                 }
@@ -757,13 +893,14 @@ fn main() {
                         stack_trace: None,
                         wasm_offset: None,
                         linear_memory_dump: None,
+                        ledger_changes: Vec::new(),
                     };
 
                     if let Ok(json) = serde_json::to_string(&response) {
-                        println!("{}", json);
+                        emit_response(&json);
                     } else {
                         eprintln!("Failed to serialize simulation response");
-                        println!("{{\"status\": \"error\", \"error\": \"Internal serialization error\"}}");
+                        emit_response(INTERNAL_SERIALIZATION_ERROR);
                     }
                     return;
                 }
@@ -790,13 +927,14 @@ fn main() {
                     .as_ref()
                     .and_then(|m: &SourceMapper| m.map_wasm_offset_to_source(0)),
                 linear_memory_dump: None,
+                ledger_changes: ledger_changes(&host, request.ledger_entries.as_ref()),
             };
 
             if let Ok(json) = serde_json::to_string(&response) {
-                println!("{}", json);
+                emit_response(&json);
             } else {
                 eprintln!("Failed to serialize simulation response");
-                println!("{{\"status\": \"error\", \"error\": \"Internal serialization error\"}}");
+                emit_response(INTERNAL_SERIALIZATION_ERROR);
             }
         }
         Ok(Err(host_error)) => {
@@ -842,6 +980,7 @@ fn main() {
                                     (topics, data)
                                 }
                             };
+                            let (topics_xdr, data_xdr) = event_xdr(&event.event.body);
 
                             let wasm_instruction = extract_wasm_instruction(&topics, &data);
                             DiagnosticEvent {
@@ -849,6 +988,8 @@ fn main() {
                                 contract_id,
                                 topics,
                                 data,
+                                topics_xdr,
+                                data_xdr,
                                 wasm_instruction,
                                 in_successful_contract_call: !event.failed_call,
                             }
@@ -948,12 +1089,13 @@ fn main() {
                 stack_trace: Some(wasm_trace),
                 wasm_offset,
                 linear_memory_dump: None,
+                ledger_changes: Vec::new(),
             };
             if let Ok(json) = serde_json::to_string(&response) {
-                println!("{}", json);
+                emit_response(&json);
             } else {
                 eprintln!("Failed to serialize host error response");
-                println!("{{\"status\": \"error\", \"error\": \"Internal serialization error\"}}");
+                emit_response(INTERNAL_SERIALIZATION_ERROR);
             }
         }
         Err(panic_info) => {
@@ -993,12 +1135,13 @@ fn main() {
                 stack_trace: Some(wasm_trace),
                 wasm_offset: None,
                 linear_memory_dump: None,
+                ledger_changes: Vec::new(),
             };
             if let Ok(json) = serde_json::to_string(&response) {
-                println!("{}", json);
+                emit_response(&json);
             } else {
                 eprintln!("Failed to serialize panic response");
-                println!("{{\"status\": \"error\", \"error\": \"Internal serialization error\"}}");
+                emit_response(INTERNAL_SERIALIZATION_ERROR);
             }
         }
     }
@@ -1397,4 +1540,11 @@ mod tests {
         assert!(report.contains("FNF:2"));
         assert!(report.contains("FNH:2"));
     }
+
+    #[test]
+    fn test_write_frame_prefixes_big_endian_length() {
+        let mut out = Vec::new();
+        write_frame(&mut out, b"{}").unwrap();
+        assert_eq!(out, vec![0, 0, 0, 2, b'{', b'}']);
+    }
 }
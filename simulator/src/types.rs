@@ -72,6 +72,17 @@ pub struct SimulationResponse {
     pub wasm_offset: Option<u64>,
     #[serde(skip_serializing_if = "Option::is_none")]
     pub linear_memory_dump: Option<String>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub ledger_changes: Vec<LedgerChange>,
+}
+
+/// A ledger entry written by the simulated transaction. `entry` is `None`
+/// when the entry was deleted.
+#[derive(Debug, Serialize)]
+pub struct LedgerChange {
+    pub key: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub entry: Option<String>,
 }
 
 #[derive(Debug, Serialize)]
@@ -80,6 +91,12 @@ pub struct DiagnosticEvent {
     pub contract_id: Option<String>,
     pub topics: Vec<String>,
     pub data: String,
+    /// Base64 XDR of each topic, for spec-aware rendering.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub topics_xdr: Vec<String>,
+    /// Base64 XDR of the data, for spec-aware rendering.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub data_xdr: Option<String>,
     pub in_successful_contract_call: bool,
     #[serde(skip_serializing_if = "Option::is_none")]
     pub wasm_instruction: Option<String>,